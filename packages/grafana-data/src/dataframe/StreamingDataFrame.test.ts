@@ -532,6 +532,67 @@ describe('Streaming JSON', () => {
     });
   });
 
+  describe('when the frame replaces overlapping rows', () => {
+    it('should replace the rows from the first time of the packet on', () => {
+      const stream = StreamingDataFrame.fromDataFrameJSON({
+        schema: {
+          fields: [
+            { name: 'time', type: FieldType.time },
+            { name: 'value', type: FieldType.number },
+          ],
+          meta: { custom: { streamReplaceOverlap: true } },
+        },
+        data: {
+          values: [
+            [100, 200, 300],
+            [1, 2, 3],
+          ],
+        },
+      });
+
+      stream.push({
+        data: {
+          values: [
+            [300, 400],
+            [30, 4],
+          ],
+        },
+      });
+
+      expect(stream.fields.map((f) => f.values)).toEqual([
+        [100, 200, 300, 400],
+        [1, 2, 30, 4],
+      ]);
+      expect(stream.length).toEqual(4);
+    });
+
+    it('should append rows without overlap', () => {
+      const stream = StreamingDataFrame.fromDataFrameJSON({
+        schema: {
+          fields: [
+            { name: 'time', type: FieldType.time },
+            { name: 'value', type: FieldType.number },
+          ],
+          meta: { custom: { streamReplaceOverlap: true } },
+        },
+        data: {
+          values: [[100], [1]],
+        },
+      });
+
+      stream.push({
+        data: {
+          values: [[200], [2]],
+        },
+      });
+
+      expect(stream.fields.map((f) => f.values)).toEqual([
+        [100, 200],
+        [1, 2],
+      ]);
+    });
+  });
+
   describe('when deserialized', function () {
     const json: DataFrameJSON = {
       schema: {
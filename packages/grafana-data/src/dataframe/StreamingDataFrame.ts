@@ -48,6 +48,12 @@ export interface StreamPacketInfo {
 
 const PROM_STYLE_METRIC_LABEL = '__name__';
 
+/**
+ * Frames with this custom meta property are time series that resend their last rows: the rows of
+ * a packet replace the rows of the frame from the first time of the packet on.
+ */
+const REPLACE_OVERLAP_META = 'streamReplaceOverlap';
+
 enum PushMode {
   wide,
   labels,
@@ -353,6 +359,9 @@ export class StreamingDataFrame implements DataFrame {
 
         // mutates appended
         appended = this.fields.map((f) => f.values);
+        if (this.meta?.custom?.[REPLACE_OVERLAP_META]) {
+          truncateOverlap(appended, values, this.timeFieldIndex);
+        }
         circPush(appended, values, this.options.maxLength, this.timeFieldIndex, this.options.maxDelta);
       }
 
@@ -557,6 +566,26 @@ export function getLastStreamingDataFramePacket(frame: DataFrame) {
   return pi?.action ? pi : undefined;
 }
 
+// mutable removal of the rows at or after the first time of newData
+function truncateOverlap(data: unknown[][], newData: unknown[][], timeIdx: number) {
+  if (timeIdx < 0 || !newData[timeIdx]?.length) {
+    return;
+  }
+
+  const times = data[timeIdx] as number[];
+  const start = (newData[timeIdx] as number[])[0];
+  let idx = times.length;
+  while (idx > 0 && times[idx - 1] >= start) {
+    idx--;
+  }
+
+  if (idx < times.length) {
+    for (let i = 0; i < data.length; i++) {
+      data[i].splice(idx);
+    }
+  }
+}
+
 // mutable circular push
 function circPush(data: unknown[][], newData: unknown[][], maxLength = Infinity, deltaIdx = 0, maxDelta = Infinity) {
   for (let i = 0; i < data.length; i++) {
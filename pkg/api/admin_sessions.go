@@ -0,0 +1,183 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/services/auth"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /admin/sessions admin_users adminSearchSessions
+//
+// Search the sessions of all users.
+//
+// Returns the active sessions (devices) of all the users, filtered by user, client IP and user agent.
+// You need to have a permission with action `users.authtoken:read` and scope `global.users:*`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: adminSearchSessionsResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) AdminSearchSessions(c *contextmodel.ReqContext) response.Response {
+	query := sessionsQueryFromRequest(c)
+	query.Page = c.QueryInt("page")
+	query.Limit = c.QueryInt("perpage")
+
+	result, err := hs.AuthTokenService.SearchSessions(c.Req.Context(), &query)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to search sessions", err)
+	}
+
+	dto := dtos.SearchSessionsResult{
+		TotalCount: result.TotalCount,
+		Sessions:   make([]*dtos.UserSession, 0, len(result.Sessions)),
+		Page:       result.Page,
+		PerPage:    result.PerPage,
+	}
+	for _, session := range result.Sessions {
+		token := toUserTokenDTO(session.UserToken)
+		token.IsActive = c.UserToken != nil && c.UserToken.Id == session.Id
+		dto.Sessions = append(dto.Sessions, &dtos.UserSession{
+			UserToken: *token,
+			UserId:    session.UserId,
+			Login:     session.Login,
+			Email:     session.Email,
+			Name:      session.Name,
+		})
+	}
+
+	return response.JSON(http.StatusOK, dto)
+}
+
+// swagger:route POST /admin/sessions/revoke admin_users adminRevokeSessions
+//
+// Revoke the sessions of all users matching the filters.
+//
+// Revokes the active sessions (devices) matching the same filters as the search, e.g. during incident response.
+// The session of the caller is never revoked. At least one filter must be provided.
+// You need to have a permission with action `users.authtoken:write` and scope `global.users:*`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: adminRevokeSessionsResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) AdminRevokeSessions(c *contextmodel.ReqContext) response.Response {
+	form := dtos.RevokeSessionsForm{}
+	if err := web.Bind(c.Req, &form); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	query := auth.SearchSessionsQuery{
+		UserID:    form.UserID,
+		Query:     form.Query,
+		ClientIP:  form.ClientIP,
+		UserAgent: form.UserAgent,
+	}
+	if form.SeenBefore != nil {
+		query.SeenBefore = form.SeenBefore.Unix()
+	}
+	if form.CreatedAfter != nil {
+		query.CreatedAfter = form.CreatedAfter.Unix()
+	}
+	if query == (auth.SearchSessionsQuery{}) && !form.All {
+		return response.Error(http.StatusBadRequest, "At least one filter is required, set all to revoke every session", nil)
+	}
+
+	cmd := auth.RevokeSessionsCmd{Query: query}
+	if c.UserToken != nil {
+		cmd.ExceptTokenID = c.UserToken.Id
+	}
+
+	revoked, err := hs.AuthTokenService.RevokeSessions(c.Req.Context(), &cmd)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to revoke sessions", err)
+	}
+
+	c.Logger.Info("Sessions revoked", "by", c.SignedInUser.GetLogin(), "count", revoked)
+	return response.JSON(http.StatusOK, dtos.RevokeSessionsResult{
+		Message: "Sessions revoked",
+		Revoked: revoked,
+	})
+}
+
+func sessionsQueryFromRequest(c *contextmodel.ReqContext) auth.SearchSessionsQuery {
+	query := auth.SearchSessionsQuery{
+		UserID:    c.QueryInt64("userId"),
+		Query:     c.Query("query"),
+		ClientIP:  c.Query("clientIp"),
+		UserAgent: c.Query("userAgent"),
+	}
+	if seenBefore, err := time.Parse(time.RFC3339, c.Query("seenBefore")); err == nil {
+		query.SeenBefore = seenBefore.Unix()
+	}
+	if createdAfter, err := time.Parse(time.RFC3339, c.Query("createdAfter")); err == nil {
+		query.CreatedAfter = createdAfter.Unix()
+	}
+	return query
+}
+
+// swagger:parameters adminSearchSessions
+type AdminSearchSessionsParams struct {
+	// in:query
+	// required:false
+	UserID int64 `json:"userId"`
+	// Matches the login, email or name of the user.
+	// in:query
+	// required:false
+	Query string `json:"query"`
+	// Prefix of the client IP address.
+	// in:query
+	// required:false
+	ClientIP string `json:"clientIp"`
+	// in:query
+	// required:false
+	UserAgent string `json:"userAgent"`
+	// Sessions that have not been used since, in RFC 3339 format.
+	// in:query
+	// required:false
+	SeenBefore string `json:"seenBefore"`
+	// in:query
+	// required:false
+	CreatedAfter string `json:"createdAfter"`
+	// in:query
+	// required:false
+	// default:1
+	Page int `json:"page"`
+	// in:query
+	// required:false
+	// default:100
+	// maximum:1000
+	PerPage int `json:"perpage"`
+}
+
+// swagger:parameters adminRevokeSessions
+type AdminRevokeSessionsParams struct {
+	// in:body
+	// required:true
+	Body dtos.RevokeSessionsForm `json:"body"`
+}
+
+// swagger:response adminSearchSessionsResponse
+type AdminSearchSessionsResponse struct {
+	// in:body
+	Body dtos.SearchSessionsResult `json:"body"`
+}
+
+// swagger:response adminRevokeSessionsResponse
+type AdminRevokeSessionsResponse struct {
+	// in:body
+	Body dtos.RevokeSessionsResult `json:"body"`
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/auth/authtest"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+func TestAPI_AdminSearchSessions(t *testing.T) {
+	var query *auth.SearchSessionsQuery
+	tokenService := authtest.NewFakeUserAuthTokenService()
+	tokenService.SearchSessionsProvider = func(ctx context.Context, q *auth.SearchSessionsQuery) (*auth.SearchSessionsResult, error) {
+		query = q
+		return &auth.SearchSessionsResult{
+			TotalCount: 1,
+			Sessions: []*auth.UserSession{
+				{UserToken: &auth.UserToken{Id: 2, UserId: 3, ClientIp: "10.0.0.1", UserAgent: "curl/8.0"}, Login: "alice"},
+			},
+			Page:    1,
+			PerPage: 100,
+		}, nil
+	}
+
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.Cfg = setting.NewCfg()
+		hs.AuthTokenService = tokenService
+	})
+
+	t.Run("should fail without permission", func(t *testing.T) {
+		res, err := server.Send(webtest.RequestWithSignedInUser(server.NewGetRequest("/api/admin/sessions"), userWithPermissions(1, nil)))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		require.NoError(t, res.Body.Close())
+	})
+
+	t.Run("should list sessions matching the filters", func(t *testing.T) {
+		permissions := []accesscontrol.Permission{{Action: accesscontrol.ActionUsersAuthTokenList, Scope: accesscontrol.ScopeGlobalUsersAll}}
+		res, err := server.Send(webtest.RequestWithSignedInUser(server.NewGetRequest("/api/admin/sessions?query=alice&clientIp=10.0.&perpage=10"), userWithPermissions(1, permissions)))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var result dtos.SearchSessionsResult
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+		require.NoError(t, res.Body.Close())
+
+		assert.Equal(t, "alice", query.Query)
+		assert.Equal(t, "10.0.", query.ClientIP)
+		assert.Equal(t, 10, query.Limit)
+		require.Len(t, result.Sessions, 1)
+		assert.Equal(t, "alice", result.Sessions[0].Login)
+		assert.Equal(t, int64(3), result.Sessions[0].UserId)
+		assert.Equal(t, "10.0.0.1", result.Sessions[0].ClientIp)
+	})
+}
+
+func TestAPI_AdminRevokeSessions(t *testing.T) {
+	var cmd *auth.RevokeSessionsCmd
+	tokenService := authtest.NewFakeUserAuthTokenService()
+	tokenService.RevokeSessionsProvider = func(ctx context.Context, c *auth.RevokeSessionsCmd) (int64, error) {
+		cmd = c
+		return 4, nil
+	}
+
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.Cfg = setting.NewCfg()
+		hs.AuthTokenService = tokenService
+	})
+	permissions := []accesscontrol.Permission{{Action: accesscontrol.ActionUsersAuthTokenUpdate, Scope: accesscontrol.ScopeGlobalUsersAll}}
+	newRequest := func(body string) *http.Request {
+		req := server.NewPostRequest("/api/admin/sessions/revoke", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return webtest.RequestWithSignedInUser(req, userWithPermissions(1, permissions))
+	}
+
+	t.Run("should require a filter", func(t *testing.T) {
+		res, err := server.Send(newRequest(`{}`))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		require.NoError(t, res.Body.Close())
+		assert.Nil(t, cmd)
+	})
+
+	t.Run("should revoke the sessions matching the filters", func(t *testing.T) {
+		res, err := server.Send(newRequest(`{"userAgent":"curl"}`))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var result dtos.RevokeSessionsResult
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+		require.NoError(t, res.Body.Close())
+
+		assert.Equal(t, int64(4), result.Revoked)
+		require.NotNil(t, cmd)
+		assert.Equal(t, "curl", cmd.Query.UserAgent)
+	})
+}
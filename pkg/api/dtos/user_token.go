@@ -14,3 +14,34 @@ type UserToken struct {
 	CreatedAt              time.Time `json:"createdAt"`
 	SeenAt                 time.Time `json:"seenAt"`
 }
+
+type UserSession struct {
+	UserToken
+	UserId int64  `json:"userId"`
+	Login  string `json:"login"`
+	Email  string `json:"email"`
+	Name   string `json:"name"`
+}
+
+type SearchSessionsResult struct {
+	TotalCount int64          `json:"totalCount"`
+	Sessions   []*UserSession `json:"sessions"`
+	Page       int            `json:"page"`
+	PerPage    int            `json:"perPage"`
+}
+
+type RevokeSessionsResult struct {
+	Message string `json:"message"`
+	Revoked int64  `json:"revoked"`
+}
+
+type RevokeSessionsForm struct {
+	UserID       int64      `json:"userId"`
+	Query        string     `json:"query"`
+	ClientIP     string     `json:"clientIp"`
+	UserAgent    string     `json:"userAgent"`
+	SeenBefore   *time.Time `json:"seenBefore"`
+	CreatedAfter *time.Time `json:"createdAfter"`
+	// All must be set to revoke the sessions without any filter.
+	All bool `json:"all"`
+}
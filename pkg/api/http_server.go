@@ -211,6 +211,7 @@ type HTTPServer struct {
 	oauthTokenService    oauthtoken.OAuthTokenService
 	statsService         stats.Service
 	authnService         authn.Service
+	samlClient           authn.SAMLClient
 	starApi              *starApi.API
 	promRegister         prometheus.Registerer
 	promGatherer         prometheus.Gatherer
@@ -259,6 +260,7 @@ func ProvideHTTPServer(opts ServerOptions, cfg *setting.Cfg, routeRegister routi
 	annotationRepo annotations.Repository, tagService tag.Service, searchv2HTTPService searchV2.SearchHTTPService, oauthTokenService oauthtoken.OAuthTokenService,
 	statsService stats.Service, authnService authn.Service, pluginsCDNService *pluginscdn.Service, promGatherer prometheus.Gatherer,
 	starApi *starApi.API, promRegister prometheus.Registerer, clientConfigProvider grafanaapiserver.DirectRestConfigProvider, anonService anonymous.Service,
+	samlClient authn.SAMLClient,
 ) (*HTTPServer, error) {
 	web.Env = cfg.Env
 	m := web.New()
@@ -354,6 +356,7 @@ func ProvideHTTPServer(opts ServerOptions, cfg *setting.Cfg, routeRegister routi
 		oauthTokenService:            oauthTokenService,
 		statsService:                 statsService,
 		authnService:                 authnService,
+		samlClient:                   samlClient,
 		pluginsCDNService:            pluginsCDNService,
 		starApi:                      starApi,
 		promRegister:                 promRegister,
@@ -367,6 +370,12 @@ func ProvideHTTPServer(opts ServerOptions, cfg *setting.Cfg, routeRegister routi
 	}
 	hs.registerRoutes()
 
+	// the identity provider posts the SAML messages from its own origin
+	if samlClient != nil && !licensing.FeatureEnabled("saml") {
+		hs.Csrf.AddSafeEndpoint("/saml/acs")
+		hs.Csrf.AddSafeEndpoint("/saml/slo")
+	}
+
 	// Register access control scope resolver for annotations
 	hs.AccessControl.RegisterScopeAttributeResolver(AnnotationTypeScopeResolver(hs.annotationsRepo, features, dashboardService, folderService))
 
@@ -698,6 +707,10 @@ func (hs *HTTPServer) addMiddlewaresAndStaticRoutes() {
 
 	m.Use(middleware.HandleNoCacheHeaders)
 
+	if hs.Cfg.RateLimiting.Enabled {
+		m.Use(middleware.RateLimit(hs.Cfg, hs.RemoteCacheService))
+	}
+
 	if hs.Cfg.CSPEnabled || hs.Cfg.CSPReportOnlyEnabled {
 		m.UseMiddleware(middleware.ContentSecurityPolicy(hs.Cfg, hs.log))
 	}
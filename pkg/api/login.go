@@ -241,7 +241,7 @@ func (hs *HTTPServer) loginUserWithUser(user *user.User, c *contextmodel.ReqCont
 }
 
 func (hs *HTTPServer) Logout(c *contextmodel.ReqContext) {
-	// FIXME: restructure saml client to implement authn.LogoutClient
+	// FIXME: restructure enterprise saml client to implement authn.LogoutClient
 	if hs.samlSingleLogoutEnabled() {
 		id, err := identity.UserIdentifier(c.SignedInUser.GetNamespacedID())
 		if err != nil {
@@ -335,6 +335,10 @@ func (hs *HTTPServer) redirectURLWithErrorCookie(c *contextmodel.ReqContext, err
 }
 
 func (hs *HTTPServer) samlEnabled() bool {
+	return hs.enterpriseSAMLEnabled() || (hs.samlClient != nil && hs.samlClient.IsEnabled())
+}
+
+func (hs *HTTPServer) enterpriseSAMLEnabled() bool {
 	return hs.SettingsProvider.KeyValue("auth.saml", "enabled").MustBool(false) && hs.License.FeatureEnabled("saml")
 }
 
@@ -343,7 +347,8 @@ func (hs *HTTPServer) samlName() string {
 }
 
 func (hs *HTTPServer) samlSingleLogoutEnabled() bool {
-	return hs.samlEnabled() && hs.SettingsProvider.KeyValue("auth.saml", "single_logout").MustBool(false) && hs.samlEnabled()
+	// the SAML client of the authn service handles the single logout as a logout client
+	return hs.enterpriseSAMLEnabled() && hs.SettingsProvider.KeyValue("auth.saml", "single_logout").MustBool(false)
 }
 
 func (hs *HTTPServer) samlAutoLoginEnabled() bool {
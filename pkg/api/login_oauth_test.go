@@ -120,6 +120,32 @@ func TestOAuthLogin_Redirect(t *testing.T) {
 	}
 }
 
+func TestOAuthLogin_SAMLStateCookie(t *testing.T) {
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.Cfg = setting.NewCfg()
+		hs.SecretsService = fakes.NewFakeSecretsService()
+		hs.authnService = &authntest.FakeService{
+			ExpectedRedirect: &authn.Redirect{
+				URL:   "https://idp.example.com/sso",
+				Extra: map[string]string{authn.KeyOAuthState: "some-state"},
+			},
+		}
+	})
+	setClientWithoutRedirectFollow(t)
+
+	res, err := server.Send(server.NewGetRequest("/login/saml"))
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+
+	require.Len(t, res.Cookies(), 1)
+	stateCookie := res.Cookies()[0]
+	assert.Equal(t, OauthStateCookieName, stateCookie.Name)
+	assert.Equal(t, "some-state", stateCookie.Value)
+	// the identity provider posts the response from another site
+	assert.Equal(t, http.SameSiteNoneMode, stateCookie.SameSite)
+	assert.True(t, stateCookie.Secure)
+}
+
 func TestOAuthLogin_AuthorizationCode(t *testing.T) {
 	type testCase struct {
 		desc             string
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/middleware/cookies"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/authn"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	loginservice "github.com/grafana/grafana/pkg/services/login"
+)
+
+// SAMLMetadata returns the metadata of the Grafana SAML service provider,
+// to be registered in the identity provider.
+func (hs *HTTPServer) SAMLMetadata(c *contextmodel.ReqContext) response.Response {
+	data, err := hs.samlClient.Metadata(c.Req.Context())
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get SAML metadata", err)
+	}
+
+	return response.Respond(http.StatusOK, data).SetHeader("Content-Type", "application/xml")
+}
+
+// SAMLACS is the assertion consumer service, it logs the user in with the
+// response posted by the identity provider.
+func (hs *HTTPServer) SAMLACS(c *contextmodel.ReqContext) {
+	req := &authn.Request{HTTPRequest: c.Req, Resp: c.Resp}
+	identity, err := hs.authnService.Login(c.Req.Context(), authn.ClientSAML, req)
+	// NOTE: always delete the state cookie, even if login failed
+	cookies.DeleteCookie(c.Resp, OauthStateCookieName, hs.samlStateCookieOptions)
+
+	if err != nil {
+		c.Redirect(hs.redirectURLWithErrorCookie(c, err))
+		return
+	}
+
+	metrics.MApiLoginSAML.Inc()
+	authn.HandleLoginRedirect(c.Req, c.Resp, hs.Cfg, identity, hs.ValidateRedirectTo)
+}
+
+// samlStateCookieOptions returns the options of the state cookie of SAML logins. The identity provider
+// posts the response from its own site, so the cookie is only sent with it when its SameSite mode is
+// None, which browsers only accept for secure cookies.
+func (hs *HTTPServer) samlStateCookieOptions() cookies.CookieOptions {
+	options := hs.CookieOptionsFromCfg()
+	options.Secure = true
+	options.SameSiteDisabled = false
+	options.SameSiteMode = http.SameSiteNoneMode
+	return options
+}
+
+// SAMLSLO is the single logout service. It receives the response to the logout
+// requests sent by Grafana, and the logout requests sent by the identity provider.
+func (hs *HTTPServer) SAMLSLO(c *contextmodel.ReqContext) {
+	logout, err := hs.samlClient.SingleLogout(c.Req.Context(), c.Req)
+	if err != nil {
+		hs.redirectWithError(c, err)
+		return
+	}
+
+	if logout.NameID != "" && c.IsSignedIn && c.UserToken != nil {
+		hs.logoutSAMLSession(c, logout.NameID)
+	}
+
+	c.Redirect(logout.Redirect.URL)
+}
+
+// logoutSAMLSession revokes the session of the request when it belongs to the
+// user the identity provider requested to log out.
+func (hs *HTTPServer) logoutSAMLSession(c *contextmodel.ReqContext, nameID string) {
+	userID, err := identity.UserIdentifier(c.SignedInUser.GetNamespacedID())
+	if err != nil || userID == 0 {
+		return
+	}
+
+	authInfo, err := hs.authInfoService.GetAuthInfo(c.Req.Context(), &loginservice.GetAuthInfoQuery{UserId: userID})
+	if err != nil || authInfo.AuthModule != loginservice.SAMLAuthModule || authInfo.AuthId != nameID {
+		c.Logger.Debug("Session does not belong to the SAML user to log out", "userID", userID)
+		return
+	}
+
+	if err := hs.AuthTokenService.RevokeToken(c.Req.Context(), c.UserToken, false); err != nil {
+		c.Logger.Error("Failed to revoke session on SAML single logout", "userID", userID, "error", err)
+		return
+	}
+	authn.DeleteSessionCookie(c.Resp, hs.Cfg)
+	c.Logger.Info("Successful SAML single logout", "userID", userID)
+}
@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/services/ngalert/api"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/prom"
+)
+
+func convertPrometheusRulesCommand(c utils.CommandLine) error {
+	path := c.Args().First()
+	if path == "" {
+		return fmt.Errorf("missing path of the Prometheus rule file")
+	}
+	output := c.String("output")
+	dryRun := c.Bool("dry-run")
+	if output == "" && !dryRun {
+		return fmt.Errorf("missing path of the output file, specify --output or --dry-run")
+	}
+	interval, err := model.ParseDuration(c.String("interval"))
+	if err != nil {
+		return fmt.Errorf("invalid interval: %w", err)
+	}
+
+	// #nosec G304 - the path is provided by the operator
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read rule file: %w", err)
+	}
+	file, err := prom.ParseRuleFile(content)
+	if err != nil {
+		return err
+	}
+
+	folder := c.String("folder")
+	converter, err := prom.NewConverter(prom.Config{
+		OrgID:           1,
+		DatasourceUID:   c.String("datasource-uid"),
+		DefaultInterval: time.Duration(interval),
+	})
+	if err != nil {
+		return err
+	}
+	groups, issues := converter.Convert(file.Groups)
+
+	for _, issue := range issues {
+		logger.Warnf("%s\n", formatConversionIssue(issue))
+	}
+
+	exportGroups := make([]ngmodels.AlertRuleGroupWithFolderTitle, 0, len(groups))
+	rules := 0
+	for _, group := range groups {
+		for i := range group.Rules {
+			// provisioned rules must have a UID. It is derived from the rule so that converting the file again
+			// updates the provisioned rules instead of creating new ones.
+			group.Rules[i].UID = convertedRuleUID(folder, group.Name, group.Rules[i].Title)
+		}
+		rules += len(group.Rules)
+		key := ngmodels.AlertRuleGroupKey{OrgID: 1, RuleGroup: group.Name}
+		exportGroups = append(exportGroups, ngmodels.NewAlertRuleGroupWithFolderTitle(key, group.Rules, folder))
+	}
+
+	logger.Infof("\n")
+	if dryRun {
+		logger.Infof("%d rules in %d groups can be converted, %d issues found\n", rules, len(groups), len(issues))
+		return nil
+	}
+
+	export, err := api.AlertingFileExportFromAlertRuleGroupWithFolderTitle(exportGroups)
+	if err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(export)
+	if err != nil {
+		return fmt.Errorf("failed to marshal provisioning file: %w", err)
+	}
+	if err := os.WriteFile(output, b, 0600); err != nil {
+		return fmt.Errorf("failed to write provisioning file: %w", err)
+	}
+	logger.Infof("%d rules in %d groups written to %s, %d issues found %s\n", rules, len(groups), output, len(issues), color.GreenString("✔"))
+	return nil
+}
+
+func formatConversionIssue(issue prom.Issue) string {
+	subject := fmt.Sprintf("group %q", issue.Group)
+	if issue.Rule != "" {
+		subject = fmt.Sprintf("rule %q in %s", issue.Rule, subject)
+	}
+	if issue.Skipped {
+		return fmt.Sprintf("%s is skipped: %s", subject, issue.Message)
+	}
+	return fmt.Sprintf("%s: %s", subject, issue.Message)
+}
+
+func convertedRuleUID(folder, group, title string) string {
+	h := fnv.New64a()
+	for _, s := range []string{folder, group, title} {
+		_, _ = h.Write([]byte(s))
+		_, _ = h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", h.Sum64())
+}
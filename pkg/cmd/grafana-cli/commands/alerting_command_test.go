@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+func TestConvertPrometheusRulesCommand(t *testing.T) {
+	dir := t.TempDir()
+	ruleFile := filepath.Join(dir, "rules.yaml")
+	require.NoError(t, os.WriteFile(ruleFile, []byte(`
+groups:
+  - name: api
+    rules:
+      - alert: InstanceDown
+        expr: up == 0
+        for: 5m
+      - record: job:up:sum
+        expr: sum by (job) (up)
+`), 0600))
+
+	newContext := func(t *testing.T, args ...string) utils.CommandLine {
+		t.Helper()
+		flagSet := flag.NewFlagSet("Test", 0)
+		flagSet.String("datasource-uid", "", "")
+		flagSet.String("folder", "", "")
+		flagSet.String("interval", "1m", "")
+		flagSet.String("output", "", "")
+		flagSet.Bool("dry-run", false, "")
+		require.NoError(t, flagSet.Parse(args))
+		return &utils.ContextCommandLine{Context: cli.NewContext(&cli.App{Name: "Test"}, flagSet, nil)}
+	}
+
+	t.Run("should write provisioning file", func(t *testing.T) {
+		output := filepath.Join(dir, "provisioning.yaml")
+		err := convertPrometheusRulesCommand(newContext(t, "--datasource-uid=prom", "--folder=Imported", "--output="+output, ruleFile))
+		require.NoError(t, err)
+
+		b, err := os.ReadFile(output)
+		require.NoError(t, err)
+		var export definitions.AlertingFileExport
+		require.NoError(t, yaml.Unmarshal(b, &export))
+		require.Len(t, export.Groups, 1)
+		require.Equal(t, "api", export.Groups[0].Name)
+		require.Equal(t, "Imported", export.Groups[0].Folder)
+		require.Len(t, export.Groups[0].Rules, 1)
+		require.Equal(t, "InstanceDown", export.Groups[0].Rules[0].Title)
+		require.Equal(t, convertedRuleUID("Imported", "api", "InstanceDown"), export.Groups[0].Rules[0].UID)
+	})
+
+	t.Run("should not write file in dry run", func(t *testing.T) {
+		err := convertPrometheusRulesCommand(newContext(t, "--datasource-uid=prom", "--folder=Imported", "--dry-run", ruleFile))
+		require.NoError(t, err)
+	})
+
+	t.Run("should fail without output", func(t *testing.T) {
+		err := convertPrometheusRulesCommand(newContext(t, "--datasource-uid=prom", "--folder=Imported", ruleFile))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/server"
+	"github.com/grafana/grafana/pkg/services/backup"
+)
+
+func backupCreateCommand(c utils.CommandLine, runner server.Runner) error {
+	path := c.Args().First()
+	if path == "" {
+		return fmt.Errorf("missing path of the backup file")
+	}
+
+	// #nosec G304 - the path is provided by the operator
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	svc := backup.NewService(runner.Cfg, runner.SQLStore, runner.EncryptionService)
+	manifest, err := svc.Create(context.Background(), f)
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	logger.Infof("\n")
+	logger.Infof("Backup of %d tables and %d files written to %s %s", len(manifest.Tables), len(manifest.Files), path, color.GreenString("✔"))
+	return nil
+}
+
+func backupRestoreCommand(c utils.CommandLine, runner server.Runner) error {
+	path := c.Args().First()
+	if path == "" {
+		return fmt.Errorf("missing path of the backup file")
+	}
+
+	// #nosec G304 - the path is provided by the operator
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	svc := backup.NewService(runner.Cfg, runner.SQLStore, runner.EncryptionService)
+	if c.Bool("check") {
+		manifest, err := backup.ReadManifest(f)
+		if err != nil {
+			return err
+		}
+		if err := svc.Check(context.Background(), manifest); err != nil {
+			return err
+		}
+		logger.Infof("\n")
+		logger.Infof("Backup created by Grafana %s at %s can be restored %s", manifest.GrafanaVersion, manifest.CreatedAt, color.GreenString("✔"))
+		return nil
+	}
+
+	result, err := svc.Restore(context.Background(), f, backup.RestoreOptions{
+		Provisioning:  c.Bool("provisioning"),
+		AlertingState: c.Bool("alerting-state"),
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("\n")
+	logger.Infof("Restored %d tables and %d files %s", len(result.RestoredRows), len(result.RestoredFiles), color.GreenString("✔"))
+	for _, p := range result.MissingPlugins {
+		logger.Infof("Plugin %s %s is not installed, run: grafana cli plugins install %s %s", p.ID, p.Version, p.ID, p.Version)
+	}
+	return nil
+}
@@ -167,6 +167,72 @@ var adminCommands = []*cli.Command{
 			},
 		},
 	},
+	{
+		Name:  "backup",
+		Usage: "Creates and restores backups of the database, provisioning files and alerting state",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "create",
+				Usage:  "create <file>. Writes a consistent backup of the instance, safe to execute while Grafana is running.",
+				Action: runRunnerCommand(backupCreateCommand),
+			},
+			{
+				Name:   "restore",
+				Usage:  "restore <file>. Replaces the content of the database with the backup. Grafana must be stopped. > Note: This is irreversible it will change the state of the database.",
+				Action: runRunnerCommand(backupRestoreCommand),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "check",
+						Usage: "Only check that the backup is compatible with this instance",
+					},
+					&cli.BoolFlag{
+						Name:  "provisioning",
+						Usage: "Restore the provisioning files into the provisioning path",
+					},
+					&cli.BoolFlag{
+						Name:  "alerting-state",
+						Usage: "Restore the silences and notification log of the Alertmanagers into the data path",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:  "alerting",
+		Usage: "Alerting commands",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "convert-prometheus-rules",
+				Usage:  "convert-prometheus-rules <file>. Converts a Prometheus rule file to a Grafana Alerting provisioning file and reports anything that cannot be converted.",
+				Action: runPluginCommand(convertPrometheusRulesCommand),
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "datasource-uid",
+						Usage:    "UID of the Prometheus data source the rules query",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "folder",
+						Usage:    "Title of the folder the rules are provisioned into",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "interval",
+						Usage: "Evaluation interval of the rule groups that do not specify one",
+						Value: "1m",
+					},
+					&cli.StringFlag{
+						Name:  "output",
+						Usage: "Path of the provisioning file to write",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Only report the result of the conversion",
+					},
+				},
+			},
+		},
+	},
 	{
 		Name:  "user-manager",
 		Usage: "Runs different helpful user commands",
@@ -0,0 +1,152 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/util/converter"
+)
+
+// reduceNativeHistograms replaces the native histogram frames of a Prometheus response with the estimated quantile of
+// every histogram, the same way the histogram_quantile function of PromQL does. The heatmap-cells frames of range
+// queries become time series, and the histogram frames of instant queries become numbers at the end of the time range.
+// Other frames are returned unchanged.
+func reduceNativeHistograms(frames data.Frames, quantile *float64, at time.Time) (data.Frames, error) {
+	reduced := make(data.Frames, 0, len(frames))
+	for _, frame := range frames {
+		if frame == nil || frame.Meta == nil {
+			reduced = append(reduced, frame)
+			continue
+		}
+		switch frame.Meta.Type {
+		case converter.FrameTypeHeatmapCells, converter.FrameTypeHistogram:
+		default:
+			reduced = append(reduced, frame)
+			continue
+		}
+		if quantile == nil {
+			return nil, fmt.Errorf("the response contains native histograms, set histogramQuantile in the query to reduce them to a quantile")
+		}
+
+		var (
+			f   *data.Frame
+			err error
+		)
+		if frame.Meta.Type == converter.FrameTypeHeatmapCells {
+			f, err = heatmapCellsToSeries(frame, *quantile)
+		} else {
+			f, err = histogramToVector(frame, *quantile, at)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to reduce native histogram frame [%v]: %w", frame.Name, err)
+		}
+		reduced = append(reduced, f)
+	}
+	return reduced, nil
+}
+
+// heatmapCellsToSeries reduces a heatmap-cells frame, with a row per bucket and the time of the histogram in the
+// xMax field, to a time series with a value per histogram.
+func heatmapCellsToSeries(frame *data.Frame, quantile float64) (*data.Frame, error) {
+	timeField, _ := frame.FieldByName("xMax")
+	lower, upper, count, labels, err := histogramBucketFields(frame, "yMin", "yMax")
+	if err != nil {
+		return nil, err
+	}
+	if timeField == nil || timeField.Type() != data.FieldTypeTime {
+		return nil, fmt.Errorf("missing time field xMax")
+	}
+
+	times := make([]time.Time, 0)
+	values := make([]float64, 0)
+	for start := 0; start < timeField.Len(); {
+		t := timeField.At(start).(time.Time)
+		end := start + 1
+		for end < timeField.Len() && timeField.At(end).(time.Time).Equal(t) {
+			end++
+		}
+		times = append(times, t)
+		values = append(values, histogramQuantile(quantile, lower[start:end], upper[start:end], count[start:end]))
+		start = end
+	}
+
+	return prometheusFrame(frame.Name, "matrix", labels, times, values), nil
+}
+
+// histogramToVector reduces the histogram frame of an instant query to a single value.
+func histogramToVector(frame *data.Frame, quantile float64, at time.Time) (*data.Frame, error) {
+	lower, upper, count, labels, err := histogramBucketFields(frame, "xMin", "xMax")
+	if err != nil {
+		return nil, err
+	}
+	return prometheusFrame(frame.Name, "vector", labels, []time.Time{at}, []float64{histogramQuantile(quantile, lower, upper, count)}), nil
+}
+
+func histogramBucketFields(frame *data.Frame, lowerName, upperName string) (lower, upper, count []float64, labels data.Labels, err error) {
+	names := []string{lowerName, upperName, "count"}
+	values := make([][]float64, len(names))
+	for i, name := range names {
+		field, _ := frame.FieldByName(name)
+		if field == nil || field.Type() != data.FieldTypeFloat64 {
+			return nil, nil, nil, nil, fmt.Errorf("missing bucket field %s", name)
+		}
+		values[i] = make([]float64, field.Len())
+		for j := range values[i] {
+			values[i][j] = field.At(j).(float64)
+		}
+		if field.Labels != nil {
+			labels = field.Labels
+		}
+	}
+	return values[0], values[1], values[2], labels, nil
+}
+
+// prometheusFrame returns a frame in the shape the Prometheus data source returns float samples in.
+func prometheusFrame(name, resultType string, labels data.Labels, times []time.Time, values []float64) *data.Frame {
+	frame := data.NewFrame(name,
+		data.NewField(data.TimeSeriesTimeFieldName, nil, times),
+		data.NewField(data.TimeSeriesValueFieldName, labels, values),
+	)
+	frame.Meta = &data.FrameMeta{
+		Type:   data.FrameTypeTimeSeriesMulti,
+		Custom: map[string]string{"resultType": resultType},
+	}
+	return frame
+}
+
+// histogramQuantile estimates the quantile of a histogram from the bounds and the counts of its buckets, which are
+// sorted by their bounds. The quantile is interpolated linearly within the bucket it falls into.
+func histogramQuantile(quantile float64, lower, upper, count []float64) float64 {
+	switch {
+	case math.IsNaN(quantile):
+		return math.NaN()
+	case quantile < 0:
+		return math.Inf(-1)
+	case quantile > 1:
+		return math.Inf(1)
+	}
+
+	total := 0.0
+	for _, c := range count {
+		total += c
+	}
+	if total == 0 {
+		return math.NaN()
+	}
+
+	rank := quantile * total
+	cumulative := 0.0
+	for i, c := range count {
+		if c == 0 {
+			continue
+		}
+		if cumulative+c >= rank {
+			return lower[i] + (upper[i]-lower[i])*(rank-cumulative)/c
+		}
+		cumulative += c
+	}
+	return upper[len(upper)-1]
+}
@@ -0,0 +1,90 @@
+package expr
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/infra/log/logtest"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/util/converter"
+)
+
+func TestHistogramQuantile(t *testing.T) {
+	lower := []float64{0, 1, 2}
+	upper := []float64{1, 2, 4}
+	count := []float64{2, 0, 2}
+
+	require.Equal(t, 0.0, histogramQuantile(0, lower, upper, count))
+	require.Equal(t, 0.5, histogramQuantile(0.25, lower, upper, count))
+	require.Equal(t, 1.0, histogramQuantile(0.5, lower, upper, count))
+	require.Equal(t, 3.0, histogramQuantile(0.75, lower, upper, count))
+	require.Equal(t, 4.0, histogramQuantile(1, lower, upper, count))
+	require.True(t, math.IsInf(histogramQuantile(-1, lower, upper, count), -1))
+	require.True(t, math.IsInf(histogramQuantile(2, lower, upper, count), 1))
+	require.True(t, math.IsNaN(histogramQuantile(0.5, lower, upper, []float64{0, 0, 0})))
+}
+
+func TestReduceNativeHistograms(t *testing.T) {
+	labels := data.Labels{"job": "api"}
+	t0 := time.Unix(1700000000, 0).UTC()
+	t1 := t0.Add(time.Minute)
+	quantile := 0.5
+
+	heatmap := data.NewFrame("api",
+		data.NewField("xMax", nil, []time.Time{t0, t0, t1}),
+		data.NewField("yMin", labels, []float64{0, 1, 0}),
+		data.NewField("yMax", nil, []float64{1, 2, 1}),
+		data.NewField("count", nil, []float64{1, 3, 4}),
+		data.NewField("yLayout", nil, []int8{0, 0, 0}),
+	)
+	heatmap.Meta = &data.FrameMeta{Type: converter.FrameTypeHeatmapCells, Custom: map[string]string{"resultType": "matrix"}}
+
+	histogram := data.NewFrame("",
+		data.NewField("xMin", nil, []float64{0, 1}),
+		data.NewField("xMax", nil, []float64{1, 2}),
+		data.NewField("count", labels, []float64{2, 2}),
+	)
+	histogram.Meta = &data.FrameMeta{Type: converter.FrameTypeHistogram, Custom: map[string]string{"resultType": "vector"}}
+
+	t.Run("should reduce heatmap cells to a time series", func(t *testing.T) {
+		frames, err := reduceNativeHistograms(data.Frames{heatmap}, &quantile, t1)
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		require.Equal(t, "api", frames[0].Name)
+		require.Equal(t, []time.Time{t0, t1}, []time.Time{frames[0].Fields[0].At(0).(time.Time), frames[0].Fields[0].At(1).(time.Time)})
+		require.Equal(t, []float64{1 + 1.0/3, 0.5}, []float64{frames[0].Fields[1].At(0).(float64), frames[0].Fields[1].At(1).(float64)})
+		require.Equal(t, labels, frames[0].Fields[1].Labels)
+	})
+
+	t.Run("should reduce an instant histogram to a vector", func(t *testing.T) {
+		s := &Service{features: featuremgmt.WithFeatures()}
+		frames, err := reduceNativeHistograms(data.Frames{histogram}, &quantile, t1)
+		require.NoError(t, err)
+		_, res, err := convertDataFramesToResults(context.Background(), frames, datasources.DS_PROMETHEUS, s, &logtest.Fake{})
+		require.NoError(t, err)
+		require.Len(t, res.Values, 1)
+		n, ok := res.Values[0].(mathexp.Number)
+		require.True(t, ok)
+		require.Equal(t, 1.0, *n.GetFloat64Value())
+		require.Equal(t, labels, n.GetLabels())
+	})
+
+	t.Run("should keep other frames", func(t *testing.T) {
+		series := data.NewFrame("", data.NewField("Time", nil, []time.Time{t0}), data.NewField("Value", nil, []float64{1}))
+		frames, err := reduceNativeHistograms(data.Frames{series}, nil, t1)
+		require.NoError(t, err)
+		require.Equal(t, data.Frames{series}, frames)
+	})
+
+	t.Run("should fail without a quantile", func(t *testing.T) {
+		_, err := reduceNativeHistograms(data.Frames{heatmap}, nil, t1)
+		require.ErrorContains(t, err, "histogramQuantile")
+	})
+}
@@ -149,6 +149,9 @@ type DSNode struct {
 	intervalMS int64
 	maxDP      int64
 	request    Request
+
+	// histogramQuantile is the quantile the native histograms of the response are reduced to.
+	histogramQuantile *float64
 }
 
 // NodeType returns the data pipeline node type.
@@ -201,6 +204,14 @@ func (s *Service) buildDSNode(dp *simple.DirectedGraph, rn *rawNode, req *Reques
 		dsNode.maxDP = int64(floatMaxDP)
 	}
 
+	if rawQuantile, ok := rn.Query["histogramQuantile"]; ok {
+		quantile, ok := rawQuantile.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected histogramQuantile to be an float64, got type %T for refId %v", rawQuantile, rn.RefID)
+		}
+		dsNode.histogramQuantile = &quantile
+	}
+
 	return dsNode, nil
 }
 
@@ -289,6 +300,13 @@ func executeDSNodesGrouped(ctx context.Context, now time.Time, vars mathexp.Vars
 					return
 				}
 
+				dataFrames, err = reduceNativeHistograms(dataFrames, dn.histogramQuantile, dn.timeRange.AbsoluteTime(now).To)
+				if err != nil {
+					vars[dn.refID] = mathexp.Results{Error: makeConversionError(dn.refID, err)}
+					instrument(err, "")
+					continue
+				}
+
 				var result mathexp.Results
 				responseType, result, err := convertDataFramesToResults(ctx, dataFrames, dn.datasource.Type, s, logger)
 				if err != nil {
@@ -357,6 +375,11 @@ func (dn *DSNode) Execute(ctx context.Context, now time.Time, _ mathexp.Vars, s
 		return mathexp.Results{}, MakeQueryError(dn.refID, dn.datasource.UID, err)
 	}
 
+	dataFrames, err = reduceNativeHistograms(dataFrames, dn.histogramQuantile, dn.timeRange.AbsoluteTime(now).To)
+	if err != nil {
+		return mathexp.Results{}, makeConversionError(dn.refID, err)
+	}
+
 	var result mathexp.Results
 	responseType, result, err = convertDataFramesToResults(ctx, dataFrames, dn.datasource.Type, s, logger)
 	if err != nil {
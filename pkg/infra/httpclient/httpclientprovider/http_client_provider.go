@@ -39,6 +39,11 @@ func New(cfg *setting.Cfg, validator validations.PluginRequestValidator, tracer
 		middlewares = append(middlewares, HTTPLoggerMiddleware(cfg.PluginSettings))
 	}
 
+	if cfg.DataProxyRecordReplayMode != "" {
+		logger.Warn("Data source requests are recorded or replayed", "mode", cfg.DataProxyRecordReplayMode, "path", cfg.DataProxyRecordReplayPath)
+		middlewares = append(middlewares, RecordReplayMiddleware(logger, cfg.DataProxyRecordReplayMode, cfg.DataProxyRecordReplayPath, cfg.DataProxyRecordReplayIgnoredParams))
+	}
+
 	setDefaultTimeoutOptions(cfg)
 
 	return newProviderFunc(sdkhttpclient.ProviderOptions{
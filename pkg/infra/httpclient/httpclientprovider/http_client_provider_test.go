@@ -86,4 +86,22 @@ func TestHTTPClientProvider(t *testing.T) {
 		require.Equal(t, HostRedirectValidationMiddlewareName, o.Middlewares[7].(sdkhttpclient.MiddlewareName).MiddlewareName())
 		require.Equal(t, HTTPLoggerMiddlewareName, o.Middlewares[8].(sdkhttpclient.MiddlewareName).MiddlewareName())
 	})
+
+	t.Run("When creating new provider and record replay mode is set, it should apply expected middleware", func(t *testing.T) {
+		origNewProviderFunc := newProviderFunc
+		providerOpts := []sdkhttpclient.ProviderOptions{}
+		newProviderFunc = func(opts ...sdkhttpclient.ProviderOptions) *sdkhttpclient.Provider {
+			providerOpts = opts
+			return nil
+		}
+		t.Cleanup(func() {
+			newProviderFunc = origNewProviderFunc
+		})
+		tracer := tracing.InitializeTracerForTest()
+		_ = New(&setting.Cfg{DataProxyRecordReplayMode: ReplayMode, DataProxyRecordReplayPath: t.TempDir()}, &validations.OSSPluginRequestValidator{}, tracer)
+		require.Len(t, providerOpts, 1)
+		o := providerOpts[0]
+		require.Len(t, o.Middlewares, 9)
+		require.Equal(t, RecordReplayMiddlewareName, o.Middlewares[8].(sdkhttpclient.MiddlewareName).MiddlewareName())
+	})
 }
@@ -0,0 +1,234 @@
+package httpclientprovider
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// RecordReplayMiddlewareName is the middleware name used by RecordReplayMiddleware.
+const RecordReplayMiddlewareName = "record-replay"
+
+const (
+	// RecordMode writes the requests and responses of data sources to cassettes.
+	RecordMode = "record"
+	// ReplayMode serves the responses of data sources from cassettes, without sending the requests.
+	ReplayMode = "replay"
+)
+
+// cassette is a recorded request and its response.
+type cassette struct {
+	Request  cassetteRequest  `json:"request"`
+	Response cassetteResponse `json:"response"`
+}
+
+type cassetteRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	cassetteBody
+}
+
+type cassetteResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	cassetteBody
+}
+
+// cassetteBody is a body as text, or as base64 if it is not valid UTF-8.
+type cassetteBody struct {
+	Body       string `json:"body,omitempty"`
+	BodyBase64 string `json:"bodyBase64,omitempty"`
+}
+
+func newCassetteBody(b []byte) cassetteBody {
+	if utf8.Valid(b) {
+		return cassetteBody{Body: string(b)}
+	}
+	return cassetteBody{BodyBase64: base64.StdEncoding.EncodeToString(b)}
+}
+
+func (b cassetteBody) bytes() ([]byte, error) {
+	if b.BodyBase64 != "" {
+		return base64.StdEncoding.DecodeString(b.BodyBase64)
+	}
+	return []byte(b.Body), nil
+}
+
+// RecordReplayMiddleware records the requests of data sources and their responses to a cassette directory per data
+// source, or replays the recorded responses, depending on mode. The recordings are keyed by a hash of the method,
+// URL and body of the request, without the ignoredParams query parameters, so that requests with relative time ranges
+// can be replayed by ignoring their time parameters. The middleware should be the last one, so that it records the
+// requests as they are sent.
+func RecordReplayMiddleware(logger log.Logger, mode string, path string, ignoredParams []string) sdkhttpclient.Middleware {
+	return sdkhttpclient.NamedMiddlewareFunc(RecordReplayMiddlewareName, func(opts sdkhttpclient.Options, next http.RoundTripper) http.RoundTripper {
+		if mode != RecordMode && mode != ReplayMode {
+			return next
+		}
+		dir := filepath.Join(path, cassetteDirName(opts.Labels))
+
+		return sdkhttpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			body, err := readRequestBody(req)
+			if err != nil {
+				return nil, err
+			}
+			file := filepath.Join(dir, requestHash(req, body, ignoredParams)+".json")
+
+			if mode == ReplayMode {
+				return replayResponse(req, file)
+			}
+
+			res, err := next.RoundTrip(req)
+			if err != nil || res.StatusCode == http.StatusSwitchingProtocols {
+				return res, err
+			}
+			if err := recordResponse(req, body, res, dir, file); err != nil {
+				logger.Warn("Failed to record response", "url", req.URL.Redacted(), "file", file, "error", err)
+			}
+			return res, nil
+		})
+	})
+}
+
+// cassetteDirName returns the name of the cassette directory of a data source, which is its UID.
+func cassetteDirName(labels map[string]string) string {
+	name := labels["datasource_uid"]
+	if name == "" {
+		name = labels["datasource_type"]
+	}
+	if name == "" {
+		return "default"
+	}
+	return strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(name)
+}
+
+// readRequestBody reads the body of a request and replaces it, so that it can still be sent.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Body.Close(); err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+// requestHash returns a hash of the method, the URL, with sorted query parameters and without user info and the
+// ignored parameters, and the body of a request.
+func requestHash(req *http.Request, body []byte, ignoredParams []string) string {
+	u := *req.URL
+	u.User = nil
+	query := u.Query()
+	for _, param := range ignoredParams {
+		query.Del(param)
+	}
+	u.RawQuery = query.Encode()
+
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\n%s\n", req.Method, u.String())
+	_, _ = h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func recordResponse(req *http.Request, reqBody []byte, res *http.Response, dir, file string) error {
+	resBody, err := io.ReadAll(res.Body)
+	if closeErr := res.Body.Close(); err == nil {
+		err = closeErr
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+	if err != nil {
+		return err
+	}
+
+	header := res.Header.Clone()
+	header.Del("Set-Cookie")
+	c := cassette{
+		Request: cassetteRequest{
+			Method:       req.Method,
+			URL:          req.URL.Redacted(),
+			cassetteBody: newCassetteBody(reqBody),
+		},
+		Response: cassetteResponse{
+			StatusCode:   res.StatusCode,
+			Header:       header,
+			cassetteBody: newCassetteBody(resBody),
+		},
+	}
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	// write to a temporary file first, so that concurrent replays never read a partial cassette
+	tmp, err := os.CreateTemp(dir, ".cassette-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+func replayResponse(req *http.Request, file string) (*http.Response, error) {
+	// nolint:gosec
+	// The file name is a hash of the request.
+	b, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no recorded response for %s %s in %s", req.Method, req.URL.Redacted(), file)
+		}
+		return nil, err
+	}
+
+	var c cassette
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("failed to read cassette %s: %w", file, err)
+	}
+	body, err := c.Response.bytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette %s: %w", file, err)
+	}
+
+	header := c.Response.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.Response.StatusCode, http.StatusText(c.Response.StatusCode)),
+		StatusCode:    c.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -2,6 +2,7 @@ package remotecache
 
 import (
 	"context"
+	"math"
 	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
@@ -43,7 +44,11 @@ func (dc *databaseCache) internalRunGC() {
 		now := getTime().Unix()
 		sql := `DELETE FROM cache_data WHERE (? - created_at) >= expires AND expires <> 0`
 
-		_, err := session.Exec(sql, now)
+		if _, err := session.Exec(sql, now); err != nil {
+			return err
+		}
+
+		_, err := session.Exec(`DELETE FROM cache_counter WHERE (? - created_at) >= expires AND expires <> 0`, now)
 		return err
 	})
 
@@ -138,6 +143,120 @@ func (dc *databaseCache) Count(ctx context.Context, prefix string) (int64, error
 	return res, err
 }
 
+// Increment updates the counter in place so that concurrent increments from
+// several instances are not lost. The returned value may already include
+// increments made by other instances right after this one.
+func (dc *databaseCache) Increment(ctx context.Context, key string, delta int64, expire time.Duration) (int64, error) {
+	var value int64
+	err := dc.SQLStore.WithDbSession(ctx, func(session *db.Session) error {
+		now := getTime().Unix()
+		expiresInSeconds := int64(expire / time.Second)
+
+		update := func() (int64, error) {
+			res, err := session.Exec(`UPDATE cache_counter SET counter = counter + ? WHERE cache_key = ? AND (expires = 0 OR ? - created_at < expires)`, delta, key, now)
+			if err != nil {
+				return 0, err
+			}
+			return res.RowsAffected()
+		}
+
+		updated, err := update()
+		if err != nil {
+			return err
+		}
+		if updated == 0 {
+			if _, err := session.Exec(`DELETE FROM cache_counter WHERE cache_key = ? AND expires <> 0 AND ? - created_at >= expires`, key, now); err != nil {
+				return err
+			}
+			_, err := session.Exec(`INSERT INTO cache_counter (cache_key,counter,created_at,expires) VALUES(?,?,?,?)`, key, delta, now, expiresInSeconds)
+			if err == nil {
+				value = delta
+				return nil
+			}
+			if !dc.SQLStore.GetDialect().IsUniqueConstraintViolation(err) {
+				return err
+			}
+			// another instance created the counter first, increment it
+			if _, err := update(); err != nil {
+				return err
+			}
+		}
+
+		_, err = session.SQL(`SELECT counter FROM cache_counter WHERE cache_key = ?`, key).Get(&value)
+		return err
+	})
+
+	return value, err
+}
+
+// CompareAndSwap only updates the counter if it still has the old value, so
+// that only one of several instances swapping the same value succeeds.
+func (dc *databaseCache) CompareAndSwap(ctx context.Context, key string, old, new int64, expire time.Duration) (bool, int64, error) {
+	var swapped bool
+	var value int64
+	err := dc.SQLStore.WithDbSession(ctx, func(session *db.Session) error {
+		now := getTime().Unix()
+		expiresInSeconds := int64(math.Ceil(expire.Seconds()))
+
+		res, err := session.Exec(`UPDATE cache_counter SET counter = ?, created_at = ?, expires = ? WHERE cache_key = ? AND counter = ? AND (expires = 0 OR ? - created_at < expires)`, new, now, expiresInSeconds, key, old, now)
+		if err != nil {
+			return err
+		}
+		updated, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if updated > 0 {
+			swapped, value = true, new
+			return nil
+		}
+
+		var counter cacheCounter
+		exist, err := session.SQL(`SELECT counter, created_at, expires FROM cache_counter WHERE cache_key = ?`, key).Get(&counter)
+		if err != nil {
+			return err
+		}
+		if exist && !counter.expired(now) {
+			value = counter.Counter
+			// MySQL does not count the rows that the update has not changed
+			swapped = value == old && old == new
+			return nil
+		}
+
+		if old != 0 {
+			return nil
+		}
+		if exist {
+			if _, err := session.Exec(`DELETE FROM cache_counter WHERE cache_key = ? AND expires <> 0 AND ? - created_at >= expires`, key, now); err != nil {
+				return err
+			}
+		}
+		_, err = session.Exec(`INSERT INTO cache_counter (cache_key,counter,created_at,expires) VALUES(?,?,?,?)`, key, new, now, expiresInSeconds)
+		if err == nil {
+			swapped, value = true, new
+			return nil
+		}
+		if !dc.SQLStore.GetDialect().IsUniqueConstraintViolation(err) {
+			return err
+		}
+		// another instance created the counter first
+		_, err = session.SQL(`SELECT counter FROM cache_counter WHERE cache_key = ?`, key).Get(&value)
+		return err
+	})
+
+	return swapped, value, err
+}
+
+type cacheCounter struct {
+	Counter   int64
+	CreatedAt int64
+	Expires   int64
+}
+
+func (c cacheCounter) expired(now int64) bool {
+	return c.Expires != 0 && now-c.CreatedAt >= c.Expires
+}
+
 // CacheData is the struct representing the table in the database
 type CacheData struct {
 	CacheKey  string
@@ -115,3 +115,78 @@ func TestDatabaseStorageCount(t *testing.T) {
 	require.NoError(t, errC)
 	assert.Equal(t, int64(2), n)
 }
+
+func TestDatabaseStorageIncrement(t *testing.T) {
+	sqlstore := db.InitTestDB(t)
+
+	db := &databaseCache{
+		SQLStore: sqlstore,
+		log:      log.New("remotecache.database"),
+	}
+
+	value, err := db.Increment(context.Background(), "counter", 1, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), value)
+
+	value, err = db.Increment(context.Background(), "counter", 2, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), value)
+
+	value, err = db.Increment(context.Background(), "counter", -1, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), value)
+
+	// an expired counter starts over
+	getTime = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	t.Cleanup(func() { getTime = time.Now })
+	value, err = db.Increment(context.Background(), "counter", 1, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), value)
+}
+
+func TestDatabaseStorageCompareAndSwap(t *testing.T) {
+	sqlstore := db.InitTestDB(t)
+
+	db := &databaseCache{
+		SQLStore: sqlstore,
+		log:      log.New("remotecache.database"),
+	}
+
+	// a missing counter has the value zero
+	swapped, value, err := db.CompareAndSwap(context.Background(), "counter", 1, 2, time.Minute)
+	require.NoError(t, err)
+	require.False(t, swapped)
+	require.Equal(t, int64(0), value)
+
+	swapped, value, err = db.CompareAndSwap(context.Background(), "counter", 0, 2, time.Minute)
+	require.NoError(t, err)
+	require.True(t, swapped)
+	require.Equal(t, int64(2), value)
+
+	swapped, value, err = db.CompareAndSwap(context.Background(), "counter", 0, 3, time.Minute)
+	require.NoError(t, err)
+	require.False(t, swapped)
+	require.Equal(t, int64(2), value)
+
+	swapped, value, err = db.CompareAndSwap(context.Background(), "counter", 2, 3, time.Minute)
+	require.NoError(t, err)
+	require.True(t, swapped)
+	require.Equal(t, int64(3), value)
+
+	swapped, _, err = db.CompareAndSwap(context.Background(), "counter", 3, 3, time.Minute)
+	require.NoError(t, err)
+	require.True(t, swapped)
+
+	// an expired counter has the value zero again
+	getTime = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	t.Cleanup(func() { getTime = time.Now })
+	swapped, value, err = db.CompareAndSwap(context.Background(), "counter", 3, 4, time.Minute)
+	require.NoError(t, err)
+	require.False(t, swapped)
+	require.Equal(t, int64(0), value)
+
+	swapped, value, err = db.CompareAndSwap(context.Background(), "counter", 0, 4, time.Minute)
+	require.NoError(t, err)
+	require.True(t, swapped)
+	require.Equal(t, int64(4), value)
+}
@@ -3,6 +3,8 @@ package remotecache
 import (
 	"context"
 	"errors"
+	"math"
+	"strconv"
 	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
@@ -61,6 +63,82 @@ func (s *memcachedStorage) Count(ctx context.Context, prefix string) (int64, err
 	return 0, ErrNotImplemented
 }
 
+// Increment creates the counter with Add when it is missing, memcached cannot
+// increment a missing item.
+func (s *memcachedStorage) Increment(ctx context.Context, key string, delta int64, expires time.Duration) (int64, error) {
+	for {
+		value, err := s.incr(key, delta)
+		if !errors.Is(err, memcache.ErrCacheMiss) {
+			return int64(value), err
+		}
+
+		// memcached counters cannot go below zero
+		initial := delta
+		if initial < 0 {
+			initial = 0
+		}
+		err = s.c.Add(newItem(key, []byte(strconv.FormatInt(initial, 10)), int32(expires/time.Second)))
+		if !errors.Is(err, memcache.ErrNotStored) {
+			return initial, err
+		}
+		// another instance created the counter first, increment it
+	}
+}
+
+func (s *memcachedStorage) incr(key string, delta int64) (uint64, error) {
+	if delta < 0 {
+		return s.c.Decrement(key, uint64(-delta))
+	}
+	return s.c.Increment(key, uint64(delta))
+}
+
+// CompareAndSwap uses the compare and swap of memcached, which swaps the item
+// only if it has not been changed since it was read.
+func (s *memcachedStorage) CompareAndSwap(ctx context.Context, key string, old, new int64, expires time.Duration) (bool, int64, error) {
+	item := newItem(key, []byte(strconv.FormatInt(new, 10)), int32(math.Ceil(expires.Seconds())))
+
+	current, err := s.c.Get(key)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		if old != 0 {
+			return false, 0, nil
+		}
+		err = s.c.Add(item)
+		if err == nil {
+			return true, new, nil
+		}
+		if !errors.Is(err, memcache.ErrNotStored) {
+			return false, 0, err
+		}
+		// another instance created the counter first
+		current, err = s.c.Get(key)
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			return false, 0, nil
+		}
+	}
+	if err != nil {
+		return false, 0, err
+	}
+
+	value, err := strconv.ParseInt(string(current.Value), 10, 64)
+	if err != nil {
+		return false, 0, err
+	}
+	if value != old {
+		return false, value, nil
+	}
+
+	current.Value = item.Value
+	current.Expiration = item.Expiration
+	err = s.c.CompareAndSwap(current)
+	if errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrNotStored) {
+		return false, value, nil
+	}
+	if err != nil {
+		return false, 0, err
+	}
+	return true, new, nil
+}
+
 // Delete delete a key from the cache
 func (s *memcachedStorage) Delete(ctx context.Context, key string) error {
 	return s.c.Delete(key)
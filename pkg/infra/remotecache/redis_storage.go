@@ -119,3 +119,47 @@ func (s *redisStorage) Count(ctx context.Context, prefix string) (int64, error)
 
 	return int64(len(cmd.Val())), nil
 }
+
+// incrementScript increments the counter and starts the expiration of a new
+// counter in one step, so a counter is never left without expiration.
+var incrementScript = redis.NewScript(`
+local value = redis.call('INCRBY', KEYS[1], ARGV[1])
+if redis.call('PTTL', KEYS[1]) == -1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return value
+`)
+
+// compareAndSwapScript compares the counter as a string, lua numbers cannot
+// hold every int64.
+var compareAndSwapScript = redis.NewScript(`
+local value = redis.call('GET', KEYS[1]) or '0'
+if value ~= ARGV[1] then
+	return {0, value}
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return {1, ARGV[2]}
+`)
+
+// Increment runs a script as redis runs scripts atomically.
+func (s *redisStorage) Increment(ctx context.Context, key string, delta int64, expires time.Duration) (int64, error) {
+	return incrementScript.Run(ctx, s.c, []string{key}, delta, expires.Milliseconds()).Int64()
+}
+
+// CompareAndSwap runs a script as redis runs scripts atomically.
+func (s *redisStorage) CompareAndSwap(ctx context.Context, key string, old, new int64, expires time.Duration) (bool, int64, error) {
+	res, err := compareAndSwapScript.Run(ctx, s.c, []string{key}, strconv.FormatInt(old, 10), strconv.FormatInt(new, 10), expires.Milliseconds()).Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if len(res) != 2 {
+		return false, 0, fmt.Errorf("unexpected compare and swap result: %v", res)
+	}
+	swapped, _ := res[0].(int64)
+	str, _ := res[1].(string)
+	value, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return false, 0, err
+	}
+	return swapped == 1, value, nil
+}
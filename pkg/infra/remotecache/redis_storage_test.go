@@ -1,12 +1,16 @@
 package remotecache
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_parseRedisConnStr(t *testing.T) {
@@ -97,3 +101,59 @@ func Test_parseRedisConnStr(t *testing.T) {
 		assert.EqualValues(t, testCase.OutputOptions, options, reason)
 	}
 }
+
+func TestRedisStorageIncrement(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	s := &redisStorage{c: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+
+	value, err := s.Increment(context.Background(), "counter", 2, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), value)
+	require.Equal(t, time.Minute, mr.TTL("counter"))
+
+	// the expiration of an existing counter is kept
+	mr.FastForward(30 * time.Second)
+	value, err = s.Increment(context.Background(), "counter", 2, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), value)
+	require.Equal(t, 30*time.Second, mr.TTL("counter"))
+
+	mr.FastForward(30 * time.Second)
+	value, err = s.Increment(context.Background(), "counter", 1, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), value)
+}
+
+func TestRedisStorageCompareAndSwap(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	s := &redisStorage{c: redis.NewClient(&redis.Options{Addr: mr.Addr()})}
+
+	// a missing counter has the value zero
+	swapped, value, err := s.CompareAndSwap(context.Background(), "counter", 1, 2, time.Minute)
+	require.NoError(t, err)
+	require.False(t, swapped)
+	require.Equal(t, int64(0), value)
+
+	swapped, value, err = s.CompareAndSwap(context.Background(), "counter", 0, 1700000000123456789, time.Minute)
+	require.NoError(t, err)
+	require.True(t, swapped)
+	require.Equal(t, int64(1700000000123456789), value)
+	require.Equal(t, time.Minute, mr.TTL("counter"))
+
+	swapped, value, err = s.CompareAndSwap(context.Background(), "counter", 1700000000123456788, 3, time.Minute)
+	require.NoError(t, err)
+	require.False(t, swapped)
+	require.Equal(t, int64(1700000000123456789), value)
+
+	swapped, value, err = s.CompareAndSwap(context.Background(), "counter", 1700000000123456789, 3, time.Second)
+	require.NoError(t, err)
+	require.True(t, swapped)
+	require.Equal(t, int64(3), value)
+	require.Equal(t, time.Second, mr.TTL("counter"))
+}
@@ -76,6 +76,21 @@ type CacheStorage interface {
 	Count(ctx context.Context, prefix string) (int64, error)
 }
 
+// Counter allows the caller to atomically increment counters shared by all
+// Grafana instances. Counters are kept apart from the items stored with Set
+// and cannot be read with Get.
+type Counter interface {
+	// Increment adds delta to the counter stored at key and returns its new value.
+	// A missing or expired counter starts at zero and expires after `expire`.
+	Increment(ctx context.Context, key string, delta int64, expire time.Duration) (int64, error)
+
+	// CompareAndSwap sets the counter stored at key to new if its value is old,
+	// a missing or expired counter has the value zero. The counter expires after
+	// `expire`. It returns whether the counter was set and its value, which can
+	// be outdated when the counter is changed concurrently.
+	CompareAndSwap(ctx context.Context, key string, old, new int64, expire time.Duration) (bool, int64, error)
+}
+
 // RemoteCache allows Grafana to cache data outside its own process
 type RemoteCache struct {
 	client   CacheStorage
@@ -107,6 +122,32 @@ func (ds *RemoteCache) Count(ctx context.Context, prefix string) (int64, error)
 	return ds.client.Count(ctx, prefix)
 }
 
+// Increment adds delta to the counter stored at key and returns its new value.
+func (ds *RemoteCache) Increment(ctx context.Context, key string, delta int64, expire time.Duration) (int64, error) {
+	counter, ok := ds.client.(Counter)
+	if !ok {
+		return 0, ErrNotImplemented
+	}
+	if expire == 0 {
+		expire = defaultMaxCacheExpiration
+	}
+
+	return counter.Increment(ctx, key, delta, expire)
+}
+
+// CompareAndSwap sets the counter stored at key to new if its value is old.
+func (ds *RemoteCache) CompareAndSwap(ctx context.Context, key string, old, new int64, expire time.Duration) (bool, int64, error) {
+	counter, ok := ds.client.(Counter)
+	if !ok {
+		return false, 0, ErrNotImplemented
+	}
+	if expire == 0 {
+		expire = defaultMaxCacheExpiration
+	}
+
+	return counter.CompareAndSwap(ctx, key, old, new, expire)
+}
+
 // Run starts the backend processes for cache clients.
 func (ds *RemoteCache) Run(ctx context.Context) error {
 	// create new interface if more clients need GC jobs
@@ -177,6 +218,23 @@ func (pcs *encryptedCacheStorage) Count(ctx context.Context, prefix string) (int
 	return pcs.cache.Count(ctx, prefix)
 }
 
+// Increment does not encrypt the counter, it only holds a number.
+func (pcs *encryptedCacheStorage) Increment(ctx context.Context, key string, delta int64, expire time.Duration) (int64, error) {
+	counter, ok := pcs.cache.(Counter)
+	if !ok {
+		return 0, ErrNotImplemented
+	}
+	return counter.Increment(ctx, key, delta, expire)
+}
+
+func (pcs *encryptedCacheStorage) CompareAndSwap(ctx context.Context, key string, old, new int64, expire time.Duration) (bool, int64, error) {
+	counter, ok := pcs.cache.(Counter)
+	if !ok {
+		return false, 0, ErrNotImplemented
+	}
+	return counter.CompareAndSwap(ctx, key, old, new, expire)
+}
+
 type prefixCacheStorage struct {
 	cache  CacheStorage
 	prefix string
@@ -195,3 +253,19 @@ func (pcs *prefixCacheStorage) Delete(ctx context.Context, key string) error {
 func (pcs *prefixCacheStorage) Count(ctx context.Context, prefix string) (int64, error) {
 	return pcs.cache.Count(ctx, pcs.prefix+prefix)
 }
+
+func (pcs *prefixCacheStorage) Increment(ctx context.Context, key string, delta int64, expire time.Duration) (int64, error) {
+	counter, ok := pcs.cache.(Counter)
+	if !ok {
+		return 0, ErrNotImplemented
+	}
+	return counter.Increment(ctx, pcs.prefix+key, delta, expire)
+}
+
+func (pcs *prefixCacheStorage) CompareAndSwap(ctx context.Context, key string, old, new int64, expire time.Duration) (bool, int64, error) {
+	counter, ok := pcs.cache.(Counter)
+	if !ok {
+		return false, 0, ErrNotImplemented
+	}
+	return counter.CompareAndSwap(ctx, pcs.prefix+key, old, new, expire)
+}
@@ -2,6 +2,7 @@ package remotecache
 
 import (
 	"context"
+	"strconv"
 	"time"
 )
 
@@ -32,6 +33,22 @@ func (fcs FakeCacheStorage) Count(_ context.Context, prefix string) (int64, erro
 	return int64(len(fcs.Storage)), nil
 }
 
+func (fcs FakeCacheStorage) Increment(_ context.Context, key string, delta int64, exp time.Duration) (int64, error) {
+	value, _ := strconv.ParseInt(string(fcs.Storage[key]), 10, 64)
+	value += delta
+	fcs.Storage[key] = []byte(strconv.FormatInt(value, 10))
+	return value, nil
+}
+
+func (fcs FakeCacheStorage) CompareAndSwap(_ context.Context, key string, old, new int64, exp time.Duration) (bool, int64, error) {
+	value, _ := strconv.ParseInt(string(fcs.Storage[key]), 10, 64)
+	if value != old {
+		return false, value, nil
+	}
+	fcs.Storage[key] = []byte(strconv.FormatInt(new, 10))
+	return true, new, nil
+}
+
 func NewFakeCacheStorage() FakeCacheStorage {
 	return FakeCacheStorage{
 		Storage: map[string][]byte{},
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/middleware/cookies"
@@ -213,6 +214,34 @@ func SnapshotPublicModeOrSignedIn(cfg *setting.Cfg) web.Handler {
 	}
 }
 
+// ReqRecentLogin creates a middleware that requires users signed in with a
+// session to have logged in within the re-authentication max age. It is used
+// for sensitive admin actions. Other authentication methods are not affected.
+func ReqRecentLogin(cfg *setting.Cfg) web.Handler {
+	return func(c *contextmodel.ReqContext) {
+		if cfg.ReauthenticationMaxAge <= 0 || c.UserToken == nil {
+			return
+		}
+
+		if time.Since(time.Unix(c.UserToken.CreatedAt, 0)) <= cfg.ReauthenticationMaxAge {
+			return
+		}
+
+		if c.IsApiRequest() {
+			c.JSON(http.StatusUnauthorized, map[string]any{
+				"message": "Sign in again to perform this action",
+				"error": map[string]any{
+					"id": "ERR_REAUTHENTICATION_REQUIRED",
+				},
+			})
+			return
+		}
+
+		writeRedirectCookie(c)
+		c.Redirect(setting.AppSubUrl + "/logout")
+	}
+}
+
 func ReqNotSignedIn(c *contextmodel.ReqContext) {
 	if c.IsSignedIn {
 		c.Redirect(setting.AppSubUrl + "/")
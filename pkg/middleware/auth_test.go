@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -14,6 +15,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
+	"github.com/grafana/grafana/pkg/services/auth"
 	"github.com/grafana/grafana/pkg/services/authn"
 	"github.com/grafana/grafana/pkg/services/authn/authntest"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
@@ -122,6 +124,30 @@ func TestAuth_Middleware(t *testing.T) {
 			expecedReached: true,
 			expectedCode:   http.StatusOK,
 		},
+		{
+			desc:           "ReqRecentLogin should return 200 for a recent session",
+			path:           "/api/secure",
+			authMiddleware: ReqRecentLogin(&setting.Cfg{ReauthenticationMaxAge: 5 * time.Minute}),
+			identity:       &authn.Identity{ID: "user:1", SessionToken: &auth.UserToken{CreatedAt: time.Now().Add(-time.Minute).Unix()}},
+			expecedReached: true,
+			expectedCode:   http.StatusOK,
+		},
+		{
+			desc:           "ReqRecentLogin should return 401 for an old session",
+			path:           "/api/secure",
+			authMiddleware: ReqRecentLogin(&setting.Cfg{ReauthenticationMaxAge: 5 * time.Minute}),
+			identity:       &authn.Identity{ID: "user:1", SessionToken: &auth.UserToken{CreatedAt: time.Now().Add(-time.Hour).Unix()}},
+			expecedReached: false,
+			expectedCode:   http.StatusUnauthorized,
+		},
+		{
+			desc:           "ReqRecentLogin should return 200 for identities without session",
+			path:           "/api/secure",
+			authMiddleware: ReqRecentLogin(&setting.Cfg{ReauthenticationMaxAge: 5 * time.Minute}),
+			identity:       &authn.Identity{ID: "service-account:1"},
+			expecedReached: true,
+			expectedCode:   http.StatusOK,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const rateLimitCachePrefix = "ratelimit:"
+
+// queryPaths are the endpoints that use the query budget.
+var queryPaths = []string{"/api/ds/query"}
+
+type rateLimiter struct {
+	settings       setting.RateLimitSettings
+	trustedProxies []*net.IPNet
+	cache          remotecache.Counter
+	log            log.Logger
+	now            func() time.Time
+}
+
+// RateLimit returns a middleware that limits the rate of the API requests
+// with token buckets stored in the remote cache. It must be registered after
+// the context handler.
+func RateLimit(cfg *setting.Cfg, cache remotecache.Counter) web.Handler {
+	l := &rateLimiter{
+		settings:       cfg.RateLimiting,
+		trustedProxies: cfg.TrustedProxies,
+		cache:          cache,
+		log:            log.New("ratelimit"),
+		now:            time.Now,
+	}
+	return l.handle
+}
+
+func (l *rateLimiter) handle(c *contextmodel.ReqContext) {
+	path := c.Req.URL.Path
+	if !strings.HasPrefix(path, "/api/") {
+		return
+	}
+
+	class, budget := l.budget(c.Req.Method, path)
+	limit := budget.ForRole(requestRole(c))
+	if limit.Rate < 0 {
+		return
+	}
+
+	key := rateLimitCachePrefix + class + ":" + l.requestKey(c)
+	allowed, remaining, retryAfter, err := l.take(c.Req.Context(), key, limit)
+	if err != nil {
+		// A broken cache must not take the API down with it.
+		l.log.Warn("Failed to check rate limit", "key", key, "error", err)
+		return
+	}
+
+	c.Resp.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+	c.Resp.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	if !allowed {
+		c.Resp.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.JsonApiErr(http.StatusTooManyRequests, "Rate limit exceeded", nil)
+	}
+}
+
+func (l *rateLimiter) budget(method, path string) (string, setting.RateLimitBudget) {
+	for _, p := range queryPaths {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return "query", l.settings.Query
+		}
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return "read", l.settings.Read
+	default:
+		return "write", l.settings.Write
+	}
+}
+
+func (l *rateLimiter) requestKey(c *contextmodel.ReqContext) string {
+	signedIn := c.SignedInUser != nil && !c.SignedInUser.IsNil() && c.IsSignedIn
+	switch {
+	case l.settings.KeyBy == setting.RateLimitKeyOrg && signedIn:
+		return fmt.Sprintf("org:%d", c.SignedInUser.GetOrgID())
+	case l.settings.KeyBy == setting.RateLimitKeyIdentity && signedIn:
+		namespace, id := c.SignedInUser.GetNamespacedID()
+		if namespace == identity.NamespaceServiceAccount {
+			if token := requestToken(c.Req); token != "" {
+				// Each token of a service account gets its own budget. The token
+				// has been authenticated already, its hash identifies it without
+				// looking up its ID again.
+				sum := sha256.Sum256([]byte(token))
+				return "token:" + hex.EncodeToString(sum[:16])
+			}
+		}
+		return namespace + ":" + id
+	default:
+		return "ip:" + web.ClientIP(c.Req, l.trustedProxies).String()
+	}
+}
+
+// requestToken returns the API key or service account token of the request.
+func requestToken(req *http.Request) string {
+	header := req.Header.Get("Authorization")
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+		return token
+	}
+	if username, password, ok := req.BasicAuth(); ok && username == "api_key" {
+		return password
+	}
+	return ""
+}
+
+func requestRole(c *contextmodel.ReqContext) string {
+	if c.SignedInUser == nil || c.SignedInUser.IsNil() || !c.IsSignedIn {
+		return "none"
+	}
+	role := strings.ToLower(string(c.SignedInUser.GetOrgRole()))
+	if role == "" {
+		return "none"
+	}
+	return role
+}
+
+// maxTakeAttempts is the number of times a token is taken from a bucket that
+// is changed concurrently before the request is rejected.
+const maxTakeAttempts = 5
+
+// take takes a token from the bucket of key for the request. The bucket holds
+// up to burst tokens and is refilled with rate tokens per second. It is stored
+// in the remote cache as the time at which it is full again, which moves
+// forward by the refill interval of a token for every request. The time is
+// updated with compare and swap, so concurrent requests on any instance are
+// all counted without any locking. It returns whether the request is allowed,
+// the number of tokens left in the bucket and, when none are left, how long
+// until the next token is refilled.
+func (l *rateLimiter) take(ctx context.Context, key string, limit setting.RateLimit) (bool, int, time.Duration, error) {
+	if limit.Burst <= 0 || limit.Rate == 0 {
+		return false, 0, time.Second, nil
+	}
+
+	interval := int64(float64(time.Second) / limit.Rate)
+	if interval <= 0 {
+		interval = 1
+	}
+	capacity := int64(limit.Burst) * interval
+
+	// a missing bucket is full
+	var full int64
+	for attempt := 0; attempt < maxTakeAttempts; attempt++ {
+		now := l.now().UnixNano()
+		next := max(full, now) + interval
+		if next-now > capacity {
+			return false, 0, time.Duration(next - now - capacity), nil
+		}
+
+		// The bucket is not needed anymore once it is full again.
+		swapped, value, err := l.cache.CompareAndSwap(ctx, key, full, next, time.Duration(next-now))
+		if err != nil {
+			return false, 0, 0, err
+		}
+		if swapped {
+			return true, int((capacity - (next - now)) / interval), 0, nil
+		}
+		full = value
+	}
+
+	// The bucket is taken from by too many concurrent requests.
+	return false, 0, time.Duration(interval), nil
+}
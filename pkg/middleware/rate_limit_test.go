@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestMiddlewareRateLimit(t *testing.T) {
+	configureRateLimit := func(cfg *setting.Cfg) {
+		cfg.RateLimiting = setting.RateLimitSettings{
+			Enabled: true,
+			KeyBy:   setting.RateLimitKeyIdentity,
+			Query:   setting.RateLimitBudget{Default: setting.RateLimit{Rate: 0.001, Burst: 1}},
+			Write:   setting.RateLimitBudget{Default: setting.RateLimit{Rate: -1}},
+			Read: setting.RateLimitBudget{
+				Default: setting.RateLimit{Rate: 0.001, Burst: 2},
+				Roles:   map[string]setting.RateLimit{"admin": {Rate: -1}},
+			},
+		}
+	}
+
+	middlewareScenario(t, "requests over the burst are rejected", func(t *testing.T, sc *scenarioContext) {
+		sc.withIdentity(&authn.Identity{ID: "user:1", OrgID: 1, OrgRoles: map[int64]org.RoleType{1: org.RoleViewer}})
+		sc.m.Get("/api/search", RateLimit(sc.cfg, remotecache.NewFakeCacheStorage()), sc.defaultHandler)
+
+		sc.fakeReq("GET", "/api/search").exec()
+		assert.Equal(t, 200, sc.resp.Code)
+		assert.Equal(t, "1", sc.resp.Header().Get("X-RateLimit-Remaining"))
+		sc.fakeReq("GET", "/api/search").exec()
+		assert.Equal(t, 200, sc.resp.Code)
+		sc.fakeReq("GET", "/api/search").exec()
+		assert.Equal(t, 429, sc.resp.Code)
+		assert.NotEmpty(t, sc.resp.Header().Get("Retry-After"))
+	}, configureRateLimit)
+
+	middlewareScenario(t, "query and read budgets are separate", func(t *testing.T, sc *scenarioContext) {
+		sc.withIdentity(&authn.Identity{ID: "user:1", OrgID: 1, OrgRoles: map[int64]org.RoleType{1: org.RoleViewer}})
+		limiter := RateLimit(sc.cfg, remotecache.NewFakeCacheStorage())
+		sc.m.Get("/api/search", limiter, sc.defaultHandler)
+		sc.m.Post("/api/ds/query", limiter, sc.defaultHandler)
+
+		sc.fakeReq("POST", "/api/ds/query").exec()
+		assert.Equal(t, 200, sc.resp.Code)
+		sc.fakeReq("POST", "/api/ds/query").exec()
+		assert.Equal(t, 429, sc.resp.Code)
+		sc.fakeReq("GET", "/api/search").exec()
+		assert.Equal(t, 200, sc.resp.Code)
+	}, configureRateLimit)
+
+	middlewareScenario(t, "limit can be disabled for a role", func(t *testing.T, sc *scenarioContext) {
+		sc.withIdentity(&authn.Identity{ID: "user:1", OrgID: 1, OrgRoles: map[int64]org.RoleType{1: org.RoleAdmin}})
+		sc.m.Get("/api/search", RateLimit(sc.cfg, remotecache.NewFakeCacheStorage()), sc.defaultHandler)
+
+		for i := 0; i < 5; i++ {
+			sc.fakeReq("GET", "/api/search").exec()
+			assert.Equal(t, 200, sc.resp.Code)
+		}
+		assert.Empty(t, sc.resp.Header().Get("X-RateLimit-Limit"))
+	}, configureRateLimit)
+
+	middlewareScenario(t, "identities have their own bucket", func(t *testing.T, sc *scenarioContext) {
+		sc.m.Get("/api/search", RateLimit(sc.cfg, remotecache.NewFakeCacheStorage()), sc.defaultHandler)
+
+		sc.withIdentity(&authn.Identity{ID: "service-account:1", OrgID: 1, OrgRoles: map[int64]org.RoleType{1: org.RoleViewer}})
+		for i := 0; i < 2; i++ {
+			sc.fakeReq("GET", "/api/search").exec()
+			assert.Equal(t, 200, sc.resp.Code)
+		}
+		sc.fakeReq("GET", "/api/search").exec()
+		assert.Equal(t, 429, sc.resp.Code)
+
+		sc.withIdentity(&authn.Identity{ID: "api-key:2", OrgID: 1, OrgRoles: map[int64]org.RoleType{1: org.RoleViewer}})
+		sc.fakeReq("GET", "/api/search").exec()
+		assert.Equal(t, 200, sc.resp.Code)
+	}, configureRateLimit)
+
+	middlewareScenario(t, "tokens of a service account have their own bucket", func(t *testing.T, sc *scenarioContext) {
+		sc.m.Get("/api/search", RateLimit(sc.cfg, remotecache.NewFakeCacheStorage()), sc.defaultHandler)
+		sc.withIdentity(&authn.Identity{ID: "service-account:1", OrgID: 1, OrgRoles: map[int64]org.RoleType{1: org.RoleViewer}})
+
+		request := func(token string) int {
+			sc.fakeReq("GET", "/api/search")
+			sc.req.Header.Set("Authorization", "Bearer "+token)
+			sc.exec()
+			return sc.resp.Code
+		}
+
+		assert.Equal(t, 200, request("glsa_first"))
+		assert.Equal(t, 200, request("glsa_first"))
+		assert.Equal(t, 429, request("glsa_first"))
+		assert.Equal(t, 200, request("glsa_second"))
+	}, configureRateLimit)
+
+	middlewareScenario(t, "spoofed X-Real-IP headers do not get a new bucket", func(t *testing.T, sc *scenarioContext) {
+		sc.cfg.RateLimiting.KeyBy = setting.RateLimitKeyIP
+		sc.m.Get("/api/search", RateLimit(sc.cfg, remotecache.NewFakeCacheStorage()), sc.defaultHandler)
+
+		for i := 0; i < 3; i++ {
+			sc.fakeReq("GET", "/api/search")
+			sc.req.RemoteAddr = "203.0.113.7:51299"
+			sc.req.Header.Set("X-Real-IP", fmt.Sprintf("192.168.1.%d", i))
+			sc.exec()
+		}
+		assert.Equal(t, 429, sc.resp.Code)
+	}, configureRateLimit)
+}
+
+func TestRateLimiter_Take(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := &rateLimiter{
+		cache: remotecache.NewFakeCacheStorage(),
+		log:   log.NewNopLogger(),
+		now:   func() time.Time { return now },
+	}
+	limit := setting.RateLimit{Rate: 1, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		allowed, _, _, err := l.take(context.Background(), "key", limit)
+		require.NoError(t, err)
+		require.True(t, allowed)
+	}
+
+	allowed, remaining, retryAfter, err := l.take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.Equal(t, 0, remaining)
+	require.Equal(t, time.Second, retryAfter)
+
+	// tokens are refilled continuously
+	now = now.Add(500 * time.Millisecond)
+	allowed, _, retryAfter, err = l.take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	require.Equal(t, 500*time.Millisecond, retryAfter)
+
+	now = now.Add(500 * time.Millisecond)
+	allowed, remaining, _, err = l.take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	require.Equal(t, 0, remaining)
+
+	// the bucket does not hold more than burst tokens
+	now = now.Add(time.Hour)
+	for i := 1; i >= 0; i-- {
+		allowed, remaining, _, err = l.take(context.Background(), "key", limit)
+		require.NoError(t, err)
+		require.True(t, allowed)
+		require.Equal(t, i, remaining)
+	}
+	allowed, _, _, err = l.take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.False(t, allowed)
+}
+
+func TestRateLimiter_TakeConcurrentlyChanged(t *testing.T) {
+	now := time.Unix(1000, 0)
+	cache := remotecache.NewFakeCacheStorage()
+	l := &rateLimiter{
+		cache: cache,
+		log:   log.NewNopLogger(),
+		now:   func() time.Time { return now },
+	}
+	limit := setting.RateLimit{Rate: 1, Burst: 2}
+
+	// another instance has taken a token
+	_, _, err := cache.CompareAndSwap(context.Background(), "key", 0, now.Add(time.Second).UnixNano(), time.Second)
+	require.NoError(t, err)
+
+	allowed, remaining, _, err := l.take(context.Background(), "key", limit)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	require.Equal(t, 0, remaining)
+}
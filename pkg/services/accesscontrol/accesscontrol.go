@@ -3,6 +3,7 @@ package accesscontrol
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/grafana/grafana/pkg/registry"
@@ -207,6 +208,51 @@ func GroupScopesByAction(permissions []Permission) map[string][]string {
 	return m
 }
 
+// RestrictPermissions returns the intersection of permissions and restriction, both grouped by action.
+// An action in restriction without scopes keeps all the scopes of the action in permissions.
+// Otherwise a scope is kept when it is covered by a scope of the restriction, and a restricting
+// scope is kept when it is covered by one of the scopes in permissions.
+func RestrictPermissions(permissions map[string][]string, restriction map[string][]string) map[string][]string {
+	restricted := make(map[string][]string)
+	for action, allowed := range restriction {
+		granted, ok := permissions[action]
+		if !ok {
+			continue
+		}
+
+		if len(allowed) == 0 {
+			restricted[action] = granted
+			continue
+		}
+
+		scopes := make(map[string]bool)
+		for _, g := range granted {
+			if g == "" {
+				// unscoped permissions cannot be narrowed down further
+				scopes[g] = true
+				continue
+			}
+			for _, a := range allowed {
+				if match(a, g) {
+					scopes[g] = true
+				} else if match(g, a) {
+					scopes[a] = true
+				}
+			}
+		}
+
+		if len(scopes) == 0 {
+			continue
+		}
+		restricted[action] = make([]string, 0, len(scopes))
+		for scope := range scopes {
+			restricted[action] = append(restricted[action], scope)
+		}
+		sort.Strings(restricted[action])
+	}
+	return restricted
+}
+
 // Reduce will reduce a list of permissions to its minimal form, grouping scopes by action
 func Reduce(ps []Permission) map[string][]string {
 	reduced := make(map[string][]string)
@@ -333,3 +333,54 @@ func Test_intersectScopes(t *testing.T) {
 		})
 	}
 }
+
+func TestRestrictPermissions(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions map[string][]string
+		restriction map[string][]string
+		want        map[string][]string
+	}{
+		{
+			name:        "action without scopes keeps all granted scopes",
+			permissions: map[string][]string{"dashboards:read": {"dashboards:uid:1", "folders:uid:2"}},
+			restriction: map[string][]string{"dashboards:read": {}},
+			want:        map[string][]string{"dashboards:read": {"dashboards:uid:1", "folders:uid:2"}},
+		},
+		{
+			name:        "actions missing from the restriction are dropped",
+			permissions: map[string][]string{"dashboards:read": {"dashboards:*"}, "dashboards:write": {"dashboards:*"}},
+			restriction: map[string][]string{"dashboards:read": {}},
+			want:        map[string][]string{"dashboards:read": {"dashboards:*"}},
+		},
+		{
+			name:        "actions that are not granted cannot be added",
+			permissions: map[string][]string{"dashboards:read": {"dashboards:*"}},
+			restriction: map[string][]string{"users:read": {}},
+			want:        map[string][]string{},
+		},
+		{
+			name:        "restricting scope narrows down a wildcard",
+			permissions: map[string][]string{"dashboards:read": {"dashboards:*"}},
+			restriction: map[string][]string{"dashboards:read": {"dashboards:uid:1"}},
+			want:        map[string][]string{"dashboards:read": {"dashboards:uid:1"}},
+		},
+		{
+			name:        "restricting wildcard keeps the granted scopes it covers",
+			permissions: map[string][]string{"dashboards:read": {"dashboards:uid:1", "folders:uid:2"}},
+			restriction: map[string][]string{"dashboards:read": {"dashboards:*"}},
+			want:        map[string][]string{"dashboards:read": {"dashboards:uid:1"}},
+		},
+		{
+			name:        "restricting scope outside of the granted ones is dropped",
+			permissions: map[string][]string{"dashboards:read": {"dashboards:uid:1"}},
+			restriction: map[string][]string{"dashboards:read": {"dashboards:uid:2"}},
+			want:        map[string][]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, RestrictPermissions(tt.permissions, tt.restriction))
+		})
+	}
+}
@@ -128,13 +128,35 @@ func testIntegrationApiKeyDataAccess(t *testing.T, fn getStore) {
 
 			assert.Nil(t, key.LastUsedAt)
 
-			err = ss.UpdateAPIKeyLastUsedDate(context.Background(), key.ID)
+			err = ss.UpdateAPIKeyLastUsedDate(context.Background(), key.ID, "10.0.0.1")
 			require.NoError(t, err)
 
 			query := apikey.GetByNameQuery{KeyName: "last-update-at", OrgID: 1}
 			key, err = ss.GetApiKeyByName(context.Background(), &query)
 			assert.Nil(t, err)
 			assert.NotNil(t, key.LastUsedAt)
+			require.NotNil(t, key.LastUsedIP)
+			assert.Equal(t, "10.0.0.1", *key.LastUsedIP)
+		})
+
+		t.Run("Add a key with permissions and allowed CIDRs", func(t *testing.T) {
+			cmd := apikey.AddCommand{
+				OrgID: 1, Name: "restricted", Key: "restricted-key",
+				Permissions:  apikey.PermissionList{{Action: "dashboards:write", Scope: "folders:uid:ci"}},
+				AllowedCIDRs: apikey.CIDRList{"10.0.0.0/8"},
+			}
+			_, err := ss.AddAPIKey(context.Background(), &cmd)
+			require.NoError(t, err)
+
+			key, err := ss.GetAPIKeyByHash(context.Background(), "restricted-key")
+			require.NoError(t, err)
+			assert.Equal(t, cmd.Permissions, key.Permissions)
+			assert.Equal(t, cmd.AllowedCIDRs, key.AllowedCIDRs)
+
+			unrestricted, err := ss.GetApiKeyByName(context.Background(), &apikey.GetByNameQuery{KeyName: "last-update-at", OrgID: 1})
+			require.NoError(t, err)
+			assert.Empty(t, unrestricted.Permissions)
+			assert.Empty(t, unrestricted.AllowedCIDRs)
 		})
 
 		t.Run("Add a key with negative lifespan", func(t *testing.T) {
@@ -115,6 +115,7 @@ func (ss *sqlStore) AddAPIKey(ctx context.Context, cmd *apikey.AddCommand) (res
 		}
 
 		isRevoked := false
+		autoRotate := cmd.AutoRotate
 		t := apikey.APIKey{
 			OrgID:            cmd.OrgID,
 			Name:             cmd.Name,
@@ -125,6 +126,9 @@ func (ss *sqlStore) AddAPIKey(ctx context.Context, cmd *apikey.AddCommand) (res
 			Expires:          expires,
 			ServiceAccountId: cmd.ServiceAccountID,
 			IsRevoked:        &isRevoked,
+			Permissions:      cmd.Permissions,
+			AllowedCIDRs:     cmd.AllowedCIDRs,
+			AutoRotate:       &autoRotate,
 		}
 
 		if _, err := sess.Insert(&t); err != nil {
@@ -184,10 +188,16 @@ func (ss *sqlStore) GetAPIKeyByHash(ctx context.Context, hash string) (*apikey.A
 	return &key, err
 }
 
-func (ss *sqlStore) UpdateAPIKeyLastUsedDate(ctx context.Context, tokenID int64) error {
+func (ss *sqlStore) UpdateAPIKeyLastUsedDate(ctx context.Context, tokenID int64, clientIP string) error {
 	now := timeNow()
 	return ss.db.WithDbSession(ctx, func(sess *db.Session) error {
-		if _, err := sess.Table("api_key").ID(tokenID).Cols("last_used_at").Update(&apikey.APIKey{LastUsedAt: &now}); err != nil {
+		cols := []string{"last_used_at"}
+		update := &apikey.APIKey{LastUsedAt: &now}
+		if clientIP != "" {
+			cols = append(cols, "last_used_ip")
+			update.LastUsedIP = &clientIP
+		}
+		if _, err := sess.Table("api_key").ID(tokenID).Cols(cols...).Update(update); err != nil {
 			return err
 		}
 
@@ -1,7 +1,9 @@
 package apikey
 
 import (
+	"encoding/json"
 	"errors"
+	"net"
 	"time"
 
 	"github.com/grafana/grafana/pkg/services/auth/identity"
@@ -14,6 +16,7 @@ var (
 	ErrInvalid           = errors.New("invalid API key")
 	ErrInvalidExpiration = errors.New("negative value for SecondsToLive")
 	ErrDuplicate         = errors.New("API key, organization ID and name must be unique")
+	ErrInvalidCIDR       = errors.New("invalid CIDR range")
 )
 
 type APIKey struct {
@@ -28,18 +31,120 @@ type APIKey struct {
 	Expires          *int64       `db:"expires"`
 	ServiceAccountId *int64       `db:"service_account_id"`
 	IsRevoked        *bool        `xorm:"is_revoked" db:"is_revoked"`
+	// Permissions restricts the key to a subset of the permissions of its service account.
+	// An empty list means the key has all the permissions of the service account.
+	Permissions PermissionList `xorm:"permissions" db:"permissions"`
+	// AllowedCIDRs restricts the client addresses the key can be used from.
+	// An empty list means the key can be used from any address.
+	AllowedCIDRs CIDRList `xorm:"allowed_cidrs" db:"allowed_cidrs"`
+	LastUsedIP   *string  `xorm:"last_used_ip" db:"last_used_ip"`
+	// AutoRotate is true if Grafana rotates the key once it reaches the rotation interval.
+	AutoRotate *bool `xorm:"auto_rotate" db:"auto_rotate"`
+	// SuccessorID is the key that replaced the key in an automatic rotation.
+	SuccessorID *int64 `xorm:"successor_id" db:"successor_id"`
+	// SuccessorKey is the encrypted secret of the successor, until it has been fetched with the key.
+	SuccessorKey []byte `xorm:"successor_key" db:"successor_key"`
 }
 
 func (k APIKey) TableName() string { return "api_key" }
 
+// Permission is an action, and optionally a scope, a key is restricted to.
+type Permission struct {
+	Action string `json:"action"`
+	Scope  string `json:"scope,omitempty"`
+}
+
+// PermissionList is stored as JSON.
+type PermissionList []Permission
+
+func (l *PermissionList) FromDB(data []byte) error {
+	if len(data) == 0 {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(data, l)
+}
+
+func (l *PermissionList) ToDB() ([]byte, error) {
+	if l == nil || len(*l) == 0 {
+		return []byte{}, nil
+	}
+	return json.Marshal(l)
+}
+
+// GroupByAction returns the permissions grouped by action, in the format used by access control.
+func (l PermissionList) GroupByAction() map[string][]string {
+	grouped := make(map[string][]string, len(l))
+	for _, p := range l {
+		if _, ok := grouped[p.Action]; !ok {
+			grouped[p.Action] = []string{}
+		}
+		if p.Scope != "" {
+			grouped[p.Action] = append(grouped[p.Action], p.Scope)
+		}
+	}
+	return grouped
+}
+
+// CIDRList is stored as JSON.
+type CIDRList []string
+
+func (l *CIDRList) FromDB(data []byte) error {
+	if len(data) == 0 {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(data, l)
+}
+
+func (l *CIDRList) ToDB() ([]byte, error) {
+	if l == nil || len(*l) == 0 {
+		return []byte{}, nil
+	}
+	return json.Marshal(l)
+}
+
+// Validate returns ErrInvalidCIDR if one of the ranges cannot be parsed.
+func (l CIDRList) Validate() error {
+	for _, cidr := range l {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return ErrInvalidCIDR
+		}
+	}
+	return nil
+}
+
+// Allows returns true if ip is contained in one of the ranges, or if the list is empty.
+func (l CIDRList) Allows(ip net.IP) bool {
+	if len(l) == 0 {
+		return true
+	}
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range l {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 // swagger:model AddAPIKeyCommand
 type AddCommand struct {
-	Name             string       `json:"name" binding:"Required"`
-	Role             org.RoleType `json:"role" binding:"Required"`
-	OrgID            int64        `json:"-" xorm:"org_id"`
-	Key              string       `json:"-"`
-	SecondsToLive    int64        `json:"secondsToLive"`
-	ServiceAccountID *int64       `json:"-"`
+	Name             string         `json:"name" binding:"Required"`
+	Role             org.RoleType   `json:"role" binding:"Required"`
+	OrgID            int64          `json:"-" xorm:"org_id"`
+	Key              string         `json:"-"`
+	SecondsToLive    int64          `json:"secondsToLive"`
+	ServiceAccountID *int64         `json:"-"`
+	Permissions      PermissionList `json:"-"`
+	AllowedCIDRs     CIDRList       `json:"-"`
+	AutoRotate       bool           `json:"-"`
 }
 
 type DeleteCommand struct {
@@ -65,6 +65,42 @@ type RotateCommand struct {
 	UserAgent     string
 }
 
+// SearchSessionsQuery filters the active sessions of all the users.
+// Empty fields are ignored.
+type SearchSessionsQuery struct {
+	UserID int64
+	// Query matches the login, email or name of the user.
+	Query     string
+	ClientIP  string
+	UserAgent string
+	// SeenBefore and CreatedAfter are unix timestamps.
+	SeenBefore   int64
+	CreatedAfter int64
+	Page         int
+	Limit        int
+}
+
+// UserSession is an active user token with the user it belongs to.
+type UserSession struct {
+	*UserToken
+	Login string
+	Email string
+	Name  string
+}
+
+type SearchSessionsResult struct {
+	TotalCount int64
+	Sessions   []*UserSession
+	Page       int
+	PerPage    int
+}
+
+type RevokeSessionsCmd struct {
+	Query SearchSessionsQuery
+	// ExceptTokenID is kept, it is the session of the caller.
+	ExceptTokenID int64
+}
+
 // UserTokenService are used for generating and validating user tokens
 type UserTokenService interface {
 	CreateToken(ctx context.Context, user *user.User, clientIP net.IP, userAgent string) (*UserToken, error)
@@ -78,6 +114,10 @@ type UserTokenService interface {
 	GetUserTokens(ctx context.Context, userID int64) ([]*UserToken, error)
 	ActiveTokenCount(ctx context.Context, userID *int64) (int64, error)
 	GetUserRevokedTokens(ctx context.Context, userID int64) ([]*UserToken, error)
+	// SearchSessions returns the active sessions of all the users matching the query.
+	SearchSessions(ctx context.Context, query *SearchSessionsQuery) (*SearchSessionsResult, error)
+	// RevokeSessions revokes all the active sessions matching the query and returns their number.
+	RevokeSessions(ctx context.Context, cmd *RevokeSessionsCmd) (int64, error)
 }
 
 type UserTokenBackgroundService interface {
@@ -100,6 +100,10 @@ func (s *UserAuthTokenService) CreateToken(ctx context.Context, user *user.User,
 		return nil, err
 	}
 
+	if err := s.revokeExcessTokens(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
 	userAuthToken.UnhashedToken = token
 
 	ctxLogger := s.log.FromContext(ctx)
@@ -137,8 +141,9 @@ func (s *UserAuthTokenService) LookupToken(ctx context.Context, unhashedToken st
 	if model.RevokedAt > 0 {
 		ctxLogger.Debug("User token has been revoked", "userID", model.UserId, "tokenID", model.Id, "revokedAt", model.RevokedAt)
 		return nil, &auth.TokenRevokedError{
-			UserID:  model.UserId,
-			TokenID: model.Id,
+			UserID:                model.UserId,
+			TokenID:               model.Id,
+			MaxConcurrentSessions: int64(s.cfg.LoginMaxConcurrentSessions),
 		}
 	}
 
@@ -0,0 +1,205 @@
+package authimpl
+
+import (
+	"context"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/auth"
+)
+
+const (
+	defaultSessionsLimit = 100
+	maxSessionsLimit     = 1000
+	revokeBatchSize      = 500
+)
+
+type userSession struct {
+	Token userAuthToken `xorm:"extends"`
+	Login string
+	Email string
+	Name  string
+}
+
+// revokeExcessTokens revokes the oldest active tokens of the user, keeping at
+// most the configured number of concurrent sessions.
+func (s *UserAuthTokenService) revokeExcessTokens(ctx context.Context, userID int64) error {
+	if s.cfg.LoginMaxConcurrentSessions <= 0 {
+		return nil
+	}
+
+	return s.sqlStore.WithTransactionalDbSession(ctx, func(dbSession *db.Session) error {
+		var ids []int64
+		err := dbSession.Table("user_auth_token").Cols("id").
+			Where("user_id = ? AND created_at > ? AND rotated_at > ? AND revoked_at = 0", userID, s.createdAfterParam(), s.rotatedAfterParam()).
+			Desc("created_at", "id").
+			Find(&ids)
+		if err != nil {
+			return err
+		}
+
+		if len(ids) <= s.cfg.LoginMaxConcurrentSessions {
+			return nil
+		}
+
+		evicted := ids[s.cfg.LoginMaxConcurrentSessions:]
+		if err := revokeTokenIDs(dbSession, evicted); err != nil {
+			return err
+		}
+
+		s.log.FromContext(ctx).Debug("Revoked sessions over the concurrent sessions limit", "userID", userID, "count", len(evicted))
+		return nil
+	})
+}
+
+func (s *UserAuthTokenService) SearchSessions(ctx context.Context, query *auth.SearchSessionsQuery) (*auth.SearchSessionsResult, error) {
+	if query.Limit <= 0 {
+		query.Limit = defaultSessionsLimit
+	}
+	if query.Limit > maxSessionsLimit {
+		query.Limit = maxSessionsLimit
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+
+	result := &auth.SearchSessionsResult{
+		Sessions: []*auth.UserSession{},
+		Page:     query.Page,
+		PerPage:  query.Limit,
+	}
+
+	err := s.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
+		from, args := s.sessionsFilter(query)
+
+		var count int64
+		if _, err := dbSession.SQL("SELECT COUNT(*) "+from, args...).Get(&count); err != nil {
+			return err
+		}
+		result.TotalCount = count
+
+		dialect := s.sqlStore.GetDialect()
+		sql := "SELECT uat.*, u.login, u.email, u.name " + from +
+			" ORDER BY uat.rotated_at DESC, uat.id DESC " +
+			dialect.LimitOffset(int64(query.Limit), int64((query.Page-1)*query.Limit))
+
+		var rows []*userSession
+		if err := dbSession.SQL(sql, args...).Find(&rows); err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			var token auth.UserToken
+			if err := row.Token.toUserToken(&token); err != nil {
+				return err
+			}
+			result.Sessions = append(result.Sessions, &auth.UserSession{
+				UserToken: &token,
+				Login:     row.Login,
+				Email:     row.Email,
+				Name:      row.Name,
+			})
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+func (s *UserAuthTokenService) RevokeSessions(ctx context.Context, cmd *auth.RevokeSessionsCmd) (int64, error) {
+	var revoked int64
+	err := s.sqlStore.WithTransactionalDbSession(ctx, func(dbSession *db.Session) error {
+		from, args := s.sessionsFilter(&cmd.Query)
+		if cmd.ExceptTokenID > 0 {
+			from += " AND uat.id <> ?"
+			args = append(args, cmd.ExceptTokenID)
+		}
+
+		// MySQL cannot update a table it selects from in a subquery, the
+		// sessions are selected first.
+		var ids []int64
+		if err := dbSession.SQL("SELECT uat.id "+from, args...).Find(&ids); err != nil {
+			return err
+		}
+
+		if err := revokeTokenIDs(dbSession, ids); err != nil {
+			return err
+		}
+		revoked = int64(len(ids))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.log.FromContext(ctx).Info("Revoked sessions", "count", revoked, "userID", cmd.Query.UserID, "query", cmd.Query.Query, "clientIP", cmd.Query.ClientIP, "userAgent", cmd.Query.UserAgent)
+	return revoked, nil
+}
+
+// likeEscape is the escape character of the LIKE patterns built from user input. A backslash
+// would need escaping itself in MySQL string literals and SQLite has no default escape character.
+const likeEscape = " ESCAPE '!'"
+
+// likeEscaper makes the wildcards in user input match literally.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// sessionsFilter returns the FROM and WHERE clauses selecting the active sessions matching query.
+func (s *UserAuthTokenService) sessionsFilter(query *auth.SearchSessionsQuery) (string, []any) {
+	dialect := s.sqlStore.GetDialect()
+
+	var sql strings.Builder
+	sql.WriteString("FROM user_auth_token AS uat INNER JOIN " + dialect.Quote("user") + " AS u ON u.id = uat.user_id")
+	sql.WriteString(" WHERE uat.revoked_at = 0 AND uat.created_at > ? AND uat.rotated_at > ?")
+	args := []any{s.createdAfterParam(), s.rotatedAfterParam()}
+
+	if query.UserID > 0 {
+		sql.WriteString(" AND uat.user_id = ?")
+		args = append(args, query.UserID)
+	}
+	if query.Query != "" {
+		like := dialect.LikeStr()
+		sql.WriteString(" AND (u.login " + like + " ?" + likeEscape + " OR u.email " + like + " ?" + likeEscape + " OR u.name " + like + " ?" + likeEscape + ")")
+		q := "%" + likeEscaper.Replace(query.Query) + "%"
+		args = append(args, q, q, q)
+	}
+	if query.ClientIP != "" {
+		// a prefix matches all the addresses of a network, e.g. 10.0.
+		sql.WriteString(" AND uat.client_ip " + dialect.LikeStr() + " ?" + likeEscape)
+		args = append(args, likeEscaper.Replace(query.ClientIP)+"%")
+	}
+	if query.UserAgent != "" {
+		sql.WriteString(" AND uat.user_agent " + dialect.LikeStr() + " ?" + likeEscape)
+		args = append(args, "%"+likeEscaper.Replace(query.UserAgent)+"%")
+	}
+	if query.SeenBefore > 0 {
+		sql.WriteString(" AND uat.rotated_at < ? AND uat.seen_at < ?")
+		args = append(args, query.SeenBefore, query.SeenBefore)
+	}
+	if query.CreatedAfter > 0 {
+		sql.WriteString(" AND uat.created_at > ?")
+		args = append(args, query.CreatedAfter)
+	}
+
+	return sql.String(), args
+}
+
+func revokeTokenIDs(dbSession *db.Session, ids []int64) error {
+	now := getTime().Unix()
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > revokeBatchSize {
+			batch = ids[:revokeBatchSize]
+		}
+		ids = ids[len(batch):]
+
+		args := make([]any, 0, len(batch)+2)
+		args = append(args, "UPDATE user_auth_token SET revoked_at = ? WHERE id IN (?"+strings.Repeat(",?", len(batch)-1)+")", now)
+		for _, id := range batch {
+			args = append(args, id)
+		}
+		if _, err := dbSession.Exec(args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
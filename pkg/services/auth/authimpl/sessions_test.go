@@ -0,0 +1,146 @@
+package authimpl
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestIntegrationConcurrentSessions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := createTestContext(t)
+	ctx.tokenService.cfg.LoginMaxConcurrentSessions = 2
+	usr := &user.User{ID: int64(10)}
+
+	now := time.Date(2018, 12, 13, 13, 45, 0, 0, time.UTC)
+	getTime = func() time.Time { return now }
+	defer func() { getTime = time.Now }()
+
+	tokens := make([]*auth.UserToken, 0, 3)
+	for i := 0; i < 3; i++ {
+		token, err := ctx.tokenService.CreateToken(context.Background(), usr, net.ParseIP("192.168.10.11"), "some user agent")
+		require.NoError(t, err)
+		tokens = append(tokens, token)
+		now = now.Add(time.Minute)
+	}
+
+	active, err := ctx.tokenService.GetUserTokens(context.Background(), usr.ID)
+	require.NoError(t, err)
+	require.Len(t, active, 2)
+
+	_, err = ctx.tokenService.LookupToken(context.Background(), tokens[0].UnhashedToken)
+	var revokedErr *auth.TokenRevokedError
+	require.ErrorAs(t, err, &revokedErr)
+	assert.Equal(t, int64(2), revokedErr.MaxConcurrentSessions)
+
+	for _, token := range tokens[1:] {
+		_, err := ctx.tokenService.LookupToken(context.Background(), token.UnhashedToken)
+		require.NoError(t, err)
+	}
+}
+
+func TestIntegrationSearchAndRevokeSessions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	ctx := createTestContext(t)
+	alice := createSessionsTestUser(t, ctx.sqlstore, "alice")
+	bob := createSessionsTestUser(t, ctx.sqlstore, "bob")
+
+	aliceToken, err := ctx.tokenService.CreateToken(context.Background(), alice, net.ParseIP("10.0.0.1"), "Mozilla/5.0 Firefox/120.0")
+	require.NoError(t, err)
+	_, err = ctx.tokenService.CreateToken(context.Background(), alice, net.ParseIP("192.168.1.1"), "curl/8.0")
+	require.NoError(t, err)
+	_, err = ctx.tokenService.CreateToken(context.Background(), bob, net.ParseIP("10.0.0.2"), "curl/8.0")
+	require.NoError(t, err)
+
+	t.Run("search without filters returns all sessions", func(t *testing.T) {
+		result, err := ctx.tokenService.SearchSessions(context.Background(), &auth.SearchSessionsQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), result.TotalCount)
+		assert.Len(t, result.Sessions, 3)
+	})
+
+	t.Run("search filters by user, network and user agent", func(t *testing.T) {
+		result, err := ctx.tokenService.SearchSessions(context.Background(), &auth.SearchSessionsQuery{Query: "alice"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), result.TotalCount)
+		for _, s := range result.Sessions {
+			assert.Equal(t, "alice", s.Login)
+		}
+
+		result, err = ctx.tokenService.SearchSessions(context.Background(), &auth.SearchSessionsQuery{ClientIP: "10.0.", UserAgent: "curl"})
+		require.NoError(t, err)
+		require.Len(t, result.Sessions, 1)
+		assert.Equal(t, bob.ID, result.Sessions[0].UserId)
+	})
+
+	t.Run("search matches wildcards literally", func(t *testing.T) {
+		result, err := ctx.tokenService.SearchSessions(context.Background(), &auth.SearchSessionsQuery{Query: "%"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), result.TotalCount)
+
+		result, err = ctx.tokenService.SearchSessions(context.Background(), &auth.SearchSessionsQuery{UserAgent: "curl_8"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), result.TotalCount)
+	})
+
+	t.Run("search is paginated", func(t *testing.T) {
+		result, err := ctx.tokenService.SearchSessions(context.Background(), &auth.SearchSessionsQuery{Limit: 2, Page: 2})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), result.TotalCount)
+		assert.Len(t, result.Sessions, 1)
+	})
+
+	t.Run("search limits the page size", func(t *testing.T) {
+		result, err := ctx.tokenService.SearchSessions(context.Background(), &auth.SearchSessionsQuery{Limit: 100000})
+		require.NoError(t, err)
+		assert.Equal(t, maxSessionsLimit, result.PerPage)
+	})
+
+	t.Run("revoke keeps the session of the caller", func(t *testing.T) {
+		revoked, err := ctx.tokenService.RevokeSessions(context.Background(), &auth.RevokeSessionsCmd{
+			Query:         auth.SearchSessionsQuery{UserAgent: "Firefox"},
+			ExceptTokenID: aliceToken.Id,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), revoked)
+	})
+
+	t.Run("revoke matching sessions", func(t *testing.T) {
+		revoked, err := ctx.tokenService.RevokeSessions(context.Background(), &auth.RevokeSessionsCmd{
+			Query: auth.SearchSessionsQuery{UserAgent: "curl"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), revoked)
+
+		result, err := ctx.tokenService.SearchSessions(context.Background(), &auth.SearchSessionsQuery{})
+		require.NoError(t, err)
+		require.Len(t, result.Sessions, 1)
+		assert.Equal(t, aliceToken.Id, result.Sessions[0].Id)
+	})
+}
+
+func createSessionsTestUser(t *testing.T, store db.DB, login string) *user.User {
+	t.Helper()
+
+	usr := &user.User{UID: login, Login: login, Email: login + "@example.org", Name: login, OrgID: 1, Created: time.Now(), Updated: time.Now()}
+	err := store.WithDbSession(context.Background(), func(sess *db.Session) error {
+		_, err := sess.Insert(usr)
+		return err
+	})
+	require.NoError(t, err)
+	return usr
+}
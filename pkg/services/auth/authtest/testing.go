@@ -26,6 +26,8 @@ type FakeUserAuthTokenService struct {
 	GetUserTokensProvider        func(ctx context.Context, userID int64) ([]*auth.UserToken, error)
 	GetUserRevokedTokensProvider func(ctx context.Context, userID int64) ([]*auth.UserToken, error)
 	BatchRevokedTokenProvider    func(ctx context.Context, userIDs []int64) error
+	SearchSessionsProvider       func(ctx context.Context, query *auth.SearchSessionsQuery) (*auth.SearchSessionsResult, error)
+	RevokeSessionsProvider       func(ctx context.Context, cmd *auth.RevokeSessionsCmd) (int64, error)
 }
 
 func NewFakeUserAuthTokenService() *FakeUserAuthTokenService {
@@ -117,6 +119,14 @@ func (s *FakeUserAuthTokenService) BatchRevokeAllUserTokens(ctx context.Context,
 	return s.BatchRevokedTokenProvider(ctx, userIds)
 }
 
+func (s *FakeUserAuthTokenService) SearchSessions(ctx context.Context, query *auth.SearchSessionsQuery) (*auth.SearchSessionsResult, error) {
+	return s.SearchSessionsProvider(ctx, query)
+}
+
+func (s *FakeUserAuthTokenService) RevokeSessions(ctx context.Context, cmd *auth.RevokeSessionsCmd) (int64, error) {
+	return s.RevokeSessionsProvider(ctx, cmd)
+}
+
 type FakeOAuthTokenService struct {
 	passThruEnabled  bool
 	ExpectedAuthUser *login.UserAuth
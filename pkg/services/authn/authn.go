@@ -57,6 +57,9 @@ type ClientParams struct {
 	LookUpParams login.UserLookupParams
 	// SyncPermissions ensure that permissions are loaded from DB and added to the identity
 	SyncPermissions bool
+	// RestrictPermissions limits the permissions loaded by SyncPermissions to this subset, grouped by action.
+	// Nil means the identity gets all of its permissions.
+	RestrictPermissions map[string][]string
 }
 
 type PostAuthHookFn func(ctx context.Context, identity *Identity, r *Request) error
@@ -126,6 +129,27 @@ type LogoutClient interface {
 	Logout(ctx context.Context, user identity.Requester, info *login.UserAuth) (*Redirect, bool)
 }
 
+// SAMLClient is implemented by the SAML client. On top of the login and logout flows
+// it serves the service provider metadata and the single logout endpoint.
+type SAMLClient interface {
+	RedirectClient
+	LogoutClient
+	// IsEnabled returns true when SAML authentication is enabled, the settings can change at runtime.
+	IsEnabled() bool
+	// Metadata returns the XML metadata of the service provider.
+	Metadata(ctx context.Context) ([]byte, error)
+	// SingleLogout handles a logout request or a logout response sent by the identity provider.
+	SingleLogout(ctx context.Context, r *http.Request) (*SAMLLogout, error)
+}
+
+type SAMLLogout struct {
+	// NameID identifies the user the identity provider requested to log out.
+	// Empty when handling the response to a logout request sent by Grafana.
+	NameID string
+	// Redirect is where the user is sent after the logout.
+	Redirect *Redirect
+}
+
 type PasswordClient interface {
 	AuthenticatePassword(ctx context.Context, r *Request, username, password string) (*Identity, error)
 }
@@ -34,6 +34,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/signingkeys"
+	"github.com/grafana/grafana/pkg/services/team"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
@@ -73,6 +74,7 @@ func ProvideService(
 	socialService social.Service, cache *remotecache.RemoteCache,
 	ldapService service.LDAP, registerer prometheus.Registerer,
 	signingKeysService signingkeys.Service, oauthServer oauthserver.OAuth2Server,
+	samlClient authn.SAMLClient, teamService team.Service,
 ) *Service {
 	s := &Service{
 		log:             log.New("authn.service"),
@@ -90,7 +92,7 @@ func ProvideService(
 	usageStats.RegisterMetricsFunc(s.getUsageStats)
 
 	s.RegisterClient(clients.ProvideRender(userService, renderService))
-	s.RegisterClient(clients.ProvideAPIKey(apikeyService, userService))
+	s.RegisterClient(clients.ProvideAPIKey(cfg, apikeyService, userService))
 
 	if cfg.LoginCookieName != "" {
 		s.RegisterClient(clients.ProvideSession(cfg, sessionService, features))
@@ -144,15 +146,20 @@ func ProvideService(
 		s.RegisterClient(clients.ProvideOAuth(clientName, cfg, oauthTokenService, socialService))
 	}
 
+	// the SAML client is always registered, it can be enabled at runtime through the SSO settings
+	s.RegisterClient(samlClient)
+
 	// FIXME (jguer): move to User package
 	userSyncService := sync.ProvideUserSync(userService, userProtectionService, authInfoService, quotaService)
 	orgUserSyncService := sync.ProvideOrgSync(userService, orgService, accessControlService)
 	s.RegisterPostAuthHook(userSyncService.SyncUserHook, 10)
 	s.RegisterPostAuthHook(userSyncService.EnableUserHook, 20)
 	s.RegisterPostAuthHook(orgUserSyncService.SyncOrgRolesHook, 30)
+	s.RegisterPostAuthHook(sync.ProvideTeamSync(teamService).SyncExternalTeamsHook, 40)
 	s.RegisterPostAuthHook(userSyncService.SyncLastSeenHook, 130)
 	s.RegisterPostAuthHook(sync.ProvideOAuthTokenSync(oauthTokenService, sessionService, socialService).SyncOauthTokenHook, 60)
 	s.RegisterPostAuthHook(userSyncService.FetchSyncedUserHook, 100)
+	s.RegisterPostAuthHook(sync.ProvideSessionSync(cfg, sessionService).EnforceIdleTimeoutHook, 105)
 
 	rbacSync := sync.ProvideRBACSync(accessControlService)
 	if features.IsEnabledGlobally(featuremgmt.FlagCloudRBACRoles) {
@@ -359,6 +366,9 @@ func (s *Service) Logout(ctx context.Context, user identity.Requester, sessionTo
 	info, _ := s.authInfoService.GetAuthInfo(ctx, &login.GetAuthInfoQuery{UserId: userID})
 	if info != nil {
 		client := authn.ClientWithPrefix(strings.TrimPrefix(info.AuthModule, "oauth_"))
+		if info.AuthModule == login.SAMLAuthModule {
+			client = authn.ClientSAML
+		}
 
 		c, ok := s.clients[client]
 		if !ok {
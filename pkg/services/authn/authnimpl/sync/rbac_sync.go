@@ -43,7 +43,17 @@ func (s *RBACSync) SyncPermissionsHook(ctx context.Context, ident *authn.Identit
 	if ident.Permissions == nil {
 		ident.Permissions = make(map[int64]map[string][]string)
 	}
-	ident.Permissions[ident.OrgID] = accesscontrol.GroupScopesByAction(permissions)
+	grouped := accesscontrol.GroupScopesByAction(permissions)
+	if ident.ClientParams.RestrictPermissions != nil {
+		grouped = accesscontrol.RestrictPermissions(grouped, ident.ClientParams.RestrictPermissions)
+		// Routes guarded by org role checks such as reqOrgAdmin would otherwise still pass for the
+		// restricted identity, so it keeps only the permissions above and no basic role.
+		if ident.OrgRoles == nil {
+			ident.OrgRoles = make(map[int64]org.RoleType)
+		}
+		ident.OrgRoles[ident.OrgID] = org.RoleNone
+	}
+	ident.Permissions[ident.OrgID] = grouped
 	return nil
 }
 
@@ -51,6 +51,25 @@ func TestRBACSync_SyncPermission(t *testing.T) {
 	}
 }
 
+func TestRBACSync_SyncPermission_Restricted(t *testing.T) {
+	s := setupTestEnv()
+	ident := &authn.Identity{
+		ID:       "service-account:2",
+		OrgID:    1,
+		OrgRoles: map[int64]org.RoleType{1: org.RoleAdmin},
+		ClientParams: authn.ClientParams{
+			SyncPermissions:     true,
+			RestrictPermissions: map[string][]string{accesscontrol.ActionUsersRead: {}},
+		},
+	}
+
+	err := s.SyncPermissionsHook(context.Background(), ident, &authn.Request{})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{accesscontrol.ActionUsersRead: {""}}, ident.Permissions[1])
+	assert.Equal(t, org.RoleNone, ident.OrgRoles[1], "restricted identities must not pass org role checks")
+}
+
 func TestRBACSync_SyncCloudRoles(t *testing.T) {
 	type testCase struct {
 		desc           string
@@ -0,0 +1,78 @@
+package sync
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var timeNow = time.Now
+
+func ProvideSessionSync(cfg *setting.Cfg, sessionService auth.UserTokenService) *SessionSync {
+	return &SessionSync{
+		cfg:            cfg,
+		sessionService: sessionService,
+		log:            log.New("session.sync"),
+	}
+}
+
+type SessionSync struct {
+	cfg            *setting.Cfg
+	sessionService auth.UserTokenService
+	log            log.Logger
+}
+
+// EnforceIdleTimeoutHook revokes sessions that have been inactive for longer than
+// the inactive lifetime of the roles of the user. It must run after the user has been fetched.
+func (s *SessionSync) EnforceIdleTimeoutHook(ctx context.Context, ident *authn.Identity, _ *authn.Request) error {
+	if ident.SessionToken == nil || len(s.cfg.LoginMaxInactiveLifetimeByRole) == 0 {
+		return nil
+	}
+
+	timeout, ok := s.idleTimeout(ident)
+	if !ok {
+		return nil
+	}
+
+	token := ident.SessionToken
+	lastActive := token.RotatedAt
+	if token.SeenAt > lastActive {
+		lastActive = token.SeenAt
+	}
+	if timeNow().Sub(time.Unix(lastActive, 0)) <= timeout {
+		return nil
+	}
+
+	s.log.FromContext(ctx).Debug("Session is idle for longer than allowed for the role", "id", ident.ID, "tokenID", token.Id, "timeout", timeout)
+	if err := s.sessionService.RevokeToken(ctx, token, true); err != nil {
+		s.log.FromContext(ctx).Error("Failed to revoke idle session", "id", ident.ID, "tokenID", token.Id, "error", err)
+	}
+
+	return &auth.TokenRevokedError{UserID: token.UserId, TokenID: token.Id}
+}
+
+// idleTimeout returns the shortest inactive lifetime configured for the roles of the identity.
+func (s *SessionSync) idleTimeout(ident *authn.Identity) (time.Duration, bool) {
+	roles := []string{strings.ToLower(string(ident.GetOrgRole()))}
+	if ident.GetIsGrafanaAdmin() {
+		roles = append(roles, "grafana_admin")
+	}
+
+	var timeout time.Duration
+	found := false
+	for _, role := range roles {
+		t, ok := s.cfg.LoginMaxInactiveLifetimeByRole[role]
+		if !ok {
+			continue
+		}
+		if !found || t < timeout {
+			timeout, found = t, true
+		}
+	}
+	return timeout, found
+}
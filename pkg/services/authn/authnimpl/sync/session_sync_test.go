@@ -0,0 +1,86 @@
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/auth"
+	"github.com/grafana/grafana/pkg/services/auth/authtest"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestSessionSync_EnforceIdleTimeoutHook(t *testing.T) {
+	now := time.Date(2023, 11, 20, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	type testCase struct {
+		desc          string
+		identity      *authn.Identity
+		expectRevoked bool
+	}
+
+	isGrafanaAdmin := true
+	session := func(idle time.Duration) *auth.UserToken {
+		return &auth.UserToken{Id: 1, UserId: 1, RotatedAt: now.Add(-idle).Unix()}
+	}
+
+	tests := []testCase{
+		{
+			desc:     "should skip identities that are not authenticated with a session",
+			identity: &authn.Identity{ID: "user:1", OrgID: 1, OrgRoles: map[int64]org.RoleType{1: org.RoleAdmin}},
+		},
+		{
+			desc:     "should keep sessions of roles without idle timeout",
+			identity: &authn.Identity{ID: "user:1", OrgID: 1, OrgRoles: map[int64]org.RoleType{1: org.RoleViewer}, SessionToken: session(48 * time.Hour)},
+		},
+		{
+			desc:     "should keep sessions active recently",
+			identity: &authn.Identity{ID: "user:1", OrgID: 1, OrgRoles: map[int64]org.RoleType{1: org.RoleAdmin}, SessionToken: session(10 * time.Minute)},
+		},
+		{
+			desc:          "should revoke idle sessions",
+			identity:      &authn.Identity{ID: "user:1", OrgID: 1, OrgRoles: map[int64]org.RoleType{1: org.RoleAdmin}, SessionToken: session(2 * time.Hour)},
+			expectRevoked: true,
+		},
+		{
+			desc:          "should use the shortest timeout of grafana admins",
+			identity:      &authn.Identity{ID: "user:1", OrgID: 1, OrgRoles: map[int64]org.RoleType{1: org.RoleViewer}, IsGrafanaAdmin: &isGrafanaAdmin, SessionToken: session(20 * time.Minute)},
+			expectRevoked: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			revoked := false
+			sessionService := authtest.NewFakeUserAuthTokenService()
+			sessionService.RevokeTokenProvider = func(ctx context.Context, token *auth.UserToken, soft bool) error {
+				revoked = true
+				assert.True(t, soft)
+				return nil
+			}
+
+			cfg := setting.NewCfg()
+			cfg.LoginMaxInactiveLifetimeByRole = map[string]time.Duration{
+				"admin":         time.Hour,
+				"grafana_admin": 15 * time.Minute,
+			}
+			s := ProvideSessionSync(cfg, sessionService)
+
+			err := s.EnforceIdleTimeoutHook(context.Background(), tt.identity, &authn.Request{})
+			assert.Equal(t, tt.expectRevoked, revoked)
+			if tt.expectRevoked {
+				var revokedErr *auth.TokenRevokedError
+				require.ErrorAs(t, err, &revokedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package sync
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func ProvideTeamSync(teamService team.Service) *TeamSync {
+	return &TeamSync{teamService, log.New("team.sync")}
+}
+
+type TeamSync struct {
+	teamService team.Service
+	log         log.Logger
+}
+
+// SyncExternalTeamsHook adds the identity to the teams assigned by the identity provider
+// and removes it from the teams it was externally added to but is no longer assigned to.
+// Memberships added from Grafana are left untouched.
+func (s *TeamSync) SyncExternalTeamsHook(ctx context.Context, id *authn.Identity, _ *authn.Request) error {
+	if id.ExternalTeams == nil {
+		return nil
+	}
+
+	ctxLogger := s.log.FromContext(ctx)
+
+	namespace, identifier := id.GetNamespacedID()
+	if namespace != authn.NamespaceUser {
+		ctxLogger.Warn("Failed to sync teams, invalid namespace for identity", "id", id.ID, "namespace", namespace)
+		return nil
+	}
+
+	userID, err := identity.IntIdentifier(namespace, identifier)
+	if err != nil {
+		ctxLogger.Warn("Failed to sync teams, invalid ID for identity", "id", id.ID, "namespace", namespace, "err", err)
+		return nil
+	}
+
+	for orgID, teamNames := range id.ExternalTeams {
+		if err := s.syncOrgTeams(ctx, userID, orgID, teamNames); err != nil {
+			ctxLogger.Error("Failed to sync teams", "id", id.ID, "orgID", orgID, "error", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *TeamSync) syncOrgTeams(ctx context.Context, userID, orgID int64, teamNames []string) error {
+	wanted := make(map[int64]bool, len(teamNames))
+	for _, name := range teamNames {
+		result, err := s.teamService.SearchTeams(ctx, &team.SearchTeamsQuery{
+			OrgID:        orgID,
+			Name:         name,
+			Limit:        1,
+			Page:         1,
+			SignedInUser: teamReader(orgID),
+		})
+		if err != nil {
+			return err
+		}
+		if len(result.Teams) == 0 {
+			s.log.FromContext(ctx).Warn("Team assigned by the identity provider does not exist", "orgID", orgID, "team", name)
+			continue
+		}
+		wanted[result.Teams[0].ID] = true
+	}
+
+	memberships, err := s.teamService.GetUserTeamMemberships(ctx, orgID, userID, true)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range memberships {
+		if wanted[m.TeamID] {
+			delete(wanted, m.TeamID)
+			continue
+		}
+		if err := s.teamService.RemoveTeamMember(ctx, &team.RemoveTeamMemberCommand{OrgID: orgID, UserID: userID, TeamID: m.TeamID}); err != nil {
+			return err
+		}
+	}
+
+	for teamID := range wanted {
+		isMember, err := s.teamService.IsTeamMember(orgID, teamID, userID)
+		if err != nil {
+			return err
+		}
+		// the user was added to the team from Grafana, it is not an external membership
+		if isMember {
+			continue
+		}
+		// external members are added with the member permission (0), not as team admins
+		if err := s.teamService.AddTeamMember(userID, orgID, teamID, true, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// teamReader returns a requester allowed to look up all the teams of the org.
+func teamReader(orgID int64) *user.SignedInUser {
+	return &user.SignedInUser{
+		OrgID: orgID,
+		Permissions: map[int64]map[string][]string{
+			orgID: {accesscontrol.ActionTeamsRead: {accesscontrol.ScopeTeamsAll}},
+		},
+	}
+}
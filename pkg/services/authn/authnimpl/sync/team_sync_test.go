@@ -0,0 +1,115 @@
+package sync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/dashboards/dashboardaccess"
+	"github.com/grafana/grafana/pkg/services/team"
+	"github.com/grafana/grafana/pkg/services/team/teamtest"
+)
+
+type fakeTeamService struct {
+	*teamtest.FakeService
+	teams   map[string]int64
+	members map[int64]bool
+
+	added   []int64
+	removed []int64
+}
+
+func (f *fakeTeamService) SearchTeams(_ context.Context, query *team.SearchTeamsQuery) (team.SearchTeamQueryResult, error) {
+	result := team.SearchTeamQueryResult{}
+	if id, ok := f.teams[query.Name]; ok {
+		result.Teams = append(result.Teams, &team.TeamDTO{ID: id, Name: query.Name, OrgID: query.OrgID})
+	}
+	return result, nil
+}
+
+func (f *fakeTeamService) IsTeamMember(_, teamID, _ int64) (bool, error) {
+	return f.members[teamID], nil
+}
+
+func (f *fakeTeamService) AddTeamMember(_, _, teamID int64, isExternal bool, _ dashboardaccess.PermissionType) error {
+	if isExternal {
+		f.added = append(f.added, teamID)
+	}
+	return nil
+}
+
+func (f *fakeTeamService) RemoveTeamMember(_ context.Context, cmd *team.RemoveTeamMemberCommand) error {
+	f.removed = append(f.removed, cmd.TeamID)
+	return nil
+}
+
+func TestTeamSync_SyncExternalTeamsHook(t *testing.T) {
+	newService := func() *fakeTeamService {
+		return &fakeTeamService{
+			FakeService: &teamtest.FakeService{
+				// the user was externally added to the ops team before
+				ExpectedMembers: []*team.TeamMemberDTO{{OrgID: 1, TeamID: 3, External: true}},
+			},
+			teams:   map[string]int64{"backend": 1, "frontend": 2, "ops": 3},
+			members: map[int64]bool{2: true, 3: true},
+		}
+	}
+
+	t.Run("should add missing and remove stale external memberships", func(t *testing.T) {
+		teamService := newService()
+		s := ProvideTeamSync(teamService)
+
+		err := s.SyncExternalTeamsHook(context.Background(), &authn.Identity{
+			ID:            authn.NamespacedID(authn.NamespaceUser, 1),
+			ExternalTeams: map[int64][]string{1: {"backend", "frontend", "unknown"}},
+		}, nil)
+		require.NoError(t, err)
+
+		// frontend is already a membership added from Grafana
+		assert.Equal(t, []int64{1}, teamService.added)
+		assert.Equal(t, []int64{3}, teamService.removed)
+	})
+
+	t.Run("should keep memberships still assigned", func(t *testing.T) {
+		teamService := newService()
+		s := ProvideTeamSync(teamService)
+
+		err := s.SyncExternalTeamsHook(context.Background(), &authn.Identity{
+			ID:            authn.NamespacedID(authn.NamespaceUser, 1),
+			ExternalTeams: map[int64][]string{1: {"ops"}},
+		}, nil)
+		require.NoError(t, err)
+
+		assert.Empty(t, teamService.added)
+		assert.Empty(t, teamService.removed)
+	})
+
+	t.Run("should skip identities without external teams", func(t *testing.T) {
+		teamService := newService()
+		s := ProvideTeamSync(teamService)
+
+		err := s.SyncExternalTeamsHook(context.Background(), &authn.Identity{
+			ID: authn.NamespacedID(authn.NamespaceUser, 1),
+		}, nil)
+		require.NoError(t, err)
+
+		assert.Empty(t, teamService.added)
+		assert.Empty(t, teamService.removed)
+	})
+
+	t.Run("should skip service accounts", func(t *testing.T) {
+		teamService := newService()
+		s := ProvideTeamSync(teamService)
+
+		err := s.SyncExternalTeamsHook(context.Background(), &authn.Identity{
+			ID:            authn.NamespacedID(authn.NamespaceServiceAccount, 1),
+			ExternalTeams: map[int64][]string{1: {"backend"}},
+		}, nil)
+		require.NoError(t, err)
+
+		assert.Empty(t, teamService.added)
+	})
+}
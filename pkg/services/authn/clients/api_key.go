@@ -3,6 +3,7 @@ package clients
 import (
 	"context"
 	"errors"
+	"net"
 	"strings"
 	"time"
 
@@ -15,32 +16,37 @@ import (
 	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/util/errutil"
+	"github.com/grafana/grafana/pkg/web"
 )
 
 var (
-	errAPIKeyInvalid     = errutil.Unauthorized("api-key.invalid", errutil.WithPublicMessage("Invalid API key"))
-	errAPIKeyExpired     = errutil.Unauthorized("api-key.expired", errutil.WithPublicMessage("Expired API key"))
-	errAPIKeyRevoked     = errutil.Unauthorized("api-key.revoked", errutil.WithPublicMessage("Revoked API key"))
-	errAPIKeyOrgMismatch = errutil.Unauthorized("api-key.organization-mismatch", errutil.WithPublicMessage("API key does not belong to the requested organization"))
+	errAPIKeyInvalid      = errutil.Unauthorized("api-key.invalid", errutil.WithPublicMessage("Invalid API key"))
+	errAPIKeyExpired      = errutil.Unauthorized("api-key.expired", errutil.WithPublicMessage("Expired API key"))
+	errAPIKeyRevoked      = errutil.Unauthorized("api-key.revoked", errutil.WithPublicMessage("Revoked API key"))
+	errAPIKeyOrgMismatch  = errutil.Unauthorized("api-key.organization-mismatch", errutil.WithPublicMessage("API key does not belong to the requested organization"))
+	errAPIKeyIPNotAllowed = errutil.Unauthorized("api-key.ip-not-allowed", errutil.WithPublicMessage("API key cannot be used from this address"))
 )
 
 var _ authn.HookClient = new(APIKey)
 var _ authn.ContextAwareClient = new(APIKey)
 
-func ProvideAPIKey(apiKeyService apikey.Service, userService user.Service) *APIKey {
+func ProvideAPIKey(cfg *setting.Cfg, apiKeyService apikey.Service, userService user.Service) *APIKey {
 	return &APIKey{
-		log:           log.New(authn.ClientAPIKey),
-		userService:   userService,
-		apiKeyService: apiKeyService,
+		log:            log.New(authn.ClientAPIKey),
+		userService:    userService,
+		apiKeyService:  apiKeyService,
+		trustedProxies: cfg.TrustedProxies,
 	}
 }
 
 type APIKey struct {
-	log           log.Logger
-	userService   user.Service
-	apiKeyService apikey.Service
+	log            log.Logger
+	userService    user.Service
+	apiKeyService  apikey.Service
+	trustedProxies []*net.IPNet
 }
 
 func (s *APIKey) Name() string {
@@ -64,6 +70,10 @@ func (s *APIKey) Authenticate(ctx context.Context, r *authn.Request) (*authn.Ide
 		return nil, errAPIKeyRevoked.Errorf("Api key is revoked")
 	}
 
+	if clientIP := s.getClientIP(r); !apiKey.AllowedCIDRs.Allows(clientIP) {
+		return nil, errAPIKeyIPNotAllowed.Errorf("API key cannot be used from %s", clientIP)
+	}
+
 	if r.OrgID == 0 {
 		r.OrgID = apiKey.OrgID
 	} else if r.OrgID != apiKey.OrgID {
@@ -90,7 +100,12 @@ func (s *APIKey) Authenticate(ctx context.Context, r *authn.Request) (*authn.Ide
 		return nil, err
 	}
 
-	return authn.IdentityFromSignedInUser(authn.NamespacedID(authn.NamespaceServiceAccount, usr.UserID), usr, authn.ClientParams{SyncPermissions: true}, login.APIKeyAuthModule), nil
+	params := authn.ClientParams{SyncPermissions: true}
+	if len(apiKey.Permissions) > 0 {
+		params.RestrictPermissions = apiKey.Permissions.GroupByAction()
+	}
+
+	return authn.IdentityFromSignedInUser(authn.NamespacedID(authn.NamespaceServiceAccount, usr.UserID), usr, params, login.APIKeyAuthModule), nil
 }
 
 func (s *APIKey) getAPIKey(ctx context.Context, token string) (*apikey.APIKey, error) {
@@ -161,16 +176,21 @@ func (s *APIKey) Hook(ctx context.Context, identity *authn.Identity, r *authn.Re
 		return nil
 	}
 
-	go func(apikeyID int64) {
+	var clientIP string
+	if ip := s.getClientIP(r); ip != nil {
+		clientIP = ip.String()
+	}
+
+	go func(apikeyID int64, clientIP string) {
 		defer func() {
 			if err := recover(); err != nil {
 				s.log.Error("Panic during user last seen sync", "err", err)
 			}
 		}()
-		if err := s.apiKeyService.UpdateAPIKeyLastUsedDate(context.Background(), apikeyID); err != nil {
+		if err := s.apiKeyService.UpdateAPIKeyLastUsedDate(context.Background(), apikeyID, clientIP); err != nil {
 			s.log.Warn("Failed to update last use date for api key", "id", apikeyID)
 		}
-	}(id)
+	}(id, clientIP)
 
 	return nil
 }
@@ -201,6 +221,15 @@ func (s *APIKey) getAPIKeyID(ctx context.Context, identity *authn.Identity, r *a
 	return -1, false
 }
 
+// getClientIP returns the socket address of the request, or the forwarded client
+// address when the request was sent by one of the configured trusted proxies.
+func (s *APIKey) getClientIP(r *authn.Request) net.IP {
+	if r.HTTPRequest == nil {
+		return nil
+	}
+	return web.ClientIP(r.HTTPRequest, s.trustedProxies)
+}
+
 func looksLikeApiKey(token string) bool {
 	return token != ""
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 var (
@@ -109,6 +110,67 @@ func TestAPIKey_Authenticate(t *testing.T) {
 			},
 			expectedErr: errAPIKeyRevoked,
 		},
+		{
+			desc: "should restrict the permissions of a service account token",
+			req: &authn.Request{HTTPRequest: &http.Request{
+				Header: map[string][]string{
+					"Authorization": {"Bearer " + secret},
+				},
+			}},
+			expectedKey: &apikey.APIKey{
+				ID:               1,
+				OrgID:            1,
+				Key:              hash,
+				ServiceAccountId: intPtr(1),
+				Permissions:      apikey.PermissionList{{Action: "dashboards:read", Scope: "dashboards:uid:1"}},
+			},
+			expectedUser: &user.SignedInUser{
+				UserID:           1,
+				OrgID:            1,
+				IsServiceAccount: true,
+				OrgRole:          org.RoleViewer,
+				Name:             "test",
+			},
+			expectedIdentity: &authn.Identity{
+				ID:             "service-account:1",
+				OrgID:          1,
+				Name:           "test",
+				OrgRoles:       map[int64]org.RoleType{1: org.RoleViewer},
+				IsGrafanaAdmin: boolPtr(false),
+				ClientParams: authn.ClientParams{
+					SyncPermissions:     true,
+					RestrictPermissions: map[string][]string{"dashboards:read": {"dashboards:uid:1"}},
+				},
+				AuthenticatedBy: login.APIKeyAuthModule,
+			},
+		},
+		{
+			desc: "should fail for api key used outside of the allowed networks",
+			req: &authn.Request{HTTPRequest: &http.Request{
+				RemoteAddr: "192.168.1.10:4000",
+				Header:     map[string][]string{"Authorization": {"Bearer " + secret}},
+			}},
+			expectedKey: &apikey.APIKey{
+				Key:          hash,
+				AllowedCIDRs: apikey.CIDRList{"10.0.0.0/8"},
+			},
+			expectedErr: errAPIKeyIPNotAllowed,
+		},
+		{
+			desc: "should fail for api key used with a spoofed X-Real-IP header",
+			req: &authn.Request{HTTPRequest: &http.Request{
+				RemoteAddr: "192.168.1.10:4000",
+				Header: map[string][]string{
+					"Authorization": {"Bearer " + secret},
+					"X-Real-Ip":     {"10.0.0.1"},
+				},
+			}},
+			expectedKey: &apikey.APIKey{
+				Key:          hash,
+				AllowedCIDRs: apikey.CIDRList{"10.0.0.0/8"},
+			},
+			expectedErr: errAPIKeyIPNotAllowed,
+		},
 		{
 			desc: "should fail for api key in another organization",
 			req:  &authn.Request{OrgID: 1, HTTPRequest: &http.Request{Header: map[string][]string{"Authorization": {"Bearer " + secret}}}},
@@ -124,7 +186,7 @@ func TestAPIKey_Authenticate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
-			c := ProvideAPIKey(&apikeytest.Service{
+			c := ProvideAPIKey(setting.NewCfg(), &apikeytest.Service{
 				ExpectedAPIKey: tt.expectedKey,
 			}, &usertest.FakeUserService{
 				ExpectedSignedInUser: tt.expectedUser,
@@ -195,7 +257,7 @@ func TestAPIKey_Test(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
-			c := ProvideAPIKey(&apikeytest.Service{}, usertest.NewUserServiceFake())
+			c := ProvideAPIKey(setting.NewCfg(), &apikeytest.Service{}, usertest.NewUserServiceFake())
 			assert.Equal(t, tt.expected, c.Test(context.Background(), tt.req))
 		})
 	}
@@ -294,7 +356,7 @@ func TestAPIKey_GetAPIKeyIDFromIdentity(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
-			c := ProvideAPIKey(&apikeytest.Service{
+			c := ProvideAPIKey(setting.NewCfg(), &apikeytest.Service{
 				ExpectedError:  tt.expectedError,
 				ExpectedAPIKey: tt.expectedKey,
 			}, &usertest.FakeUserService{
@@ -0,0 +1,1022 @@
+package clients
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	"crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+	dsig "github.com/russellhaering/goxmldsig"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/ssosettings"
+	ssoModels "github.com/grafana/grafana/pkg/services/ssosettings/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/util/errutil"
+)
+
+const (
+	samlMetadataPath = "saml/metadata"
+	samlACSPath      = "saml/acs"
+	samlSLOPath      = "saml/slo"
+
+	samlRelayStateParam = "RelayState"
+	samlRequestParam    = "SAMLRequest"
+	samlResponseParam   = "SAMLResponse"
+	samlStateSeed       = "saml"
+
+	samlMetadataFetchTimeout = 10 * time.Second
+)
+
+var (
+	errSAMLClientDisabled   = errutil.BadRequest("auth.saml.disabled", errutil.WithPublicMessage("SAML client is disabled"))
+	errSAMLInternal         = errutil.Internal("auth.saml.internal", errutil.WithPublicMessage("An internal error occurred in the SAML client"))
+	errSAMLInvalidState     = errutil.Unauthorized("auth.saml.state.invalid", errutil.WithPublicMessage("SAML response was not requested by Grafana"))
+	errSAMLInvalidResponse  = errutil.Unauthorized("auth.saml.response.invalid", errutil.WithPublicMessage("Invalid SAML response"))
+	errSAMLMissingAttribute = errutil.Unauthorized("auth.saml.attribute.missing", errutil.WithPublicMessage("SAML assertion is missing the login and email attributes"))
+	errSAMLOrgNotAllowed    = errutil.Unauthorized("auth.saml.organization.not-allowed", errutil.WithPublicMessage("User is not a member of one of the allowed organizations"))
+	errSAMLInvalidLogout    = errutil.BadRequest("auth.saml.logout.invalid", errutil.WithPublicMessage("Invalid SAML logout message"))
+)
+
+var signatureAlgorithms = map[string]string{
+	"rsa-sha1":   dsig.RSASHA1SignatureMethod,
+	"rsa-sha256": dsig.RSASHA256SignatureMethod,
+	"rsa-sha512": dsig.RSASHA512SignatureMethod,
+}
+
+var _ authn.SAMLClient = new(SAML)
+var _ ssosettings.Reloadable = new(SAML)
+
+// samlInfo holds the SAML settings, as stored by the SSO settings service.
+type samlInfo struct {
+	Enabled           bool
+	Name              string
+	SingleLogout      bool
+	AllowSignUp       bool
+	AutoLogin         bool
+	AllowIDPInitiated bool
+	SkipOrgRoleSync   bool
+	ForceAuthn        bool
+
+	EntityID           string
+	Certificate        string
+	CertificatePath    string
+	PrivateKey         string
+	PrivateKeyPath     string
+	SignatureAlgorithm string
+	IDPMetadata        string
+	IDPMetadataPath    string
+	IDPMetadataURL     string
+	NameIDFormat       string
+
+	MaxIssueDelay         time.Duration
+	MetadataValidDuration time.Duration
+
+	AttributeName   string
+	AttributeLogin  string
+	AttributeEmail  string
+	AttributeGroups string
+	AttributeRole   string
+	AttributeOrg    string
+
+	AllowedOrganizations []string
+	OrgMapping           []samlMapping
+	TeamMapping          []samlMapping
+
+	RoleValuesNone         []string
+	RoleValuesViewer       []string
+	RoleValuesEditor       []string
+	RoleValuesAdmin        []string
+	RoleValuesGrafanaAdmin []string
+}
+
+// samlMapping maps an attribute value to an org and a role or a team name, e.g. Engineering:2:Editor.
+type samlMapping struct {
+	Value  string
+	OrgID  int64
+	Target string
+}
+
+func ProvideSAML(cfg *setting.Cfg, ssoSettings ssosettings.Service, features featuremgmt.FeatureToggles) *SAML {
+	c := &SAML{
+		cfg: cfg,
+		log: log.New(authn.ClientSAML),
+	}
+
+	settings, err := ssoSettings.GetForProvider(context.Background(), ssosettings.SAMLProviderName)
+	if err != nil {
+		c.log.Error("Failed to get SAML settings", "error", err)
+	} else if info, err := parseSAMLInfo(settings.Settings); err != nil {
+		c.log.Error("Failed to parse SAML settings", "error", err)
+	} else {
+		c.info = info
+		if info.MaxIssueDelay > saml.MaxIssueDelay {
+			c.log.Warn("SAML max issue delay is longer than the longest supported delay, assertions older than the supported delay are rejected", "maxIssueDelay", info.MaxIssueDelay, "supported", saml.MaxIssueDelay)
+		}
+	}
+
+	if features.IsEnabledGlobally(featuremgmt.FlagSsoSettingsApi) {
+		ssoSettings.RegisterReloadable(ssosettings.SAMLProviderName, c)
+	}
+
+	return c
+}
+
+type SAML struct {
+	cfg *setting.Cfg
+	log log.Logger
+
+	mu   sync.RWMutex
+	info *samlInfo
+	// sp is built lazily, the identity provider metadata may have to be fetched.
+	sp *saml.ServiceProvider
+}
+
+func (c *SAML) Name() string {
+	return authn.ClientSAML
+}
+
+func (c *SAML) IsEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.info != nil && c.info.Enabled
+}
+
+func (c *SAML) Validate(ctx context.Context, settings ssoModels.SSOSettings) error {
+	info, err := parseSAMLInfo(settings.Settings)
+	if err != nil {
+		return err
+	}
+	if !info.Enabled {
+		return nil
+	}
+	if info.MaxIssueDelay > saml.MaxIssueDelay {
+		return ssosettings.ErrInvalidSettings.Errorf("invalid SAML settings: max issue delay must be at most %s", saml.MaxIssueDelay)
+	}
+
+	if _, err := newServiceProvider(ctx, c.cfg, info); err != nil {
+		return ssosettings.ErrInvalidSettings.Errorf("invalid SAML settings: %w", err)
+	}
+	return nil
+}
+
+func (c *SAML) Reload(ctx context.Context, settings ssoModels.SSOSettings) error {
+	info, err := parseSAMLInfo(settings.Settings)
+	if err != nil {
+		return err
+	}
+
+	var sp *saml.ServiceProvider
+	if info.Enabled {
+		if sp, err = newServiceProvider(ctx, c.cfg, info); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.info, c.sp = info, sp
+	return nil
+}
+
+// serviceProvider returns the current settings and service provider, building the latter
+// when it has not been built yet.
+func (c *SAML) serviceProvider(ctx context.Context) (*samlInfo, *saml.ServiceProvider, error) {
+	c.mu.RLock()
+	info, sp := c.info, c.sp
+	c.mu.RUnlock()
+
+	if info == nil || !info.Enabled {
+		return nil, nil, errSAMLClientDisabled.Errorf("saml client is disabled")
+	}
+	if sp != nil {
+		return info, sp, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// the settings may have been reloaded in the meantime
+	if c.sp != nil {
+		return c.info, c.sp, nil
+	}
+
+	sp, err := newServiceProvider(ctx, c.cfg, c.info)
+	if err != nil {
+		return nil, nil, errSAMLInternal.Errorf("failed to configure saml service provider: %w", err)
+	}
+	c.sp = sp
+	return c.info, c.sp, nil
+}
+
+func (c *SAML) Authenticate(ctx context.Context, r *authn.Request) (*authn.Identity, error) {
+	r.SetMeta(authn.MetaKeyAuthModule, login.SAMLAuthModule)
+
+	info, sp, err := c.serviceProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.HTTPRequest.ParseForm(); err != nil {
+		return nil, errSAMLInvalidResponse.Errorf("failed to parse form: %w", err)
+	}
+
+	var possibleRequestIDs []string
+	if requestID, ok := c.verifyRelayState(r.HTTPRequest.Form.Get(samlRelayStateParam)); ok {
+		// The state cookie binds the relay state to the browser that started the login. It is
+		// written with SameSite=None, so that it is sent with the response posted by the identity provider.
+		stateCookie, err := r.HTTPRequest.Cookie(oauthStateCookieName)
+		if err != nil || stateCookie.Value == "" {
+			return nil, errSAMLInvalidState.Errorf("missing state cookie")
+		}
+		if !hmac.Equal([]byte(stateCookie.Value), []byte(hashOAuthState(requestID, c.cfg.SecretKey, samlStateSeed))) {
+			return nil, errSAMLInvalidState.Errorf("relay state did not match stored state")
+		}
+		possibleRequestIDs = []string{requestID}
+	} else if !info.AllowIDPInitiated {
+		return nil, errSAMLInvalidState.Errorf("invalid relay state and identity provider initiated login is not allowed")
+	}
+
+	assertion, err := sp.ParseResponse(r.HTTPRequest, possibleRequestIDs)
+	if err != nil {
+		var invalidErr *saml.InvalidResponseError
+		if errors.As(err, &invalidErr) {
+			err = invalidErr.PrivateErr
+		}
+		return nil, errSAMLInvalidResponse.Errorf("failed to validate saml response: %w", err)
+	}
+	if expires := assertion.IssueInstant.Add(info.MaxIssueDelay); expires.Before(saml.TimeNow()) {
+		return nil, errSAMLInvalidResponse.Errorf("failed to validate saml response: assertion expired on %s", expires)
+	}
+
+	return c.identityFromAssertion(info, assertion)
+}
+
+func (c *SAML) identityFromAssertion(info *samlInfo, assertion *saml.Assertion) (*authn.Identity, error) {
+	attrs := assertionAttributes(assertion)
+
+	var nameID string
+	if assertion.Subject != nil && assertion.Subject.NameID != nil {
+		nameID = assertion.Subject.NameID.Value
+	}
+
+	userLogin, email, name := attrs.first(info.AttributeLogin), attrs.first(info.AttributeEmail), attrs.first(info.AttributeName)
+	if userLogin == "" {
+		userLogin = email
+	}
+	if email == "" && strings.Contains(userLogin, "@") {
+		email = userLogin
+	}
+	if userLogin == "" {
+		return nil, errSAMLMissingAttribute.Errorf("assertion has neither %q nor %q attributes", info.AttributeLogin, info.AttributeEmail)
+	}
+
+	orgs := attrs.all(info.AttributeOrg)
+	if len(info.AllowedOrganizations) > 0 && !containsAny(info.AllowedOrganizations, orgs) {
+		return nil, errSAMLOrgNotAllowed.Errorf("user organizations %v are not allowed", orgs)
+	}
+
+	groups := attrs.all(info.AttributeGroups)
+	role, isGrafanaAdmin := mapSAMLRole(info, attrs.all(info.AttributeRole))
+
+	var orgRoles map[int64]org.RoleType
+	if !info.SkipOrgRoleSync {
+		if len(info.OrgMapping) > 0 {
+			orgRoles = mapSAMLOrgRoles(info.OrgMapping, orgs, role)
+		} else {
+			orgRoles, _, _ = getRoles(c.cfg, func() (org.RoleType, *bool, error) { return role, nil, nil })
+		}
+	}
+	if info.SkipOrgRoleSync || len(info.RoleValuesGrafanaAdmin) == 0 {
+		isGrafanaAdmin = nil
+	}
+
+	var externalTeams map[int64][]string
+	if len(info.TeamMapping) > 0 {
+		externalTeams = mapSAMLTeams(info.TeamMapping, groups)
+	}
+
+	lookupParams := login.UserLookupParams{Login: &userLogin}
+	if email != "" {
+		lookupParams.Email = &email
+	}
+
+	return &authn.Identity{
+		Login:           userLogin,
+		Name:            name,
+		Email:           email,
+		IsGrafanaAdmin:  isGrafanaAdmin,
+		AuthenticatedBy: login.SAMLAuthModule,
+		AuthID:          nameID,
+		Groups:          groups,
+		OrgRoles:        orgRoles,
+		ExternalTeams:   externalTeams,
+		ClientParams: authn.ClientParams{
+			SyncUser:        true,
+			SyncTeams:       true,
+			FetchSyncedUser: true,
+			SyncPermissions: true,
+			AllowSignUp:     info.AllowSignUp,
+			SyncOrgRoles:    len(orgRoles) > 0,
+			LookUpParams:    lookupParams,
+		},
+	}, nil
+}
+
+func (c *SAML) RedirectURL(ctx context.Context, r *authn.Request) (*authn.Redirect, error) {
+	_, sp, err := c.serviceProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	location := sp.GetSSOBindingLocation(saml.HTTPRedirectBinding)
+	if location == "" {
+		return nil, errSAMLInternal.Errorf("identity provider does not support the redirect binding")
+	}
+
+	req, err := sp.MakeAuthenticationRequest(location, saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		return nil, errSAMLInternal.Errorf("failed to create authentication request: %w", err)
+	}
+
+	u, err := req.Redirect(c.relayState(req.ID), sp)
+	if err != nil {
+		return nil, errSAMLInternal.Errorf("failed to create authentication request: %w", err)
+	}
+
+	return &authn.Redirect{
+		URL: u.String(),
+		Extra: map[string]string{
+			authn.KeyOAuthState: hashOAuthState(req.ID, c.cfg.SecretKey, samlStateSeed),
+		},
+	}, nil
+}
+
+func (c *SAML) Logout(ctx context.Context, user identity.Requester, info *login.UserAuth) (*authn.Redirect, bool) {
+	if !c.IsEnabled() {
+		return nil, false
+	}
+
+	samlInfo, sp, err := c.serviceProvider(ctx)
+	if err != nil {
+		c.log.FromContext(ctx).Error("Failed to get saml service provider", "error", err)
+		return nil, false
+	}
+
+	if !samlInfo.SingleLogout || info.AuthId == "" || sp.GetSLOBindingLocation(saml.HTTPRedirectBinding) == "" {
+		return nil, false
+	}
+
+	u, err := sp.MakeRedirectLogoutRequest(info.AuthId, "")
+	if err != nil {
+		namespace, id := user.GetNamespacedID()
+		c.log.FromContext(ctx).Error("Failed to create logout request", "namespace", namespace, "id", id, "error", err)
+		return nil, false
+	}
+
+	return &authn.Redirect{URL: u.String()}, true
+}
+
+func (c *SAML) Metadata(ctx context.Context) ([]byte, error) {
+	_, sp, err := c.serviceProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := xml.MarshalIndent(sp.Metadata(), "", "  ")
+	if err != nil {
+		return nil, errSAMLInternal.Errorf("failed to marshal metadata: %w", err)
+	}
+	return data, nil
+}
+
+func (c *SAML) SingleLogout(ctx context.Context, r *http.Request) (*authn.SAMLLogout, error) {
+	_, sp, err := c.serviceProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, errSAMLInvalidLogout.Errorf("failed to parse form: %w", err)
+	}
+
+	// response to a logout request sent by Grafana
+	if r.Form.Get(samlResponseParam) != "" {
+		if err := sp.ValidateLogoutResponseRequest(r); err != nil {
+			var invalidErr *saml.InvalidResponseError
+			if errors.As(err, &invalidErr) {
+				err = invalidErr.PrivateErr
+			}
+			return nil, errSAMLInvalidLogout.Errorf("invalid logout response: %w", err)
+		}
+		return &authn.SAMLLogout{Redirect: &authn.Redirect{URL: c.cfg.AppSubURL + "/login"}}, nil
+	}
+
+	// logout request sent by the identity provider
+	raw := r.Form.Get(samlRequestParam)
+	if raw == "" {
+		return nil, errSAMLInvalidLogout.Errorf("missing logout request")
+	}
+
+	data, err := decodeLogoutRequest(raw, r.Method == http.MethodGet)
+	if err != nil {
+		return nil, errSAMLInvalidLogout.Errorf("failed to parse logout request: %w", err)
+	}
+	if data, err = verifyLogoutRequest(sp, r, data); err != nil {
+		return nil, errSAMLInvalidLogout.Errorf("failed to verify logout request signature: %w", err)
+	}
+
+	var req saml.LogoutRequest
+	if err := xml.Unmarshal(data, &req); err != nil {
+		return nil, errSAMLInvalidLogout.Errorf("failed to parse logout request: %w", err)
+	}
+	if req.Issuer == nil || req.Issuer.Value != sp.IDPMetadata.EntityID {
+		return nil, errSAMLInvalidLogout.Errorf("logout request was not issued by the identity provider")
+	}
+	if req.NameID == nil || req.NameID.Value == "" {
+		return nil, errSAMLInvalidLogout.Errorf("logout request is missing the name id")
+	}
+
+	redirect := &authn.Redirect{URL: c.cfg.AppSubURL + "/login"}
+	if sp.GetSLOBindingLocation(saml.HTTPRedirectBinding) != "" {
+		u, err := sp.MakeRedirectLogoutResponse(req.ID, r.Form.Get(samlRelayStateParam))
+		if err != nil {
+			return nil, errSAMLInternal.Errorf("failed to create logout response: %w", err)
+		}
+		redirect.URL = u.String()
+	}
+
+	return &authn.SAMLLogout{NameID: req.NameID.Value, Redirect: redirect}, nil
+}
+
+// relayState binds the authentication request id to the relay state, so that responses
+// to requests that were not sent by Grafana are rejected.
+func (c *SAML) relayState(requestID string) string {
+	return requestID + "." + c.relayStateMAC(requestID)
+}
+
+func (c *SAML) verifyRelayState(relayState string) (string, bool) {
+	i := strings.LastIndex(relayState, ".")
+	if i <= 0 {
+		return "", false
+	}
+	requestID, mac := relayState[:i], relayState[i+1:]
+	return requestID, hmac.Equal([]byte(mac), []byte(c.relayStateMAC(requestID)))
+}
+
+func (c *SAML) relayStateMAC(requestID string) string {
+	h := hmac.New(sha256.New, []byte(c.cfg.SecretKey))
+	h.Write([]byte(requestID))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func newServiceProvider(ctx context.Context, cfg *setting.Cfg, info *samlInfo) (*saml.ServiceProvider, error) {
+	key, err := loadPrivateKey(info.PrivateKey, info.PrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := loadCertificate(info.Certificate, info.CertificatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	idpMetadata, err := loadIDPMetadata(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+
+	rootURL, err := url.Parse(cfg.AppURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid root url: %w", err)
+	}
+
+	sp := &saml.ServiceProvider{
+		EntityID:              info.EntityID,
+		Key:                   key,
+		Certificate:           cert,
+		MetadataURL:           *rootURL.JoinPath(samlMetadataPath),
+		AcsURL:                *rootURL.JoinPath(samlACSPath),
+		SloURL:                *rootURL.JoinPath(samlSLOPath),
+		IDPMetadata:           idpMetadata,
+		AuthnNameIDFormat:     saml.NameIDFormat(info.NameIDFormat),
+		MetadataValidDuration: info.MetadataValidDuration,
+		AllowIDPInitiated:     info.AllowIDPInitiated,
+		DefaultRedirectURI:    cfg.AppSubURL + "/",
+		LogoutBindings:        []string{saml.HTTPRedirectBinding, saml.HTTPPostBinding},
+	}
+	if info.ForceAuthn {
+		sp.ForceAuthn = &info.ForceAuthn
+	}
+	if info.SignatureAlgorithm != "" {
+		sp.SignatureMethod = signatureAlgorithms[info.SignatureAlgorithm]
+	}
+	if sp.AuthnNameIDFormat == "" {
+		sp.AuthnNameIDFormat = saml.UnspecifiedNameIDFormat
+	}
+
+	return sp, nil
+}
+
+func loadPrivateKey(inline, path string) (*rsa.PrivateKey, error) {
+	block, err := loadPEM("private key", inline, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not a RSA key")
+	}
+	return key, nil
+}
+
+func loadCertificate(inline, path string) (*x509.Certificate, error) {
+	block, err := loadPEM("certificate", inline, path)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// loadPEM reads a PEM block set inline, optionally base64 encoded, or from a file.
+func loadPEM(kind, inline, path string) (*pem.Block, error) {
+	data, err := loadSetting(kind, inline, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if block, _ := pem.Decode(data); block != nil {
+		return block, nil
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(string(data)); err == nil {
+		if block, _ := pem.Decode(decoded); block != nil {
+			return block, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not PEM encoded", kind)
+}
+
+func loadSetting(kind, inline, path string) ([]byte, error) {
+	switch {
+	case inline != "":
+		return []byte(strings.TrimSpace(inline)), nil
+	case path != "":
+		// nolint:gosec
+		// We can ignore the gosec G304 warning since the path is set by an admin
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", kind, err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("%s is not set", kind)
+	}
+}
+
+func loadIDPMetadata(ctx context.Context, info *samlInfo) (*saml.EntityDescriptor, error) {
+	if info.IDPMetadataURL != "" && info.IDPMetadata == "" && info.IDPMetadataPath == "" {
+		u, err := url.Parse(info.IDPMetadataURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid identity provider metadata url: %w", err)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, samlMetadataFetchTimeout)
+		defer cancel()
+
+		metadata, err := samlsp.FetchMetadata(ctx, &http.Client{Timeout: samlMetadataFetchTimeout}, *u)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch identity provider metadata: %w", err)
+		}
+		return metadata, nil
+	}
+
+	data, err := loadSetting("identity provider metadata", info.IDPMetadata, info.IDPMetadataPath)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte("<")) {
+		if decoded, err := base64.StdEncoding.DecodeString(string(data)); err == nil {
+			data = decoded
+		}
+	}
+
+	metadata, err := samlsp.ParseMetadata(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse identity provider metadata: %w", err)
+	}
+	return metadata, nil
+}
+
+// decodeLogoutRequest decodes a logout request, deflated with the redirect binding.
+func decodeLogoutRequest(raw string, deflated bool) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if deflated {
+		if data, err = io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(data)), 1<<20)); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+var signatureHashes = map[string]crypto.Hash{
+	dsig.RSASHA1SignatureMethod:   crypto.SHA1,
+	dsig.RSASHA256SignatureMethod: crypto.SHA256,
+	dsig.RSASHA512SignatureMethod: crypto.SHA512,
+}
+
+// verifyLogoutRequest checks that a logout request was signed with one of the signing certificates
+// of the identity provider, and returns the signed request. With the redirect binding the signature
+// is part of the query string, with the POST binding it is embedded in the request document.
+func verifyLogoutRequest(sp *saml.ServiceProvider, r *http.Request, data []byte) ([]byte, error) {
+	certs, err := idpSigningCertificates(sp.IDPMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Method == http.MethodGet {
+		if err := verifyQuerySignature(r.URL.RawQuery, certs); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil {
+		return nil, err
+	}
+	if doc.Root() == nil || doc.Root().FindElement("./Signature") == nil {
+		return nil, errors.New("logout request is not signed")
+	}
+
+	validationContext := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: certs})
+	validationContext.IdAttribute = "ID"
+	validated, err := validationContext.Validate(doc.Root())
+	if err != nil {
+		return nil, err
+	}
+
+	// only the element covered by the signature is used, not the rest of the document
+	signed := etree.NewDocument()
+	signed.SetRoot(validated)
+	return signed.WriteToBytes()
+}
+
+// verifyQuerySignature checks the signature of a message sent with the redirect binding. The signed
+// string is built from the parameters as they were encoded by the identity provider.
+func verifyQuerySignature(rawQuery string, certs []*x509.Certificate) error {
+	params := map[string]string{}
+	for _, param := range strings.Split(rawQuery, "&") {
+		name, _, _ := strings.Cut(param, "=")
+		if _, ok := params[name]; !ok {
+			params[name] = param
+		}
+	}
+
+	sigAlgParam, signatureParam := params["SigAlg"], params["Signature"]
+	if sigAlgParam == "" || signatureParam == "" {
+		return errors.New("logout request is not signed")
+	}
+
+	sigAlg, err := url.QueryUnescape(strings.TrimPrefix(sigAlgParam, "SigAlg="))
+	if err != nil {
+		return err
+	}
+	hash, ok := signatureHashes[sigAlg]
+	if !ok {
+		return fmt.Errorf("unsupported signature algorithm %q", sigAlg)
+	}
+
+	encoded, err := url.QueryUnescape(strings.TrimPrefix(signatureParam, "Signature="))
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	signed := params[samlRequestParam]
+	if relayState, ok := params[samlRelayStateParam]; ok {
+		signed += "&" + relayState
+	}
+	signed += "&" + sigAlgParam
+
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+	for _, cert := range certs {
+		if key, ok := cert.PublicKey.(*rsa.PublicKey); ok && rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil {
+			return nil
+		}
+	}
+	return errors.New("signature does not match the identity provider certificates")
+}
+
+// idpSigningCertificates returns the certificates the identity provider signs its messages with.
+func idpSigningCertificates(metadata *saml.EntityDescriptor) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for _, descriptor := range metadata.IDPSSODescriptors {
+		for _, key := range descriptor.KeyDescriptors {
+			if key.Use != "" && key.Use != "signing" {
+				continue
+			}
+			for _, c := range key.KeyInfo.X509Data.X509Certificates {
+				der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(c.Data), ""))
+				if err != nil {
+					return nil, fmt.Errorf("failed to decode identity provider certificate: %w", err)
+				}
+				cert, err := x509.ParseCertificate(der)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse identity provider certificate: %w", err)
+				}
+				certs = append(certs, cert)
+			}
+		}
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("identity provider metadata has no signing certificate")
+	}
+	return certs, nil
+}
+
+type samlAttributes map[string][]string
+
+// assertionAttributes indexes the assertion attributes by name and friendly name.
+func assertionAttributes(assertion *saml.Assertion) samlAttributes {
+	attrs := samlAttributes{}
+	for _, statement := range assertion.AttributeStatements {
+		for _, attr := range statement.Attributes {
+			values := make([]string, 0, len(attr.Values))
+			for _, v := range attr.Values {
+				values = append(values, v.Value)
+			}
+			attrs[attr.Name] = append(attrs[attr.Name], values...)
+			if attr.FriendlyName != "" && attr.FriendlyName != attr.Name {
+				attrs[attr.FriendlyName] = append(attrs[attr.FriendlyName], values...)
+			}
+		}
+	}
+	return attrs
+}
+
+func (a samlAttributes) first(name string) string {
+	if values := a[name]; name != "" && len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (a samlAttributes) all(name string) []string {
+	if name == "" {
+		return nil
+	}
+	return a[name]
+}
+
+// mapSAMLRole returns the highest role matching the role attribute values and whether
+// the user is a Grafana server admin.
+func mapSAMLRole(info *samlInfo, values []string) (org.RoleType, *bool) {
+	var role org.RoleType
+	switch {
+	case containsAny(info.RoleValuesAdmin, values):
+		role = org.RoleAdmin
+	case containsAny(info.RoleValuesEditor, values):
+		role = org.RoleEditor
+	case containsAny(info.RoleValuesViewer, values):
+		role = org.RoleViewer
+	case containsAny(info.RoleValuesNone, values):
+		role = org.RoleNone
+	}
+
+	isGrafanaAdmin := containsAny(info.RoleValuesGrafanaAdmin, values)
+	return role, &isGrafanaAdmin
+}
+
+// mapSAMLOrgRoles returns the roles of the user in the orgs mapped from its org attribute values.
+// The mapping role takes precedence over the role attribute, the user is a Viewer otherwise.
+func mapSAMLOrgRoles(mappings []samlMapping, orgs []string, role org.RoleType) map[int64]org.RoleType {
+	orgRoles := map[int64]org.RoleType{}
+	for _, m := range mappings {
+		if m.Value != "*" && !containsAny([]string{m.Value}, orgs) {
+			continue
+		}
+
+		mapped := org.RoleType(m.Target)
+		if !mapped.IsValid() {
+			mapped = role
+		}
+		if !mapped.IsValid() {
+			mapped = org.RoleViewer
+		}
+		if current, ok := orgRoles[m.OrgID]; !ok || mapped.Includes(current) {
+			orgRoles[m.OrgID] = mapped
+		}
+	}
+	return orgRoles
+}
+
+// mapSAMLTeams returns the teams of the user in every org of the team mapping, orgs without
+// matching groups have no teams so that the external memberships are removed.
+func mapSAMLTeams(mappings []samlMapping, groups []string) map[int64][]string {
+	teams := map[int64][]string{}
+	for _, m := range mappings {
+		if _, ok := teams[m.OrgID]; !ok {
+			teams[m.OrgID] = []string{}
+		}
+		if m.Value == "*" || containsAny([]string{m.Value}, groups) {
+			teams[m.OrgID] = append(teams[m.OrgID], m.Target)
+		}
+	}
+	return teams
+}
+
+func containsAny(allowed, values []string) bool {
+	for _, v := range values {
+		for _, a := range allowed {
+			if v == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parseSAMLInfo(settings map[string]any) (*samlInfo, error) {
+	info := &samlInfo{
+		Enabled:           settingBool(settings, "enabled"),
+		Name:              settingString(settings, "name"),
+		SingleLogout:      settingBool(settings, "single_logout"),
+		AllowSignUp:       settingBool(settings, "allow_sign_up"),
+		AutoLogin:         settingBool(settings, "auto_login"),
+		AllowIDPInitiated: settingBool(settings, "allow_idp_initiated"),
+		SkipOrgRoleSync:   settingBool(settings, "skip_org_role_sync"),
+		ForceAuthn:        settingBool(settings, "force_authn"),
+
+		EntityID:           settingString(settings, "entity_id"),
+		Certificate:        settingString(settings, "certificate"),
+		CertificatePath:    settingString(settings, "certificate_path"),
+		PrivateKey:         settingString(settings, "private_key"),
+		PrivateKeyPath:     settingString(settings, "private_key_path"),
+		SignatureAlgorithm: settingString(settings, "signature_algorithm"),
+		IDPMetadata:        settingString(settings, "idp_metadata"),
+		IDPMetadataPath:    settingString(settings, "idp_metadata_path"),
+		IDPMetadataURL:     settingString(settings, "idp_metadata_url"),
+		NameIDFormat:       settingString(settings, "name_id_format"),
+
+		AttributeName:   settingString(settings, "assertion_attribute_name"),
+		AttributeLogin:  settingString(settings, "assertion_attribute_login"),
+		AttributeEmail:  settingString(settings, "assertion_attribute_email"),
+		AttributeGroups: settingString(settings, "assertion_attribute_groups"),
+		AttributeRole:   settingString(settings, "assertion_attribute_role"),
+		AttributeOrg:    settingString(settings, "assertion_attribute_org"),
+
+		AllowedOrganizations:   util.SplitString(settingString(settings, "allowed_organizations")),
+		RoleValuesNone:         util.SplitString(settingString(settings, "role_values_none")),
+		RoleValuesViewer:       util.SplitString(settingString(settings, "role_values_viewer")),
+		RoleValuesEditor:       util.SplitString(settingString(settings, "role_values_editor")),
+		RoleValuesAdmin:        util.SplitString(settingString(settings, "role_values_admin")),
+		RoleValuesGrafanaAdmin: util.SplitString(settingString(settings, "role_values_grafana_admin")),
+	}
+
+	if info.Name == "" {
+		info.Name = "SAML"
+	}
+	if info.AttributeLogin == "" && info.AttributeEmail == "" {
+		return nil, ssosettings.ErrInvalidSettings.Errorf("assertion_attribute_login or assertion_attribute_email must be set")
+	}
+	if info.SignatureAlgorithm != "" {
+		if _, ok := signatureAlgorithms[info.SignatureAlgorithm]; !ok {
+			return nil, ssosettings.ErrInvalidSettings.Errorf("unsupported signature_algorithm %q, supported algorithms are rsa-sha1, rsa-sha256 and rsa-sha512", info.SignatureAlgorithm)
+		}
+	}
+
+	var err error
+	if info.MaxIssueDelay, err = settingDuration(settings, "max_issue_delay", 90*time.Second); err != nil {
+		return nil, err
+	}
+	if info.MetadataValidDuration, err = settingDuration(settings, "metadata_valid_duration", 48*time.Hour); err != nil {
+		return nil, err
+	}
+	if info.OrgMapping, err = parseSAMLMappings(settings, "org_mapping"); err != nil {
+		return nil, err
+	}
+	for _, m := range info.OrgMapping {
+		if m.Target != "" && !org.RoleType(m.Target).IsValid() {
+			return nil, ssosettings.ErrInvalidSettings.Errorf("invalid role %q in org_mapping", m.Target)
+		}
+	}
+	if info.TeamMapping, err = parseSAMLMappings(settings, "team_mapping"); err != nil {
+		return nil, err
+	}
+	for _, m := range info.TeamMapping {
+		if m.Target == "" {
+			return nil, ssosettings.ErrInvalidSettings.Errorf("missing team name in team_mapping for %q", m.Value)
+		}
+	}
+
+	return info, nil
+}
+
+// parseSAMLMappings parses a list of value:orgId[:target] mappings.
+func parseSAMLMappings(settings map[string]any, key string) ([]samlMapping, error) {
+	var mappings []samlMapping
+	for _, raw := range util.SplitString(settingString(settings, key)) {
+		parts := strings.SplitN(raw, ":", 3)
+		if len(parts) < 2 {
+			return nil, ssosettings.ErrInvalidSettings.Errorf("invalid %s %q, expected value:orgId[:target]", key, raw)
+		}
+
+		orgID, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || orgID <= 0 {
+			return nil, ssosettings.ErrInvalidSettings.Errorf("invalid org id in %s %q", key, raw)
+		}
+
+		m := samlMapping{Value: parts[0], OrgID: orgID}
+		if len(parts) == 3 {
+			m.Target = parts[2]
+		}
+		mappings = append(mappings, m)
+	}
+	return mappings, nil
+}
+
+func settingString(settings map[string]any, key string) string {
+	switch v := settings[key].(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func settingBool(settings map[string]any, key string) bool {
+	switch v := settings[key].(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	default:
+		return false
+	}
+}
+
+func settingDuration(settings map[string]any, key string, def time.Duration) (time.Duration, error) {
+	raw := settingString(settings, key)
+	if raw == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, ssosettings.ErrInvalidSettings.Errorf("invalid %s %q: %v", key, raw, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,557 @@
+package clients
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/crewjam/saml"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/ssosettings"
+	ssoModels "github.com/grafana/grafana/pkg/services/ssosettings/models"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type samlTestEnv struct {
+	client *SAML
+	idp    *saml.IdentityProvider
+}
+
+func (e *samlTestEnv) GetServiceProvider(_ *http.Request, _ string) (*saml.EntityDescriptor, error) {
+	data, err := e.client.Metadata(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var metadata saml.EntityDescriptor
+	return &metadata, xml.Unmarshal(data, &metadata)
+}
+
+func newSAMLTestEnv(t *testing.T, settings map[string]any) *samlTestEnv {
+	t.Helper()
+
+	idpKey, idpCert, _, _ := generateSAMLKeyPair(t)
+	_, _, spKeyPEM, spCertPEM := generateSAMLKeyPair(t)
+
+	idp := &saml.IdentityProvider{
+		Key:         idpKey,
+		Certificate: idpCert,
+		Logger:      nil,
+		MetadataURL: *mustParseURL("https://idp.example.com/metadata"),
+		SSOURL:      *mustParseURL("https://idp.example.com/sso"),
+		LogoutURL:   *mustParseURL("https://idp.example.com/slo"),
+	}
+	idpMetadata, err := xml.Marshal(idp.Metadata())
+	require.NoError(t, err)
+
+	values := map[string]any{
+		"enabled":                   true,
+		"allow_sign_up":             true,
+		"single_logout":             true,
+		"private_key":               spKeyPEM,
+		"certificate":               base64.StdEncoding.EncodeToString([]byte(spCertPEM)),
+		"idp_metadata":              string(idpMetadata),
+		"assertion_attribute_login": "uid",
+		"assertion_attribute_email": "eduPersonPrincipalName",
+		"assertion_attribute_name":  "cn",
+		"assertion_attribute_role":  "role",
+	}
+	for k, v := range settings {
+		values[k] = v
+	}
+
+	info, err := parseSAMLInfo(values)
+	require.NoError(t, err)
+
+	cfg := setting.NewCfg()
+	cfg.AppURL = "https://grafana.example.com/"
+	cfg.SecretKey = "secret"
+
+	env := &samlTestEnv{
+		client: &SAML{cfg: cfg, log: log.NewNopLogger(), info: info},
+		idp:    idp,
+	}
+	idp.ServiceProviderProvider = env
+	return env
+}
+
+// login goes through the authentication request and returns the response of the identity provider.
+func (e *samlTestEnv) login(t *testing.T, session *saml.Session) *http.Request {
+	t.Helper()
+
+	redirect, err := e.client.RedirectURL(context.Background(), &authn.Request{})
+	require.NoError(t, err)
+
+	idpReq, err := saml.NewIdpAuthnRequest(e.idp, httptest.NewRequest(http.MethodGet, redirect.URL, nil))
+	require.NoError(t, err)
+	require.NoError(t, idpReq.Validate())
+	require.NoError(t, saml.DefaultAssertionMaker{}.MakeAssertion(idpReq, session))
+
+	form, err := idpReq.PostBinding()
+	require.NoError(t, err)
+	require.Equal(t, "https://grafana.example.com/saml/acs", form.URL)
+
+	body := url.Values{samlResponseParam: {form.SAMLResponse}, samlRelayStateParam: {form.RelayState}}
+	req := httptest.NewRequest(http.MethodPost, form.URL, strings.NewReader(body.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: oauthStateCookieName, Value: redirect.Extra[authn.KeyOAuthState]})
+	return req
+}
+
+func TestSAML_Authenticate(t *testing.T) {
+	session := &saml.Session{
+		NameID:         "alice-id",
+		UserName:       "alice",
+		UserEmail:      "alice@example.com",
+		UserCommonName: "Alice",
+		CustomAttributes: []saml.Attribute{
+			{Name: "role", Values: []saml.AttributeValue{{Value: "grafana-editors"}}},
+			{Name: "groups", Values: []saml.AttributeValue{{Value: "developers"}, {Value: "oncall"}}},
+			{Name: "org", Values: []saml.AttributeValue{{Value: "Engineering"}}},
+		},
+	}
+
+	t.Run("should authenticate with a signed and encrypted assertion", func(t *testing.T) {
+		env := newSAMLTestEnv(t, map[string]any{
+			"role_values_editor":         "grafana-editors",
+			"assertion_attribute_groups": "groups",
+		})
+
+		r := &authn.Request{HTTPRequest: env.login(t, session)}
+		identity, err := env.client.Authenticate(context.Background(), r)
+		require.NoError(t, err)
+
+		assert.Equal(t, login.SAMLAuthModule, r.GetMeta(authn.MetaKeyAuthModule))
+		assert.Equal(t, "alice", identity.Login)
+		assert.Equal(t, "alice@example.com", identity.Email)
+		assert.Equal(t, "Alice", identity.Name)
+		assert.Equal(t, "alice-id", identity.AuthID)
+		assert.Equal(t, login.SAMLAuthModule, identity.AuthenticatedBy)
+		assert.Equal(t, []string{"developers", "oncall"}, identity.Groups)
+		assert.Equal(t, map[int64]org.RoleType{1: org.RoleEditor}, identity.OrgRoles)
+		assert.True(t, identity.ClientParams.SyncUser)
+		assert.True(t, identity.ClientParams.SyncOrgRoles)
+		assert.True(t, identity.ClientParams.AllowSignUp)
+	})
+
+	t.Run("should reject a response to another authentication request", func(t *testing.T) {
+		env := newSAMLTestEnv(t, nil)
+
+		req := env.login(t, session)
+		require.NoError(t, req.ParseForm())
+		req.Form.Set(samlRelayStateParam, env.client.relayState("id-other"))
+
+		_, err := env.client.Authenticate(context.Background(), &authn.Request{HTTPRequest: req})
+		assert.ErrorIs(t, err, errSAMLInvalidState)
+	})
+
+	t.Run("should reject a response without the state cookie", func(t *testing.T) {
+		env := newSAMLTestEnv(t, nil)
+
+		req := env.login(t, session)
+		req.Header.Del("Cookie")
+
+		_, err := env.client.Authenticate(context.Background(), &authn.Request{HTTPRequest: req})
+		assert.ErrorIs(t, err, errSAMLInvalidState)
+	})
+
+	t.Run("should reject a response with the state cookie of another login", func(t *testing.T) {
+		env := newSAMLTestEnv(t, nil)
+
+		req := env.login(t, session)
+		req.Header.Del("Cookie")
+		req.AddCookie(&http.Cookie{Name: oauthStateCookieName, Value: hashOAuthState("id-other", env.client.cfg.SecretKey, samlStateSeed)})
+
+		_, err := env.client.Authenticate(context.Background(), &authn.Request{HTTPRequest: req})
+		assert.ErrorIs(t, err, errSAMLInvalidState)
+	})
+
+	t.Run("should reject an unsolicited response when identity provider initiated login is not allowed", func(t *testing.T) {
+		env := newSAMLTestEnv(t, nil)
+
+		req := env.login(t, session)
+		require.NoError(t, req.ParseForm())
+		req.Form.Del(samlRelayStateParam)
+
+		_, err := env.client.Authenticate(context.Background(), &authn.Request{HTTPRequest: req})
+		assert.ErrorIs(t, err, errSAMLInvalidState)
+	})
+
+	t.Run("should reject a tampered response", func(t *testing.T) {
+		env := newSAMLTestEnv(t, nil)
+
+		req := env.login(t, session)
+		require.NoError(t, req.ParseForm())
+		raw, err := base64.StdEncoding.DecodeString(req.PostForm.Get(samlResponseParam))
+		require.NoError(t, err)
+		tampered := strings.Replace(string(raw), "https://idp.example.com/metadata", "https://evil.example.com/metadata", 1)
+		req.PostForm.Set(samlResponseParam, base64.StdEncoding.EncodeToString([]byte(tampered)))
+
+		_, err = env.client.Authenticate(context.Background(), &authn.Request{HTTPRequest: req})
+		assert.ErrorIs(t, err, errSAMLInvalidResponse)
+	})
+
+	t.Run("should reject an assertion older than the max issue delay of the settings", func(t *testing.T) {
+		env := newSAMLTestEnv(t, map[string]any{"max_issue_delay": "1ns"})
+
+		_, err := env.client.Authenticate(context.Background(), &authn.Request{HTTPRequest: env.login(t, session)})
+		assert.ErrorIs(t, err, errSAMLInvalidResponse)
+	})
+
+	t.Run("should fail when disabled", func(t *testing.T) {
+		env := newSAMLTestEnv(t, map[string]any{"enabled": false})
+
+		_, err := env.client.Authenticate(context.Background(), &authn.Request{HTTPRequest: httptest.NewRequest(http.MethodPost, "/saml/acs", nil)})
+		assert.ErrorIs(t, err, errSAMLClientDisabled)
+	})
+}
+
+func TestSAML_identityFromAssertion(t *testing.T) {
+	assertion := func(attrs map[string][]string) *saml.Assertion {
+		statement := saml.AttributeStatement{}
+		for name, values := range attrs {
+			attr := saml.Attribute{Name: name}
+			for _, v := range values {
+				attr.Values = append(attr.Values, saml.AttributeValue{Value: v})
+			}
+			statement.Attributes = append(statement.Attributes, attr)
+		}
+		return &saml.Assertion{
+			Subject:             &saml.Subject{NameID: &saml.NameID{Value: "name-id"}},
+			AttributeStatements: []saml.AttributeStatement{statement},
+		}
+	}
+
+	attrs := map[string][]string{
+		"mail":   {"bob@example.com"},
+		"role":   {"admins", "editors"},
+		"org":    {"Engineering", "Sales"},
+		"groups": {"backend"},
+	}
+
+	tests := []struct {
+		desc     string
+		settings map[string]any
+		attrs    map[string][]string
+
+		expectedErr           error
+		expectedLogin         string
+		expectedOrgRoles      map[int64]org.RoleType
+		expectedGrafanaAdmin  *bool
+		expectedExternalTeams map[int64][]string
+	}{
+		{
+			desc:             "should use the highest matching role",
+			settings:         map[string]any{"role_values_editor": "editors", "role_values_admin": "admins"},
+			attrs:            attrs,
+			expectedLogin:    "bob@example.com",
+			expectedOrgRoles: map[int64]org.RoleType{1: org.RoleAdmin},
+		},
+		{
+			desc:                 "should set grafana admin",
+			settings:             map[string]any{"role_values_grafana_admin": "admins"},
+			attrs:                attrs,
+			expectedLogin:        "bob@example.com",
+			expectedOrgRoles:     map[int64]org.RoleType{},
+			expectedGrafanaAdmin: boolPtr(true),
+		},
+		{
+			desc: "should map orgs",
+			settings: map[string]any{
+				"assertion_attribute_org": "org",
+				"role_values_editor":      "editors",
+				"org_mapping":             "Engineering:2, Sales:3:Viewer, Marketing:4:Admin, *:5:Viewer",
+			},
+			attrs:            attrs,
+			expectedLogin:    "bob@example.com",
+			expectedOrgRoles: map[int64]org.RoleType{2: org.RoleEditor, 3: org.RoleViewer, 5: org.RoleViewer},
+		},
+		{
+			desc: "should map teams",
+			settings: map[string]any{
+				"assertion_attribute_groups": "groups",
+				"team_mapping":               "backend:1:Backend, frontend:1:Frontend, frontend:2:Web",
+			},
+			attrs:                 attrs,
+			expectedLogin:         "bob@example.com",
+			expectedOrgRoles:      map[int64]org.RoleType{},
+			expectedExternalTeams: map[int64][]string{1: {"Backend"}, 2: {}},
+		},
+		{
+			desc:             "should skip org role sync",
+			settings:         map[string]any{"role_values_admin": "admins", "skip_org_role_sync": true},
+			attrs:            attrs,
+			expectedLogin:    "bob@example.com",
+			expectedOrgRoles: nil,
+		},
+		{
+			desc:        "should reject users outside of the allowed organizations",
+			settings:    map[string]any{"assertion_attribute_org": "org", "allowed_organizations": "Support"},
+			attrs:       attrs,
+			expectedErr: errSAMLOrgNotAllowed,
+		},
+		{
+			desc:        "should fail without login and email",
+			attrs:       map[string][]string{"role": {"admins"}},
+			expectedErr: errSAMLMissingAttribute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			settings := map[string]any{
+				"assertion_attribute_login": "login",
+				"assertion_attribute_email": "mail",
+				"assertion_attribute_role":  "role",
+			}
+			for k, v := range tt.settings {
+				settings[k] = v
+			}
+			info, err := parseSAMLInfo(settings)
+			require.NoError(t, err)
+
+			c := &SAML{cfg: setting.NewCfg(), log: log.NewNopLogger()}
+			identity, err := c.identityFromAssertion(info, assertion(tt.attrs))
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedLogin, identity.Login)
+			assert.Equal(t, "name-id", identity.AuthID)
+			assert.Equal(t, tt.expectedOrgRoles, identity.OrgRoles)
+			assert.Equal(t, tt.expectedGrafanaAdmin, identity.IsGrafanaAdmin)
+			assert.Equal(t, tt.expectedExternalTeams, identity.ExternalTeams)
+		})
+	}
+}
+
+func TestSAML_parseSAMLInfo(t *testing.T) {
+	tests := []struct {
+		desc     string
+		settings map[string]any
+		wantErr  bool
+	}{
+		{desc: "should accept valid settings", settings: map[string]any{"assertion_attribute_email": "mail", "org_mapping": "Engineering:2:Editor", "max_issue_delay": "2m"}},
+		{desc: "should require login or email attribute", settings: map[string]any{}, wantErr: true},
+		{desc: "should reject unknown signature algorithm", settings: map[string]any{"assertion_attribute_email": "mail", "signature_algorithm": "dsa-sha1"}, wantErr: true},
+		{desc: "should reject invalid org id", settings: map[string]any{"assertion_attribute_email": "mail", "org_mapping": "Engineering:main:Editor"}, wantErr: true},
+		{desc: "should reject invalid role", settings: map[string]any{"assertion_attribute_email": "mail", "org_mapping": "Engineering:2:Owner"}, wantErr: true},
+		{desc: "should reject team mapping without team", settings: map[string]any{"assertion_attribute_email": "mail", "team_mapping": "developers:2"}, wantErr: true},
+		{desc: "should reject invalid duration", settings: map[string]any{"assertion_attribute_email": "mail", "max_issue_delay": "soon"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := parseSAMLInfo(tt.settings)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSAML_Validate(t *testing.T) {
+	client := &SAML{cfg: setting.NewCfg(), log: log.NewNopLogger()}
+
+	err := client.Validate(context.Background(), ssoModels.SSOSettings{Settings: map[string]any{
+		"enabled":                   true,
+		"assertion_attribute_email": "mail",
+		"max_issue_delay":           "1h",
+	}})
+	assert.ErrorIs(t, err, ssosettings.ErrInvalidSettings)
+}
+
+func TestSAML_Metadata(t *testing.T) {
+	env := newSAMLTestEnv(t, map[string]any{"signature_algorithm": "rsa-sha256"})
+
+	data, err := env.client.Metadata(context.Background())
+	require.NoError(t, err)
+
+	var metadata saml.EntityDescriptor
+	require.NoError(t, xml.Unmarshal(data, &metadata))
+	assert.Equal(t, "https://grafana.example.com/saml/metadata", metadata.EntityID)
+	require.Len(t, metadata.SPSSODescriptors, 1)
+	assert.Equal(t, "https://grafana.example.com/saml/acs", metadata.SPSSODescriptors[0].AssertionConsumerServices[0].Location)
+	assert.Equal(t, "https://grafana.example.com/saml/slo", metadata.SPSSODescriptors[0].SingleLogoutServices[0].Location)
+
+	uses := []string{}
+	for _, kd := range metadata.SPSSODescriptors[0].KeyDescriptors {
+		uses = append(uses, kd.Use)
+	}
+	assert.Contains(t, uses, "signing")
+	assert.Contains(t, uses, "encryption")
+}
+
+func TestSAML_Logout(t *testing.T) {
+	t.Run("should redirect to the identity provider", func(t *testing.T) {
+		env := newSAMLTestEnv(t, nil)
+
+		redirect, ok := env.client.Logout(context.Background(), &user.SignedInUser{UserID: 1}, &login.UserAuth{AuthId: "alice-id"})
+		require.True(t, ok)
+		assert.True(t, strings.HasPrefix(redirect.URL, "https://idp.example.com/slo?"))
+		assert.Contains(t, redirect.URL, samlRequestParam+"=")
+	})
+
+	t.Run("should not redirect without single logout", func(t *testing.T) {
+		env := newSAMLTestEnv(t, map[string]any{"single_logout": false})
+
+		_, ok := env.client.Logout(context.Background(), &user.SignedInUser{UserID: 1}, &login.UserAuth{AuthId: "alice-id"})
+		assert.False(t, ok)
+	})
+}
+
+func TestSAML_SingleLogout(t *testing.T) {
+	newLogoutRequest := func(issuer string) *saml.LogoutRequest {
+		notOnOrAfter := time.Now().Add(time.Minute)
+		return &saml.LogoutRequest{
+			ID:           "id-logout",
+			Version:      "2.0",
+			IssueInstant: time.Now(),
+			NotOnOrAfter: &notOnOrAfter,
+			Destination:  "https://grafana.example.com/saml/slo",
+			Issuer:       &saml.Issuer{Value: issuer},
+			NameID:       &saml.NameID{Value: "alice-id"},
+		}
+	}
+
+	// redirectQuery encodes a logout request with the redirect binding, signed by key when set.
+	redirectQuery := func(t *testing.T, issuer string, key *rsa.PrivateKey) string {
+		data, err := xml.Marshal(newLogoutRequest(issuer))
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		q := samlRequestParam + "=" + url.QueryEscape(base64.StdEncoding.EncodeToString(buf.Bytes())) +
+			"&" + samlRelayStateParam + "=state"
+		if key == nil {
+			return q
+		}
+
+		q += "&SigAlg=" + url.QueryEscape(dsig.RSASHA256SignatureMethod)
+		digest := sha256.Sum256([]byte(q))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+		return q + "&Signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(signature))
+	}
+
+	t.Run("should handle a signed logout request from the identity provider", func(t *testing.T) {
+		env := newSAMLTestEnv(t, nil)
+
+		q := redirectQuery(t, "https://idp.example.com/metadata", env.idp.Key.(*rsa.PrivateKey))
+		logout, err := env.client.SingleLogout(context.Background(), httptest.NewRequest(http.MethodGet, "/saml/slo?"+q, nil))
+		require.NoError(t, err)
+
+		assert.Equal(t, "alice-id", logout.NameID)
+		u, err := url.Parse(logout.Redirect.URL)
+		require.NoError(t, err)
+		assert.Equal(t, "idp.example.com", u.Host)
+		assert.NotEmpty(t, u.Query().Get(samlResponseParam))
+		assert.Equal(t, "state", u.Query().Get(samlRelayStateParam))
+	})
+
+	t.Run("should handle a logout request posted by the identity provider", func(t *testing.T) {
+		env := newSAMLTestEnv(t, nil)
+
+		req := newLogoutRequest("https://idp.example.com/metadata")
+		signer := &saml.ServiceProvider{Key: env.idp.Key.(*rsa.PrivateKey), Certificate: env.idp.Certificate, SignatureMethod: dsig.RSASHA256SignatureMethod}
+		require.NoError(t, signer.SignLogoutRequest(req))
+		doc := etree.NewDocument()
+		doc.SetRoot(req.Element())
+		data, err := doc.WriteToBytes()
+		require.NoError(t, err)
+
+		body := url.Values{samlRequestParam: {base64.StdEncoding.EncodeToString(data)}}
+		r := httptest.NewRequest(http.MethodPost, "/saml/slo", strings.NewReader(body.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		logout, err := env.client.SingleLogout(context.Background(), r)
+		require.NoError(t, err)
+		assert.Equal(t, "alice-id", logout.NameID)
+	})
+
+	t.Run("should reject an unsigned logout request", func(t *testing.T) {
+		env := newSAMLTestEnv(t, nil)
+
+		q := redirectQuery(t, "https://idp.example.com/metadata", nil)
+		_, err := env.client.SingleLogout(context.Background(), httptest.NewRequest(http.MethodGet, "/saml/slo?"+q, nil))
+		assert.ErrorIs(t, err, errSAMLInvalidLogout)
+	})
+
+	t.Run("should reject a logout request signed by another key", func(t *testing.T) {
+		env := newSAMLTestEnv(t, nil)
+		otherKey, _, _, _ := generateSAMLKeyPair(t)
+
+		q := redirectQuery(t, "https://idp.example.com/metadata", otherKey)
+		_, err := env.client.SingleLogout(context.Background(), httptest.NewRequest(http.MethodGet, "/saml/slo?"+q, nil))
+		assert.ErrorIs(t, err, errSAMLInvalidLogout)
+	})
+
+	t.Run("should reject a logout request from another issuer", func(t *testing.T) {
+		env := newSAMLTestEnv(t, nil)
+
+		q := redirectQuery(t, "https://evil.example.com/metadata", env.idp.Key.(*rsa.PrivateKey))
+		_, err := env.client.SingleLogout(context.Background(), httptest.NewRequest(http.MethodGet, "/saml/slo?"+q, nil))
+		assert.ErrorIs(t, err, errSAMLInvalidLogout)
+	})
+
+	t.Run("should reject a request without logout message", func(t *testing.T) {
+		env := newSAMLTestEnv(t, nil)
+
+		_, err := env.client.SingleLogout(context.Background(), httptest.NewRequest(http.MethodGet, "/saml/slo", nil))
+		assert.ErrorIs(t, err, errSAMLInvalidLogout)
+	})
+}
+
+func generateSAMLKeyPair(t *testing.T) (*rsa.PrivateKey, *x509.Certificate, string, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "saml.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return key, cert, string(keyPEM), string(certPEM)
+}
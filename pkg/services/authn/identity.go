@@ -73,6 +73,9 @@ type Identity struct {
 	// idP Groups that the entity is a member of. This is only populated if the
 	// identity provider supports groups.
 	Groups []string
+	// ExternalTeams maps org ids to the names of the teams the identity provider assigned the entity to.
+	// When set, the external team memberships of the entity are synced for these orgs.
+	ExternalTeams map[int64][]string
 	// OAuthToken is the OAuth token used to authenticate the entity.
 	OAuthToken *oauth2.Token
 	// SessionToken is the session token used to authenticate the entity.
@@ -0,0 +1,82 @@
+package backup
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/api/routing"
+	"github.com/grafana/grafana/pkg/infra/db"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/encryption"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	ActionCreate = "backups:create"
+)
+
+var backupWriterRole = ac.RoleDTO{
+	Name:        "fixed:backups:writer",
+	DisplayName: "Backup writer",
+	Description: "Create and download backups of the instance",
+	Group:       "Backups",
+	Permissions: []ac.Permission{
+		{Action: ActionCreate},
+	},
+}
+
+// ProvideService returns the backup service and registers the HTTP API used
+// to download a backup. Restoring is only possible through the grafana cli,
+// while the server is stopped.
+func ProvideService(
+	cfg *setting.Cfg,
+	sqlStore db.DB,
+	encryptionService encryption.Internal,
+	accessControl ac.AccessControl,
+	accesscontrolService ac.Service,
+	routeRegister routing.RouteRegister,
+) (*Service, error) {
+	s := NewService(cfg, sqlStore, encryptionService)
+
+	section := cfg.SectionWithEnvOverrides("backup")
+	if !section.Key("enabled").MustBool(true) {
+		return s, nil
+	}
+
+	if err := accesscontrolService.DeclareFixedRoles(ac.RoleRegistration{
+		Role:   backupWriterRole,
+		Grants: []string{ac.RoleGrafanaAdmin},
+	}); err != nil {
+		return nil, err
+	}
+
+	authorize := ac.Middleware(accessControl)
+	routeRegister.Group("/api/admin/backup", func(subrouter routing.RouteRegister) {
+		subrouter.Post("/", authorize(ac.EvalPermission(ActionCreate)), routing.Wrap(s.handleCreate))
+	})
+
+	return s, nil
+}
+
+func (s *Service) handleCreate(ctx *contextmodel.ReqContext) response.Response {
+	filename := fmt.Sprintf("grafana-backup-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+
+	// The archive is streamed, errors after the first write can only be logged.
+	ctx.Resp.Header().Set("Content-Type", "application/tar+gzip")
+	ctx.Resp.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	manifest, err := s.Create(ctx.Req.Context(), ctx.Resp)
+	if err != nil {
+		s.log.Error("Failed to create backup", "error", err)
+		if !ctx.Resp.Written() {
+			ctx.Resp.Header().Del("Content-Disposition")
+			return response.Error(http.StatusInternalServerError, "Failed to create backup", err)
+		}
+		return nil
+	}
+
+	s.log.Info("Backup downloaded", "user", ctx.SignedInUser.GetLogin(), "tables", len(manifest.Tables))
+	return nil
+}
@@ -0,0 +1,96 @@
+// Package backup produces and restores versioned archives of a Grafana
+// instance. An archive contains a snapshot of the database, consistent per
+// table on SQLite (secrets stay encrypted and the data keys are exported
+// alongside them), the list of installed plugins, the provisioning files and
+// the on-disk state of the unified alerting Alertmanagers.
+package backup
+
+import (
+	"errors"
+	"time"
+)
+
+// FormatVersion is the version of the archive layout. It is bumped whenever
+// the layout changes in a way older versions of Grafana cannot read.
+const FormatVersion = 1
+
+const (
+	manifestFilename = "manifest.json"
+	databaseDir      = "database"
+	provisioningDir  = "provisioning"
+	alertingDir      = "alerting"
+
+	// alertingWorkingDir is the directory inside the data path where the
+	// Alertmanagers keep their silences and notification log.
+	alertingWorkingDir = "alerting"
+
+	// encryptionCheckPayload is encrypted with the secret key when a backup is
+	// created and decrypted on restore, to make sure the target instance is
+	// able to read the secrets stored in the archive.
+	encryptionCheckPayload = "grafana-backup"
+)
+
+var (
+	ErrUnsupportedFormat     = errors.New("unsupported backup format version")
+	ErrDatabaseTypeMismatch  = errors.New("backup was created from a different database type")
+	ErrIncompatibleMigration = errors.New("backup is not compatible with the database migrations of this instance")
+	ErrSecretKeyMismatch     = errors.New("secrets in the backup cannot be decrypted with the configured secret key")
+	ErrMissingManifest       = errors.New("backup archive does not contain a manifest")
+)
+
+// Manifest describes the content of a backup archive.
+type Manifest struct {
+	FormatVersion  int       `json:"formatVersion"`
+	GrafanaVersion string    `json:"grafanaVersion"`
+	GrafanaCommit  string    `json:"grafanaCommit"`
+	CreatedAt      time.Time `json:"createdAt"`
+	// DatabaseType is the database the backup was taken from. A backup can
+	// only be restored into a database of the same type.
+	DatabaseType string `json:"databaseType"`
+	// Migrations holds the IDs of all the successful database migrations of
+	// the instance, used to check that the schema of the target matches.
+	Migrations []string    `json:"migrations"`
+	Tables     []TableInfo `json:"tables"`
+	// EncryptionCheck is a value encrypted with the secret key of the
+	// instance. The data keys in the archive are encrypted with the same key.
+	EncryptionCheck []byte       `json:"encryptionCheck"`
+	Plugins         []PluginInfo `json:"plugins"`
+	Files           []string     `json:"files"`
+}
+
+// TableInfo describes a database table stored in the archive.
+type TableInfo struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Rows    int64    `json:"rows"`
+}
+
+// PluginInfo describes a plugin installed on the instance when the backup
+// was taken. Plugins are not part of the archive and have to be installed
+// separately.
+type PluginInfo struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Version string `json:"version"`
+}
+
+// RestoreOptions control which parts of an archive are restored.
+type RestoreOptions struct {
+	// Provisioning restores the provisioning files into the provisioning path.
+	Provisioning bool
+	// AlertingState restores the silences and notification log files of the
+	// Alertmanagers into the data path.
+	AlertingState bool
+}
+
+// RestoreResult summarizes a restore.
+type RestoreResult struct {
+	Manifest Manifest `json:"manifest"`
+	// RestoredRows is the number of rows restored per table.
+	RestoredRows map[string]int64 `json:"restoredRows"`
+	// RestoredFiles are the archive paths of the restored files.
+	RestoredFiles []string `json:"restoredFiles"`
+	// MissingPlugins are the plugins of the backup that are not installed or
+	// installed with a different version.
+	MissingPlugins []PluginInfo `json:"missingPlugins"`
+}
@@ -0,0 +1,343 @@
+package backup
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"xorm.io/core"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+const dumpBatchSize = 1000
+
+// excludedTables are never part of a backup. The migration log is recorded in
+// the manifest and checked on restore, the others only hold transient data.
+var excludedTables = map[string]bool{
+	"migration_log": true,
+	"server_lock":   true,
+	"cache_data":    true,
+}
+
+// tableDump is a table spooled to a temporary file before it is added to the
+// archive.
+type tableDump struct {
+	info TableInfo
+	file *os.File
+}
+
+func (d *tableDump) close() {
+	_ = d.file.Close()
+	_ = os.Remove(d.file.Name())
+}
+
+func tableFilename(table string) string {
+	return path.Join(databaseDir, table+".jsonl")
+}
+
+// dumpDatabase reads all the tables within a single transaction on MySQL and
+// PostgreSQL, so that the backup is consistent while the instance keeps
+// serving requests: their reads do not block writes. A read transaction of
+// SQLite blocks all the writes of the instance, so there every table is read
+// in its own short transaction and the backup is only consistent per table.
+func dumpDatabase(ctx context.Context, sqlStore db.DB) ([]string, []*tableDump, error) {
+	tables, err := sqlStore.GetEngine().DBMetas()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read database schema: %w", err)
+	}
+
+	var migrations []string
+	dumps := make([]*tableDump, 0, len(tables))
+	readMigrations := func(sess *db.Session) error {
+		logs := make([]migrator.MigrationLog, 0)
+		if err := sess.Table("migration_log").Find(&logs); err != nil {
+			return fmt.Errorf("failed to read migration log: %w", err)
+		}
+		for _, l := range logs {
+			if l.Success {
+				migrations = append(migrations, l.MigrationID)
+			}
+		}
+		return nil
+	}
+	dumpTables := func(sess *db.Session, tables []*core.Table) error {
+		for _, table := range tables {
+			if excludedTables[table.Name] {
+				continue
+			}
+			dump, err := dumpTable(sess, sqlStore.GetDialect(), table)
+			if err != nil {
+				return fmt.Errorf("failed to dump table %s: %w", table.Name, err)
+			}
+			dumps = append(dumps, dump)
+		}
+		return nil
+	}
+
+	if sqlStore.GetDBType() == migrator.SQLite {
+		err = sqlStore.WithDbSession(ctx, readMigrations)
+		for _, table := range tables {
+			if err != nil {
+				break
+			}
+			err = sqlStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+				return dumpTables(sess, []*core.Table{table})
+			})
+		}
+	} else {
+		err = sqlStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+			if sqlStore.GetDBType() == migrator.Postgres {
+				if _, err := sess.Exec("SET TRANSACTION ISOLATION LEVEL REPEATABLE READ"); err != nil {
+					return err
+				}
+			}
+			if err := readMigrations(sess); err != nil {
+				return err
+			}
+			return dumpTables(sess, tables)
+		})
+	}
+	if err != nil {
+		for _, d := range dumps {
+			d.close()
+		}
+		return nil, nil, err
+	}
+
+	return migrations, dumps, nil
+}
+
+// dumpTable writes the rows of a table as JSON arrays, one per line, in the
+// order of TableInfo.Columns. Times are read as text, so that they are
+// restored in the format the database stores them in.
+func dumpTable(sess *db.Session, dialect migrator.Dialect, table *core.Table) (*tableDump, error) {
+	file, err := os.CreateTemp("", "grafana-backup-*.jsonl")
+	if err != nil {
+		return nil, err
+	}
+	dump := &tableDump{
+		info: TableInfo{Name: table.Name, Columns: table.ColumnsSeq()},
+		file: file,
+	}
+
+	quoted := make([]string, 0, len(dump.info.Columns))
+	for _, c := range dump.info.Columns {
+		if col := table.GetColumn(c); col != nil && col.SQLType.IsTime() {
+			quoted = append(quoted, castToText(dialect, dialect.Quote(c))+" AS "+dialect.Quote(c))
+			continue
+		}
+		quoted = append(quoted, dialect.Quote(c))
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(quoted, ", "), dialect.Quote(table.Name))
+
+	// Tables with a primary key are read in batches, the others at once.
+	pks := make([]string, 0, len(table.PrimaryKeys))
+	for _, pk := range table.PrimaryKeys {
+		pks = append(pks, dialect.Quote(pk))
+	}
+
+	w := bufio.NewWriter(file)
+	enc := json.NewEncoder(w)
+	for offset := int64(0); ; offset += dumpBatchSize {
+		q := query
+		if len(pks) > 0 {
+			q += " ORDER BY " + strings.Join(pks, ", ") + " " + dialect.LimitOffset(dumpBatchSize, offset)
+		}
+
+		rows, err := sess.QueryInterface(q)
+		if err != nil {
+			dump.close()
+			return nil, err
+		}
+
+		for _, row := range rows {
+			values := make([]any, 0, len(dump.info.Columns))
+			for _, c := range dump.info.Columns {
+				values = append(values, encodeValue(table.GetColumn(c), row[c]))
+			}
+			if err := enc.Encode(values); err != nil {
+				dump.close()
+				return nil, err
+			}
+		}
+		dump.info.Rows += int64(len(rows))
+
+		if len(pks) == 0 || len(rows) < dumpBatchSize {
+			break
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		dump.close()
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		dump.close()
+		return nil, err
+	}
+	return dump, nil
+}
+
+// castToText returns the SQL expression converting expr to text.
+func castToText(dialect migrator.Dialect, expr string) string {
+	if dialect.DriverName() == migrator.MySQL {
+		return fmt.Sprintf("CAST(%s AS CHAR)", expr)
+	}
+	return fmt.Sprintf("CAST(%s AS TEXT)", expr)
+}
+
+func encodeValue(col *core.Column, v any) any {
+	if val, ok := v.([]byte); ok {
+		if col != nil && col.SQLType.IsBlob() {
+			// encoded as base64 by encoding/json
+			return val
+		}
+		return string(val)
+	}
+	return v
+}
+
+func decodeValue(col *core.Column, v any) (any, error) {
+	switch val := v.(type) {
+	case string:
+		if col == nil {
+			return val, nil
+		}
+		if col.SQLType.IsBlob() {
+			return base64.StdEncoding.DecodeString(val)
+		}
+		return val, nil
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i, nil
+		}
+		return val.Float64()
+	}
+	return v, nil
+}
+
+// checkMigrations verifies that the backup was taken from an instance with
+// exactly the same database migrations as the target.
+func checkMigrations(backup []string, target []string) error {
+	inTarget := make(map[string]bool, len(target))
+	for _, m := range target {
+		inTarget[m] = true
+	}
+	inBackup := make(map[string]bool, len(backup))
+	for _, m := range backup {
+		inBackup[m] = true
+	}
+
+	var newer, older []string
+	for _, m := range backup {
+		if !inTarget[m] {
+			newer = append(newer, m)
+		}
+	}
+	for _, m := range target {
+		if !inBackup[m] {
+			older = append(older, m)
+		}
+	}
+
+	if len(newer) > 0 {
+		return fmt.Errorf("%w: the backup was created by a newer version of Grafana, %d migration(s) are missing on this instance, first: %q",
+			ErrIncompatibleMigration, len(newer), newer[0])
+	}
+	if len(older) > 0 {
+		return fmt.Errorf("%w: the backup was created by an older version of Grafana, restore it with that version and upgrade afterwards, %d migration(s) are missing in the backup, first: %q",
+			ErrIncompatibleMigration, len(older), older[0])
+	}
+	return nil
+}
+
+func targetMigrations(ctx context.Context, sqlStore db.DB) ([]string, error) {
+	var migrations []string
+	err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		logs := make([]migrator.MigrationLog, 0)
+		if err := sess.Table("migration_log").Find(&logs); err != nil {
+			return err
+		}
+		for _, l := range logs {
+			if l.Success {
+				migrations = append(migrations, l.MigrationID)
+			}
+		}
+		return nil
+	})
+	return migrations, err
+}
+
+// clearTables removes all the rows of the tables stored in the backup.
+func clearTables(sess *db.Session, dialect migrator.Dialect, tables []TableInfo) error {
+	for _, t := range tables {
+		if _, err := sess.Exec(fmt.Sprintf("DELETE FROM %s", dialect.Quote(t.Name))); err != nil {
+			return fmt.Errorf("failed to clear table %s: %w", t.Name, err)
+		}
+	}
+	return nil
+}
+
+// restoreTable inserts the rows of a table dump.
+func restoreTable(sess *db.Session, dialect migrator.Dialect, table *core.Table, info TableInfo, r io.Reader) (int64, error) {
+	for _, c := range info.Columns {
+		if table.GetColumn(c) == nil {
+			return 0, fmt.Errorf("column %s of table %s does not exist in the target database", c, info.Name)
+		}
+	}
+
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var count int64
+	for {
+		var values []any
+		if err := dec.Decode(&values); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return count, fmt.Errorf("failed to read row %d of table %s: %w", count+1, info.Name, err)
+		}
+		if len(values) != len(info.Columns) {
+			return count, fmt.Errorf("row %d of table %s has %d values, expected %d", count+1, info.Name, len(values), len(info.Columns))
+		}
+
+		row := make(map[string]any, len(values))
+		for i, c := range info.Columns {
+			v, err := decodeValue(table.GetColumn(c), values[i])
+			if err != nil {
+				return count, fmt.Errorf("failed to decode column %s of table %s: %w", c, info.Name, err)
+			}
+			row[c] = v
+		}
+
+		query, args, err := dialect.InsertQuery(info.Name, row)
+		if err != nil {
+			return count, err
+		}
+		if _, err := sess.Exec(append([]any{query}, args...)...); err != nil {
+			return count, fmt.Errorf("failed to insert row %d into table %s: %w", count+1, info.Name, err)
+		}
+		count++
+	}
+
+	if dialect.DriverName() == migrator.Postgres && table.AutoIncrement != "" {
+		// Rows were inserted with explicit ids, move the sequence past them.
+		seq := fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', '%s'), (SELECT COALESCE(MAX(%s), 0) + 1 FROM %s), false)",
+			info.Name, table.AutoIncrement, dialect.Quote(table.AutoIncrement), dialect.Quote(info.Name))
+		if _, err := sess.Exec(seq); err != nil {
+			return count, fmt.Errorf("failed to reset sequence of table %s: %w", info.Name, err)
+		}
+	}
+
+	return count, nil
+}
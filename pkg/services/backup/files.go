@@ -0,0 +1,124 @@
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// archiveFile is a file on disk and its path inside the archive.
+type archiveFile struct {
+	diskPath    string
+	archivePath string
+}
+
+// collectFiles walks root and returns the regular files below it, stored
+// under prefix in the archive. A missing root is not an error.
+func collectFiles(root string, prefix string) ([]archiveFile, error) {
+	if root == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(root); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	var files []archiveFile
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, archiveFile{diskPath: p, archivePath: path.Join(prefix, filepath.ToSlash(rel))})
+		return nil
+	})
+	return files, err
+}
+
+// restorePath maps an archive path back to a path below root. Paths that
+// escape root are rejected.
+func restorePath(root string, prefix string, archivePath string) (string, bool) {
+	rel := strings.TrimPrefix(archivePath, prefix+"/")
+	if rel == archivePath || rel == "" {
+		return "", false
+	}
+	p := filepath.Join(root, filepath.FromSlash(rel))
+	if !strings.HasPrefix(p, filepath.Clean(root)+string(filepath.Separator)) {
+		return "", false
+	}
+	return p, true
+}
+
+// installedPlugins reads the plugin.json files of the plugins installed in
+// pluginsPath. Core plugins ship with Grafana and are not listed.
+func installedPlugins(pluginsPath string) ([]PluginInfo, error) {
+	if pluginsPath == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(pluginsPath); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	seen := map[string]bool{}
+	var plugins []PluginInfo
+	err := filepath.WalkDir(pluginsPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != "plugin.json" {
+			return nil
+		}
+
+		// #nosec G304 - the path is below the configured plugins path
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		var pj struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+			Info struct {
+				Version string `json:"version"`
+			} `json:"info"`
+		}
+		if err := json.Unmarshal(data, &pj); err != nil || pj.ID == "" || seen[pj.ID] {
+			return nil
+		}
+		seen[pj.ID] = true
+		plugins = append(plugins, PluginInfo{ID: pj.ID, Type: pj.Type, Version: pj.Info.Version})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].ID < plugins[j].ID })
+	return plugins, nil
+}
+
+// missingPlugins returns the plugins of the backup that are not installed
+// with the same version.
+func missingPlugins(backup []PluginInfo, installed []PluginInfo) []PluginInfo {
+	versions := make(map[string]string, len(installed))
+	for _, p := range installed {
+		versions[p.ID] = p.Version
+	}
+
+	var missing []PluginInfo
+	for _, p := range backup {
+		if v, ok := versions[p.ID]; !ok || v != p.Version {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,316 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"xorm.io/core"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/encryption"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Service creates and restores backup archives. It is used both by the HTTP
+// API and by the grafana cli.
+type Service struct {
+	cfg        *setting.Cfg
+	sqlStore   db.DB
+	encryption encryption.Internal
+	log        log.Logger
+}
+
+func NewService(cfg *setting.Cfg, sqlStore db.DB, encryptionService encryption.Internal) *Service {
+	return &Service{
+		cfg:        cfg,
+		sqlStore:   sqlStore,
+		encryption: encryptionService,
+		log:        log.New("backup"),
+	}
+}
+
+// Create writes a gzipped tar archive of the instance to w. The manifest is
+// always the first entry of the archive.
+func (s *Service) Create(ctx context.Context, w io.Writer) (*Manifest, error) {
+	migrations, dumps, err := dumpDatabase(ctx, s.sqlStore)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, d := range dumps {
+			d.close()
+		}
+	}()
+
+	check, err := s.encryption.Encrypt(ctx, []byte(encryptionCheckPayload), s.cfg.SecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt the secret key check: %w", err)
+	}
+
+	plugins, err := installedPlugins(s.cfg.PluginsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list installed plugins: %w", err)
+	}
+
+	provisioningFiles, err := collectFiles(s.cfg.ProvisioningPath, provisioningDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect provisioning files: %w", err)
+	}
+	alertingFiles, err := collectFiles(filepath.Join(s.cfg.DataPath, alertingWorkingDir), alertingDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect alerting state files: %w", err)
+	}
+	files := append(provisioningFiles, alertingFiles...)
+
+	manifest := &Manifest{
+		FormatVersion:   FormatVersion,
+		GrafanaVersion:  s.cfg.BuildVersion,
+		GrafanaCommit:   s.cfg.BuildCommit,
+		CreatedAt:       time.Now().UTC(),
+		DatabaseType:    string(s.sqlStore.GetDBType()),
+		Migrations:      migrations,
+		Tables:          make([]TableInfo, 0, len(dumps)),
+		EncryptionCheck: check,
+		Plugins:         plugins,
+		Files:           make([]string, 0, len(files)),
+	}
+	for _, d := range dumps {
+		manifest.Tables = append(manifest.Tables, d.info)
+	}
+	for _, f := range files {
+		manifest.Files = append(manifest.Files, f.archivePath)
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeEntry(tw, manifestFilename, int64(len(data)), bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	for _, d := range dumps {
+		stat, err := d.file.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if err := writeEntry(tw, tableFilename(d.info.Name), stat.Size(), d.file); err != nil {
+			return nil, fmt.Errorf("failed to write table %s: %w", d.info.Name, err)
+		}
+	}
+
+	for _, f := range files {
+		// The Alertmanagers replace their files atomically, reading the whole
+		// file keeps the size in the header consistent with the content.
+		// #nosec G304 - the path is below the provisioning or data path
+		content, err := os.ReadFile(f.diskPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.diskPath, err)
+		}
+		if err := writeEntry(tw, f.archivePath, int64(len(content)), bytes.NewReader(content)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+
+	s.log.Info("Backup created", "tables", len(manifest.Tables), "files", len(manifest.Files))
+	return manifest, nil
+}
+
+func writeEntry(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	header := &tar.Header{
+		Name:    name,
+		ModTime: time.Now(),
+		Mode:    int64(0o644),
+		Size:    size,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := io.Copy(tw, r)
+	return err
+}
+
+// ReadManifest returns the manifest of an archive without restoring it.
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return readManifest(tar.NewReader(gr))
+}
+
+func readManifest(tr *tar.Reader) (*Manifest, error) {
+	header, err := tr.Next()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrMissingManifest
+		}
+		return nil, err
+	}
+	if header.Name != manifestFilename {
+		return nil, ErrMissingManifest
+	}
+
+	var manifest Manifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if manifest.FormatVersion < 1 || manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedFormat, manifest.FormatVersion)
+	}
+	return &manifest, nil
+}
+
+// Check verifies that the archive described by manifest can be restored into
+// this instance.
+func (s *Service) Check(ctx context.Context, manifest *Manifest) error {
+	if manifest.DatabaseType != string(s.sqlStore.GetDBType()) {
+		return fmt.Errorf("%w: backup uses %s, this instance uses %s", ErrDatabaseTypeMismatch, manifest.DatabaseType, s.sqlStore.GetDBType())
+	}
+
+	migrations, err := targetMigrations(ctx, s.sqlStore)
+	if err != nil {
+		return fmt.Errorf("failed to read migration log: %w", err)
+	}
+	if err := checkMigrations(manifest.Migrations, migrations); err != nil {
+		return err
+	}
+
+	decrypted, err := s.encryption.Decrypt(ctx, manifest.EncryptionCheck, s.cfg.SecretKey)
+	if err != nil || string(decrypted) != encryptionCheckPayload {
+		return ErrSecretKeyMismatch
+	}
+	return nil
+}
+
+// Restore replaces the content of the database with the content of the
+// archive. All the tables are restored within a single transaction. Files are
+// only written once the database has been restored successfully.
+func (s *Service) Restore(ctx context.Context, r io.Reader, opts RestoreOptions) (*RestoreResult, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gr)
+
+	manifest, err := readManifest(tr)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Check(ctx, manifest); err != nil {
+		return nil, err
+	}
+
+	metas, err := s.sqlStore.GetEngine().DBMetas()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database schema: %w", err)
+	}
+	targetTables := make(map[string]*core.Table, len(metas))
+	for _, t := range metas {
+		targetTables[t.Name] = t
+	}
+	backupTables := make(map[string]TableInfo, len(manifest.Tables))
+	for _, t := range manifest.Tables {
+		if targetTables[t.Name] == nil {
+			return nil, fmt.Errorf("%w: table %s does not exist", ErrIncompatibleMigration, t.Name)
+		}
+		backupTables[tableFilename(t.Name)] = t
+	}
+
+	result := &RestoreResult{
+		Manifest:     *manifest,
+		RestoredRows: make(map[string]int64, len(manifest.Tables)),
+	}
+	type pendingFile struct {
+		archivePath string
+		diskPath    string
+		content     []byte
+	}
+	var pending []pendingFile
+
+	dialect := s.sqlStore.GetDialect()
+	err = s.sqlStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		if err := clearTables(sess, dialect, manifest.Tables); err != nil {
+			return err
+		}
+
+		for {
+			header, err := tr.Next()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return err
+			}
+
+			if info, ok := backupTables[header.Name]; ok {
+				n, err := restoreTable(sess, dialect, targetTables[info.Name], info, tr)
+				if err != nil {
+					return err
+				}
+				result.RestoredRows[info.Name] = n
+				continue
+			}
+
+			var diskPath string
+			var ok bool
+			switch {
+			case opts.Provisioning && strings.HasPrefix(header.Name, provisioningDir+"/"):
+				diskPath, ok = restorePath(s.cfg.ProvisioningPath, provisioningDir, header.Name)
+			case opts.AlertingState && strings.HasPrefix(header.Name, alertingDir+"/"):
+				diskPath, ok = restorePath(filepath.Join(s.cfg.DataPath, alertingWorkingDir), alertingDir, header.Name)
+			}
+			if !ok {
+				continue
+			}
+			content, err := io.ReadAll(tr)
+			if err != nil {
+				return err
+			}
+			pending = append(pending, pendingFile{archivePath: header.Name, diskPath: diskPath, content: content})
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore database: %w", err)
+	}
+
+	for _, f := range pending {
+		if err := os.MkdirAll(filepath.Dir(f.diskPath), 0o750); err != nil {
+			return result, err
+		}
+		if err := os.WriteFile(f.diskPath, f.content, 0o640); err != nil {
+			return result, fmt.Errorf("failed to restore %s: %w", f.archivePath, err)
+		}
+		result.RestoredFiles = append(result.RestoredFiles, f.archivePath)
+	}
+
+	plugins, err := installedPlugins(s.cfg.PluginsPath)
+	if err != nil {
+		s.log.Warn("Failed to list installed plugins", "error", err)
+	}
+	result.MissingPlugins = missingPlugins(manifest.Plugins, plugins)
+
+	s.log.Info("Backup restored", "createdAt", manifest.CreatedAt, "tables", len(result.RestoredRows), "files", len(result.RestoredFiles))
+	return result, nil
+}
@@ -0,0 +1,180 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	encryptionservice "github.com/grafana/grafana/pkg/services/encryption/service"
+	"github.com/grafana/grafana/pkg/services/secrets"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationBackupRestore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	sqlStore := db.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.SecretKey = "backup-test-secret"
+	cfg.ProvisioningPath = t.TempDir()
+	cfg.DataPath = t.TempDir()
+	cfg.PluginsPath = t.TempDir()
+
+	writeFile(t, filepath.Join(cfg.ProvisioningPath, "datasources", "ds.yaml"), "apiVersion: 1\n")
+	writeFile(t, filepath.Join(cfg.DataPath, alertingWorkingDir, "1", "silences"), "silences")
+	writeFile(t, filepath.Join(cfg.PluginsPath, "test-panel", "plugin.json"), `{"id":"test-panel","type":"panel","info":{"version":"1.2.3"}}`)
+
+	ctx := context.Background()
+	created := time.Date(2023, 11, 20, 10, 30, 0, 0, time.UTC)
+	insertRows(t, sqlStore, "kv-value", created, []byte{0x00, 0x01, 0xff})
+
+	svc := NewService(cfg, sqlStore, encryptionservice.SetupTestService(t))
+	createdText := readCreatedText(t, sqlStore)
+
+	var buf bytes.Buffer
+	manifest, err := svc.Create(ctx, &buf)
+	require.NoError(t, err)
+	require.Equal(t, FormatVersion, manifest.FormatVersion)
+	require.NotEmpty(t, manifest.Migrations)
+	require.Equal(t, []PluginInfo{{ID: "test-panel", Type: "panel", Version: "1.2.3"}}, manifest.Plugins)
+	require.ElementsMatch(t, []string{"provisioning/datasources/ds.yaml", "alerting/1/silences"}, manifest.Files)
+	for _, table := range manifest.Tables {
+		require.NotContains(t, excludedTables, table.Name)
+	}
+
+	t.Run("manifest is the first entry of the archive", func(t *testing.T) {
+		m, err := ReadManifest(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		require.Equal(t, manifest.Migrations, m.Migrations)
+		require.NoError(t, svc.Check(ctx, m))
+	})
+
+	t.Run("restore replaces the database content and restores files", func(t *testing.T) {
+		err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			if _, err := sess.Exec("DELETE FROM kv_store"); err != nil {
+				return err
+			}
+			_, err := sess.Exec("DELETE FROM data_keys")
+			return err
+		})
+		require.NoError(t, err)
+		insertRows(t, sqlStore, "changed", created.Add(time.Hour), []byte("changed"))
+		require.NoError(t, os.RemoveAll(filepath.Join(cfg.DataPath, alertingWorkingDir)))
+
+		result, err := svc.Restore(ctx, bytes.NewReader(buf.Bytes()), RestoreOptions{AlertingState: true})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), result.RestoredRows["kv_store"])
+		assert.Equal(t, int64(1), result.RestoredRows["data_keys"])
+		assert.Equal(t, []string{"alerting/1/silences"}, result.RestoredFiles)
+		assert.Empty(t, result.MissingPlugins)
+
+		var items []kvstore.Item
+		var keys []secrets.DataKey
+		err = sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			if err := sess.Table("kv_store").Find(&items); err != nil {
+				return err
+			}
+			return sess.Table("data_keys").Find(&keys)
+		})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "kv-value", items[0].Value)
+		assert.True(t, created.Equal(items[0].Created))
+		assert.Equal(t, createdText, readCreatedText(t, sqlStore), "times keep the format of the database")
+		require.Len(t, keys, 1)
+		assert.Equal(t, []byte{0x00, 0x01, 0xff}, keys[0].EncryptedData)
+		assert.True(t, keys[0].Active)
+
+		content, err := os.ReadFile(filepath.Join(cfg.DataPath, alertingWorkingDir, "1", "silences"))
+		require.NoError(t, err)
+		assert.Equal(t, "silences", string(content))
+	})
+
+	t.Run("restore fails with a different secret key", func(t *testing.T) {
+		other := *cfg
+		other.SecretKey = "another-secret"
+		svc := NewService(&other, sqlStore, encryptionservice.SetupTestService(t))
+
+		_, err := svc.Restore(ctx, bytes.NewReader(buf.Bytes()), RestoreOptions{})
+		require.ErrorIs(t, err, ErrSecretKeyMismatch)
+	})
+
+	t.Run("restore fails when the migrations differ", func(t *testing.T) {
+		err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Exec("INSERT INTO migration_log (migration_id, `sql`, success, error, timestamp) VALUES (?, '', ?, '', ?)", "backup test migration", true, time.Now())
+			return err
+		})
+		require.NoError(t, err)
+
+		_, err = svc.Restore(ctx, bytes.NewReader(buf.Bytes()), RestoreOptions{})
+		require.ErrorIs(t, err, ErrIncompatibleMigration)
+	})
+}
+
+func TestCheckMigrations(t *testing.T) {
+	require.NoError(t, checkMigrations([]string{"a", "b"}, []string{"b", "a"}))
+	require.ErrorIs(t, checkMigrations([]string{"a", "b", "c"}, []string{"a", "b"}), ErrIncompatibleMigration)
+	require.ErrorIs(t, checkMigrations([]string{"a"}, []string{"a", "b"}), ErrIncompatibleMigration)
+}
+
+func TestRestorePath(t *testing.T) {
+	root := filepath.Join("var", "lib", "grafana")
+
+	p, ok := restorePath(root, "alerting", "alerting/1/silences")
+	require.True(t, ok)
+	require.Equal(t, filepath.Join(root, "1", "silences"), p)
+
+	_, ok = restorePath(root, "alerting", "alerting/../../etc/passwd")
+	require.False(t, ok)
+
+	_, ok = restorePath(root, "alerting", "provisioning/x.yaml")
+	require.False(t, ok)
+}
+
+func insertRows(t *testing.T, sqlStore db.DB, value string, created time.Time, encrypted []byte) {
+	t.Helper()
+
+	orgID, namespace, key := int64(1), "backup", "key"
+	err := sqlStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+		if _, err := sess.Table("kv_store").Insert(&kvstore.Item{
+			OrgId: &orgID, Namespace: &namespace, Key: &key, Value: value, Created: created, Updated: created,
+		}); err != nil {
+			return err
+		}
+		_, err := sess.Table("data_keys").Insert(&secrets.DataKey{
+			Active: true, Id: "key-" + value, Scope: "root", Provider: "secretKey.v1", EncryptedData: encrypted, Created: created, Updated: created,
+		})
+		return err
+	})
+	require.NoError(t, err)
+}
+
+// readCreatedText returns the creation time of the kv_store item as stored in the database.
+func readCreatedText(t *testing.T, sqlStore db.DB) string {
+	t.Helper()
+
+	var created string
+	err := sqlStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+		_, err := sess.SQL("SELECT " + castToText(sqlStore.GetDialect(), "created") + " FROM kv_store").Get(&created)
+		return err
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, created)
+	return created
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
@@ -67,11 +67,14 @@ type API struct {
 	ContactPointService  *provisioning.ContactPointService
 	Templates            *provisioning.TemplateService
 	MuteTimings          *provisioning.MuteTimingService
+	MaintenanceWindows   *provisioning.MaintenanceWindowService
 	AlertRules           *provisioning.AlertRuleService
 	AlertsRouter         *sender.AlertsRouter
 	EvaluatorFactory     eval.EvaluatorFactory
 	FeatureManager       featuremgmt.FeatureToggles
 	Historian            Historian
+	NotificationHistory  NotificationHistorian
+	RuleCosts            RuleCostReader
 	Tracer               tracing.Tracer
 	AppUrl               *url.URL
 	UpgradeService       migration.UpgradeService
@@ -93,7 +96,14 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 	api.RegisterAlertmanagerApiEndpoints(NewForkingAM(
 		api.DatasourceCache,
 		NewLotexAM(proxy, logger),
-		&AlertmanagerSrv{crypto: api.MultiOrgAlertmanager.Crypto, log: logger, ac: api.AccessControl, mam: api.MultiOrgAlertmanager},
+		&AlertmanagerSrv{
+			crypto:             api.MultiOrgAlertmanager.Crypto,
+			log:                logger,
+			ac:                 api.AccessControl,
+			mam:                api.MultiOrgAlertmanager,
+			ruleStore:          api.RuleStore,
+			includeFolderLabel: !api.Cfg.UnifiedAlerting.ReservedLabels.IsReservedLabelDisabled(models.FolderTitleLabel),
+		},
 	), m)
 	// Register endpoints for proxying to Prometheus-compatible backends.
 	api.RegisterPrometheusApiEndpoints(NewForkingProm(
@@ -114,6 +124,7 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 			log:                logger,
 			cfg:                &api.Cfg.UnifiedAlerting,
 			authz:              ruleAuthzService,
+			costs:              api.RuleCosts,
 		},
 	), m)
 	api.RegisterTestingApiEndpoints(NewTestingApi(
@@ -145,11 +156,13 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 		templates:           api.Templates,
 		muteTimings:         api.MuteTimings,
 		alertRules:          api.AlertRules,
+		maintenanceWindows:  api.MaintenanceWindows,
 	}), m)
 
 	api.RegisterHistoryApiEndpoints(NewStateHistoryApi(&HistorySrv{
-		logger: logger,
-		hist:   api.Historian,
+		logger:           logger,
+		hist:             api.Historian,
+		notificationHist: api.NotificationHistory,
 	}), m)
 
 	api.RegisterNotificationsApiEndpoints(NewNotificationsApi(api.MuteTimings), m)
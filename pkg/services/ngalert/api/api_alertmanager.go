@@ -11,6 +11,7 @@ import (
 
 	"github.com/go-openapi/strfmt"
 	alertingNotify "github.com/grafana/alerting/notify"
+	"github.com/prometheus/common/model"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -18,7 +19,9 @@ import (
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	authz "github.com/grafana/grafana/pkg/services/ngalert/accesscontrol"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -29,10 +32,13 @@ const (
 )
 
 type AlertmanagerSrv struct {
-	log    log.Logger
-	ac     accesscontrol.AccessControl
-	mam    *notifier.MultiOrgAlertmanager
-	crypto notifier.Crypto
+	log       log.Logger
+	ac        accesscontrol.AccessControl
+	mam       *notifier.MultiOrgAlertmanager
+	crypto    notifier.Crypto
+	ruleStore RuleStore
+	// includeFolderLabel is true if the folder title is added to the labels of alerts.
+	includeFolderLabel bool
 }
 
 type UnknownReceiverError struct {
@@ -356,6 +362,88 @@ func (srv AlertmanagerSrv) RoutePostTestTemplates(c *contextmodel.ReqContext, bo
 	return response.JSON(http.StatusOK, newTestTemplateResult(res))
 }
 
+func (srv AlertmanagerSrv) RoutePostTestRoutes(c *contextmodel.ReqContext, body apimodels.TestRoutesConfigBodyParams) response.Response {
+	orgID := c.SignedInUser.GetOrgID()
+	am, errResp := srv.AlertmanagerFor(orgID)
+	if errResp != nil {
+		return errResp
+	}
+
+	lset := model.LabelSet{}
+	if body.RuleUID != "" {
+		rules, err := srv.ruleStore.GetAlertRulesGroupByRuleUID(c.Req.Context(), &ngmodels.GetAlertRulesGroupByRuleUIDQuery{UID: body.RuleUID, OrgID: orgID})
+		if err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "failed to get alert rule")
+		}
+		var rule *ngmodels.AlertRule
+		for _, r := range rules {
+			if r.UID == body.RuleUID {
+				rule = r
+				break
+			}
+		}
+		if rule == nil {
+			return ErrResp(http.StatusNotFound, ngmodels.ErrAlertRuleNotFound, "")
+		}
+		namespace, err := srv.ruleStore.GetNamespaceByUID(c.Req.Context(), rule.NamespaceUID, orgID, c.SignedInUser)
+		if err != nil {
+			return toNamespaceErrorResponse(err)
+		}
+		for name, value := range rule.Labels {
+			lset[model.LabelName(name)] = model.LabelValue(value)
+		}
+		for name, value := range state.GetRuleExtraLabels(rule, namespace.Title, srv.includeFolderLabel) {
+			lset[model.LabelName(name)] = model.LabelValue(value)
+		}
+	}
+	for name, value := range body.Labels {
+		lset[name] = value
+	}
+	if len(lset) == 0 {
+		return ErrResp(http.StatusBadRequest, errors.New("either labels or rule UID must be specified"), "")
+	}
+
+	var cfg apimodels.Config
+	if body.AlertmanagerConfig != nil {
+		cfg = body.AlertmanagerConfig.Config
+	} else {
+		current, err := srv.mam.GetAlertmanagerConfiguration(c.Req.Context(), orgID)
+		if err != nil {
+			if errors.Is(err, store.ErrNoAlertmanagerConfiguration) {
+				return ErrResp(http.StatusNotFound, err, "")
+			}
+			return ErrResp(http.StatusInternalServerError, err, "failed to get Alertmanager configuration")
+		}
+		cfg = current.AlertmanagerConfig.Config
+	}
+
+	alerts, err := am.GetAlerts(c.Req.Context(), true, true, true, nil, "")
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get firing alerts")
+	}
+	firing := make([]model.LabelSet, 0, len(alerts))
+	for _, alert := range alerts {
+		labels := make(model.LabelSet, len(alert.Labels))
+		for name, value := range alert.Labels {
+			labels[model.LabelName(name)] = model.LabelValue(value)
+		}
+		firing = append(firing, labels)
+	}
+
+	now := time.Now()
+	if body.Time != nil {
+		now = *body.Time
+	}
+	result, err := notifier.TestRoutes(cfg, lset, firing, now)
+	if err != nil {
+		if errors.Is(err, notifier.ErrTestRoutesInvalidConfig) {
+			return ErrResp(http.StatusBadRequest, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusOK, newTestRoutesResult(result))
+}
+
 // contextWithTimeoutFromRequest returns a context with a deadline set from the
 // Request-Timeout header in the HTTP request. If the header is absent then the
 // context will use the default timeout. The timeout in the Request-Timeout
@@ -481,3 +569,30 @@ func (srv AlertmanagerSrv) AlertmanagerFor(orgID int64) (notifier.Alertmanager,
 	srv.log.Error("Unable to obtain the org's Alertmanager", "error", err)
 	return nil, response.Error(http.StatusInternalServerError, "unable to obtain org's Alertmanager", err)
 }
+
+func newTestRoutesResult(r *notifier.TestRoutesResult) apimodels.TestRoutesResult {
+	result := apimodels.TestRoutesResult{
+		Labels: r.Labels,
+		Routes: make([]apimodels.TestRouteResult, 0, len(r.Routes)),
+	}
+	for _, route := range r.Routes {
+		result.Routes = append(result.Routes, apimodels.TestRouteResult{
+			Path:                    route.Path,
+			Receiver:                route.Opts.Receiver,
+			GroupBy:                 notifier.GroupByLabels(route.Opts),
+			GroupWait:               model.Duration(route.Opts.GroupWait),
+			GroupInterval:           model.Duration(route.Opts.GroupInterval),
+			RepeatInterval:          model.Duration(route.Opts.RepeatInterval),
+			MuteTimeIntervals:       route.Opts.MuteTimeIntervals,
+			ActiveMuteTimeIntervals: route.ActiveMuteTimeIntervals,
+		})
+	}
+	for _, rule := range r.InhibitRules {
+		result.InhibitRules = append(result.InhibitRules, apimodels.TestInhibitRuleResult{
+			Index:  rule.Index,
+			Rule:   rule.Rule,
+			Active: rule.Active,
+		})
+	}
+	return result
+}
@@ -13,6 +13,7 @@ import (
 	alertingNotify "github.com/grafana/alerting/notify"
 	amv2 "github.com/prometheus/alertmanager/api/v2/models"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/api/response"
@@ -495,6 +496,57 @@ func TestRoutePostTestTemplates(t *testing.T) {
 	})
 }
 
+func TestRoutePostTestRoutes(t *testing.T) {
+	sut := createSut(t)
+
+	t.Run("assert 404 when no alertmanager found", func(t *testing.T) {
+		response := sut.RoutePostTestRoutes(createRequestCtxInOrg(10), apimodels.TestRoutesConfigBodyParams{})
+		require.Equal(t, 404, response.Status())
+	})
+
+	t.Run("assert 400 when neither labels nor rule UID are specified", func(t *testing.T) {
+		response := sut.RoutePostTestRoutes(createRequestCtxInOrg(1), apimodels.TestRoutesConfigBodyParams{})
+		require.Equal(t, 400, response.Status())
+	})
+
+	t.Run("assert 200 and the current configuration is used if no configuration is specified", func(t *testing.T) {
+		response := sut.RoutePostTestRoutes(createRequestCtxInOrg(1), apimodels.TestRoutesConfigBodyParams{
+			Labels: model.LabelSet{"team": "a"},
+		})
+		require.Equal(t, 200, response.Status())
+
+		var result apimodels.TestRoutesResult
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Len(t, result.Routes, 1)
+		require.Equal(t, "grafana-default-email", result.Routes[0].Receiver)
+		require.Empty(t, result.Routes[0].Path)
+	})
+
+	t.Run("assert 200 and the proposed configuration is used", func(t *testing.T) {
+		request := createAmConfigRequest(t, `{
+			"alertmanager_config": {
+				"route": {
+					"receiver": "default",
+					"routes": [{"receiver": "team-a", "object_matchers": [["team", "=", "a"]], "group_by": ["alertname"]}]
+				},
+				"receivers": [{"name": "default"}, {"name": "team-a"}]
+			}
+		}`)
+		response := sut.RoutePostTestRoutes(createRequestCtxInOrg(1), apimodels.TestRoutesConfigBodyParams{
+			Labels:             model.LabelSet{"team": "a"},
+			AlertmanagerConfig: &request.AlertmanagerConfig,
+		})
+		require.Equal(t, 200, response.Status())
+
+		var result apimodels.TestRoutesResult
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Len(t, result.Routes, 1)
+		require.Equal(t, "team-a", result.Routes[0].Receiver)
+		require.Equal(t, []int{0}, result.Routes[0].Path)
+		require.Equal(t, []string{"alertname"}, result.Routes[0].GroupBy)
+	})
+}
+
 func TestSilenceCreate(t *testing.T) {
 	makeSilence := func(comment string, createdBy string,
 		startsAt, endsAt strfmt.DateTime, matchers amv2.Matchers) amv2.Silence {
@@ -28,6 +28,7 @@ type ProvisioningSrv struct {
 	templates           TemplateService
 	muteTimings         MuteTimingService
 	alertRules          AlertRuleService
+	maintenanceWindows  MaintenanceWindowService
 }
 
 type ContactPointService interface {
@@ -57,6 +58,14 @@ type MuteTimingService interface {
 	DeleteMuteTiming(ctx context.Context, name string, orgID int64) error
 }
 
+type MaintenanceWindowService interface {
+	GetMaintenanceWindows(ctx context.Context, orgID int64) ([]*alerting_models.MaintenanceWindow, error)
+	GetMaintenanceWindow(ctx context.Context, orgID int64, uid string) (*alerting_models.MaintenanceWindow, error)
+	CreateMaintenanceWindow(ctx context.Context, window alerting_models.MaintenanceWindow) (*alerting_models.MaintenanceWindow, error)
+	UpdateMaintenanceWindow(ctx context.Context, window alerting_models.MaintenanceWindow) (*alerting_models.MaintenanceWindow, error)
+	DeleteMaintenanceWindow(ctx context.Context, orgID int64, uid string) error
+}
+
 type AlertRuleService interface {
 	GetAlertRules(ctx context.Context, orgID int64) ([]*alerting_models.AlertRule, map[string]alerting_models.Provenance, error)
 	GetAlertRule(ctx context.Context, orgID int64, ruleUID string) (alerting_models.AlertRule, alerting_models.Provenance, error)
@@ -309,6 +318,51 @@ func (srv *ProvisioningSrv) RouteDeleteMuteTiming(c *contextmodel.ReqContext, na
 	return response.JSON(http.StatusNoContent, nil)
 }
 
+func (srv *ProvisioningSrv) RouteGetMaintenanceWindows(c *contextmodel.ReqContext) response.Response {
+	windows, err := srv.maintenanceWindows.GetMaintenanceWindows(c.Req.Context(), c.SignedInUser.GetOrgID())
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to get maintenance windows", err)
+	}
+	result := make(definitions.MaintenanceWindows, 0, len(windows))
+	for _, w := range windows {
+		result = append(result, ApiMaintenanceWindowFromMaintenanceWindow(w))
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (srv *ProvisioningSrv) RouteGetMaintenanceWindow(c *contextmodel.ReqContext, uid string) response.Response {
+	window, err := srv.maintenanceWindows.GetMaintenanceWindow(c.Req.Context(), c.SignedInUser.GetOrgID(), uid)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to get maintenance window", err)
+	}
+	return response.JSON(http.StatusOK, ApiMaintenanceWindowFromMaintenanceWindow(window))
+}
+
+func (srv *ProvisioningSrv) RoutePostMaintenanceWindow(c *contextmodel.ReqContext, mw definitions.MaintenanceWindow) response.Response {
+	created, err := srv.maintenanceWindows.CreateMaintenanceWindow(c.Req.Context(), MaintenanceWindowFromApiMaintenanceWindow(c.SignedInUser.GetOrgID(), mw))
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to create maintenance window", err)
+	}
+	return response.JSON(http.StatusCreated, ApiMaintenanceWindowFromMaintenanceWindow(created))
+}
+
+func (srv *ProvisioningSrv) RoutePutMaintenanceWindow(c *contextmodel.ReqContext, mw definitions.MaintenanceWindow, uid string) response.Response {
+	mw.UID = uid
+	updated, err := srv.maintenanceWindows.UpdateMaintenanceWindow(c.Req.Context(), MaintenanceWindowFromApiMaintenanceWindow(c.SignedInUser.GetOrgID(), mw))
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to update maintenance window", err)
+	}
+	return response.JSON(http.StatusAccepted, ApiMaintenanceWindowFromMaintenanceWindow(updated))
+}
+
+func (srv *ProvisioningSrv) RouteDeleteMaintenanceWindow(c *contextmodel.ReqContext, uid string) response.Response {
+	err := srv.maintenanceWindows.DeleteMaintenanceWindow(c.Req.Context(), c.SignedInUser.GetOrgID(), uid)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to delete maintenance window", err)
+	}
+	return response.JSON(http.StatusNoContent, nil)
+}
+
 func (srv *ProvisioningSrv) RouteGetAlertRules(c *contextmodel.ReqContext) response.Response {
 	rules, provenances, err := srv.alertRules.GetAlertRules(c.Req.Context(), c.SignedInUser.GetOrgID())
 	if err != nil {
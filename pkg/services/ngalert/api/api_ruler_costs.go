@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const (
+	defaultRuleCostsLimit = 10
+	maxRuleCostsLimit     = 1000
+)
+
+// RuleCostReader provides the cost of the evaluation of alert rules.
+type RuleCostReader interface {
+	RuleCosts(orgID int64) []ngmodels.AlertRuleCost
+}
+
+// RouteGetRuleCost returns the cost of the evaluation of the rule.
+// Returns http.StatusNotFound if the rule has not been evaluated by this instance yet.
+func (srv RulerSrv) RouteGetRuleCost(c *contextmodel.ReqContext, ruleUID string) response.Response {
+	rule, err := srv.getAuthorizedRuleByUid(c.Req.Context(), c, ruleUID)
+	if err != nil {
+		return ruleErrorResponse(err)
+	}
+	for _, cost := range srv.costs.RuleCosts(rule.OrgID) {
+		if cost.UID == rule.UID {
+			return response.JSON(http.StatusOK, toRuleCost(cost))
+		}
+	}
+	return ErrResp(http.StatusNotFound, fmt.Errorf("rule %s has not been evaluated by this instance", rule.UID), "")
+}
+
+// RouteGetRuleCosts returns the most expensive rules that the user is authorized to access, most expensive first.
+func (srv RulerSrv) RouteGetRuleCosts(c *contextmodel.ReqContext) response.Response {
+	limit := c.QueryInt64WithDefault("limit", defaultRuleCostsLimit)
+	if limit <= 0 || limit > maxRuleCostsLimit {
+		return ErrResp(http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", maxRuleCostsLimit), "")
+	}
+	sortKey := ngmodels.AlertRuleCostSortByDuration
+	if s := c.Query("sort"); s != "" {
+		sortKey = ngmodels.AlertRuleCostSortKey(s)
+		if !sortKey.IsValid() {
+			return ErrResp(http.StatusBadRequest, fmt.Errorf("unknown sort key %q", s), "")
+		}
+	}
+
+	namespaceMap, err := srv.store.GetUserVisibleNamespaces(c.Req.Context(), c.SignedInUser.GetOrgID(), c.SignedInUser)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get namespaces visible to the user")
+	}
+	result := apimodels.RuleCosts{}
+	if len(namespaceMap) == 0 {
+		return response.JSON(http.StatusOK, result)
+	}
+	namespaceUIDs := make([]string, 0, len(namespaceMap))
+	for uid := range namespaceMap {
+		namespaceUIDs = append(namespaceUIDs, uid)
+	}
+	groups, _, err := srv.searchAuthorizedAlertRules(c.Req.Context(), c, namespaceUIDs, "", 0)
+	if err != nil {
+		return errorToResponse(err)
+	}
+	authorized := make(map[string]struct{})
+	for _, rules := range groups {
+		for _, rule := range rules {
+			authorized[rule.UID] = struct{}{}
+		}
+	}
+
+	costs := make([]ngmodels.AlertRuleCost, 0, len(authorized))
+	for _, cost := range srv.costs.RuleCosts(c.SignedInUser.GetOrgID()) {
+		if _, ok := authorized[cost.UID]; ok {
+			costs = append(costs, cost)
+		}
+	}
+	sort.SliceStable(costs, func(i, j int) bool {
+		return sortKey.Less(costs[j], costs[i])
+	})
+	if int64(len(costs)) > limit {
+		costs = costs[:limit]
+	}
+	for _, cost := range costs {
+		result = append(result, toRuleCost(cost))
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func toRuleCost(cost ngmodels.AlertRuleCost) apimodels.RuleCost {
+	return apimodels.RuleCost{
+		RuleUID:            cost.UID,
+		Title:              cost.Title,
+		FolderUID:          cost.NamespaceUID,
+		RuleGroup:          cost.RuleGroup,
+		Evaluations:        cost.Evaluations,
+		Failures:           cost.Failures,
+		MissedTicks:        cost.MissedTicks,
+		AvgDurationSeconds: cost.AvgDuration.Seconds(),
+		MaxDurationSeconds: cost.MaxDuration.Seconds(),
+		AvgQueryBytes:      cost.AvgQueryBytes,
+		AvgSeries:          cost.AvgSeries,
+		LastEvaluation:     cost.LastEvaluation,
+		BackoffFactor:      cost.BackoffFactor,
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/folder"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+)
+
+type fakeRuleCostReader []models.AlertRuleCost
+
+func (f fakeRuleCostReader) RuleCosts(orgID int64) []models.AlertRuleCost {
+	result := make([]models.AlertRuleCost, 0, len(f))
+	for _, cost := range f {
+		if cost.OrgID == orgID {
+			result = append(result, cost)
+		}
+	}
+	return result
+}
+
+func TestRouteGetRuleCosts(t *testing.T) {
+	orgID := rand.Int63()
+	folder1, folder2 := randFolder(), randFolder()
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.Folders[orgID] = []*folder.Folder{folder1, folder2}
+	rule1 := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder1))()
+	rule2 := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder1))()
+	rule3 := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder2))()
+	notEvaluated := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder2))()
+	ruleStore.PutRule(context.Background(), rule1, rule2, rule3, notEvaluated)
+
+	costs := fakeRuleCostReader{
+		{AlertRuleKey: rule1.GetKey(), AvgDuration: time.Second, AvgQueryBytes: 300, Evaluations: 3, BackoffFactor: 1},
+		{AlertRuleKey: rule2.GetKey(), AvgDuration: 3 * time.Second, AvgQueryBytes: 100, Evaluations: 3, BackoffFactor: 2},
+		{AlertRuleKey: rule3.GetKey(), AvgDuration: 2 * time.Second, AvgQueryBytes: 200, Evaluations: 3, BackoffFactor: 1},
+	}
+	createCostService := func() *RulerSrv {
+		svc := createService(ruleStore)
+		svc.costs = costs
+		return svc
+	}
+	uids := func(result apimodels.RuleCosts) []string {
+		var r []string
+		for _, c := range result {
+			r = append(r, c.RuleUID)
+		}
+		return r
+	}
+
+	t.Run("should return rules sorted by cost", func(t *testing.T) {
+		response := createCostService().RouteGetRuleCosts(createRequestContext(orgID, nil))
+		require.Equalf(t, http.StatusOK, response.Status(), "unexpected response: %s", string(response.Body()))
+		var result apimodels.RuleCosts
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Equal(t, []string{rule2.UID, rule3.UID, rule1.UID}, uids(result))
+		require.Equal(t, 3.0, result[0].AvgDurationSeconds)
+		require.Equal(t, 2, result[0].BackoffFactor)
+	})
+
+	t.Run("should sort by query and limit the result", func(t *testing.T) {
+		req := createRequestContext(orgID, nil)
+		req.Req.Form.Set("sort", "query_bytes")
+		req.Req.Form.Set("limit", "2")
+		response := createCostService().RouteGetRuleCosts(req)
+		require.Equal(t, http.StatusOK, response.Status())
+		var result apimodels.RuleCosts
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Equal(t, []string{rule1.UID, rule3.UID}, uids(result))
+	})
+
+	t.Run("should return 400 if sort key is unknown", func(t *testing.T) {
+		req := createRequestContext(orgID, nil)
+		req.Req.Form.Set("sort", "unknown")
+		response := createCostService().RouteGetRuleCosts(req)
+		require.Equal(t, http.StatusBadRequest, response.Status())
+	})
+
+	t.Run("should not return rules the user cannot access", func(t *testing.T) {
+		req := createRequestContextWithPerms(orgID, createPermissionsForRules([]*models.AlertRule{rule1, rule2}, orgID), nil)
+		response := createCostService().RouteGetRuleCosts(req)
+		require.Equal(t, http.StatusOK, response.Status())
+		var result apimodels.RuleCosts
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Equal(t, []string{rule2.UID, rule1.UID}, uids(result))
+	})
+
+	t.Run("should return cost of a single rule", func(t *testing.T) {
+		response := createCostService().RouteGetRuleCost(createRequestContext(orgID, nil), rule1.UID)
+		require.Equal(t, http.StatusOK, response.Status())
+		var result apimodels.RuleCost
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Equal(t, rule1.UID, result.RuleUID)
+		require.Equal(t, int64(300), result.AvgQueryBytes)
+
+		response = createCostService().RouteGetRuleCost(createRequestContext(orgID, nil), notEvaluated.UID)
+		require.Equal(t, http.StatusNotFound, response.Status())
+	})
+}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
@@ -10,6 +12,7 @@ import (
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/infra/log"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 )
 
@@ -17,9 +20,15 @@ type Historian interface {
 	Query(ctx context.Context, query models.HistoryQuery) (*data.Frame, error)
 }
 
+// NotificationHistorian provides access to the history of attempts to deliver notifications.
+type NotificationHistorian interface {
+	Query(ctx context.Context, query models.NotificationHistoryQuery) ([]models.NotificationHistoryEntry, error)
+}
+
 type HistorySrv struct {
-	logger log.Logger
-	hist   Historian
+	logger           log.Logger
+	hist             Historian
+	notificationHist NotificationHistorian
 }
 
 const labelQueryPrefix = "labels_"
@@ -38,6 +47,10 @@ func (srv *HistorySrv) RouteQueryStateHistory(c *contextmodel.ReqContext) respon
 			labels[k[len(labelQueryPrefix):]] = v[0]
 		}
 	}
+	matchers, err := getMatchersFromRequest(c.Req)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
 
 	query := models.HistoryQuery{
 		RuleUID:      ruleUID,
@@ -49,6 +62,7 @@ func (srv *HistorySrv) RouteQueryStateHistory(c *contextmodel.ReqContext) respon
 		To:           time.Unix(to, 0),
 		Limit:        limit,
 		Labels:       labels,
+		Matchers:     matchers,
 	}
 	frame, err := srv.hist.Query(c.Req.Context(), query)
 	if err != nil {
@@ -56,3 +70,61 @@ func (srv *HistorySrv) RouteQueryStateHistory(c *contextmodel.ReqContext) respon
 	}
 	return response.JSON(http.StatusOK, frame)
 }
+
+func (srv *HistorySrv) RouteQueryNotificationHistory(c *contextmodel.ReqContext) response.Response {
+	if srv.notificationHist == nil {
+		return ErrResp(http.StatusNotFound, errors.New("notification history is not enabled"), "")
+	}
+
+	status := models.NotificationStatus(c.Query("status"))
+	switch status {
+	case "", models.NotificationStatusSuccess, models.NotificationStatusFailed:
+	default:
+		return ErrResp(http.StatusBadRequest, fmt.Errorf("invalid status %q, must be one of: %s, %s", status, models.NotificationStatusSuccess, models.NotificationStatusFailed), "")
+	}
+
+	query := models.NotificationHistoryQuery{
+		OrgID:            c.SignedInUser.GetOrgID(),
+		Receiver:         c.Query("receiver"),
+		Integration:      c.Query("integration"),
+		Status:           status,
+		GroupKey:         c.Query("groupKey"),
+		AlertFingerprint: c.Query("fingerprint"),
+		Limit:            c.QueryInt("limit"),
+	}
+	if from := c.QueryInt64("from"); from > 0 {
+		query.From = time.Unix(from, 0)
+	}
+	if to := c.QueryInt64("to"); to > 0 {
+		query.To = time.Unix(to, 0)
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.From.After(query.To) {
+		return ErrResp(http.StatusBadRequest, errors.New("the start of the time range cannot be after the end"), "")
+	}
+
+	entries, err := srv.notificationHist.Query(c.Req.Context(), query)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to query notification history")
+	}
+
+	result := apimodels.NotificationHistoryResponse{
+		Entries: make([]apimodels.NotificationHistoryEntry, 0, len(entries)),
+	}
+	for _, e := range entries {
+		result.Entries = append(result.Entries, apimodels.NotificationHistoryEntry{
+			Timestamp:         e.Timestamp,
+			Receiver:          e.Receiver,
+			Integration:       e.Integration,
+			IntegrationIndex:  e.IntegrationIndex,
+			GroupKey:          e.GroupKey,
+			AlertFingerprints: e.AlertFingerprints,
+			AlertsFiring:      e.AlertsFiring,
+			AlertsResolved:    e.AlertsResolved,
+			Status:            string(e.Status),
+			Retry:             e.Retry,
+			Error:             e.Error,
+			DurationMs:        e.Duration.Milliseconds(),
+		})
+	}
+	return response.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/prom"
+)
+
+// RoutePostImportPrometheusRules converts Prometheus rule groups to Grafana-managed rules and saves them in the folder,
+// replacing the rule groups with the same name. Existing rules of these groups are updated if their title matches the
+// name of an alert. Rules that cannot be converted or fail validation are skipped and reported as issues. Existing rules
+// whose import is skipped are kept in the group unchanged.
+// If the request is a dry run, the converted rules are returned but not saved.
+func (srv RulerSrv) RoutePostImportPrometheusRules(c *contextmodel.ReqContext, body apimodels.PrometheusImportRequest) response.Response {
+	if body.FolderUID == "" {
+		return ErrResp(http.StatusBadRequest, errors.New("folder_uid must be specified"), "")
+	}
+	if body.DatasourceUID == "" {
+		return ErrResp(http.StatusBadRequest, errors.New("datasource_uid must be specified"), "")
+	}
+	namespace, err := srv.store.GetNamespaceByUID(c.Req.Context(), body.FolderUID, c.SignedInUser.GetOrgID(), c.SignedInUser)
+	if err != nil {
+		return toNamespaceErrorResponse(err)
+	}
+
+	converter, err := prom.NewConverter(prom.Config{
+		OrgID:           c.SignedInUser.GetOrgID(),
+		NamespaceUID:    namespace.UID,
+		DatasourceUID:   body.DatasourceUID,
+		DefaultInterval: srv.cfg.DefaultRuleEvaluationInterval,
+	})
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	groups, issues := converter.Convert(body.Groups)
+	groups, issues = srv.validateImportedRuleGroups(c, groups, issues)
+	skipped := skippedRules(issues)
+
+	submitted := make([][]*ngmodels.AlertRuleWithOptionals, 0, len(groups))
+	importGroups := func(ctx context.Context) error {
+		for _, group := range groups {
+			groupKey := ngmodels.AlertRuleGroupKey{
+				OrgID:        c.SignedInUser.GetOrgID(),
+				NamespaceUID: namespace.UID,
+				RuleGroup:    group.Name,
+			}
+			rules, err := srv.matchExistingRules(ctx, groupKey, group, skipped[group.Name])
+			if err != nil {
+				return err
+			}
+			submitted = append(submitted, rules)
+			if body.DryRun {
+				continue
+			}
+			if _, err := srv.applyAlertRuleGroupChanges(ctx, c, groupKey, rules); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if body.DryRun {
+		err = importGroups(c.Req.Context())
+	} else {
+		// all groups are imported in a single transaction, so a failure leaves no group half imported.
+		err = srv.xactManager.InTransaction(c.Req.Context(), importGroups)
+	}
+	if err != nil {
+		return updateRuleGroupErrorResponse(err)
+	}
+
+	result := apimodels.PrometheusImportResponse{
+		DryRun: body.DryRun,
+		Groups: make([]apimodels.GettableRuleGroupConfig, 0, len(groups)),
+		Issues: make([]apimodels.PrometheusImportIssue, 0, len(issues)),
+	}
+	for i, group := range groups {
+		rules := make(ngmodels.RulesGroup, 0, len(submitted[i]))
+		for _, rule := range submitted[i] {
+			rules = append(rules, &rule.AlertRule)
+		}
+		result.Groups = append(result.Groups, toGettableRuleGroupConfig(group.Name, rules, nil))
+	}
+	for _, issue := range issues {
+		result.Issues = append(result.Issues, apimodels.PrometheusImportIssue{
+			Group:   issue.Group,
+			Rule:    issue.Rule,
+			Skipped: issue.Skipped,
+			Message: issue.Message,
+		})
+	}
+	if body.DryRun {
+		return response.JSON(http.StatusOK, result)
+	}
+	return response.JSON(http.StatusAccepted, result)
+}
+
+// validateImportedRuleGroups validates the converted rule groups the same way as the rules submitted to the ruler API.
+// Groups and rules that fail validation are removed from the result and reported as issues.
+func (srv RulerSrv) validateImportedRuleGroups(c *contextmodel.ReqContext, groups []prom.RuleGroup, issues []prom.Issue) ([]prom.RuleGroup, []prom.Issue) {
+	result := make([]prom.RuleGroup, 0, len(groups))
+	for _, group := range groups {
+		if _, err := validateInterval(srv.cfg, group.Interval); err != nil {
+			issues = append(issues, prom.Issue{Group: group.Name, Skipped: true, Message: err.Error()})
+			continue
+		}
+		valid := make([]ngmodels.AlertRule, 0, len(group.Rules))
+		for _, rule := range group.Rules {
+			err := rule.ValidateAlertRule(*srv.cfg)
+			if err == nil {
+				err = srv.conditionValidator.Validate(eval.NewContext(c.Req.Context(), c.SignedInUser), rule.GetEvalCondition())
+			}
+			if err != nil {
+				issues = append(issues, prom.Issue{Group: group.Name, Rule: rule.Title, Skipped: true, Message: err.Error()})
+				continue
+			}
+			rule.RuleGroupIndex = len(valid) + 1
+			valid = append(valid, rule)
+		}
+		if len(valid) == 0 {
+			issues = append(issues, prom.Issue{Group: group.Name, Skipped: true, Message: "rule group has no valid rules"})
+			continue
+		}
+		group.Rules = valid
+		result = append(result, group)
+	}
+	return result, issues
+}
+
+// skippedRules returns the titles of the rules whose import is skipped, by rule group.
+func skippedRules(issues []prom.Issue) map[string]map[string]struct{} {
+	result := make(map[string]map[string]struct{})
+	for _, issue := range issues {
+		if !issue.Skipped || issue.Rule == "" {
+			continue
+		}
+		if result[issue.Group] == nil {
+			result[issue.Group] = make(map[string]struct{})
+		}
+		result[issue.Group][issue.Rule] = struct{}{}
+	}
+	return result
+}
+
+// matchExistingRules returns the rules to submit to the group. The rules that have the same title as a rule of the
+// group get its UID so that the existing rule is updated instead of being replaced by a new one. The existing rules
+// whose title is skipped are submitted unchanged, except for the interval of the group, so they are not deleted.
+func (srv RulerSrv) matchExistingRules(ctx context.Context, groupKey ngmodels.AlertRuleGroupKey, group prom.RuleGroup, skipped map[string]struct{}) ([]*ngmodels.AlertRuleWithOptionals, error) {
+	existing, err := srv.store.ListAlertRules(ctx, &ngmodels.ListAlertRulesQuery{
+		OrgID:         groupKey.OrgID,
+		NamespaceUIDs: []string{groupKey.NamespaceUID},
+		RuleGroup:     groupKey.RuleGroup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query database for rules in the group %s: %w", groupKey, err)
+	}
+	uids := make(map[string]string, len(existing))
+	for _, rule := range existing {
+		uids[rule.Title] = rule.UID
+	}
+	result := make([]*ngmodels.AlertRuleWithOptionals, 0, len(group.Rules))
+	for _, rule := range group.Rules {
+		rule.UID = uids[rule.Title]
+		result = append(result, &ngmodels.AlertRuleWithOptionals{AlertRule: rule})
+	}
+	for _, rule := range existing {
+		if _, ok := skipped[rule.Title]; !ok {
+			continue
+		}
+		kept := *rule
+		kept.IntervalSeconds = int64(group.Interval.Seconds())
+		kept.RuleGroupIndex = len(result) + 1
+		result = append(result, &ngmodels.AlertRuleWithOptionals{AlertRule: kept})
+	}
+	return result, nil
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/folder"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+	"github.com/grafana/grafana/pkg/services/quota/quotatest"
+)
+
+func TestRoutePostImportPrometheusRules(t *testing.T) {
+	orgID := rand.Int63()
+	f := randFolder()
+
+	editorPermissions := func(folderUID string) map[int64]map[string][]string {
+		scope := dashboards.ScopeFoldersProvider.GetResourceScopeUID(folderUID)
+		return map[int64]map[string][]string{orgID: {
+			datasources.ActionQuery:     {datasources.ScopeAll},
+			ac.ActionAlertingRuleRead:   {scope},
+			ac.ActionAlertingRuleCreate: {scope},
+			ac.ActionAlertingRuleUpdate: {scope},
+			ac.ActionAlertingRuleDelete: {scope},
+		}}
+	}
+
+	setup := func(t *testing.T, rules ...*models.AlertRule) (*fakes.RuleStore, *RulerSrv) {
+		ruleStore := fakes.NewRuleStore(t)
+		ruleStore.Folders[orgID] = []*folder.Folder{f}
+		ruleStore.PutRule(context.Background(), rules...)
+		svc := createService(ruleStore)
+		svc.cfg.DefaultRuleEvaluationInterval = time.Minute
+		svc.conditionValidator = &recordingConditionValidator{}
+		svc.QuotaService = quotatest.New(false, nil)
+		return ruleStore, svc
+	}
+
+	body := func(dryRun bool) apimodels.PrometheusImportRequest {
+		return apimodels.PrometheusImportRequest{
+			FolderUID:     f.UID,
+			DatasourceUID: "prom",
+			DryRun:        dryRun,
+			Groups: []apimodels.PrometheusRuleGroup{
+				{
+					Name:     "api",
+					Interval: model.Duration(30 * time.Second),
+					Rules: []apimodels.ApiRuleNode{
+						{Alert: "HighErrorRate", Expr: "rate(errors_total[5m]) > 1"},
+						{Alert: "InstanceDown", Expr: "up == 0"},
+						{Record: "job:errors:rate5m", Expr: "sum by (job) (rate(errors_total[5m]))"},
+					},
+				},
+			},
+		}
+	}
+
+	getInserts := func(ruleStore *fakes.RuleStore) []models.AlertRule {
+		var result []models.AlertRule
+		for _, cmd := range ruleStore.GetRecordedCommands(func(cmd any) (any, bool) {
+			c, ok := cmd.([]models.AlertRule)
+			return c, ok
+		}) {
+			result = append(result, cmd.([]models.AlertRule)...)
+		}
+		return result
+	}
+
+	t.Run("dry run should return converted rules and not save them", func(t *testing.T) {
+		ruleStore, svc := setup(t)
+		response := svc.RoutePostImportPrometheusRules(createRequestContextWithPerms(orgID, editorPermissions(f.UID), nil), body(true))
+		require.Equalf(t, http.StatusOK, response.Status(), "unexpected response: %s", string(response.Body()))
+
+		var result apimodels.PrometheusImportResponse
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		assert.True(t, result.DryRun)
+		require.Len(t, result.Groups, 1)
+		assert.Equal(t, "api", result.Groups[0].Name)
+		assert.Equal(t, model.Duration(30*time.Second), result.Groups[0].Interval)
+		require.Len(t, result.Groups[0].Rules, 2)
+		assert.Equal(t, "HighErrorRate", result.Groups[0].Rules[0].GrafanaManagedAlert.Title)
+		assert.Equal(t, []apimodels.PrometheusImportIssue{
+			{Group: "api", Rule: "job:errors:rate5m", Skipped: true, Message: "recording rules are not supported"},
+		}, result.Issues)
+		assert.Empty(t, getInserts(ruleStore))
+	})
+
+	t.Run("should save converted rules", func(t *testing.T) {
+		ruleStore, svc := setup(t)
+		response := svc.RoutePostImportPrometheusRules(createRequestContextWithPerms(orgID, editorPermissions(f.UID), nil), body(false))
+		require.Equalf(t, http.StatusAccepted, response.Status(), "unexpected response: %s", string(response.Body()))
+
+		inserts := getInserts(ruleStore)
+		require.Len(t, inserts, 2)
+		assert.Equal(t, "HighErrorRate", inserts[0].Title)
+		assert.Equal(t, f.UID, inserts[0].NamespaceUID)
+		assert.Equal(t, "api", inserts[0].RuleGroup)
+		assert.Equal(t, int64(30), inserts[0].IntervalSeconds)
+	})
+
+	t.Run("should update existing rules with the same title", func(t *testing.T) {
+		existing := models.AlertRuleGen(withOrgID(orgID), withNamespace(f), withGroup("api"), models.WithTitle("InstanceDown"), models.WithInterval(30*time.Second))()
+		ruleStore, svc := setup(t, existing)
+		response := svc.RoutePostImportPrometheusRules(createRequestContextWithPerms(orgID, editorPermissions(f.UID), nil), body(false))
+		require.Equalf(t, http.StatusAccepted, response.Status(), "unexpected response: %s", string(response.Body()))
+
+		inserts := getInserts(ruleStore)
+		require.Len(t, inserts, 1)
+		assert.Equal(t, "HighErrorRate", inserts[0].Title)
+		var updates []models.UpdateRule
+		for _, cmd := range ruleStore.GetRecordedCommands(func(cmd any) (any, bool) {
+			c, ok := cmd.([]models.UpdateRule)
+			return c, ok
+		}) {
+			updates = append(updates, cmd.([]models.UpdateRule)...)
+		}
+		require.Len(t, updates, 1)
+		assert.Equal(t, existing.UID, updates[0].New.UID)
+	})
+
+	t.Run("should skip rules that fail validation", func(t *testing.T) {
+		ruleStore, svc := setup(t)
+		svc.conditionValidator = &recordingConditionValidator{hook: func(c models.Condition) error {
+			if c.Data[0].DatasourceUID == "prom" {
+				return errors.New("data source not found")
+			}
+			return nil
+		}}
+		response := svc.RoutePostImportPrometheusRules(createRequestContextWithPerms(orgID, editorPermissions(f.UID), nil), body(false))
+		require.Equalf(t, http.StatusAccepted, response.Status(), "unexpected response: %s", string(response.Body()))
+
+		var result apimodels.PrometheusImportResponse
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		assert.Empty(t, result.Groups)
+		assert.Contains(t, result.Issues, apimodels.PrometheusImportIssue{Group: "api", Rule: "InstanceDown", Skipped: true, Message: "data source not found"})
+		assert.Contains(t, result.Issues, apimodels.PrometheusImportIssue{Group: "api", Skipped: true, Message: "rule group has no valid rules"})
+		assert.Empty(t, getInserts(ruleStore))
+	})
+
+	t.Run("should keep existing rules whose import is skipped when a group is imported again", func(t *testing.T) {
+		gen := func(title string) *models.AlertRule {
+			return models.AlertRuleGen(withOrgID(orgID), withNamespace(f), withGroup("api"), models.WithTitle(title), models.WithInterval(30*time.Second))()
+		}
+		highErrorRate, instanceDown := gen("HighErrorRate"), gen("InstanceDown")
+		ruleStore, svc := setup(t, highErrorRate, instanceDown)
+		svc.conditionValidator = &recordingConditionValidator{hook: func(c models.Condition) error {
+			if strings.Contains(string(c.Data[0].Model), `"expr":"up"`) {
+				return errors.New("invalid query")
+			}
+			return nil
+		}}
+		response := svc.RoutePostImportPrometheusRules(createRequestContextWithPerms(orgID, editorPermissions(f.UID), nil), body(false))
+		require.Equalf(t, http.StatusAccepted, response.Status(), "unexpected response: %s", string(response.Body()))
+
+		var result apimodels.PrometheusImportResponse
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		assert.Contains(t, result.Issues, apimodels.PrometheusImportIssue{Group: "api", Rule: "InstanceDown", Skipped: true, Message: "invalid query"})
+
+		for _, op := range ruleStore.RecordedOps {
+			if q, ok := op.(fakes.GenericRecordedQuery); ok {
+				require.NotEqual(t, "DeleteAlertRulesByUID", q.Name)
+			}
+		}
+		assert.Empty(t, getInserts(ruleStore))
+		var updated []string
+		for _, cmd := range ruleStore.GetRecordedCommands(func(cmd any) (any, bool) {
+			c, ok := cmd.([]models.UpdateRule)
+			return c, ok
+		}) {
+			for _, update := range cmd.([]models.UpdateRule) {
+				updated = append(updated, update.New.UID)
+				if update.New.UID == instanceDown.UID {
+					assert.Equal(t, instanceDown.Data, update.New.Data)
+				}
+			}
+		}
+		assert.Contains(t, updated, highErrorRate.UID)
+	})
+
+	t.Run("should return 400 if data source is not specified", func(t *testing.T) {
+		_, svc := setup(t)
+		b := body(true)
+		b.DatasourceUID = ""
+		response := svc.RoutePostImportPrometheusRules(createRequestContextWithPerms(orgID, editorPermissions(f.UID), nil), b)
+		require.Equal(t, http.StatusBadRequest, response.Status())
+	})
+
+	t.Run("should return 403 if user cannot create rules in the folder", func(t *testing.T) {
+		ruleStore, svc := setup(t)
+		response := svc.RoutePostImportPrometheusRules(createRequestContextWithPerms(orgID, editorPermissions("other"), nil), body(false))
+		require.Equal(t, http.StatusForbidden, response.Status())
+		assert.Empty(t, getInserts(ruleStore))
+	})
+}
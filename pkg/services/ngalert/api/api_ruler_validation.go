@@ -95,6 +95,7 @@ func validateRuleNode(
 		RuleGroup:       groupName,
 		NoDataState:     noDataState,
 		ExecErrState:    errorState,
+		Dependencies:    AlertRuleDependenciesFromApiRuleDependencies(ruleNode.GrafanaManagedAlert.Dependencies),
 	}
 
 	newAlertRule.For, err = validateForInterval(ruleNode)
@@ -233,5 +234,88 @@ func validateRuleGroup(
 
 		result = append(result, &ruleWithOptionals)
 	}
+
+	if err := validateRuleDependencies(result); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
+
+// validateRuleDependencies checks that rules depend only on other rules of the same group and that dependencies do not form a cycle.
+func validateRuleDependencies(rules []*ngmodels.AlertRuleWithOptionals) error {
+	byUID := make(map[string]*ngmodels.AlertRuleWithOptionals, len(rules))
+	for _, rule := range rules {
+		if rule.UID != "" {
+			byUID[rule.UID] = rule
+		}
+	}
+
+	for idx, rule := range rules {
+		if len(rule.Dependencies) == 0 {
+			continue
+		}
+		if rule.UID == "" {
+			return fmt.Errorf("%w: rule [%d] has dependencies but no UID", ngmodels.ErrAlertRuleFailedValidation, idx)
+		}
+		seen := make(map[string]struct{}, len(rule.Dependencies))
+		for _, dep := range rule.Dependencies {
+			if dep.RuleUID == "" {
+				return fmt.Errorf("%w: rule [%d] has a dependency without a rule UID", ngmodels.ErrAlertRuleFailedValidation, idx)
+			}
+			if dep.RuleUID == rule.UID {
+				return fmt.Errorf("%w: rule [%d] cannot depend on itself", ngmodels.ErrAlertRuleFailedValidation, idx)
+			}
+			if _, ok := seen[dep.RuleUID]; ok {
+				return fmt.Errorf("%w: rule [%d] depends on rule %s more than once", ngmodels.ErrAlertRuleFailedValidation, idx, dep.RuleUID)
+			}
+			seen[dep.RuleUID] = struct{}{}
+			if _, ok := byUID[dep.RuleUID]; !ok {
+				return fmt.Errorf("%w: rule [%d] depends on rule %s that does not belong to the group", ngmodels.ErrAlertRuleFailedValidation, idx, dep.RuleUID)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make(map[string]int, len(byUID))
+	var path []string
+	var visit func(uid string) error
+	visit = func(uid string) error {
+		switch marks[uid] {
+		case visited:
+			return nil
+		case visiting:
+			start := 0
+			for i, p := range path {
+				if p == uid {
+					start = i
+					break
+				}
+			}
+			cycle := append(append([]string{}, path[start:]...), uid)
+			return fmt.Errorf("%w: rule dependencies form a cycle: %s", ngmodels.ErrAlertRuleFailedValidation, strings.Join(cycle, " -> "))
+		}
+		marks[uid] = visiting
+		path = append(path, uid)
+		for _, dep := range byUID[uid].Dependencies {
+			if err := visit(dep.RuleUID); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		marks[uid] = visited
+		return nil
+	}
+	for _, rule := range rules {
+		if rule.UID == "" {
+			continue
+		}
+		if err := visit(rule.UID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
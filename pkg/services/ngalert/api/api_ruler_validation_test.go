@@ -222,6 +222,20 @@ func TestValidateRuleGroup(t *testing.T) {
 			require.True(t, alert.HasPause)
 		}
 	})
+
+	t.Run("should accept dependencies on rules of the same group", func(t *testing.T) {
+		r1 := validRule()
+		r2 := validRule()
+		r3 := validRule()
+		r2.GrafanaManagedAlert.Dependencies = []apimodels.RuleDependency{{RuleUID: r1.GrafanaManagedAlert.UID, InhibitWhenFiring: true}}
+		r3.GrafanaManagedAlert.Dependencies = []apimodels.RuleDependency{{RuleUID: r1.GrafanaManagedAlert.UID}, {RuleUID: r2.GrafanaManagedAlert.UID}}
+		g := validGroup(cfg, r3, r2, r1)
+		alerts, err := validateRuleGroup(&g, orgId, folder, cfg)
+		require.NoError(t, err)
+		require.Equal(t, []models.AlertRuleDependency{{RuleUID: r1.GrafanaManagedAlert.UID}, {RuleUID: r2.GrafanaManagedAlert.UID}}, alerts[0].Dependencies)
+		require.Equal(t, []models.AlertRuleDependency{{RuleUID: r1.GrafanaManagedAlert.UID, InhibitWhenFiring: true}}, alerts[1].Dependencies)
+		require.Empty(t, alerts[2].Dependencies)
+	})
 }
 
 func TestValidateRuleGroupFailures(t *testing.T) {
@@ -283,6 +297,59 @@ func TestValidateRuleGroupFailures(t *testing.T) {
 				require.Contains(t, err.Error(), apiModel.Rules[0].GrafanaManagedAlert.UID)
 			},
 		},
+		{
+			name: "fail if rule depends on itself",
+			group: func() *apimodels.PostableRuleGroupConfig {
+				r1 := validRule()
+				r1.GrafanaManagedAlert.Dependencies = []apimodels.RuleDependency{{RuleUID: r1.GrafanaManagedAlert.UID}}
+				g := validGroup(cfg, r1)
+				return &g
+			},
+		},
+		{
+			name: "fail if rule depends on a rule outside of the group",
+			group: func() *apimodels.PostableRuleGroupConfig {
+				r1 := validRule()
+				r1.GrafanaManagedAlert.Dependencies = []apimodels.RuleDependency{{RuleUID: util.GenerateShortUID()}}
+				g := validGroup(cfg, r1, validRule())
+				return &g
+			},
+			assert: func(t *testing.T, apiModel *apimodels.PostableRuleGroupConfig, err error) {
+				require.ErrorIs(t, err, models.ErrAlertRuleFailedValidation)
+				require.Contains(t, err.Error(), apiModel.Rules[0].GrafanaManagedAlert.Dependencies[0].RuleUID)
+			},
+		},
+		{
+			name: "fail if rule without UID has dependencies",
+			group: func() *apimodels.PostableRuleGroupConfig {
+				r1 := validRule()
+				r2 := validRule()
+				r1.GrafanaManagedAlert.UID = ""
+				r1.GrafanaManagedAlert.Dependencies = []apimodels.RuleDependency{{RuleUID: r2.GrafanaManagedAlert.UID}}
+				g := validGroup(cfg, r1, r2)
+				return &g
+			},
+		},
+		{
+			name: "fail if dependencies form a cycle",
+			group: func() *apimodels.PostableRuleGroupConfig {
+				r1 := validRule()
+				r2 := validRule()
+				r3 := validRule()
+				r1.GrafanaManagedAlert.Dependencies = []apimodels.RuleDependency{{RuleUID: r2.GrafanaManagedAlert.UID}}
+				r2.GrafanaManagedAlert.Dependencies = []apimodels.RuleDependency{{RuleUID: r3.GrafanaManagedAlert.UID}}
+				r3.GrafanaManagedAlert.Dependencies = []apimodels.RuleDependency{{RuleUID: r1.GrafanaManagedAlert.UID}}
+				g := validGroup(cfg, r1, r2, r3)
+				return &g
+			},
+			assert: func(t *testing.T, apiModel *apimodels.PostableRuleGroupConfig, err error) {
+				require.ErrorIs(t, err, models.ErrAlertRuleFailedValidation)
+				require.ErrorContains(t, err, "cycle")
+				for _, r := range apiModel.Rules {
+					require.Contains(t, err.Error(), r.GrafanaManagedAlert.UID)
+				}
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
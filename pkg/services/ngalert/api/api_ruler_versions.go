@@ -0,0 +1,220 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/util/cmputil"
+)
+
+// ruleVersionFieldsToIgnoreInDiff contains fields that are not relevant when comparing two versions of a rule.
+// DashboardUID and PanelID are derived from annotations and are not stored in versions.
+var ruleVersionFieldsToIgnoreInDiff = []string{"ID", "OrgID", "UID", "Version", "Updated", "UpdatedBy", "DashboardUID", "PanelID"}
+
+// RouteGetRuleVersions returns all versions of the rule, latest version first.
+// Returns http.StatusForbidden if the user is not authorized to access the rule.
+func (srv RulerSrv) RouteGetRuleVersions(c *contextmodel.ReqContext, ruleUID string) response.Response {
+	rule, err := srv.getAuthorizedRuleByUid(c.Req.Context(), c, ruleUID)
+	if err != nil {
+		return ruleVersionErrorResponse(err)
+	}
+
+	versions, err := srv.store.ListAlertRuleVersions(c.Req.Context(), &ngmodels.ListAlertRuleVersionsQuery{
+		RuleUID: rule.UID,
+		OrgID:   rule.OrgID,
+	})
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get rule versions")
+	}
+
+	provenance, err := srv.provenanceStore.GetProvenance(c.Req.Context(), &rule, rule.OrgID)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get rule provenance")
+	}
+	provenanceRecords := map[string]ngmodels.Provenance{rule.ResourceID(): provenance}
+
+	result := make(apimodels.GettableRuleVersions, 0, len(versions))
+	for _, v := range versions {
+		versionRule := v.AlertRule()
+		versionRule.ID = rule.ID
+		result = append(result, apimodels.GettableRuleVersion{
+			Version:       v.Version,
+			ParentVersion: v.ParentVersion,
+			Created:       v.Created,
+			CreatedBy:     v.CreatedBy,
+			Rule:          toGettableExtendedRuleNode(versionRule, provenanceRecords),
+		})
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// RouteGetRuleVersionsDiff compares two versions of the rule field by field.
+// If the version to compare to is not specified, the version is compared to the current rule.
+func (srv RulerSrv) RouteGetRuleVersionsDiff(c *contextmodel.ReqContext, ruleUID string) response.Response {
+	from := c.QueryInt64("from")
+	to := c.QueryInt64("to")
+	if from <= 0 {
+		return ErrResp(http.StatusBadRequest, errors.New("query parameter 'from' must be a positive version"), "")
+	}
+	if to < 0 {
+		return ErrResp(http.StatusBadRequest, errors.New("query parameter 'to' must be a positive version"), "")
+	}
+
+	rule, err := srv.getAuthorizedRuleByUid(c.Req.Context(), c, ruleUID)
+	if err != nil {
+		return ruleVersionErrorResponse(err)
+	}
+
+	fromVersion, err := srv.store.GetAlertRuleVersion(c.Req.Context(), &ngmodels.GetAlertRuleVersionQuery{
+		RuleUID: rule.UID,
+		OrgID:   rule.OrgID,
+		Version: from,
+	})
+	if err != nil {
+		return ruleVersionErrorResponse(err)
+	}
+	left := fromVersion.AlertRule()
+
+	right := rule
+	if to > 0 {
+		toVersion, err := srv.store.GetAlertRuleVersion(c.Req.Context(), &ngmodels.GetAlertRuleVersionQuery{
+			RuleUID: rule.UID,
+			OrgID:   rule.OrgID,
+			Version: to,
+		})
+		if err != nil {
+			return ruleVersionErrorResponse(err)
+		}
+		right = toVersion.AlertRule()
+	} else {
+		to = rule.Version
+	}
+
+	return response.JSON(http.StatusOK, apimodels.RuleVersionsDiff{
+		From: from,
+		To:   to,
+		Diff: toRuleVersionFieldDiffs(left.Diff(&right, ruleVersionFieldsToIgnoreInDiff...)),
+	})
+}
+
+// RoutePostRestoreRuleVersion restores the definition of the rule from a previous version.
+// The rule keeps its folder, group, evaluation interval and pause state, and the restored definition is saved as a new version.
+// The same authorization and provenance checks as for updating the rule group apply.
+func (srv RulerSrv) RoutePostRestoreRuleVersion(c *contextmodel.ReqContext, ruleUID string, version int64) response.Response {
+	rules, err := srv.store.GetAlertRulesGroupByRuleUID(c.Req.Context(), &ngmodels.GetAlertRulesGroupByRuleUIDQuery{
+		UID:   ruleUID,
+		OrgID: c.SignedInUser.GetOrgID(),
+	})
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get rule group")
+	}
+	var current *ngmodels.AlertRule
+	for _, r := range rules {
+		if r.UID == ruleUID {
+			current = r
+			break
+		}
+	}
+	if current == nil {
+		return ruleVersionErrorResponse(ngmodels.ErrAlertRuleNotFound)
+	}
+	if err := srv.authz.AuthorizeAccessToRuleGroup(c.Req.Context(), c.SignedInUser, rules); err != nil {
+		return errorToResponse(err)
+	}
+	if version == current.Version {
+		return ErrResp(http.StatusBadRequest, fmt.Errorf("version %d is the current version of the rule", version), "")
+	}
+
+	v, err := srv.store.GetAlertRuleVersion(c.Req.Context(), &ngmodels.GetAlertRuleVersionQuery{
+		RuleUID: current.UID,
+		OrgID:   current.OrgID,
+		Version: version,
+	})
+	if err != nil {
+		return ruleVersionErrorResponse(err)
+	}
+
+	restored, err := restoreRuleVersion(current, v)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to restore rule version")
+	}
+
+	submitted := make([]*ngmodels.AlertRuleWithOptionals, 0, len(rules))
+	for _, r := range rules {
+		if r.UID == restored.UID {
+			r = restored
+		}
+		submitted = append(submitted, &ngmodels.AlertRuleWithOptionals{AlertRule: *r, HasPause: true})
+	}
+	return srv.updateAlertRulesInGroup(c, current.GetGroupKey(), submitted)
+}
+
+// restoreRuleVersion returns a copy of the current rule with the definition of the version.
+func restoreRuleVersion(current *ngmodels.AlertRule, v *ngmodels.AlertRuleVersion) (*ngmodels.AlertRule, error) {
+	restored := ngmodels.CopyRule(current)
+	restored.IsPaused = current.IsPaused
+	restored.Title = v.Title
+	restored.Condition = v.Condition
+	restored.Data = v.Data
+	restored.NoDataState = v.NoDataState
+	restored.ExecErrState = v.ExecErrState
+	restored.For = v.For
+	restored.Annotations = v.Annotations
+	restored.Labels = v.Labels
+	restored.DashboardUID = nil
+	restored.PanelID = nil
+	if err := restored.SetDashboardAndPanelFromAnnotations(); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+func toRuleVersionFieldDiffs(report cmputil.DiffReport) []apimodels.RuleVersionFieldDiff {
+	result := make([]apimodels.RuleVersionFieldDiff, 0, len(report))
+	for _, d := range report {
+		result = append(result, apimodels.RuleVersionFieldDiff{
+			Path: d.Path,
+			From: ruleVersionDiffValue(d.Path, d.Left),
+			To:   ruleVersionDiffValue(d.Path, d.Right),
+		})
+	}
+	return result
+}
+
+// ruleVersionDiffValue converts a value reported by the diff to a value that can be rendered as JSON.
+func ruleVersionDiffValue(path string, v reflect.Value) any {
+	// an invalid value means that an element was added to or removed from a collection
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	switch value := v.Interface().(type) {
+	case time.Duration:
+		return model.Duration(value).String()
+	case ngmodels.Duration:
+		return model.Duration(value).String()
+	case string:
+		// query models are compared as strings, render them as JSON objects
+		if strings.HasSuffix(path, ".Model") && json.Valid([]byte(value)) {
+			return json.RawMessage(value)
+		}
+	}
+	return v.Interface()
+}
+
+func ruleVersionErrorResponse(err error) response.Response {
+	if errors.Is(err, ngmodels.ErrAlertRuleVersionNotFound) {
+		return ErrResp(http.StatusNotFound, err, "")
+	}
+	return ruleErrorResponse(err)
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/folder"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+)
+
+func TestRouteGetRuleVersions(t *testing.T) {
+	orgID := rand.Int63()
+	folder := randFolder()
+
+	t.Run("should return versions latest first", func(t *testing.T) {
+		ruleStore, rule := initRuleVersionsStore(t, orgID, folder)
+
+		response := createService(ruleStore).RouteGetRuleVersions(createRequestContext(orgID, nil), rule.UID)
+		require.Equalf(t, http.StatusOK, response.Status(), "unexpected response: %s", string(response.Body()))
+
+		var result apimodels.GettableRuleVersions
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Len(t, result, 2)
+		assert.Equal(t, int64(2), result[0].Version)
+		assert.Equal(t, int64(1), result[0].ParentVersion)
+		assert.Equal(t, "editor", result[0].CreatedBy)
+		assert.Equal(t, rule.Title, result[0].Rule.GrafanaManagedAlert.Title)
+		assert.Equal(t, int64(1), result[1].Version)
+		assert.Equal(t, "old title", result[1].Rule.GrafanaManagedAlert.Title)
+		assert.Equal(t, rule.ID, result[1].Rule.GrafanaManagedAlert.ID)
+	})
+
+	t.Run("should return 404 if rule does not exist", func(t *testing.T) {
+		ruleStore, _ := initRuleVersionsStore(t, orgID, folder)
+
+		response := createService(ruleStore).RouteGetRuleVersions(createRequestContext(orgID, nil), "unknown")
+		require.Equal(t, http.StatusNotFound, response.Status())
+	})
+
+	t.Run("should return 403 if user cannot access the rule", func(t *testing.T) {
+		ruleStore, rule := initRuleVersionsStore(t, orgID, folder)
+
+		request := createRequestContextWithPerms(orgID, map[int64]map[string][]string{}, nil)
+		response := createService(ruleStore).RouteGetRuleVersions(request, rule.UID)
+		require.Equal(t, http.StatusForbidden, response.Status())
+	})
+}
+
+func TestRouteGetRuleVersionsDiff(t *testing.T) {
+	orgID := rand.Int63()
+	folder := randFolder()
+
+	getDiff := func(t *testing.T, ruleStore *fakes.RuleStore, ruleUID string, query string) apimodels.RuleVersionsDiff {
+		t.Helper()
+		request := createRequestContext(orgID, nil)
+		var err error
+		request.Req.Form, err = url.ParseQuery(query)
+		require.NoError(t, err)
+
+		response := createService(ruleStore).RouteGetRuleVersionsDiff(request, ruleUID)
+		require.Equalf(t, http.StatusOK, response.Status(), "unexpected response: %s", string(response.Body()))
+
+		var result apimodels.RuleVersionsDiff
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		return result
+	}
+
+	paths := func(diff apimodels.RuleVersionsDiff) map[string]apimodels.RuleVersionFieldDiff {
+		result := make(map[string]apimodels.RuleVersionFieldDiff, len(diff.Diff))
+		for _, d := range diff.Diff {
+			result[d.Path] = d
+		}
+		return result
+	}
+
+	t.Run("should compare two versions", func(t *testing.T) {
+		ruleStore, rule := initRuleVersionsStore(t, orgID, folder)
+
+		result := getDiff(t, ruleStore, rule.UID, "from=1&to=2")
+		assert.Equal(t, int64(1), result.From)
+		assert.Equal(t, int64(2), result.To)
+
+		diffs := paths(result)
+		require.Contains(t, diffs, "Title")
+		assert.Equal(t, "old title", diffs["Title"].From)
+		assert.Equal(t, rule.Title, diffs["Title"].To)
+		require.Contains(t, diffs, "Labels[severity]")
+		assert.Nil(t, diffs["Labels[severity]"].From)
+		assert.Equal(t, "critical", diffs["Labels[severity]"].To)
+		require.Contains(t, diffs, "Data[0].Model")
+		assert.Equal(t, map[string]any{"threshold": float64(10)}, diffs["Data[0].Model"].From)
+		require.Contains(t, diffs, "For")
+		assert.Equal(t, "1m", diffs["For"].From)
+		assert.Equal(t, "5m", diffs["For"].To)
+		assert.NotContains(t, diffs, "Version")
+		assert.NotContains(t, diffs, "Updated")
+	})
+
+	t.Run("should compare to the current rule if version to compare to is not specified", func(t *testing.T) {
+		ruleStore, rule := initRuleVersionsStore(t, orgID, folder)
+
+		result := getDiff(t, ruleStore, rule.UID, "from=2")
+		assert.Equal(t, int64(2), result.From)
+		assert.Equal(t, rule.Version, result.To)
+		assert.Empty(t, result.Diff)
+	})
+
+	t.Run("should return 400 if version to compare from is missing", func(t *testing.T) {
+		ruleStore, rule := initRuleVersionsStore(t, orgID, folder)
+
+		response := createService(ruleStore).RouteGetRuleVersionsDiff(createRequestContext(orgID, nil), rule.UID)
+		require.Equal(t, http.StatusBadRequest, response.Status())
+	})
+
+	t.Run("should return 404 if version does not exist", func(t *testing.T) {
+		ruleStore, rule := initRuleVersionsStore(t, orgID, folder)
+
+		request := createRequestContext(orgID, nil)
+		request.Req.Form.Set("from", "10")
+		response := createService(ruleStore).RouteGetRuleVersionsDiff(request, rule.UID)
+		require.Equal(t, http.StatusNotFound, response.Status())
+	})
+}
+
+func TestRoutePostRestoreRuleVersion(t *testing.T) {
+	orgID := rand.Int63()
+	folder := randFolder()
+
+	editorPermissions := func(rule *models.AlertRule) map[int64]map[string][]string {
+		scope := dashboards.ScopeFoldersProvider.GetResourceScopeUID(rule.NamespaceUID)
+		return map[int64]map[string][]string{orgID: {
+			datasources.ActionQuery:     {datasources.ScopeAll},
+			ac.ActionAlertingRuleRead:   {scope},
+			ac.ActionAlertingRuleUpdate: {scope},
+		}}
+	}
+
+	getUpdates := func(ruleStore *fakes.RuleStore) []models.UpdateRule {
+		var result []models.UpdateRule
+		for _, cmd := range ruleStore.GetRecordedCommands(func(cmd any) (any, bool) {
+			c, ok := cmd.([]models.UpdateRule)
+			return c, ok
+		}) {
+			result = append(result, cmd.([]models.UpdateRule)...)
+		}
+		return result
+	}
+
+	t.Run("should save the version as a new version of the rule", func(t *testing.T) {
+		ruleStore, rule := initRuleVersionsStore(t, orgID, folder)
+		svc := createService(ruleStore)
+		svc.conditionValidator = &recordingConditionValidator{}
+
+		request := createRequestContextWithPerms(orgID, editorPermissions(rule), nil)
+		request.SignedInUser.Login = "admin"
+
+		response := svc.RoutePostRestoreRuleVersion(request, rule.UID, 1)
+		require.Equalf(t, http.StatusAccepted, response.Status(), "unexpected response: %s", string(response.Body()))
+
+		updates := getUpdates(ruleStore)
+		require.Len(t, updates, 1)
+		restored := updates[0].New
+		assert.Equal(t, rule.UID, restored.UID)
+		assert.Equal(t, "old title", restored.Title)
+		assert.Equal(t, time.Minute, restored.For)
+		assert.Equal(t, map[string]string{"team": "a"}, restored.Labels)
+		assert.JSONEq(t, `{"threshold": 10}`, string(restored.Data[0].Model))
+		assert.Equal(t, rule.IntervalSeconds, restored.IntervalSeconds)
+		assert.Equal(t, rule.IsPaused, restored.IsPaused)
+		assert.Equal(t, "admin", restored.UpdatedBy)
+	})
+
+	t.Run("should return 400 if rule is provisioned", func(t *testing.T) {
+		ruleStore, rule := initRuleVersionsStore(t, orgID, folder)
+		provenanceStore := fakes.NewFakeProvisioningStore()
+		require.NoError(t, provenanceStore.SetProvenance(context.Background(), rule, orgID, models.ProvenanceAPI))
+		svc := createServiceWithProvenanceStore(ruleStore, provenanceStore)
+		svc.conditionValidator = &recordingConditionValidator{}
+
+		response := svc.RoutePostRestoreRuleVersion(createRequestContextWithPerms(orgID, editorPermissions(rule), nil), rule.UID, 1)
+		require.Equal(t, http.StatusBadRequest, response.Status())
+		require.Empty(t, getUpdates(ruleStore))
+	})
+
+	t.Run("should return 403 if user cannot update the rule", func(t *testing.T) {
+		ruleStore, rule := initRuleVersionsStore(t, orgID, folder)
+		svc := createService(ruleStore)
+		svc.conditionValidator = &recordingConditionValidator{}
+
+		response := svc.RoutePostRestoreRuleVersion(createRequestContext(orgID, nil), rule.UID, 1)
+		require.Equal(t, http.StatusForbidden, response.Status())
+		require.Empty(t, getUpdates(ruleStore))
+	})
+
+	t.Run("should return 400 if version is the current version", func(t *testing.T) {
+		ruleStore, rule := initRuleVersionsStore(t, orgID, folder)
+
+		response := createService(ruleStore).RoutePostRestoreRuleVersion(createRequestContextWithPerms(orgID, editorPermissions(rule), nil), rule.UID, rule.Version)
+		require.Equal(t, http.StatusBadRequest, response.Status())
+	})
+
+	t.Run("should return 404 if version does not exist", func(t *testing.T) {
+		ruleStore, rule := initRuleVersionsStore(t, orgID, folder)
+
+		response := createService(ruleStore).RoutePostRestoreRuleVersion(createRequestContextWithPerms(orgID, editorPermissions(rule), nil), rule.UID, 10)
+		require.Equal(t, http.StatusNotFound, response.Status())
+	})
+}
+
+// initRuleVersionsStore creates a store with a rule at version 2 and its two versions.
+// Version 1 differs from the current rule in title, labels, duration and query model.
+func initRuleVersionsStore(t *testing.T, orgID int64, folder *folder.Folder) (*fakes.RuleStore, *models.AlertRule) {
+	t.Helper()
+
+	rule := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder), func(rule *models.AlertRule) {
+		rule.Version = 2
+		rule.For = 5 * time.Minute
+		rule.Labels = map[string]string{"team": "a", "severity": "critical"}
+		rule.Annotations = nil
+		rule.DashboardUID = nil
+		rule.PanelID = nil
+		rule.Data[0].Model = json.RawMessage(`{"threshold":20}`)
+	})()
+
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+	ruleStore.PutRule(context.Background(), rule)
+
+	current := models.AlertRuleVersion{
+		RuleOrgID:        rule.OrgID,
+		RuleUID:          rule.UID,
+		RuleNamespaceUID: rule.NamespaceUID,
+		RuleGroup:        rule.RuleGroup,
+		RuleGroupIndex:   rule.RuleGroupIndex,
+		ParentVersion:    1,
+		Version:          2,
+		Created:          rule.Updated,
+		Title:            rule.Title,
+		Condition:        rule.Condition,
+		Data:             rule.Data,
+		IntervalSeconds:  rule.IntervalSeconds,
+		NoDataState:      rule.NoDataState,
+		ExecErrState:     rule.ExecErrState,
+		For:              rule.For,
+		Labels:           rule.Labels,
+		IsPaused:         rule.IsPaused,
+		CreatedBy:        "editor",
+	}
+	previous := current
+	previous.ParentVersion = 0
+	previous.Version = 1
+	previous.Created = rule.Updated.Add(-time.Hour)
+	previous.Title = "old title"
+	previous.For = time.Minute
+	previous.Labels = map[string]string{"team": "a"}
+	previous.Data = []models.AlertQuery{rule.Data[0]}
+	previous.Data[0].Model = json.RawMessage(`{"threshold":10}`)
+	previous.CreatedBy = "viewer"
+	ruleStore.PutRuleVersion(context.Background(), &previous, &current)
+
+	return ruleStore, rule
+}
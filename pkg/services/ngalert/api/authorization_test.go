@@ -40,7 +40,7 @@ func TestAuthorize(t *testing.T) {
 		}
 		paths[p] = methods
 	}
-	require.Len(t, paths, 62)
+	require.Len(t, paths, 72)
 
 	ac := acmock.New()
 	api := &API{AccessControl: ac}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
+	"github.com/prometheus/alertmanager/pkg/labels"
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
@@ -64,6 +65,66 @@ func ProvisionedAlertRuleFromAlertRules(rules []*models.AlertRule, provenances m
 	return result
 }
 
+// AlertRuleDependenciesFromApiRuleDependencies converts a collection of definitions.RuleDependency to collection of models.AlertRuleDependency
+func AlertRuleDependenciesFromApiRuleDependencies(deps []definitions.RuleDependency) []models.AlertRuleDependency {
+	if len(deps) == 0 {
+		return nil
+	}
+	result := make([]models.AlertRuleDependency, 0, len(deps))
+	for _, d := range deps {
+		result = append(result, models.AlertRuleDependency{
+			RuleUID:           d.RuleUID,
+			InhibitWhenFiring: d.InhibitWhenFiring,
+		})
+	}
+	return result
+}
+
+// ApiRuleDependenciesFromAlertRuleDependencies converts a collection of models.AlertRuleDependency to collection of definitions.RuleDependency
+func ApiRuleDependenciesFromAlertRuleDependencies(deps []models.AlertRuleDependency) []definitions.RuleDependency {
+	if len(deps) == 0 {
+		return nil
+	}
+	result := make([]definitions.RuleDependency, 0, len(deps))
+	for _, d := range deps {
+		result = append(result, definitions.RuleDependency{
+			RuleUID:           d.RuleUID,
+			InhibitWhenFiring: d.InhibitWhenFiring,
+		})
+	}
+	return result
+}
+
+// MaintenanceWindowFromApiMaintenanceWindow converts definitions.MaintenanceWindow to models.MaintenanceWindow of the given organization
+func MaintenanceWindowFromApiMaintenanceWindow(orgID int64, w definitions.MaintenanceWindow) models.MaintenanceWindow {
+	return models.MaintenanceWindow{
+		OrgID:         orgID,
+		UID:           w.UID,
+		Title:         w.Title,
+		StartsAt:      w.StartsAt,
+		EndsAt:        w.EndsAt,
+		TimeIntervals: w.TimeIntervals,
+		NamespaceUID:  w.FolderUID,
+		RuleGroup:     w.RuleGroup,
+		Matchers:      labels.Matchers(w.Matchers),
+	}
+}
+
+// ApiMaintenanceWindowFromMaintenanceWindow converts models.MaintenanceWindow to definitions.MaintenanceWindow
+func ApiMaintenanceWindowFromMaintenanceWindow(w *models.MaintenanceWindow) definitions.MaintenanceWindow {
+	return definitions.MaintenanceWindow{
+		UID:           w.UID,
+		Title:         w.Title,
+		StartsAt:      w.StartsAt,
+		EndsAt:        w.EndsAt,
+		TimeIntervals: w.TimeIntervals,
+		FolderUID:     w.NamespaceUID,
+		RuleGroup:     w.RuleGroup,
+		Matchers:      definitions.ObjectMatchers(w.Matchers),
+		Updated:       w.Updated,
+	}
+}
+
 // AlertQueriesFromApiAlertQueries converts a collection of definitions.AlertQuery to collection of models.AlertQuery
 func AlertQueriesFromApiAlertQueries(queries []definitions.AlertQuery) []models.AlertQuery {
 	result := make([]models.AlertQuery, 0, len(queries))
@@ -3,10 +3,12 @@ package api
 import (
 	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/util/errutil"
 )
 
@@ -43,3 +45,11 @@ func errorToResponse(err error) response.Response {
 	}
 	return ErrResp(500, err, "")
 }
+
+// ruleErrorResponse converts the errors of getting a single alert rule to a response.
+func ruleErrorResponse(err error) response.Response {
+	if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+		return ErrResp(http.StatusNotFound, err, "")
+	}
+	return errorToResponse(err)
+}
@@ -64,6 +64,26 @@ func (f *ProvisioningApiHandler) handleRouteDeleteTemplate(ctx *contextmodel.Req
 	return f.svc.RouteDeleteTemplate(ctx, name)
 }
 
+func (f *ProvisioningApiHandler) handleRouteGetMaintenanceWindows(ctx *contextmodel.ReqContext) response.Response {
+	return f.svc.RouteGetMaintenanceWindows(ctx)
+}
+
+func (f *ProvisioningApiHandler) handleRouteGetMaintenanceWindow(ctx *contextmodel.ReqContext, uid string) response.Response {
+	return f.svc.RouteGetMaintenanceWindow(ctx, uid)
+}
+
+func (f *ProvisioningApiHandler) handleRoutePostMaintenanceWindow(ctx *contextmodel.ReqContext, mw apimodels.MaintenanceWindow) response.Response {
+	return f.svc.RoutePostMaintenanceWindow(ctx, mw)
+}
+
+func (f *ProvisioningApiHandler) handleRoutePutMaintenanceWindow(ctx *contextmodel.ReqContext, mw apimodels.MaintenanceWindow, uid string) response.Response {
+	return f.svc.RoutePutMaintenanceWindow(ctx, mw, uid)
+}
+
+func (f *ProvisioningApiHandler) handleRouteDeleteMaintenanceWindow(ctx *contextmodel.ReqContext, uid string) response.Response {
+	return f.svc.RouteDeleteMaintenanceWindow(ctx, uid)
+}
+
 func (f *ProvisioningApiHandler) handleRouteGetMuteTiming(ctx *contextmodel.ReqContext, name string) response.Response {
 	return f.svc.RouteGetMuteTiming(ctx, name)
 }
@@ -178,6 +178,20 @@ import (
 //       403: PermissionDenied
 //       409: AlertManagerNotReady
 
+// swagger:route POST /alertmanager/grafana/config/api/v1/routes/test alertmanager RoutePostTestGrafanaRoutes
+//
+// Simulate routing of an alert through the notification policy tree without sending notifications.
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//
+//       200: TestRoutesResult
+//       400: ValidationError
+//       403: PermissionDenied
+//       404: NotFound
+//       409: AlertManagerNotReady
+
 // swagger:route GET /alertmanager/grafana/api/v2/silences alertmanager RouteGetGrafanaSilences
 //
 // get silences
@@ -341,6 +355,68 @@ type TestTemplatesErrorResult struct {
 	Message string `json:"message"`
 }
 
+// swagger:parameters RoutePostTestGrafanaRoutes
+type TestRoutesConfigParams struct {
+	// in:body
+	Body TestRoutesConfigBodyParams
+}
+
+type TestRoutesConfigBodyParams struct {
+	// Labels of the alert to route. If RuleUID is set, they are added to the labels of the alert rule.
+	Labels model.LabelSet `json:"labels,omitempty"`
+
+	// UID of the alert rule whose labels are used to route the alert.
+	RuleUID string `json:"rule_uid,omitempty"`
+
+	// Configuration to test. If it is not set, the current configuration is used.
+	AlertmanagerConfig *PostableApiAlertingConfig `json:"alertmanager_config,omitempty"`
+
+	// Time at which the mute time intervals are checked. Defaults to the current time.
+	Time *time.Time `json:"time,omitempty"`
+}
+
+// swagger:model
+type TestRoutesResult struct {
+	// Labels of the routed alert.
+	Labels model.LabelSet `json:"labels"`
+
+	// Routes that match the alert in the order they are matched.
+	Routes []TestRouteResult `json:"routes"`
+
+	// Inhibition rules whose target matchers match the alert.
+	InhibitRules []TestInhibitRuleResult `json:"inhibit_rules,omitempty"`
+}
+
+type TestRouteResult struct {
+	// Position of the route and its parents in the routing tree, starting from the children of the root route. Empty for the root route.
+	Path []int `json:"path"`
+
+	// Receiver of the route.
+	Receiver string `json:"receiver"`
+
+	// Grouping and timing options of the route, including the ones inherited from its parents.
+	GroupBy        []string       `json:"group_by"`
+	GroupWait      model.Duration `json:"group_wait"`
+	GroupInterval  model.Duration `json:"group_interval"`
+	RepeatInterval model.Duration `json:"repeat_interval"`
+
+	// Mute time intervals of the route.
+	MuteTimeIntervals []string `json:"mute_time_intervals,omitempty"`
+
+	// Mute time intervals of the route that are active at the time of the test. Notifications are not sent if it is not empty.
+	ActiveMuteTimeIntervals []string `json:"active_mute_time_intervals,omitempty"`
+}
+
+type TestInhibitRuleResult struct {
+	// Position of the rule in the inhibition rules of the configuration.
+	Index int `json:"index"`
+
+	Rule config.InhibitRule `json:"rule"`
+
+	// True if a firing alert matches the source of the rule, i.e. the alert is inhibited.
+	Active bool `json:"active"`
+}
+
 // swagger:enum TemplateErrorKind
 type TemplateErrorKind string
 
@@ -164,6 +164,84 @@ import (
 //       403: ForbiddenError
 //       404: NotFound
 
+// swagger:route Get /ruler/grafana/api/v1/rule/{RuleUID}/versions ruler RouteGetRuleVersions
+//
+// List the versions of a rule, latest version first
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: GettableRuleVersions
+//       403: ForbiddenError
+//       404: NotFound
+
+// swagger:route Get /ruler/grafana/api/v1/rule/{RuleUID}/versions/diff ruler RouteGetRuleVersionsDiff
+//
+// Compare two versions of a rule field by field
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: RuleVersionsDiff
+//       400: ValidationError
+//       403: ForbiddenError
+//       404: NotFound
+
+// swagger:route POST /ruler/grafana/api/v1/rule/{RuleUID}/versions/{Version}/restore ruler RoutePostRestoreRuleVersion
+//
+// Restore a previous version of a rule. The restored rule is saved as a new version.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       202: UpdateRuleGroupResponse
+//       400: ValidationError
+//       403: ForbiddenError
+//       404: NotFound
+
+// swagger:route Get /ruler/grafana/api/v1/rule/{RuleUID}/cost ruler RouteGetRuleCost
+//
+// Get the cost of the evaluation of a rule over its latest evaluations
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: RuleCost
+//       403: ForbiddenError
+//       404: NotFound
+
+// swagger:route Get /ruler/grafana/api/v1/costs ruler RouteGetRuleCosts
+//
+// List the most expensive rules, most expensive first
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: RuleCosts
+//       400: ValidationError
+
+// swagger:route POST /ruler/grafana/api/v1/import/prometheus ruler RoutePostImportPrometheusRules
+//
+// Converts Prometheus rule groups to Grafana-managed rules and imports them into a folder. Rule groups with the same name in the folder are replaced.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: PrometheusImportResponse
+//       202: PrometheusImportResponse
+//       400: ValidationError
+//       403: ForbiddenError
+//       404: NotFound
+
 // swagger:parameters RoutePostNameRulesConfig RoutePostNameGrafanaRulesConfig RoutePostRulesGroupForExport
 type NamespaceConfig struct {
 	// The UID of the rule folder
@@ -197,6 +275,147 @@ type PathGetRulesParams struct {
 	PanelID int64
 }
 
+// swagger:parameters RouteGetRuleVersions RouteGetRuleVersionsDiff RoutePostRestoreRuleVersion RouteGetRuleCost
+type PathRuleUID struct {
+	// The UID of the rule
+	// in: path
+	RuleUID string
+}
+
+// swagger:parameters RouteGetRuleVersionsDiff
+type RuleVersionsDiffParams struct {
+	// The version to compare from
+	// in: query
+	// required: true
+	From int64 `json:"from"`
+	// The version to compare to. Defaults to the current rule
+	// in: query
+	To int64 `json:"to"`
+}
+
+// swagger:parameters RoutePostRestoreRuleVersion
+type PathRuleVersion struct {
+	// The version of the rule to restore
+	// in: path
+	Version int64
+}
+
+// swagger:model
+type GettableRuleVersions []GettableRuleVersion
+
+type GettableRuleVersion struct {
+	Version       int64     `json:"version"`
+	ParentVersion int64     `json:"parent_version"`
+	Created       time.Time `json:"created"`
+	// The login of the user that created the version, empty if unknown
+	CreatedBy string                   `json:"created_by,omitempty"`
+	Rule      GettableExtendedRuleNode `json:"rule"`
+}
+
+// swagger:model
+type RuleVersionsDiff struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+	// The fields that differ between the two versions
+	Diff []RuleVersionFieldDiff `json:"diff"`
+}
+
+type RuleVersionFieldDiff struct {
+	// Path to the field, for example Title, Labels[severity] or Data[1].Model
+	Path string `json:"path"`
+	// The value of the field in the version to compare from, absent if the field was added
+	From any `json:"from,omitempty"`
+	// The value of the field in the version to compare to, absent if the field was removed
+	To any `json:"to,omitempty"`
+}
+
+// swagger:parameters RouteGetRuleCosts
+type RuleCostsParams struct {
+	// The maximum number of rules to return. Defaults to 10
+	// in: query
+	Limit int64 `json:"limit"`
+	// The cost to sort the rules by. Defaults to duration
+	// in: query
+	// enum: duration,query_bytes,series,failures,missed_ticks
+	Sort string `json:"sort"`
+}
+
+// swagger:parameters RoutePostImportPrometheusRules
+type ImportPrometheusRulesParams struct {
+	// in:body
+	Body PrometheusImportRequest
+}
+
+// swagger:model
+type PrometheusImportRequest struct {
+	// The UID of the folder the rules are imported into
+	// required: true
+	FolderUID string `json:"folder_uid"`
+	// The UID of the Prometheus data source the rules query
+	// required: true
+	DatasourceUID string `json:"datasource_uid"`
+	// Convert the rules and report the result without saving them
+	DryRun bool                  `json:"dry_run"`
+	Groups []PrometheusRuleGroup `json:"groups"`
+}
+
+// PrometheusRuleGroups is the content of a Prometheus rule file.
+type PrometheusRuleGroups struct {
+	Groups []PrometheusRuleGroup `yaml:"groups" json:"groups"`
+}
+
+// swagger:model
+type PrometheusRuleGroup struct {
+	Name     string         `yaml:"name" json:"name"`
+	Interval model.Duration `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Limit    int            `yaml:"limit,omitempty" json:"limit,omitempty"`
+	Rules    []ApiRuleNode  `yaml:"rules" json:"rules"`
+}
+
+// swagger:model
+type PrometheusImportResponse struct {
+	DryRun bool `json:"dry_run"`
+	// The converted rule groups. The UIDs of the rules are set only if the rules are saved
+	Groups []GettableRuleGroupConfig `json:"groups"`
+	// The parts of the rules that could not be converted as is
+	Issues []PrometheusImportIssue `json:"issues"`
+}
+
+type PrometheusImportIssue struct {
+	Group string `json:"group"`
+	// The name of the alert or recording rule, empty if the issue concerns the whole group
+	Rule string `json:"rule,omitempty"`
+	// The rule or group is not imported
+	Skipped bool   `json:"skipped"`
+	Message string `json:"message"`
+}
+
+// swagger:model
+type RuleCosts []RuleCost
+
+// swagger:model
+type RuleCost struct {
+	RuleUID   string `json:"rule_uid"`
+	Title     string `json:"title"`
+	FolderUID string `json:"folder_uid"`
+	RuleGroup string `json:"rule_group"`
+	// The number of the latest evaluations the cost is computed from
+	Evaluations int `json:"evaluations"`
+	// The number of the evaluations that failed
+	Failures int `json:"failures"`
+	// The number of evaluations that were skipped because the previous evaluation was still running
+	MissedTicks        int     `json:"missed_ticks"`
+	AvgDurationSeconds float64 `json:"avg_duration_seconds"`
+	MaxDurationSeconds float64 `json:"max_duration_seconds"`
+	// The average approximate size of the data returned by the queries of the rule
+	AvgQueryBytes int64 `json:"avg_query_bytes"`
+	// The average number of series returned by the queries of the rule
+	AvgSeries      int       `json:"avg_series"`
+	LastEvaluation time.Time `json:"last_evaluation"`
+	// The factor by which the evaluation interval of the rule is increased because it exceeds the evaluation budget
+	BackoffFactor int `json:"backoff_factor"`
+}
+
 // swagger:model
 type RuleGroupConfigResponse struct {
 	GettableRuleGroupConfig
@@ -415,6 +634,7 @@ type PostableGrafanaRule struct {
 	NoDataState  NoDataState         `json:"no_data_state" yaml:"no_data_state"`
 	ExecErrState ExecutionErrorState `json:"exec_err_state" yaml:"exec_err_state"`
 	IsPaused     *bool               `json:"is_paused" yaml:"is_paused"`
+	Dependencies []RuleDependency    `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
 }
 
 // swagger:model
@@ -434,6 +654,16 @@ type GettableGrafanaRule struct {
 	ExecErrState    ExecutionErrorState `json:"exec_err_state" yaml:"exec_err_state"`
 	Provenance      Provenance          `json:"provenance,omitempty" yaml:"provenance,omitempty"`
 	IsPaused        bool                `json:"is_paused" yaml:"is_paused"`
+	Dependencies    []RuleDependency    `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+}
+
+// RuleDependency references a rule of the same group that is evaluated before the rule that declares it.
+// swagger:model
+type RuleDependency struct {
+	// UID of the rule of the same group this rule depends on.
+	RuleUID string `json:"rule_uid" yaml:"rule_uid"`
+	// If true, the dependent rule does not fire while the referenced rule is firing.
+	InhibitWhenFiring bool `json:"inhibit_when_firing,omitempty" yaml:"inhibit_when_firing,omitempty"`
 }
 
 // AlertQuery represents a single query associated with an alert definition.
@@ -0,0 +1,82 @@
+package definitions
+
+import "time"
+
+// swagger:route GET /v1/notifications/history history RouteGetNotificationHistory
+//
+// Query the history of attempts to deliver notifications.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: NotificationHistory
+//       400: ValidationError
+//       404: NotFound
+
+// swagger:parameters RouteGetNotificationHistory
+type NotificationHistoryParams struct {
+	// Unix timestamp in seconds of the start of the time range.
+	// in:query
+	// required:false
+	From int64 `json:"from"`
+	// Unix timestamp in seconds of the end of the time range.
+	// in:query
+	// required:false
+	To int64 `json:"to"`
+	// Maximum number of entries to return.
+	// in:query
+	// required:false
+	Limit int `json:"limit"`
+	// Name of the contact point.
+	// in:query
+	// required:false
+	Receiver string `json:"receiver"`
+	// Type of the integration, e.g. email or slack.
+	// in:query
+	// required:false
+	Integration string `json:"integration"`
+	// Outcome of the attempt.
+	// in:query
+	// required:false
+	// enum: success,failed
+	Status string `json:"status"`
+	// Fingerprint of an alert the notification was sent for.
+	// in:query
+	// required:false
+	Fingerprint string `json:"fingerprint"`
+	// Key of the group of alerts the notification was sent for.
+	// in:query
+	// required:false
+	GroupKey string `json:"groupKey"`
+}
+
+// swagger:response NotificationHistory
+type NotificationHistory struct {
+	// in:body
+	Body NotificationHistoryResponse
+}
+
+// swagger:model
+type NotificationHistoryResponse struct {
+	Entries []NotificationHistoryEntry `json:"entries"`
+}
+
+// swagger:model
+type NotificationHistoryEntry struct {
+	Timestamp         time.Time `json:"timestamp"`
+	Receiver          string    `json:"receiver"`
+	Integration       string    `json:"integration"`
+	IntegrationIndex  int       `json:"integrationIndex"`
+	GroupKey          string    `json:"groupKey"`
+	AlertFingerprints []string  `json:"alertFingerprints"`
+	AlertsFiring      int       `json:"alertsFiring"`
+	AlertsResolved    int       `json:"alertsResolved"`
+	// enum: success,failed
+	Status string `json:"status"`
+	// Number of attempts to deliver the same notification that preceded this one.
+	Retry int    `json:"retry"`
+	Error string `json:"error,omitempty"`
+	// Duration of the attempt in milliseconds.
+	DurationMs int64 `json:"durationMs"`
+}
@@ -0,0 +1,91 @@
+package definitions
+
+import (
+	"time"
+
+	"github.com/prometheus/alertmanager/timeinterval"
+)
+
+// swagger:route GET /v1/provisioning/maintenance-windows provisioning stable RouteGetMaintenanceWindows
+//
+// Get all maintenance windows.
+//
+//     Responses:
+//       200: MaintenanceWindows
+
+// swagger:route GET /v1/provisioning/maintenance-windows/{UID} provisioning stable RouteGetMaintenanceWindow
+//
+// Get a maintenance window.
+//
+//     Responses:
+//       200: MaintenanceWindow
+//       404: description: Not found.
+
+// swagger:route POST /v1/provisioning/maintenance-windows provisioning stable RoutePostMaintenanceWindow
+//
+// Create a new maintenance window.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       201: MaintenanceWindow
+//       400: ValidationError
+
+// swagger:route PUT /v1/provisioning/maintenance-windows/{UID} provisioning stable RoutePutMaintenanceWindow
+//
+// Replace an existing maintenance window.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       202: MaintenanceWindow
+//       400: ValidationError
+//       404: description: Not found.
+
+// swagger:route DELETE /v1/provisioning/maintenance-windows/{UID} provisioning stable RouteDeleteMaintenanceWindow
+//
+// Delete a maintenance window.
+//
+//     Responses:
+//       204: description: The maintenance window was deleted successfully.
+//       404: description: Not found.
+
+// swagger:model
+type MaintenanceWindows []MaintenanceWindow
+
+// swagger:parameters RouteGetMaintenanceWindow RoutePutMaintenanceWindow RouteDeleteMaintenanceWindow
+type MaintenanceWindowUIDParam struct {
+	// Maintenance window UID
+	// in:path
+	UID string
+}
+
+// swagger:parameters RoutePostMaintenanceWindow RoutePutMaintenanceWindow
+type MaintenanceWindowPayload struct {
+	// in:body
+	Body MaintenanceWindow
+}
+
+// MaintenanceWindow is a period of planned maintenance during which alert rules in its scope are not evaluated.
+// swagger:model
+type MaintenanceWindow struct {
+	UID string `json:"uid"`
+	// required: true
+	Title string `json:"title"`
+	// Start of the window. Required if the window has no time intervals.
+	StartsAt *time.Time `json:"startsAt,omitempty"`
+	// End of the window. Required if the window has no time intervals.
+	EndsAt *time.Time `json:"endsAt,omitempty"`
+	// Time intervals when a recurring window is active.
+	TimeIntervals []timeinterval.TimeInterval `json:"timeIntervals,omitempty"`
+	// UID of the folder of the rules in maintenance. Empty means all folders.
+	FolderUID string `json:"folderUID,omitempty"`
+	// Rule group in maintenance. Requires folderUID.
+	RuleGroup string `json:"ruleGroup,omitempty"`
+	// Matchers that the labels of the rules in maintenance must match.
+	Matchers ObjectMatchers `json:"matchers,omitempty"`
+	// readonly: true
+	Updated time.Time `json:"updated,omitempty"`
+}
@@ -82,6 +82,9 @@ func (r *conditionEvaluator) Evaluate(ctx context.Context, now time.Time) (Resul
 	if err != nil {
 		return nil, err
 	}
+	if stats := evaluationStatsFromContext(ctx); stats != nil {
+		stats.collect(r.condition, response)
+	}
 	execResults := queryDataResponseToExecutionResults(r.condition, response)
 	return evaluateExecutionResult(execResults, now), nil
 }
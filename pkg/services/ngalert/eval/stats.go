@@ -0,0 +1,83 @@
+package eval
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// EvaluationStats are the statistics of the data returned by the queries of a condition.
+type EvaluationStats struct {
+	// QueryBytes is the approximate size of the data returned by the queries, without expressions.
+	QueryBytes int64
+	// Series is the number of series returned by the queries, without expressions.
+	Series int
+}
+
+type evaluationStatsContextKey struct{}
+
+// WithEvaluationStats returns a context that makes ConditionEvaluator.Evaluate collect the statistics of the evaluation into stats.
+func WithEvaluationStats(ctx context.Context, stats *EvaluationStats) context.Context {
+	return context.WithValue(ctx, evaluationStatsContextKey{}, stats)
+}
+
+func evaluationStatsFromContext(ctx context.Context) *EvaluationStats {
+	stats, _ := ctx.Value(evaluationStatsContextKey{}).(*EvaluationStats)
+	return stats
+}
+
+// collect adds the statistics of the responses of the queries of the condition.
+func (s *EvaluationStats) collect(c models.Condition, resp *backend.QueryDataResponse) {
+	if resp == nil {
+		return
+	}
+	for _, q := range c.Data {
+		if isExpr, err := q.IsExpression(); err != nil || isExpr {
+			continue
+		}
+		r, ok := resp.Responses[q.RefID]
+		if !ok {
+			continue
+		}
+		for _, frame := range r.Frames {
+			s.QueryBytes += frameSize(frame)
+			s.Series += frameSeries(frame)
+		}
+	}
+}
+
+// frameSize returns the approximate size of the data in the frame. Values of strings are counted by their length, labels by
+// the length of their names and values, and values of other types as 8 bytes.
+func frameSize(frame *data.Frame) int64 {
+	var size int64
+	for _, field := range frame.Fields {
+		for name, value := range field.Labels {
+			size += int64(len(name) + len(value))
+		}
+		switch field.Type() {
+		case data.FieldTypeString, data.FieldTypeNullableString:
+			for i := 0; i < field.Len(); i++ {
+				if v, ok := field.ConcreteAt(i); ok {
+					size += int64(len(v.(string)))
+				}
+			}
+		default:
+			size += int64(field.Len()) * 8
+		}
+	}
+	return size
+}
+
+// frameSeries returns the number of series in the frame, that is the number of its fields that are not time.
+func frameSeries(frame *data.Frame) int {
+	series := 0
+	for _, field := range frame.Fields {
+		if t := field.Type(); t != data.FieldTypeTime && t != data.FieldTypeNullableTime {
+			series++
+		}
+	}
+	return series
+}
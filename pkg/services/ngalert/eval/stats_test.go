@@ -0,0 +1,48 @@
+package eval
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestEvaluationStats(t *testing.T) {
+	query := models.GenerateAlertQuery()
+	query.RefID = "A"
+	condition := models.Condition{
+		Condition: "B",
+		Data:      []models.AlertQuery{query, models.CreateReduceExpression("B", "A", "last")},
+	}
+	resp := &backend.QueryDataResponse{Responses: backend.Responses{
+		"A": {Frames: data.Frames{
+			data.NewFrame("",
+				data.NewField("time", nil, []time.Time{time.Now(), time.Now()}),
+				data.NewField("value", data.Labels{"job": "api"}, []float64{1, 2}),
+				data.NewField("text", nil, []string{"abc", "de"}),
+			),
+		}},
+		"B": {Frames: data.Frames{
+			data.NewFrame("", data.NewField("value", nil, []float64{1, 2, 3})),
+		}},
+	}}
+
+	t.Run("should count data of queries but not expressions", func(t *testing.T) {
+		stats := &EvaluationStats{}
+		stats.collect(condition, resp)
+		// 2 times and 2 floats of 8 bytes, labels of 6 bytes and strings of 5 bytes.
+		require.Equal(t, int64(43), stats.QueryBytes)
+		require.Equal(t, 2, stats.Series)
+	})
+
+	t.Run("should be read from the context", func(t *testing.T) {
+		require.Nil(t, evaluationStatsFromContext(context.Background()))
+		stats := &EvaluationStats{}
+		require.Same(t, stats, evaluationStatsFromContext(WithEvaluationStats(context.Background(), stats)))
+	})
+}
@@ -6,9 +6,11 @@ import (
 )
 
 type State struct {
-	StateUpdateDuration   prometheus.Histogram
-	StateFullSyncDuration prometheus.Histogram
-	r                     prometheus.Registerer
+	StateUpdateDuration            prometheus.Histogram
+	StateFullSyncDuration          prometheus.Histogram
+	StateExportRemoteWrites        prometheus.Counter
+	StateExportRemoteWriteFailures prometheus.Counter
+	r                              prometheus.Registerer
 }
 
 // Registerer exposes the Prometheus register directly. The state package needs this as, it uses a collector to fetch the current alerts by state in the system.
@@ -37,5 +39,21 @@ func NewStateMetrics(r prometheus.Registerer) *State {
 				Buckets:   []float64{0.01, 0.1, 1, 2, 5, 10, 60},
 			},
 		),
+		StateExportRemoteWrites: promauto.With(r).NewCounter(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "state_export_remote_writes_total",
+				Help:      "The total number of pushes of the ALERTS series to the remote write endpoint.",
+			},
+		),
+		StateExportRemoteWriteFailures: promauto.With(r).NewCounter(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "state_export_remote_write_failures_total",
+				Help:      "The total number of failed pushes of the ALERTS series to the remote write endpoint.",
+			},
+		),
 	}
 }
@@ -22,6 +22,8 @@ import (
 var (
 	// ErrAlertRuleNotFound is an error for an unknown alert rule.
 	ErrAlertRuleNotFound = fmt.Errorf("could not find alert rule")
+	// ErrAlertRuleVersionNotFound is an error for an unknown version of an alert rule.
+	ErrAlertRuleVersionNotFound = errors.New("could not find alert rule version")
 	// ErrAlertRuleFailedGenerateUniqueUID is an error for failure to generate alert rule UID
 	ErrAlertRuleFailedGenerateUniqueUID = errors.New("failed to generate alert rule UID")
 	// ErrCannotEditNamespace is an error returned if the user does not have permissions to edit the namespace
@@ -130,6 +132,8 @@ const (
 	StateReasonPaused        = "Paused"
 	StateReasonUpdated       = "Updated"
 	StateReasonRuleDeleted   = "RuleDeleted"
+	StateReasonInhibited     = "Inhibited"
+	StateReasonMaintenance   = "Maintenance"
 )
 
 var (
@@ -222,6 +226,19 @@ type AlertRule struct {
 	Annotations map[string]string
 	Labels      map[string]string
 	IsPaused    bool
+	// UpdatedBy is the login of the user that made the last change to the rule, if known.
+	UpdatedBy string `xorm:"updated_by"`
+	// Dependencies are the rules of the same group that are evaluated before this rule.
+	Dependencies []AlertRuleDependency
+}
+
+// AlertRuleDependency declares that an alert rule depends on another rule of the same group. Within an evaluation
+// of the group, a rule is evaluated after the rules it depends on, so it can use their up-to-date state.
+type AlertRuleDependency struct {
+	// RuleUID is the UID of the rule this rule depends on.
+	RuleUID string `json:"ruleUid"`
+	// InhibitWhenFiring prevents the rule from firing while the rule it depends on has firing alerts.
+	InhibitWhenFiring bool `json:"inhibitWhenFiring,omitempty"`
 }
 
 // AlertRuleWithOptionals This is to avoid having to pass in additional arguments deep in the call stack. Alert rule
@@ -520,6 +537,47 @@ type AlertRuleVersion struct {
 	Annotations map[string]string
 	Labels      map[string]string
 	IsPaused    bool
+	// CreatedBy is the login of the user that created the version, if known.
+	CreatedBy    string `xorm:"created_by"`
+	Dependencies []AlertRuleDependency
+}
+
+// AlertRule returns the alert rule as it was at this version.
+func (v AlertRuleVersion) AlertRule() AlertRule {
+	return AlertRule{
+		OrgID:           v.RuleOrgID,
+		Title:           v.Title,
+		Condition:       v.Condition,
+		Data:            v.Data,
+		Updated:         v.Created,
+		IntervalSeconds: v.IntervalSeconds,
+		Version:         v.Version,
+		UID:             v.RuleUID,
+		NamespaceUID:    v.RuleNamespaceUID,
+		RuleGroup:       v.RuleGroup,
+		RuleGroupIndex:  v.RuleGroupIndex,
+		NoDataState:     v.NoDataState,
+		ExecErrState:    v.ExecErrState,
+		For:             v.For,
+		Annotations:     v.Annotations,
+		Labels:          v.Labels,
+		IsPaused:        v.IsPaused,
+		UpdatedBy:       v.CreatedBy,
+		Dependencies:    v.Dependencies,
+	}
+}
+
+// ListAlertRuleVersionsQuery is the query for listing the versions of an alert rule, latest version first.
+type ListAlertRuleVersionsQuery struct {
+	RuleUID string
+	OrgID   int64
+}
+
+// GetAlertRuleVersionQuery is the query for retrieving a single version of an alert rule.
+type GetAlertRuleVersionQuery struct {
+	RuleUID string
+	OrgID   int64
+	Version int64
 }
 
 // GetAlertRuleByUIDQuery is the query for retrieving/deleting an alert rule by UID and organisation ID.
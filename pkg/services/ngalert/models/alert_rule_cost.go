@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+)
+
+// AlertRuleCost is the cost of the evaluation of an alert rule, computed over its latest evaluations.
+type AlertRuleCost struct {
+	AlertRuleKey
+	Title        string
+	NamespaceUID string
+	RuleGroup    string
+
+	// Evaluations is the number of evaluations the cost is computed from.
+	Evaluations int
+	// Failures is the number of the evaluations that failed.
+	Failures int
+	// MissedTicks is the number of evaluations that were skipped because the previous evaluation was still running.
+	MissedTicks int
+
+	AvgDuration   time.Duration
+	MaxDuration   time.Duration
+	AvgQueryBytes int64
+	AvgSeries     int
+
+	LastEvaluation time.Time
+	// BackoffFactor is the factor by which the evaluation interval of the rule is increased because it exceeds
+	// the evaluation budget. It is 1 if the rule is evaluated at its configured interval.
+	BackoffFactor int
+}
+
+// AlertRuleCostSortKey is the cost attribute that alert rules are sorted by.
+type AlertRuleCostSortKey string
+
+const (
+	AlertRuleCostSortByDuration    AlertRuleCostSortKey = "duration"
+	AlertRuleCostSortByQueryBytes  AlertRuleCostSortKey = "query_bytes"
+	AlertRuleCostSortBySeries      AlertRuleCostSortKey = "series"
+	AlertRuleCostSortByFailures    AlertRuleCostSortKey = "failures"
+	AlertRuleCostSortByMissedTicks AlertRuleCostSortKey = "missed_ticks"
+)
+
+// Less returns true if the cost of a is less than the cost of b by the key. Unknown keys compare the average duration.
+func (k AlertRuleCostSortKey) Less(a, b AlertRuleCost) bool {
+	switch k {
+	case AlertRuleCostSortByQueryBytes:
+		return a.AvgQueryBytes < b.AvgQueryBytes
+	case AlertRuleCostSortBySeries:
+		return a.AvgSeries < b.AvgSeries
+	case AlertRuleCostSortByFailures:
+		return a.Failures < b.Failures
+	case AlertRuleCostSortByMissedTicks:
+		return a.MissedTicks < b.MissedTicks
+	default:
+		return a.AvgDuration < b.AvgDuration
+	}
+}
+
+// IsValid returns true if the key is one of the known sort keys.
+func (k AlertRuleCostSortKey) IsValid() bool {
+	switch k {
+	case AlertRuleCostSortByDuration, AlertRuleCostSortByQueryBytes, AlertRuleCostSortBySeries, AlertRuleCostSortByFailures, AlertRuleCostSortByMissedTicks:
+		return true
+	}
+	return false
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"time"
 
+	"github.com/prometheus/alertmanager/pkg/labels"
+
 	"github.com/grafana/grafana/pkg/services/auth/identity"
 )
 
@@ -13,6 +15,8 @@ type HistoryQuery struct {
 	DashboardUID string
 	PanelID      int64
 	Labels       map[string]string
+	// Matchers select the alert instances by their labels. Unlike Labels, they support all types of matchers.
+	Matchers     labels.Matchers
 	From         time.Time
 	To           time.Time
 	Limit        int
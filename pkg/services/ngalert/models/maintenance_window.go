@@ -0,0 +1,98 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/timeinterval"
+)
+
+var (
+	// ErrMaintenanceWindowNotFound is returned when the maintenance window does not exist.
+	ErrMaintenanceWindowNotFound = errors.New("could not find maintenance window")
+	// ErrMaintenanceWindowFailedValidation is returned when the maintenance window is not valid.
+	ErrMaintenanceWindowFailedValidation = errors.New("invalid maintenance window")
+)
+
+// MaintenanceWindow is a period of planned maintenance. Alert rules in the scope of an active maintenance window are not evaluated.
+// A window without time intervals is active from StartsAt till EndsAt. A window with time intervals is recurring:
+// it is active when the time is in any of the intervals, and StartsAt and EndsAt, if set, limit the period when it recurs.
+type MaintenanceWindow struct {
+	ID            int64                       `xorm:"pk autoincr 'id'"`
+	OrgID         int64                       `xorm:"org_id"`
+	UID           string                      `xorm:"uid"`
+	Title         string                      `xorm:"title"`
+	StartsAt      *time.Time                  `xorm:"starts_at"`
+	EndsAt        *time.Time                  `xorm:"ends_at"`
+	TimeIntervals []timeinterval.TimeInterval `xorm:"time_intervals"`
+	// NamespaceUID limits the window to the rules of the folder. Empty means all folders.
+	NamespaceUID string `xorm:"namespace_uid"`
+	// RuleGroup limits the window to the rules of the group. Empty means all groups.
+	RuleGroup string `xorm:"rule_group"`
+	// Matchers limits the window to the rules whose labels match all matchers.
+	Matchers labels.Matchers `xorm:"matchers"`
+	Updated  time.Time       `xorm:"updated"`
+}
+
+// Validate checks that the maintenance window has a title and a valid time specification.
+func (w *MaintenanceWindow) Validate() error {
+	if w.Title == "" {
+		return fmt.Errorf("%w: title cannot be empty", ErrMaintenanceWindowFailedValidation)
+	}
+	if len(w.TimeIntervals) == 0 && (w.StartsAt == nil || w.EndsAt == nil) {
+		return fmt.Errorf("%w: a window without time intervals must have both start and end time", ErrMaintenanceWindowFailedValidation)
+	}
+	if w.StartsAt != nil && w.EndsAt != nil && !w.EndsAt.After(*w.StartsAt) {
+		return fmt.Errorf("%w: end time must be after the start time", ErrMaintenanceWindowFailedValidation)
+	}
+	if w.RuleGroup != "" && w.NamespaceUID == "" {
+		return fmt.Errorf("%w: folder must be specified if rule group is specified", ErrMaintenanceWindowFailedValidation)
+	}
+	return nil
+}
+
+// IsActive returns true if the maintenance window is active at the given time.
+func (w *MaintenanceWindow) IsActive(t time.Time) bool {
+	if w.StartsAt != nil && t.Before(*w.StartsAt) {
+		return false
+	}
+	if w.EndsAt != nil && !t.Before(*w.EndsAt) {
+		return false
+	}
+	if len(w.TimeIntervals) == 0 {
+		return true
+	}
+	for _, ti := range w.TimeIntervals {
+		if ti.ContainsTime(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers returns true if the rule is in the scope of the maintenance window.
+func (w *MaintenanceWindow) Covers(rule *AlertRule) bool {
+	if rule.OrgID != w.OrgID {
+		return false
+	}
+	if w.NamespaceUID != "" && rule.NamespaceUID != w.NamespaceUID {
+		return false
+	}
+	if w.RuleGroup != "" && rule.RuleGroup != w.RuleGroup {
+		return false
+	}
+	for _, m := range w.Matchers {
+		if !m.Matches(rule.Labels[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ListMaintenanceWindowsQuery is the query for listing maintenance windows.
+type ListMaintenanceWindowsQuery struct {
+	// OrgID limits the result to the windows of the organization. Zero means all organizations.
+	OrgID int64
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceWindowValidate(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	testCases := []struct {
+		name   string
+		window MaintenanceWindow
+		valid  bool
+	}{
+		{
+			name:   "one-off window",
+			window: MaintenanceWindow{Title: "test", StartsAt: &start, EndsAt: &end},
+			valid:  true,
+		},
+		{
+			name:   "recurring window without bounds",
+			window: MaintenanceWindow{Title: "test", TimeIntervals: []timeinterval.TimeInterval{{}}},
+			valid:  true,
+		},
+		{
+			name:   "empty title",
+			window: MaintenanceWindow{StartsAt: &start, EndsAt: &end},
+		},
+		{
+			name:   "one-off window without end",
+			window: MaintenanceWindow{Title: "test", StartsAt: &start},
+		},
+		{
+			name:   "end before start",
+			window: MaintenanceWindow{Title: "test", StartsAt: &end, EndsAt: &start},
+		},
+		{
+			name:   "group without folder",
+			window: MaintenanceWindow{Title: "test", StartsAt: &start, EndsAt: &end, RuleGroup: "group"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.window.Validate()
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, ErrMaintenanceWindowFailedValidation)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindowIsActive(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	t.Run("one-off window is active between start and end", func(t *testing.T) {
+		w := MaintenanceWindow{StartsAt: &start, EndsAt: &end}
+		require.False(t, w.IsActive(start.Add(-time.Second)))
+		require.True(t, w.IsActive(start))
+		require.True(t, w.IsActive(end.Add(-time.Second)))
+		require.False(t, w.IsActive(end))
+	})
+
+	t.Run("recurring window is active in time intervals within bounds", func(t *testing.T) {
+		w := MaintenanceWindow{
+			StartsAt:      &start,
+			EndsAt:        &end,
+			TimeIntervals: []timeinterval.TimeInterval{{Times: []timeinterval.TimeRange{{StartMinute: 60, EndMinute: 120}}}},
+		}
+		require.False(t, w.IsActive(start.Add(30*time.Minute)))
+		require.True(t, w.IsActive(start.Add(90*time.Minute)))
+		require.False(t, w.IsActive(end.Add(90*time.Minute)))
+	})
+}
+
+func TestMaintenanceWindowCovers(t *testing.T) {
+	rule := AlertRuleGen(WithOrgID(1), WithLabels(map[string]string{"team": "db"}))()
+
+	require.True(t, (&MaintenanceWindow{OrgID: 1}).Covers(rule))
+	require.False(t, (&MaintenanceWindow{OrgID: 2}).Covers(rule))
+	require.True(t, (&MaintenanceWindow{OrgID: 1, NamespaceUID: rule.NamespaceUID, RuleGroup: rule.RuleGroup}).Covers(rule))
+	require.False(t, (&MaintenanceWindow{OrgID: 1, NamespaceUID: rule.NamespaceUID, RuleGroup: "other"}).Covers(rule))
+	require.False(t, (&MaintenanceWindow{OrgID: 1, NamespaceUID: "other"}).Covers(rule))
+
+	matcher, err := labels.NewMatcher(labels.MatchRegexp, "team", "d.*")
+	require.NoError(t, err)
+	require.True(t, (&MaintenanceWindow{OrgID: 1, Matchers: labels.Matchers{matcher}}).Covers(rule))
+	matcher, err = labels.NewMatcher(labels.MatchEqual, "team", "web")
+	require.NoError(t, err)
+	require.False(t, (&MaintenanceWindow{OrgID: 1, Matchers: labels.Matchers{matcher}}).Covers(rule))
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// NotificationStatus is the outcome of an attempt to deliver a notification.
+type NotificationStatus string
+
+const (
+	NotificationStatusSuccess NotificationStatus = "success"
+	NotificationStatusFailed  NotificationStatus = "failed"
+)
+
+// NotificationHistoryEntry describes a single attempt of an integration of a contact point to deliver a notification.
+// A notification that was retried by the Alertmanager results in multiple entries with the same group key.
+type NotificationHistoryEntry struct {
+	OrgID     int64     `json:"orgId"`
+	Timestamp time.Time `json:"timestamp"`
+	// Receiver is the name of the contact point.
+	Receiver string `json:"receiver"`
+	// Integration is the type of the integration of the contact point, e.g. email or slack.
+	Integration string `json:"integration"`
+	// IntegrationIndex is the position of the integration in the contact point.
+	IntegrationIndex int `json:"integrationIndex"`
+	// GroupKey identifies the group of alerts the notification was sent for.
+	GroupKey          string             `json:"groupKey"`
+	AlertFingerprints []string           `json:"alertFingerprints"`
+	AlertsFiring      int                `json:"alertsFiring"`
+	AlertsResolved    int                `json:"alertsResolved"`
+	Status            NotificationStatus `json:"status"`
+	// Retry is the number of attempts to deliver the same notification that preceded this one.
+	Retry    int           `json:"retry"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// NotificationHistoryQuery represents a query for notification history.
+type NotificationHistoryQuery struct {
+	OrgID            int64
+	Receiver         string
+	Integration      string
+	Status           NotificationStatus
+	GroupKey         string
+	AlertFingerprint string
+	From             time.Time
+	To               time.Time
+	Limit            int
+}
@@ -375,6 +375,7 @@ func CopyRule(r *AlertRule) *AlertRule {
 		NoDataState:     r.NoDataState,
 		ExecErrState:    r.ExecErrState,
 		For:             r.For,
+		UpdatedBy:       r.UpdatedBy,
 	}
 
 	if r.DashboardUID != nil {
@@ -412,6 +413,11 @@ func CopyRule(r *AlertRule) *AlertRule {
 		}
 	}
 
+	if r.Dependencies != nil {
+		result.Dependencies = make([]AlertRuleDependency, len(r.Dependencies))
+		copy(result.Dependencies, r.Dependencies)
+	}
+
 	return &result
 }
 
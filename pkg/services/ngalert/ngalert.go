@@ -3,6 +3,7 @@ package ngalert
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"time"
 
@@ -32,11 +33,13 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/migration"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/history"
 	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
 	"github.com/grafana/grafana/pkg/services/ngalert/remote"
 	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
 	"github.com/grafana/grafana/pkg/services/ngalert/sender"
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/services/ngalert/state/exporter"
 	"github.com/grafana/grafana/pkg/services/ngalert/state/historian"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/notifications"
@@ -154,6 +157,12 @@ type AlertNG struct {
 	annotationsRepo      annotations.Repository
 	store                *store.DBstore
 
+	stateHistorian Historian
+	// notificationHistorian is nil if notification history is disabled.
+	notificationHistorian NotificationHistorian
+	// stateExporter is nil if the export of alert state is disabled.
+	stateExporter *exporter.Exporter
+
 	bus          bus.Bus
 	pluginsStore pluginstore.Store
 	tracer       tracing.Tracer
@@ -222,6 +231,15 @@ func (ng *AlertNG) init() error {
 		}
 	}
 
+	if ng.Cfg.UnifiedAlerting.NotificationHistory.Enabled {
+		notificationHistorian, err := configureNotificationHistorianBackend(initCtx, ng.Cfg.UnifiedAlerting, ng.SQLStore, ng.Metrics.GetHistorianMetrics(), ng.Log)
+		if err != nil {
+			return err
+		}
+		ng.notificationHistorian = notificationHistorian
+		overrides = append(overrides, notifier.WithNotificationHistorian(notificationHistorian))
+	}
+
 	decryptFn := ng.SecretsService.GetDecryptedValue
 	multiOrgMetrics := ng.Metrics.GetMultiOrgAlertmanagerMetrics()
 	moa, err := notifier.NewMultiOrgAlertmanager(ng.Cfg, ng.store, ng.store, ng.KVStore, ng.store, decryptFn, multiOrgMetrics, ng.NotificationService, moaLogger, ng.SecretsService, overrides...)
@@ -270,6 +288,8 @@ func (ng *AlertNG) init() error {
 		AppURL:               appUrl,
 		EvaluatorFactory:     evalFactory,
 		RuleStore:            ng.store,
+		MaintenanceWindows:   ng.store,
+		EvaluationBudget:     ng.Cfg.UnifiedAlerting.EvaluationBudget,
 		Metrics:              ng.Metrics.GetSchedulerMetrics(),
 		AlertSender:          alertsRouter,
 		Tracer:               ng.tracer,
@@ -279,10 +299,11 @@ func (ng *AlertNG) init() error {
 	// There are a set of feature toggles available that act as short-circuits for common configurations.
 	// If any are set, override the config accordingly.
 	ApplyStateHistoryFeatureToggles(&ng.Cfg.UnifiedAlerting.StateHistory, ng.FeatureToggles, ng.Log)
-	history, err := configureHistorianBackend(initCtx, ng.Cfg.UnifiedAlerting.StateHistory, ng.annotationsRepo, ng.dashboardService, ng.store, ng.Metrics.GetHistorianMetrics(), ng.Log)
+	history, err := configureHistorianBackend(initCtx, ng.Cfg.UnifiedAlerting.StateHistory, ng.annotationsRepo, ng.dashboardService, ng.store, ng.SQLStore, ng.Metrics.GetHistorianMetrics(), ng.Log)
 	if err != nil {
 		return err
 	}
+	ng.stateHistorian = history
 	cfg := state.ManagerCfg{
 		Metrics:                        ng.Metrics.GetStateMetrics(),
 		ExternalURL:                    appUrl,
@@ -303,6 +324,16 @@ func (ng *AlertNG) init() error {
 		statePersister = state.NewAsyncStatePersister(logger, ticker, cfg)
 	}
 	stateManager := state.NewManager(cfg, statePersister)
+
+	if ng.Cfg.UnifiedAlerting.HAShardEvaluation {
+		// The periodic state persister replaces all alert instances in the database with the content of the local cache,
+		// which holds only the rules that are evaluated by this instance.
+		if ng.FeatureToggles.IsEnabledGlobally(featuremgmt.FlagAlertingSaveStatePeriodic) {
+			ng.Log.Warn("Sharding of rule evaluation is not supported when the state is saved periodically, all rules will be evaluated by this instance")
+		} else {
+			schedCfg.ClusterMembership = ng.MultiOrgAlertmanager
+		}
+	}
 	scheduler := schedule.NewScheduler(schedCfg, stateManager)
 
 	// if it is required to include folder title to the alerts, we need to subscribe to changes of alert title
@@ -313,6 +344,20 @@ func (ng *AlertNG) init() error {
 	ng.stateManager = stateManager
 	ng.schedule = scheduler
 
+	if exportCfg := ng.Cfg.UnifiedAlerting.StateExport; exportCfg.Enabled {
+		// Without sharding, all members of a high-availability cluster evaluate all rules and only one of them exports the alerts.
+		var membership exporter.ClusterMembership
+		if schedCfg.ClusterMembership == nil {
+			membership = ng.MultiOrgAlertmanager
+		}
+		ng.stateExporter = exporter.NewExporter(exportCfg, stateManager, membership, &http.Client{}, clk, ng.Metrics.GetStateMetrics(), log.New("ngalert.state.exporter"))
+		if exportCfg.Metrics {
+			if err := ng.Metrics.Registerer.Register(ng.stateExporter); err != nil {
+				return fmt.Errorf("failed to register alert state exporter: %w", err)
+			}
+		}
+	}
+
 	receiverService := notifier.NewReceiverService(ng.accesscontrol, ng.store, ng.store, ng.SecretsService, ng.store, ng.Log)
 
 	// Provisioning
@@ -320,6 +365,7 @@ func (ng *AlertNG) init() error {
 	contactPointService := provisioning.NewContactPointService(ng.store, ng.SecretsService, ng.store, ng.store, receiverService, ng.Log)
 	templateService := provisioning.NewTemplateService(ng.store, ng.store, ng.store, ng.Log)
 	muteTimingService := provisioning.NewMuteTimingService(ng.store, ng.store, ng.store, ng.Log)
+	maintenanceWindowService := provisioning.NewMaintenanceWindowService(ng.store, ng.Log)
 	alertRuleService := provisioning.NewAlertRuleService(ng.store, ng.store, ng.dashboardService, ng.QuotaService, ng.store,
 		int64(ng.Cfg.UnifiedAlerting.DefaultRuleEvaluationInterval.Seconds()),
 		int64(ng.Cfg.UnifiedAlerting.BaseInterval.Seconds()), ng.Log)
@@ -343,12 +389,15 @@ func (ng *AlertNG) init() error {
 		ContactPointService:  contactPointService,
 		Templates:            templateService,
 		MuteTimings:          muteTimingService,
+		MaintenanceWindows:   maintenanceWindowService,
 		AlertRules:           alertRuleService,
 		AlertsRouter:         alertsRouter,
 		EvaluatorFactory:     evalFactory,
 		FeatureManager:       ng.FeatureToggles,
 		AppUrl:               appUrl,
 		Historian:            history,
+		NotificationHistory:  ng.notificationHistorian,
+		RuleCosts:            ng.schedule,
 		Hooks:                api.NewHooks(ng.Log),
 		Tracer:               ng.tracer,
 		UpgradeService:       ng.upgradeService,
@@ -413,6 +462,14 @@ func (ng *AlertNG) Run(ctx context.Context) error {
 	children.Go(func() error {
 		return ng.AlertsRouter.Run(subCtx)
 	})
+	// Some history backends delete the history that is older than their retention period in the background.
+	for _, h := range []any{ng.stateHistorian, ng.notificationHistorian} {
+		if cleaner, ok := h.(interface{ Run(context.Context) error }); ok {
+			children.Go(func() error {
+				return cleaner.Run(subCtx)
+			})
+		}
+	}
 
 	// We explicitly check that UA is enabled here in case FlagAlertingPreviewUpgrade is enabled but UA is disabled.
 	if ng.Cfg.UnifiedAlerting.ExecuteAlerts && ng.Cfg.UnifiedAlerting.IsEnabled() {
@@ -433,6 +490,11 @@ func (ng *AlertNG) Run(ctx context.Context) error {
 		children.Go(func() error {
 			return ng.stateManager.Run(subCtx)
 		})
+		if ng.stateExporter != nil {
+			children.Go(func() error {
+				return ng.stateExporter.Run(subCtx)
+			})
+		}
 	}
 	return children.Wait()
 }
@@ -453,7 +515,7 @@ type Historian interface {
 	state.Historian
 }
 
-func configureHistorianBackend(ctx context.Context, cfg setting.UnifiedAlertingStateHistorySettings, ar annotations.Repository, ds dashboards.DashboardService, rs historian.RuleStore, met *metrics.Historian, l log.Logger) (Historian, error) {
+func configureHistorianBackend(ctx context.Context, cfg setting.UnifiedAlertingStateHistorySettings, ar annotations.Repository, ds dashboards.DashboardService, rs historian.RuleStore, sqlStore db.DB, met *metrics.Historian, l log.Logger) (Historian, error) {
 	if !cfg.Enabled {
 		met.Info.WithLabelValues("noop").Set(0)
 		return historian.NewNopHistorian(), nil
@@ -468,7 +530,7 @@ func configureHistorianBackend(ctx context.Context, cfg setting.UnifiedAlertingS
 	if backend == historian.BackendTypeMultiple {
 		primaryCfg := cfg
 		primaryCfg.Backend = cfg.MultiPrimary
-		primary, err := configureHistorianBackend(ctx, primaryCfg, ar, ds, rs, met, l)
+		primary, err := configureHistorianBackend(ctx, primaryCfg, ar, ds, rs, sqlStore, met, l)
 		if err != nil {
 			return nil, fmt.Errorf("multi-backend target \"%s\" was misconfigured: %w", cfg.MultiPrimary, err)
 		}
@@ -477,7 +539,7 @@ func configureHistorianBackend(ctx context.Context, cfg setting.UnifiedAlertingS
 		for _, b := range cfg.MultiSecondaries {
 			secCfg := cfg
 			secCfg.Backend = b
-			sec, err := configureHistorianBackend(ctx, secCfg, ar, ds, rs, met, l)
+			sec, err := configureHistorianBackend(ctx, secCfg, ar, ds, rs, sqlStore, met, l)
 			if err != nil {
 				return nil, fmt.Errorf("multi-backend target \"%s\" was miconfigured: %w", b, err)
 			}
@@ -506,10 +568,48 @@ func configureHistorianBackend(ctx context.Context, cfg setting.UnifiedAlertingS
 		}
 		return backend, nil
 	}
+	if backend == historian.BackendTypeSQL {
+		return historian.NewSQLBackend(sqlStore, cfg.SQLRetention, met), nil
+	}
 
 	return nil, fmt.Errorf("unrecognized state history backend: %s", backend)
 }
 
+type NotificationHistorian interface {
+	api.NotificationHistorian
+	notifier.NotificationHistorian
+}
+
+func configureNotificationHistorianBackend(ctx context.Context, cfg setting.UnifiedAlertingSettings, store db.DB, met *metrics.Historian, l log.Logger) (NotificationHistorian, error) {
+	backend, err := history.ParseBackendType(cfg.NotificationHistory.Backend)
+	if err != nil {
+		return nil, err
+	}
+	logger := l.New("component", "notification-history", "backend", backend.String())
+	logger.Info("Initializing notification history")
+
+	if backend == history.BackendTypeSQL {
+		return history.NewSQLBackend(store, cfg.NotificationHistory.Retention, logger), nil
+	}
+	if backend == history.BackendTypeLoki {
+		// The Loki backend shares the connection settings with state history.
+		lcfg, err := historian.NewLokiConfig(cfg.StateHistory)
+		if err != nil {
+			return nil, fmt.Errorf("invalid remote loki configuration: %w", err)
+		}
+		backend := history.NewRemoteLokiBackend(lcfg, historian.NewRequester(), met, logger)
+
+		testConnCtx, cancelFunc := context.WithTimeout(ctx, 10*time.Second)
+		defer cancelFunc()
+		if err := backend.TestConnection(testConnCtx); err != nil {
+			logger.Error("Failed to communicate with configured remote Loki backend, notification history may not be persisted", "error", err)
+		}
+		return backend, nil
+	}
+
+	return nil, fmt.Errorf("unrecognized notification history backend: %s", backend)
+}
+
 // ApplyStateHistoryFeatureToggles edits state history configuration to comply with currently active feature toggles.
 func ApplyStateHistoryFeatureToggles(cfg *setting.UnifiedAlertingStateHistorySettings, ft featuremgmt.FeatureToggles, logger log.Logger) {
 	backend, _ := historian.ParseBackendType(cfg.Backend)
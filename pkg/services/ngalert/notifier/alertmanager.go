@@ -57,6 +57,9 @@ type alertmanager struct {
 
 	decryptFn alertingNotify.GetDecryptedValueFn
 	orgID     int64
+
+	// notificationHistorian records attempts to deliver notifications. It is nil if notification history is disabled.
+	notificationHistorian NotificationHistorian
 }
 
 // maintenanceOptions represent the options for components that need maintenance on a frequency within the Alertmanager.
@@ -378,6 +381,9 @@ func (am *alertmanager) buildReceiverIntegrations(receiver *alertingNotify.APIRe
 	if err != nil {
 		return nil, err
 	}
+	if am.notificationHistorian != nil {
+		integrations = withNotificationHistory(integrations, receiver.Name, am.orgID, am.notificationHistorian, am.logger)
+	}
 	return integrations, nil
 }
 
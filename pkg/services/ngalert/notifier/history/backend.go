@@ -0,0 +1,49 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BackendType identifies different kinds of notification history backends.
+type BackendType string
+
+// String implements Stringer for BackendType.
+func (bt BackendType) String() string {
+	return string(bt)
+}
+
+const (
+	BackendTypeSQL  BackendType = "sql"
+	BackendTypeLoki BackendType = "loki"
+)
+
+// defaultQueryLimit is the number of entries returned by a query that does not specify a limit.
+const defaultQueryLimit = 1000
+
+// maximumQueryLimit is the largest number of entries a single query can return.
+const maximumQueryLimit = 5000
+
+func ParseBackendType(s string) (BackendType, error) {
+	norm := strings.ToLower(strings.TrimSpace(s))
+
+	types := map[BackendType]struct{}{
+		BackendTypeSQL:  {},
+		BackendTypeLoki: {},
+	}
+	p := BackendType(norm)
+	if _, ok := types[p]; !ok {
+		return "", fmt.Errorf("unrecognized notification history backend: %s", p)
+	}
+	return p, nil
+}
+
+func queryLimit(limit int) int {
+	if limit < 1 {
+		return defaultQueryLimit
+	}
+	if limit > maximumQueryLimit {
+		return maximumQueryLimit
+	}
+	return limit
+}
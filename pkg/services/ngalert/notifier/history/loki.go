@@ -0,0 +1,214 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/client"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	statehistorian "github.com/grafana/grafana/pkg/services/ngalert/state/historian"
+)
+
+const (
+	FromLabel        = "from"
+	FromLabelValue   = "notification-history"
+	OrgIDLabel       = "orgID"
+	ReceiverLabel    = "receiver"
+	IntegrationLabel = "integration"
+	StatusLabel      = "status"
+)
+
+type remoteLokiClient interface {
+	Ping(context.Context) error
+	Push(context.Context, []statehistorian.Stream) error
+	RangeQuery(ctx context.Context, logQL string, start, end, limit int64) (statehistorian.QueryRes, error)
+}
+
+// lokiEntry is the log line of a single notification history entry.
+type lokiEntry struct {
+	GroupKey          string   `json:"groupKey"`
+	IntegrationIndex  int      `json:"integrationIndex"`
+	AlertFingerprints []string `json:"alertFingerprints"`
+	AlertsFiring      int      `json:"alertsFiring"`
+	AlertsResolved    int      `json:"alertsResolved"`
+	Retry             int      `json:"retry"`
+	Error             string   `json:"error,omitempty"`
+	DurationMs        int64    `json:"durationMs"`
+}
+
+// RemoteLokiBackend is a notification history backend that stores entries in a Loki instance.
+// The retention of the entries is managed by Loki.
+type RemoteLokiBackend struct {
+	client         remoteLokiClient
+	externalLabels map[string]string
+	log            log.Logger
+}
+
+func NewRemoteLokiBackend(cfg statehistorian.LokiConfig, req client.Requester, metrics *metrics.Historian, logger log.Logger) *RemoteLokiBackend {
+	return &RemoteLokiBackend{
+		client:         statehistorian.NewLokiClient(cfg, req, metrics, logger),
+		externalLabels: cfg.ExternalLabels,
+		log:            logger,
+	}
+}
+
+// TestConnection checks whether the Loki instance can be reached.
+func (h *RemoteLokiBackend) TestConnection(ctx context.Context) error {
+	return h.client.Ping(ctx)
+}
+
+// Record writes the entries to Loki.
+func (h *RemoteLokiBackend) Record(ctx context.Context, entries []models.NotificationHistoryEntry) <-chan error {
+	errCh := make(chan error, 1)
+	if len(entries) == 0 {
+		close(errCh)
+		return errCh
+	}
+
+	streams, err := h.entriesToStreams(entries)
+	if err != nil {
+		errCh <- err
+		close(errCh)
+		return errCh
+	}
+
+	go func() {
+		defer close(errCh)
+		if err := h.client.Push(ctx, streams); err != nil {
+			errCh <- fmt.Errorf("failed to push notification history to Loki: %w", err)
+		}
+	}()
+	return errCh
+}
+
+func (h *RemoteLokiBackend) entriesToStreams(entries []models.NotificationHistoryEntry) ([]statehistorian.Stream, error) {
+	streams := make(map[string]*statehistorian.Stream)
+	keys := make([]string, 0)
+	for _, e := range entries {
+		labels := make(map[string]string, len(h.externalLabels)+5)
+		for k, v := range h.externalLabels {
+			labels[k] = v
+		}
+		labels[FromLabel] = FromLabelValue
+		labels[OrgIDLabel] = strconv.FormatInt(e.OrgID, 10)
+		labels[ReceiverLabel] = e.Receiver
+		labels[IntegrationLabel] = e.Integration
+		labels[StatusLabel] = string(e.Status)
+
+		line, err := json.Marshal(lokiEntry{
+			GroupKey:          e.GroupKey,
+			IntegrationIndex:  e.IntegrationIndex,
+			AlertFingerprints: e.AlertFingerprints,
+			AlertsFiring:      e.AlertsFiring,
+			AlertsResolved:    e.AlertsResolved,
+			Retry:             e.Retry,
+			Error:             e.Error,
+			DurationMs:        e.Duration.Milliseconds(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize notification history entry: %w", err)
+		}
+
+		key := fmt.Sprintf("%d/%s/%s/%s", e.OrgID, e.Receiver, e.Integration, e.Status)
+		s, ok := streams[key]
+		if !ok {
+			s = &statehistorian.Stream{Stream: labels}
+			streams[key] = s
+			keys = append(keys, key)
+		}
+		s.Values = append(s.Values, statehistorian.Sample{T: e.Timestamp, V: string(line)})
+	}
+
+	result := make([]statehistorian.Stream, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, *streams[k])
+	}
+	return result, nil
+}
+
+// Query returns the entries that match the query, newest first.
+func (h *RemoteLokiBackend) Query(ctx context.Context, query models.NotificationHistoryQuery) ([]models.NotificationHistoryEntry, error) {
+	to := query.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from := query.From
+	if from.IsZero() {
+		from = to.Add(-24 * time.Hour)
+	}
+	limit := queryLimit(query.Limit)
+
+	res, err := h.client.RangeQuery(ctx, BuildLogQuery(query), from.UnixNano(), to.UnixNano(), int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification history from Loki: %w", err)
+	}
+
+	result := make([]models.NotificationHistoryEntry, 0)
+	for _, stream := range res.Data.Result {
+		orgID, err := strconv.ParseInt(stream.Stream[OrgIDLabel], 10, 64)
+		if err != nil {
+			h.log.Warn("Ignoring notification history stream with an invalid organization label", "labels", stream.Stream)
+			continue
+		}
+		for _, sample := range stream.Values {
+			var line lokiEntry
+			if err := json.Unmarshal([]byte(sample.V), &line); err != nil {
+				h.log.Warn("Ignoring notification history entry that cannot be parsed", "error", err)
+				continue
+			}
+			result = append(result, models.NotificationHistoryEntry{
+				OrgID:             orgID,
+				Timestamp:         sample.T,
+				Receiver:          stream.Stream[ReceiverLabel],
+				Integration:       stream.Stream[IntegrationLabel],
+				IntegrationIndex:  line.IntegrationIndex,
+				GroupKey:          line.GroupKey,
+				AlertFingerprints: line.AlertFingerprints,
+				AlertsFiring:      line.AlertsFiring,
+				AlertsResolved:    line.AlertsResolved,
+				Status:            models.NotificationStatus(stream.Stream[StatusLabel]),
+				Retry:             line.Retry,
+				Error:             line.Error,
+				Duration:          time.Duration(line.DurationMs) * time.Millisecond,
+			})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.After(result[j].Timestamp)
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// BuildLogQuery builds the LogQL query that selects the notification history entries that match the query.
+func BuildLogQuery(query models.NotificationHistoryQuery) string {
+	logQL := fmt.Sprintf("{%s=%q,%s=%q", FromLabel, FromLabelValue, OrgIDLabel, strconv.FormatInt(query.OrgID, 10))
+	if query.Receiver != "" {
+		logQL += fmt.Sprintf(",%s=%q", ReceiverLabel, query.Receiver)
+	}
+	if query.Integration != "" {
+		logQL += fmt.Sprintf(",%s=%q", IntegrationLabel, query.Integration)
+	}
+	if query.Status != "" {
+		logQL += fmt.Sprintf(",%s=%q", StatusLabel, string(query.Status))
+	}
+	logQL += "}"
+
+	if query.AlertFingerprint != "" {
+		// Fingerprints are hexadecimal, so the quoted fingerprint only matches the fingerprint itself.
+		logQL += fmt.Sprintf(" |= %q", strconv.Quote(query.AlertFingerprint))
+	}
+	if query.GroupKey != "" {
+		logQL += fmt.Sprintf(" | json | groupKey=%q", query.GroupKey)
+	}
+	return logQL
+}
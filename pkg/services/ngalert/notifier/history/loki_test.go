@@ -0,0 +1,129 @@
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	statehistorian "github.com/grafana/grafana/pkg/services/ngalert/state/historian"
+)
+
+type fakeLokiClient struct {
+	pushed []statehistorian.Stream
+	logQL  string
+	limit  int64
+}
+
+func (f *fakeLokiClient) Ping(context.Context) error {
+	return nil
+}
+
+func (f *fakeLokiClient) Push(_ context.Context, streams []statehistorian.Stream) error {
+	f.pushed = append(f.pushed, streams...)
+	return nil
+}
+
+func (f *fakeLokiClient) RangeQuery(_ context.Context, logQL string, _, _, limit int64) (statehistorian.QueryRes, error) {
+	f.logQL = logQL
+	f.limit = limit
+	return statehistorian.QueryRes{Data: statehistorian.QueryData{Result: f.pushed}}, nil
+}
+
+func TestRemoteLokiBackend(t *testing.T) {
+	now := time.Now().Truncate(time.Millisecond)
+	entries := []models.NotificationHistoryEntry{
+		{
+			OrgID:             1,
+			Timestamp:         now.Add(-time.Minute),
+			Receiver:          "team-a",
+			Integration:       "email",
+			GroupKey:          "group-1",
+			AlertFingerprints: []string{"aaaa"},
+			AlertsFiring:      1,
+			Status:            models.NotificationStatusFailed,
+			Error:             "connection refused",
+			Duration:          time.Second,
+		},
+		{
+			OrgID:             1,
+			Timestamp:         now,
+			Receiver:          "team-a",
+			Integration:       "email",
+			GroupKey:          "group-1",
+			AlertFingerprints: []string{"aaaa"},
+			AlertsFiring:      1,
+			Status:            models.NotificationStatusSuccess,
+			Retry:             1,
+		},
+		{
+			OrgID:             1,
+			Timestamp:         now.Add(-30 * time.Second),
+			Receiver:          "team-a",
+			Integration:       "email",
+			GroupKey:          "group-2",
+			AlertFingerprints: []string{"bbbb"},
+			AlertsResolved:    1,
+			Status:            models.NotificationStatusSuccess,
+		},
+	}
+
+	client := &fakeLokiClient{}
+	backend := &RemoteLokiBackend{
+		client:         client,
+		externalLabels: map[string]string{"cluster": "prod"},
+		log:            log.NewNopLogger(),
+	}
+	require.NoError(t, <-backend.Record(context.Background(), entries))
+
+	t.Run("should group entries into streams by labels", func(t *testing.T) {
+		require.Len(t, client.pushed, 2)
+		require.Equal(t, map[string]string{
+			"cluster":        "prod",
+			FromLabel:        FromLabelValue,
+			OrgIDLabel:       "1",
+			ReceiverLabel:    "team-a",
+			IntegrationLabel: "email",
+			StatusLabel:      "failed",
+		}, client.pushed[0].Stream)
+		require.Len(t, client.pushed[0].Values, 1)
+		require.Len(t, client.pushed[1].Values, 2)
+	})
+
+	t.Run("should return entries newest first", func(t *testing.T) {
+		result, err := backend.Query(context.Background(), models.NotificationHistoryQuery{OrgID: 1, Limit: 2})
+		require.NoError(t, err)
+		require.Equal(t, int64(2), client.limit)
+		require.Len(t, result, 2)
+		require.Equal(t, entries[1], result[0])
+		require.Equal(t, entries[2], result[1])
+	})
+}
+
+func TestBuildLogQuery(t *testing.T) {
+	testCases := map[string]struct {
+		query    models.NotificationHistoryQuery
+		expected string
+	}{
+		"organization only": {
+			query:    models.NotificationHistoryQuery{OrgID: 1},
+			expected: `{from="notification-history",orgID="1"}`,
+		},
+		"labels": {
+			query:    models.NotificationHistoryQuery{OrgID: 1, Receiver: "team-a", Integration: "slack", Status: models.NotificationStatusFailed},
+			expected: `{from="notification-history",orgID="1",receiver="team-a",integration="slack",status="failed"}`,
+		},
+		"fingerprint and group key": {
+			query:    models.NotificationHistoryQuery{OrgID: 1, AlertFingerprint: "aaaa", GroupKey: `{}:{alertname="test"}`},
+			expected: `{from="notification-history",orgID="1"} |= "\"aaaa\"" | json | groupKey="{}:{alertname=\"test\"}"`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, BuildLogQuery(tc.query))
+		})
+	}
+}
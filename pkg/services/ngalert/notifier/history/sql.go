@@ -0,0 +1,201 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// cleanupInterval is how often the SQL backend deletes entries that are older than the retention period.
+const cleanupInterval = time.Hour
+
+// notificationHistoryRow is a row of the alert_notification_history table.
+type notificationHistoryRow struct {
+	ID                int64  `xorm:"pk autoincr 'id'"`
+	OrgID             int64  `xorm:"org_id"`
+	Timestamp         int64  `xorm:"timestamp"`
+	Receiver          string `xorm:"receiver"`
+	Integration       string `xorm:"integration"`
+	IntegrationIndex  int    `xorm:"integration_index"`
+	GroupKey          string `xorm:"group_key"`
+	AlertFingerprints string `xorm:"alert_fingerprints"`
+	AlertsFiring      int    `xorm:"alerts_firing"`
+	AlertsResolved    int    `xorm:"alerts_resolved"`
+	Status            string `xorm:"status"`
+	Retry             int    `xorm:"retry"`
+	Error             string `xorm:"error"`
+	DurationMs        int64  `xorm:"duration_ms"`
+}
+
+func (notificationHistoryRow) TableName() string {
+	return "alert_notification_history"
+}
+
+// SQLBackend is a notification history backend that stores entries in the Grafana database.
+type SQLBackend struct {
+	store     db.DB
+	retention time.Duration
+	clock     clock.Clock
+	log       log.Logger
+}
+
+func NewSQLBackend(store db.DB, retention time.Duration, logger log.Logger) *SQLBackend {
+	return &SQLBackend{
+		store:     store,
+		retention: retention,
+		clock:     clock.New(),
+		log:       logger,
+	}
+}
+
+// Record writes the entries to the database.
+func (h *SQLBackend) Record(ctx context.Context, entries []models.NotificationHistoryEntry) <-chan error {
+	errCh := make(chan error, 1)
+	if len(entries) == 0 {
+		close(errCh)
+		return errCh
+	}
+
+	rows := make([]*notificationHistoryRow, 0, len(entries))
+	for _, e := range entries {
+		rows = append(rows, &notificationHistoryRow{
+			OrgID:             e.OrgID,
+			Timestamp:         e.Timestamp.UnixMilli(),
+			Receiver:          e.Receiver,
+			Integration:       e.Integration,
+			IntegrationIndex:  e.IntegrationIndex,
+			GroupKey:          e.GroupKey,
+			AlertFingerprints: strings.Join(e.AlertFingerprints, ","),
+			AlertsFiring:      e.AlertsFiring,
+			AlertsResolved:    e.AlertsResolved,
+			Status:            string(e.Status),
+			Retry:             e.Retry,
+			Error:             e.Error,
+			DurationMs:        e.Duration.Milliseconds(),
+		})
+	}
+
+	go func() {
+		defer close(errCh)
+		err := h.store.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.InsertMulti(&rows)
+			return err
+		})
+		if err != nil {
+			errCh <- fmt.Errorf("failed to save notification history: %w", err)
+		}
+	}()
+	return errCh
+}
+
+// Query returns the entries that match the query, newest first.
+// likeEscape is the escape character of the LIKE patterns built from user input. A backslash
+// would need escaping itself in MySQL string literals and SQLite has no default escape character.
+const likeEscape = " ESCAPE '!'"
+
+// likeEscaper makes the wildcards in user input match literally.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+func (h *SQLBackend) Query(ctx context.Context, query models.NotificationHistoryQuery) ([]models.NotificationHistoryEntry, error) {
+	var rows []notificationHistoryRow
+	err := h.store.WithDbSession(ctx, func(sess *db.Session) error {
+		q := sess.Table("alert_notification_history").Where("org_id = ?", query.OrgID)
+		if !query.From.IsZero() {
+			q = q.And("timestamp >= ?", query.From.UnixMilli())
+		}
+		if !query.To.IsZero() {
+			q = q.And("timestamp <= ?", query.To.UnixMilli())
+		}
+		if query.Receiver != "" {
+			q = q.And("receiver = ?", query.Receiver)
+		}
+		if query.Integration != "" {
+			q = q.And("integration = ?", query.Integration)
+		}
+		if query.Status != "" {
+			q = q.And("status = ?", string(query.Status))
+		}
+		if query.GroupKey != "" {
+			q = q.And("group_key = ?", query.GroupKey)
+		}
+		if query.AlertFingerprint != "" {
+			// Fingerprints are stored as a comma-separated list, and a fingerprint never contains a comma.
+			q = q.And("("+h.store.GetDialect().Concat("','", "alert_fingerprints", "','")+") LIKE ?"+likeEscape, "%,"+likeEscaper.Replace(query.AlertFingerprint)+",%")
+		}
+		return q.Desc("timestamp").Desc("id").Limit(queryLimit(query.Limit)).Find(&rows)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification history: %w", err)
+	}
+
+	result := make([]models.NotificationHistoryEntry, 0, len(rows))
+	for _, row := range rows {
+		var fingerprints []string
+		if row.AlertFingerprints != "" {
+			fingerprints = strings.Split(row.AlertFingerprints, ",")
+		}
+		result = append(result, models.NotificationHistoryEntry{
+			OrgID:             row.OrgID,
+			Timestamp:         time.UnixMilli(row.Timestamp),
+			Receiver:          row.Receiver,
+			Integration:       row.Integration,
+			IntegrationIndex:  row.IntegrationIndex,
+			GroupKey:          row.GroupKey,
+			AlertFingerprints: fingerprints,
+			AlertsFiring:      row.AlertsFiring,
+			AlertsResolved:    row.AlertsResolved,
+			Status:            models.NotificationStatus(row.Status),
+			Retry:             row.Retry,
+			Error:             row.Error,
+			Duration:          time.Duration(row.DurationMs) * time.Millisecond,
+		})
+	}
+	return result, nil
+}
+
+// Run periodically deletes the entries that are older than the retention period until the context is cancelled.
+func (h *SQLBackend) Run(ctx context.Context) error {
+	if h.retention <= 0 {
+		return nil
+	}
+	ticker := h.clock.Ticker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := h.DeleteExpired(ctx); err != nil {
+			h.log.Error("Failed to delete expired notification history", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeleteExpired deletes the entries that are older than the retention period and returns the number of deleted entries.
+func (h *SQLBackend) DeleteExpired(ctx context.Context) (int64, error) {
+	cutoff := h.clock.Now().Add(-h.retention).UnixMilli()
+	var deleted int64
+	err := h.store.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("DELETE FROM alert_notification_history WHERE timestamp < ?", cutoff)
+		if err != nil {
+			return err
+		}
+		deleted, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		h.log.Debug("Deleted expired notification history", "count", deleted)
+	}
+	return deleted, nil
+}
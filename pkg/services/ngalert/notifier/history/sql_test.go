@@ -0,0 +1,116 @@
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestIntegrationSQLBackend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	backend := NewSQLBackend(sqlStore, 24*time.Hour, log.NewNopLogger())
+	clk := clock.NewMock()
+	clk.Set(time.Now().Truncate(time.Millisecond))
+	backend.clock = clk
+	now := clk.Now()
+
+	entries := []models.NotificationHistoryEntry{
+		{
+			OrgID:             1,
+			Timestamp:         now.Add(-2 * time.Minute),
+			Receiver:          "team-a",
+			Integration:       "email",
+			GroupKey:          "group-1",
+			AlertFingerprints: []string{"aaaa", "bbbb"},
+			AlertsFiring:      2,
+			Status:            models.NotificationStatusFailed,
+			Error:             "connection refused",
+			Duration:          1500 * time.Millisecond,
+		},
+		{
+			OrgID:             1,
+			Timestamp:         now.Add(-time.Minute),
+			Receiver:          "team-a",
+			Integration:       "email",
+			GroupKey:          "group-1",
+			AlertFingerprints: []string{"aaaa", "bbbb"},
+			AlertsFiring:      2,
+			Status:            models.NotificationStatusSuccess,
+			Retry:             1,
+			Duration:          200 * time.Millisecond,
+		},
+		{
+			OrgID:             1,
+			Timestamp:         now,
+			Receiver:          "team-b",
+			Integration:       "slack",
+			IntegrationIndex:  1,
+			GroupKey:          "group-2",
+			AlertFingerprints: []string{"bbbbcccc"},
+			AlertsResolved:    1,
+			Status:            models.NotificationStatusSuccess,
+		},
+		{
+			OrgID:             2,
+			Timestamp:         now,
+			Receiver:          "team-a",
+			Integration:       "email",
+			GroupKey:          "group-1",
+			AlertFingerprints: []string{"aaaa"},
+			AlertsFiring:      1,
+			Status:            models.NotificationStatusSuccess,
+		},
+	}
+	require.NoError(t, <-backend.Record(context.Background(), entries))
+
+	t.Run("should return entries of organization newest first", func(t *testing.T) {
+		result, err := backend.Query(context.Background(), models.NotificationHistoryQuery{OrgID: 1})
+		require.NoError(t, err)
+		require.Equal(t, []models.NotificationHistoryEntry{entries[2], entries[1], entries[0]}, result)
+	})
+
+	t.Run("should filter entries", func(t *testing.T) {
+		testCases := map[string]struct {
+			query    models.NotificationHistoryQuery
+			expected []models.NotificationHistoryEntry
+		}{
+			"by receiver":                   {query: models.NotificationHistoryQuery{OrgID: 1, Receiver: "team-b"}, expected: entries[2:3]},
+			"by integration":                {query: models.NotificationHistoryQuery{OrgID: 1, Integration: "email"}, expected: []models.NotificationHistoryEntry{entries[1], entries[0]}},
+			"by status":                     {query: models.NotificationHistoryQuery{OrgID: 1, Status: models.NotificationStatusFailed}, expected: entries[0:1]},
+			"by group key":                  {query: models.NotificationHistoryQuery{OrgID: 1, GroupKey: "group-2"}, expected: entries[2:3]},
+			"by fingerprint":                {query: models.NotificationHistoryQuery{OrgID: 1, AlertFingerprint: "bbbb"}, expected: []models.NotificationHistoryEntry{entries[1], entries[0]}},
+			"by fingerprint with wildcards": {query: models.NotificationHistoryQuery{OrgID: 1, AlertFingerprint: "b_bb"}, expected: []models.NotificationHistoryEntry{}},
+			"by time range":                 {query: models.NotificationHistoryQuery{OrgID: 1, From: now.Add(-90 * time.Second), To: now.Add(-30 * time.Second)}, expected: entries[1:2]},
+			"with limit":                    {query: models.NotificationHistoryQuery{OrgID: 1, Limit: 1}, expected: entries[2:3]},
+			"with no matching row":          {query: models.NotificationHistoryQuery{OrgID: 3}, expected: []models.NotificationHistoryEntry{}},
+		}
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				result, err := backend.Query(context.Background(), tc.query)
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, result)
+			})
+		}
+	})
+
+	t.Run("should delete entries older than retention", func(t *testing.T) {
+		clk.Add(24*time.Hour - 30*time.Second)
+		deleted, err := backend.DeleteExpired(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(2), deleted)
+
+		result, err := backend.Query(context.Background(), models.NotificationHistoryQuery{OrgID: 1})
+		require.NoError(t, err)
+		require.Equal(t, []models.NotificationHistoryEntry{entries[2]}, result)
+	})
+}
@@ -83,6 +83,8 @@ type MultiOrgAlertmanager struct {
 
 	metrics *metrics.MultiOrgAlertmanager
 	ns      notifications.Service
+
+	notificationHistorian NotificationHistorian
 }
 
 type OrgAlertmanagerFactory func(ctx context.Context, orgID int64) (Alertmanager, error)
@@ -122,7 +124,12 @@ func NewMultiOrgAlertmanager(cfg *setting.Cfg, configStore AlertingStore, orgSto
 	// Set up the default per tenant Alertmanager factory.
 	moa.factory = func(ctx context.Context, orgID int64) (Alertmanager, error) {
 		m := metrics.NewAlertmanagerMetrics(moa.metrics.GetOrCreateOrgRegistry(orgID))
-		return NewAlertmanager(ctx, orgID, moa.settings, moa.configStore, moa.kvStore, moa.peer, moa.decryptFn, moa.ns, m)
+		am, err := NewAlertmanager(ctx, orgID, moa.settings, moa.configStore, moa.kvStore, moa.peer, moa.decryptFn, moa.ns, m)
+		if err != nil {
+			return nil, err
+		}
+		am.notificationHistorian = moa.notificationHistorian
+		return am, nil
 	}
 
 	for _, opt := range opts {
@@ -418,6 +425,24 @@ func (moa *MultiOrgAlertmanager) AlertmanagerFor(orgID int64) (Alertmanager, err
 	return orgAM, nil
 }
 
+// ClusterMembers returns the name of this instance and the names of all live members of the Alertmanager cluster,
+// including this instance. It returns false if high availability mode is not configured.
+func (moa *MultiOrgAlertmanager) ClusterMembers() (string, []string, bool) {
+	switch p := moa.peer.(type) {
+	case *alertingCluster.Peer:
+		peers := p.Peers()
+		members := make([]string, 0, len(peers))
+		for _, m := range peers {
+			members = append(members, m.Name())
+		}
+		return p.Name(), members, true
+	case *redisPeer:
+		return p.withPrefix(p.name), p.Members(), true
+	default:
+		return "", nil, false
+	}
+}
+
 // NilPeer and NilChannel implements the Alertmanager clustering interface.
 type NilPeer struct{}
 
@@ -0,0 +1,133 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	alertingNotify "github.com/grafana/alerting/notify"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// notificationAttemptsRetention is how long the number of attempts to deliver a notification is remembered
+// to calculate the retry count. The Alertmanager does not retry a notification for longer than the group interval.
+const notificationAttemptsRetention = time.Hour
+
+// NotificationHistorian maintains a log of attempts to deliver notifications.
+type NotificationHistorian interface {
+	// Record writes the entries to notification history. It returns a channel that is closed when writing
+	// the entries has completed. If an error has occurred, the channel will contain a non-nil error.
+	Record(ctx context.Context, entries []models.NotificationHistoryEntry) <-chan error
+}
+
+// WithNotificationHistorian makes the Alertmanagers of all organizations record every attempt to deliver a notification.
+func WithNotificationHistorian(h NotificationHistorian) Option {
+	return func(moa *MultiOrgAlertmanager) {
+		moa.notificationHistorian = h
+	}
+}
+
+// withNotificationHistory wraps the integrations so that every call of the integration is recorded to notification history.
+func withNotificationHistory(integrations []*alertingNotify.Integration, receiver string, orgID int64, h NotificationHistorian, l log.Logger) []*alertingNotify.Integration {
+	result := make([]*alertingNotify.Integration, 0, len(integrations))
+	for _, integration := range integrations {
+		n := &historyRecordingNotifier{
+			integration: integration,
+			receiver:    receiver,
+			orgID:       orgID,
+			historian:   h,
+			log:         l,
+			attempts:    make(map[notificationAttemptKey]int),
+		}
+		result = append(result, alertingNotify.NewIntegration(n, n, integration.Name(), integration.Index(), receiver))
+	}
+	return result
+}
+
+// notificationAttemptKey identifies a notification that can be attempted multiple times.
+// All retries of a notification share the group key and the time the notification pipeline started.
+type notificationAttemptKey struct {
+	groupKey     string
+	pipelineTime time.Time
+}
+
+// historyRecordingNotifier is a notifier that delegates to an integration and records the outcome of every call.
+type historyRecordingNotifier struct {
+	integration *alertingNotify.Integration
+	receiver    string
+	orgID       int64
+	historian   NotificationHistorian
+	log         log.Logger
+
+	mtx      sync.Mutex
+	attempts map[notificationAttemptKey]int
+}
+
+func (n *historyRecordingNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	start := time.Now()
+	retry, err := n.integration.Notify(ctx, alerts...)
+	duration := time.Since(start)
+
+	groupKey, _ := notify.GroupKey(ctx)
+	pipelineTime, ok := notify.Now(ctx)
+	if !ok {
+		pipelineTime = start
+	}
+
+	entry := models.NotificationHistoryEntry{
+		OrgID:             n.orgID,
+		Timestamp:         start,
+		Receiver:          n.receiver,
+		Integration:       n.integration.Name(),
+		IntegrationIndex:  n.integration.Index(),
+		GroupKey:          groupKey,
+		AlertFingerprints: make([]string, 0, len(alerts)),
+		Status:            models.NotificationStatusSuccess,
+		Retry:             n.nextAttempt(notificationAttemptKey{groupKey: groupKey, pipelineTime: pipelineTime}, start),
+		Duration:          duration,
+	}
+	for _, a := range alerts {
+		entry.AlertFingerprints = append(entry.AlertFingerprints, a.Fingerprint().String())
+		if a.ResolvedAt(pipelineTime) {
+			entry.AlertsResolved++
+		} else {
+			entry.AlertsFiring++
+		}
+	}
+	if err != nil {
+		entry.Status = models.NotificationStatusFailed
+		entry.Error = err.Error()
+	}
+
+	// Recording must not delay or cancel the notification pipeline, which can be cancelled right after this call returns.
+	errCh := n.historian.Record(context.Background(), []models.NotificationHistoryEntry{entry})
+	go func() {
+		if err := <-errCh; err != nil {
+			n.log.Error("Failed to record notification history", "receiver", n.receiver, "integration", entry.Integration, "error", err)
+		}
+	}()
+
+	return retry, err
+}
+
+func (n *historyRecordingNotifier) SendResolved() bool {
+	return n.integration.SendResolved()
+}
+
+// nextAttempt returns the number of previous attempts to deliver the notification and counts the current one.
+func (n *historyRecordingNotifier) nextAttempt(key notificationAttemptKey, now time.Time) int {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	for k := range n.attempts {
+		if now.Sub(k.pipelineTime) > notificationAttemptsRetention {
+			delete(n.attempts, k)
+		}
+	}
+	attempt := n.attempts[key]
+	n.attempts[key] = attempt + 1
+	return attempt
+}
@@ -0,0 +1,108 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	alertingNotify "github.com/grafana/alerting/notify"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+type fakeNotificationHistorian struct {
+	mtx     sync.Mutex
+	entries []models.NotificationHistoryEntry
+}
+
+func (f *fakeNotificationHistorian) Record(_ context.Context, entries []models.NotificationHistoryEntry) <-chan error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.entries = append(f.entries, entries...)
+	errCh := make(chan error)
+	close(errCh)
+	return errCh
+}
+
+type fakeIntegrationNotifier struct {
+	retry bool
+	err   error
+}
+
+func (f *fakeIntegrationNotifier) Notify(context.Context, ...*types.Alert) (bool, error) {
+	return f.retry, f.err
+}
+
+func (f *fakeIntegrationNotifier) SendResolved() bool {
+	return true
+}
+
+func TestWithNotificationHistory(t *testing.T) {
+	now := time.Now()
+	firing := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "firing"}, StartsAt: now.Add(-time.Minute)}}
+	resolved := &types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "resolved"}, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(-time.Minute)}}
+
+	notificationCtx := func(groupKey string, pipelineTime time.Time) context.Context {
+		ctx := notify.WithGroupKey(context.Background(), groupKey)
+		return notify.WithNow(ctx, pipelineTime)
+	}
+
+	t.Run("should record successful notification", func(t *testing.T) {
+		historian := &fakeNotificationHistorian{}
+		n := &fakeIntegrationNotifier{}
+		integrations := withNotificationHistory([]*alertingNotify.Integration{alertingNotify.NewIntegration(n, n, "email", 1, "team-a")}, "team-a", 1, historian, log.NewNopLogger())
+		require.Len(t, integrations, 1)
+		require.Equal(t, "email", integrations[0].Name())
+		require.Equal(t, 1, integrations[0].Index())
+
+		retry, err := integrations[0].Notify(notificationCtx("{}:{alertname=\"test\"}", now), firing, resolved)
+		require.NoError(t, err)
+		require.False(t, retry)
+
+		require.Len(t, historian.entries, 1)
+		entry := historian.entries[0]
+		require.Equal(t, int64(1), entry.OrgID)
+		require.Equal(t, "team-a", entry.Receiver)
+		require.Equal(t, "email", entry.Integration)
+		require.Equal(t, 1, entry.IntegrationIndex)
+		require.Equal(t, "{}:{alertname=\"test\"}", entry.GroupKey)
+		require.Equal(t, []string{firing.Fingerprint().String(), resolved.Fingerprint().String()}, entry.AlertFingerprints)
+		require.Equal(t, 1, entry.AlertsFiring)
+		require.Equal(t, 1, entry.AlertsResolved)
+		require.Equal(t, models.NotificationStatusSuccess, entry.Status)
+		require.Equal(t, 0, entry.Retry)
+		require.Empty(t, entry.Error)
+	})
+
+	t.Run("should record failed notification and count retries", func(t *testing.T) {
+		historian := &fakeNotificationHistorian{}
+		n := &fakeIntegrationNotifier{retry: true, err: errors.New("connection refused")}
+		integrations := withNotificationHistory([]*alertingNotify.Integration{alertingNotify.NewIntegration(n, n, "webhook", 0, "team-b")}, "team-b", 2, historian, log.NewNopLogger())
+
+		ctx := notificationCtx("group-1", now)
+		for i := 0; i < 3; i++ {
+			retry, err := integrations[0].Notify(ctx, firing)
+			require.ErrorIs(t, err, n.err)
+			require.True(t, retry)
+		}
+		// the next flush of the same group is a new notification
+		_, _ = integrations[0].Notify(notificationCtx("group-1", now.Add(time.Minute)), firing)
+		// as is a notification of another group
+		_, _ = integrations[0].Notify(notificationCtx("group-2", now), firing)
+
+		retries := make([]int, 0, len(historian.entries))
+		for _, e := range historian.entries {
+			require.Equal(t, models.NotificationStatusFailed, e.Status)
+			require.Equal(t, "connection refused", e.Error)
+			retries = append(retries, e.Retry)
+		}
+		require.Equal(t, []int{0, 1, 2, 0, 0}, retries)
+	})
+}
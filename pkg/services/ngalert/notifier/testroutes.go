@@ -0,0 +1,148 @@
+package notifier
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/inhibit"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/prometheus/common/model"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+var ErrTestRoutesInvalidConfig = errors.New("invalid notification policy configuration")
+
+// TestRoutesResult is the result of routing an alert through the notification policy tree.
+type TestRoutesResult struct {
+	Labels       model.LabelSet
+	Routes       []TestRouteResult
+	InhibitRules []TestInhibitRuleResult
+}
+
+// TestRouteResult is a route that matches the alert.
+type TestRouteResult struct {
+	// Path is the position of the route and its parents in the routing tree, starting from the children of the root route.
+	// It is empty for the root route.
+	Path []int
+	// Opts are the routing options of the route, including the ones inherited from its parents.
+	Opts dispatch.RouteOpts
+	// ActiveMuteTimeIntervals are the mute time intervals of the route that are active at the time of the test.
+	ActiveMuteTimeIntervals []string
+}
+
+// TestInhibitRuleResult is an inhibition rule whose target matchers match the alert.
+type TestInhibitRuleResult struct {
+	// Index is the position of the rule in the configuration.
+	Index int
+	Rule  config.InhibitRule
+	// Active is true if any of the firing alerts matches the source of the rule, i.e. the rule inhibits the alert.
+	Active bool
+}
+
+// TestRoutes routes an alert with the given labels through the routing tree of the configuration without sending notifications.
+// The mute time intervals of the matched routes are checked at the given time, and the inhibition rules are checked against the
+// given firing alerts.
+func TestRoutes(cfg apimodels.Config, lset model.LabelSet, firing []model.LabelSet, now time.Time) (*TestRoutesResult, error) {
+	if cfg.Route == nil {
+		return nil, fmt.Errorf("%w: no root route", ErrTestRoutesInvalidConfig)
+	}
+	if err := cfg.Route.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTestRoutesInvalidConfig, err)
+	}
+	muteTimes := make(map[string][]timeinterval.TimeInterval, len(cfg.MuteTimeIntervals))
+	names := make(map[string]struct{}, len(cfg.MuteTimeIntervals))
+	for _, mt := range cfg.MuteTimeIntervals {
+		muteTimes[mt.Name] = mt.TimeIntervals
+		names[mt.Name] = struct{}{}
+	}
+	if err := cfg.Route.ValidateMuteTimes(names); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTestRoutesInvalidConfig, err)
+	}
+	intervener := timeinterval.NewIntervener(muteTimes)
+
+	root := dispatch.NewRoute(cfg.Route.AsAMRoute(), nil)
+	paths := make(map[*dispatch.Route][]int)
+	var walk func(r *dispatch.Route, path []int)
+	walk = func(r *dispatch.Route, path []int) {
+		paths[r] = path
+		for i, child := range r.Routes {
+			walk(child, append(append(make([]int, 0, len(path)+1), path...), i))
+		}
+	}
+	walk(root, []int{})
+
+	result := &TestRoutesResult{Labels: lset}
+	for _, r := range root.Match(lset) {
+		route := TestRouteResult{
+			Path: paths[r],
+			Opts: r.RouteOpts,
+		}
+		for _, name := range r.RouteOpts.MuteTimeIntervals {
+			muted, err := intervener.Mutes([]string{name}, now)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrTestRoutesInvalidConfig, err)
+			}
+			if muted {
+				route.ActiveMuteTimeIntervals = append(route.ActiveMuteTimeIntervals, name)
+			}
+		}
+		result.Routes = append(result.Routes, route)
+	}
+
+	for i, cr := range cfg.InhibitRules {
+		rule := inhibit.NewInhibitRule(cr)
+		if !rule.TargetMatchers.Matches(lset) {
+			continue
+		}
+		result.InhibitRules = append(result.InhibitRules, TestInhibitRuleResult{
+			Index:  i,
+			Rule:   cr,
+			Active: inhibitedBy(rule, lset, firing),
+		})
+	}
+	return result, nil
+}
+
+// inhibitedBy returns true if any of the firing alerts matches the source of the inhibition rule and has the same values of the equal labels.
+// Like in the Alertmanager, a firing alert that matches both source and target cannot inhibit an alert that matches both too.
+func inhibitedBy(rule *inhibit.InhibitRule, lset model.LabelSet, firing []model.LabelSet) bool {
+	fp := lset.Fingerprint()
+	twoSided := rule.SourceMatchers.Matches(lset)
+	for _, alert := range firing {
+		if alert.Fingerprint() == fp || !rule.SourceMatchers.Matches(alert) {
+			continue
+		}
+		if twoSided && rule.TargetMatchers.Matches(alert) {
+			continue
+		}
+		equal := true
+		for name := range rule.Equal {
+			if alert[name] != lset[name] {
+				equal = false
+				break
+			}
+		}
+		if equal {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupByLabels returns the sorted labels that the route groups the alerts by. It returns "..." if the route groups by all labels.
+func GroupByLabels(opts dispatch.RouteOpts) []string {
+	if opts.GroupByAll {
+		return []string{"..."}
+	}
+	result := make([]string, 0, len(opts.GroupBy))
+	for name := range opts.GroupBy {
+		result = append(result, string(name))
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,125 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+func TestTestRoutes(t *testing.T) {
+	matcher := func(name, value string) *labels.Matcher {
+		m, err := labels.NewMatcher(labels.MatchEqual, name, value)
+		require.NoError(t, err)
+		return m
+	}
+	groupWait := model.Duration(time.Minute)
+	cfg := apimodels.Config{
+		Route: &apimodels.Route{
+			Receiver: "default",
+			Routes: []*apimodels.Route{
+				{
+					Receiver:       "team-a",
+					ObjectMatchers: apimodels.ObjectMatchers{matcher("team", "a")},
+					GroupByStr:     []string{"alertname"},
+					GroupWait:      &groupWait,
+					Continue:       true,
+					Routes: []*apimodels.Route{
+						{
+							Receiver:          "team-a-critical",
+							ObjectMatchers:    apimodels.ObjectMatchers{matcher("severity", "critical")},
+							MuteTimeIntervals: []string{"weekends", "nights"},
+						},
+					},
+				},
+				{
+					Receiver:       "all-teams",
+					ObjectMatchers: apimodels.ObjectMatchers{matcher("severity", "critical")},
+				},
+			},
+		},
+		MuteTimeIntervals: []config.MuteTimeInterval{
+			{
+				Name:          "weekends",
+				TimeIntervals: []timeinterval.TimeInterval{{Weekdays: []timeinterval.WeekdayRange{{InclusiveRange: timeinterval.InclusiveRange{Begin: 0, End: 0}}, {InclusiveRange: timeinterval.InclusiveRange{Begin: 6, End: 6}}}}},
+			},
+			{
+				Name:          "nights",
+				TimeIntervals: []timeinterval.TimeInterval{{Times: []timeinterval.TimeRange{{StartMinute: 0, EndMinute: 360}}}},
+			},
+		},
+		InhibitRules: []config.InhibitRule{
+			{
+				SourceMatchers: config.Matchers{matcher("alertname", "ClusterDown")},
+				TargetMatchers: config.Matchers{matcher("severity", "critical")},
+				Equal:          model.LabelNames{"cluster"},
+			},
+			{
+				SourceMatchers: config.Matchers{matcher("alertname", "Other")},
+				TargetMatchers: config.Matchers{matcher("team", "b")},
+			},
+		},
+	}
+	// Saturday, 10:00 UTC
+	saturday := time.Date(2024, 1, 6, 10, 0, 0, 0, time.UTC)
+
+	t.Run("should return the root route if no child route matches", func(t *testing.T) {
+		result, err := TestRoutes(cfg, model.LabelSet{"team": "b"}, nil, saturday)
+		require.NoError(t, err)
+		require.Len(t, result.Routes, 1)
+		require.Equal(t, "default", result.Routes[0].Opts.Receiver)
+		require.Empty(t, result.Routes[0].Path)
+		require.Len(t, result.InhibitRules, 1)
+		require.Equal(t, 1, result.InhibitRules[0].Index)
+		require.False(t, result.InhibitRules[0].Active)
+	})
+
+	t.Run("should return all matching routes with inherited options and active mute time intervals", func(t *testing.T) {
+		lset := model.LabelSet{"team": "a", "severity": "critical", "cluster": "eu"}
+		result, err := TestRoutes(cfg, lset, nil, saturday)
+		require.NoError(t, err)
+		require.Len(t, result.Routes, 2)
+
+		require.Equal(t, "team-a-critical", result.Routes[0].Opts.Receiver)
+		require.Equal(t, []int{0, 0}, result.Routes[0].Path)
+		require.Equal(t, []string{"alertname"}, GroupByLabels(result.Routes[0].Opts))
+		require.Equal(t, time.Minute, result.Routes[0].Opts.GroupWait)
+		require.Equal(t, []string{"weekends"}, result.Routes[0].ActiveMuteTimeIntervals)
+
+		require.Equal(t, "all-teams", result.Routes[1].Opts.Receiver)
+		require.Equal(t, []int{1}, result.Routes[1].Path)
+		require.Empty(t, result.Routes[1].ActiveMuteTimeIntervals)
+	})
+
+	t.Run("should return active inhibition rules if a firing alert matches the source", func(t *testing.T) {
+		lset := model.LabelSet{"severity": "critical", "cluster": "eu"}
+
+		result, err := TestRoutes(cfg, lset, []model.LabelSet{{"alertname": "ClusterDown", "cluster": "us"}}, saturday)
+		require.NoError(t, err)
+		require.Len(t, result.InhibitRules, 1)
+		require.False(t, result.InhibitRules[0].Active)
+
+		result, err = TestRoutes(cfg, lset, []model.LabelSet{{"alertname": "ClusterDown", "cluster": "eu"}}, saturday)
+		require.NoError(t, err)
+		require.Len(t, result.InhibitRules, 1)
+		require.True(t, result.InhibitRules[0].Active)
+	})
+
+	t.Run("should fail if the route uses undefined mute time intervals", func(t *testing.T) {
+		invalid := cfg
+		invalid.MuteTimeIntervals = nil
+		_, err := TestRoutes(invalid, model.LabelSet{"team": "a"}, nil, saturday)
+		require.ErrorIs(t, err, ErrTestRoutesInvalidConfig)
+	})
+
+	t.Run("should fail if there is no root route", func(t *testing.T) {
+		_, err := TestRoutes(apimodels.Config{}, model.LabelSet{"team": "a"}, nil, saturday)
+		require.ErrorIs(t, err, ErrTestRoutesInvalidConfig)
+	})
+}
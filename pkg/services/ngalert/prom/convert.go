@@ -0,0 +1,370 @@
+package prom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/prometheus/promql/parser"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const (
+	// queryRefID is the RefID of the query of the converted rules. Templates refer to its value instead of $value.
+	queryRefID     = "A"
+	reduceRefID    = "B"
+	conditionRefID = "C"
+
+	// queryTimeRange is the relative time range of the instant query of the converted rules.
+	queryTimeRange = 10 * time.Minute
+)
+
+// Config configures the conversion of Prometheus rules to Grafana-managed rules.
+type Config struct {
+	OrgID        int64
+	NamespaceUID string
+	// DatasourceUID is the UID of the Prometheus data source the converted rules query.
+	DatasourceUID string
+	// DatasourceType is the type of the data source. Defaults to prometheus.
+	DatasourceType string
+	// DefaultInterval is the evaluation interval of the groups that do not specify one.
+	DefaultInterval time.Duration
+}
+
+// Issue is a part of a Prometheus rule group that cannot be converted as is.
+type Issue struct {
+	Group string
+	// Rule is the name of the alert or recording rule, empty if the issue concerns the whole group.
+	Rule string
+	// Skipped is true if the rule, or the whole group, is not converted.
+	Skipped bool
+	Message string
+}
+
+// RuleGroup is a converted Prometheus rule group.
+type RuleGroup struct {
+	Name     string
+	Interval time.Duration
+	Rules    []models.AlertRule
+}
+
+// Converter converts Prometheus alerting rules to Grafana-managed rules that query a Prometheus data source.
+//
+// The expression of an alerting rule that compares a vector with a number, for example `rate(errors[5m]) > 0.1`, is
+// split into a query of the vector, a reduce expression and a threshold expression, so the threshold can be edited in
+// Grafana. Any other expression is queried as is, and the rule fires for every series it returns, as it does in Prometheus.
+type Converter struct {
+	cfg Config
+}
+
+func NewConverter(cfg Config) (*Converter, error) {
+	if cfg.DatasourceUID == "" {
+		return nil, errors.New("data source UID must be specified")
+	}
+	if cfg.DatasourceType == "" {
+		cfg.DatasourceType = datasources.DS_PROMETHEUS
+	}
+	if cfg.DefaultInterval <= 0 {
+		return nil, errors.New("default interval must be greater than zero")
+	}
+	return &Converter{cfg: cfg}, nil
+}
+
+// ParseRuleFile parses a Prometheus rule file.
+func ParseRuleFile(content []byte) (apimodels.PrometheusRuleGroups, error) {
+	var result apimodels.PrometheusRuleGroups
+	if err := yaml.Unmarshal(content, &result); err != nil {
+		return apimodels.PrometheusRuleGroups{}, fmt.Errorf("failed to parse rule file: %w", err)
+	}
+	return result, nil
+}
+
+// Convert converts the rule groups. Rules that cannot be converted are skipped and reported as issues, as well as
+// the parts of the rules that are not supported by Grafana.
+func (c *Converter) Convert(groups []apimodels.PrometheusRuleGroup) ([]RuleGroup, []Issue) {
+	var result []RuleGroup
+	var issues []Issue
+	names := make(map[string]struct{}, len(groups))
+	titles := make(map[string]struct{})
+	for _, group := range groups {
+		if group.Name == "" {
+			issues = append(issues, Issue{Skipped: true, Message: "rule group must have a name"})
+			continue
+		}
+		if _, ok := names[group.Name]; ok {
+			issues = append(issues, Issue{Group: group.Name, Skipped: true, Message: "rule group with the same name is already converted"})
+			continue
+		}
+		names[group.Name] = struct{}{}
+
+		interval := time.Duration(group.Interval)
+		if interval == 0 {
+			interval = c.cfg.DefaultInterval
+		}
+		if group.Limit > 0 {
+			issues = append(issues, Issue{Group: group.Name, Message: "limit of alerts is not supported and is ignored"})
+		}
+
+		converted := RuleGroup{Name: group.Name, Interval: interval}
+		for _, rule := range group.Rules {
+			alertRule, ruleIssues, err := c.convertRule(group.Name, interval, rule)
+			issues = append(issues, ruleIssues...)
+			if err != nil {
+				issues = append(issues, Issue{Group: group.Name, Rule: ruleName(rule), Skipped: true, Message: err.Error()})
+				continue
+			}
+			if _, ok := titles[alertRule.Title]; ok {
+				issues = append(issues, Issue{Group: group.Name, Rule: alertRule.Title, Skipped: true, Message: "alert rule with the same name is already converted, names of Grafana-managed rules must be unique in a folder"})
+				continue
+			}
+			titles[alertRule.Title] = struct{}{}
+			alertRule.RuleGroupIndex = len(converted.Rules) + 1
+			converted.Rules = append(converted.Rules, alertRule)
+		}
+		if len(converted.Rules) == 0 {
+			issues = append(issues, Issue{Group: group.Name, Skipped: true, Message: "rule group has no rules that can be converted"})
+			continue
+		}
+		result = append(result, converted)
+	}
+	return result, issues
+}
+
+func (c *Converter) convertRule(group string, interval time.Duration, rule apimodels.ApiRuleNode) (models.AlertRule, []Issue, error) {
+	if rule.Record != "" {
+		return models.AlertRule{}, nil, errors.New("recording rules are not supported")
+	}
+	if rule.Alert == "" {
+		return models.AlertRule{}, nil, errors.New("rule must have a name")
+	}
+	promExpr, err := parser.ParseExpr(rule.Expr)
+	if err != nil {
+		return models.AlertRule{}, nil, fmt.Errorf("failed to parse expression: %w", err)
+	}
+
+	var issues []Issue
+	if rule.KeepFiringFor != nil && *rule.KeepFiringFor != 0 {
+		issues = append(issues, Issue{Group: group, Rule: rule.Alert, Message: "keep_firing_for is not supported and is ignored"})
+	}
+
+	data, err := c.convertExpr(promExpr)
+	if err != nil {
+		return models.AlertRule{}, nil, err
+	}
+
+	labels, labelIssues := convertTemplates(rule.Labels)
+	annotations, annotationIssues := convertTemplates(rule.Annotations)
+	for _, msg := range append(labelIssues, annotationIssues...) {
+		issues = append(issues, Issue{Group: group, Rule: rule.Alert, Message: msg})
+	}
+
+	var forDuration time.Duration
+	if rule.For != nil {
+		forDuration = time.Duration(*rule.For)
+	}
+
+	return models.AlertRule{
+		OrgID:           c.cfg.OrgID,
+		Title:           rule.Alert,
+		Condition:       conditionRefID,
+		Data:            data,
+		IntervalSeconds: int64(interval.Seconds()),
+		NamespaceUID:    c.cfg.NamespaceUID,
+		RuleGroup:       group,
+		// A Prometheus rule does not fire if its expression returns no series.
+		NoDataState:  models.OK,
+		ExecErrState: models.ErrorErrState,
+		For:          forDuration,
+		Labels:       labels,
+		Annotations:  annotations,
+	}, issues, nil
+}
+
+// convertExpr converts the expression of a Prometheus rule to a query, a reduce expression and a condition.
+func (c *Converter) convertExpr(promExpr parser.Expr) ([]models.AlertQuery, error) {
+	var query, condition models.AlertQuery
+	var err error
+	// Prometheus comparisons drop NaN values, other expressions keep them.
+	reduceMode := ""
+	if vector, op, threshold, ok := splitThreshold(promExpr); ok {
+		if query, err = c.prometheusQuery(vector.String()); err != nil {
+			return nil, err
+		}
+		reduceMode = "dropNN"
+		switch op {
+		case parser.GTR:
+			condition, err = thresholdExpression(expr.ThresholdIsAbove, threshold)
+		case parser.LSS:
+			condition, err = thresholdExpression(expr.ThresholdIsBelow, threshold)
+		default:
+			condition, err = mathExpression(fmt.Sprintf("$%s %s %s", reduceRefID, op, strconv.FormatFloat(threshold, 'g', -1, 64)))
+		}
+	} else {
+		if query, err = c.prometheusQuery(promExpr.String()); err != nil {
+			return nil, err
+		}
+		// the rule fires for every series that the query returns, whatever its value.
+		condition, err = mathExpression(fmt.Sprintf("is_number($%[1]s) || is_nan($%[1]s) || is_inf($%[1]s)", reduceRefID))
+	}
+	if err != nil {
+		return nil, err
+	}
+	reduce, err := reduceExpression(reduceMode)
+	if err != nil {
+		return nil, err
+	}
+	return []models.AlertQuery{query, reduce, condition}, nil
+}
+
+// splitThreshold splits an expression that compares a vector with a number, for example `up == 0`, into the vector,
+// the comparison operator and the number. The operator is flipped if the number is on the left.
+func splitThreshold(promExpr parser.Expr) (parser.Expr, parser.ItemType, float64, bool) {
+	binary, ok := unwrapParens(promExpr).(*parser.BinaryExpr)
+	if !ok || !binary.Op.IsComparisonOperator() || binary.ReturnBool {
+		return nil, 0, 0, false
+	}
+	lhs, rhs := unwrapParens(binary.LHS), unwrapParens(binary.RHS)
+	if n, ok := numberLiteral(rhs); ok && lhs.Type() == parser.ValueTypeVector {
+		return lhs, binary.Op, n, true
+	}
+	if n, ok := numberLiteral(lhs); ok && rhs.Type() == parser.ValueTypeVector {
+		return rhs, flip(binary.Op), n, true
+	}
+	return nil, 0, 0, false
+}
+
+func unwrapParens(promExpr parser.Expr) parser.Expr {
+	for {
+		p, ok := promExpr.(*parser.ParenExpr)
+		if !ok {
+			return promExpr
+		}
+		promExpr = p.Expr
+	}
+}
+
+func numberLiteral(promExpr parser.Expr) (float64, bool) {
+	var n float64
+	switch e := promExpr.(type) {
+	case *parser.NumberLiteral:
+		n = e.Val
+	case *parser.UnaryExpr:
+		literal, ok := unwrapParens(e.Expr).(*parser.NumberLiteral)
+		if !ok {
+			return 0, false
+		}
+		n = literal.Val
+		if e.Op == parser.SUB {
+			n = -n
+		}
+	default:
+		return 0, false
+	}
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, false
+	}
+	return n, true
+}
+
+func flip(op parser.ItemType) parser.ItemType {
+	switch op {
+	case parser.GTR:
+		return parser.LSS
+	case parser.LSS:
+		return parser.GTR
+	case parser.GTE:
+		return parser.LTE
+	case parser.LTE:
+		return parser.GTE
+	default:
+		return op
+	}
+}
+
+func (c *Converter) prometheusQuery(promQL string) (models.AlertQuery, error) {
+	model, err := json.Marshal(map[string]any{
+		"refId":   queryRefID,
+		"expr":    promQL,
+		"instant": true,
+		"range":   false,
+		"datasource": map[string]string{
+			"type": c.cfg.DatasourceType,
+			"uid":  c.cfg.DatasourceUID,
+		},
+	})
+	if err != nil {
+		return models.AlertQuery{}, err
+	}
+	return models.AlertQuery{
+		RefID:             queryRefID,
+		DatasourceUID:     c.cfg.DatasourceUID,
+		RelativeTimeRange: models.RelativeTimeRange{From: models.Duration(queryTimeRange)},
+		Model:             model,
+	}, nil
+}
+
+func reduceExpression(mode string) (models.AlertQuery, error) {
+	m := map[string]any{
+		"type":       "reduce",
+		"expression": queryRefID,
+		"reducer":    "last",
+	}
+	if mode != "" {
+		m["settings"] = map[string]string{"mode": mode}
+	}
+	return expression(reduceRefID, m)
+}
+
+func thresholdExpression(evaluator string, threshold float64) (models.AlertQuery, error) {
+	return expression(conditionRefID, map[string]any{
+		"type":       "threshold",
+		"expression": reduceRefID,
+		"conditions": []any{
+			map[string]any{
+				"evaluator": map[string]any{
+					"type":   evaluator,
+					"params": []float64{threshold},
+				},
+			},
+		},
+	})
+}
+
+func mathExpression(mathExpr string) (models.AlertQuery, error) {
+	return expression(conditionRefID, map[string]any{
+		"type":       "math",
+		"expression": mathExpr,
+	})
+}
+
+func expression(refID string, m map[string]any) (models.AlertQuery, error) {
+	m["refId"] = refID
+	m["datasource"] = map[string]string{
+		"type": expr.DatasourceType,
+		"uid":  expr.DatasourceUID,
+	}
+	model, err := json.Marshal(m)
+	if err != nil {
+		return models.AlertQuery{}, err
+	}
+	return models.AlertQuery{
+		RefID:         refID,
+		DatasourceUID: expr.DatasourceUID,
+		Model:         model,
+	}, nil
+}
+
+func ruleName(rule apimodels.ApiRuleNode) string {
+	if rule.Alert != "" {
+		return rule.Alert
+	}
+	return rule.Record
+}
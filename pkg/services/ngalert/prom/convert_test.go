@@ -0,0 +1,162 @@
+package prom
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const ruleFile = `
+groups:
+  - name: api
+    interval: 30s
+    limit: 10
+    rules:
+      - alert: HighErrorRate
+        expr: sum by (job) (rate(errors_total[5m])) > 0.5
+        for: 5m
+        keep_firing_for: 10m
+        labels:
+          severity: critical
+          team: "{{ $labels.job }}"
+        annotations:
+          summary: "Error rate of {{ $labels.job }} is {{ $value | humanize }}"
+          runbook: "{{ $externalURL }}/runbook"
+      - record: job:errors:rate5m
+        expr: sum by (job) (rate(errors_total[5m]))
+      - alert: InstanceDown
+        expr: 0 >= (up)
+      - alert: Absent
+        expr: absent(up{job="api"})
+        annotations:
+          description: "{{ query \"up\" }} {{ $externalLabels.cluster }}"
+  - name: broken
+    rules:
+      - alert: Invalid
+        expr: sum(
+`
+
+func TestConverter(t *testing.T) {
+	converter, err := NewConverter(Config{
+		OrgID:           1,
+		NamespaceUID:    "folder",
+		DatasourceUID:   "prom",
+		DefaultInterval: time.Minute,
+	})
+	require.NoError(t, err)
+
+	file, err := ParseRuleFile([]byte(ruleFile))
+	require.NoError(t, err)
+	groups, issues := converter.Convert(file.Groups)
+
+	require.Len(t, groups, 1)
+	group := groups[0]
+	require.Equal(t, "api", group.Name)
+	require.Equal(t, 30*time.Second, group.Interval)
+	require.Len(t, group.Rules, 3)
+
+	t.Run("should split comparison with a number into query and threshold", func(t *testing.T) {
+		rule := group.Rules[0]
+		require.Equal(t, "HighErrorRate", rule.Title)
+		require.Equal(t, int64(1), rule.OrgID)
+		require.Equal(t, "folder", rule.NamespaceUID)
+		require.Equal(t, "api", rule.RuleGroup)
+		require.Equal(t, 1, rule.RuleGroupIndex)
+		require.Equal(t, int64(30), rule.IntervalSeconds)
+		require.Equal(t, 5*time.Minute, rule.For)
+		require.Equal(t, models.OK, rule.NoDataState)
+		require.Equal(t, "C", rule.Condition)
+		require.Len(t, rule.Data, 3)
+		require.Equal(t, "prom", rule.Data[0].DatasourceUID)
+		require.Equal(t, `sum by (job) (rate(errors_total[5m]))`, modelField(t, rule.Data[0], "expr"))
+		require.Equal(t, true, modelField(t, rule.Data[0], "instant"))
+		require.Equal(t, "reduce", modelField(t, rule.Data[1], "type"))
+		require.Equal(t, map[string]any{"mode": "dropNN"}, modelField(t, rule.Data[1], "settings"))
+		require.Equal(t, "threshold", modelField(t, rule.Data[2], "type"))
+		require.Equal(t, []any{map[string]any{"evaluator": map[string]any{"type": "gt", "params": []any{0.5}}}}, modelField(t, rule.Data[2], "conditions"))
+		require.NoError(t, rule.ValidateAlertRule(setting.UnifiedAlertingSettings{BaseInterval: 10 * time.Second}))
+	})
+
+	t.Run("should flip comparison with a number on the left", func(t *testing.T) {
+		rule := group.Rules[1]
+		require.Equal(t, "up", modelField(t, rule.Data[0], "expr"))
+		require.Equal(t, "math", modelField(t, rule.Data[2], "type"))
+		require.Equal(t, "$B <= 0", modelField(t, rule.Data[2], "expression"))
+	})
+
+	t.Run("should fire for every series of other expressions", func(t *testing.T) {
+		rule := group.Rules[2]
+		require.Equal(t, `absent(up{job="api"})`, modelField(t, rule.Data[0], "expr"))
+		require.Nil(t, modelField(t, rule.Data[1], "settings"))
+		require.Equal(t, "is_number($B) || is_nan($B) || is_inf($B)", modelField(t, rule.Data[2], "expression"))
+	})
+
+	t.Run("should convert templates", func(t *testing.T) {
+		rule := group.Rules[0]
+		require.Equal(t, map[string]string{"severity": "critical", "team": "{{ $labels.job }}"}, rule.Labels)
+		require.Equal(t, "Error rate of {{ $labels.job }} is {{ $values.A.Value | humanize }}", rule.Annotations["summary"])
+		require.Equal(t, "{{ externalURL }}/runbook", rule.Annotations["runbook"])
+	})
+
+	t.Run("should report unsupported features", func(t *testing.T) {
+		require.ElementsMatch(t, []Issue{
+			{Group: "api", Message: "limit of alerts is not supported and is ignored"},
+			{Group: "api", Rule: "HighErrorRate", Message: "keep_firing_for is not supported and is ignored"},
+			{Group: "api", Rule: "job:errors:rate5m", Skipped: true, Message: "recording rules are not supported"},
+			{Group: "api", Rule: "Absent", Message: `template of "description" uses external labels, which are not available in Grafana`},
+			{Group: "api", Rule: "Absent", Message: `template of "description" uses the query function, which always returns no result in Grafana`},
+			{Group: "broken", Rule: "Invalid", Skipped: true, Message: issueMessage(issues, "Invalid")},
+			{Group: "broken", Skipped: true, Message: "rule group has no rules that can be converted"},
+		}, issues)
+		require.Contains(t, issueMessage(issues, "Invalid"), "failed to parse expression")
+	})
+}
+
+func TestConverterDuplicates(t *testing.T) {
+	converter, err := NewConverter(Config{DatasourceUID: "prom", DefaultInterval: time.Minute})
+	require.NoError(t, err)
+	rule := apimodels.ApiRuleNode{Alert: "Down", Expr: "up == 0"}
+	groups, issues := converter.Convert([]apimodels.PrometheusRuleGroup{
+		{Name: "a", Interval: model.Duration(time.Minute), Rules: []apimodels.ApiRuleNode{rule}},
+		{Name: "a", Rules: []apimodels.ApiRuleNode{rule}},
+		{Name: "b", Rules: []apimodels.ApiRuleNode{rule, {Alert: "Other", Expr: "up != 1"}}},
+	})
+	require.Len(t, groups, 2)
+	require.Len(t, groups[1].Rules, 1)
+	require.Equal(t, "Other", groups[1].Rules[0].Title)
+	require.Equal(t, 1, groups[1].Rules[0].RuleGroupIndex)
+	require.Len(t, issues, 2)
+	require.Equal(t, Issue{Group: "a", Skipped: true, Message: "rule group with the same name is already converted"}, issues[0])
+	require.Equal(t, "Down", issues[1].Rule)
+	require.True(t, issues[1].Skipped)
+}
+
+func TestNewConverter(t *testing.T) {
+	_, err := NewConverter(Config{DefaultInterval: time.Minute})
+	require.Error(t, err)
+	_, err = NewConverter(Config{DatasourceUID: "prom"})
+	require.Error(t, err)
+}
+
+func modelField(t *testing.T, q models.AlertQuery, field string) any {
+	t.Helper()
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(q.Model, &m))
+	return m[field]
+}
+
+func issueMessage(issues []Issue, rule string) string {
+	for _, issue := range issues {
+		if issue.Rule == rule {
+			return issue.Message
+		}
+	}
+	return ""
+}
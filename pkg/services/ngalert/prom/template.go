@@ -0,0 +1,54 @@
+package prom
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// In Grafana, $value is a string of the values of all queries and expressions of the rule. The value of the
+	// Prometheus expression is the value of the query.
+	valueVariable = regexp.MustCompile(`\$value\b`)
+	valueField    = regexp.MustCompile(`(^|[^\w\])])\.Value\b`)
+
+	externalURL    = regexp.MustCompile(`\$externalURL\b|(^|[^\w\])])\.ExternalURL\b`)
+	externalLabels = regexp.MustCompile(`\$externalLabels\b|\.ExternalLabels\b`)
+	queryFunction  = regexp.MustCompile(`{{-?[^}]*\bquery\b`)
+)
+
+// convertTemplates rewrites the Prometheus templates in the values of labels or annotations so that Grafana expands
+// them the same way. Returns the converted values and the descriptions of the parts of the templates that Grafana does
+// not support.
+func convertTemplates(values map[string]string) (map[string]string, []string) {
+	if values == nil {
+		return nil, nil
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make(map[string]string, len(values))
+	var issues []string
+	for _, k := range keys {
+		v := values[k]
+		if !strings.Contains(v, "{{") {
+			result[k] = v
+			continue
+		}
+		v = valueVariable.ReplaceAllString(v, fmt.Sprintf("$$values.%s.Value", queryRefID))
+		v = valueField.ReplaceAllString(v, fmt.Sprintf("${1}.Values.%s.Value", queryRefID))
+		v = externalURL.ReplaceAllString(v, "${1}externalURL")
+		if externalLabels.MatchString(v) {
+			issues = append(issues, fmt.Sprintf("template of %q uses external labels, which are not available in Grafana", k))
+		}
+		if queryFunction.MatchString(v) {
+			issues = append(issues, fmt.Sprintf("template of %q uses the query function, which always returns no result in Grafana", k))
+		}
+		result[k] = v
+	}
+	return result, issues
+}
@@ -42,3 +42,20 @@ func MakeErrTimeIntervalInvalid(err error) error {
 
 	return ErrTimeIntervalInvalid.Build(data)
 }
+
+var (
+	ErrMaintenanceWindowNotFound = errutil.NotFound("alerting.maintenance-windows.notFound", errutil.WithPublicMessage("Maintenance window not found"))
+	ErrMaintenanceWindowInvalid  = errutil.BadRequest("alerting.maintenance-windows.invalidFormat").MustTemplate("Invalid maintenance window: {{ .Public.Error }}", errutil.WithPublic("Invalid maintenance window: {{ .Public.Error }}"))
+)
+
+// MakeErrMaintenanceWindowInvalid creates an error with the ErrMaintenanceWindowInvalid template
+func MakeErrMaintenanceWindowInvalid(err error) error {
+	data := errutil.TemplateData{
+		Public: map[string]interface{}{
+			"Error": err.Error(),
+		},
+		Error: err,
+	}
+
+	return ErrMaintenanceWindowInvalid.Build(data)
+}
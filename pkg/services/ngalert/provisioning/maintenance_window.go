@@ -0,0 +1,88 @@
+package provisioning
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// MaintenanceWindowStore is the store of maintenance windows.
+type MaintenanceWindowStore interface {
+	ListMaintenanceWindows(ctx context.Context, query *models.ListMaintenanceWindowsQuery) ([]*models.MaintenanceWindow, error)
+	GetMaintenanceWindow(ctx context.Context, orgID int64, uid string) (*models.MaintenanceWindow, error)
+	InsertMaintenanceWindow(ctx context.Context, window models.MaintenanceWindow) (*models.MaintenanceWindow, error)
+	UpdateMaintenanceWindow(ctx context.Context, window models.MaintenanceWindow) (*models.MaintenanceWindow, error)
+	DeleteMaintenanceWindow(ctx context.Context, orgID int64, uid string) error
+}
+
+type MaintenanceWindowService struct {
+	store MaintenanceWindowStore
+	log   log.Logger
+}
+
+func NewMaintenanceWindowService(store MaintenanceWindowStore, log log.Logger) *MaintenanceWindowService {
+	return &MaintenanceWindowService{
+		store: store,
+		log:   log,
+	}
+}
+
+// GetMaintenanceWindows returns all maintenance windows of the org.
+func (svc *MaintenanceWindowService) GetMaintenanceWindows(ctx context.Context, orgID int64) ([]*models.MaintenanceWindow, error) {
+	return svc.store.ListMaintenanceWindows(ctx, &models.ListMaintenanceWindowsQuery{OrgID: orgID})
+}
+
+// GetMaintenanceWindow returns the maintenance window by UID.
+func (svc *MaintenanceWindowService) GetMaintenanceWindow(ctx context.Context, orgID int64, uid string) (*models.MaintenanceWindow, error) {
+	w, err := svc.store.GetMaintenanceWindow(ctx, orgID, uid)
+	return w, svc.translateError(err)
+}
+
+// CreateMaintenanceWindow validates and stores a new maintenance window.
+func (svc *MaintenanceWindowService) CreateMaintenanceWindow(ctx context.Context, window models.MaintenanceWindow) (*models.MaintenanceWindow, error) {
+	if err := window.Validate(); err != nil {
+		return nil, MakeErrMaintenanceWindowInvalid(err)
+	}
+	w, err := svc.store.InsertMaintenanceWindow(ctx, window)
+	if err != nil {
+		return nil, svc.translateError(err)
+	}
+	svc.log.Info("Created maintenance window", "org", w.OrgID, "uid", w.UID, "title", w.Title)
+	return w, nil
+}
+
+// UpdateMaintenanceWindow validates and replaces an existing maintenance window.
+func (svc *MaintenanceWindowService) UpdateMaintenanceWindow(ctx context.Context, window models.MaintenanceWindow) (*models.MaintenanceWindow, error) {
+	if err := window.Validate(); err != nil {
+		return nil, MakeErrMaintenanceWindowInvalid(err)
+	}
+	w, err := svc.store.UpdateMaintenanceWindow(ctx, window)
+	if err != nil {
+		return nil, svc.translateError(err)
+	}
+	svc.log.Info("Updated maintenance window", "org", w.OrgID, "uid", w.UID, "title", w.Title)
+	return w, nil
+}
+
+// DeleteMaintenanceWindow deletes the maintenance window by UID.
+func (svc *MaintenanceWindowService) DeleteMaintenanceWindow(ctx context.Context, orgID int64, uid string) error {
+	if err := svc.store.DeleteMaintenanceWindow(ctx, orgID, uid); err != nil {
+		return svc.translateError(err)
+	}
+	svc.log.Info("Deleted maintenance window", "org", orgID, "uid", uid)
+	return nil
+}
+
+func (svc *MaintenanceWindowService) translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, models.ErrMaintenanceWindowNotFound):
+		return ErrMaintenanceWindowNotFound.Errorf("")
+	case errors.Is(err, models.ErrMaintenanceWindowFailedValidation):
+		return MakeErrMaintenanceWindowInvalid(err)
+	}
+	return err
+}
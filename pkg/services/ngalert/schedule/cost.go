@@ -0,0 +1,206 @@
+package schedule
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// ruleCostWindow is the number of the latest evaluations and missed ticks that the cost of a rule is computed from.
+	ruleCostWindow = 20
+	// ruleBackoffMinEvaluations is the number of evaluations after a change of the backoff of a rule before it can be changed again.
+	ruleBackoffMinEvaluations = 5
+)
+
+// costSample is a single evaluation or missed tick of a rule.
+type costSample struct {
+	duration   time.Duration
+	queryBytes int64
+	series     int
+	failed     bool
+	missed     bool
+}
+
+type ruleCostStats struct {
+	title        string
+	namespaceUID string
+	ruleGroup    string
+
+	samples [ruleCostWindow]costSample
+	next    int
+	count   int
+
+	lastEvaluation time.Time
+	// backoff is the exponent of the backoff factor of the rule.
+	backoff int
+	// sinceBackoffChange is the number of evaluations since the backoff was last changed.
+	sinceBackoffChange int
+}
+
+func (s *ruleCostStats) add(sample costSample) {
+	s.samples[s.next] = sample
+	s.next = (s.next + 1) % ruleCostWindow
+	if s.count < ruleCostWindow {
+		s.count++
+	}
+}
+
+func (s *ruleCostStats) cost(key ngmodels.AlertRuleKey) ngmodels.AlertRuleCost {
+	result := ngmodels.AlertRuleCost{
+		AlertRuleKey:   key,
+		Title:          s.title,
+		NamespaceUID:   s.namespaceUID,
+		RuleGroup:      s.ruleGroup,
+		LastEvaluation: s.lastEvaluation,
+		BackoffFactor:  1 << s.backoff,
+	}
+	var totalDuration time.Duration
+	var totalBytes int64
+	var totalSeries int
+	for _, sample := range s.samples[:s.count] {
+		if sample.missed {
+			result.MissedTicks++
+			continue
+		}
+		result.Evaluations++
+		if sample.failed {
+			result.Failures++
+		}
+		totalDuration += sample.duration
+		totalBytes += sample.queryBytes
+		totalSeries += sample.series
+		if sample.duration > result.MaxDuration {
+			result.MaxDuration = sample.duration
+		}
+	}
+	if result.Evaluations > 0 {
+		result.AvgDuration = totalDuration / time.Duration(result.Evaluations)
+		result.AvgQueryBytes = totalBytes / int64(result.Evaluations)
+		result.AvgSeries = totalSeries / result.Evaluations
+	}
+	return result
+}
+
+// ruleCostTracker tracks the cost of the evaluation of alert rules. If the evaluation budget is enabled, it backs off
+// rules whose average cost exceeds the budget by doubling their evaluation interval, and restores it when the cost
+// drops below half of the budget.
+type ruleCostTracker struct {
+	mtx    sync.Mutex
+	rules  map[ngmodels.AlertRuleKey]*ruleCostStats
+	budget setting.UnifiedAlertingEvaluationBudgetSettings
+	log    log.Logger
+}
+
+func newRuleCostTracker(budget setting.UnifiedAlertingEvaluationBudgetSettings, logger log.Logger) *ruleCostTracker {
+	return &ruleCostTracker{
+		rules:  make(map[ngmodels.AlertRuleKey]*ruleCostStats),
+		budget: budget,
+		log:    logger,
+	}
+}
+
+func (t *ruleCostTracker) getOrCreate(rule *ngmodels.AlertRule) *ruleCostStats {
+	key := rule.GetKey()
+	stats, ok := t.rules[key]
+	if !ok {
+		stats = &ruleCostStats{}
+		t.rules[key] = stats
+	}
+	stats.title = rule.Title
+	stats.namespaceUID = rule.NamespaceUID
+	stats.ruleGroup = rule.RuleGroup
+	return stats
+}
+
+// observeEvaluation records an evaluation of the rule and updates its backoff.
+func (t *ruleCostTracker) observeEvaluation(rule *ngmodels.AlertRule, at time.Time, sample costSample) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	stats := t.getOrCreate(rule)
+	stats.add(sample)
+	stats.lastEvaluation = at
+	stats.sinceBackoffChange++
+	if !t.budget.Enabled || stats.sinceBackoffChange < ruleBackoffMinEvaluations {
+		return
+	}
+
+	cost := stats.cost(rule.GetKey())
+	switch {
+	case t.exceedsBudget(cost) && 1<<(stats.backoff+1) <= t.budget.MaxBackoffFactor:
+		stats.backoff++
+	case stats.backoff > 0 && t.belowHalfBudget(cost):
+		stats.backoff--
+	default:
+		return
+	}
+	stats.sinceBackoffChange = 0
+	t.log.Warn("Changed evaluation backoff of the rule because of its evaluation cost", append(rule.GetKey().LogContext(),
+		"backoffFactor", 1<<stats.backoff, "avgDuration", cost.AvgDuration, "avgQueryBytes", cost.AvgQueryBytes)...)
+}
+
+func (t *ruleCostTracker) exceedsBudget(cost ngmodels.AlertRuleCost) bool {
+	return cost.AvgDuration > t.budget.MaxDuration || (t.budget.MaxQueryBytes > 0 && cost.AvgQueryBytes > t.budget.MaxQueryBytes)
+}
+
+func (t *ruleCostTracker) belowHalfBudget(cost ngmodels.AlertRuleCost) bool {
+	return cost.AvgDuration < t.budget.MaxDuration/2 && (t.budget.MaxQueryBytes == 0 || cost.AvgQueryBytes < t.budget.MaxQueryBytes/2)
+}
+
+// observeMissedTick records a tick of the rule that was missed because the previous evaluation was still running.
+func (t *ruleCostTracker) observeMissedTick(rule *ngmodels.AlertRule) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.getOrCreate(rule).add(costSample{missed: true})
+}
+
+// backoffFactor returns the factor by which the evaluation interval of the rule is increased.
+func (t *ruleCostTracker) backoffFactor(key ngmodels.AlertRuleKey) int64 {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if stats, ok := t.rules[key]; ok {
+		return 1 << stats.backoff
+	}
+	return 1
+}
+
+// backedOff returns the number of rules that are evaluated less frequently than configured.
+func (t *ruleCostTracker) backedOff() int {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	n := 0
+	for _, stats := range t.rules {
+		if stats.backoff > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (t *ruleCostTracker) remove(keys ...ngmodels.AlertRuleKey) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	for _, key := range keys {
+		delete(t.rules, key)
+	}
+}
+
+// costs returns the costs of the rules of the organization sorted by the rule UID.
+func (t *ruleCostTracker) costs(orgID int64) []ngmodels.AlertRuleCost {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	result := make([]ngmodels.AlertRuleCost, 0)
+	for key, stats := range t.rules {
+		if key.OrgID == orgID {
+			result = append(result, stats.cost(key))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UID < result[j].UID
+	})
+	return result
+}
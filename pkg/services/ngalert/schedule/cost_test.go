@@ -0,0 +1,121 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestRuleCostTracker(t *testing.T) {
+	now := time.Now()
+	budget := setting.UnifiedAlertingEvaluationBudgetSettings{
+		Enabled:          true,
+		MaxDuration:      10 * time.Second,
+		MaxQueryBytes:    1000,
+		MaxBackoffFactor: 4,
+	}
+
+	t.Run("should aggregate evaluations and missed ticks", func(t *testing.T) {
+		tracker := newRuleCostTracker(setting.UnifiedAlertingEvaluationBudgetSettings{}, log.NewNopLogger())
+		rule := ngmodels.AlertRuleGen()()
+		tracker.observeEvaluation(rule, now, costSample{duration: time.Second, queryBytes: 100, series: 1})
+		tracker.observeEvaluation(rule, now.Add(time.Minute), costSample{duration: 3 * time.Second, queryBytes: 300, series: 3, failed: true})
+		tracker.observeMissedTick(rule)
+
+		costs := tracker.costs(rule.OrgID)
+		require.Len(t, costs, 1)
+		cost := costs[0]
+		require.Equal(t, rule.GetKey(), cost.AlertRuleKey)
+		require.Equal(t, rule.Title, cost.Title)
+		require.Equal(t, 2, cost.Evaluations)
+		require.Equal(t, 1, cost.Failures)
+		require.Equal(t, 1, cost.MissedTicks)
+		require.Equal(t, 2*time.Second, cost.AvgDuration)
+		require.Equal(t, 3*time.Second, cost.MaxDuration)
+		require.Equal(t, int64(200), cost.AvgQueryBytes)
+		require.Equal(t, 2, cost.AvgSeries)
+		require.Equal(t, now.Add(time.Minute), cost.LastEvaluation)
+		require.Equal(t, 1, cost.BackoffFactor)
+	})
+
+	t.Run("should keep only the latest samples", func(t *testing.T) {
+		tracker := newRuleCostTracker(setting.UnifiedAlertingEvaluationBudgetSettings{}, log.NewNopLogger())
+		rule := ngmodels.AlertRuleGen()()
+		for i := 0; i < ruleCostWindow; i++ {
+			tracker.observeEvaluation(rule, now, costSample{duration: time.Minute})
+		}
+		for i := 0; i < ruleCostWindow; i++ {
+			tracker.observeEvaluation(rule, now, costSample{duration: time.Second})
+		}
+		cost := tracker.costs(rule.OrgID)[0]
+		require.Equal(t, ruleCostWindow, cost.Evaluations)
+		require.Equal(t, time.Second, cost.MaxDuration)
+	})
+
+	t.Run("should back off rules that exceed the budget", func(t *testing.T) {
+		tracker := newRuleCostTracker(budget, log.NewNopLogger())
+		rule := ngmodels.AlertRuleGen()()
+		expensive := costSample{duration: time.Second, queryBytes: 2000}
+		for i := 0; i < ruleBackoffMinEvaluations-1; i++ {
+			tracker.observeEvaluation(rule, now, expensive)
+		}
+		require.Equal(t, int64(1), tracker.backoffFactor(rule.GetKey()))
+
+		tracker.observeEvaluation(rule, now, expensive)
+		require.Equal(t, int64(2), tracker.backoffFactor(rule.GetKey()))
+		require.Equal(t, 1, tracker.backedOff())
+
+		for i := 0; i < 10*ruleBackoffMinEvaluations; i++ {
+			tracker.observeEvaluation(rule, now, expensive)
+		}
+		require.Equal(t, int64(budget.MaxBackoffFactor), tracker.backoffFactor(rule.GetKey()))
+	})
+
+	t.Run("should restore rules that drop below half of the budget", func(t *testing.T) {
+		tracker := newRuleCostTracker(budget, log.NewNopLogger())
+		rule := ngmodels.AlertRuleGen()()
+		for i := 0; i < ruleBackoffMinEvaluations; i++ {
+			tracker.observeEvaluation(rule, now, costSample{duration: time.Minute})
+		}
+		require.Equal(t, int64(2), tracker.backoffFactor(rule.GetKey()))
+
+		for i := 0; i < 2*ruleCostWindow; i++ {
+			tracker.observeEvaluation(rule, now, costSample{duration: time.Second})
+		}
+		require.Equal(t, int64(1), tracker.backoffFactor(rule.GetKey()))
+		require.Zero(t, tracker.backedOff())
+	})
+
+	t.Run("should not back off rules if budget is disabled", func(t *testing.T) {
+		disabled := budget
+		disabled.Enabled = false
+		tracker := newRuleCostTracker(disabled, log.NewNopLogger())
+		rule := ngmodels.AlertRuleGen()()
+		for i := 0; i < ruleCostWindow; i++ {
+			tracker.observeEvaluation(rule, now, costSample{duration: time.Minute})
+		}
+		require.Equal(t, int64(1), tracker.backoffFactor(rule.GetKey()))
+	})
+
+	t.Run("should return costs of the organization and forget removed rules", func(t *testing.T) {
+		tracker := newRuleCostTracker(budget, log.NewNopLogger())
+		rule1 := ngmodels.AlertRuleGen(ngmodels.WithOrgID(1))()
+		rule2 := ngmodels.AlertRuleGen(ngmodels.WithOrgID(1))()
+		other := ngmodels.AlertRuleGen(ngmodels.WithOrgID(2))()
+		for _, rule := range []*ngmodels.AlertRule{rule1, rule2, other} {
+			tracker.observeEvaluation(rule, now, costSample{duration: time.Second})
+		}
+		require.Len(t, tracker.costs(1), 2)
+		require.Len(t, tracker.costs(2), 1)
+
+		tracker.remove(rule1.GetKey())
+		costs := tracker.costs(1)
+		require.Len(t, costs, 1)
+		require.Equal(t, rule2.UID, costs[0].UID)
+	})
+}
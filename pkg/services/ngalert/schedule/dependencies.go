@@ -0,0 +1,90 @@
+package schedule
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// orderByDependencies makes evaluations of rules that depend on other rules scheduled on the same tick wait for the evaluations of those rules.
+// It returns the items that do not wait for any other item, which should be dispatched right away. Every other item is passed to dispatch
+// once all evaluations it waits for are done. Items that belong to a dependency cycle or depend on one do not wait for anything.
+func orderByDependencies(items []readyToRunItem, dispatch func(readyToRunItem), logger log.Logger) []readyToRunItem {
+	index := make(map[ngmodels.AlertRuleKey]int, len(items))
+	for i, item := range items {
+		index[item.rule.GetKey()] = i
+	}
+
+	dependents := make([][]int, len(items))
+	pending := make([]int32, len(items))
+	hasDependencies := false
+	for i, item := range items {
+		for _, dep := range item.rule.Dependencies {
+			j, ok := index[ngmodels.AlertRuleKey{OrgID: item.rule.OrgID, UID: dep.RuleUID}]
+			if !ok || j == i {
+				continue
+			}
+			dependents[j] = append(dependents[j], i)
+			pending[i]++
+			hasDependencies = true
+		}
+	}
+	if !hasDependencies {
+		return items
+	}
+
+	// Kahn's algorithm. Items that are not reached belong to a cycle or depend on an item in a cycle.
+	remaining := make([]int32, len(items))
+	copy(remaining, pending)
+	queue := make([]int, 0, len(items))
+	for i := range items {
+		if remaining[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+	reached := make([]bool, len(items))
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		reached[i] = true
+		for _, j := range dependents[i] {
+			remaining[j]--
+			if remaining[j] == 0 {
+				queue = append(queue, j)
+			}
+		}
+	}
+	for i, item := range items {
+		if reached[i] {
+			continue
+		}
+		logger.Warn("Rule depends on a dependency cycle and will be evaluated without waiting for its dependencies", item.rule.GetKey().LogContext()...)
+		pending[i] = 0
+	}
+
+	roots := make([]readyToRunItem, 0, len(items))
+	for i := range items {
+		i := i
+		var once sync.Once
+		items[i].afterEval = func() {
+			once.Do(func() {
+				for _, j := range dependents[i] {
+					if !reached[j] {
+						continue
+					}
+					if atomic.AddInt32(&pending[j], -1) == 0 {
+						dispatch(items[j])
+					}
+				}
+			})
+		}
+	}
+	for i := range items {
+		if pending[i] == 0 {
+			roots = append(roots, items[i])
+		}
+	}
+	return roots
+}
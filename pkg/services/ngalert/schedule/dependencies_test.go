@@ -0,0 +1,92 @@
+package schedule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestOrderByDependencies(t *testing.T) {
+	gen := models.AlertRuleGen(models.WithOrgID(1))
+
+	newItems := func(rules ...*models.AlertRule) []readyToRunItem {
+		items := make([]readyToRunItem, 0, len(rules))
+		for _, rule := range rules {
+			items = append(items, readyToRunItem{evaluation: evaluation{rule: rule}})
+		}
+		return items
+	}
+	dependOn := func(rule *models.AlertRule, deps ...*models.AlertRule) {
+		for _, dep := range deps {
+			rule.Dependencies = append(rule.Dependencies, models.AlertRuleDependency{RuleUID: dep.UID})
+		}
+	}
+	uids := func(items []readyToRunItem) []string {
+		result := make([]string, 0, len(items))
+		for _, item := range items {
+			result = append(result, item.rule.UID)
+		}
+		return result
+	}
+
+	t.Run("should return all items if there are no dependencies", func(t *testing.T) {
+		items := newItems(gen(), gen(), gen())
+		roots := orderByDependencies(items, func(readyToRunItem) {
+			t.Fatal("unexpected dispatch")
+		}, log.NewNopLogger())
+		require.Equal(t, uids(items), uids(roots))
+	})
+
+	t.Run("should dispatch dependent rules after all their dependencies are evaluated", func(t *testing.T) {
+		r1, r2, r3 := gen(), gen(), gen()
+		dependOn(r2, r1)
+		dependOn(r3, r1, r2)
+		items := newItems(r3, r2, r1)
+
+		var dispatched []readyToRunItem
+		roots := orderByDependencies(items, func(item readyToRunItem) {
+			dispatched = append(dispatched, item)
+		}, log.NewNopLogger())
+		require.Equal(t, []string{r1.UID}, uids(roots))
+
+		roots[0].done()
+		require.Equal(t, []string{r2.UID}, uids(dispatched))
+
+		// calling done more than once does not release dependent rules twice
+		roots[0].done()
+		require.Len(t, dispatched, 1)
+
+		dispatched[0].done()
+		require.Equal(t, []string{r2.UID, r3.UID}, uids(dispatched))
+	})
+
+	t.Run("should ignore dependencies that are not scheduled on the tick", func(t *testing.T) {
+		r1, r2 := gen(), gen()
+		dependOn(r2, r1)
+		items := newItems(r2)
+		roots := orderByDependencies(items, func(readyToRunItem) {
+			t.Fatal("unexpected dispatch")
+		}, log.NewNopLogger())
+		require.Equal(t, []string{r2.UID}, uids(roots))
+	})
+
+	t.Run("should not wait for rules in a dependency cycle", func(t *testing.T) {
+		r1, r2, r3, r4 := gen(), gen(), gen(), gen()
+		dependOn(r1, r2)
+		dependOn(r2, r1)
+		dependOn(r3, r2)
+		dependOn(r4, gen())
+		items := newItems(r1, r2, r3, r4)
+
+		roots := orderByDependencies(items, func(readyToRunItem) {
+			t.Fatal("unexpected dispatch")
+		}, log.NewNopLogger())
+		require.ElementsMatch(t, []string{r1.UID, r2.UID, r3.UID, r4.UID}, uids(roots))
+		for _, root := range roots {
+			root.done()
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package schedule
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// MaintenanceWindowStore provides the windows of planned maintenance during which alert rules are not evaluated.
+type MaintenanceWindowStore interface {
+	ListMaintenanceWindows(ctx context.Context, query *ngmodels.ListMaintenanceWindowsQuery) ([]*ngmodels.MaintenanceWindow, error)
+}
+
+// maintenanceWindowsRefreshInterval is how often the maintenance windows are fetched from the store. Changes to the
+// windows take effect on all instances within this interval, while windows that start or end are applied at the tick.
+const maintenanceWindowsRefreshInterval = time.Minute
+
+// maintenanceWindowCache caches the maintenance windows of all organizations, so the store is not queried on every tick.
+type maintenanceWindowCache struct {
+	store           MaintenanceWindowStore
+	refreshInterval time.Duration
+
+	mtx       sync.Mutex
+	windows   []*ngmodels.MaintenanceWindow
+	fetchedAt time.Time
+}
+
+func newMaintenanceWindowCache(store MaintenanceWindowStore, refreshInterval time.Duration) *maintenanceWindowCache {
+	return &maintenanceWindowCache{
+		store:           store,
+		refreshInterval: refreshInterval,
+	}
+}
+
+// get returns the cached maintenance windows, fetching them from the store if they are older than the refresh interval.
+// If the windows cannot be fetched, the previously fetched windows are returned and fetching is retried on the next call.
+func (c *maintenanceWindowCache) get(ctx context.Context, now time.Time) ([]*ngmodels.MaintenanceWindow, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if !c.fetchedAt.IsZero() && now.Sub(c.fetchedAt) < c.refreshInterval {
+		return c.windows, nil
+	}
+	windows, err := c.store.ListMaintenanceWindows(ctx, &ngmodels.ListMaintenanceWindowsQuery{})
+	if err != nil {
+		return c.windows, err
+	}
+	c.windows = windows
+	c.fetchedAt = now
+	return windows, nil
+}
+
+// activeMaintenanceWindows returns maintenance windows of all organizations that are active at the tick.
+// If the windows cannot be fetched, rules are evaluated according to the last windows that were fetched.
+func (sch *schedule) activeMaintenanceWindows(ctx context.Context, tick time.Time) []*ngmodels.MaintenanceWindow {
+	if sch.maintenanceWindows == nil {
+		return nil
+	}
+	windows, err := sch.maintenanceWindows.get(ctx, tick)
+	if err != nil {
+		sch.log.Error("Failed to fetch maintenance windows", "error", err)
+	}
+	active := make([]*ngmodels.MaintenanceWindow, 0, len(windows))
+	for _, w := range windows {
+		if w.IsActive(tick) {
+			active = append(active, w)
+		}
+	}
+	return active
+}
+
+// inMaintenance returns true if the rule is in the scope of any of the windows.
+func inMaintenance(windows []*ngmodels.MaintenanceWindow, rule *ngmodels.AlertRule) bool {
+	for _, w := range windows {
+		if w.Covers(rule) {
+			return true
+		}
+	}
+	return false
+}
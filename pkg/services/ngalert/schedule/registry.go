@@ -16,7 +16,11 @@ import (
 	"github.com/grafana/grafana/pkg/util"
 )
 
-var errRuleDeleted = errors.New("rule deleted")
+var (
+	errRuleDeleted = errors.New("rule deleted")
+	// errRuleUnassigned is the reason the evaluation of a rule is stopped when the rule is assigned to another instance of the cluster.
+	errRuleUnassigned = errors.New("rule assigned to another instance")
+)
 
 type alertRuleInfoRegistry struct {
 	mu            sync.Mutex
@@ -130,6 +134,17 @@ type evaluation struct {
 	scheduledAt time.Time
 	rule        *models.AlertRule
 	folderTitle string
+	// inMaintenance is true if the rule is in an active maintenance window and must not be evaluated.
+	inMaintenance bool
+	// afterEval is called when the evaluation is complete or will not happen. Can be nil.
+	afterEval func()
+}
+
+// done notifies the scheduler that the evaluation is complete or will not happen.
+func (e *evaluation) done() {
+	if e.afterEval != nil {
+		e.afterEval()
+	}
 }
 
 type alertRulesRegistry struct {
@@ -336,6 +351,15 @@ func (r ruleWithFolder) Fingerprint() fingerprint {
 		writeInt(0)
 	}
 
+	for _, dep := range rule.Dependencies {
+		writeString(dep.RuleUID)
+		if dep.InhibitWhenFiring {
+			writeInt(1)
+		} else {
+			writeInt(0)
+		}
+	}
+
 	// fields that do not affect the state.
 	// TODO consider removing fields below from the fingerprint
 	writeInt(rule.ID)
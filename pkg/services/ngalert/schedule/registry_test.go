@@ -415,6 +415,9 @@ func TestRuleWithFolderFingerprint(t *testing.T) {
 				"key-label": "value-label",
 			},
 			IsPaused: false,
+			Dependencies: []models.AlertRuleDependency{
+				{RuleUID: "test-dependency"},
+			},
 		}
 		r2 := &models.AlertRule{
 			ID:        2,
@@ -450,11 +453,15 @@ func TestRuleWithFolderFingerprint(t *testing.T) {
 				"key-label": "value-label23",
 			},
 			IsPaused: true,
+			Dependencies: []models.AlertRuleDependency{
+				{RuleUID: "test-dependency-2", InhibitWhenFiring: true},
+			},
 		}
 
 		excludedFields := map[string]struct{}{
-			"Version": {},
-			"Updated": {},
+			"Version":   {},
+			"Updated":   {},
+			"UpdatedBy": {},
 		}
 
 		tp := reflect.TypeOf(rule).Elem()
@@ -23,6 +23,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/ticker"
 )
 
@@ -32,6 +33,8 @@ type ScheduleService interface {
 	// Run the scheduler until the context is canceled or the scheduler returns
 	// an error. The scheduler is terminated when this function returns.
 	Run(context.Context) error
+	// RuleCosts returns the cost of the evaluation of the alert rules of the organization that are evaluated by this instance.
+	RuleCosts(orgID int64) []ngmodels.AlertRuleCost
 }
 
 // retryDelay represents how long to wait between each failed rule evaluation.
@@ -94,6 +97,16 @@ type schedule struct {
 	// last evaluated.
 	schedulableAlertRules alertRulesRegistry
 
+	// sharder distributes alert rules across the instances of a high-availability cluster.
+	// It is nil if every instance evaluates all alert rules.
+	sharder *ruleSharder
+
+	// maintenanceWindows caches the windows of planned maintenance. It is nil if maintenance windows are not supported.
+	maintenanceWindows *maintenanceWindowCache
+
+	// costs tracks the cost of the evaluation of alert rules and backs off rules that exceed the evaluation budget.
+	costs *ruleCostTracker
+
 	tracer tracing.Tracer
 }
 
@@ -110,8 +123,16 @@ type SchedulerCfg struct {
 	RuleStore            RulesStore
 	Metrics              *metrics.Scheduler
 	AlertSender          AlertsSender
-	Tracer               tracing.Tracer
-	Log                  log.Logger
+	// ClusterMembership enables sharding of the evaluation of alert rules across the members of the cluster.
+	// If it is nil, all alert rules are evaluated by this instance.
+	ClusterMembership ClusterMembership
+	// MaintenanceWindows provides the windows of planned maintenance during which alert rules are not evaluated.
+	// If it is nil, alert rules are always evaluated.
+	MaintenanceWindows MaintenanceWindowStore
+	// EvaluationBudget configures the backoff of alert rules whose evaluation is too expensive.
+	EvaluationBudget setting.UnifiedAlertingEvaluationBudgetSettings
+	Tracer           tracing.Tracer
+	Log              log.Logger
 }
 
 // NewScheduler returns a new schedule.
@@ -138,9 +159,18 @@ func NewScheduler(cfg SchedulerCfg, stateManager *state.Manager) *schedule {
 		minRuleInterval:       cfg.MinRuleInterval,
 		schedulableAlertRules: alertRulesRegistry{rules: make(map[ngmodels.AlertRuleKey]*ngmodels.AlertRule)},
 		alertsSender:          cfg.AlertSender,
+		costs:                 newRuleCostTracker(cfg.EvaluationBudget, cfg.Log),
 		tracer:                cfg.Tracer,
 	}
 
+	if cfg.MaintenanceWindows != nil {
+		sch.maintenanceWindows = newMaintenanceWindowCache(cfg.MaintenanceWindows, maintenanceWindowsRefreshInterval)
+	}
+
+	if cfg.ClusterMembership != nil {
+		sch.sharder = newRuleSharder(cfg.ClusterMembership, cfg.Log, cfg.Metrics)
+	}
+
 	return &sch
 }
 
@@ -173,11 +203,31 @@ func (sch *schedule) deleteAlertRule(keys ...ngmodels.AlertRuleKey) {
 		// stop rule evaluation
 		ruleInfo.stop(errRuleDeleted)
 	}
+	sch.costs.remove(keys...)
 	// Our best bet at this point is that we update the metrics with what we hope to schedule in the next tick.
 	alertRules, _ := sch.schedulableAlertRules.all()
 	sch.updateRulesMetrics(alertRules)
 }
 
+// unassignAlertRule stops evaluation of the rules that are assigned to another instance of the cluster.
+// Unlike deleteAlertRule, the rules remain schedulable and their state is kept in the database for the new owner.
+func (sch *schedule) unassignAlertRule(keys ...ngmodels.AlertRuleKey) {
+	for _, key := range keys {
+		ruleInfo, ok := sch.registry.del(key)
+		if !ok {
+			continue
+		}
+		sch.log.Info("Stopping evaluation of the rule because it is assigned to another instance", key.LogContext()...)
+		ruleInfo.stop(errRuleUnassigned)
+	}
+	sch.costs.remove(keys...)
+}
+
+// RuleCosts returns the cost of the evaluation of the alert rules of the organization that are evaluated by this instance.
+func (sch *schedule) RuleCosts(orgID int64) []ngmodels.AlertRuleCost {
+	return sch.costs.costs(orgID)
+}
+
 func (sch *schedule) schedulePeriodic(ctx context.Context, t *ticker.T) error {
 	dispatcherGroup, ctx := errgroup.WithContext(ctx)
 	for {
@@ -267,11 +317,26 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 
 	sch.updateRulesMetrics(alertRules)
 
+	isOwned := func(ngmodels.AlertRuleGroupKey) bool { return true }
+	if sch.sharder != nil {
+		isOwned = sch.sharder.owner()
+	}
+	unassigned := make(map[ngmodels.AlertRuleKey]struct{})
+
 	readyToRun := make([]readyToRunItem, 0)
 	updatedRules := make([]ngmodels.AlertRuleKeyWithVersion, 0, len(updated)) // this is needed for tests only
 	missingFolder := make(map[string][]string)
 	for _, item := range alertRules {
 		key := item.GetKey()
+		if !isOwned(item.GetGroupKey()) {
+			// The rule is evaluated by another instance of the cluster. Its state is no longer updated by this
+			// instance, so drop it from the cache. The new owner loads the state from the database.
+			if n := sch.stateManager.ForgetRule(key); n > 0 {
+				sch.log.Debug("Removed state of the rule that is assigned to another instance", append(key.LogContext(), "states", n)...)
+			}
+			unassigned[key] = struct{}{}
+			continue
+		}
 		ruleInfo, newRoutine := sch.registry.getOrCreateInfo(ctx, key)
 
 		// enforce minimum evaluation interval
@@ -298,6 +363,11 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 		itemFrequency := item.IntervalSeconds / int64(sch.baseInterval.Seconds())
 		offset := jitterOffsetInTicks(item, sch.baseInterval, sch.jitterEvaluations)
 		isReadyToRun := item.IntervalSeconds != 0 && (tickNum%itemFrequency)-offset == 0
+		// rules that exceed the evaluation budget are evaluated only on every backoffFactor-th tick they are ready to run.
+		if backoffFactor := sch.costs.backoffFactor(key); isReadyToRun && backoffFactor > 1 && ((tickNum-offset)/itemFrequency)%backoffFactor != 0 {
+			sch.log.Debug("Skip rule evaluation because it exceeds the evaluation budget", append(key.LogContext(), "tick", tickNum, "backoffFactor", backoffFactor)...)
+			isReadyToRun = false
+		}
 
 		var folderTitle string
 		if !sch.disableGrafanaFolder {
@@ -340,34 +410,65 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 		sch.log.Warn("Unable to obtain folder titles for some rules", "missingFolderUIDToRuleUID", missingFolder)
 	}
 
-	var step int64 = 0
 	if len(readyToRun) > 0 {
-		step = sch.baseInterval.Nanoseconds() / int64(len(readyToRun))
+		windows := sch.activeMaintenanceWindows(ctx, tick)
+		for i := range readyToRun {
+			readyToRun[i].inMaintenance = inMaintenance(windows, readyToRun[i].rule)
+		}
 	}
 
-	for i := range readyToRun {
-		item := readyToRun[i]
+	dispatch := func(item readyToRunItem) {
+		key := item.rule.GetKey()
+		success, dropped := item.ruleInfo.eval(&item.evaluation)
+		if !success {
+			sch.log.Debug("Scheduled evaluation was canceled because evaluation routine was stopped", append(key.LogContext(), "time", tick)...)
+			item.evaluation.done()
+			return
+		}
+		if dropped != nil {
+			sch.log.Warn("Tick dropped because alert rule evaluation is too slow", append(key.LogContext(), "time", tick)...)
+			orgID := fmt.Sprint(key.OrgID)
+			sch.metrics.EvaluationMissed.WithLabelValues(orgID, item.rule.Title).Inc()
+			sch.costs.observeMissedTick(item.rule)
+			dropped.done()
+		}
+	}
+
+	// rules that depend on other rules of the same tick are dispatched when the evaluation of those rules is done.
+	toDispatch := orderByDependencies(readyToRun, func(item readyToRunItem) {
+		go dispatch(item)
+	}, sch.log)
+
+	var step int64 = 0
+	if len(toDispatch) > 0 {
+		step = sch.baseInterval.Nanoseconds() / int64(len(toDispatch))
+	}
+
+	for i := range toDispatch {
+		item := toDispatch[i]
 
 		time.AfterFunc(time.Duration(int64(i)*step), func() {
-			key := item.rule.GetKey()
-			success, dropped := item.ruleInfo.eval(&item.evaluation)
-			if !success {
-				sch.log.Debug("Scheduled evaluation was canceled because evaluation routine was stopped", append(key.LogContext(), "time", tick)...)
-				return
-			}
-			if dropped != nil {
-				sch.log.Warn("Tick dropped because alert rule evaluation is too slow", append(key.LogContext(), "time", tick)...)
-				orgID := fmt.Sprint(key.OrgID)
-				sch.metrics.EvaluationMissed.WithLabelValues(orgID, item.rule.Title).Inc()
-			}
+			dispatch(item)
 		})
 	}
 
-	// unregister and stop routines of the deleted alert rules
+	sch.metrics.BackedOffAlertRules.Set(float64(sch.costs.backedOff()))
+
+	if sch.sharder != nil {
+		sch.metrics.OwnedAlertRules.Set(float64(len(alertRules) - len(unassigned)))
+	}
+
+	// unregister and stop routines of the deleted alert rules and of the rules that are assigned to another instance
 	toDelete := make([]ngmodels.AlertRuleKey, 0, len(registeredDefinitions))
+	toUnassign := make([]ngmodels.AlertRuleKey, 0)
 	for key := range registeredDefinitions {
+		if _, ok := unassigned[key]; ok {
+			toUnassign = append(toUnassign, key)
+			continue
+		}
 		toDelete = append(toDelete, key)
 	}
+	sch.unassignAlertRule(toUnassign...)
 	sch.deleteAlertRule(toDelete...)
 	return readyToRun, registeredDefinitions, updatedRules
 }
@@ -402,7 +503,7 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 		notify(states)
 	}
 
-	evaluate := func(ctx context.Context, f fingerprint, attempt int64, e *evaluation, span trace.Span, retry bool) error {
+	evaluate := func(ctx context.Context, f fingerprint, attempt int64, e *evaluation, span trace.Span, retry bool, sample *costSample) error {
 		logger := logger.New("version", e.rule.Version, "fingerprint", f, "attempt", attempt, "now", e.scheduledAt).FromContext(ctx)
 		start := sch.clock.Now()
 
@@ -413,11 +514,12 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 		ruleEval, err := sch.evaluatorFactory.Create(evalCtx, e.rule.GetEvalCondition())
 		var results eval.Results
 		var dur time.Duration
+		var evalStats eval.EvaluationStats
 		if err != nil {
 			dur = sch.clock.Now().Sub(start)
 			logger.Error("Failed to build rule evaluator", "error", err)
 		} else {
-			results, err = ruleEval.Evaluate(ctx, e.scheduledAt)
+			results, err = ruleEval.Evaluate(eval.WithEvaluationStats(ctx, &evalStats), e.scheduledAt)
 			dur = sch.clock.Now().Sub(start)
 			if err != nil {
 				logger.Error("Failed to evaluate rule", "error", err, "duration", dur)
@@ -426,6 +528,10 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 
 		evalTotal.Inc()
 		evalDuration.Observe(dur.Seconds())
+		sample.duration += dur
+		sample.queryBytes += evalStats.QueryBytes
+		sample.series = evalStats.Series
+		sample.failed = err != nil || results.HasErrors()
 
 		if ctx.Err() != nil { // check if the context is not cancelled. The evaluation can be a long-running task.
 			span.SetStatus(codes.Error, "rule evaluation cancelled")
@@ -497,7 +603,12 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 	}
 
 	evalRunning := false
+	// stateLoaded is used only when evaluation is sharded. The rule could have been evaluated by another instance
+	// of the cluster, so its latest state is loaded from the database before the first evaluation.
+	stateLoaded := sch.sharder == nil
 	var currentFingerprint fingerprint
+	// maintenanceApplied is true when the state of the rule was reset because the rule entered a maintenance window.
+	maintenanceApplied := false
 	defer sch.stopApplied(key)
 	for {
 		select {
@@ -519,6 +630,7 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 				return nil
 			}
 			if evalRunning {
+				ctx.done()
 				continue
 			}
 
@@ -526,9 +638,26 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 				evalRunning = true
 				defer func() {
 					evalRunning = false
+					ctx.done()
 					sch.evalApplied(key, ctx.scheduledAt)
 				}()
 
+				if !stateLoaded {
+					sch.stateManager.WarmRule(grafanaCtx, ctx.rule)
+					stateLoaded = true
+				}
+
+				// the cost of a tick is recorded once, with the total of its attempts
+				var (
+					sample      costSample
+					evaluatedAt time.Time
+				)
+				defer func() {
+					if !evaluatedAt.IsZero() {
+						sch.costs.observeEvaluation(ctx.rule, evaluatedAt, sample)
+					}
+				}()
+
 				for attempt := int64(1); attempt <= sch.maxAttempts; attempt++ {
 					isPaused := ctx.rule.IsPaused
 					f := ruleWithFolder{ctx.rule, ctx.folderTitle}.Fingerprint()
@@ -550,6 +679,16 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 						logger.Debug("Skip rule evaluation because it is paused")
 						return
 					}
+					if ctx.inMaintenance {
+						if !maintenanceApplied {
+							logger.Info("Rule is in a maintenance window. Reset the state and skip evaluations till the window ends")
+							notify(sch.stateManager.ResetStateByRuleUID(grafanaCtx, ctx.rule, ngmodels.StateReasonMaintenance))
+							maintenanceApplied = true
+						}
+						logger.Debug("Skip rule evaluation because it is in a maintenance window")
+						return
+					}
+					maintenanceApplied = false
 
 					fpStr := currentFingerprint.String()
 					utcTick := ctx.scheduledAt.UTC().Format(time.RFC3339Nano)
@@ -570,7 +709,10 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 					}
 
 					retry := attempt < sch.maxAttempts
-					err := evaluate(tracingCtx, f, attempt, ctx, span, retry)
+					if evaluatedAt.IsZero() {
+						evaluatedAt = sch.clock.Now()
+					}
+					err := evaluate(tracingCtx, f, attempt, ctx, span, retry, &sample)
 					// This is extremely confusing - when we exhaust all retry attempts, or we have no retryable errors
 					// we return nil - so technically, this is meaningless to know whether the evaluation has errors or not.
 					span.End()
@@ -600,6 +742,10 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 				states := sch.stateManager.DeleteStateByRuleUID(ngmodels.WithRuleKey(ctx, key), key, ngmodels.StateReasonRuleDeleted)
 				notify(states)
 			}
+			// keep the state in the database if the rule is now evaluated by another instance, which takes it over.
+			if errors.Is(grafanaCtx.Err(), errRuleUnassigned) {
+				sch.stateManager.ForgetRule(key)
+			}
 			logger.Debug("Stopping alert rule routine")
 			return nil
 		}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math/rand"
 	"net/url"
+	"sync"
 	"testing"
 	"time"
 
@@ -361,6 +362,228 @@ func TestProcessTicks(t *testing.T) {
 	})
 }
 
+func TestProcessTicksWithSharding(t *testing.T) {
+	ctx := context.Background()
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+
+	ruleStore := newFakeRulesStore()
+	instanceStore := &state.FakeInstanceStore{}
+	sched := setupScheduler(t, ruleStore, instanceStore, nil, nil, nil)
+	membership := newFakeClusterMembership("instance-a", "instance-a", "instance-b")
+	sched.sharder = newRuleSharder(membership, log.NewNopLogger(), sched.metrics)
+
+	evalAppliedCh := make(chan evalAppliedInfo, 2)
+	stopAppliedCh := make(chan models.AlertRuleKey, 2)
+	sched.evalAppliedFunc = func(alertDefKey models.AlertRuleKey, now time.Time) {
+		evalAppliedCh <- evalAppliedInfo{alertDefKey: alertDefKey, now: now}
+	}
+	sched.stopAppliedFunc = func(alertDefKey models.AlertRuleKey) {
+		stopAppliedCh <- alertDefKey
+	}
+
+	// find one rule that is owned by this instance and one that is owned by the other instance
+	owns := sched.sharder.owner()
+	var ownedRule, otherRule *models.AlertRule
+	gen := models.AlertRuleGen(models.WithOrgID(1), models.WithInterval(time.Second), withQueryForState(t, eval.Normal))
+	for ownedRule == nil || otherRule == nil {
+		rule := gen()
+		if owns(rule.GetGroupKey()) {
+			ownedRule = rule
+		} else {
+			otherRule = rule
+		}
+	}
+	ruleStore.PutRule(ctx, ownedRule, otherRule)
+
+	tick := time.Time{}
+
+	t.Run("should evaluate only rules owned by this instance", func(t *testing.T) {
+		tick = tick.Add(time.Second)
+
+		scheduled, stopped, _ := sched.processTick(ctx, dispatcherGroup, tick)
+
+		require.Len(t, scheduled, 1)
+		require.Equal(t, ownedRule, scheduled[0].rule)
+		require.Empty(t, stopped)
+		assertEvalRun(t, evalAppliedCh, tick, ownedRule.GetKey())
+		require.Equal(t, 1.0, testutil.ToFloat64(sched.metrics.OwnedAlertRules))
+	})
+
+	t.Run("should evaluate all rules when the other instance leaves", func(t *testing.T) {
+		membership.setMembers("instance-a")
+		tick = tick.Add(time.Second)
+
+		scheduled, stopped, _ := sched.processTick(ctx, dispatcherGroup, tick)
+
+		require.Len(t, scheduled, 2)
+		require.Empty(t, stopped)
+		assertEvalRun(t, evalAppliedCh, tick, ownedRule.GetKey(), otherRule.GetKey())
+		require.Equal(t, 2.0, testutil.ToFloat64(sched.metrics.OwnedAlertRules))
+	})
+
+	t.Run("should hand the rule over when the other instance joins", func(t *testing.T) {
+		membership.setMembers("instance-a", "instance-b")
+		tick = tick.Add(time.Second)
+		require.NotEmpty(t, sched.stateManager.GetStatesForRuleUID(otherRule.OrgID, otherRule.UID))
+
+		scheduled, stopped, _ := sched.processTick(ctx, dispatcherGroup, tick)
+
+		require.Len(t, scheduled, 1)
+		require.Equal(t, ownedRule, scheduled[0].rule)
+		require.Len(t, stopped, 1)
+		require.Contains(t, stopped, otherRule.GetKey())
+		assertStopRun(t, stopAppliedCh, otherRule.GetKey())
+		assertEvalRun(t, evalAppliedCh, tick, ownedRule.GetKey())
+
+		// the rule is still schedulable, and its state is kept in the database for the new owner
+		require.NotNil(t, sched.schedulableAlertRules.get(otherRule.GetKey()))
+		require.Empty(t, sched.stateManager.GetStatesForRuleUID(otherRule.OrgID, otherRule.UID))
+		for _, op := range instanceStore.RecordedOps {
+			if o, ok := op.(state.FakeInstanceStoreOp); ok {
+				require.NotEqual(t, "DeleteAlertInstances", o.Name)
+			}
+		}
+	})
+}
+
+func TestProcessTicksWithShardingAndDependencies(t *testing.T) {
+	ctx := context.Background()
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+
+	ruleStore := newFakeRulesStore()
+	sched := setupScheduler(t, ruleStore, &state.FakeInstanceStore{}, nil, nil, nil)
+	sched.sharder = newRuleSharder(newFakeClusterMembership("instance-a", "instance-a", "instance-b"), log.NewNopLogger(), sched.metrics)
+
+	evalAppliedCh := make(chan evalAppliedInfo, 4)
+	sched.evalAppliedFunc = func(alertDefKey models.AlertRuleKey, now time.Time) {
+		evalAppliedCh <- evalAppliedInfo{alertDefKey: alertDefKey, now: now}
+	}
+
+	// find one rule group that is owned by this instance and one that is owned by the other instance.
+	// The second rule of each group is inhibited by the first one, so both must be evaluated by the same instance.
+	owns := sched.sharder.owner()
+	newGroup := func() []*models.AlertRule {
+		rules := models.GenerateAlertRules(2, models.AlertRuleGen(models.WithOrgID(1), models.WithInterval(time.Second), withQueryForState(t, eval.Normal)))
+		groupKey := models.GenerateGroupKey(1)
+		for i, rule := range rules {
+			rule.NamespaceUID, rule.RuleGroup, rule.RuleGroupIndex = groupKey.NamespaceUID, groupKey.RuleGroup, i+1
+		}
+		rules[1].Dependencies = []models.AlertRuleDependency{{RuleUID: rules[0].UID, InhibitWhenFiring: true}}
+		return rules
+	}
+	var ownedGroup, otherGroup []*models.AlertRule
+	for ownedGroup == nil || otherGroup == nil {
+		group := newGroup()
+		if owns(group[0].GetGroupKey()) {
+			ownedGroup = group
+		} else {
+			otherGroup = group
+		}
+	}
+	ruleStore.PutRule(ctx, append(ownedGroup, otherGroup...)...)
+
+	tick := time.Time{}.Add(time.Second)
+	scheduled, _, _ := sched.processTick(ctx, dispatcherGroup, tick)
+
+	require.Len(t, scheduled, 2)
+	require.ElementsMatch(t, ownedGroup, []*models.AlertRule{scheduled[0].rule, scheduled[1].rule})
+	assertEvalRun(t, evalAppliedCh, tick, ownedGroup[0].GetKey(), ownedGroup[1].GetKey())
+}
+
+type fakeMaintenanceWindowStore struct {
+	mtx     sync.Mutex
+	windows []*models.MaintenanceWindow
+	calls   int
+}
+
+func (f *fakeMaintenanceWindowStore) ListMaintenanceWindows(_ context.Context, _ *models.ListMaintenanceWindowsQuery) ([]*models.MaintenanceWindow, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.calls++
+	return f.windows, nil
+}
+
+func (f *fakeMaintenanceWindowStore) set(windows ...*models.MaintenanceWindow) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.windows = windows
+}
+
+func TestProcessTicksWithMaintenanceWindows(t *testing.T) {
+	ctx := context.Background()
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+
+	ruleStore := newFakeRulesStore()
+	instanceStore := &state.FakeInstanceStore{}
+	sender := AlertsSenderMock{}
+	sender.EXPECT().Send(mock.Anything, mock.Anything, mock.Anything).Return()
+	sched := setupScheduler(t, ruleStore, instanceStore, nil, &sender, nil)
+	windows := &fakeMaintenanceWindowStore{}
+	sched.maintenanceWindows = newMaintenanceWindowCache(windows, maintenanceWindowsRefreshInterval)
+
+	evalAppliedCh := make(chan evalAppliedInfo, 2)
+	sched.evalAppliedFunc = func(alertDefKey models.AlertRuleKey, now time.Time) {
+		evalAppliedCh <- evalAppliedInfo{alertDefKey: alertDefKey, now: now}
+	}
+
+	gen := models.AlertRuleGen(models.WithOrgID(1), models.WithInterval(time.Second), withQueryForState(t, eval.Alerting))
+	rule, otherRule := gen(), gen()
+	ruleStore.PutRule(ctx, rule, otherRule)
+
+	tick := time.Time{}
+
+	t.Run("should evaluate rules when there is no active maintenance window", func(t *testing.T) {
+		tick = tick.Add(time.Second)
+		scheduled, _, _ := sched.processTick(ctx, dispatcherGroup, tick)
+		require.Len(t, scheduled, 2)
+		for _, item := range scheduled {
+			require.False(t, item.inMaintenance)
+		}
+		assertEvalRun(t, evalAppliedCh, tick, rule.GetKey(), otherRule.GetKey())
+		require.NotEmpty(t, sched.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID))
+	})
+
+	t.Run("should skip evaluation and reset state of rules in an active maintenance window", func(t *testing.T) {
+		start := tick
+		end := tick.Add(time.Hour)
+		windows.set(
+			&models.MaintenanceWindow{OrgID: rule.OrgID, StartsAt: &start, EndsAt: &end, NamespaceUID: rule.NamespaceUID, RuleGroup: rule.RuleGroup},
+			&models.MaintenanceWindow{OrgID: rule.OrgID, StartsAt: &end, EndsAt: util.Pointer(end.Add(time.Hour))},
+		)
+
+		// the change is picked up only when the cached windows are refreshed
+		tick = tick.Add(time.Second)
+		scheduled, _, _ := sched.processTick(ctx, dispatcherGroup, tick)
+		require.Len(t, scheduled, 2)
+		for _, item := range scheduled {
+			require.False(t, item.inMaintenance)
+		}
+		assertEvalRun(t, evalAppliedCh, tick, rule.GetKey(), otherRule.GetKey())
+		require.Equal(t, 1, windows.calls)
+
+		tick = tick.Add(maintenanceWindowsRefreshInterval)
+		scheduled, _, _ = sched.processTick(ctx, dispatcherGroup, tick)
+		require.Equal(t, 2, windows.calls)
+		require.Len(t, scheduled, 2)
+		for _, item := range scheduled {
+			require.Equal(t, item.rule.UID == rule.UID, item.inMaintenance)
+		}
+		assertEvalRun(t, evalAppliedCh, tick, rule.GetKey(), otherRule.GetKey())
+
+		require.Empty(t, sched.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID))
+		require.NotEmpty(t, sched.stateManager.GetStatesForRuleUID(otherRule.OrgID, otherRule.UID))
+	})
+
+	t.Run("should evaluate rules again when the maintenance window ends", func(t *testing.T) {
+		windows.set()
+		tick = tick.Add(maintenanceWindowsRefreshInterval)
+		scheduled, _, _ := sched.processTick(ctx, dispatcherGroup, tick)
+		require.Len(t, scheduled, 2)
+		assertEvalRun(t, evalAppliedCh, tick, rule.GetKey(), otherRule.GetKey())
+		require.NotEmpty(t, sched.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID))
+	})
+}
+
 func TestSchedule_ruleRoutine(t *testing.T) {
 	createSchedule := func(
 		evalAppliedChan chan time.Time,
@@ -570,6 +793,28 @@ func TestSchedule_ruleRoutine(t *testing.T) {
 
 			require.Empty(t, sch.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID))
 		})
+		t.Run("and forget the state without resolving it if the rule is assigned to another instance", func(t *testing.T) {
+			stoppedChan := make(chan error)
+			senderMock := &AlertsSenderMock{}
+			sch, _, _, _ := createSchedule(make(chan time.Time), senderMock)
+
+			rule := models.AlertRuleGen()()
+			_ = sch.stateManager.ProcessEvalResults(context.Background(), sch.clock.Now(), rule, eval.GenerateResults(rand.Intn(5)+1, eval.ResultGen(eval.WithEvaluatedAt(sch.clock.Now()))), nil)
+			require.NotEmpty(t, sch.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID))
+
+			ctx, cancel := util.WithCancelCause(context.Background())
+			go func() {
+				err := sch.ruleRoutine(ctx, rule.GetKey(), make(chan *evaluation), make(chan ruleVersionAndPauseStatus))
+				stoppedChan <- err
+			}()
+
+			cancel(errRuleUnassigned)
+			err := waitForErrChannel(t, stoppedChan)
+			require.NoError(t, err)
+
+			require.Empty(t, sch.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID))
+			senderMock.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything)
+		})
 	})
 
 	t.Run("when a message is sent to update channel", func(t *testing.T) {
@@ -751,6 +996,13 @@ func TestSchedule_ruleRoutine(t *testing.T) {
 			require.NoError(t, err)
 		})
 
+		t.Run("it should record the cost of the tick once", func(t *testing.T) {
+			costs := sch.RuleCosts(rule.OrgID)
+			require.Len(t, costs, 1)
+			assert.Equal(t, 1, costs[0].Evaluations)
+			assert.Equal(t, 1, costs[0].Failures)
+		})
+
 		t.Run("it should send special alert DatasourceError", func(t *testing.T) {
 			sender.AssertNumberOfCalls(t, "Send", 1)
 			args, ok := sender.Calls[0].Arguments[2].(definitions.PostableAlerts)
@@ -0,0 +1,145 @@
+package schedule
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// ringTokensPerMember is the number of virtual nodes every member of the cluster gets in the hash ring.
+// The more tokens, the more even the distribution of alert rules across members.
+const ringTokensPerMember = 128
+
+// ClusterMembership provides the members of the high-availability cluster the instance is part of.
+type ClusterMembership interface {
+	// ClusterMembers returns the name of this instance and the names of all live members of the cluster,
+	// including this instance. It returns false if the instance is not part of a cluster.
+	ClusterMembers() (string, []string, bool)
+}
+
+// ruleSharder distributes alert rules across the members of a high-availability cluster using consistent hashing,
+// so that every rule is evaluated by only one member. Rules are distributed by rule group, because rules of a group
+// are evaluated in dependency order and are inhibited by the state of other rules of the group, which is only known
+// to the member that evaluates them. When members join or leave the cluster, only the rule groups
+// that hash to the affected part of the ring move to a different member.
+//
+// The sharder fails open: if the membership cannot be determined or this instance is not a member of the cluster,
+// it owns all rules. Evaluating a rule more than once is preferable to not evaluating it at all, because
+// duplicated notifications are removed by the Alertmanager.
+type ruleSharder struct {
+	membership ClusterMembership
+	log        log.Logger
+	metrics    *metrics.Scheduler
+
+	mtx     sync.Mutex
+	members []string
+	ring    *hashRing
+}
+
+func newRuleSharder(membership ClusterMembership, logger log.Logger, m *metrics.Scheduler) *ruleSharder {
+	return &ruleSharder{
+		membership: membership,
+		log:        logger,
+		metrics:    m,
+	}
+}
+
+// owner returns a function that tells whether the rules of the group are evaluated by this instance, according to the current
+// members of the cluster. The function should be obtained once per tick, so all rules are distributed using the same view of the cluster.
+func (s *ruleSharder) owner() func(key ngmodels.AlertRuleGroupKey) bool {
+	self, members, ok := s.membership.ClusterMembers()
+	if !ok || !slices.Contains(members, self) {
+		return func(ngmodels.AlertRuleGroupKey) bool { return true }
+	}
+	ring := s.ringFor(members)
+	return func(key ngmodels.AlertRuleGroupKey) bool {
+		return ring.get(ruleShardKey(key)) == self
+	}
+}
+
+// ringFor returns the ring of the members, building a new one if the members changed since the last call.
+func (s *ruleSharder) ringFor(members []string) *hashRing {
+	sorted := slices.Clone(members)
+	sort.Strings(sorted)
+	sorted = slices.Compact(sorted)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.ring != nil && slices.Equal(s.members, sorted) {
+		return s.ring
+	}
+	if s.ring != nil {
+		s.log.Info("Members of the cluster have changed, redistributing alert rules", "previous", strings.Join(s.members, ","), "current", strings.Join(sorted, ","))
+		s.metrics.ShardRebalances.Inc()
+	}
+	s.members = sorted
+	s.ring = newHashRing(sorted, ringTokensPerMember)
+	return s.ring
+}
+
+func ruleShardKey(key ngmodels.AlertRuleGroupKey) uint64 {
+	return hashString(fmt.Sprintf("%d/%s/%s", key.OrgID, key.NamespaceUID, key.RuleGroup))
+}
+
+// hashRing is an immutable consistent hash ring.
+type hashRing struct {
+	tokens []uint64
+	owners map[uint64]string
+}
+
+func newHashRing(members []string, tokensPerMember int) *hashRing {
+	r := &hashRing{
+		tokens: make([]uint64, 0, len(members)*tokensPerMember),
+		owners: make(map[uint64]string, len(members)*tokensPerMember),
+	}
+	for _, member := range members {
+		for i := 0; i < tokensPerMember; i++ {
+			token := hashString(fmt.Sprintf("%s-%d", member, i))
+			// in the unlikely case of a collision, the member that is lexicographically smaller wins,
+			// so all instances build identical rings.
+			if existing, ok := r.owners[token]; ok {
+				if existing < member {
+					continue
+				}
+			} else {
+				r.tokens = append(r.tokens, token)
+			}
+			r.owners[token] = member
+		}
+	}
+	slices.Sort(r.tokens)
+	return r
+}
+
+// get returns the member that owns the key, that is, the member of the first token that is equal to or greater than the key.
+func (r *hashRing) get(key uint64) string {
+	if len(r.tokens) == 0 {
+		return ""
+	}
+	idx := sort.Search(len(r.tokens), func(i int) bool { return r.tokens[i] >= key })
+	if idx == len(r.tokens) {
+		idx = 0
+	}
+	return r.owners[r.tokens[idx]]
+}
+
+// hashString hashes the string with FNV-1a. FNV does not spread similar strings, such as tokens of the same member,
+// evenly over the ring, so the result goes through the finalizer of MurmurHash3 to mix the bits.
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	k := h.Sum64()
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
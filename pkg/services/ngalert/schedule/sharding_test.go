@@ -0,0 +1,121 @@
+package schedule
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+type fakeClusterMembership struct {
+	mtx     sync.Mutex
+	self    string
+	members []string
+	ok      bool
+}
+
+func newFakeClusterMembership(self string, members ...string) *fakeClusterMembership {
+	return &fakeClusterMembership{self: self, members: members, ok: true}
+}
+
+func (f *fakeClusterMembership) ClusterMembers() (string, []string, bool) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.self, f.members, f.ok
+}
+
+func (f *fakeClusterMembership) setMembers(members ...string) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.members = members
+}
+
+func generateRuleGroupKeys(n int) []models.AlertRuleGroupKey {
+	keys := make([]models.AlertRuleGroupKey, 0, n)
+	for i := 0; i < n; i++ {
+		keys = append(keys, models.AlertRuleGroupKey{OrgID: int64(i%3 + 1), NamespaceUID: fmt.Sprintf("folder-%d", i%7), RuleGroup: fmt.Sprintf("group-%d", i)})
+	}
+	return keys
+}
+
+func TestRuleSharder(t *testing.T) {
+	newSharder := func(membership ClusterMembership) (*ruleSharder, *metrics.Scheduler) {
+		m := metrics.NewSchedulerMetrics(prometheus.NewPedanticRegistry())
+		return newRuleSharder(membership, log.NewNopLogger(), m), m
+	}
+	keys := generateRuleGroupKeys(3000)
+	members := []string{"instance-a", "instance-b", "instance-c"}
+
+	t.Run("every rule group should be owned by exactly one member", func(t *testing.T) {
+		owners := make(map[string]func(models.AlertRuleGroupKey) bool, len(members))
+		for _, m := range members {
+			sharder, _ := newSharder(newFakeClusterMembership(m, members...))
+			owners[m] = sharder.owner()
+		}
+
+		perMember := make(map[string]int, len(members))
+		for _, key := range keys {
+			count := 0
+			for m, owns := range owners {
+				if owns(key) {
+					count++
+					perMember[m]++
+				}
+			}
+			require.Equalf(t, 1, count, "rule group %s should be owned by exactly one member", key)
+		}
+		// with enough tokens per member, the distribution should be reasonably even
+		for m, n := range perMember {
+			require.InDeltaf(t, len(keys)/len(members), n, float64(len(keys))/10, "member %s owns too many or too few rule groups", m)
+		}
+	})
+
+	t.Run("should not depend on the order of members", func(t *testing.T) {
+		s1, _ := newSharder(newFakeClusterMembership("instance-a", "instance-a", "instance-b", "instance-c"))
+		s2, _ := newSharder(newFakeClusterMembership("instance-a", "instance-c", "instance-a", "instance-b"))
+		owns1, owns2 := s1.owner(), s2.owner()
+		for _, key := range keys {
+			require.Equal(t, owns1(key), owns2(key))
+		}
+	})
+
+	t.Run("should move only rule groups of the affected member when a member joins", func(t *testing.T) {
+		membership := newFakeClusterMembership("instance-a", members...)
+		sharder, m := newSharder(membership)
+		before := sharder.owner()
+
+		membership.setMembers(append(members, "instance-d")...)
+		after := sharder.owner()
+
+		for _, key := range keys {
+			if !before(key) {
+				require.Falsef(t, after(key), "rule group %s should not move to instance-a", key)
+			}
+		}
+		require.Equal(t, 1.0, testutil.ToFloat64(m.ShardRebalances))
+	})
+
+	t.Run("should own all rule groups", func(t *testing.T) {
+		testCases := map[string]*fakeClusterMembership{
+			"if instance is not part of a cluster": {ok: false},
+			"if instance is not a member":          newFakeClusterMembership("instance-d", members...),
+			"if instance is the only member":       newFakeClusterMembership("instance-a", "instance-a"),
+		}
+		for name, membership := range testCases {
+			t.Run(name, func(t *testing.T) {
+				sharder, _ := newSharder(membership)
+				owns := sharder.owner()
+				for _, key := range keys {
+					require.True(t, owns(key))
+				}
+			})
+		}
+	})
+}
@@ -245,6 +245,20 @@ func (c *cache) setAllStates(newStates map[int64]map[string]*ruleStates) {
 	c.states = newStates
 }
 
+// setRuleStates replaces all states of the rule with the given states.
+func (c *cache) setRuleStates(orgID int64, uid string, states []*State) {
+	rs := &ruleStates{states: make(map[string]*State, len(states))}
+	for _, s := range states {
+		rs.states[s.CacheID] = s
+	}
+	c.mtxStates.Lock()
+	defer c.mtxStates.Unlock()
+	if _, ok := c.states[orgID]; !ok {
+		c.states[orgID] = make(map[string]*ruleStates)
+	}
+	c.states[orgID][uid] = rs
+}
+
 func (c *cache) set(entry *State) {
 	c.mtxStates.Lock()
 	defer c.mtxStates.Unlock()
@@ -330,6 +344,23 @@ func (c *cache) removeByRuleUID(orgID int64, uid string) []*State {
 	return states
 }
 
+// getActive returns the alerting and pending states of all organizations.
+func (c *cache) getActive() []*State {
+	var states []*State
+	c.mtxStates.RLock()
+	defer c.mtxStates.RUnlock()
+	for _, orgStates := range c.states {
+		for _, rs := range orgStates {
+			for _, st := range rs.states {
+				if st.State == eval.Alerting || st.State == eval.Pending {
+					states = append(states, st)
+				}
+			}
+		}
+	}
+	return states
+}
+
 // asInstances returns the whole content of the cache as a slice of AlertInstance.
 func (c *cache) asInstances(skipNormalState bool) []ngModels.AlertInstance {
 	var states []ngModels.AlertInstance
@@ -0,0 +1,354 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/benbjohnson/clock"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/client"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	alertMetricName         = "ALERTS"
+	alertForStateMetricName = "ALERTS_FOR_STATE"
+	alertStateLabel         = "alertstate"
+	// ruleUIDLabel replaces the reserved label of the rule UID, which Prometheus would drop.
+	ruleUIDLabel = "grafana_rule_uid"
+
+	alertHelp         = "Active alerts of Grafana-managed alert rules."
+	alertForStateHelp = "The time when the active alerts of Grafana-managed alert rules entered their state, in seconds since the epoch."
+)
+
+// StateReader returns the active alert instances.
+type StateReader interface {
+	GetActiveStates() []*state.State
+}
+
+// ClusterMembership provides the members of the high-availability cluster the instance is part of.
+type ClusterMembership interface {
+	// ClusterMembers returns the name of this instance and the names of all live members of the cluster,
+	// including this instance. It returns false if the instance is not part of a cluster.
+	ClusterMembers() (string, []string, bool)
+}
+
+// Exporter exposes the active alerts of Grafana-managed alert rules as the ALERTS and ALERTS_FOR_STATE series that
+// Prometheus generates for its alerting rules. The series can be collected from the metrics endpoint of Grafana, as
+// the Exporter implements prometheus.Collector, and pushed to a Prometheus remote write endpoint.
+//
+// If the Exporter has a cluster membership, all members of the cluster evaluate the same rules and only the first of
+// the live members, by name, exports the series, so that the series are not written once per member.
+type Exporter struct {
+	cfg        setting.UnifiedAlertingStateExportSettings
+	states     StateReader
+	membership ClusterMembership
+	client     client.Requester
+	clock      clock.Clock
+	metrics    *metrics.State
+	log        log.Logger
+
+	mtx sync.Mutex
+	// written contains the labels of the series of the last successful push, by their key.
+	written map[string][]prompb.Label
+}
+
+// NewExporter returns a new Exporter. The membership is nil if every instance exports the alerts of the rules it evaluates.
+func NewExporter(cfg setting.UnifiedAlertingStateExportSettings, states StateReader, membership ClusterMembership, req client.Requester, clk clock.Clock, m *metrics.State, logger log.Logger) *Exporter {
+	return &Exporter{
+		cfg:        cfg,
+		states:     states,
+		membership: membership,
+		client:     req,
+		clock:      clk,
+		metrics:    m,
+		log:        logger,
+		written:    map[string][]prompb.Label{},
+	}
+}
+
+// isExporting returns true if this instance exports the series. Like the sharding of rules, it fails open:
+// if the membership cannot be determined or the instance is not a member of the cluster, the instance exports the series.
+func (e *Exporter) isExporting() bool {
+	if e.membership == nil {
+		return true
+	}
+	self, members, ok := e.membership.ClusterMembers()
+	if !ok || !slices.Contains(members, self) {
+		return true
+	}
+	return slices.Min(members) == self
+}
+
+// series is a sample of an exported series.
+type series struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// Describe implements prometheus.Collector. The exporter is an unchecked collector because the label names of the
+// series depend on the labels of the alerts.
+func (e *Exporter) Describe(chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector.
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range e.series() {
+		help := alertHelp
+		if s.name == alertForStateMetricName {
+			help = alertForStateHelp
+		}
+		desc := prometheus.NewDesc(s.name, help, nil, s.labels)
+		m, err := prometheus.NewConstMetric(desc, prometheus.GaugeValue, s.value)
+		if err != nil {
+			e.log.Warn("Failed to export alert", "error", err)
+			continue
+		}
+		ch <- m
+	}
+}
+
+// series returns the ALERTS and ALERTS_FOR_STATE series of the active alerts, or nothing if this instance does not export the series.
+func (e *Exporter) series() []series {
+	if !e.isExporting() {
+		return nil
+	}
+	states := e.states.GetActiveStates()
+	result := make([]series, 0, 2*len(states))
+	seen := make(map[string]struct{}, len(states))
+	for _, st := range states {
+		var alertState string
+		switch st.State {
+		case eval.Alerting:
+			alertState = "firing"
+		case eval.Pending:
+			alertState = "pending"
+		default:
+			continue
+		}
+		lbls := e.labels(st)
+		key := seriesKey(alertMetricName, lbls)
+		if _, ok := seen[key]; ok {
+			// alerts of different rules can only have the same labels after they are sanitized.
+			continue
+		}
+		seen[key] = struct{}{}
+
+		result = append(result, series{
+			name:   alertForStateMetricName,
+			labels: lbls,
+			value:  float64(st.StartsAt.Unix()),
+		})
+		withState := make(map[string]string, len(lbls)+1)
+		for k, v := range lbls {
+			withState[k] = v
+		}
+		withState[alertStateLabel] = alertState
+		result = append(result, series{
+			name:   alertMetricName,
+			labels: withState,
+			value:  1,
+		})
+	}
+	return result
+}
+
+// labels returns the labels of the series of the alert. The names of the labels are sanitized, and the labels that
+// Prometheus reserves for internal use are dropped.
+func (e *Exporter) labels(st *state.State) map[string]string {
+	result := make(map[string]string, len(st.Labels)+len(e.cfg.ExternalLabels)+1)
+	keys := make([]string, 0, len(st.Labels))
+	for k := range st.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := st.Labels[k]
+		if v == "" || strings.HasPrefix(k, "__") || k == alertStateLabel {
+			continue
+		}
+		name := sanitizeLabelName(k)
+		if _, ok := result[name]; ok {
+			continue
+		}
+		result[name] = v
+	}
+	if st.AlertRuleUID != "" {
+		result[ruleUIDLabel] = st.AlertRuleUID
+	}
+	for k, v := range e.cfg.ExternalLabels {
+		name := sanitizeLabelName(k)
+		if _, ok := result[name]; !ok && v != "" {
+			result[name] = v
+		}
+	}
+	return result
+}
+
+// sanitizeLabelName replaces the characters that are not allowed in the names of Prometheus labels with underscores.
+func sanitizeLabelName(name string) string {
+	var b strings.Builder
+	b.Grow(len(name))
+	for i, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_' || (i > 0 && r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+func seriesKey(name string, lbls map[string]string) string {
+	keys := make([]string, 0, len(lbls))
+	for k := range lbls {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range keys {
+		b.WriteByte(0)
+		b.WriteString(k)
+		b.WriteByte(0)
+		b.WriteString(lbls[k])
+	}
+	return b.String()
+}
+
+// Run pushes the series to the remote write endpoint every interval until the context is cancelled.
+func (e *Exporter) Run(ctx context.Context) error {
+	if e.cfg.RemoteWriteURL == "" {
+		return nil
+	}
+	e.log.Info("Pushing alert state to remote write endpoint", "url", e.cfg.RemoteWriteURL, "interval", e.cfg.RemoteWriteInterval)
+	ticker := e.clock.Ticker(e.cfg.RemoteWriteInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := e.push(ctx); err != nil {
+				e.log.Error("Failed to push alert state to remote write endpoint", "error", err)
+			}
+		}
+	}
+}
+
+// push writes the current series to the remote write endpoint. The series that were pushed before but are no longer
+// active get a staleness marker, so that they end immediately as if Prometheus stopped generating them.
+func (e *Exporter) push(ctx context.Context) error {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if !e.isExporting() {
+		// another member of the cluster continues the same series, so they must not be marked as stale.
+		e.written = map[string][]prompb.Label{}
+		return nil
+	}
+
+	ts := e.clock.Now().UnixMilli()
+	current := e.series()
+	written := make(map[string][]prompb.Label, len(current))
+	timeSeries := make([]prompb.TimeSeries, 0, len(current)+len(e.written))
+	for _, s := range current {
+		lbls := toPromLabels(s.name, s.labels)
+		written[seriesKey(s.name, s.labels)] = lbls
+		timeSeries = append(timeSeries, prompb.TimeSeries{
+			Labels:  lbls,
+			Samples: []prompb.Sample{{Value: s.value, Timestamp: ts}},
+		})
+	}
+	for key, lbls := range e.written {
+		if _, ok := written[key]; ok {
+			continue
+		}
+		timeSeries = append(timeSeries, prompb.TimeSeries{
+			Labels:  lbls,
+			Samples: []prompb.Sample{{Value: math.Float64frombits(value.StaleNaN), Timestamp: ts}},
+		})
+	}
+	if len(timeSeries) == 0 {
+		return nil
+	}
+
+	e.metrics.StateExportRemoteWrites.Inc()
+	if err := e.write(ctx, timeSeries); err != nil {
+		e.metrics.StateExportRemoteWriteFailures.Inc()
+		// the staleness markers of the series that ended are sent again with the next push.
+		return err
+	}
+	e.written = written
+	return nil
+}
+
+func (e *Exporter) write(ctx context.Context, timeSeries []prompb.TimeSeries) error {
+	b, err := proto.Marshal(&prompb.WriteRequest{Timeseries: timeSeries})
+	if err != nil {
+		return fmt.Errorf("failed to marshal write request: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.RemoteWriteTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.cfg.RemoteWriteURL, bytes.NewReader(snappy.Encode(nil, b)))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if e.cfg.RemoteWriteBasicAuthUsername != "" || e.cfg.RemoteWriteBasicAuthPassword != "" {
+		req.SetBasicAuth(e.cfg.RemoteWriteBasicAuthUsername, e.cfg.RemoteWriteBasicAuthPassword)
+	}
+	if e.cfg.RemoteWriteTenantID != "" {
+		req.Header.Set("X-Scope-OrgID", e.cfg.RemoteWriteTenantID)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			e.log.Warn("Failed to close response body", "error", err)
+		}
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("received a non-2xx response from the remote write endpoint, status: %d, body: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// toPromLabels returns the labels of a series sorted by name, as required by remote write.
+func toPromLabels(name string, lbls map[string]string) []prompb.Label {
+	result := make([]prompb.Label, 0, len(lbls)+1)
+	result = append(result, prompb.Label{Name: "__name__", Value: name})
+	for k, v := range lbls {
+		result = append(result, prompb.Label{Name: k, Value: v})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Ensure the exporter can be registered as a collector.
+var _ prometheus.Collector = (*Exporter)(nil)
@@ -0,0 +1,214 @@
+package exporter
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeStateReader struct {
+	states []*state.State
+}
+
+func (f *fakeStateReader) GetActiveStates() []*state.State {
+	return f.states
+}
+
+func newState(ruleUID string, s eval.State, startsAt time.Time, labels map[string]string) *state.State {
+	return &state.State{
+		OrgID:        1,
+		AlertRuleUID: ruleUID,
+		State:        s,
+		StartsAt:     startsAt,
+		Labels:       labels,
+	}
+}
+
+func TestExporterCollect(t *testing.T) {
+	startsAt := time.Unix(1700000000, 0)
+	reader := &fakeStateReader{states: []*state.State{
+		newState("rule-1", eval.Alerting, startsAt, map[string]string{
+			"alertname":          "HighErrorRate",
+			"grafana_folder":     "Services",
+			"__alert_rule_uid__": "rule-1",
+			"team.name":          "api",
+			"empty":              "",
+		}),
+		newState("rule-2", eval.Pending, startsAt, map[string]string{"alertname": "InstanceDown"}),
+		newState("rule-3", eval.Normal, startsAt, map[string]string{"alertname": "Normal"}),
+	}}
+	cfg := setting.UnifiedAlertingStateExportSettings{
+		ExternalLabels: map[string]string{"cluster": "eu", "team.name": "platform"},
+	}
+	e := NewExporter(cfg, reader, nil, nil, clock.NewMock(), metrics.NewStateMetrics(prometheus.NewRegistry()), log.NewNopLogger())
+
+	expected := `
+# HELP ALERTS Active alerts of Grafana-managed alert rules.
+# TYPE ALERTS gauge
+ALERTS{alertname="HighErrorRate",alertstate="firing",cluster="eu",grafana_folder="Services",grafana_rule_uid="rule-1",team_name="api"} 1
+ALERTS{alertname="InstanceDown",alertstate="pending",cluster="eu",grafana_rule_uid="rule-2",team_name="platform"} 1
+# HELP ALERTS_FOR_STATE The time when the active alerts of Grafana-managed alert rules entered their state, in seconds since the epoch.
+# TYPE ALERTS_FOR_STATE gauge
+ALERTS_FOR_STATE{alertname="HighErrorRate",cluster="eu",grafana_folder="Services",grafana_rule_uid="rule-1",team_name="api"} 1.7e+09
+ALERTS_FOR_STATE{alertname="InstanceDown",cluster="eu",grafana_rule_uid="rule-2",team_name="platform"} 1.7e+09
+`
+	require.NoError(t, testutil.CollectAndCompare(e, strings.NewReader(expected)))
+}
+
+func TestExporterPush(t *testing.T) {
+	var requests []*http.Request
+	var writes []prompb.WriteRequest
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		compressed, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		b, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		var req prompb.WriteRequest
+		require.NoError(t, proto.Unmarshal(b, &req))
+		writes = append(writes, req)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	clk := clock.NewMock()
+	clk.Set(time.Unix(1700000060, 0))
+	reader := &fakeStateReader{states: []*state.State{
+		newState("rule-1", eval.Alerting, time.Unix(1700000000, 0), map[string]string{"alertname": "HighErrorRate"}),
+	}}
+	cfg := setting.UnifiedAlertingStateExportSettings{
+		RemoteWriteURL:      server.URL,
+		RemoteWriteTenantID: "tenant",
+		RemoteWriteTimeout:  time.Second,
+	}
+	m := metrics.NewStateMetrics(prometheus.NewRegistry())
+	e := NewExporter(cfg, reader, nil, &http.Client{}, clk, m, log.NewNopLogger())
+
+	t.Run("should push series of active alerts", func(t *testing.T) {
+		require.NoError(t, e.push(context.Background()))
+		require.Len(t, writes, 1)
+		require.Equal(t, "tenant", requests[0].Header.Get("X-Scope-OrgID"))
+		require.Equal(t, "snappy", requests[0].Header.Get("Content-Encoding"))
+		require.Equal(t, []prompb.TimeSeries{
+			{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "ALERTS_FOR_STATE"},
+					{Name: "alertname", Value: "HighErrorRate"},
+					{Name: "grafana_rule_uid", Value: "rule-1"},
+				},
+				Samples: []prompb.Sample{{Value: 1700000000, Timestamp: 1700000060000}},
+			},
+			{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: "ALERTS"},
+					{Name: "alertname", Value: "HighErrorRate"},
+					{Name: "alertstate", Value: "firing"},
+					{Name: "grafana_rule_uid", Value: "rule-1"},
+				},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: 1700000060000}},
+			},
+		}, writes[0].Timeseries)
+	})
+
+	t.Run("should keep staleness markers until they are written", func(t *testing.T) {
+		reader.states = nil
+		status = http.StatusInternalServerError
+		require.Error(t, e.push(context.Background()))
+		require.Equal(t, float64(1), testutil.ToFloat64(m.StateExportRemoteWriteFailures))
+
+		status = http.StatusNoContent
+		clk.Add(time.Minute)
+		require.NoError(t, e.push(context.Background()))
+		last := writes[len(writes)-1]
+		require.Len(t, last.Timeseries, 2)
+		for _, ts := range last.Timeseries {
+			require.Len(t, ts.Samples, 1)
+			require.True(t, value.IsStaleNaN(ts.Samples[0].Value))
+			require.Equal(t, int64(1700000120000), ts.Samples[0].Timestamp)
+		}
+	})
+
+	t.Run("should not push when there are no series", func(t *testing.T) {
+		count := len(writes)
+		require.NoError(t, e.push(context.Background()))
+		require.Len(t, writes, count)
+		require.Equal(t, float64(3), testutil.ToFloat64(m.StateExportRemoteWrites))
+	})
+}
+
+type fakeClusterMembership struct {
+	self    string
+	members []string
+}
+
+func (f *fakeClusterMembership) ClusterMembers() (string, []string, bool) {
+	return f.self, f.members, true
+}
+
+func TestExporterInCluster(t *testing.T) {
+	var writes int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writes++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	reader := &fakeStateReader{states: []*state.State{
+		newState("rule-1", eval.Alerting, time.Unix(1700000000, 0), map[string]string{"alertname": "HighErrorRate"}),
+	}}
+	cfg := setting.UnifiedAlertingStateExportSettings{
+		RemoteWriteURL:     server.URL,
+		RemoteWriteTimeout: time.Second,
+	}
+	membership := &fakeClusterMembership{self: "instance-b", members: []string{"instance-b", "instance-a"}}
+	e := NewExporter(cfg, reader, membership, &http.Client{}, clock.NewMock(), metrics.NewStateMetrics(prometheus.NewRegistry()), log.NewNopLogger())
+
+	t.Run("should export only from the first member of the cluster", func(t *testing.T) {
+		require.Empty(t, e.series())
+		require.NoError(t, e.push(context.Background()))
+		require.Equal(t, 0, writes)
+
+		membership.members = []string{"instance-b", "instance-c"}
+		require.Len(t, e.series(), 2)
+		require.NoError(t, e.push(context.Background()))
+		require.Equal(t, 1, writes)
+	})
+
+	t.Run("should not mark series as stale when another member takes over", func(t *testing.T) {
+		membership.members = []string{"instance-b", "instance-a"}
+		require.NoError(t, e.push(context.Background()))
+		require.Equal(t, 1, writes)
+		require.Empty(t, e.written)
+	})
+
+	t.Run("should export if the instance is not a member of the cluster", func(t *testing.T) {
+		membership.members = []string{"instance-a"}
+		require.Len(t, e.series(), 2)
+	})
+}
+
+func TestSanitizeLabelName(t *testing.T) {
+	require.Equal(t, "team_name", sanitizeLabelName("team.name"))
+	require.Equal(t, "_abc", sanitizeLabelName("1abc"))
+	require.Equal(t, "a1_", sanitizeLabelName("a1-"))
+}
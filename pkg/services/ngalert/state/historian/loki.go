@@ -400,6 +400,10 @@ func BuildLogQuery(query models.HistoryQuery) (string, error) {
 	}
 	logQL += labelFilters
 
+	for _, m := range query.Matchers {
+		logQL += fmt.Sprintf(" | labels_%s%s%q", m.Name, m.Type, m.Value)
+	}
+
 	return logQL, nil
 }
 
@@ -407,5 +411,6 @@ func queryHasLogFilters(query models.HistoryQuery) bool {
 	return query.RuleUID != "" ||
 		query.DashboardUID != "" ||
 		query.PanelID != 0 ||
-		len(query.Labels) > 0
+		len(query.Labels) > 0 ||
+		len(query.Matchers) > 0
 }
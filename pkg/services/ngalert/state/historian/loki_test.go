@@ -19,6 +19,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	history_model "github.com/grafana/grafana/pkg/services/ngalert/state/historian/model"
+	"github.com/prometheus/alertmanager/pkg/labels"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
@@ -278,6 +279,17 @@ func TestRemoteLokiBackend(t *testing.T) {
 				},
 				exp: `{orgID="123",from="state-history"} | json | ruleUID="rule-uid" | labels_customlabel="customvalue"`,
 			},
+			{
+				name: "filters instance labels by matchers",
+				query: models.HistoryQuery{
+					OrgID: 123,
+					Matchers: labels.Matchers{
+						{Type: labels.MatchRegexp, Name: "severity", Value: "crit.*"},
+						{Type: labels.MatchNotEqual, Name: "team", Value: "a"},
+					},
+				},
+				exp: `{orgID="123",from="state-history"} | json | labels_severity=~"crit.*" | labels_team!="a"`,
+			},
 		}
 
 		for _, tc := range cases {
@@ -0,0 +1,469 @@
+package historian
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	history_model "github.com/grafana/grafana/pkg/services/ngalert/state/historian/model"
+)
+
+const (
+	// sqlCleanupInterval is how often the SQL backend deletes the history that is older than the retention period.
+	sqlCleanupInterval = time.Hour
+	// sqlMaxQueryLimit is the largest number of transitions a single query of the SQL backend can return.
+	sqlMaxQueryLimit = 5000
+)
+
+// stateHistoryRow is a row of the alert_state_history table. Every row is a single state transition of an alert instance.
+type stateHistoryRow struct {
+	ID            int64  `xorm:"pk autoincr 'id'"`
+	OrgID         int64  `xorm:"org_id"`
+	RuleID        int64  `xorm:"rule_id"`
+	RuleUID       string `xorm:"rule_uid"`
+	RuleTitle     string `xorm:"rule_title"`
+	RuleGroup     string `xorm:"rule_group"`
+	RuleCondition string `xorm:"rule_condition"`
+	NamespaceUID  string `xorm:"namespace_uid"`
+	DashboardUID  string `xorm:"dashboard_uid"`
+	PanelID       int64  `xorm:"panel_id"`
+	Fingerprint   string `xorm:"fingerprint"`
+	Timestamp     int64  `xorm:"timestamp"`
+	PreviousState string `xorm:"previous_state"`
+	CurrentState  string `xorm:"current_state"`
+	Error         string `xorm:"error"`
+	StateValues   string `xorm:"state_values"`
+}
+
+func (stateHistoryRow) TableName() string {
+	return "alert_state_history"
+}
+
+// stateHistoryLabelRow is a row of the alert_state_history_label table. The labels of an alert instance
+// are stored once per organization and label set, identified by the fingerprint of the label set.
+type stateHistoryLabelRow struct {
+	ID          int64  `xorm:"pk autoincr 'id'"`
+	OrgID       int64  `xorm:"org_id"`
+	Fingerprint string `xorm:"fingerprint"`
+	Key         string `xorm:"label_key"`
+	Value       string `xorm:"label_value"`
+}
+
+func (stateHistoryLabelRow) TableName() string {
+	return "alert_state_history_label"
+}
+
+// SQLBackend is a state.Historian that records state history to dedicated tables in the Grafana database.
+// Unlike annotations, it keeps the labels of alert instances, so the history can be queried by label matchers.
+type SQLBackend struct {
+	store     db.DB
+	retention time.Duration
+	clock     clock.Clock
+	metrics   *metrics.Historian
+	log       log.Logger
+}
+
+func NewSQLBackend(store db.DB, retention time.Duration, metrics *metrics.Historian) *SQLBackend {
+	return &SQLBackend{
+		store:     store,
+		retention: retention,
+		clock:     clock.New(),
+		metrics:   metrics,
+		log:       log.New("ngalert.state.historian", "backend", "sql"),
+	}
+}
+
+// Record writes a number of state transitions for a given rule to the database.
+func (h *SQLBackend) Record(ctx context.Context, rule history_model.RuleMeta, states []state.StateTransition) <-chan error {
+	logger := h.log.FromContext(ctx)
+	rows, labelSets := h.statesToRows(rule, states, logger)
+
+	errCh := make(chan error, 1)
+	if len(rows) == 0 {
+		close(errCh)
+		return errCh
+	}
+
+	// This is a new background job, so let's create a brand new context for it, like the other backends do.
+	writeCtx := context.Background()
+	writeCtx, cancel := context.WithTimeout(writeCtx, StateHistoryWriteTimeout)
+	writeCtx = history_model.WithRuleData(writeCtx, rule)
+	writeCtx = trace.ContextWithSpan(writeCtx, trace.SpanFromContext(ctx))
+
+	go func(ctx context.Context) {
+		defer cancel()
+		defer close(errCh)
+		logger := h.log.FromContext(ctx)
+
+		org := fmt.Sprint(rule.OrgID)
+		h.metrics.WritesTotal.WithLabelValues(org, "sql").Inc()
+		h.metrics.TransitionsTotal.WithLabelValues(org).Add(float64(len(rows)))
+
+		if err := h.save(ctx, rule.OrgID, rows, labelSets); err != nil {
+			logger.Error("Failed to save alert state history batch", "error", err)
+			h.metrics.WritesFailed.WithLabelValues(org, "sql").Inc()
+			h.metrics.TransitionsFailed.WithLabelValues(org).Add(float64(len(rows)))
+			errCh <- fmt.Errorf("failed to save alert state history batch: %w", err)
+		}
+	}(writeCtx)
+	return errCh
+}
+
+func (h *SQLBackend) statesToRows(rule history_model.RuleMeta, states []state.StateTransition, logger log.Logger) ([]*stateHistoryRow, map[string]data.Labels) {
+	rows := make([]*stateHistoryRow, 0, len(states))
+	labelSets := make(map[string]data.Labels)
+	for _, state := range states {
+		if !shouldRecord(state) {
+			continue
+		}
+
+		sanitizedLabels := removePrivateLabels(state.Labels)
+		fingerprint := labelFingerprint(sanitizedLabels)
+		labelSets[fingerprint] = sanitizedLabels
+
+		row := &stateHistoryRow{
+			OrgID:         rule.OrgID,
+			RuleID:        rule.ID,
+			RuleUID:       rule.UID,
+			RuleTitle:     rule.Title,
+			RuleGroup:     rule.Group,
+			RuleCondition: rule.Condition,
+			NamespaceUID:  rule.NamespaceUID,
+			DashboardUID:  rule.DashboardUID,
+			PanelID:       rule.PanelID,
+			Fingerprint:   fingerprint,
+			Timestamp:     state.State.LastEvaluationTime.UnixMilli(),
+			PreviousState: state.PreviousFormatted(),
+			CurrentState:  state.Formatted(),
+		}
+		if state.State.State == eval.Error {
+			row.Error = state.Error.Error()
+		}
+		if values := valuesAsDataBlob(state.State); values != nil {
+			encoded, err := values.Encode()
+			if err != nil {
+				logger.Error("Failed to serialize values of state, skipping", "error", err)
+				continue
+			}
+			row.StateValues = string(encoded)
+		}
+		rows = append(rows, row)
+	}
+	return rows, labelSets
+}
+
+// save inserts the transitions and the label sets that are not stored yet. Transitions are inserted first,
+// so that the label sets they refer to are never considered unused by a concurrent cleanup.
+func (h *SQLBackend) save(ctx context.Context, orgID int64, rows []*stateHistoryRow, labelSets map[string]data.Labels) error {
+	fingerprints := make([]string, 0, len(labelSets))
+	for fp := range labelSets {
+		fingerprints = append(fingerprints, fp)
+	}
+	sort.Strings(fingerprints)
+
+	return h.store.WithDbSession(ctx, func(sess *db.Session) error {
+		if _, err := sess.InsertMulti(&rows); err != nil {
+			return fmt.Errorf("failed to save state transitions: %w", err)
+		}
+
+		var existing []string
+		if err := sess.Table("alert_state_history_label").Distinct("fingerprint").Where("org_id = ?", orgID).In("fingerprint", fingerprints).Find(&existing); err != nil {
+			return fmt.Errorf("failed to look up stored label sets: %w", err)
+		}
+		stored := make(map[string]struct{}, len(existing))
+		for _, fp := range existing {
+			stored[fp] = struct{}{}
+		}
+
+		for _, fp := range fingerprints {
+			if _, ok := stored[fp]; ok || len(labelSets[fp]) == 0 {
+				continue
+			}
+			labelRows := make([]*stateHistoryLabelRow, 0, len(labelSets[fp]))
+			for k, v := range labelSets[fp] {
+				labelRows = append(labelRows, &stateHistoryLabelRow{OrgID: orgID, Fingerprint: fp, Key: k, Value: v})
+			}
+			// Another instance of Grafana may have stored the same label set in the meantime.
+			if _, err := sess.InsertMulti(&labelRows); err != nil && !h.store.GetDialect().IsUniqueConstraintViolation(err) {
+				return fmt.Errorf("failed to save label set: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Query retrieves state history entries from the database and formats the results into a dataframe
+// that has the same shape as the one of the Loki backend.
+func (h *SQLBackend) Query(ctx context.Context, query models.HistoryQuery) (*data.Frame, error) {
+	now := h.clock.Now()
+	to := now
+	if !query.To.IsZero() && query.To.Unix() > 0 {
+		to = query.To
+	}
+	from := to.Add(-defaultQueryRange)
+	if !query.From.IsZero() && query.From.Unix() > 0 {
+		from = query.From
+	}
+	if from.After(to) {
+		return nil, fmt.Errorf("start time cannot be after end time")
+	}
+	limit := query.Limit
+	if limit < 1 {
+		limit = defaultPageSize
+	}
+	if limit > sqlMaxQueryLimit {
+		limit = sqlMaxQueryLimit
+	}
+
+	matchers := make(labels.Matchers, 0, len(query.Labels)+len(query.Matchers))
+	matchers = append(matchers, query.Matchers...)
+	for k, v := range query.Labels {
+		m, err := labels.NewMatcher(labels.MatchEqual, k, v)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+
+	var rows []stateHistoryRow
+	var labelSets map[string]data.Labels
+	err := h.store.WithDbSession(ctx, func(sess *db.Session) error {
+		var fingerprints []string
+		if len(matchers) > 0 {
+			matching, err := h.matchingLabelSets(sess, query.OrgID, matchers)
+			if err != nil {
+				return err
+			}
+			if len(matching) == 0 {
+				return nil
+			}
+			labelSets = matching
+			for fp := range matching {
+				fingerprints = append(fingerprints, fp)
+			}
+		}
+
+		q := sess.Table("alert_state_history").
+			Where("org_id = ?", query.OrgID).
+			And("timestamp >= ?", from.UnixMilli()).
+			And("timestamp <= ?", to.UnixMilli())
+		if query.RuleUID != "" {
+			q = q.And("rule_uid = ?", query.RuleUID)
+		}
+		if query.DashboardUID != "" {
+			q = q.And("dashboard_uid = ?", query.DashboardUID)
+		}
+		if query.PanelID != 0 {
+			q = q.And("panel_id = ?", query.PanelID)
+		}
+		if fingerprints != nil {
+			q = q.In("fingerprint", fingerprints)
+		}
+		if err := q.Desc("timestamp").Desc("id").Limit(limit).Find(&rows); err != nil {
+			return fmt.Errorf("failed to query state transitions: %w", err)
+		}
+
+		if labelSets == nil {
+			seen := make(map[string]struct{}, len(rows))
+			for _, row := range rows {
+				if _, ok := seen[row.Fingerprint]; !ok {
+					seen[row.Fingerprint] = struct{}{}
+					fingerprints = append(fingerprints, row.Fingerprint)
+				}
+			}
+			var err error
+			if labelSets, err = h.labelSets(sess, query.OrgID, fingerprints); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The frame lists the transitions in chronological order.
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Timestamp < rows[j].Timestamp
+	})
+	return h.rowsToFrame(rows, labelSets)
+}
+
+// matchingLabelSets returns the label sets of the organization that match all matchers, by fingerprint.
+// Matchers that need the label to be set narrow the label sets down in the query, by value for equality
+// matchers. All the matchers are then evaluated in Go, because regular expressions are not supported by
+// all databases.
+func (h *SQLBackend) matchingLabelSets(sess *db.Session, orgID int64, matchers labels.Matchers) (map[string]data.Labels, error) {
+	q := sess.Table("alert_state_history_label").Where("org_id = ?", orgID)
+	for _, m := range matchers {
+		if m.Matches("") {
+			// the matcher also selects the label sets without the label
+			continue
+		}
+		cond := "fingerprint IN (SELECT fingerprint FROM alert_state_history_label WHERE org_id = ? AND label_key = ?"
+		args := []any{orgID, m.Name}
+		if m.Type == labels.MatchEqual {
+			cond += " AND label_value = ?"
+			args = append(args, m.Value)
+		}
+		q = q.And(cond+")", args...)
+	}
+
+	var rows []stateHistoryLabelRow
+	if err := q.Find(&rows); err != nil {
+		return nil, fmt.Errorf("failed to query label sets: %w", err)
+	}
+	sets := groupLabelRows(rows)
+	for fp, lbls := range sets {
+		for _, m := range matchers {
+			if !m.Matches(lbls[m.Name]) {
+				delete(sets, fp)
+				break
+			}
+		}
+	}
+	return sets, nil
+}
+
+func (h *SQLBackend) labelSets(sess *db.Session, orgID int64, fingerprints []string) (map[string]data.Labels, error) {
+	if len(fingerprints) == 0 {
+		return map[string]data.Labels{}, nil
+	}
+	var rows []stateHistoryLabelRow
+	if err := sess.Table("alert_state_history_label").Where("org_id = ?", orgID).In("fingerprint", fingerprints).Find(&rows); err != nil {
+		return nil, fmt.Errorf("failed to query label sets: %w", err)
+	}
+	return groupLabelRows(rows), nil
+}
+
+func groupLabelRows(rows []stateHistoryLabelRow) map[string]data.Labels {
+	sets := make(map[string]data.Labels)
+	for _, row := range rows {
+		lbls, ok := sets[row.Fingerprint]
+		if !ok {
+			lbls = make(data.Labels)
+			sets[row.Fingerprint] = lbls
+		}
+		lbls[row.Key] = row.Value
+	}
+	return sets
+}
+
+func (h *SQLBackend) rowsToFrame(rows []stateHistoryRow, labelSets map[string]data.Labels) (*data.Frame, error) {
+	frame := data.NewFrame("states")
+	lbls := data.Labels(map[string]string{})
+
+	times := make([]time.Time, 0, len(rows))
+	lines := make([]json.RawMessage, 0, len(rows))
+	streamLabels := make([]json.RawMessage, 0, len(rows))
+	for _, row := range rows {
+		values := simplejson.New()
+		if row.StateValues != "" {
+			var err error
+			if values, err = simplejson.NewJson([]byte(row.StateValues)); err != nil {
+				return nil, fmt.Errorf("failed to deserialize values of state transition: %w", err)
+			}
+		}
+		instanceLabels := labelSets[row.Fingerprint]
+		if instanceLabels == nil {
+			instanceLabels = data.Labels{}
+		}
+		line, err := json.Marshal(LokiEntry{
+			SchemaVersion:  1,
+			Previous:       row.PreviousState,
+			Current:        row.CurrentState,
+			Error:          row.Error,
+			Values:         values,
+			Condition:      row.RuleCondition,
+			DashboardUID:   row.DashboardUID,
+			PanelID:        row.PanelID,
+			Fingerprint:    row.Fingerprint,
+			RuleTitle:      row.RuleTitle,
+			RuleID:         row.RuleID,
+			RuleUID:        row.RuleUID,
+			InstanceLabels: instanceLabels,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize state transition: %w", err)
+		}
+		stream, err := json.Marshal(map[string]string{
+			StateHistoryLabelKey: StateHistoryLabelValue,
+			OrgIDLabel:           fmt.Sprint(row.OrgID),
+			GroupLabel:           row.RuleGroup,
+			FolderUIDLabel:       row.NamespaceUID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize stream labels: %w", err)
+		}
+
+		times = append(times, time.UnixMilli(row.Timestamp))
+		lines = append(lines, line)
+		streamLabels = append(streamLabels, stream)
+	}
+
+	frame.Fields = append(frame.Fields, data.NewField(dfTime, lbls, times))
+	frame.Fields = append(frame.Fields, data.NewField(dfLine, lbls, lines))
+	frame.Fields = append(frame.Fields, data.NewField(dfLabels, lbls, streamLabels))
+	return frame, nil
+}
+
+// Run periodically deletes the state history that is older than the retention period until the context is cancelled.
+func (h *SQLBackend) Run(ctx context.Context) error {
+	if h.retention <= 0 {
+		return nil
+	}
+	ticker := h.clock.Ticker(sqlCleanupInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := h.DeleteExpired(ctx); err != nil {
+			h.log.Error("Failed to delete expired state history", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeleteExpired deletes the state transitions that are older than the retention period, and the label sets
+// that are no longer referenced by any transition. It returns the number of deleted transitions.
+func (h *SQLBackend) DeleteExpired(ctx context.Context) (int64, error) {
+	cutoff := h.clock.Now().Add(-h.retention).UnixMilli()
+	var deleted int64
+	err := h.store.WithDbSession(ctx, func(sess *db.Session) error {
+		res, err := sess.Exec("DELETE FROM alert_state_history WHERE timestamp < ?", cutoff)
+		if err != nil {
+			return err
+		}
+		if deleted, err = res.RowsAffected(); err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return nil
+		}
+		_, err = sess.Exec(`DELETE FROM alert_state_history_label WHERE NOT EXISTS (
+			SELECT 1 FROM alert_state_history h WHERE h.org_id = alert_state_history_label.org_id AND h.fingerprint = alert_state_history_label.fingerprint
+		)`)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	h.log.Debug("Deleted expired state history", "count", deleted)
+	return deleted, nil
+}
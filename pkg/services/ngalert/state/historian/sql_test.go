@@ -0,0 +1,181 @@
+package historian
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+func TestIntegrationSQLBackend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	met := metrics.NewHistorianMetrics(prometheus.NewRegistry(), metrics.Subsystem)
+	backend := NewSQLBackend(sqlStore, 24*time.Hour, met)
+	clk := clock.NewMock()
+	clk.Set(time.Now().Truncate(time.Millisecond))
+	backend.clock = clk
+	now := clk.Now()
+
+	transition := func(at time.Time, current eval.State, lbls data.Labels) state.StateTransition {
+		return state.StateTransition{
+			PreviousState: eval.Normal,
+			State: &state.State{
+				State:              current,
+				Labels:             lbls,
+				LastEvaluationTime: at,
+				Values:             map[string]float64{"A": 1},
+			},
+		}
+	}
+	critical := data.Labels{"alertname": "cpu", "severity": "critical", "instance": "a", "__alert_rule_uid__": "rule-uid"}
+	warning := data.Labels{"alertname": "cpu", "severity": "warning", "instance": "b"}
+
+	rule := createTestRule()
+	require.NoError(t, <-backend.Record(context.Background(), rule, []state.StateTransition{
+		transition(now.Add(-3*time.Minute), eval.Pending, critical),
+		transition(now.Add(-2*time.Minute), eval.Alerting, warning),
+	}))
+	// the label set of critical is stored once
+	require.NoError(t, <-backend.Record(context.Background(), rule, []state.StateTransition{
+		transition(now.Add(-time.Minute), eval.Alerting, critical),
+	}))
+	otherRule := createTestRule()
+	otherRule.UID = "other-rule-uid"
+	require.NoError(t, <-backend.Record(context.Background(), otherRule, []state.StateTransition{
+		transition(now, eval.Alerting, warning),
+	}))
+
+	var labelRows int64
+	err := sqlStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+		var err error
+		labelRows, err = sess.Table("alert_state_history_label").Count()
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(6), labelRows)
+
+	query := func(t *testing.T, q models.HistoryQuery) []LokiEntry {
+		t.Helper()
+		q.OrgID = rule.OrgID
+		frame, err := backend.Query(context.Background(), q)
+		require.NoError(t, err)
+		require.Len(t, frame.Fields, 3)
+		entries := make([]LokiEntry, 0, frame.Rows())
+		for i := 0; i < frame.Rows(); i++ {
+			var entry LokiEntry
+			require.NoError(t, json.Unmarshal(frame.Fields[1].At(i).(json.RawMessage), &entry))
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+	states := func(entries []LokiEntry) []string {
+		result := make([]string, 0, len(entries))
+		for _, e := range entries {
+			result = append(result, e.RuleUID+"/"+e.InstanceLabels["instance"]+"/"+e.Current)
+		}
+		return result
+	}
+
+	t.Run("should return transitions in chronological order", func(t *testing.T) {
+		entries := query(t, models.HistoryQuery{})
+		require.Equal(t, []string{"rule-uid/a/Pending", "rule-uid/b/Alerting", "rule-uid/a/Alerting", "other-rule-uid/b/Alerting"}, states(entries))
+
+		entry := entries[0]
+		require.Equal(t, "Normal", entry.Previous)
+		require.Equal(t, rule.Title, entry.RuleTitle)
+		require.Equal(t, rule.DashboardUID, entry.DashboardUID)
+		require.Equal(t, map[string]string{"alertname": "cpu", "severity": "critical", "instance": "a"}, entry.InstanceLabels)
+		require.Equal(t, 1.0, entry.Values.Get("A").MustFloat64())
+	})
+
+	t.Run("should filter transitions", func(t *testing.T) {
+		testCases := map[string]struct {
+			query    models.HistoryQuery
+			expected []string
+		}{
+			"by rule": {
+				query:    models.HistoryQuery{RuleUID: "other-rule-uid"},
+				expected: []string{"other-rule-uid/b/Alerting"},
+			},
+			"by labels": {
+				query:    models.HistoryQuery{Labels: map[string]string{"severity": "critical"}},
+				expected: []string{"rule-uid/a/Pending", "rule-uid/a/Alerting"},
+			},
+			"by matchers": {
+				query: models.HistoryQuery{Matchers: labels.Matchers{
+					mustMatcher(t, labels.MatchRegexp, "severity", "warn.*"),
+					mustMatcher(t, labels.MatchNotEqual, "team", "a"),
+				}},
+				expected: []string{"rule-uid/b/Alerting", "other-rule-uid/b/Alerting"},
+			},
+			"by equality matchers": {
+				query: models.HistoryQuery{Matchers: labels.Matchers{
+					mustMatcher(t, labels.MatchEqual, "instance", "b"),
+					mustMatcher(t, labels.MatchEqual, "team", ""),
+				}},
+				expected: []string{"rule-uid/b/Alerting", "other-rule-uid/b/Alerting"},
+			},
+			"by matchers that match no label set": {
+				query:    models.HistoryQuery{Matchers: labels.Matchers{mustMatcher(t, labels.MatchEqual, "team", "a")}},
+				expected: []string{},
+			},
+			"by time range": {
+				query:    models.HistoryQuery{From: now.Add(-150 * time.Second), To: now.Add(-30 * time.Second)},
+				expected: []string{"rule-uid/b/Alerting", "rule-uid/a/Alerting"},
+			},
+			"with limit, keeping the newest transitions": {
+				query:    models.HistoryQuery{Limit: 2},
+				expected: []string{"rule-uid/a/Alerting", "other-rule-uid/b/Alerting"},
+			},
+		}
+		for name, tc := range testCases {
+			t.Run(name, func(t *testing.T) {
+				require.Equal(t, tc.expected, states(query(t, tc.query)))
+			})
+		}
+	})
+
+	t.Run("should delete transitions older than retention and unused label sets", func(t *testing.T) {
+		clk.Add(24*time.Hour - 90*time.Second)
+		deleted, err := backend.DeleteExpired(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(2), deleted)
+
+		entries := query(t, models.HistoryQuery{From: now.Add(-time.Hour), To: clk.Now()})
+		require.Equal(t, []string{"rule-uid/a/Alerting", "other-rule-uid/b/Alerting"}, states(entries))
+
+		clk.Add(time.Hour)
+		deleted, err = backend.DeleteExpired(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(2), deleted)
+		err = sqlStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+			var err error
+			labelRows, err = sess.Table("alert_state_history_label").Count()
+			return err
+		})
+		require.NoError(t, err)
+		require.Zero(t, labelRows)
+	})
+}
+
+func mustMatcher(t *testing.T, mt labels.MatchType, name, value string) *labels.Matcher {
+	t.Helper()
+	m, err := labels.NewMatcher(mt, name, value)
+	require.NoError(t, err)
+	return m
+}
@@ -163,33 +163,8 @@ func (st *Manager) Warm(ctx context.Context, rulesReader RuleReader) {
 				orgStates[entry.RuleUID] = rulesStates
 			}
 
-			lbs := map[string]string(entry.Labels)
-			cacheID, err := entry.Labels.StringKey()
-			if err != nil {
-				st.log.Error("Error getting cacheId for entry", "error", err)
-			}
-			var resultFp data.Fingerprint
-			if entry.ResultFingerprint != "" {
-				fp, err := strconv.ParseUint(entry.ResultFingerprint, 16, 64)
-				if err != nil {
-					st.log.Error("Failed to parse result fingerprint of alert instance", "error", err, "ruleUID", entry.RuleUID)
-				}
-				resultFp = data.Fingerprint(fp)
-			}
-			rulesStates.states[cacheID] = &State{
-				AlertRuleUID:         entry.RuleUID,
-				OrgID:                entry.RuleOrgID,
-				CacheID:              cacheID,
-				Labels:               lbs,
-				State:                translateInstanceState(entry.CurrentState),
-				StateReason:          entry.CurrentReason,
-				LastEvaluationString: "",
-				StartsAt:             entry.CurrentStateSince,
-				EndsAt:               entry.CurrentStateEnd,
-				LastEvaluationTime:   entry.LastEvalTime,
-				Annotations:          ruleForEntry.Annotations,
-				ResultFingerprint:    resultFp,
-			}
+			s := st.stateFromInstance(entry, ruleForEntry)
+			rulesStates.states[s.CacheID] = s
 			statesCount++
 		}
 	}
@@ -197,6 +172,66 @@ func (st *Manager) Warm(ctx context.Context, rulesReader RuleReader) {
 	st.log.Info("State cache has been initialized", "states", statesCount, "duration", time.Since(startTime))
 }
 
+// WarmRule replaces the states of the rule in the cache with the states that are saved in the instance store.
+// It is used when the evaluation of the rule is handed over from another instance of a high-availability cluster,
+// which persisted the states of the rule while it was evaluating it.
+func (st *Manager) WarmRule(ctx context.Context, rule *ngModels.AlertRule) {
+	if st.instanceStore == nil {
+		return
+	}
+	logger := st.log.FromContext(ctx)
+	alertInstances, err := st.instanceStore.ListAlertInstances(ctx, &ngModels.ListAlertInstancesQuery{
+		RuleOrgID: rule.OrgID,
+		RuleUID:   rule.UID,
+	})
+	if err != nil {
+		logger.Error("Unable to fetch previous state of the rule", "error", err)
+		return
+	}
+	states := make([]*State, 0, len(alertInstances))
+	for _, entry := range alertInstances {
+		states = append(states, st.stateFromInstance(entry, rule))
+	}
+	st.cache.setRuleStates(rule.OrgID, rule.UID, states)
+	logger.Debug("State of the rule has been loaded", "states", len(states))
+}
+
+// ForgetRule removes the states of the rule from the cache but, unlike DeleteStateByRuleUID, keeps them
+// in the instance store and does not resolve them. It is used when the evaluation of the rule is handed over
+// to another instance of a high-availability cluster. Returns the number of removed states.
+func (st *Manager) ForgetRule(ruleKey ngModels.AlertRuleKey) int {
+	return len(st.cache.removeByRuleUID(ruleKey.OrgID, ruleKey.UID))
+}
+
+func (st *Manager) stateFromInstance(entry *ngModels.AlertInstance, rule *ngModels.AlertRule) *State {
+	cacheID, err := entry.Labels.StringKey()
+	if err != nil {
+		st.log.Error("Error getting cacheId for entry", "error", err)
+	}
+	var resultFp data.Fingerprint
+	if entry.ResultFingerprint != "" {
+		fp, err := strconv.ParseUint(entry.ResultFingerprint, 16, 64)
+		if err != nil {
+			st.log.Error("Failed to parse result fingerprint of alert instance", "error", err, "ruleUID", entry.RuleUID)
+		}
+		resultFp = data.Fingerprint(fp)
+	}
+	return &State{
+		AlertRuleUID:         entry.RuleUID,
+		OrgID:                entry.RuleOrgID,
+		CacheID:              cacheID,
+		Labels:               map[string]string(entry.Labels),
+		State:                translateInstanceState(entry.CurrentState),
+		StateReason:          entry.CurrentReason,
+		LastEvaluationString: "",
+		StartsAt:             entry.CurrentStateSince,
+		EndsAt:               entry.CurrentStateEnd,
+		LastEvaluationTime:   entry.LastEvalTime,
+		Annotations:          rule.Annotations,
+		ResultFingerprint:    resultFp,
+	}
+}
+
 func (st *Manager) Get(orgID int64, alertRuleUID, stateId string) *State {
 	return st.cache.get(orgID, alertRuleUID, stateId)
 }
@@ -312,27 +347,46 @@ func (st *Manager) setNextStateForRule(ctx context.Context, alertRule *ngModels.
 			return transitions // if there are no current states for the rule. Create ones for each result
 		}
 	}
+	inhibited := st.isInhibited(alertRule)
+	if inhibited {
+		logger.Debug("Rule is inhibited by a firing dependency")
+	}
 	transitions := make([]StateTransition, 0, len(results))
 	for _, result := range results {
 		currentState := st.cache.getOrCreate(ctx, logger, alertRule, result, extraLabels, st.externalURL)
-		s := st.setNextState(ctx, alertRule, currentState, result, logger)
+		s := st.setNextState(ctx, alertRule, currentState, result, inhibited, logger)
 		transitions = append(transitions, s)
 	}
 	return transitions
 }
 
+// isInhibited returns true if any of the dependencies of the rule that inhibit it has firing alerts.
+func (st *Manager) isInhibited(alertRule *ngModels.AlertRule) bool {
+	for _, dep := range alertRule.Dependencies {
+		if !dep.InhibitWhenFiring {
+			continue
+		}
+		for _, s := range st.cache.getStatesForRuleUID(alertRule.OrgID, dep.RuleUID, false) {
+			if s.State == eval.Alerting {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (st *Manager) setNextStateForAll(ctx context.Context, alertRule *ngModels.AlertRule, result eval.Result, logger log.Logger) []StateTransition {
 	currentStates := st.cache.getStatesForRuleUID(alertRule.OrgID, alertRule.UID, false)
 	transitions := make([]StateTransition, 0, len(currentStates))
 	for _, currentState := range currentStates {
-		t := st.setNextState(ctx, alertRule, currentState, result, logger)
+		t := st.setNextState(ctx, alertRule, currentState, result, false, logger)
 		transitions = append(transitions, t)
 	}
 	return transitions
 }
 
-// Set the current state based on evaluation results
-func (st *Manager) setNextState(ctx context.Context, alertRule *ngModels.AlertRule, currentState *State, result eval.Result, logger log.Logger) StateTransition {
+// Set the current state based on evaluation results. If inhibited is true, an Alerting result is handled as Normal.
+func (st *Manager) setNextState(ctx context.Context, alertRule *ngModels.AlertRule, currentState *State, result eval.Result, inhibited bool, logger log.Logger) StateTransition {
 	start := st.clock.Now()
 	currentState.LastEvaluationTime = result.EvaluatedAt
 	currentState.EvaluationDuration = result.EvaluationDuration
@@ -371,6 +425,11 @@ func (st *Manager) setNextState(ctx context.Context, alertRule *ngModels.AlertRu
 		}
 	}
 
+	inhibited = inhibited && result.State == eval.Alerting
+	if inhibited {
+		result.State = eval.Normal
+	}
+
 	switch result.State {
 	case eval.Normal:
 		logger.Debug("Setting next state", "handler", "resultNormal")
@@ -396,6 +455,9 @@ func (st *Manager) setNextState(ctx context.Context, alertRule *ngModels.AlertRu
 		result.State != eval.Alerting {
 		currentState.StateReason = result.State.String()
 	}
+	if inhibited {
+		currentState.StateReason = ngModels.StateReasonInhibited
+	}
 
 	// Set Resolved property so the scheduler knows to send a postable alert
 	// to Alertmanager.
@@ -432,6 +494,12 @@ func (st *Manager) GetAll(orgID int64) []*State {
 	allStates := st.cache.getAll(orgID, st.doNotSaveNormalState)
 	return allStates
 }
+
+// GetActiveStates returns the alerting and pending states of all organizations.
+func (st *Manager) GetActiveStates() []*State {
+	return st.cache.getActive()
+}
+
 func (st *Manager) GetStatesForRuleUID(orgID int64, alertRuleUID string) []*State {
 	return st.cache.getStatesForRuleUID(orgID, alertRuleUID, st.doNotSaveNormalState)
 }
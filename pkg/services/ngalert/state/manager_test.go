@@ -227,6 +227,22 @@ func TestWarmStateCache(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("forgotten rule state can be warmed again", func(t *testing.T) {
+		require.Equal(t, len(expectedEntries), st.ForgetRule(rule.GetKey()))
+		require.Empty(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID))
+
+		st.WarmRule(ctx, rule)
+
+		require.Len(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID), len(expectedEntries))
+		for _, entry := range expectedEntries {
+			cacheEntry := st.Get(entry.OrgID, entry.AlertRuleUID, entry.CacheID)
+			if diff := cmp.Diff(entry, cacheEntry, cmpopts.IgnoreFields(state.State{}, "Results")); diff != "" {
+				t.Errorf("Result mismatch (-want +got):\n%s", diff)
+				t.FailNow()
+			}
+		}
+	})
 }
 
 func TestDashboardAnnotations(t *testing.T) {
@@ -1676,6 +1692,58 @@ func TestStaleResults(t *testing.T) {
 	})
 }
 
+func TestProcessEvalResultsInhibitedByDependency(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+
+	cfg := state.ManagerCfg{
+		Metrics:       metrics.NewNGAlert(prometheus.NewPedanticRegistry()).GetStateMetrics(),
+		ExternalURL:   nil,
+		InstanceStore: &state.FakeInstanceStore{},
+		Images:        &state.NoopImageService{},
+		Clock:         clk,
+		Historian:     &state.FakeHistorian{},
+		Tracer:        tracing.InitializeTracerForTest(),
+		Log:           log.New("ngalert.state.manager"),
+	}
+	st := state.NewManager(cfg, state.NewNoopPersister())
+
+	upstream := models.AlertRuleGen(models.WithFor(0))()
+	dependent := models.AlertRuleGen(models.WithFor(0), models.WithOrgID(upstream.OrgID))()
+	dependent.Dependencies = []models.AlertRuleDependency{{RuleUID: upstream.UID, InhibitWhenFiring: true}}
+
+	results := func(s eval.State) eval.Results {
+		return eval.Results{eval.ResultGen(eval.WithState(s), eval.WithLabels(data.Labels{"instance": "test"}), eval.WithEvaluatedAt(clk.Now()))()}
+	}
+
+	t.Run("should inhibit alerting results while dependency is firing", func(t *testing.T) {
+		st.ProcessEvalResults(ctx, clk.Now(), upstream, results(eval.Alerting), nil)
+		processed := st.ProcessEvalResults(ctx, clk.Now(), dependent, results(eval.Alerting), nil)
+		require.Len(t, processed, 1)
+		assert.Equal(t, eval.Normal, processed[0].State.State)
+		assert.Equal(t, models.StateReasonInhibited, processed[0].StateReason)
+	})
+
+	t.Run("should fire when dependency is not firing", func(t *testing.T) {
+		clk.Add(time.Duration(upstream.IntervalSeconds) * time.Second)
+		st.ProcessEvalResults(ctx, clk.Now(), upstream, results(eval.Normal), nil)
+		processed := st.ProcessEvalResults(ctx, clk.Now(), dependent, results(eval.Alerting), nil)
+		require.Len(t, processed, 1)
+		assert.Equal(t, eval.Alerting, processed[0].State.State)
+		assert.Empty(t, processed[0].StateReason)
+	})
+
+	t.Run("should not inhibit if dependency does not inhibit", func(t *testing.T) {
+		clk.Add(time.Duration(upstream.IntervalSeconds) * time.Second)
+		st.ProcessEvalResults(ctx, clk.Now(), upstream, results(eval.Alerting), nil)
+		rule := models.CopyRule(dependent)
+		rule.Dependencies[0].InhibitWhenFiring = false
+		processed := st.ProcessEvalResults(ctx, clk.Now(), rule, results(eval.Alerting), nil)
+		require.Len(t, processed, 1)
+		assert.Equal(t, eval.Alerting, processed[0].State.State)
+	})
+}
+
 func TestDeleteStateByRuleUID(t *testing.T) {
 	interval := time.Minute
 	ctx := context.Background()
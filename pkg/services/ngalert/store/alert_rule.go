@@ -117,6 +117,44 @@ func (st DBstore) GetAlertRulesGroupByRuleUID(ctx context.Context, query *ngmode
 	return result, err
 }
 
+// ListAlertRuleVersions returns all versions of an alert rule, latest version first.
+func (st DBstore) ListAlertRuleVersions(ctx context.Context, query *ngmodels.ListAlertRuleVersionsQuery) (result []*ngmodels.AlertRuleVersion, err error) {
+	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		versions := make([]*ngmodels.AlertRuleVersion, 0)
+		err := sess.Table("alert_rule_version").
+			Where("rule_org_id = ? AND rule_uid = ?", query.OrgID, query.RuleUID).
+			Desc("version", "id").
+			Find(&versions)
+		if err != nil {
+			return err
+		}
+		result = versions
+		return nil
+	})
+	return result, err
+}
+
+// GetAlertRuleVersion returns a single version of an alert rule.
+// Returns models.ErrAlertRuleVersionNotFound if the rule does not have the version.
+func (st DBstore) GetAlertRuleVersion(ctx context.Context, query *ngmodels.GetAlertRuleVersionQuery) (result *ngmodels.AlertRuleVersion, err error) {
+	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		version := ngmodels.AlertRuleVersion{}
+		has, err := sess.Table("alert_rule_version").
+			Where("rule_org_id = ? AND rule_uid = ? AND version = ?", query.OrgID, query.RuleUID, query.Version).
+			Desc("id").
+			Get(&version)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ngmodels.ErrAlertRuleVersionNotFound
+		}
+		result = &version
+		return nil
+	})
+	return result, err
+}
+
 // InsertAlertRules is a handler for creating/updating alert rules.
 // Returns the UID and ID of rules that were created in the same order as the input rules.
 func (st DBstore) InsertAlertRules(ctx context.Context, rules []ngmodels.AlertRule) ([]ngmodels.AlertRuleKeyWithId, error) {
@@ -158,6 +196,9 @@ func (st DBstore) InsertAlertRules(ctx context.Context, rules []ngmodels.AlertRu
 				For:              r.For,
 				Annotations:      r.Annotations,
 				Labels:           r.Labels,
+				IsPaused:         r.IsPaused,
+				CreatedBy:        r.UpdatedBy,
+				Dependencies:     r.Dependencies,
 			})
 		}
 		if len(newRules) > 0 {
@@ -234,6 +275,9 @@ func (st DBstore) UpdateAlertRules(ctx context.Context, rules []ngmodels.UpdateR
 				For:              r.New.For,
 				Annotations:      r.New.Annotations,
 				Labels:           r.New.Labels,
+				IsPaused:         r.New.IsPaused,
+				CreatedBy:        r.New.UpdatedBy,
+				Dependencies:     r.New.Dependencies,
 			})
 		}
 		if len(ruleVersions) > 0 {
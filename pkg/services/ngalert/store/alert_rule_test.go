@@ -577,6 +577,59 @@ func TestIntegrationInsertAlertRules(t *testing.T) {
 	}
 }
 
+func TestIntegrationAlertRuleVersions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	sqlStore := db.InitTestDB(t)
+	cfg := setting.NewCfg()
+	cfg.UnifiedAlerting.BaseInterval = 1 * time.Second
+	store := &DBstore{
+		SQLStore:      sqlStore,
+		FolderService: setupFolderService(t, sqlStore, cfg),
+		Logger:        log.New("test-dbstore"),
+		Cfg:           cfg.UnifiedAlerting,
+	}
+
+	rule := models.AlertRuleGen(models.WithOrgID(1), withIntervalMatching(store.Cfg.BaseInterval))()
+	rule.UpdatedBy = "creator"
+	ids, err := store.InsertAlertRules(context.Background(), []models.AlertRule{*rule})
+	require.NoError(t, err)
+	rule.ID = ids[0].ID
+
+	existing, err := store.GetAlertRuleByUID(context.Background(), &models.GetAlertRuleByUIDQuery{OrgID: rule.OrgID, UID: rule.UID})
+	require.NoError(t, err)
+	updated := models.CopyRule(existing)
+	updated.Title = util.GenerateShortUID()
+	updated.UpdatedBy = "editor"
+	err = store.UpdateAlertRules(context.Background(), []models.UpdateRule{{Existing: existing, New: *updated}})
+	require.NoError(t, err)
+
+	t.Run("should list versions latest first", func(t *testing.T) {
+		versions, err := store.ListAlertRuleVersions(context.Background(), &models.ListAlertRuleVersionsQuery{OrgID: rule.OrgID, RuleUID: rule.UID})
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		require.Equal(t, existing.Version+1, versions[0].Version)
+		require.Equal(t, updated.Title, versions[0].Title)
+		require.Equal(t, "editor", versions[0].CreatedBy)
+		require.Equal(t, existing.Version, versions[1].Version)
+		require.Equal(t, rule.Title, versions[1].Title)
+		require.Equal(t, "creator", versions[1].CreatedBy)
+	})
+
+	t.Run("should get version", func(t *testing.T) {
+		v, err := store.GetAlertRuleVersion(context.Background(), &models.GetAlertRuleVersionQuery{OrgID: rule.OrgID, RuleUID: rule.UID, Version: existing.Version})
+		require.NoError(t, err)
+		require.Equal(t, rule.Title, v.Title)
+	})
+
+	t.Run("should return ErrAlertRuleVersionNotFound if version does not exist", func(t *testing.T) {
+		_, err := store.GetAlertRuleVersion(context.Background(), &models.GetAlertRuleVersionQuery{OrgID: rule.OrgID, RuleUID: rule.UID, Version: existing.Version + 100})
+		require.ErrorIs(t, err, models.ErrAlertRuleVersionNotFound)
+	})
+}
+
 // createAlertRule creates an alert rule in the database and returns it.
 // If a generator is not specified, uniqueness of primary key is not guaranteed.
 func createRule(t *testing.T, store *DBstore, generate func() *models.AlertRule) *models.AlertRule {
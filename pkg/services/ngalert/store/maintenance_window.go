@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const maintenanceWindowTable = "alert_maintenance_window"
+
+// ListMaintenanceWindows returns maintenance windows that match the query ordered by ID.
+func (st DBstore) ListMaintenanceWindows(ctx context.Context, query *ngmodels.ListMaintenanceWindowsQuery) (result []*ngmodels.MaintenanceWindow, err error) {
+	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		q := sess.Table(maintenanceWindowTable)
+		if query.OrgID > 0 {
+			q = q.Where("org_id = ?", query.OrgID)
+		}
+		windows := make([]*ngmodels.MaintenanceWindow, 0)
+		if err := q.Asc("id").Find(&windows); err != nil {
+			return err
+		}
+		result = windows
+		return nil
+	})
+	return result, err
+}
+
+// GetMaintenanceWindow returns the maintenance window with the given UID.
+func (st DBstore) GetMaintenanceWindow(ctx context.Context, orgID int64, uid string) (result *ngmodels.MaintenanceWindow, err error) {
+	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		window := ngmodels.MaintenanceWindow{}
+		has, err := sess.Table(maintenanceWindowTable).Where("org_id = ? AND uid = ?", orgID, uid).Get(&window)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ngmodels.ErrMaintenanceWindowNotFound
+		}
+		result = &window
+		return nil
+	})
+	return result, err
+}
+
+// InsertMaintenanceWindow stores a new maintenance window. It generates the UID if it is not set.
+func (st DBstore) InsertMaintenanceWindow(ctx context.Context, window ngmodels.MaintenanceWindow) (*ngmodels.MaintenanceWindow, error) {
+	err := st.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		if window.UID == "" {
+			window.UID = util.GenerateShortUID()
+		} else if err := util.ValidateUID(window.UID); err != nil {
+			return fmt.Errorf("%w: %s", ngmodels.ErrMaintenanceWindowFailedValidation, err)
+		}
+		exists, err := sess.Table(maintenanceWindowTable).Where("org_id = ? AND uid = ?", window.OrgID, window.UID).Exist()
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("%w: maintenance window with UID %s already exists", ngmodels.ErrMaintenanceWindowFailedValidation, window.UID)
+		}
+		window.Updated = TimeNow()
+		if _, err := sess.Table(maintenanceWindowTable).Insert(&window); err != nil {
+			return fmt.Errorf("failed to insert maintenance window: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+// UpdateMaintenanceWindow replaces the maintenance window with the same UID.
+func (st DBstore) UpdateMaintenanceWindow(ctx context.Context, window ngmodels.MaintenanceWindow) (*ngmodels.MaintenanceWindow, error) {
+	err := st.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		existing := ngmodels.MaintenanceWindow{}
+		has, err := sess.Table(maintenanceWindowTable).Where("org_id = ? AND uid = ?", window.OrgID, window.UID).Get(&existing)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ngmodels.ErrMaintenanceWindowNotFound
+		}
+		window.ID = existing.ID
+		window.Updated = TimeNow()
+		if _, err := sess.Table(maintenanceWindowTable).ID(window.ID).AllCols().Update(&window); err != nil {
+			return fmt.Errorf("failed to update maintenance window: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+// DeleteMaintenanceWindow deletes the maintenance window with the given UID.
+func (st DBstore) DeleteMaintenanceWindow(ctx context.Context, orgID int64, uid string) error {
+	return st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		affected, err := sess.Exec("DELETE FROM "+maintenanceWindowTable+" WHERE org_id = ? AND uid = ?", orgID, uid)
+		if err != nil {
+			return err
+		}
+		if n, err := affected.RowsAffected(); err == nil && n == 0 {
+			return ngmodels.ErrMaintenanceWindowNotFound
+		}
+		return nil
+	})
+}
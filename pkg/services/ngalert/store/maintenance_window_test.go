@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestIntegrationMaintenanceWindows(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	store := &DBstore{
+		SQLStore: db.InitTestDB(t),
+		Logger:   log.New("test-dbstore"),
+	}
+	ctx := context.Background()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	oneOff := models.MaintenanceWindow{
+		OrgID:        1,
+		Title:        "database upgrade",
+		StartsAt:     &start,
+		EndsAt:       &end,
+		NamespaceUID: "folder",
+		Matchers:     labels.Matchers{{Name: "team", Type: labels.MatchEqual, Value: "db"}},
+	}
+	recurring := models.MaintenanceWindow{
+		OrgID: 2,
+		UID:   "nightly",
+		Title: "nightly backup",
+		TimeIntervals: []timeinterval.TimeInterval{{
+			Times: []timeinterval.TimeRange{{StartMinute: 60, EndMinute: 120}},
+		}},
+	}
+
+	created, err := store.InsertMaintenanceWindow(ctx, oneOff)
+	require.NoError(t, err)
+	require.NotEmpty(t, created.UID)
+	_, err = store.InsertMaintenanceWindow(ctx, recurring)
+	require.NoError(t, err)
+
+	t.Run("should fail to insert window with the same UID", func(t *testing.T) {
+		_, err := store.InsertMaintenanceWindow(ctx, recurring)
+		require.ErrorIs(t, err, models.ErrMaintenanceWindowFailedValidation)
+	})
+
+	t.Run("should get window by UID", func(t *testing.T) {
+		w, err := store.GetMaintenanceWindow(ctx, 1, created.UID)
+		require.NoError(t, err)
+		require.Equal(t, oneOff.Title, w.Title)
+		require.True(t, start.Equal(*w.StartsAt))
+		require.True(t, end.Equal(*w.EndsAt))
+		require.Len(t, w.Matchers, 1)
+		require.True(t, w.Matchers[0].Matches("db"))
+
+		w, err = store.GetMaintenanceWindow(ctx, 2, "nightly")
+		require.NoError(t, err)
+		require.Nil(t, w.StartsAt)
+		require.Equal(t, recurring.TimeIntervals, w.TimeIntervals)
+
+		_, err = store.GetMaintenanceWindow(ctx, 2, created.UID)
+		require.ErrorIs(t, err, models.ErrMaintenanceWindowNotFound)
+	})
+
+	t.Run("should list windows", func(t *testing.T) {
+		all, err := store.ListMaintenanceWindows(ctx, &models.ListMaintenanceWindowsQuery{})
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+
+		org, err := store.ListMaintenanceWindows(ctx, &models.ListMaintenanceWindowsQuery{OrgID: 2})
+		require.NoError(t, err)
+		require.Len(t, org, 1)
+		require.Equal(t, "nightly", org[0].UID)
+	})
+
+	t.Run("should update window", func(t *testing.T) {
+		update := recurring
+		update.Title = "weekly backup"
+		update.RuleGroup = "group"
+		update.NamespaceUID = "folder"
+		_, err := store.UpdateMaintenanceWindow(ctx, update)
+		require.NoError(t, err)
+
+		w, err := store.GetMaintenanceWindow(ctx, 2, "nightly")
+		require.NoError(t, err)
+		require.Equal(t, "weekly backup", w.Title)
+		require.Equal(t, "group", w.RuleGroup)
+
+		update.UID = "unknown"
+		_, err = store.UpdateMaintenanceWindow(ctx, update)
+		require.ErrorIs(t, err, models.ErrMaintenanceWindowNotFound)
+	})
+
+	t.Run("should delete window", func(t *testing.T) {
+		require.NoError(t, store.DeleteMaintenanceWindow(ctx, 2, "nightly"))
+		require.ErrorIs(t, store.DeleteMaintenanceWindow(ctx, 2, "nightly"), models.ErrMaintenanceWindowNotFound)
+
+		all, err := store.ListMaintenanceWindows(ctx, &models.ListMaintenanceWindowsQuery{})
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"sort"
 	"sync"
 	"testing"
 	"time"
@@ -21,10 +22,12 @@ type RuleStore struct {
 	t   *testing.T
 	mtx sync.Mutex
 	// OrgID -> RuleGroup -> Namespace -> Rules
-	Rules       map[int64][]*models.AlertRule
-	Hook        func(cmd any) error // use Hook if you need to intercept some query and return an error
-	RecordedOps []any
-	Folders     map[int64][]*folder.Folder
+	Rules map[int64][]*models.AlertRule
+	// OrgID -> versions of rules
+	RuleVersions map[int64][]*models.AlertRuleVersion
+	Hook         func(cmd any) error // use Hook if you need to intercept some query and return an error
+	RecordedOps  []any
+	Folders      map[int64][]*folder.Folder
 }
 
 type GenericRecordedQuery struct {
@@ -34,8 +37,9 @@ type GenericRecordedQuery struct {
 
 func NewRuleStore(t *testing.T) *RuleStore {
 	return &RuleStore{
-		t:     t,
-		Rules: map[int64][]*models.AlertRule{},
+		t:            t,
+		Rules:        map[int64][]*models.AlertRule{},
+		RuleVersions: map[int64][]*models.AlertRuleVersion{},
 		Hook: func(any) error {
 			return nil
 		},
@@ -142,6 +146,49 @@ func (f *RuleStore) GetAlertRuleByUID(_ context.Context, q *models.GetAlertRuleB
 	return nil, nil
 }
 
+// PutRuleVersion puts the version of a rule in the RuleVersions map.
+func (f *RuleStore) PutRuleVersion(_ context.Context, versions ...*models.AlertRuleVersion) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, v := range versions {
+		f.RuleVersions[v.RuleOrgID] = append(f.RuleVersions[v.RuleOrgID], v)
+	}
+}
+
+func (f *RuleStore) ListAlertRuleVersions(_ context.Context, q *models.ListAlertRuleVersionsQuery) ([]*models.AlertRuleVersion, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.RecordedOps = append(f.RecordedOps, *q)
+	if err := f.Hook(*q); err != nil {
+		return nil, err
+	}
+	result := make([]*models.AlertRuleVersion, 0)
+	for _, v := range f.RuleVersions[q.OrgID] {
+		if v.RuleUID == q.RuleUID {
+			result = append(result, v)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version > result[j].Version
+	})
+	return result, nil
+}
+
+func (f *RuleStore) GetAlertRuleVersion(_ context.Context, q *models.GetAlertRuleVersionQuery) (*models.AlertRuleVersion, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.RecordedOps = append(f.RecordedOps, *q)
+	if err := f.Hook(*q); err != nil {
+		return nil, err
+	}
+	for _, v := range f.RuleVersions[q.OrgID] {
+		if v.RuleUID == q.RuleUID && v.Version == q.Version {
+			return v, nil
+		}
+	}
+	return nil, models.ErrAlertRuleVersionNotFound
+}
+
 func (f *RuleStore) GetAlertRulesGroupByRuleUID(_ context.Context, q *models.GetAlertRulesGroupByRuleUIDQuery) ([]*models.AlertRule, error) {
 	f.mtx.Lock()
 	defer f.mtx.Unlock()
@@ -3,13 +3,17 @@ package api
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/components/satokengen"
+	"github.com/grafana/grafana/pkg/services/apikey"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
+	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -36,6 +40,13 @@ type TokenDTO struct {
 	HasExpired bool `json:"hasExpired"`
 	// example: false
 	IsRevoked *bool `json:"isRevoked"`
+	// example: 10.0.0.1
+	LastUsedIP  *string               `json:"lastUsedIp,omitempty"`
+	Permissions apikey.PermissionList `json:"permissions,omitempty"`
+	// example: ["10.0.0.0/8"]
+	AllowedCIDRs apikey.CIDRList `json:"allowedCidrs,omitempty"`
+	// example: false
+	AutoRotate bool `json:"autoRotate"`
 }
 
 func hasExpired(expiration *int64) bool {
@@ -104,6 +115,10 @@ func (api *ServiceAccountsAPI) ListTokens(ctx *contextmodel.ReqContext) response
 			HasExpired:             isExpired,
 			LastUsedAt:             token.LastUsedAt,
 			IsRevoked:              token.IsRevoked,
+			LastUsedIP:             token.LastUsedIP,
+			Permissions:            token.Permissions,
+			AllowedCIDRs:           token.AllowedCIDRs,
+			AutoRotate:             token.AutoRotate != nil && *token.AutoRotate,
 		}
 	}
 
@@ -144,33 +159,99 @@ func (api *ServiceAccountsAPI) CreateToken(c *contextmodel.ReqContext) response.
 	// Force affected service account to be the one referenced in the URL
 	cmd.OrgId = c.SignedInUser.GetOrgID()
 
+	if resp := api.validateTokenExpiration(cmd.SecondsToLive); resp != nil {
+		return resp
+	}
+
+	newKeyInfo, err := satokengen.New(ServiceID)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Generating service account token failed", err)
+	}
+
+	cmd.Key = newKeyInfo.HashedKey
+
+	apiKey, err := api.service.AddServiceAccountToken(c.Req.Context(), saID, &cmd)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to add service account token", err)
+	}
+
+	result := &dtos.NewApiKeyResult{
+		ID:   apiKey.ID,
+		Name: apiKey.Name,
+		Key:  newKeyInfo.ClientSecret,
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
+
+func (api *ServiceAccountsAPI) validateTokenExpiration(secondsToLive int64) response.Response {
 	if api.cfg.ApiKeyMaxSecondsToLive != -1 {
-		if cmd.SecondsToLive == 0 {
+		if secondsToLive == 0 {
 			return response.Error(http.StatusBadRequest, "Number of seconds before expiration should be set", nil)
 		}
-		if cmd.SecondsToLive > api.cfg.ApiKeyMaxSecondsToLive {
+		if secondsToLive > api.cfg.ApiKeyMaxSecondsToLive {
 			return response.Error(http.StatusBadRequest, "Number of seconds before expiration is greater than the global limit", nil)
 		}
 	}
 
 	if api.cfg.SATokenExpirationDayLimit > 0 {
 		dayExpireLimit := time.Now().Add(time.Duration(api.cfg.SATokenExpirationDayLimit) * time.Hour * 24).Truncate(24 * time.Hour)
-		expirationDate := time.Now().Add(time.Duration(cmd.SecondsToLive) * time.Second).Truncate(24 * time.Hour)
+		expirationDate := time.Now().Add(time.Duration(secondsToLive) * time.Second).Truncate(24 * time.Hour)
 		if expirationDate.After(dayExpireLimit) {
 			return response.Respond(http.StatusBadRequest, "The expiration date input exceeds the limit for service account access tokens expiration date")
 		}
 	}
 
+	return nil
+}
+
+// swagger:route POST /serviceaccounts/{serviceAccountId}/tokens/{tokenId}/rotate service_accounts rotateToken
+//
+// # RotateToken replaces a service account token with a new one
+//
+// The new token gets the name, permissions and allowed CIDRs of the rotated token.
+// The rotated token keeps working until the overlap window has passed.
+//
+// Required permissions (See note in the [introduction](https://grafana.com/docs/grafana/latest/developers/http_api/serviceaccount/#service-account-api) for an explanation):
+// action: `serviceaccounts:write` scope: `serviceaccounts:id:1` (single service account)
+//
+// Responses:
+// 200: createTokenResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (api *ServiceAccountsAPI) RotateToken(c *contextmodel.ReqContext) response.Response {
+	saID, err := strconv.ParseInt(web.Params(c.Req)[":serviceAccountId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Service Account ID is invalid", err)
+	}
+
+	tokenID, err := strconv.ParseInt(web.Params(c.Req)[":tokenId"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "Token ID is invalid", err)
+	}
+
+	cmd := serviceaccounts.RotateServiceAccountTokenCommand{}
+	if err = web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "Bad request data", err)
+	}
+	cmd.OrgId = c.SignedInUser.GetOrgID()
+
+	if resp := api.validateTokenExpiration(cmd.SecondsToLive); resp != nil {
+		return resp
+	}
+
 	newKeyInfo, err := satokengen.New(ServiceID)
 	if err != nil {
 		return response.Error(http.StatusInternalServerError, "Generating service account token failed", err)
 	}
-
 	cmd.Key = newKeyInfo.HashedKey
 
-	apiKey, err := api.service.AddServiceAccountToken(c.Req.Context(), saID, &cmd)
+	apiKey, err := api.service.RotateServiceAccountToken(c.Req.Context(), saID, tokenID, &cmd)
 	if err != nil {
-		return response.ErrOrFallback(http.StatusInternalServerError, "failed to add service account token", err)
+		return response.ErrOrFallback(http.StatusInternalServerError, "failed to rotate service account token", err)
 	}
 
 	result := &dtos.NewApiKeyResult{
@@ -182,6 +263,64 @@ func (api *ServiceAccountsAPI) CreateToken(c *contextmodel.ReqContext) response.
 	return response.JSON(http.StatusOK, result)
 }
 
+// swagger:route POST /serviceaccounts/tokens/successor service_accounts takeTokenSuccessor
+//
+// # TakeTokenSuccessor returns the token that replaced the calling token in an automatic rotation
+//
+// The request must be authenticated with the rotated service account token. The new token
+// can be fetched only once, while the rotated token is still in its overlap window.
+//
+// Responses:
+// 200: createTokenResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (api *ServiceAccountsAPI) TakeTokenSuccessor(c *contextmodel.ReqContext) response.Response {
+	namespace, _ := c.SignedInUser.GetNamespacedID()
+	if namespace != identity.NamespaceServiceAccount {
+		return response.Error(http.StatusForbidden, "Only service account tokens have successors", nil)
+	}
+
+	decoded, err := satokengen.Decode(getTokenFromRequest(c.Req))
+	if err != nil {
+		return response.Error(http.StatusForbidden, "Only service account tokens have successors", err)
+	}
+	hash, err := decoded.Hash()
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to hash service account token", err)
+	}
+
+	apiKey, key, err := api.service.TakeServiceAccountTokenSuccessor(c.Req.Context(), c.SignedInUser.GetOrgID(), hash)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get service account token successor", err)
+	}
+
+	result := &dtos.NewApiKeyResult{
+		ID:   apiKey.ID,
+		Name: apiKey.Name,
+		Key:  key,
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
+
+// getTokenFromRequest returns the token the request was authenticated with, sent as a bearer token
+// or as the password of the api_key user.
+func getTokenFromRequest(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+		return token
+	}
+	if strings.HasPrefix(header, "Basic ") {
+		username, password, err := util.DecodeBasicAuthHeader(header)
+		if err == nil && username == "api_key" {
+			return password
+		}
+	}
+	return ""
+}
+
 // swagger:route DELETE /serviceaccounts/{serviceAccountId}/tokens/{tokenId} service_accounts deleteToken
 //
 // # DeleteToken deletes service account tokens
@@ -235,6 +374,16 @@ type CreateTokenParams struct {
 	Body serviceaccounts.AddServiceAccountTokenCommand
 }
 
+// swagger:parameters rotateToken
+type RotateTokenParams struct {
+	// in:path
+	TokenId int64 `json:"tokenId"`
+	// in:path
+	ServiceAccountId int64 `json:"serviceAccountId"`
+	// in:body
+	Body serviceaccounts.RotateServiceAccountTokenCommand
+}
+
 // swagger:parameters deleteToken
 type DeleteTokenParams struct {
 	// in:path
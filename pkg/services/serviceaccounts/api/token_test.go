@@ -126,6 +126,71 @@ func TestServiceAccountsAPI_CreateToken(t *testing.T) {
 	}
 }
 
+func TestServiceAccountsAPI_TakeTokenSuccessor(t *testing.T) {
+	type TestCase struct {
+		desc         string
+		user         *user.SignedInUser
+		token        string
+		expectedErr  error
+		expectedCode int
+	}
+
+	tests := []TestCase{
+		{
+			desc:         "should return the successor to the rotated service account token",
+			user:         &user.SignedInUser{OrgID: 1, UserID: 2, IsServiceAccount: true},
+			token:        "Bearer glsa_yscW25imSKJIuav8zF37RZmnbiDvB05G_fcaaf58a",
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "should return the successor to a token sent with basic auth",
+			user:         &user.SignedInUser{OrgID: 1, UserID: 2, IsServiceAccount: true},
+			token:        "Basic YXBpX2tleTpnbHNhX3lzY1cyNWltU0tKSXVhdjh6RjM3UlptbmJpRHZCMDVHX2ZjYWFmNThh",
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "should not return a successor to a user",
+			user:         &user.SignedInUser{OrgID: 1, UserID: 2},
+			token:        "Bearer glsa_yscW25imSKJIuav8zF37RZmnbiDvB05G_fcaaf58a",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should not return a successor to a malformed token",
+			user:         &user.SignedInUser{OrgID: 1, UserID: 2, IsServiceAccount: true},
+			token:        "Bearer glsa_invalid",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should return not found when the token has no successor",
+			user:         &user.SignedInUser{OrgID: 1, UserID: 2, IsServiceAccount: true},
+			token:        "Bearer glsa_yscW25imSKJIuav8zF37RZmnbiDvB05G_fcaaf58a",
+			expectedErr:  serviceaccounts.ErrTokenSuccessorNotFound.Errorf(""),
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			server := setupTests(t, func(a *ServiceAccountsAPI) {
+				a.service = &satests.FakeServiceAccountService{
+					ExpectedAPIKey:       &apikey.APIKey{ID: 2, Name: "token"},
+					ExpectedSuccessorKey: "glsa_new",
+					ExpectedErr:          tt.expectedErr,
+				}
+			})
+
+			req := server.NewRequest(http.MethodPost, "/api/serviceaccounts/tokens/successor", nil)
+			req.Header.Set("Authorization", tt.token)
+			webtest.RequestWithSignedInUser(req, tt.user)
+			res, err := server.SendJSON(req)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
+
 func TestServiceAccountsAPI_DeleteToken(t *testing.T) {
 	type TestCase struct {
 		desc         string
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/services/apikey"
@@ -13,6 +14,8 @@ import (
 
 const maxRetrievedTokens = 300
 
+var timeNow = time.Now
+
 func (s *ServiceAccountsStoreImpl) ListTokens(
 	ctx context.Context, query *serviceaccounts.GetSATokensQuery,
 ) ([]apikey.APIKey, error) {
@@ -56,6 +59,9 @@ func (s *ServiceAccountsStoreImpl) AddServiceAccountToken(ctx context.Context, s
 			Key:              cmd.Key,
 			SecondsToLive:    cmd.SecondsToLive,
 			ServiceAccountID: &serviceAccountId,
+			Permissions:      cmd.Permissions,
+			AllowedCIDRs:     cmd.AllowedCIDRs,
+			AutoRotate:       cmd.AutoRotate,
 		}
 
 		key, err := s.apiKeyService.AddAPIKey(ctx, addKeyCmd)
@@ -75,6 +81,134 @@ func (s *ServiceAccountsStoreImpl) AddServiceAccountToken(ctx context.Context, s
 	})
 }
 
+// RotateServiceAccountToken creates a new token with the name, restrictions and automatic rotation of an existing one.
+// The existing token is renamed and expires once the overlap window has passed. The encrypted secret of the new token
+// is kept with the existing token when it is rotated automatically, so that its clients can fetch it.
+func (s *ServiceAccountsStoreImpl) RotateServiceAccountToken(ctx context.Context, serviceAccountId, tokenId int64, cmd *serviceaccounts.RotateServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	var apiKey *apikey.APIKey
+
+	err := s.sqlStore.InTransaction(ctx, func(ctx context.Context) error {
+		var old apikey.APIKey
+		err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			has, err := sess.Where("id=? AND org_id=? AND service_account_id=?", tokenId, cmd.OrgId, serviceAccountId).Get(&old)
+			if err != nil {
+				return err
+			}
+			if !has || (old.IsRevoked != nil && *old.IsRevoked) {
+				return serviceaccounts.ErrServiceAccountTokenNotFound.Errorf("service account token with id %d not found for service account with id %d", tokenId, serviceAccountId)
+			}
+
+			now := timeNow()
+			overlapEnd := now.Add(time.Duration(cmd.OverlapSeconds) * time.Second).Unix()
+			if old.Expires == nil || *old.Expires > overlapEnd {
+				old.Expires = &overlapEnd
+			}
+			name, autoRotate := old.Name, old.AutoRotate
+			old.Name = fmt.Sprintf("%s-rotated-%d", name, now.Unix())
+			old.Updated = now
+			old.AutoRotate = new(bool)
+			if _, err := sess.ID(old.ID).Cols("name", "expires", "updated", "auto_rotate").Update(&old); err != nil {
+				return err
+			}
+			old.Name, old.AutoRotate = name, autoRotate
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		key, err := s.apiKeyService.AddAPIKey(ctx, &apikey.AddCommand{
+			Name:             old.Name,
+			Role:             org.RoleViewer,
+			OrgID:            cmd.OrgId,
+			Key:              cmd.Key,
+			SecondsToLive:    cmd.SecondsToLive,
+			ServiceAccountID: &serviceAccountId,
+			Permissions:      old.Permissions,
+			AllowedCIDRs:     old.AllowedCIDRs,
+			AutoRotate:       old.AutoRotate != nil && *old.AutoRotate,
+		})
+		if err != nil {
+			if errors.Is(err, apikey.ErrInvalidExpiration) {
+				return serviceaccounts.ErrInvalidTokenExpiration.Errorf("invalid service account token expiration value %d", cmd.SecondsToLive)
+			}
+			return err
+		}
+
+		if len(cmd.SuccessorKey) > 0 {
+			err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+				_, err := sess.Exec("UPDATE api_key SET successor_id = ?, successor_key = ? WHERE id = ?", key.ID, cmd.SuccessorKey, old.ID)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		apiKey = key
+		return nil
+	})
+	return apiKey, err
+}
+
+// ListTokensToRotate returns the service account tokens with automatic rotation that were created before the given
+// time and have neither expired nor been revoked.
+func (s *ServiceAccountsStoreImpl) ListTokensToRotate(ctx context.Context, createdBefore time.Time) ([]apikey.APIKey, error) {
+	result := make([]apikey.APIKey, 0)
+	err := s.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		dialect := s.sqlStore.GetDialect()
+		return sess.Limit(maxRetrievedTokens, 0).
+			Where("service_account_id IS NOT NULL AND auto_rotate = ?", dialect.BooleanStr(true)).
+			Where("is_revoked IS NULL OR is_revoked = ?", dialect.BooleanStr(false)).
+			Where("expires IS NULL OR expires > ?", timeNow().Unix()).
+			Where("created < ?", createdBefore).
+			Asc("created").
+			Find(&result)
+	})
+	return result, err
+}
+
+// TakeServiceAccountTokenSuccessor returns the token that replaced the token with the given hash in an automatic
+// rotation, with the encrypted secret of the successor. The secret is handed out only once.
+func (s *ServiceAccountsStoreImpl) TakeServiceAccountTokenSuccessor(ctx context.Context, orgID int64, keyHash string) (*apikey.APIKey, []byte, error) {
+	var successor apikey.APIKey
+	var secret []byte
+
+	err := s.sqlStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		var old apikey.APIKey
+		has, err := sess.Where("org_id=? AND "+s.sqlStore.GetDialect().Quote("key")+"=? AND service_account_id IS NOT NULL", orgID, keyHash).Get(&old)
+		if err != nil {
+			return err
+		}
+		if !has || old.SuccessorID == nil || len(old.SuccessorKey) == 0 {
+			return serviceaccounts.ErrTokenSuccessorNotFound.Errorf("service account token has no successor")
+		}
+
+		res, err := sess.Exec("UPDATE api_key SET successor_key = NULL WHERE id = ? AND successor_key IS NOT NULL", old.ID)
+		if err != nil {
+			return err
+		}
+		// another request has taken the successor first
+		if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+			return serviceaccounts.ErrTokenSuccessorNotFound.Errorf("service account token has no successor")
+		}
+
+		has, err = sess.ID(*old.SuccessorID).Get(&successor)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return serviceaccounts.ErrTokenSuccessorNotFound.Errorf("successor %d of service account token %d not found", *old.SuccessorID, old.ID)
+		}
+		secret = old.SuccessorKey
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &successor, secret, nil
+}
+
 func (s *ServiceAccountsStoreImpl) DeleteServiceAccountToken(ctx context.Context, orgId, serviceAccountId, tokenId int64) error {
 	rawSQL := "DELETE FROM api_key WHERE id=? and org_id=? and service_account_id=?"
 
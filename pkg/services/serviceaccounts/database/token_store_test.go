@@ -3,10 +3,12 @@ package database
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/components/apikeygen"
+	"github.com/grafana/grafana/pkg/services/apikey"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts/tests"
 )
@@ -176,3 +178,131 @@ func TestStore_DeleteServiceAccountToken(t *testing.T) {
 		}
 	}
 }
+
+func TestStore_RotateServiceAccountToken(t *testing.T) {
+	userToCreate := tests.TestUser{Login: "servicetestwithTeam@admin", IsServiceAccount: true}
+	db, store := setupTestDatabase(t)
+	sa := tests.SetupUserServiceAccount(t, db, userToCreate)
+
+	keyName := t.Name()
+	key, err := apikeygen.New(sa.OrgID, keyName)
+	require.NoError(t, err)
+
+	cmd := serviceaccounts.AddServiceAccountTokenCommand{
+		Name:         keyName,
+		OrgId:        sa.OrgID,
+		Key:          key.HashedKey,
+		Permissions:  apikey.PermissionList{{Action: "dashboards:read", Scope: "dashboards:*"}},
+		AllowedCIDRs: apikey.CIDRList{"10.0.0.0/8"},
+	}
+
+	oldKey, err := store.AddServiceAccountToken(context.Background(), sa.ID, &cmd)
+	require.NoError(t, err)
+
+	newHash, err := apikeygen.New(sa.OrgID, keyName)
+	require.NoError(t, err)
+
+	// Rotate a token of another service account
+	_, err = store.RotateServiceAccountToken(context.Background(), sa.ID+1, oldKey.ID, &serviceaccounts.RotateServiceAccountTokenCommand{
+		OrgId: sa.OrgID,
+		Key:   newHash.HashedKey,
+	})
+	require.ErrorIs(t, err, serviceaccounts.ErrServiceAccountTokenNotFound)
+
+	newKey, err := store.RotateServiceAccountToken(context.Background(), sa.ID, oldKey.ID, &serviceaccounts.RotateServiceAccountTokenCommand{
+		OrgId:          sa.OrgID,
+		Key:            newHash.HashedKey,
+		OverlapSeconds: 60,
+	})
+	require.NoError(t, err)
+	require.Equal(t, keyName, newKey.Name)
+	require.Equal(t, cmd.Permissions, newKey.Permissions)
+	require.Equal(t, cmd.AllowedCIDRs, newKey.AllowedCIDRs)
+
+	// Verify against DB
+	keys, err := store.ListTokens(context.Background(), &serviceaccounts.GetSATokensQuery{
+		OrgID:            &sa.OrgID,
+		ServiceAccountID: &sa.ID,
+	})
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+
+	for _, k := range keys {
+		if k.ID == oldKey.ID {
+			require.NotEqual(t, keyName, k.Name)
+			require.NotNil(t, k.Expires)
+			require.LessOrEqual(t, *k.Expires, time.Now().Add(time.Minute).Unix())
+			continue
+		}
+		require.Equal(t, newKey.ID, k.ID)
+		require.Nil(t, k.Expires)
+		require.Equal(t, cmd.Permissions, k.Permissions)
+	}
+}
+
+func TestStore_RotateServiceAccountTokenAutomatically(t *testing.T) {
+	userToCreate := tests.TestUser{Login: "servicetestwithTeam@admin", IsServiceAccount: true}
+	db, store := setupTestDatabase(t)
+	sa := tests.SetupUserServiceAccount(t, db, userToCreate)
+
+	keyName := t.Name()
+	key, err := apikeygen.New(sa.OrgID, keyName)
+	require.NoError(t, err)
+
+	oldKey, err := store.AddServiceAccountToken(context.Background(), sa.ID, &serviceaccounts.AddServiceAccountTokenCommand{
+		Name:       keyName,
+		OrgId:      sa.OrgID,
+		Key:        key.HashedKey,
+		AutoRotate: true,
+	})
+	require.NoError(t, err)
+
+	manual, err := apikeygen.New(sa.OrgID, keyName+"-manual")
+	require.NoError(t, err)
+	_, err = store.AddServiceAccountToken(context.Background(), sa.ID, &serviceaccounts.AddServiceAccountTokenCommand{
+		Name:  keyName + "-manual",
+		OrgId: sa.OrgID,
+		Key:   manual.HashedKey,
+	})
+	require.NoError(t, err)
+
+	// Tokens created after the given time are not due
+	toRotate, err := store.ListTokensToRotate(context.Background(), oldKey.Created.Add(-time.Minute))
+	require.NoError(t, err)
+	require.Empty(t, toRotate)
+
+	toRotate, err = store.ListTokensToRotate(context.Background(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, toRotate, 1)
+	require.Equal(t, oldKey.ID, toRotate[0].ID)
+
+	// Only a rotated token has a successor
+	_, _, err = store.TakeServiceAccountTokenSuccessor(context.Background(), sa.OrgID, key.HashedKey)
+	require.ErrorIs(t, err, serviceaccounts.ErrTokenSuccessorNotFound)
+
+	newHash, err := apikeygen.New(sa.OrgID, keyName)
+	require.NoError(t, err)
+	newKey, err := store.RotateServiceAccountToken(context.Background(), sa.ID, oldKey.ID, &serviceaccounts.RotateServiceAccountTokenCommand{
+		OrgId:          sa.OrgID,
+		Key:            newHash.HashedKey,
+		OverlapSeconds: 60,
+		SuccessorKey:   []byte("encrypted"),
+	})
+	require.NoError(t, err)
+	require.True(t, *newKey.AutoRotate)
+
+	// The new token is due once it is old enough, the rotated one is not due anymore
+	toRotate, err = store.ListTokensToRotate(context.Background(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, toRotate, 1)
+	require.Equal(t, newKey.ID, toRotate[0].ID)
+
+	successor, secret, err := store.TakeServiceAccountTokenSuccessor(context.Background(), sa.OrgID, key.HashedKey)
+	require.NoError(t, err)
+	require.Equal(t, newKey.ID, successor.ID)
+	require.Equal(t, []byte("encrypted"), secret)
+
+	// The successor is handed out only once
+	_, _, err = store.TakeServiceAccountTokenSuccessor(context.Background(), sa.OrgID, key.HashedKey)
+	require.ErrorIs(t, err, serviceaccounts.ErrTokenSuccessorNotFound)
+}
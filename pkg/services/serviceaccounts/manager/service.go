@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/grafana/grafana/pkg/components/satokengen"
 	"github.com/grafana/grafana/pkg/infra/kvstore"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/apikey"
 	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts/database"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts/secretscan"
@@ -21,8 +23,11 @@ import (
 )
 
 const (
-	metricsCollectionInterval = time.Minute * 30
-	defaultSecretScanInterval = time.Minute * 5
+	metricsCollectionInterval  = time.Minute * 30
+	defaultSecretScanInterval  = time.Minute * 5
+	tokenRotationCheckInterval = time.Minute * 10
+	// tokenServiceID is the prefix of the service account tokens, the same as the one of the tokens created in the API.
+	tokenServiceID = "sa"
 )
 
 type ServiceAccountsService struct {
@@ -30,9 +35,13 @@ type ServiceAccountsService struct {
 	log               log.Logger
 	backgroundLog     log.Logger
 	secretScanService secretscan.Checker
+	secretsService    secrets.Service
 
 	secretScanEnabled  bool
 	secretScanInterval time.Duration
+
+	tokenRotationInterval time.Duration
+	tokenRotationOverlap  time.Duration
 }
 
 func ProvideServiceAccountsService(
@@ -44,6 +53,7 @@ func ProvideServiceAccountsService(
 	userService user.Service,
 	orgService org.Service,
 	accesscontrolService accesscontrol.Service,
+	secretsService secrets.Service,
 ) (*ServiceAccountsService, error) {
 	serviceAccountsStore := database.ProvideServiceAccountsStore(
 		cfg,
@@ -54,9 +64,13 @@ func ProvideServiceAccountsService(
 		orgService,
 	)
 	s := &ServiceAccountsService{
-		store:         serviceAccountsStore,
-		log:           log.New("serviceaccounts"),
-		backgroundLog: log.New("serviceaccounts.background"),
+		store:          serviceAccountsStore,
+		log:            log.New("serviceaccounts"),
+		backgroundLog:  log.New("serviceaccounts.background"),
+		secretsService: secretsService,
+
+		tokenRotationInterval: cfg.SATokenRotationInterval,
+		tokenRotationOverlap:  cfg.SATokenRotationOverlap,
 	}
 
 	if err := RegisterRoles(accesscontrolService); err != nil {
@@ -112,6 +126,17 @@ func (sa *ServiceAccountsService) Run(ctx context.Context) error {
 		defer tokenCheckTicker.Stop()
 	}
 
+	tokenRotationTicker := time.NewTicker(tokenRotationCheckInterval)
+
+	if sa.tokenRotationInterval <= 0 {
+		tokenRotationTicker.Stop()
+	} else {
+		sa.backgroundLog.Debug("Enabled automatic token rotation and executing first rotation")
+		sa.rotateTokens(ctx)
+
+		defer tokenRotationTicker.Stop()
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -134,10 +159,60 @@ func (sa *ServiceAccountsService) Run(ctx context.Context) error {
 			if err := sa.secretScanService.CheckTokens(ctx); err != nil {
 				sa.backgroundLog.Warn("Failed to check for leaked tokens", "error", err.Error())
 			}
+		case <-tokenRotationTicker.C:
+			sa.backgroundLog.Debug("Rotating tokens")
+
+			sa.rotateTokens(ctx)
 		}
 	}
 }
 
+// rotateTokens rotates the tokens with automatic rotation that are older than the rotation interval.
+// The new secret is encrypted and kept with the rotated token, which keeps working for the rotation overlap
+// so that its clients can fetch the new token.
+func (sa *ServiceAccountsService) rotateTokens(ctx context.Context) {
+	tokens, err := sa.store.ListTokensToRotate(ctx, time.Now().Add(-sa.tokenRotationInterval))
+	if err != nil {
+		sa.backgroundLog.Warn("Failed to list tokens to rotate", "error", err.Error())
+		return
+	}
+
+	for _, token := range tokens {
+		if err := sa.rotateToken(ctx, token); err != nil {
+			sa.backgroundLog.Warn("Failed to rotate token", "tokenID", token.ID, "orgID", token.OrgID, "error", err.Error())
+			continue
+		}
+		sa.backgroundLog.Info("Rotated token", "tokenID", token.ID, "orgID", token.OrgID)
+	}
+}
+
+func (sa *ServiceAccountsService) rotateToken(ctx context.Context, token apikey.APIKey) error {
+	newKeyInfo, err := satokengen.New(tokenServiceID)
+	if err != nil {
+		return err
+	}
+
+	successorKey, err := sa.secretsService.Encrypt(ctx, []byte(newKeyInfo.ClientSecret), secrets.WithoutScope())
+	if err != nil {
+		return err
+	}
+
+	// the new token lives as long as the rotated one did
+	var secondsToLive int64
+	if token.Expires != nil {
+		secondsToLive = *token.Expires - token.Created.Unix()
+	}
+
+	_, err = sa.store.RotateServiceAccountToken(ctx, *token.ServiceAccountId, token.ID, &serviceaccounts.RotateServiceAccountTokenCommand{
+		OrgId:          token.OrgID,
+		Key:            newKeyInfo.HashedKey,
+		SecondsToLive:  secondsToLive,
+		OverlapSeconds: int64(sa.tokenRotationOverlap.Seconds()),
+		SuccessorKey:   successorKey,
+	})
+	return err
+}
+
 var _ serviceaccounts.Service = (*ServiceAccountsService)(nil)
 
 func (sa *ServiceAccountsService) CreateServiceAccount(ctx context.Context, orgID int64, saForm *serviceaccounts.CreateServiceAccountForm) (*serviceaccounts.ServiceAccountDTO, error) {
@@ -213,9 +288,48 @@ func (sa *ServiceAccountsService) AddServiceAccountToken(ctx context.Context, se
 	if err := validServiceAccountID(serviceAccountID); err != nil {
 		return nil, err
 	}
+	if err := validTokenRestrictions(query.Permissions, query.AllowedCIDRs); err != nil {
+		return nil, err
+	}
+	if query.AutoRotate && sa.tokenRotationInterval <= 0 {
+		return nil, serviceaccounts.ErrTokenAutoRotationDisabled.Errorf("automatic rotation requested with token_rotation_interval not set")
+	}
 	return sa.store.AddServiceAccountToken(ctx, serviceAccountID, query)
 }
 
+func (sa *ServiceAccountsService) RotateServiceAccountToken(ctx context.Context, serviceAccountID, tokenID int64, cmd *serviceaccounts.RotateServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	if err := validOrgID(cmd.OrgId); err != nil {
+		return nil, err
+	}
+	if err := validServiceAccountID(serviceAccountID); err != nil {
+		return nil, err
+	}
+	if err := validServiceAccountTokenID(tokenID); err != nil {
+		return nil, err
+	}
+	if cmd.OverlapSeconds < 0 {
+		return nil, serviceaccounts.ErrInvalidTokenOverlap.Errorf("invalid service account token overlap value %d", cmd.OverlapSeconds)
+	}
+	return sa.store.RotateServiceAccountToken(ctx, serviceAccountID, tokenID, cmd)
+}
+
+// TakeServiceAccountTokenSuccessor returns the token that replaced the token with the given hash in an automatic
+// rotation, with its secret. The secret can be taken only once.
+func (sa *ServiceAccountsService) TakeServiceAccountTokenSuccessor(ctx context.Context, orgID int64, keyHash string) (*apikey.APIKey, string, error) {
+	if err := validOrgID(orgID); err != nil {
+		return nil, "", err
+	}
+	successor, successorKey, err := sa.store.TakeServiceAccountTokenSuccessor(ctx, orgID, keyHash)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := sa.secretsService.Decrypt(ctx, successorKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return successor, string(secret), nil
+}
+
 func (sa *ServiceAccountsService) DeleteServiceAccountToken(ctx context.Context, orgID, serviceAccountID int64, tokenID int64) error {
 	if err := validOrgID(orgID); err != nil {
 		return err
@@ -263,6 +377,17 @@ func validServiceAccountTokenID(tokenID int64) error {
 	}
 	return nil
 }
+func validTokenRestrictions(permissions apikey.PermissionList, cidrs apikey.CIDRList) error {
+	for _, p := range permissions {
+		if p.Action == "" {
+			return serviceaccounts.ErrInvalidTokenRestriction.Errorf("permission without action specified")
+		}
+	}
+	if err := cidrs.Validate(); err != nil {
+		return serviceaccounts.ErrInvalidTokenRestriction.Errorf("invalid allowed CIDRs: %w", err)
+	}
+	return nil
+}
 func validAPIKeyID(apiKeyID int64) error {
 	if apiKeyID == 0 {
 		return serviceaccounts.ErrServiceAccountInvalidAPIKeyID.Errorf("invalid API key ID 0 has been specified")
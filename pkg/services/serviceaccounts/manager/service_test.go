@@ -3,12 +3,15 @@ package manager
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/components/satokengen"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/apikey"
 	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/secrets/fakes"
 	"github.com/grafana/grafana/pkg/services/serviceaccounts"
 )
 
@@ -22,7 +25,10 @@ type FakeServiceAccountStore struct {
 	ExpectedAPIKeys                         []apikey.APIKey
 	ExpectedAPIKey                          *apikey.APIKey
 	ExpectedBoolean                         bool
+	ExpectedSuccessorKey                    []byte
 	ExpectedError                           error
+
+	RotateCalls []*serviceaccounts.RotateServiceAccountTokenCommand
 }
 
 var _ store = (*FakeServiceAccountStore)(nil)
@@ -87,6 +93,16 @@ func (f *FakeServiceAccountStore) ListTokens(ctx context.Context, query *service
 	return f.ExpectedAPIKeys, f.ExpectedError
 }
 
+// ListTokensToRotate is a fake listing tokens to rotate.
+func (f *FakeServiceAccountStore) ListTokensToRotate(ctx context.Context, createdBefore time.Time) ([]apikey.APIKey, error) {
+	return f.ExpectedAPIKeys, f.ExpectedError
+}
+
+// TakeServiceAccountTokenSuccessor is a fake taking the successor of a service account token.
+func (f *FakeServiceAccountStore) TakeServiceAccountTokenSuccessor(ctx context.Context, orgID int64, keyHash string) (*apikey.APIKey, []byte, error) {
+	return f.ExpectedAPIKey, f.ExpectedSuccessorKey, f.ExpectedError
+}
+
 // RevokeServiceAccountToken is a fake revoking a service account token.
 func (f *FakeServiceAccountStore) RevokeServiceAccountToken(ctx context.Context, orgId, serviceAccountId, tokenId int64) error {
 	return f.ExpectedError
@@ -102,6 +118,12 @@ func (f *FakeServiceAccountStore) DeleteServiceAccountToken(ctx context.Context,
 	return f.ExpectedError
 }
 
+// RotateServiceAccountToken is a fake rotating a service account token.
+func (f *FakeServiceAccountStore) RotateServiceAccountToken(ctx context.Context, serviceAccountID, tokenID int64, cmd *serviceaccounts.RotateServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	f.RotateCalls = append(f.RotateCalls, cmd)
+	return f.ExpectedAPIKey, f.ExpectedError
+}
+
 // GetUsageMetrics is a fake getting usage metrics.
 func (f *FakeServiceAccountStore) GetUsageMetrics(ctx context.Context) (*serviceaccounts.Stats, error) {
 	return f.ExpectedStats, f.ExpectedError
@@ -117,7 +139,12 @@ func (f *SecretsCheckerFake) CheckTokens(ctx context.Context) error {
 
 func TestProvideServiceAccount_DeleteServiceAccount(t *testing.T) {
 	storeMock := newServiceAccountStoreFake()
-	svc := ServiceAccountsService{storeMock, log.New("test"), log.New("background.test"), &SecretsCheckerFake{}, false, 0}
+	svc := ServiceAccountsService{
+		store:             storeMock,
+		log:               log.New("test"),
+		backgroundLog:     log.New("background.test"),
+		secretScanService: &SecretsCheckerFake{},
+	}
 	testOrgId := 1
 
 	t.Run("should create service account", func(t *testing.T) {
@@ -144,3 +171,60 @@ func TestProvideServiceAccount_DeleteServiceAccount(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestServiceAccountsService_RotateTokens(t *testing.T) {
+	storeMock := newServiceAccountStoreFake()
+	svc := ServiceAccountsService{
+		store:                 storeMock,
+		log:                   log.New("test"),
+		backgroundLog:         log.New("background.test"),
+		secretsService:        fakes.NewFakeSecretsService(),
+		tokenRotationInterval: 30 * 24 * time.Hour,
+		tokenRotationOverlap:  time.Hour,
+	}
+
+	saID := int64(2)
+	created := time.Now().Add(-31 * 24 * time.Hour)
+	expires := created.Add(90 * 24 * time.Hour).Unix()
+	storeMock.ExpectedAPIKeys = []apikey.APIKey{
+		{ID: 1, OrgID: 1, ServiceAccountId: &saID, Created: created, Expires: &expires},
+		{ID: 3, OrgID: 1, ServiceAccountId: &saID, Created: created},
+	}
+
+	svc.rotateTokens(context.Background())
+
+	require.Len(t, storeMock.RotateCalls, 2)
+	for _, cmd := range storeMock.RotateCalls {
+		require.Equal(t, int64(1), cmd.OrgId)
+		require.Equal(t, int64(3600), cmd.OverlapSeconds)
+		require.NotEmpty(t, cmd.Key)
+		require.NotEmpty(t, cmd.SuccessorKey)
+	}
+	require.Equal(t, int64(90*24*3600), storeMock.RotateCalls[0].SecondsToLive)
+	require.Zero(t, storeMock.RotateCalls[1].SecondsToLive)
+
+	t.Run("should take the successor secret", func(t *testing.T) {
+		storeMock.ExpectedAPIKey = &apikey.APIKey{ID: 4}
+		storeMock.ExpectedSuccessorKey = storeMock.RotateCalls[0].SuccessorKey
+
+		successor, secret, err := svc.TakeServiceAccountTokenSuccessor(context.Background(), 1, "hash")
+		require.NoError(t, err)
+		require.Equal(t, int64(4), successor.ID)
+		decoded, err := satokengen.Decode(secret)
+		require.NoError(t, err)
+		hash, err := decoded.Hash()
+		require.NoError(t, err)
+		require.Equal(t, storeMock.RotateCalls[0].Key, hash)
+	})
+}
+
+func TestServiceAccountsService_AddServiceAccountTokenAutoRotate(t *testing.T) {
+	svc := ServiceAccountsService{store: newServiceAccountStoreFake()}
+
+	_, err := svc.AddServiceAccountToken(context.Background(), 1, &serviceaccounts.AddServiceAccountTokenCommand{OrgId: 1, Name: "token", AutoRotate: true})
+	require.ErrorIs(t, err, serviceaccounts.ErrTokenAutoRotationDisabled)
+
+	svc.tokenRotationInterval = time.Hour
+	_, err = svc.AddServiceAccountToken(context.Background(), 1, &serviceaccounts.AddServiceAccountTokenCommand{OrgId: 1, Name: "token", AutoRotate: true})
+	require.NoError(t, err)
+}
@@ -13,7 +13,14 @@ import (
 
 func Test_UsageStats(t *testing.T) {
 	storeMock := newServiceAccountStoreFake()
-	svc := ServiceAccountsService{storeMock, log.New("test"), log.New("background-test"), &SecretsCheckerFake{}, true, 5}
+	svc := ServiceAccountsService{
+		store:              storeMock,
+		log:                log.New("test"),
+		backgroundLog:      log.New("background-test"),
+		secretScanService:  &SecretsCheckerFake{},
+		secretScanEnabled:  true,
+		secretScanInterval: 5,
+	}
 	err := svc.DeleteServiceAccount(context.Background(), 1, 1)
 	require.NoError(t, err)
 
@@ -5,6 +5,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/models/roletype"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/apikey"
 	"github.com/grafana/grafana/pkg/services/auth/identity"
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/util/errutil"
@@ -41,6 +42,10 @@ var (
 	ErrServiceAccountTokenNotFound       = errutil.NotFound("serviceaccounts.ErrTokenNotFound", errutil.WithPublicMessage("service account token not found"))
 	ErrInvalidTokenExpiration            = errutil.ValidationFailed("serviceaccounts.ErrInvalidInput", errutil.WithPublicMessage("invalid SecondsToLive value"))
 	ErrDuplicateToken                    = errutil.BadRequest("serviceaccounts.ErrTokenAlreadyExists", errutil.WithPublicMessage("service account token with given name already exists in the organization"))
+	ErrInvalidTokenRestriction           = errutil.ValidationFailed("serviceaccounts.ErrInvalidTokenRestriction", errutil.WithPublicMessage("invalid service account token permissions or allowed CIDRs"))
+	ErrInvalidTokenOverlap               = errutil.ValidationFailed("serviceaccounts.ErrInvalidTokenOverlap", errutil.WithPublicMessage("invalid OverlapSeconds value"))
+	ErrTokenAutoRotationDisabled         = errutil.ValidationFailed("serviceaccounts.ErrTokenAutoRotationDisabled", errutil.WithPublicMessage("automatic rotation of service account tokens is not enabled"))
+	ErrTokenSuccessorNotFound            = errutil.NotFound("serviceaccounts.ErrTokenSuccessorNotFound", errutil.WithPublicMessage("the token has not been rotated automatically or its successor has been fetched already"))
 )
 
 type MigrationResult struct {
@@ -106,6 +111,26 @@ type AddServiceAccountTokenCommand struct {
 	OrgId         int64  `json:"-"`
 	Key           string `json:"-"`
 	SecondsToLive int64  `json:"secondsToLive"`
+	// Permissions restricts the token to a subset of the permissions of the service account.
+	Permissions apikey.PermissionList `json:"permissions,omitempty"`
+	// AllowedCIDRs restricts the addresses the token can be used from.
+	AllowedCIDRs apikey.CIDRList `json:"allowedCidrs,omitempty"`
+	// AutoRotate lets Grafana rotate the token once it reaches the token_rotation_interval.
+	AutoRotate bool `json:"autoRotate,omitempty"`
+}
+
+// RotateServiceAccountTokenCommand replaces a token with a new one with the same name and restrictions.
+// The rotated token keeps working for OverlapSeconds, so clients can switch to the new token.
+//
+// swagger:model
+type RotateServiceAccountTokenCommand struct {
+	OrgId         int64  `json:"-"`
+	Key           string `json:"-"`
+	SecondsToLive int64  `json:"secondsToLive"`
+	// example: 3600
+	OverlapSeconds int64 `json:"overlapSeconds"`
+	// SuccessorKey is the encrypted secret of the new token of an automatic rotation, kept with the rotated token.
+	SuccessorKey []byte `json:"-"`
 }
 
 type SearchOrgServiceAccountsQuery struct {
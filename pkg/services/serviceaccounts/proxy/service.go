@@ -105,6 +105,21 @@ func (s *ServiceAccountsProxy) DeleteServiceAccountToken(ctx context.Context, or
 	return s.proxiedService.DeleteServiceAccountToken(ctx, orgID, serviceAccountID, tokenID)
 }
 
+func (s *ServiceAccountsProxy) RotateServiceAccountToken(ctx context.Context, serviceAccountID, tokenID int64, cmd *serviceaccounts.RotateServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	if s.isProxyEnabled {
+		sa, err := s.proxiedService.RetrieveServiceAccount(ctx, cmd.OrgId, serviceAccountID)
+		if err != nil {
+			return nil, err
+		}
+
+		if isExternalServiceAccount(sa.Login) {
+			s.log.Error("unable to rotate tokens for external service accounts", "serviceAccountID", serviceAccountID)
+			return nil, extsvcaccounts.ErrCannotCreateToken
+		}
+	}
+	return s.proxiedService.RotateServiceAccountToken(ctx, serviceAccountID, tokenID, cmd)
+}
+
 func (s *ServiceAccountsProxy) EnableServiceAccount(ctx context.Context, orgID int64, serviceAccountID int64, enable bool) error {
 	if s.isProxyEnabled {
 		sa, err := s.proxiedService.RetrieveServiceAccount(ctx, orgID, serviceAccountID)
@@ -123,6 +138,10 @@ func (s *ServiceAccountsProxy) ListTokens(ctx context.Context, query *serviceacc
 	return s.proxiedService.ListTokens(ctx, query)
 }
 
+func (s *ServiceAccountsProxy) TakeServiceAccountTokenSuccessor(ctx context.Context, orgID int64, keyHash string) (*apikey.APIKey, string, error) {
+	return s.proxiedService.TakeServiceAccountTokenSuccessor(ctx, orgID, keyHash)
+}
+
 func (s *ServiceAccountsProxy) MigrateApiKey(ctx context.Context, orgID int64, keyId int64) error {
 	return s.proxiedService.MigrateApiKey(ctx, orgID, keyId)
 }
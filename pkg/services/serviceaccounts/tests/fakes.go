@@ -17,6 +17,7 @@ type FakeServiceAccountService struct {
 	ExpectedServiceAccountID               int64
 	ExpectedServiceAccountProfile          *serviceaccounts.ServiceAccountProfileDTO
 	ExpectedServiceAccountTokens           []apikey.APIKey
+	ExpectedSuccessorKey                   string
 }
 
 var _ serviceaccounts.Service = new(FakeServiceAccountService)
@@ -25,6 +26,10 @@ func (f *FakeServiceAccountService) AddServiceAccountToken(ctx context.Context,
 	return f.ExpectedAPIKey, f.ExpectedErr
 }
 
+func (f *FakeServiceAccountService) RotateServiceAccountToken(ctx context.Context, id, tokenID int64, cmd *serviceaccounts.RotateServiceAccountTokenCommand) (*apikey.APIKey, error) {
+	return f.ExpectedAPIKey, f.ExpectedErr
+}
+
 func (f *FakeServiceAccountService) CreateServiceAccount(ctx context.Context, orgID int64, saForm *serviceaccounts.CreateServiceAccountForm) (*serviceaccounts.ServiceAccountDTO, error) {
 	return f.ExpectedServiceAccount, f.ExpectedErr
 }
@@ -53,6 +58,10 @@ func (f *FakeServiceAccountService) ListTokens(ctx context.Context, query *servi
 	return f.ExpectedServiceAccountTokens, f.ExpectedErr
 }
 
+func (f *FakeServiceAccountService) TakeServiceAccountTokenSuccessor(ctx context.Context, orgID int64, keyHash string) (*apikey.APIKey, string, error) {
+	return f.ExpectedAPIKey, f.ExpectedSuccessorKey, f.ExpectedErr
+}
+
 func (f *FakeServiceAccountService) MigrateApiKey(ctx context.Context, orgID, keyID int64) error {
 	return f.ExpectedErr
 }
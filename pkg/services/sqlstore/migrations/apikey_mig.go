@@ -100,4 +100,30 @@ func addApiKeyMigrations(mg *Migrator) {
 	mg.AddMigration("Add is_revoked column to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
 		Name: "is_revoked", Type: DB_Bool, Nullable: true, Default: "0",
 	}))
+
+	// permissions and allowed_cidrs restrict what a service account token can be used for, stored as JSON lists.
+	mg.AddMigration("Add permissions column to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "permissions", Type: DB_Text, Nullable: true,
+	}))
+
+	mg.AddMigration("Add allowed_cidrs column to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "allowed_cidrs", Type: DB_Text, Nullable: true,
+	}))
+
+	mg.AddMigration("Add last_used_ip column to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "last_used_ip", Type: DB_NVarchar, Length: 255, Nullable: true,
+	}))
+
+	// the successor of an automatically rotated service account token is handed to the clients of the rotated token.
+	mg.AddMigration("Add auto_rotate column to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "auto_rotate", Type: DB_Bool, Nullable: true, Default: "0",
+	}))
+
+	mg.AddMigration("Add successor_id column to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "successor_id", Type: DB_BigInt, Nullable: true,
+	}))
+
+	mg.AddMigration("Add successor_key column to api_key table", NewAddColumnMigration(apiKeyV2, &Column{
+		Name: "successor_key", Type: DB_Blob, Nullable: true,
+	}))
 }
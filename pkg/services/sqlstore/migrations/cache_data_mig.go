@@ -19,4 +19,16 @@ func addCacheMigration(mg *migrator.Migrator) {
 	mg.AddMigration("create cache_data table", migrator.NewAddTableMigration(cacheDataV1))
 
 	mg.AddMigration("add unique index cache_data.cache_key", migrator.NewAddIndexMigration(cacheDataV1, cacheDataV1.Indices[0]))
+
+	var cacheCounterV1 = migrator.Table{
+		Name: "cache_counter",
+		Columns: []*migrator.Column{
+			{Name: "cache_key", Type: migrator.DB_NVarchar, IsPrimaryKey: true, Length: 168},
+			{Name: "counter", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "expires", Type: migrator.DB_Integer, Length: 255, Nullable: false},
+			{Name: "created_at", Type: migrator.DB_Integer, Length: 255, Nullable: false},
+		},
+	}
+
+	mg.AddMigration("create cache_counter table", migrator.NewAddTableMigration(cacheCounterV1))
 }
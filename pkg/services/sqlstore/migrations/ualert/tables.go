@@ -24,9 +24,139 @@ func AddTablesMigrations(mg *migrator.Migrator) {
 	mg.AddMigration("add last_applied column to alert_configuration_history", migrator.NewAddColumnMigration(migrator.Table{Name: "alert_configuration_history"}, &migrator.Column{
 		Name: "last_applied", Type: migrator.DB_Int, Nullable: false, Default: "0",
 	}))
+
+	mg.AddMigration("add updated_by column to alert_rule", migrator.NewAddColumnMigration(migrator.Table{Name: "alert_rule"}, &migrator.Column{
+		Name: "updated_by", Type: migrator.DB_NVarchar, Length: 190, Nullable: true,
+	}))
+
+	mg.AddMigration("add created_by column to alert_rule_version", migrator.NewAddColumnMigration(migrator.Table{Name: "alert_rule_version"}, &migrator.Column{
+		Name: "created_by", Type: migrator.DB_NVarchar, Length: 190, Nullable: true,
+	}))
+
+	addNotificationHistoryMigrations(mg)
+
+	addStateHistoryMigrations(mg)
+
+	mg.AddMigration("add dependencies column to alert_rule", migrator.NewAddColumnMigration(migrator.Table{Name: "alert_rule"}, &migrator.Column{
+		Name: "dependencies", Type: migrator.DB_Text, Nullable: true,
+	}))
+
+	mg.AddMigration("add dependencies column to alert_rule_version", migrator.NewAddColumnMigration(migrator.Table{Name: "alert_rule_version"}, &migrator.Column{
+		Name: "dependencies", Type: migrator.DB_Text, Nullable: true,
+	}))
+
+	addMaintenanceWindowMigrations(mg)
 	// End of migration log, add new migrations above this line.
 }
 
+// addNotificationHistoryMigrations creates the table that keeps the attempts of the Alertmanager to deliver notifications.
+func addNotificationHistoryMigrations(mg *migrator.Migrator) {
+	notificationHistory := migrator.Table{
+		Name: "alert_notification_history",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "timestamp", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "receiver", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "integration", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "integration_index", Type: migrator.DB_Int, Nullable: false},
+			{Name: "group_key", Type: migrator.DB_Text, Nullable: false},
+			{Name: "alert_fingerprints", Type: migrator.DB_Text, Nullable: false},
+			{Name: "alerts_firing", Type: migrator.DB_Int, Nullable: false},
+			{Name: "alerts_resolved", Type: migrator.DB_Int, Nullable: false},
+			{Name: "status", Type: migrator.DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "retry", Type: migrator.DB_Int, Nullable: false},
+			{Name: "error", Type: migrator.DB_Text, Nullable: true},
+			{Name: "duration_ms", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "timestamp"}, Type: migrator.IndexType},
+			{Cols: []string{"org_id", "receiver", "timestamp"}, Type: migrator.IndexType},
+		},
+	}
+	mg.AddMigration("create alert_notification_history table", migrator.NewAddTableMigration(notificationHistory))
+	mg.AddMigration("add index in alert_notification_history on org_id and timestamp", migrator.NewAddIndexMigration(notificationHistory, notificationHistory.Indices[0]))
+	mg.AddMigration("add index in alert_notification_history on org_id, receiver and timestamp", migrator.NewAddIndexMigration(notificationHistory, notificationHistory.Indices[1]))
+}
+
+// addMaintenanceWindowMigrations creates the table that keeps the windows of planned maintenance during which alert rules are not evaluated.
+func addMaintenanceWindowMigrations(mg *migrator.Migrator) {
+	maintenanceWindow := migrator.Table{
+		Name: "alert_maintenance_window",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "title", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "starts_at", Type: migrator.DB_DateTime, Nullable: true},
+			{Name: "ends_at", Type: migrator.DB_DateTime, Nullable: true},
+			{Name: "time_intervals", Type: migrator.DB_Text, Nullable: true},
+			{Name: "namespace_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "rule_group", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "matchers", Type: migrator.DB_Text, Nullable: true},
+			{Name: "updated", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "uid"}, Type: migrator.UniqueIndex},
+		},
+	}
+	mg.AddMigration("create alert_maintenance_window table", migrator.NewAddTableMigration(maintenanceWindow))
+	mg.AddMigration("add unique index in alert_maintenance_window on org_id and uid", migrator.NewAddIndexMigration(maintenanceWindow, maintenanceWindow.Indices[0]))
+}
+
+// addStateHistoryMigrations creates the tables of the SQL state history backend. The labels of alert instances are
+// stored once per label set, and transitions refer to them by the fingerprint of the label set.
+func addStateHistoryMigrations(mg *migrator.Migrator) {
+	stateHistory := migrator.Table{
+		Name: "alert_state_history",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "rule_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "rule_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "rule_title", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "rule_group", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "rule_condition", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "namespace_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "dashboard_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: true},
+			{Name: "panel_id", Type: migrator.DB_BigInt, Nullable: true},
+			{Name: "fingerprint", Type: migrator.DB_NVarchar, Length: 16, Nullable: false},
+			{Name: "timestamp", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "previous_state", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "current_state", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "error", Type: migrator.DB_Text, Nullable: true},
+			{Name: "state_values", Type: migrator.DB_Text, Nullable: true},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "timestamp"}, Type: migrator.IndexType},
+			{Cols: []string{"org_id", "rule_uid", "timestamp"}, Type: migrator.IndexType},
+			{Cols: []string{"org_id", "fingerprint", "timestamp"}, Type: migrator.IndexType},
+		},
+	}
+	mg.AddMigration("create alert_state_history table", migrator.NewAddTableMigration(stateHistory))
+	mg.AddMigration("add index in alert_state_history on org_id and timestamp", migrator.NewAddIndexMigration(stateHistory, stateHistory.Indices[0]))
+	mg.AddMigration("add index in alert_state_history on org_id, rule_uid and timestamp", migrator.NewAddIndexMigration(stateHistory, stateHistory.Indices[1]))
+	mg.AddMigration("add index in alert_state_history on org_id, fingerprint and timestamp", migrator.NewAddIndexMigration(stateHistory, stateHistory.Indices[2]))
+
+	stateHistoryLabel := migrator.Table{
+		Name: "alert_state_history_label",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "fingerprint", Type: migrator.DB_NVarchar, Length: 16, Nullable: false},
+			{Name: "label_key", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "label_value", Type: migrator.DB_Text, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "fingerprint", "label_key"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"org_id", "label_key"}, Type: migrator.IndexType},
+		},
+	}
+	mg.AddMigration("create alert_state_history_label table", migrator.NewAddTableMigration(stateHistoryLabel))
+	mg.AddMigration("add unique index in alert_state_history_label on org_id, fingerprint and label_key", migrator.NewAddIndexMigration(stateHistoryLabel, stateHistoryLabel.Indices[0]))
+	mg.AddMigration("add index in alert_state_history_label on org_id and label_key", migrator.NewAddIndexMigration(stateHistoryLabel, stateHistoryLabel.Indices[1]))
+}
+
 // historicalTableMigrations contains those migrations that existed prior to creating the improved messaging around migration immutability.
 func historicalTableMigrations(mg *migrator.Migrator) {
 	// DO NOT EDIT
@@ -13,8 +13,14 @@ var (
 	ConfigurableOAuthProviders = []string{"github", "gitlab", "google", "generic_oauth", "azuread", "okta"}
 
 	AllOAuthProviders = []string{social.GitHubProviderName, social.GitlabProviderName, social.GoogleProviderName, social.GenericOAuthProviderName, social.GrafanaComProviderName, social.AzureADProviderName, social.OktaProviderName}
+
+	// AllProviders is a list of all the providers the SSO settings are managed for
+	AllProviders = append(append([]string{}, AllOAuthProviders...), SAMLProviderName)
 )
 
+// SAMLProviderName is the name of the SAML provider
+const SAMLProviderName = "saml"
+
 // Service is a SSO settings service
 //
 //go:generate mockery --name Service --structname MockService --outpkg ssosettingstests --filename service_mock.go --output ./ssosettingstests/
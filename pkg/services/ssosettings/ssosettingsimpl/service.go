@@ -43,7 +43,7 @@ func ProvideService(cfg *setting.Cfg, sqlStore db.DB, ac ac.AccessControl,
 	secrets secrets.Service, usageStats usagestats.Service, registerer prometheus.Registerer) *Service {
 	strategies := []ssosettings.FallbackStrategy{
 		strategies.NewOAuthStrategy(cfg),
-		// register other strategies here, for example SAML
+		strategies.NewSAMLStrategy(cfg),
 	}
 
 	store := database.ProvideStore(sqlStore)
@@ -113,14 +113,14 @@ func (s *Service) GetForProviderWithRedactedSecrets(ctx context.Context, provide
 }
 
 func (s *Service) List(ctx context.Context) ([]*models.SSOSettings, error) {
-	result := make([]*models.SSOSettings, 0, len(ssosettings.AllOAuthProviders))
+	result := make([]*models.SSOSettings, 0, len(ssosettings.AllProviders))
 	storedSettings, err := s.store.List(ctx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	for _, provider := range ssosettings.AllOAuthProviders {
+	for _, provider := range ssosettings.AllProviders {
 		dbSettings := getSettingByProvider(provider, storedSettings)
 		if dbSettings != nil {
 			// Settings are coming from the database thus secrets are encrypted
@@ -480,7 +480,8 @@ func isSecret(fieldName string) bool {
 			return true
 		}
 	}
-	return false
+	// the SAML private key can be set inline or as a path, only the inline key is a secret
+	return strings.ToLower(fieldName) == "private_key"
 }
 
 func isNewSecretValue(value string) bool {
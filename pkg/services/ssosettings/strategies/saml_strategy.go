@@ -0,0 +1,74 @@
+package strategies
+
+import (
+	"context"
+	"maps"
+
+	"github.com/grafana/grafana/pkg/services/ssosettings"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type SAMLStrategy struct {
+	cfg      *setting.Cfg
+	settings map[string]any
+}
+
+var _ ssosettings.FallbackStrategy = (*SAMLStrategy)(nil)
+
+func NewSAMLStrategy(cfg *setting.Cfg) *SAMLStrategy {
+	return &SAMLStrategy{
+		cfg:      cfg,
+		settings: loadSAMLSettings(cfg),
+	}
+}
+
+func (s *SAMLStrategy) IsMatch(provider string) bool {
+	return provider == ssosettings.SAMLProviderName
+}
+
+func (s *SAMLStrategy) GetProviderConfig(_ context.Context, provider string) (map[string]any, error) {
+	result := make(map[string]any, len(s.settings))
+	maps.Copy(result, s.settings)
+	return result, nil
+}
+
+func loadSAMLSettings(cfg *setting.Cfg) map[string]any {
+	section := cfg.Raw.Section("auth.saml")
+
+	return map[string]any{
+		"enabled":                    section.Key("enabled").MustBool(false),
+		"name":                       section.Key("name").MustString("SAML"),
+		"single_logout":              section.Key("single_logout").MustBool(false),
+		"allow_sign_up":              section.Key("allow_sign_up").MustBool(true),
+		"auto_login":                 section.Key("auto_login").MustBool(false),
+		"allow_idp_initiated":        section.Key("allow_idp_initiated").MustBool(false),
+		"entity_id":                  section.Key("entity_id").Value(),
+		"certificate":                section.Key("certificate").Value(),
+		"certificate_path":           section.Key("certificate_path").Value(),
+		"private_key":                section.Key("private_key").Value(),
+		"private_key_path":           section.Key("private_key_path").Value(),
+		"signature_algorithm":        section.Key("signature_algorithm").Value(),
+		"idp_metadata":               section.Key("idp_metadata").Value(),
+		"idp_metadata_path":          section.Key("idp_metadata_path").Value(),
+		"idp_metadata_url":           section.Key("idp_metadata_url").Value(),
+		"max_issue_delay":            section.Key("max_issue_delay").MustString("90s"),
+		"metadata_valid_duration":    section.Key("metadata_valid_duration").MustString("48h"),
+		"name_id_format":             section.Key("name_id_format").Value(),
+		"force_authn":                section.Key("force_authn").MustBool(false),
+		"assertion_attribute_name":   section.Key("assertion_attribute_name").MustString("displayName"),
+		"assertion_attribute_login":  section.Key("assertion_attribute_login").MustString("mail"),
+		"assertion_attribute_email":  section.Key("assertion_attribute_email").MustString("mail"),
+		"assertion_attribute_groups": section.Key("assertion_attribute_groups").Value(),
+		"assertion_attribute_role":   section.Key("assertion_attribute_role").Value(),
+		"assertion_attribute_org":    section.Key("assertion_attribute_org").Value(),
+		"allowed_organizations":      section.Key("allowed_organizations").Value(),
+		"org_mapping":                section.Key("org_mapping").Value(),
+		"team_mapping":               section.Key("team_mapping").Value(),
+		"role_values_none":           section.Key("role_values_none").Value(),
+		"role_values_viewer":         section.Key("role_values_viewer").Value(),
+		"role_values_editor":         section.Key("role_values_editor").Value(),
+		"role_values_admin":          section.Key("role_values_admin").Value(),
+		"role_values_grafana_admin":  section.Key("role_values_grafana_admin").Value(),
+		"skip_org_role_sync":         section.Key("skip_org_role_sync").MustBool(false),
+	}
+}
@@ -0,0 +1,54 @@
+package strategies
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestSAMLStrategy_GetProviderConfig(t *testing.T) {
+	iniFile, err := ini.Load([]byte(`
+	[auth.saml]
+	enabled = true
+	single_logout = true
+	idp_metadata_url = https://idp.example.com/metadata
+	certificate_path = /etc/grafana/saml.crt
+	private_key_path = /etc/grafana/saml.key
+	assertion_attribute_role = role
+	org_mapping = Engineering:2:Editor
+	team_mapping = developers:1:Backend
+	`))
+	require.NoError(t, err)
+
+	cfg := setting.NewCfg()
+	cfg.Raw = iniFile
+
+	strategy := NewSAMLStrategy(cfg)
+	require.True(t, strategy.IsMatch("saml"))
+	require.False(t, strategy.IsMatch("generic_oauth"))
+
+	result, err := strategy.GetProviderConfig(context.Background(), "saml")
+	require.NoError(t, err)
+
+	require.Equal(t, true, result["enabled"])
+	require.Equal(t, true, result["single_logout"])
+	require.Equal(t, "SAML", result["name"])
+	require.Equal(t, "https://idp.example.com/metadata", result["idp_metadata_url"])
+	require.Equal(t, "/etc/grafana/saml.crt", result["certificate_path"])
+	require.Equal(t, "/etc/grafana/saml.key", result["private_key_path"])
+	require.Equal(t, "role", result["assertion_attribute_role"])
+	require.Equal(t, "mail", result["assertion_attribute_email"])
+	require.Equal(t, "Engineering:2:Editor", result["org_mapping"])
+	require.Equal(t, "developers:1:Backend", result["team_mapping"])
+	require.Equal(t, "90s", result["max_issue_delay"])
+
+	// the returned map is a copy
+	result["enabled"] = false
+	result, err = strategy.GetProviderConfig(context.Background(), "saml")
+	require.NoError(t, err)
+	require.Equal(t, true, result["enabled"])
+}
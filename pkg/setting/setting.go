@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -111,6 +112,7 @@ type Cfg struct {
 	EnableGzip       bool
 	EnforceDomain    bool
 	MinTLSVersion    string
+	TrustedProxies   []*net.IPNet
 
 	// Security settings
 	SecretKey             string
@@ -220,20 +222,26 @@ type Cfg struct {
 	LoginMaxInactiveLifetime     time.Duration
 	LoginMaxLifetime             time.Duration
 	TokenRotationIntervalMinutes int
-	SigV4AuthEnabled             bool
-	SigV4VerboseLogging          bool
-	AzureAuthEnabled             bool
-	AzureSkipOrgRoleSync         bool
-	BasicAuthEnabled             bool
-	AdminUser                    string
-	AdminPassword                string
-	DisableLogin                 bool
-	AdminEmail                   string
-	DisableLoginForm             bool
-	SignoutRedirectUrl           string
-	IDResponseHeaderEnabled      bool
-	IDResponseHeaderPrefix       string
-	IDResponseHeaderNamespaces   map[string]struct{}
+	// LoginMaxConcurrentSessions is the number of sessions a user can have, the oldest ones are revoked. 0 is unlimited.
+	LoginMaxConcurrentSessions int
+	// LoginMaxInactiveLifetimeByRole overrides LoginMaxInactiveLifetime for the roles viewer, editor, admin and grafana_admin.
+	LoginMaxInactiveLifetimeByRole map[string]time.Duration
+	// ReauthenticationMaxAge is how long after login users can perform sensitive admin actions. 0 disables it.
+	ReauthenticationMaxAge     time.Duration
+	SigV4AuthEnabled           bool
+	SigV4VerboseLogging        bool
+	AzureAuthEnabled           bool
+	AzureSkipOrgRoleSync       bool
+	BasicAuthEnabled           bool
+	AdminUser                  string
+	AdminPassword              string
+	DisableLogin               bool
+	AdminEmail                 string
+	DisableLoginForm           bool
+	SignoutRedirectUrl         string
+	IDResponseHeaderEnabled    bool
+	IDResponseHeaderPrefix     string
+	IDResponseHeaderNamespaces map[string]struct{}
 	// Not documented & not supported
 	// stand in until a more complete solution is implemented
 	AuthConfigUIAdminAccess bool
@@ -291,19 +299,22 @@ type Cfg struct {
 	SSOSettingsConfigurableProviders map[string]bool
 
 	// Dataproxy
-	SendUserHeader                 bool
-	DataProxyLogging               bool
-	DataProxyTimeout               int
-	DataProxyDialTimeout           int
-	DataProxyTLSHandshakeTimeout   int
-	DataProxyExpectContinueTimeout int
-	DataProxyMaxConnsPerHost       int
-	DataProxyMaxIdleConns          int
-	DataProxyKeepAlive             int
-	DataProxyIdleConnTimeout       int
-	ResponseLimit                  int64
-	DataProxyRowLimit              int64
-	DataProxyUserAgent             string
+	SendUserHeader                     bool
+	DataProxyLogging                   bool
+	DataProxyTimeout                   int
+	DataProxyDialTimeout               int
+	DataProxyTLSHandshakeTimeout       int
+	DataProxyExpectContinueTimeout     int
+	DataProxyMaxConnsPerHost           int
+	DataProxyMaxIdleConns              int
+	DataProxyKeepAlive                 int
+	DataProxyIdleConnTimeout           int
+	ResponseLimit                      int64
+	DataProxyRowLimit                  int64
+	DataProxyUserAgent                 string
+	DataProxyRecordReplayMode          string
+	DataProxyRecordReplayPath          string
+	DataProxyRecordReplayIgnoredParams []string
 
 	// DistributedCache
 	RemoteCacheOptions *RemoteCacheOptions
@@ -332,6 +343,10 @@ type Cfg struct {
 
 	// Service Accounts
 	SATokenExpirationDayLimit int
+	// SATokenRotationInterval is the age at which the tokens with automatic rotation are rotated, zero disables it.
+	SATokenRotationInterval time.Duration
+	// SATokenRotationOverlap is the time during which an automatically rotated token keeps working.
+	SATokenRotationOverlap time.Duration
 
 	// Annotations
 	AnnotationCleanupJobBatchSize      int64
@@ -357,6 +372,8 @@ type Cfg struct {
 	SqlDatasourceMaxOpenConnsDefault    int
 	SqlDatasourceMaxIdleConnsDefault    int
 	SqlDatasourceMaxConnLifetimeDefault int
+	// SQLiteDatasourceAllowedDirectories are the directories whose files the SQLite data source can open.
+	SQLiteDatasourceAllowedDirectories []string
 
 	// Snapshots
 	SnapshotEnabled      bool
@@ -412,6 +429,8 @@ type Cfg struct {
 
 	Quota QuotaSettings
 
+	RateLimiting RateLimitSettings
+
 	// User settings
 	AllowUserSignUp            bool
 	AllowUserOrgCreate         bool
@@ -1201,6 +1220,9 @@ func (cfg *Cfg) parseINIFile(iniFile *ini.File) error {
 	}
 
 	cfg.readQuotaSettings()
+	if err := cfg.readRateLimitSettings(); err != nil {
+		return err
+	}
 
 	cfg.readExpressionsSettings()
 	if err := cfg.readGrafanaEnvironmentMetrics(); err != nil {
@@ -1529,6 +1551,27 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 		return err
 	}
 
+	cfg.LoginMaxConcurrentSessions = auth.Key("login_maximum_concurrent_sessions").MustInt(0)
+
+	cfg.LoginMaxInactiveLifetimeByRole = make(map[string]time.Duration)
+	for _, role := range []string{"viewer", "editor", "admin", "grafana_admin"} {
+		val := valueAsString(auth, "login_maximum_inactive_lifetime_duration_"+role, "")
+		if val == "" {
+			continue
+		}
+		cfg.LoginMaxInactiveLifetimeByRole[role], err = gtime.ParseDuration(val)
+		if err != nil {
+			return err
+		}
+	}
+
+	if val := valueAsString(auth, "reauthentication_max_age", ""); val != "" {
+		cfg.ReauthenticationMaxAge, err = gtime.ParseDuration(val)
+		if err != nil {
+			return err
+		}
+	}
+
 	cfg.ApiKeyMaxSecondsToLive = auth.Key("api_key_max_seconds_to_live").MustInt64(-1)
 
 	cfg.TokenRotationIntervalMinutes = auth.Key("token_rotation_interval_minutes").MustInt(10)
@@ -1718,6 +1761,16 @@ func readUserSettings(iniFile *ini.File, cfg *Cfg) error {
 func readServiceAccountSettings(iniFile *ini.File, cfg *Cfg) error {
 	serviceAccount := iniFile.Section("service_accounts")
 	cfg.SATokenExpirationDayLimit = serviceAccount.Key("token_expiration_day_limit").MustInt(-1)
+
+	var err error
+	cfg.SATokenRotationInterval, err = gtime.ParseDuration(valueAsString(serviceAccount, "token_rotation_interval", "0"))
+	if err != nil {
+		return fmt.Errorf("invalid token_rotation_interval: %w", err)
+	}
+	cfg.SATokenRotationOverlap, err = gtime.ParseDuration(valueAsString(serviceAccount, "token_rotation_overlap", "24h"))
+	if err != nil {
+		return fmt.Errorf("invalid token_rotation_overlap: %w", err)
+	}
 	return nil
 }
 
@@ -1916,6 +1969,11 @@ func (cfg *Cfg) readServerSettings(iniFile *ini.File) error {
 
 	cfg.ReadTimeout = server.Key("read_timeout").MustDuration(0)
 
+	cfg.TrustedProxies, err = parseTrustedProxies(server.Key("trusted_proxies").String())
+	if err != nil {
+		return err
+	}
+
 	headersSection := cfg.Raw.Section("server.custom_response_headers")
 	keys := headersSection.Keys()
 	cfg.CustomResponseHeaders = make(map[string]string, len(keys))
@@ -1927,6 +1985,32 @@ func (cfg *Cfg) readServerSettings(iniFile *ini.File) error {
 	return nil
 }
 
+// parseTrustedProxies parses a comma or space separated list of IP addresses
+// and CIDR ranges. Bare addresses are treated as single host ranges.
+func parseTrustedProxies(value string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, entry := range util.SplitString(value) {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		proxies = append(proxies, ipNet)
+	}
+	return proxies, nil
+}
+
 // GetContentDeliveryURL returns full content delivery URL with /<edition>/<version> added to URL
 func (cfg *Cfg) GetContentDeliveryURL(prefix string) (string, error) {
 	if cfg.CDNRootURL == nil {
@@ -1960,6 +2044,7 @@ func (cfg *Cfg) readSqlDataSourceSettings() {
 	cfg.SqlDatasourceMaxOpenConnsDefault = sqlDatasources.Key("max_open_conns_default").MustInt(100)
 	cfg.SqlDatasourceMaxIdleConnsDefault = sqlDatasources.Key("max_idle_conns_default").MustInt(100)
 	cfg.SqlDatasourceMaxConnLifetimeDefault = sqlDatasources.Key("max_conn_lifetime_default").MustInt(14400)
+	cfg.SQLiteDatasourceAllowedDirectories = util.SplitString(sqlDatasources.Key("sqlite_allowed_directories").MustString(""))
 }
 
 func GetAllowedOriginGlobs(originPatterns []string) ([]glob.Glob, error) {
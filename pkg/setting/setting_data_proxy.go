@@ -2,8 +2,11 @@ package setting
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/util"
 )
 
 const defaultDataProxyRowLimit = int64(1000000)
@@ -32,5 +35,19 @@ func readDataProxySettings(iniFile *ini.File, cfg *Cfg) error {
 		cfg.DataProxyRowLimit = defaultDataProxyRowLimit
 	}
 
+	cfg.DataProxyRecordReplayMode = dataproxy.Key("record_replay_mode").String()
+	switch cfg.DataProxyRecordReplayMode {
+	case "", "record", "replay":
+	default:
+		return fmt.Errorf("invalid record_replay_mode %q in [dataproxy], expected record or replay", cfg.DataProxyRecordReplayMode)
+	}
+	cfg.DataProxyRecordReplayPath = dataproxy.Key("record_replay_path").String()
+	if cfg.DataProxyRecordReplayPath == "" {
+		cfg.DataProxyRecordReplayPath = filepath.Join(cfg.DataPath, "cassettes")
+	} else {
+		cfg.DataProxyRecordReplayPath = makeAbsolute(cfg.DataProxyRecordReplayPath, cfg.HomePath)
+	}
+	cfg.DataProxyRecordReplayIgnoredParams = util.SplitString(dataproxy.Key("record_replay_ignored_params").String())
+
 	return nil
 }
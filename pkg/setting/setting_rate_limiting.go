@@ -0,0 +1,87 @@
+package setting
+
+import (
+	"fmt"
+)
+
+const (
+	RateLimitKeyIdentity = "identity"
+	RateLimitKeyIP       = "ip"
+	RateLimitKeyOrg      = "org"
+)
+
+// RateLimitRoles are the roles for which the budgets can be overridden.
+// Unauthenticated requests use the budgets of the "none" role.
+var RateLimitRoles = []string{"none", "viewer", "editor", "admin"}
+
+type RateLimit struct {
+	// Rate is the number of requests per second added to the bucket. A
+	// negative rate disables the limit.
+	Rate float64
+	// Burst is the size of the bucket.
+	Burst int
+}
+
+// RateLimitBudget holds the limit of a class of endpoints, per role.
+type RateLimitBudget struct {
+	Default RateLimit
+	Roles   map[string]RateLimit
+}
+
+// ForRole returns the limit of role, or the default one when it has not been overridden.
+func (b RateLimitBudget) ForRole(role string) RateLimit {
+	if l, ok := b.Roles[role]; ok {
+		return l
+	}
+	return b.Default
+}
+
+type RateLimitSettings struct {
+	Enabled bool
+	// KeyBy is what requests are grouped by: identity, ip or org.
+	KeyBy string
+
+	// Query is the budget of the data source query endpoints.
+	Query RateLimitBudget
+	// Write is the budget of the API requests that are not GET, HEAD or OPTIONS.
+	Write RateLimitBudget
+	// Read is the budget of the remaining API requests.
+	Read RateLimitBudget
+}
+
+func (cfg *Cfg) readRateLimitSettings() error {
+	section := cfg.Raw.Section("rate_limiting")
+	cfg.RateLimiting.Enabled = section.Key("enabled").MustBool(false)
+	cfg.RateLimiting.KeyBy = valueAsString(section, "key_by", RateLimitKeyIdentity)
+	switch cfg.RateLimiting.KeyBy {
+	case RateLimitKeyIdentity, RateLimitKeyIP, RateLimitKeyOrg:
+	default:
+		return fmt.Errorf("invalid rate_limiting key_by %q, must be one of identity, ip or org", cfg.RateLimiting.KeyBy)
+	}
+
+	readBudget := func(class string, rate float64, burst int) RateLimitBudget {
+		budget := RateLimitBudget{
+			Default: RateLimit{
+				Rate:  section.Key(class + "_rate").MustFloat64(rate),
+				Burst: section.Key(class + "_burst").MustInt(burst),
+			},
+			Roles: make(map[string]RateLimit),
+		}
+		for _, role := range RateLimitRoles {
+			rateKey, burstKey := class+"_rate_"+role, class+"_burst_"+role
+			if !section.HasKey(rateKey) && !section.HasKey(burstKey) {
+				continue
+			}
+			budget.Roles[role] = RateLimit{
+				Rate:  section.Key(rateKey).MustFloat64(budget.Default.Rate),
+				Burst: section.Key(burstKey).MustInt(budget.Default.Burst),
+			}
+		}
+		return budget
+	}
+
+	cfg.RateLimiting.Query = readBudget("query", 10, 50)
+	cfg.RateLimiting.Write = readBudget("write", 5, 20)
+	cfg.RateLimiting.Read = readBudget("read", 20, 100)
+	return nil
+}
@@ -61,6 +61,14 @@ const (
 	// DefaultRuleEvaluationInterval indicates a default interval of for how long a rule should be evaluated to change state from Pending to Alerting
 	DefaultRuleEvaluationInterval = SchedulerBaseInterval * 6 // == 60 seconds
 	stateHistoryDefaultEnabled    = true
+	// stateHistoryDefaultSQLRetention is 30 days
+	stateHistoryDefaultSQLRetention = 30 * 24 * time.Hour
+	// notificationHistoryDefaultRetention is 7 days
+	notificationHistoryDefaultRetention = 7 * 24 * time.Hour
+	evaluationBudgetDefaultMaxDuration  = 10 * time.Second
+	evaluationBudgetDefaultMaxBackoff   = 8
+	stateExportDefaultInterval          = time.Minute
+	stateExportDefaultTimeout           = 10 * time.Second
 )
 
 type UnifiedAlertingSettings struct {
@@ -80,6 +88,7 @@ type UnifiedAlertingSettings struct {
 	HARedisPassword                string
 	HARedisDB                      int
 	HARedisMaxConns                int
+	HAShardEvaluation              bool
 	MaxAttempts                    int64
 	MinInterval                    time.Duration
 	EvaluationTimeout              time.Duration
@@ -95,6 +104,9 @@ type UnifiedAlertingSettings struct {
 	Screenshots                   UnifiedAlertingScreenshotSettings
 	ReservedLabels                UnifiedAlertingReservedLabelSettings
 	StateHistory                  UnifiedAlertingStateHistorySettings
+	NotificationHistory           UnifiedAlertingNotificationHistorySettings
+	EvaluationBudget              UnifiedAlertingEvaluationBudgetSettings
+	StateExport                   UnifiedAlertingStateExportSettings
 	RemoteAlertmanager            RemoteAlertmanagerSettings
 	Upgrade                       UnifiedAlertingUpgradeSettings
 	// MaxStateSaveConcurrency controls the number of goroutines (per rule) that can save alert state in parallel.
@@ -102,6 +114,45 @@ type UnifiedAlertingSettings struct {
 	StatePeriodicSaveInterval time.Duration
 }
 
+// UnifiedAlertingNotificationHistorySettings contains the configuration of the history of notification delivery attempts.
+type UnifiedAlertingNotificationHistorySettings struct {
+	Enabled bool
+	Backend string
+	// Retention is how long the "sql" backend keeps the history.
+	Retention time.Duration
+}
+
+// UnifiedAlertingEvaluationBudgetSettings contains the configuration of the backoff of alert rules whose evaluation is too expensive.
+type UnifiedAlertingEvaluationBudgetSettings struct {
+	Enabled bool
+	// MaxDuration is the maximum average duration of the evaluation of a rule.
+	MaxDuration time.Duration
+	// MaxQueryBytes is the maximum average size of the data returned by the queries of a rule. Zero means no limit.
+	MaxQueryBytes int64
+	// MaxBackoffFactor is the maximum factor by which the evaluation interval of a rule that exceeds the budget is increased.
+	MaxBackoffFactor int
+}
+
+// UnifiedAlertingStateExportSettings contains the configuration of the export of the active alerts as the ALERTS and
+// ALERTS_FOR_STATE series of Prometheus.
+type UnifiedAlertingStateExportSettings struct {
+	Enabled bool
+	// Metrics controls whether the series are exposed on the metrics endpoint of Grafana. The series contain the labels
+	// of the alerts of all organizations.
+	Metrics bool
+	// RemoteWriteURL is the Prometheus remote write endpoint the series are pushed to. The series are not pushed if empty.
+	RemoteWriteURL      string
+	RemoteWriteTenantID string
+	// RemoteWriteBasicAuthUsername and RemoteWriteBasicAuthPassword are used for basic auth
+	// if one of them is set.
+	RemoteWriteBasicAuthUsername string
+	RemoteWriteBasicAuthPassword string
+	RemoteWriteInterval          time.Duration
+	RemoteWriteTimeout           time.Duration
+	// ExternalLabels are added to all series, unless the alert has a label with the same name.
+	ExternalLabels map[string]string
+}
+
 // RemoteAlertmanagerSettings contains the configuration needed
 // to disable the internal Alertmanager and use an external one instead.
 type RemoteAlertmanagerSettings struct {
@@ -137,6 +188,8 @@ type UnifiedAlertingStateHistorySettings struct {
 	MultiPrimary          string
 	MultiSecondaries      []string
 	ExternalLabels        map[string]string
+	// SQLRetention is how long the "sql" backend keeps the history.
+	SQLRetention time.Duration
 }
 
 type UnifiedAlertingUpgradeSettings struct {
@@ -261,6 +314,7 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 	uaCfg.HARedisPassword = ua.Key("ha_redis_password").MustString("")
 	uaCfg.HARedisDB = ua.Key("ha_redis_db").MustInt(0)
 	uaCfg.HARedisMaxConns = ua.Key("ha_redis_max_conns").MustInt(alertmanagerRedisDefaultMaxConns)
+	uaCfg.HAShardEvaluation = ua.Key("ha_shard_evaluation").MustBool(false)
 	peers := ua.Key("ha_peers").MustString("")
 	uaCfg.HAPeers = make([]string, 0)
 	if peers != "" {
@@ -400,8 +454,65 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 		MultiSecondaries:      splitTrim(stateHistory.Key("secondaries").MustString(""), ","),
 		ExternalLabels:        stateHistoryLabels.KeysHash(),
 	}
+	uaCfgStateHistory.SQLRetention, err = gtime.ParseDuration(valueAsString(stateHistory, "sql_retention", stateHistoryDefaultSQLRetention.String()))
+	if err != nil {
+		return err
+	}
 	uaCfg.StateHistory = uaCfgStateHistory
 
+	notificationHistory := iniFile.Section("unified_alerting.notification_history")
+	uaCfgNotificationHistory := UnifiedAlertingNotificationHistorySettings{
+		Enabled: notificationHistory.Key("enabled").MustBool(false),
+		Backend: notificationHistory.Key("backend").MustString("sql"),
+	}
+	uaCfgNotificationHistory.Retention, err = gtime.ParseDuration(valueAsString(notificationHistory, "retention", notificationHistoryDefaultRetention.String()))
+	if err != nil {
+		return err
+	}
+	uaCfg.NotificationHistory = uaCfgNotificationHistory
+
+	evaluationBudget := iniFile.Section("unified_alerting.evaluation_budget")
+	uaCfgEvaluationBudget := UnifiedAlertingEvaluationBudgetSettings{
+		Enabled:          evaluationBudget.Key("enabled").MustBool(false),
+		MaxQueryBytes:    evaluationBudget.Key("max_query_bytes").MustInt64(0),
+		MaxBackoffFactor: evaluationBudget.Key("max_backoff_factor").MustInt(evaluationBudgetDefaultMaxBackoff),
+	}
+	uaCfgEvaluationBudget.MaxDuration, err = gtime.ParseDuration(valueAsString(evaluationBudget, "max_duration", evaluationBudgetDefaultMaxDuration.String()))
+	if err != nil {
+		return err
+	}
+	if uaCfgEvaluationBudget.Enabled && uaCfgEvaluationBudget.MaxDuration <= 0 {
+		return fmt.Errorf("value of setting 'max_duration' in section 'unified_alerting.evaluation_budget' should be greater than 0 but got %s", uaCfgEvaluationBudget.MaxDuration)
+	}
+	if uaCfgEvaluationBudget.MaxBackoffFactor < 1 {
+		return fmt.Errorf("value of setting 'max_backoff_factor' in section 'unified_alerting.evaluation_budget' should be at least 1 but got %d", uaCfgEvaluationBudget.MaxBackoffFactor)
+	}
+	uaCfg.EvaluationBudget = uaCfgEvaluationBudget
+
+	stateExport := iniFile.Section("unified_alerting.state_export")
+	stateExportLabels := iniFile.Section("unified_alerting.state_export.external_labels")
+	uaCfgStateExport := UnifiedAlertingStateExportSettings{
+		Enabled:                      stateExport.Key("enabled").MustBool(false),
+		Metrics:                      stateExport.Key("metrics").MustBool(false),
+		RemoteWriteURL:               stateExport.Key("remote_write_url").MustString(""),
+		RemoteWriteTenantID:          stateExport.Key("remote_write_tenant_id").MustString(""),
+		RemoteWriteBasicAuthUsername: stateExport.Key("remote_write_basic_auth_username").MustString(""),
+		RemoteWriteBasicAuthPassword: stateExport.Key("remote_write_basic_auth_password").MustString(""),
+		ExternalLabels:               stateExportLabels.KeysHash(),
+	}
+	uaCfgStateExport.RemoteWriteInterval, err = gtime.ParseDuration(valueAsString(stateExport, "remote_write_interval", stateExportDefaultInterval.String()))
+	if err != nil {
+		return err
+	}
+	if uaCfgStateExport.RemoteWriteInterval <= 0 {
+		return fmt.Errorf("value of setting 'remote_write_interval' in section 'unified_alerting.state_export' should be greater than 0 but got %s", uaCfgStateExport.RemoteWriteInterval)
+	}
+	uaCfgStateExport.RemoteWriteTimeout, err = gtime.ParseDuration(valueAsString(stateExport, "remote_write_timeout", stateExportDefaultTimeout.String()))
+	if err != nil {
+		return err
+	}
+	uaCfg.StateExport = uaCfgStateExport
+
 	uaCfg.MaxStateSaveConcurrency = ua.Key("max_state_save_concurrency").MustInt(1)
 
 	uaCfg.StatePeriodicSaveInterval, err = gtime.ParseDuration(valueAsString(ua, "state_periodic_save_interval", (time.Minute * 5).String()))
@@ -72,6 +72,32 @@ func TestCfg_ReadUnifiedAlertingSettings(t *testing.T) {
 			require.Equal(t, SchedulerBaseInterval, cfg.UnifiedAlerting.BaseInterval)
 		})
 	})
+
+	t.Run("should read evaluation budget 'max_duration'", func(t *testing.T) {
+		s, err := cfg.Raw.NewSection("unified_alerting.evaluation_budget")
+		require.NoError(t, err)
+		_, err = s.NewKey("max_duration", "30s")
+		require.NoError(t, err)
+
+		require.NoError(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw))
+		require.Equal(t, 30*time.Second, cfg.UnifiedAlerting.EvaluationBudget.MaxDuration)
+
+		t.Run("and fail if it is not positive and the budget is enabled", func(t *testing.T) {
+			_, err = s.NewKey("max_duration", "0s")
+			require.NoError(t, err)
+			require.NoError(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw))
+
+			_, err = s.NewKey("enabled", "true")
+			require.NoError(t, err)
+			require.ErrorContains(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw), "max_duration")
+
+			_, err = s.NewKey("max_duration", "-1s")
+			require.NoError(t, err)
+			require.ErrorContains(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw), "max_duration")
+		})
+
+		cfg.Raw.DeleteSection("unified_alerting.evaluation_budget")
+	})
 }
 
 func TestUnifiedAlertingSettings(t *testing.T) {
@@ -0,0 +1,159 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/experimental/errorsource"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ESQLRequest represents a request to the ES|QL query API
+type ESQLRequest struct {
+	Query  string         `json:"query"`
+	Filter map[string]any `json:"filter,omitempty"`
+}
+
+// SQLRequest represents a request to the SQL search API
+type SQLRequest struct {
+	Query     string         `json:"query"`
+	Filter    map[string]any `json:"filter,omitempty"`
+	FetchSize int            `json:"fetch_size,omitempty"`
+	TimeZone  string         `json:"time_zone,omitempty"`
+	// MaxRows limits the rows that are read through the cursor of the query, all rows are read if it is zero.
+	MaxRows int `json:"-"`
+}
+
+// ColumnarColumn represents a column of the response of an ES|QL or SQL query
+type ColumnarColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ColumnarResponse represents the response of an ES|QL or SQL query, with the rows of all pages of SQL queries
+type ColumnarResponse struct {
+	Columns []ColumnarColumn
+	Rows    [][]any
+	// Truncated is true if the rows of a SQL query were limited by MaxRows.
+	Truncated bool
+}
+
+type esqlResponse struct {
+	Columns []ColumnarColumn `json:"columns"`
+	Values  [][]any          `json:"values"`
+}
+
+type sqlResponse struct {
+	Columns []ColumnarColumn `json:"columns"`
+	Rows    [][]any          `json:"rows"`
+	Cursor  string           `json:"cursor"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+func (c *baseClientImpl) ExecuteESQL(r *ESQLRequest) (*ColumnarResponse, error) {
+	var res esqlResponse
+	if err := c.executeJSONRequest("executeESQL", "_query", "format=json", r, &res); err != nil {
+		return nil, err
+	}
+	return &ColumnarResponse{Columns: res.Columns, Rows: res.Values}, nil
+}
+
+// ExecuteSQL executes a SQL query and reads the pages of its rows through the cursor, until there are no more rows or
+// MaxRows rows were read. The cursor is closed if there are rows left.
+func (c *baseClientImpl) ExecuteSQL(r *SQLRequest) (*ColumnarResponse, error) {
+	var page sqlResponse
+	if err := c.executeJSONRequest("executeSQL", "_sql", "format=json", r, &page); err != nil {
+		return nil, err
+	}
+
+	res := &ColumnarResponse{Columns: page.Columns, Rows: page.Rows}
+	for page.Cursor != "" {
+		if r.MaxRows > 0 && len(res.Rows) >= r.MaxRows {
+			res.Truncated = true
+			if err := c.executeJSONRequest("closeSQLCursor", "_sql/close", "", map[string]string{"cursor": page.Cursor}, nil); err != nil {
+				c.logger.Warn("Failed to close SQL cursor", "error", err)
+			}
+			break
+		}
+
+		cursor := page.Cursor
+		page = sqlResponse{}
+		if err := c.executeJSONRequest("executeSQL", "_sql", "format=json", map[string]string{"cursor": cursor}, &page); err != nil {
+			return nil, err
+		}
+		res.Rows = append(res.Rows, page.Rows...)
+	}
+
+	if r.MaxRows > 0 && len(res.Rows) > r.MaxRows {
+		res.Rows = res.Rows[:r.MaxRows]
+		res.Truncated = true
+	}
+	return res, nil
+}
+
+// executeJSONRequest posts a JSON body and decodes the JSON response into out, with the numbers as json.Number so
+// that large integers keep their precision. Error responses are returned as downstream errors.
+func (c *baseClientImpl) executeJSONRequest(operation, uriPath, uriQuery string, body any, out any) error {
+	var err error
+	_, span := c.tracer.Start(c.ctx, "datasource.elasticsearch.queryData."+operation, trace.WithAttributes(
+		attribute.String("path", uriPath),
+		attribute.String("url", c.ds.URL),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	res, err := c.executeRequest(http.MethodPost, uriPath, uriQuery, "application/json", b)
+	if err != nil {
+		c.logger.Error("Error received from Elasticsearch", "error", err, "path", uriPath, "duration", time.Since(start), "stage", StageDatabaseRequest)
+		return err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			c.logger.Warn("Failed to close response body", "error", err)
+		}
+	}()
+
+	c.logger.Debug("Response received from Elasticsearch", "path", uriPath, "statusCode", res.StatusCode, "duration", time.Since(start), "stage", StageDatabaseRequest)
+
+	dec := json.NewDecoder(res.Body)
+	dec.UseNumber()
+	if res.StatusCode >= http.StatusBadRequest {
+		var errRes errorResponse
+		if decodeErr := dec.Decode(&errRes); decodeErr != nil || errRes.Error.Reason == "" {
+			err = errorsource.DownstreamError(fmt.Errorf("unexpected status code %d from elasticsearch", res.StatusCode), false)
+			return err
+		}
+		err = errorsource.DownstreamError(fmt.Errorf("%s: %s", errRes.Error.Type, errRes.Error.Reason), false)
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+
+	if err = dec.Decode(out); err != nil {
+		c.logger.Error("Failed to decode response from Elasticsearch", "error", err, "path", uriPath)
+		return err
+	}
+	return nil
+}
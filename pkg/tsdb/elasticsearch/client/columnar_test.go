@@ -0,0 +1,84 @@
+package es
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+func TestClient_ExecuteSQL(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests = append(requests, r.URL.Path+" "+string(body))
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var req map[string]any
+		require.NoError(t, json.Unmarshal(body, &req))
+		rw.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/_sql/close":
+			_, err = rw.Write([]byte(`{"succeeded":true}`))
+		case req["query"] == "SELECT bad":
+			rw.WriteHeader(http.StatusBadRequest)
+			_, err = rw.Write([]byte(`{"error":{"type":"verification_exception","reason":"Found 1 problem"},"status":400}`))
+		case req["cursor"] == nil:
+			_, err = rw.Write([]byte(`{"columns":[{"name":"n","type":"long"}],"rows":[[1],[2]],"cursor":"c1"}`))
+		case req["cursor"] == "c1":
+			_, err = rw.Write([]byte(`{"rows":[[3],[4]],"cursor":"c2"}`))
+		default:
+			_, err = rw.Write([]byte(`{"rows":[[5]]}`))
+		}
+		require.NoError(t, err)
+	}))
+	t.Cleanup(ts.Close)
+
+	ds := DatasourceInfo{
+		URL:        ts.URL,
+		HTTPClient: ts.Client(),
+		Database:   "logs",
+	}
+	timeRange := backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()}
+	c, err := NewClient(context.Background(), &ds, timeRange, log.New("test", "test"), tracing.InitializeTracerForTest())
+	require.NoError(t, err)
+
+	t.Run("should read all pages through the cursor", func(t *testing.T) {
+		requests = nil
+		res, err := c.ExecuteSQL(&SQLRequest{Query: "SELECT n FROM logs", FetchSize: 2})
+		require.NoError(t, err)
+		require.Equal(t, []ColumnarColumn{{Name: "n", Type: "long"}}, res.Columns)
+		require.Len(t, res.Rows, 5)
+		require.Equal(t, json.Number("5"), res.Rows[4][0])
+		require.False(t, res.Truncated)
+		require.Equal(t, []string{
+			`/_sql {"query":"SELECT n FROM logs","fetch_size":2}`,
+			`/_sql {"cursor":"c1"}`,
+			`/_sql {"cursor":"c2"}`,
+		}, requests)
+	})
+
+	t.Run("should close the cursor when the rows are limited", func(t *testing.T) {
+		requests = nil
+		res, err := c.ExecuteSQL(&SQLRequest{Query: "SELECT n FROM logs", FetchSize: 2, MaxRows: 3})
+		require.NoError(t, err)
+		require.Len(t, res.Rows, 3)
+		require.True(t, res.Truncated)
+		require.Equal(t, `/_sql/close {"cursor":"c2"}`, requests[len(requests)-1])
+	})
+
+	t.Run("should return the reason of errors", func(t *testing.T) {
+		_, err := c.ExecuteSQL(&SQLRequest{Query: "SELECT bad"})
+		require.EqualError(t, err, "verification_exception: Found 1 problem")
+	})
+}
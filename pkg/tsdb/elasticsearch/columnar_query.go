@@ -0,0 +1,154 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/experimental/errorsource"
+
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+)
+
+const (
+	esqlQueryType = "esql"
+	sqlQueryType  = "sql"
+	// sqlFetchSize is the number of rows of a page of a SQL query.
+	sqlFetchSize = 1000
+	// sqlMaxRows is the maximum number of rows read through the cursor of a SQL query.
+	sqlMaxRows = 10000
+)
+
+func isColumnarQuery(q *Query) bool {
+	return q.QueryType == esqlQueryType || q.QueryType == sqlQueryType
+}
+
+// executeColumnarQuery sends an ES|QL or SQL query to the _query or _sql endpoint, with a filter on the time field
+// for the time range of the query, and returns the columns of the response as a frame.
+func (e *elasticsearchDataQuery) executeColumnarQuery(q *Query, from, to int64) backend.DataResponse {
+	if strings.TrimSpace(q.RawQuery) == "" {
+		return errorsource.Response(errorsource.DownstreamError(fmt.Errorf("query is empty"), false))
+	}
+
+	filter := map[string]any{
+		"range": map[string]any{
+			e.client.GetConfiguredFields().TimeField: map[string]any{
+				"gte":    from,
+				"lte":    to,
+				"format": es.DateFormatEpochMS,
+			},
+		},
+	}
+
+	var (
+		res *es.ColumnarResponse
+		err error
+	)
+	if q.QueryType == esqlQueryType {
+		res, err = e.client.ExecuteESQL(&es.ESQLRequest{Query: q.RawQuery, Filter: filter})
+	} else {
+		res, err = e.client.ExecuteSQL(&es.SQLRequest{
+			Query:     q.RawQuery,
+			Filter:    filter,
+			FetchSize: sqlFetchSize,
+			TimeZone:  "Z",
+			MaxRows:   sqlMaxRows,
+		})
+	}
+	if err != nil {
+		return errorsource.Response(err)
+	}
+
+	frame, err := columnarResponseToFrame(res)
+	if err != nil {
+		return errorsource.Response(errorsource.PluginError(err, false))
+	}
+	frame.RefID = q.RefID
+	frame.Meta = &data.FrameMeta{ExecutedQueryString: q.RawQuery}
+	if res.Truncated {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("The query returned more than %d rows, only the first %d rows are shown.", sqlMaxRows, sqlMaxRows),
+		})
+	}
+	return backend.DataResponse{Frames: data.Frames{frame}}
+}
+
+// columnarResponseToFrame returns a frame with a nullable field per column, of the type that matches the Elasticsearch
+// type of the column. Columns of types without a matching field type become string fields.
+func columnarResponseToFrame(res *es.ColumnarResponse) (*data.Frame, error) {
+	frame := data.NewFrame("")
+	for i, column := range res.Columns {
+		field := data.NewFieldFromFieldType(columnFieldType(column.Type), len(res.Rows))
+		field.Name = column.Name
+		for j, row := range res.Rows {
+			if i >= len(row) || row[i] == nil {
+				continue
+			}
+			v, err := columnValue(field.Type(), row[i])
+			if err != nil {
+				return nil, fmt.Errorf("failed to read value of column %s: %w", column.Name, err)
+			}
+			field.Set(j, v)
+		}
+		frame.Fields = append(frame.Fields, field)
+	}
+	return frame, nil
+}
+
+func columnFieldType(esType string) data.FieldType {
+	switch esType {
+	case "boolean":
+		return data.FieldTypeNullableBool
+	case "byte", "short", "integer", "long", "counter_integer", "counter_long":
+		return data.FieldTypeNullableInt64
+	case "unsigned_long", "double", "float", "half_float", "scaled_float", "counter_double":
+		return data.FieldTypeNullableFloat64
+	case "date", "datetime", "date_nanos":
+		return data.FieldTypeNullableTime
+	default:
+		return data.FieldTypeNullableString
+	}
+}
+
+func columnValue(fieldType data.FieldType, value any) (any, error) {
+	switch fieldType {
+	case data.FieldTypeNullableBool:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value %v for boolean", value)
+		}
+		return &b, nil
+	case data.FieldTypeNullableInt64:
+		n, ok := value.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value %v for integer", value)
+		}
+		i, err := n.Int64()
+		return &i, err
+	case data.FieldTypeNullableFloat64:
+		n, ok := value.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value %v for number", value)
+		}
+		f, err := n.Float64()
+		return &f, err
+	case data.FieldTypeNullableTime:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value %v for date", value)
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return &t, err
+	default:
+		if s, ok := value.(string); ok {
+			return &s, nil
+		}
+		b, err := json.Marshal(value)
+		s := string(b)
+		return &s, err
+	}
+}
@@ -0,0 +1,104 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+)
+
+func TestExecuteColumnarQuery(t *testing.T) {
+	from := time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC)
+	to := time.Date(2018, 5, 15, 17, 55, 0, 0, time.UTC)
+	timeRange := backend.TimeRange{From: from, To: to}
+
+	execute := func(c *fakeClient, queries ...backend.DataQuery) *backend.QueryDataResponse {
+		for i := range queries {
+			queries[i].TimeRange = timeRange
+		}
+		res, err := newElasticsearchDataQuery(context.Background(), c, queries, log.New("test.logger"), tracing.InitializeTracerForTest()).execute()
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("should send ES|QL queries with a filter on the time range", func(t *testing.T) {
+		c := newFakeClient()
+		c.columnarResponse = &es.ColumnarResponse{
+			Columns: []es.ColumnarColumn{{Name: "@timestamp", Type: "date"}, {Name: "host", Type: "keyword"}, {Name: "count", Type: "long"}, {Name: "avg", Type: "double"}},
+			Rows: [][]any{
+				{"2018-05-15T17:50:00.000Z", "a", json.Number("2"), json.Number("1.5")},
+				{"2018-05-15T17:51:00.000Z", nil, json.Number("3"), nil},
+			},
+		}
+		res := execute(c, backend.DataQuery{RefID: "A", QueryType: esqlQueryType, JSON: json.RawMessage(`{"query":"FROM logs | STATS count = COUNT(*), avg = AVG(bytes) BY @timestamp, host"}`)})
+
+		require.Len(t, c.esqlRequests, 1)
+		require.Empty(t, c.multisearchRequests)
+		require.Equal(t, "FROM logs | STATS count = COUNT(*), avg = AVG(bytes) BY @timestamp, host", c.esqlRequests[0].Query)
+		filter, err := json.Marshal(c.esqlRequests[0].Filter)
+		require.NoError(t, err)
+		require.JSONEq(t, fmt.Sprintf(`{"range":{"@timestamp":{"gte":%d,"lte":%d,"format":"epoch_millis"}}}`, from.UnixMilli(), to.UnixMilli()), string(filter))
+
+		r := res.Responses["A"]
+		require.NoError(t, r.Error)
+		require.Len(t, r.Frames, 1)
+		frame := r.Frames[0]
+		require.Equal(t, []data.FieldType{data.FieldTypeNullableTime, data.FieldTypeNullableString, data.FieldTypeNullableInt64, data.FieldTypeNullableFloat64}, frameFieldTypes(frame))
+		require.Equal(t, from, *frame.Fields[0].At(0).(*time.Time))
+		require.Equal(t, "a", *frame.Fields[1].At(0).(*string))
+		require.Nil(t, frame.Fields[1].At(1))
+		require.Equal(t, int64(3), *frame.Fields[2].At(1).(*int64))
+		require.Equal(t, 1.5, *frame.Fields[3].At(0).(*float64))
+	})
+
+	t.Run("should send SQL queries next to the multisearch request", func(t *testing.T) {
+		c := newFakeClient()
+		c.columnarResponse = &es.ColumnarResponse{
+			Columns:   []es.ColumnarColumn{{Name: "host", Type: "keyword"}},
+			Rows:      [][]any{{"a"}},
+			Truncated: true,
+		}
+		res := execute(c,
+			backend.DataQuery{RefID: "A", QueryType: sqlQueryType, JSON: json.RawMessage(`{"query":"SELECT host FROM logs"}`)},
+			backend.DataQuery{RefID: "B", JSON: json.RawMessage(`{"metrics":[{"type":"count","id":"1"}],"bucketAggs":[{"type":"date_histogram","field":"@timestamp","id":"2"}]}`)},
+		)
+
+		require.Len(t, c.sqlRequests, 1)
+		require.Equal(t, sqlFetchSize, c.sqlRequests[0].FetchSize)
+		require.Len(t, c.multisearchRequests, 1)
+		require.Len(t, c.multisearchRequests[0].Requests, 1)
+		require.Contains(t, res.Responses, "A")
+		require.Len(t, res.Responses["A"].Frames[0].Meta.Notices, 1)
+	})
+
+	t.Run("should return errors of the query", func(t *testing.T) {
+		c := newFakeClient()
+		c.columnarError = fmt.Errorf("verification_exception: unknown index [logs]")
+		res := execute(c, backend.DataQuery{RefID: "A", QueryType: esqlQueryType, JSON: json.RawMessage(`{"query":"FROM logs"}`)})
+		require.ErrorContains(t, res.Responses["A"].Error, "unknown index")
+	})
+
+	t.Run("should fail on empty queries", func(t *testing.T) {
+		c := newFakeClient()
+		res := execute(c, backend.DataQuery{RefID: "A", QueryType: sqlQueryType, JSON: json.RawMessage(`{"query":" "}`)})
+		require.ErrorContains(t, res.Responses["A"].Error, "query is empty")
+		require.Empty(t, c.sqlRequests)
+	})
+}
+
+func frameFieldTypes(frame *data.Frame) []data.FieldType {
+	types := make([]data.FieldType, len(frame.Fields))
+	for i, field := range frame.Fields {
+		types[i] = field.Type()
+	}
+	return types
+}
@@ -51,10 +51,24 @@ func (e *elasticsearchDataQuery) execute() (*backend.QueryDataResponse, error) {
 		return errorsource.AddPluginErrorToResponse(e.dataQueries[0].RefID, response, err), nil
 	}
 
-	ms := e.client.MultiSearch()
-
 	from := e.dataQueries[0].TimeRange.From.UnixNano() / int64(time.Millisecond)
 	to := e.dataQueries[0].TimeRange.To.UnixNano() / int64(time.Millisecond)
+
+	// ES|QL and SQL queries have their own endpoints, the other queries are sent in a single multisearch request
+	searchQueries := make([]*Query, 0, len(queries))
+	for _, q := range queries {
+		if isColumnarQuery(q) {
+			response.Responses[q.RefID] = e.executeColumnarQuery(q, from, to)
+			continue
+		}
+		searchQueries = append(searchQueries, q)
+	}
+	if len(searchQueries) == 0 {
+		return response, nil
+	}
+	queries = searchQueries
+
+	ms := e.client.MultiSearch()
 	for _, q := range queries {
 		if err := e.processQuery(q, ms, from, to); err != nil {
 			mq, _ := json.Marshal(q)
@@ -77,7 +91,14 @@ func (e *elasticsearchDataQuery) execute() (*backend.QueryDataResponse, error) {
 		return errorsource.AddErrorToResponse(e.dataQueries[0].RefID, response, err), nil
 	}
 
-	return parseResponse(e.ctx, res.Responses, queries, e.client.GetConfiguredFields(), e.logger, e.tracer)
+	result, err := parseResponse(e.ctx, res.Responses, queries, e.client.GetConfiguredFields(), e.logger, e.tracer)
+	if err != nil {
+		return result, err
+	}
+	for refID, columnarResponse := range response.Responses {
+		result.Responses[refID] = columnarResponse
+	}
+	return result, nil
 }
 
 func (e *elasticsearchDataQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilder, from, to int64) error {
@@ -8,6 +8,8 @@ import (
 
 // Query represents the time series query model of the datasource
 type Query struct {
+	// QueryType is esql or sql for ES|QL and SQL queries, which are sent as they are in RawQuery
+	QueryType     string       `json:"queryType"`
 	RawQuery      string       `json:"query"`
 	BucketAggs    []*BucketAgg `json:"bucketAggs"`
 	Metrics       []*MetricAgg `json:"metrics"`
@@ -34,6 +34,7 @@ func parseQuery(tsdbQuery []backend.DataQuery, logger log.Logger) ([]*Query, err
 		interval := q.Interval
 
 		queries = append(queries, &Query{
+			QueryType:     q.QueryType,
 			RawQuery:      rawQuery,
 			BucketAggs:    bucketAggs,
 			Metrics:       metrics,
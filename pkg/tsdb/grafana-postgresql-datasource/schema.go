@@ -0,0 +1,42 @@
+package postgres
+
+import "strings"
+
+// postgresSchemaDialect reads the schema from information_schema and the system catalogs.
+type postgresSchemaDialect struct{}
+
+func (postgresSchemaDialect) DatabasesQuery() string {
+	return "SELECT datname FROM pg_database WHERE NOT datistemplate ORDER BY datname"
+}
+
+func (postgresSchemaDialect) SchemasQuery() string {
+	return `SELECT schema_name FROM information_schema.schemata
+WHERE schema_name NOT IN ('information_schema', 'pg_catalog', 'pg_toast') AND schema_name NOT LIKE 'pg\_temp\_%' AND schema_name NOT LIKE 'pg\_toast\_temp\_%'
+ORDER BY schema_name`
+}
+
+func (postgresSchemaDialect) TablesQuery() string {
+	return "SELECT table_name FROM information_schema.tables WHERE table_schema = COALESCE(NULLIF($1, ''), current_schema()) ORDER BY table_name"
+}
+
+func (postgresSchemaDialect) ColumnsQuery() string {
+	return `SELECT column_name, data_type, is_nullable = 'YES' FROM information_schema.columns
+WHERE table_schema = COALESCE(NULLIF($1, ''), current_schema()) AND table_name = $2 ORDER BY ordinal_position`
+}
+
+func (postgresSchemaDialect) IndexesQuery() string {
+	return `SELECT i.relname, a.attname, ix.indisunique
+FROM pg_class t
+JOIN pg_namespace n ON n.oid = t.relnamespace
+JOIN pg_index ix ON ix.indrelid = t.oid
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, position) ON true
+JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+WHERE n.nspname = COALESCE(NULLIF($1, ''), current_schema()) AND t.relname = $2
+ORDER BY i.relname, k.position`
+}
+
+func (postgresSchemaDialect) IsTimeType(dataType string) bool {
+	dataType = strings.ToLower(dataType)
+	return strings.HasPrefix(dataType, "timestamp") || dataType == "date"
+}
@@ -0,0 +1,158 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// connector opens connections to a SQLite database and creates the temporary tables of the attached files in every
+// connection. The connections are read-only and can't attach other databases, see authorize.
+type connector struct {
+	driver *sqlite3.SQLiteDriver
+	dsn    string
+}
+
+func newConnector(dsn string, tables []*table) *connector {
+	return &connector{
+		dsn: dsn,
+		driver: &sqlite3.SQLiteDriver{
+			ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+				for _, t := range tables {
+					if err := createTable(conn, t); err != nil {
+						return err
+					}
+				}
+				if _, err := conn.Exec("PRAGMA query_only = true", nil); err != nil {
+					return err
+				}
+				conn.RegisterAuthorizer(authorize)
+				return nil
+			},
+		},
+	}
+}
+
+// sqliteRecursive is the authorizer action of recursive common table expressions, which go-sqlite3 does not export.
+const sqliteRecursive = 33
+
+// readOnlyPragmas are the pragmas that queries may run, they only read the schema.
+var readOnlyPragmas = map[string]struct{}{
+	"database_list":    {},
+	"foreign_key_list": {},
+	"index_info":       {},
+	"index_list":       {},
+	"index_xinfo":      {},
+	"table_info":       {},
+	"table_list":       {},
+	"table_xinfo":      {},
+}
+
+// authorize is the authorizer of the connections. PRAGMA query_only can be turned off by any query, so it denies
+// the statements that change the databases or the schema, attaching databases and the pragmas that are not read-only.
+func authorize(action int, arg1, _, _ string) int {
+	switch action {
+	case sqlite3.SQLITE_SELECT, sqlite3.SQLITE_READ, sqlite3.SQLITE_FUNCTION, sqliteRecursive, sqlite3.SQLITE_TRANSACTION:
+		return sqlite3.SQLITE_OK
+	case sqlite3.SQLITE_PRAGMA:
+		if _, ok := readOnlyPragmas[strings.ToLower(arg1)]; ok {
+			return sqlite3.SQLITE_OK
+		}
+	}
+	return sqlite3.SQLITE_DENY
+}
+
+func (c *connector) Connect(_ context.Context) (driver.Conn, error) {
+	c2, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{SQLiteConn: c2.(*sqlite3.SQLiteConn)}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// conn returns rows that type the columns without declared type, see typedRows.
+type conn struct {
+	*sqlite3.SQLiteConn
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.SQLiteConn.QueryContext(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return newTypedRows(rows.(*sqlite3.SQLiteRows)), nil
+}
+
+// typedRows reads the first row ahead, to type the columns without declared type, such as expressions and
+// aggregations, after the storage class of their first value. Numbers are REAL, since SQLite converts integers to
+// floating point in arithmetic when needed, and text is TEXT.
+type typedRows struct {
+	*sqlite3.SQLiteRows
+	types []string
+	first []driver.Value
+	err   error
+}
+
+func newTypedRows(rows *sqlite3.SQLiteRows) *typedRows {
+	columns := rows.Columns()
+	r := &typedRows{SQLiteRows: rows, types: make([]string, len(columns)), first: make([]driver.Value, len(columns))}
+	for i := range columns {
+		r.types[i] = rows.ColumnTypeDatabaseTypeName(i)
+	}
+	r.err = rows.Next(r.first)
+	if r.err != nil {
+		r.first = nil
+		return r
+	}
+	for i, t := range r.types {
+		if t != "" {
+			continue
+		}
+		switch r.first[i].(type) {
+		case int64, float64:
+			r.types[i] = "REAL"
+		case string:
+			r.types[i] = "TEXT"
+		}
+	}
+	return r
+}
+
+func (r *typedRows) Next(dest []driver.Value) error {
+	if r.first != nil {
+		copy(dest, r.first)
+		r.first = nil
+		return nil
+	}
+	if r.err != nil {
+		err := r.err
+		r.err = io.EOF
+		return err
+	}
+	return r.SQLiteRows.Next(dest)
+}
+
+func (r *typedRows) ColumnTypeDatabaseTypeName(i int) string {
+	return r.types[i]
+}
+
+func (r *typedRows) ColumnTypeScanType(i int) reflect.Type {
+	switch r.types[i] {
+	case "REAL":
+		return reflect.TypeOf(sql.NullFloat64{})
+	case "TEXT":
+		if r.SQLiteRows.ColumnTypeDatabaseTypeName(i) == "" {
+			return reflect.TypeOf(sql.NullString{})
+		}
+	}
+	return r.SQLiteRows.ColumnTypeScanType(i)
+}
@@ -0,0 +1,301 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/apache/arrow/go/v13/parquet/pqarrow"
+	"github.com/mattn/go-sqlite3"
+)
+
+var (
+	errNoAllowedDirectories = errors.New("no directory is allowed for SQLite data sources, see sqlite_allowed_directories in the [sql_datasources] section of the configuration")
+	errPathNotAllowed       = errors.New("path is outside of the allowed directories")
+
+	tableNameRegExp = regexp.MustCompile(`^[_a-zA-Z][_a-zA-Z0-9]*$`)
+)
+
+// resolvePath returns the absolute path of a file, after following symbolic links, if it is in one of the allowed
+// directories. Relative paths are relative to the first allowed directory.
+func resolvePath(allowedDirectories []string, path string) (string, error) {
+	if len(allowedDirectories) == 0 {
+		return "", errNoAllowedDirectories
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(allowedDirectories[0], path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path %q: %w", path, err)
+	}
+	resolved, err = filepath.Abs(resolved)
+	if err != nil {
+		return "", err
+	}
+	for _, dir := range allowedDirectories {
+		dir, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			continue
+		}
+		dir, err = filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, resolved); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", errPathNotAllowed, path)
+}
+
+// FileTable is a CSV or Parquet file attached to the data source as a temporary table.
+type FileTable struct {
+	Path  string `json:"path"`
+	Table string `json:"table"`
+}
+
+// table holds the content of a file, with the SQLite type of every column.
+type table struct {
+	name    string
+	columns []string
+	types   []string
+	rows    [][]driver.Value
+}
+
+// loadFile reads a CSV or Parquet file, depending on its extension.
+func loadFile(ctx context.Context, path, name string) (*table, error) {
+	if !tableNameRegExp.MatchString(name) {
+		return nil, fmt.Errorf("invalid table name %q for file %s", name, path)
+	}
+	var t *table
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		t, err = loadCSV(path)
+	case ".parquet":
+		t, err = loadParquet(ctx, path)
+	default:
+		return nil, fmt.Errorf("unsupported file %s, only CSV and Parquet files can be attached", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	t.name = name
+	return t, nil
+}
+
+// loadCSV reads a CSV file whose first row is the header. A column is INTEGER or REAL if all of its values are
+// numbers, and TEXT otherwise. Empty values are NULL.
+func loadCSV(path string) (*table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("missing header")
+		}
+		return nil, err
+	}
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &table{columns: header, types: make([]string, len(header)), rows: make([][]driver.Value, len(records))}
+	for i := range t.rows {
+		t.rows[i] = make([]driver.Value, len(header))
+	}
+	for c := range header {
+		t.types[c] = csvColumnType(records, c)
+		for i, record := range records {
+			v := record[c]
+			if v == "" {
+				continue
+			}
+			switch t.types[c] {
+			case "INTEGER":
+				t.rows[i][c], _ = strconv.ParseInt(v, 10, 64)
+			case "REAL":
+				t.rows[i][c], _ = strconv.ParseFloat(v, 64)
+			default:
+				t.rows[i][c] = v
+			}
+		}
+	}
+	return t, nil
+}
+
+func csvColumnType(records [][]string, c int) string {
+	columnType := "INTEGER"
+	for _, record := range records {
+		v := record[c]
+		if v == "" {
+			continue
+		}
+		if columnType == "INTEGER" {
+			if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+				continue
+			}
+			columnType = "REAL"
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return "TEXT"
+		}
+	}
+	return columnType
+}
+
+// loadParquet reads a Parquet file. Timestamps and dates are stored as ISO 8601 text, which SQLite date and time
+// functions understand.
+func loadParquet(ctx context.Context, path string) (*table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	tbl, err := pqarrow.ReadTable(ctx, f, nil, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	if err != nil {
+		return nil, err
+	}
+	defer tbl.Release()
+
+	schema := tbl.Schema()
+	t := &table{
+		columns: make([]string, schema.NumFields()),
+		types:   make([]string, schema.NumFields()),
+		rows:    make([][]driver.Value, tbl.NumRows()),
+	}
+	for i := range t.rows {
+		t.rows[i] = make([]driver.Value, schema.NumFields())
+	}
+	for c, field := range schema.Fields() {
+		t.columns[c] = field.Name
+		t.types[c] = parquetColumnType(field.Type)
+		row := 0
+		for _, chunk := range tbl.Column(c).Data().Chunks() {
+			for i := 0; i < chunk.Len(); i++ {
+				if !chunk.IsNull(i) {
+					t.rows[row][c] = arrowValue(chunk, i)
+				}
+				row++
+			}
+		}
+	}
+	return t, nil
+}
+
+func parquetColumnType(dt arrow.DataType) string {
+	switch dt.ID() {
+	case arrow.BOOL, arrow.INT8, arrow.INT16, arrow.INT32, arrow.INT64, arrow.UINT8, arrow.UINT16, arrow.UINT32, arrow.UINT64:
+		return "INTEGER"
+	case arrow.FLOAT16, arrow.FLOAT32, arrow.FLOAT64:
+		return "REAL"
+	case arrow.TIMESTAMP:
+		return "DATETIME"
+	case arrow.DATE32, arrow.DATE64:
+		return "DATE"
+	case arrow.BINARY, arrow.LARGE_BINARY, arrow.FIXED_SIZE_BINARY:
+		return "BLOB"
+	default:
+		return "TEXT"
+	}
+}
+
+func arrowValue(arr arrow.Array, i int) driver.Value {
+	switch a := arr.(type) {
+	case *array.Boolean:
+		return a.Value(i)
+	case *array.Int8:
+		return int64(a.Value(i))
+	case *array.Int16:
+		return int64(a.Value(i))
+	case *array.Int32:
+		return int64(a.Value(i))
+	case *array.Int64:
+		return a.Value(i)
+	case *array.Uint8:
+		return int64(a.Value(i))
+	case *array.Uint16:
+		return int64(a.Value(i))
+	case *array.Uint32:
+		return int64(a.Value(i))
+	case *array.Uint64:
+		return int64(a.Value(i))
+	case *array.Float32:
+		return float64(a.Value(i))
+	case *array.Float64:
+		return a.Value(i)
+	case *array.String:
+		return a.Value(i)
+	case *array.LargeString:
+		return a.Value(i)
+	case *array.Binary:
+		return a.Value(i)
+	case *array.Timestamp:
+		unit := a.DataType().(*arrow.TimestampType).Unit
+		return a.Value(i).ToTime(unit).UTC().Format(time.RFC3339Nano)
+	case *array.Date32:
+		return a.Value(i).FormattedString()
+	case *array.Date64:
+		return a.Value(i).FormattedString()
+	default:
+		return a.ValueStr(i)
+	}
+}
+
+func createTable(conn *sqlite3.SQLiteConn, t *table) error {
+	columns := make([]string, len(t.columns))
+	placeholders := make([]string, len(t.columns))
+	for i, name := range t.columns {
+		columns[i] = quoteIdentifier(name) + " " + t.types[i]
+		placeholders[i] = "?"
+	}
+	if _, err := conn.Exec(fmt.Sprintf("CREATE TEMP TABLE %s (%s)", quoteIdentifier(t.name), strings.Join(columns, ", ")), nil); err != nil {
+		return fmt.Errorf("failed to create table %s: %w", t.name, err)
+	}
+	if len(t.rows) == 0 {
+		return nil
+	}
+
+	if _, err := conn.Exec("BEGIN", nil); err != nil {
+		return err
+	}
+	stmt, err := conn.Prepare(fmt.Sprintf("INSERT INTO temp.%s VALUES (%s)", quoteIdentifier(t.name), strings.Join(placeholders, ", ")))
+	if err != nil {
+		_, _ = conn.Exec("ROLLBACK", nil)
+		return err
+	}
+	defer func() { _ = stmt.Close() }()
+	for _, row := range t.rows {
+		//nolint:staticcheck // the connect hook only has access to the driver connection
+		if _, err := stmt.Exec(row); err != nil {
+			_, _ = conn.Exec("ROLLBACK", nil)
+			return fmt.Errorf("failed to fill table %s: %w", t.name, err)
+		}
+	}
+	_, err = conn.Exec("COMMIT", nil)
+	return err
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMacroEngine(t *testing.T) {
+	engine := newSqliteMacroEngine()
+	from := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
+	to := from.Add(5 * time.Minute)
+	timeRange := backend.TimeRange{From: from, To: to}
+
+	t.Run("interpolate __time function", func(t *testing.T) {
+		sql, err := engine.Interpolate(&backend.DataQuery{}, timeRange, "select $__time(time_column)")
+		require.NoError(t, err)
+		require.Equal(t, "select unixepoch(time_column, 'auto') AS time", sql)
+	})
+
+	t.Run("interpolate __timeFilter function", func(t *testing.T) {
+		sql, err := engine.Interpolate(&backend.DataQuery{}, timeRange, "WHERE $__timeFilter(time_column)")
+		require.NoError(t, err)
+		require.Equal(t, "WHERE unixepoch(time_column, 'auto') BETWEEN 1523556000 AND 1523556300", sql)
+	})
+
+	t.Run("interpolate __timeFrom and __timeTo functions", func(t *testing.T) {
+		sql, err := engine.Interpolate(&backend.DataQuery{}, timeRange, "select $__timeFrom(), $__timeTo()")
+		require.NoError(t, err)
+		require.Equal(t, "select '2018-04-12T18:00:00Z', '2018-04-12T18:05:00Z'", sql)
+	})
+
+	t.Run("interpolate __timeGroup function", func(t *testing.T) {
+		sql, err := engine.Interpolate(&backend.DataQuery{}, timeRange, "GROUP BY $__timeGroup(time_column, '5m')")
+		require.NoError(t, err)
+		sql2, err := engine.Interpolate(&backend.DataQuery{}, timeRange, "GROUP BY $__timeGroupAlias(time_column, '5m')")
+		require.NoError(t, err)
+
+		require.Equal(t, "GROUP BY unixepoch(time_column, 'auto') / 300 * 300", sql)
+		require.Equal(t, sql+" AS time", sql2)
+	})
+
+	t.Run("interpolate __timeGroup function with fill", func(t *testing.T) {
+		query := &backend.DataQuery{JSON: []byte("{}")}
+		_, err := engine.Interpolate(query, timeRange, "GROUP BY $__timeGroup(time_column, '5m', NULL)")
+		require.NoError(t, err)
+		require.Contains(t, string(query.JSON), `"fill":true`)
+	})
+
+	t.Run("interpolate __unixEpoch functions", func(t *testing.T) {
+		sql, err := engine.Interpolate(&backend.DataQuery{}, timeRange, "WHERE $__unixEpochFilter(time) GROUP BY $__unixEpochGroupAlias(time, '1m')")
+		require.NoError(t, err)
+		require.Equal(t, "WHERE time >= 1523556000 AND time <= 1523556300 GROUP BY time / 60 * 60 AS time", sql)
+	})
+
+	t.Run("return errors of invalid macros", func(t *testing.T) {
+		_, err := engine.Interpolate(&backend.DataQuery{}, timeRange, "select $__time()")
+		require.Error(t, err)
+		_, err = engine.Interpolate(&backend.DataQuery{}, timeRange, "select $__timeGroup(time)")
+		require.Error(t, err)
+		_, err = engine.Interpolate(&backend.DataQuery{}, timeRange, "select $__unknown(time)")
+		require.Error(t, err)
+	})
+}
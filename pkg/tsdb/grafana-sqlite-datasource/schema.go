@@ -0,0 +1,40 @@
+package sqlite
+
+import "strings"
+
+// sqliteSchemaDialect reads the schema from the catalog of SQLite. The schemas are main, the database file, and temp,
+// the attached files. Tables of the temp schema are listed with the main tables when no schema is given.
+type sqliteSchemaDialect struct{}
+
+func (sqliteSchemaDialect) DatabasesQuery() string {
+	return "SELECT 'main'"
+}
+
+func (sqliteSchemaDialect) SchemasQuery() string {
+	return "SELECT name FROM pragma_database_list WHERE name IN ('main', 'temp') ORDER BY seq"
+}
+
+func (sqliteSchemaDialect) TablesQuery() string {
+	return `SELECT name FROM (
+  SELECT name, 'main' AS schema FROM sqlite_master WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%'
+  UNION ALL
+  SELECT name, 'temp' AS schema FROM sqlite_temp_master WHERE type IN ('table', 'view')
+) WHERE ?1 IN ('', schema) ORDER BY name`
+}
+
+func (sqliteSchemaDialect) ColumnsQuery() string {
+	return `SELECT name, type, "notnull" = 0 FROM pragma_table_info(?2, NULLIF(?1, '')) ORDER BY cid`
+}
+
+func (sqliteSchemaDialect) IndexesQuery() string {
+	return `SELECT il.name, ii.name, il."unique" FROM pragma_index_list(?2, NULLIF(?1, '')) il
+JOIN pragma_index_info(il.name, NULLIF(?1, '')) ii ORDER BY il.name, ii.seqno`
+}
+
+func (sqliteSchemaDialect) IsTimeType(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "datetime", "timestamp", "date":
+		return true
+	}
+	return false
+}
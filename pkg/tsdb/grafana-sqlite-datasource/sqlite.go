@@ -0,0 +1,167 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
+
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+)
+
+type Service struct {
+	im     instancemgmt.InstanceManager
+	logger log.Logger
+}
+
+func ProvideService(cfg *setting.Cfg) *Service {
+	logger := backend.NewLoggerWith("logger", "tsdb.sqlite")
+	return &Service{
+		im:     datasource.NewInstanceManager(newInstanceSettings(cfg, logger)),
+		logger: logger,
+	}
+}
+
+// JsonData is the configuration of a SQLite data source.
+type JsonData struct {
+	sqleng.JsonData
+	// Path is the path of the database file. The data source uses an empty in-memory database if it is empty.
+	Path string `json:"path"`
+	// Files are the CSV and Parquet files that are attached as temporary tables.
+	Files []FileTable `json:"files"`
+}
+
+func newInstanceSettings(cfg *setting.Cfg, logger log.Logger) datasource.InstanceFactoryFunc {
+	return func(ctx context.Context, settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		jsonData := JsonData{
+			JsonData: sqleng.JsonData{
+				MaxOpenConns:    cfg.SqlDatasourceMaxOpenConnsDefault,
+				MaxIdleConns:    cfg.SqlDatasourceMaxIdleConnsDefault,
+				ConnMaxLifetime: cfg.SqlDatasourceMaxConnLifetimeDefault,
+			},
+		}
+
+		err := json.Unmarshal(settings.JSONData, &jsonData)
+		if err != nil {
+			return nil, fmt.Errorf("error reading settings: %w", err)
+		}
+
+		dsn := "file::memory:?mode=memory"
+		if jsonData.Path != "" {
+			path, err := resolvePath(cfg.SQLiteDatasourceAllowedDirectories, jsonData.Path)
+			if err != nil {
+				return nil, err
+			}
+			dsn = (&url.URL{Scheme: "file", Opaque: path, RawQuery: "mode=ro"}).String()
+		}
+
+		tables := make([]*table, 0, len(jsonData.Files))
+		for _, f := range jsonData.Files {
+			path, err := resolvePath(cfg.SQLiteDatasourceAllowedDirectories, f.Path)
+			if err != nil {
+				return nil, err
+			}
+			t, err := loadFile(ctx, path, f.Table)
+			if err != nil {
+				return nil, err
+			}
+			tables = append(tables, t)
+		}
+
+		dsInfo := sqleng.DataSourceInfo{
+			JsonData: jsonData.JsonData,
+			Database: jsonData.Path,
+			ID:       settings.ID,
+			Updated:  settings.Updated,
+			UID:      settings.UID,
+		}
+
+		config := sqleng.DataPluginConfiguration{
+			DSInfo:            dsInfo,
+			TimeColumnNames:   []string{"time", "time_sec"},
+			MetricColumnTypes: []string{"TEXT", "VARCHAR", "CHAR", "CLOB"},
+			RowLimit:          cfg.DataProxyRowLimit,
+			SchemaDialect:     sqliteSchemaDialect{},
+		}
+
+		db := openDB(dsn, tables, config.DSInfo.JsonData)
+		return sqleng.NewQueryDataHandler(cfg, db, config, &sqliteQueryResultTransformer{}, newSqliteMacroEngine(), logger)
+	}
+}
+
+// openDB opens the pool of connections of the data source. Every connection holds its own copy of the attached files,
+// so a data source with files uses a single connection that is never closed, and its queries run one at a time.
+func openDB(dsn string, tables []*table, jsonData sqleng.JsonData) *sql.DB {
+	db := sql.OpenDB(newConnector(dsn, tables))
+	if len(tables) > 0 {
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		db.SetConnMaxLifetime(0)
+		return db
+	}
+	db.SetMaxOpenConns(jsonData.MaxOpenConns)
+	db.SetMaxIdleConns(jsonData.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(jsonData.ConnMaxLifetime) * time.Second)
+	return db
+}
+
+func (s *Service) getDataSourceHandler(ctx context.Context, pluginCtx backend.PluginContext) (*sqleng.DataSourceHandler, error) {
+	i, err := s.im.Get(ctx, pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+	instance := i.(*sqleng.DataSourceHandler)
+	return instance, nil
+}
+
+// CheckHealth pings the database
+func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	dsHandler, err := s.getDataSourceHandler(ctx, req.PluginContext)
+	if err != nil {
+		return &backend.CheckHealthResult{Status: backend.HealthStatusError, Message: err.Error()}, nil
+	}
+
+	err = dsHandler.Ping()
+	if err != nil {
+		return &backend.CheckHealthResult{Status: backend.HealthStatusError, Message: dsHandler.TransformQueryError(s.logger, err).Error()}, nil
+	}
+	return &backend.CheckHealthResult{Status: backend.HealthStatusOk, Message: "Database Connection OK"}, nil
+}
+
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	dsHandler, err := s.getDataSourceHandler(ctx, req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+	return dsHandler.QueryData(ctx, req)
+}
+
+// CallResource serves the schema of the database, see sqleng.DataSourceHandler.CallResource.
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	dsHandler, err := s.getDataSourceHandler(ctx, req.PluginContext)
+	if err != nil {
+		return err
+	}
+	return dsHandler.CallResource(ctx, req, sender)
+}
+
+type sqliteQueryResultTransformer struct{}
+
+func (t *sqliteQueryResultTransformer) TransformQueryError(_ log.Logger, err error) error {
+	return err
+}
+
+func (t *sqliteQueryResultTransformer) GetConverterList() []sqlutil.StringConverter {
+	// The driver scans the columns after their declared type, and the connections type the columns without declared
+	// type, see typedRows.
+	return nil
+}
@@ -0,0 +1,284 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v13/arrow"
+	"github.com/apache/arrow/go/v13/arrow/array"
+	"github.com/apache/arrow/go/v13/arrow/memory"
+	"github.com/apache/arrow/go/v13/parquet/pqarrow"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+)
+
+func TestResolvePath(t *testing.T) {
+	dir := t.TempDir()
+	other := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "field.db"), nil, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(other, "secret.db"), nil, 0600))
+	require.NoError(t, os.Symlink(filepath.Join(other, "secret.db"), filepath.Join(dir, "link.db")))
+
+	t.Run("should resolve paths relative to the first allowed directory", func(t *testing.T) {
+		path, err := resolvePath([]string{dir, other}, "field.db")
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(dir, "field.db"), path)
+	})
+
+	t.Run("should allow absolute paths in any allowed directory", func(t *testing.T) {
+		path, err := resolvePath([]string{dir, other}, filepath.Join(other, "secret.db"))
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(other, "secret.db"), path)
+	})
+
+	t.Run("should reject paths outside of the allowed directories", func(t *testing.T) {
+		_, err := resolvePath([]string{dir}, filepath.Join(other, "secret.db"))
+		require.ErrorIs(t, err, errPathNotAllowed)
+		_, err = resolvePath([]string{dir}, "../"+filepath.Base(other)+"/secret.db")
+		require.ErrorIs(t, err, errPathNotAllowed)
+		_, err = resolvePath([]string{dir}, "link.db")
+		require.ErrorIs(t, err, errPathNotAllowed)
+	})
+
+	t.Run("should reject all paths without allowed directories", func(t *testing.T) {
+		_, err := resolvePath(nil, filepath.Join(dir, "field.db"))
+		require.ErrorIs(t, err, errNoAllowedDirectories)
+	})
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("should infer the types of CSV columns", func(t *testing.T) {
+		path := filepath.Join(dir, "readings.csv")
+		require.NoError(t, os.WriteFile(path, []byte("time,count,value,sensor\n2024-01-01T00:00:00Z,1,1.5,a\n2024-01-01T00:01:00Z,,2,\n"), 0600))
+		tbl, err := loadFile(context.Background(), path, "readings")
+		require.NoError(t, err)
+		require.Equal(t, []string{"time", "count", "value", "sensor"}, tbl.columns)
+		require.Equal(t, []string{"TEXT", "INTEGER", "REAL", "TEXT"}, tbl.types)
+		require.Equal(t, []any{"2024-01-01T00:01:00Z", nil, float64(2), nil}, []any{tbl.rows[1][0], tbl.rows[1][1], tbl.rows[1][2], tbl.rows[1][3]})
+	})
+
+	t.Run("should read Parquet files", func(t *testing.T) {
+		path := filepath.Join(dir, "readings.parquet")
+		writeParquet(t, path)
+		tbl, err := loadFile(context.Background(), path, "readings")
+		require.NoError(t, err)
+		require.Equal(t, []string{"time", "value"}, tbl.columns)
+		require.Equal(t, []string{"DATETIME", "REAL"}, tbl.types)
+		require.Len(t, tbl.rows, 2)
+		require.Equal(t, "2024-01-01T00:01:00Z", tbl.rows[1][0])
+		require.Nil(t, tbl.rows[1][1])
+	})
+
+	t.Run("should reject invalid table names and unsupported files", func(t *testing.T) {
+		_, err := loadFile(context.Background(), filepath.Join(dir, "readings.csv"), "readings; DROP TABLE x")
+		require.Error(t, err)
+		_, err = loadFile(context.Background(), filepath.Join(dir, "readings.json"), "readings")
+		require.Error(t, err)
+	})
+}
+
+func writeParquet(t *testing.T, path string) {
+	t.Helper()
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "time", Type: &arrow.TimestampType{Unit: arrow.Millisecond}},
+		{Name: "value", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+	}, nil)
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+	b.Field(0).(*array.TimestampBuilder).AppendValues([]arrow.Timestamp{1704067200000, 1704067260000}, nil)
+	b.Field(1).(*array.Float64Builder).AppendValues([]float64{1.5, 0}, []bool{true, false})
+	rec := b.NewRecord()
+	defer rec.Release()
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w, err := pqarrow.NewFileWriter(schema, f, nil, pqarrow.DefaultWriterProps())
+	require.NoError(t, err)
+	require.NoError(t, w.Write(rec))
+	require.NoError(t, w.Close())
+}
+
+func TestOpenDB(t *testing.T) {
+	jsonData := sqleng.JsonData{MaxOpenConns: 100, MaxIdleConns: 100, ConnMaxLifetime: 14400}
+
+	t.Run("should use the pool settings of the data source", func(t *testing.T) {
+		db := openDB("file::memory:?mode=memory", nil, jsonData)
+		t.Cleanup(func() { _ = db.Close() })
+		require.Equal(t, 100, db.Stats().MaxOpenConnections)
+	})
+
+	t.Run("should use a single connection if files are attached", func(t *testing.T) {
+		tables := []*table{{name: "events", columns: []string{"message"}, types: []string{"TEXT"}, rows: [][]driver.Value{{"started"}}}}
+		db := openDB("file::memory:?mode=memory", tables, jsonData)
+		t.Cleanup(func() { _ = db.Close() })
+		require.Equal(t, 1, db.Stats().MaxOpenConnections)
+
+		var message string
+		require.NoError(t, db.QueryRow("SELECT message FROM events").Scan(&message))
+		require.Equal(t, "started", message)
+		require.Equal(t, 1, db.Stats().OpenConnections)
+	})
+}
+
+func TestConnector(t *testing.T) {
+	tables := []*table{{name: "events", columns: []string{"message"}, types: []string{"TEXT"}, rows: [][]driver.Value{{"started"}}}}
+	db := openDB("file::memory:?mode=memory", tables, sqleng.JsonData{})
+	t.Cleanup(func() { _ = db.Close() })
+
+	t.Run("should allow reading the tables and the schema", func(t *testing.T) {
+		for _, query := range []string{
+			"SELECT message FROM events",
+			"WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 3) SELECT count(*) FROM n",
+			"SELECT name FROM pragma_table_info('events', 'temp')",
+			"PRAGMA table_info(events)",
+		} {
+			rows, err := db.Query(query)
+			require.NoError(t, err, query)
+			require.NoError(t, rows.Close())
+		}
+	})
+
+	t.Run("should deny changes to the tables and the schema", func(t *testing.T) {
+		for _, query := range []string{
+			"PRAGMA query_only = false",
+			"PRAGMA query_only = false; CREATE TABLE other (a TEXT)",
+			"CREATE TEMP TABLE other (a TEXT)",
+			"INSERT INTO events VALUES ('stopped')",
+			"UPDATE events SET message = 'stopped'",
+			"DELETE FROM events",
+			"DROP TABLE events",
+			"ALTER TABLE events RENAME TO other",
+			"ATTACH DATABASE ':memory:' AS other",
+		} {
+			_, err := db.Exec(query)
+			require.Error(t, err, query)
+		}
+
+		var message string
+		require.NoError(t, db.QueryRow("SELECT message FROM events").Scan(&message))
+		require.Equal(t, "started", message)
+	})
+}
+
+func TestQueryData(t *testing.T) {
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "field.db"))
+	require.NoError(t, err)
+	_, err = db.Exec(`
+CREATE TABLE readings (ts DATETIME, sensor TEXT, value REAL);
+INSERT INTO readings VALUES ('2024-01-01 00:00:10', 'a', 1), ('2024-01-01 00:00:50', 'a', 3), ('2024-01-01 00:01:30', 'b', 2);
+`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "events.csv"), []byte("time,message\n1704067230,started\n"), 0600))
+
+	cfg := setting.NewCfg()
+	cfg.SQLiteDatasourceAllowedDirectories = []string{dir}
+	cfg.DataProxyRowLimit = 1000
+	s := ProvideService(cfg)
+	jsonData, err := json.Marshal(JsonData{Path: "field.db", Files: []FileTable{{Path: "events.csv", Table: "events"}}})
+	require.NoError(t, err)
+	pluginCtx := backend.PluginContext{DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: 1, UID: "sqlite", JSONData: jsonData}}
+
+	query := func(t *testing.T, format, rawSQL string) *data.Frame {
+		t.Helper()
+		model, err := json.Marshal(map[string]any{"rawSql": rawSQL, "format": format})
+		require.NoError(t, err)
+		resp, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: pluginCtx,
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				JSON:      model,
+				Interval:  time.Minute,
+				TimeRange: backend.TimeRange{From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 1, 1, 0, 2, 0, 0, time.UTC)},
+			}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, resp.Responses["A"].Error)
+		require.Len(t, resp.Responses["A"].Frames, 1)
+		return resp.Responses["A"].Frames[0]
+	}
+
+	t.Run("should report the health of the database", func(t *testing.T) {
+		res, err := s.CheckHealth(context.Background(), &backend.CheckHealthRequest{PluginContext: pluginCtx})
+		require.NoError(t, err)
+		require.Equal(t, backend.HealthStatusOk, res.Status)
+	})
+
+	t.Run("should query time series grouped by interval", func(t *testing.T) {
+		frame := query(t, "time_series", `SELECT $__timeGroupAlias(ts, '1m'), sensor AS metric, sum(value) AS value
+FROM readings WHERE $__timeFilter(ts) GROUP BY 1, 2 ORDER BY 1`)
+		require.Len(t, frame.Fields, 3)
+		require.Equal(t, int64(1704067200), frame.Fields[0].At(0).(time.Time).Unix())
+		require.Equal(t, int64(1704067260), frame.Fields[0].At(1).(time.Time).Unix())
+		require.Equal(t, "a", frame.Fields[1].Name)
+		require.Equal(t, 4.0, *frame.Fields[1].At(0).(*float64))
+		require.Equal(t, "b", frame.Fields[2].Name)
+		require.Equal(t, 2.0, *frame.Fields[2].At(1).(*float64))
+	})
+
+	t.Run("should query attached files", func(t *testing.T) {
+		frame := query(t, "table", "SELECT $__time(time), message FROM events")
+		require.Equal(t, int64(1704067230), frame.Fields[0].At(0).(*time.Time).Unix())
+		require.Equal(t, "started", *frame.Fields[1].At(0).(*string))
+	})
+
+	t.Run("should not allow writes or attaching databases", func(t *testing.T) {
+		for _, rawSQL := range []string{
+			"INSERT INTO readings VALUES ('2024-01-01 00:00:00', 'c', 1)",
+			"DELETE FROM events",
+			"ATTACH DATABASE '/tmp/other.db' AS other",
+		} {
+			model, err := json.Marshal(map[string]any{"rawSql": rawSQL, "format": "table"})
+			require.NoError(t, err)
+			resp, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+				PluginContext: pluginCtx,
+				Queries:       []backend.DataQuery{{RefID: "A", JSON: model}},
+			})
+			require.NoError(t, err)
+			require.Error(t, resp.Responses["A"].Error, rawSQL)
+		}
+	})
+
+	t.Run("should list the tables of the database and of the attached files", func(t *testing.T) {
+		sender := &fakeResourceSender{}
+		err := s.CallResource(context.Background(), &backend.CallResourceRequest{PluginContext: pluginCtx, Method: "GET", Path: "tables", URL: "tables"}, sender)
+		require.NoError(t, err)
+		require.JSONEq(t, `["events", "readings"]`, string(sender.response.Body))
+
+		err = s.CallResource(context.Background(), &backend.CallResourceRequest{PluginContext: pluginCtx, Method: "GET", Path: "columns", URL: "columns?schema=temp&table=events"}, sender)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"name": "time", "type": "INTEGER", "nullable": true}, {"name": "message", "type": "TEXT", "nullable": true}]`, string(sender.response.Body))
+	})
+
+	t.Run("should reject databases outside of the allowed directories", func(t *testing.T) {
+		jsonData, err := json.Marshal(JsonData{Path: "/etc/passwd"})
+		require.NoError(t, err)
+		res, err := s.CheckHealth(context.Background(), &backend.CheckHealthRequest{PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: 2, UID: "other", JSONData: jsonData},
+		}})
+		require.NoError(t, err)
+		require.Equal(t, backend.HealthStatusError, res.Status)
+	})
+}
+
+type fakeResourceSender struct {
+	response *backend.CallResourceResponse
+}
+
+func (s *fakeResourceSender) Send(resp *backend.CallResourceResponse) error {
+	s.response = resp
+	return nil
+}
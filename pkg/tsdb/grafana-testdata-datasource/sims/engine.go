@@ -66,6 +66,9 @@ func NewSimulationEngine() (*SimulationEngine, error) {
 		newFlightSimInfo,
 		newSinewaveInfo,
 		newTankSimInfo,
+		newKubernetesSimInfo,
+		newHTTPServiceSimInfo,
+		newLogGeneratorSimInfo,
 	}
 
 	for _, init := range initializers {
@@ -165,9 +168,10 @@ func (s *SimulationEngine) QueryData(ctx context.Context, req *backend.QueryData
 
 		if sq.Stream && req.PluginContext.DataSourceInstanceSettings != nil {
 			uid := req.PluginContext.DataSourceInstanceSettings.UID
-			frame.Meta = &data.FrameMeta{
-				Channel: fmt.Sprintf("ds/%s/sim/%s", uid, sim.GetState().Key.String()),
+			if frame.Meta == nil {
+				frame.Meta = &data.FrameMeta{}
 			}
+			frame.Meta.Channel = fmt.Sprintf("ds/%s/sim/%s", uid, sim.GetState().Key.String())
 		}
 
 		respD := resp.Responses[q.RefID]
@@ -0,0 +1,212 @@
+package sims
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type httpServiceSim struct {
+	key simulationKey
+	cfg httpServiceConfig
+}
+
+var (
+	_ Simulation = (*httpServiceSim)(nil)
+)
+
+type httpServiceConfig struct {
+	Seed           int64   `json:"seed"`
+	RequestRate    float64 `json:"requestRate"`    // requests/second
+	LatencyMedian  float64 `json:"latencyMedian"`  // milliseconds
+	LatencySpread  float64 `json:"latencySpread"`  // standard deviation of the log-normal latency
+	ErrorRate      float64 `json:"errorRate"`      // 0-1
+	BurstsPerHour  float64 `json:"burstsPerHour"`  // how often errors burst
+	BurstDuration  float64 `json:"burstDuration"`  // seconds
+	BurstErrorRate float64 `json:"burstErrorRate"` // 0-1, while errors burst
+}
+
+const (
+	// error bursts start at a random time in windows of this length
+	burstWindow = 10 * time.Minute
+	// the latency is higher while errors burst
+	burstLatencyFactor = 3
+)
+
+// latencyBuckets are the upper bounds of the buckets of the latency histogram (seconds)
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, math.Inf(1)}
+
+func (s *httpServiceSim) GetState() simulationState {
+	return simulationState{
+		Key:    s.key,
+		Config: s.cfg,
+	}
+}
+
+func (s *httpServiceSim) SetConfig(vals map[string]any) error {
+	return updateConfigObjectFromJSON(&s.cfg, vals)
+}
+
+func bucketName(le float64) string {
+	return strconv.FormatFloat(le, 'g', -1, 64)
+}
+
+func (s *httpServiceSim) NewFrame(size int) *data.Frame {
+	frame := data.NewFrameOfFieldTypes("", size,
+		data.FieldTypeTime,    // time
+		data.FieldTypeFloat64, // requests
+		data.FieldTypeFloat64, // errors
+		data.FieldTypeFloat64, // errorRatio
+		data.FieldTypeFloat64, // latencyP50
+		data.FieldTypeFloat64, // latencyP90
+		data.FieldTypeFloat64, // latencyP99
+		data.FieldTypeBool,    // burst
+	)
+	frame.Fields[0].Name = "time"
+	frame.Fields[1].Name = "requests"
+	frame.Fields[1].Config = &data.FieldConfig{Unit: "reqps"}
+	frame.Fields[2].Name = "errors"
+	frame.Fields[2].Config = &data.FieldConfig{Unit: "reqps"}
+	frame.Fields[3].Name = "errorRatio"
+	frame.Fields[3].Config = &data.FieldConfig{Unit: "percentunit"}
+	frame.Fields[4].Name = "latencyP50"
+	frame.Fields[5].Name = "latencyP90"
+	frame.Fields[6].Name = "latencyP99"
+	for _, f := range frame.Fields[4:7] {
+		f.Config = &data.FieldConfig{Unit: "ms"}
+	}
+	frame.Fields[7].Name = "burst"
+
+	// the requests per second in each bucket of the latency histogram
+	for _, le := range latencyBuckets {
+		f := data.NewFieldFromFieldType(data.FieldTypeFloat64, size)
+		f.Name = bucketName(le)
+		f.Labels = data.Labels{"le": f.Name}
+		f.Config = &data.FieldConfig{Unit: "reqps"}
+		frame.Fields = append(frame.Fields, f)
+	}
+	return frame
+}
+
+// inBurst returns true when errors burst at the time. A burst starts at a random time in a window with the
+// probability of the bursts per hour.
+func (s *httpServiceSim) inBurst(t time.Time) bool {
+	duration := time.Duration(s.cfg.BurstDuration * float64(time.Second))
+	p := math.Min(s.cfg.BurstsPerHour*burstWindow.Hours(), 1)
+	if duration <= 0 || p <= 0 {
+		return false
+	}
+
+	window := t.Truncate(burstWindow)
+	windows := int(duration/burstWindow) + 1
+	for i := 0; i <= windows; i++ {
+		w := window.Add(-time.Duration(i) * burstWindow)
+		idx := w.Unix() / int64(burstWindow.Seconds())
+		if seededFloat(s.cfg.Seed, idx) >= p {
+			continue
+		}
+		start := w.Add(time.Duration(seededFloat(s.cfg.Seed, idx, 1) * float64(burstWindow)))
+		if !t.Before(start) && t.Before(start.Add(duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *httpServiceSim) GetValues(t time.Time) map[string]any {
+	// daily traffic, with the peak at noon (UTC)
+	secondOfDay := float64(t.Sub(t.Truncate(24*time.Hour))) / float64(24*time.Hour)
+	load := 1 + 0.3*math.Sin(2*math.Pi*secondOfDay-math.Pi/2)
+
+	noise := seededFloat(s.cfg.Seed, t.UnixMilli())
+	requests := s.cfg.RequestRate * load * (0.95 + 0.1*noise)
+
+	burst := s.inBurst(t)
+	errorRate := s.cfg.ErrorRate
+	median := s.cfg.LatencyMedian * (0.9 + 0.2*noise)
+	if burst {
+		errorRate = s.cfg.BurstErrorRate
+		median *= burstLatencyFactor
+	}
+	spread := s.cfg.LatencySpread
+
+	values := map[string]any{
+		"time":       t,
+		"requests":   requests,
+		"errors":     requests * errorRate,
+		"errorRatio": errorRate,
+		"latencyP50": median,
+		"latencyP90": median * math.Exp(spread*1.2816),
+		"latencyP99": median * math.Exp(spread*2.3263),
+		"burst":      burst,
+	}
+
+	// the latency is log-normal, so the share of requests below a bound is its cumulative distribution
+	below := 0.0
+	for _, le := range latencyBuckets {
+		share := 1.0
+		if !math.IsInf(le, 1) && median > 0 && spread > 0 {
+			share = 0.5 * (1 + math.Erf((math.Log(le*1000)-math.Log(median))/(spread*math.Sqrt2)))
+		} else if !math.IsInf(le, 1) && le*1000 < median {
+			share = 0
+		}
+		values[bucketName(le)] = requests * (share - below)
+		below = share
+	}
+	return values
+}
+
+func (s *httpServiceSim) Close() error {
+	return nil
+}
+
+func newHTTPServiceSimInfo() simulationInfo {
+	hc := httpServiceConfig{
+		Seed:           1,
+		RequestRate:    100,
+		LatencyMedian:  50,
+		LatencySpread:  0.6,
+		ErrorRate:      0.01,
+		BurstsPerHour:  0.5,
+		BurstDuration:  120,
+		BurstErrorRate: 0.3,
+	}
+
+	df := data.NewFrame("")
+	df.Fields = append(df.Fields, data.NewField("seed", nil, []int64{hc.Seed}))
+	df.Fields = append(df.Fields, data.NewField("requestRate", nil, []float64{hc.RequestRate}).SetConfig(&data.FieldConfig{
+		Unit: "reqps",
+	}))
+	df.Fields = append(df.Fields, data.NewField("latencyMedian", nil, []float64{hc.LatencyMedian}).SetConfig(&data.FieldConfig{
+		Unit: "ms",
+	}))
+	df.Fields = append(df.Fields, data.NewField("latencySpread", nil, []float64{hc.LatencySpread}))
+	df.Fields = append(df.Fields, data.NewField("errorRate", nil, []float64{hc.ErrorRate}).SetConfig(&data.FieldConfig{
+		Unit: "percentunit",
+	}))
+	df.Fields = append(df.Fields, data.NewField("burstsPerHour", nil, []float64{hc.BurstsPerHour}))
+	df.Fields = append(df.Fields, data.NewField("burstDuration", nil, []float64{hc.BurstDuration}).SetConfig(&data.FieldConfig{
+		Unit: "s",
+	}))
+	df.Fields = append(df.Fields, data.NewField("burstErrorRate", nil, []float64{hc.BurstErrorRate}).SetConfig(&data.FieldConfig{
+		Unit: "percentunit",
+	}))
+
+	return simulationInfo{
+		Type:         "http",
+		Name:         "HTTP service",
+		Description:  "Requests, errors with bursts, and a latency histogram of an HTTP service",
+		ConfigFields: df,
+		OnlyForward:  false,
+		create: func(cfg simulationState) (Simulation, error) {
+			s := &httpServiceSim{
+				key: cfg.Key,
+				cfg: hc,
+			}
+			err := updateConfigObjectFromJSON(&s.cfg, cfg.Config) // override any fields
+			return s, err
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package sims
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPServiceSim(t *testing.T) {
+	s, err := NewSimulationEngine()
+	require.NoError(t, err)
+
+	sim, err := s.getSimFromPath("sim/http/1hz")
+	require.NoError(t, err)
+
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	v := sim.GetValues(at)
+	require.Equal(t, v, sim.GetValues(at))
+
+	t.Run("should spread the requests over the latency buckets", func(t *testing.T) {
+		sum := 0.0
+		for _, le := range latencyBuckets {
+			sum += v[bucketName(le)].(float64)
+		}
+		require.InDelta(t, v["requests"].(float64), sum, 1e-9)
+		require.Less(t, v["latencyP50"].(float64), v["latencyP90"].(float64))
+		require.Less(t, v["latencyP90"].(float64), v["latencyP99"].(float64))
+	})
+
+	t.Run("should burst errors", func(t *testing.T) {
+		require.NoError(t, sim.SetConfig(map[string]any{
+			"burstsPerHour": 6,
+			"burstDuration": 600,
+		}))
+		v := sim.GetValues(at)
+		require.Equal(t, true, v["burst"])
+		require.Equal(t, 0.3, v["errorRatio"])
+
+		require.NoError(t, sim.SetConfig(map[string]any{
+			"burstsPerHour": 0,
+		}))
+		v = sim.GetValues(at)
+		require.Equal(t, false, v["burst"])
+		require.Equal(t, 0.01, v["errorRatio"])
+	})
+
+	frame := sim.NewFrame(0)
+	require.Len(t, frame.Fields, 8+len(latencyBuckets))
+	require.Equal(t, "+Inf", frame.Fields[len(frame.Fields)-1].Name)
+	require.True(t, math.IsInf(latencyBuckets[len(latencyBuckets)-1], 1))
+}
@@ -0,0 +1,212 @@
+package sims
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type kubernetesSim struct {
+	key simulationKey
+	cfg kubernetesConfig
+}
+
+var (
+	_ Simulation = (*kubernetesSim)(nil)
+)
+
+type kubernetesConfig struct {
+	Seed           int64   `json:"seed"`
+	Pods           int     `json:"pods"`
+	CPUUsage       float64 `json:"cpuUsage"`       // average usage of a pod (cores)
+	MemoryLimit    float64 `json:"memoryLimit"`    // pods are OOM killed above it (MiB)
+	MemoryLeak     float64 `json:"memoryLeak"`     // memory growth of a pod (MiB/hour)
+	RestartsPerDay float64 `json:"restartsPerDay"` // crashes of a pod that are not OOM kills
+}
+
+const (
+	// pods are not ready and use more cpu right after a restart
+	podStartupDuration = 2 * time.Minute
+	podReadyDelay      = 30 * time.Second
+	// the crashes of a pod are found within this many hours
+	maxCrashSearchHours = 48
+)
+
+func (s *kubernetesSim) GetState() simulationState {
+	return simulationState{
+		Key:    s.key,
+		Config: s.cfg,
+	}
+}
+
+func (s *kubernetesSim) SetConfig(vals map[string]any) error {
+	return updateConfigObjectFromJSON(&s.cfg, vals)
+}
+
+func (s *kubernetesSim) podName(pod int) string {
+	return fmt.Sprintf("app-%08x-%05x", seededHash(s.cfg.Seed)>>32, seededHash(s.cfg.Seed, int64(pod))>>44)
+}
+
+func (s *kubernetesSim) NewFrame(size int) *data.Frame {
+	frame := data.NewFrameOfFieldTypes("", size, data.FieldTypeTime)
+	frame.Fields[0].Name = "time"
+
+	ready := data.NewFieldFromFieldType(data.FieldTypeFloat64, size)
+	ready.Name = "ready"
+	frame.Fields = append(frame.Fields, ready)
+
+	for pod := 0; pod < s.cfg.Pods; pod++ {
+		name := s.podName(pod)
+		labels := data.Labels{"pod": name}
+
+		cpu := data.NewFieldFromFieldType(data.FieldTypeFloat64, size)
+		cpu.Name = "cpu " + name
+		cpu.Labels = labels
+		cpu.Config = &data.FieldConfig{Unit: "cores"}
+
+		memory := data.NewFieldFromFieldType(data.FieldTypeFloat64, size)
+		memory.Name = "memory " + name
+		memory.Labels = labels
+		memory.Config = &data.FieldConfig{Unit: "bytes"}
+
+		restarts := data.NewFieldFromFieldType(data.FieldTypeFloat64, size)
+		restarts.Name = "restarts " + name
+		restarts.Labels = labels
+
+		frame.Fields = append(frame.Fields, cpu, memory, restarts)
+	}
+	return frame
+}
+
+// baseMemory is the memory of a pod right after it started (MiB)
+func (s *kubernetesSim) baseMemory() float64 {
+	return s.cfg.MemoryLimit * 0.3
+}
+
+// nextCrash returns the first crash of a pod after the time. Every hour, a pod crashes at a random time with the
+// probability of its restarts per day.
+func (s *kubernetesSim) nextCrash(pod int, after time.Time) (time.Time, bool) {
+	p := math.Min(s.cfg.RestartsPerDay/24, 1)
+	hour := after.Truncate(time.Hour)
+	for i := 0; i < maxCrashSearchHours; i++ {
+		h := hour.Unix() / 3600
+		if seededFloat(s.cfg.Seed, int64(pod), h) < p {
+			crash := hour.Add(time.Duration(seededFloat(s.cfg.Seed, int64(pod), h, 1) * float64(time.Hour)))
+			if crash.After(after) {
+				return crash, true
+			}
+		}
+		hour = hour.Add(time.Hour)
+	}
+	return time.Time{}, false
+}
+
+// podRestarts returns the restarts of a pod since the start of the day (UTC), when the pods are replaced by a
+// rollout, and its last start. Pods restart when they crash or reach the memory limit.
+func (s *kubernetesSim) podRestarts(pod int, t time.Time) (int, time.Time) {
+	last := t.Truncate(24 * time.Hour)
+	restarts := 0
+
+	var oomAfter time.Duration
+	if s.cfg.MemoryLeak > 0 {
+		oomAfter = time.Duration((s.cfg.MemoryLimit - s.baseMemory()) / s.cfg.MemoryLeak * float64(time.Hour))
+		// a pod is not OOM killed before it started
+		if oomAfter < podStartupDuration {
+			oomAfter = podStartupDuration
+		}
+	}
+	for {
+		next, ok := s.nextCrash(pod, last)
+		if oomAfter > 0 {
+			if oom := last.Add(oomAfter); !ok || oom.Before(next) {
+				next, ok = oom, true
+			}
+		}
+		if !ok || next.After(t) {
+			return restarts, last
+		}
+		restarts++
+		last = next
+	}
+}
+
+func (s *kubernetesSim) GetValues(t time.Time) map[string]any {
+	// daily load, with the peak at noon (UTC)
+	secondOfDay := float64(t.Sub(t.Truncate(24*time.Hour))) / float64(24*time.Hour)
+	load := 1 + 0.3*math.Sin(2*math.Pi*secondOfDay-math.Pi/2)
+
+	values := map[string]any{
+		"time": t,
+	}
+	ready := 0.0
+	for pod := 0; pod < s.cfg.Pods; pod++ {
+		name := s.podName(pod)
+		restarts, last := s.podRestarts(pod, t)
+		uptime := t.Sub(last)
+
+		noise := seededFloat(s.cfg.Seed, int64(pod), t.UnixMilli())
+		cpu := s.cfg.CPUUsage * load * (0.9 + 0.2*noise)
+		if uptime < podStartupDuration {
+			cpu *= 2
+		}
+		if uptime >= podReadyDelay {
+			ready++
+		}
+
+		memory := s.baseMemory() + s.cfg.MemoryLeak*uptime.Hours() + noise*s.cfg.MemoryLimit*0.02
+		memory = math.Min(memory, s.cfg.MemoryLimit)
+
+		values["cpu "+name] = cpu
+		values["memory "+name] = memory * 1024 * 1024
+		values["restarts "+name] = float64(restarts)
+	}
+	values["ready"] = ready
+	return values
+}
+
+func (s *kubernetesSim) Close() error {
+	return nil
+}
+
+func newKubernetesSimInfo() simulationInfo {
+	kc := kubernetesConfig{
+		Seed:           1,
+		Pods:           5,
+		CPUUsage:       0.5,
+		MemoryLimit:    512,
+		MemoryLeak:     20,
+		RestartsPerDay: 2,
+	}
+
+	df := data.NewFrame("")
+	df.Fields = append(df.Fields, data.NewField("seed", nil, []int64{kc.Seed}))
+	df.Fields = append(df.Fields, data.NewField("pods", nil, []int64{int64(kc.Pods)}))
+	df.Fields = append(df.Fields, data.NewField("cpuUsage", nil, []float64{kc.CPUUsage}).SetConfig(&data.FieldConfig{
+		Unit: "cores",
+	}))
+	df.Fields = append(df.Fields, data.NewField("memoryLimit", nil, []float64{kc.MemoryLimit}).SetConfig(&data.FieldConfig{
+		Unit: "mbytes",
+	}))
+	df.Fields = append(df.Fields, data.NewField("memoryLeak", nil, []float64{kc.MemoryLeak}).SetConfig(&data.FieldConfig{
+		Unit: "mbytes",
+	}))
+	df.Fields = append(df.Fields, data.NewField("restartsPerDay", nil, []float64{kc.RestartsPerDay}))
+
+	return simulationInfo{
+		Type:         "kubernetes",
+		Name:         "Kubernetes",
+		Description:  "Pods of a deployment with CPU and memory usage, and restarts",
+		ConfigFields: df,
+		OnlyForward:  false,
+		create: func(cfg simulationState) (Simulation, error) {
+			s := &kubernetesSim{
+				key: cfg.Key,
+				cfg: kc,
+			}
+			err := updateConfigObjectFromJSON(&s.cfg, cfg.Config) // override any fields
+			return s, err
+		},
+	}
+}
@@ -0,0 +1,49 @@
+package sims
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubernetesSim(t *testing.T) {
+	s, err := NewSimulationEngine()
+	require.NoError(t, err)
+
+	sim, err := s.getSimFromPath("sim/kubernetes/1hz")
+	require.NoError(t, err)
+	require.NoError(t, sim.SetConfig(map[string]any{
+		"pods":           3,
+		"memoryLeak":     100,
+		"restartsPerDay": 0,
+	}))
+
+	frame := sim.NewFrame(0)
+	require.Len(t, frame.Fields, 2+3*3)
+
+	t.Run("should restart pods that reach the memory limit", func(t *testing.T) {
+		k := sim.(*kubernetesSim)
+		day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		// 512MiB limit, started at 30% of it and growing 100MiB/hour
+		restarts, last := k.podRestarts(0, day.Add(4*time.Hour))
+		require.Equal(t, 1, restarts)
+		require.InDelta(t, 3.584, last.Sub(day).Hours(), 1e-6)
+
+		restarts, last = k.podRestarts(0, day.Add(time.Minute))
+		require.Equal(t, 0, restarts)
+		require.Equal(t, day, last)
+	})
+
+	t.Run("should return the same values for the same time", func(t *testing.T) {
+		at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+		v := sim.GetValues(at)
+		require.Equal(t, v, sim.GetValues(at))
+		require.Equal(t, 3.0, v["ready"])
+
+		name := sim.(*kubernetesSim).podName(1)
+		require.InDelta(t, 0.5, v["cpu "+name], 0.25)
+		require.LessOrEqual(t, v["memory "+name], 512.0*1024*1024)
+	})
+}
@@ -0,0 +1,168 @@
+package sims
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+type logGeneratorSim struct {
+	key simulationKey
+	cfg logGeneratorConfig
+}
+
+var (
+	_ Simulation = (*logGeneratorSim)(nil)
+)
+
+type logGeneratorConfig struct {
+	Seed      int64   `json:"seed"`
+	Services  string  `json:"services"`  // comma separated
+	ErrorRate float64 `json:"errorRate"` // 0-1
+	WarnRate  float64 `json:"warnRate"`  // 0-1
+	DebugRate float64 `json:"debugRate"` // 0-1
+}
+
+var (
+	logMethods = []string{"GET", "GET", "GET", "POST", "PUT", "DELETE"}
+	logPaths   = []string{"/api/users", "/api/orders", "/api/orders/:id", "/api/cart", "/api/search", "/health"}
+)
+
+func (s *logGeneratorSim) GetState() simulationState {
+	return simulationState{
+		Key:    s.key,
+		Config: s.cfg,
+	}
+}
+
+func (s *logGeneratorSim) SetConfig(vals map[string]any) error {
+	return updateConfigObjectFromJSON(&s.cfg, vals)
+}
+
+func (s *logGeneratorSim) NewFrame(size int) *data.Frame {
+	frame := data.NewFrameOfFieldTypes("", size,
+		data.FieldTypeTime,   // timestamp
+		data.FieldTypeString, // body
+		data.FieldTypeString, // severity
+		data.FieldTypeString, // id
+		data.FieldTypeJSON,   // labels
+		data.FieldTypeString, // traceID
+	)
+	frame.Fields[0].Name = "timestamp"
+	frame.Fields[1].Name = "body"
+	frame.Fields[2].Name = "severity"
+	frame.Fields[3].Name = "id"
+	frame.Fields[4].Name = "labels"
+	frame.Fields[5].Name = "traceID"
+	frame.Meta = &data.FrameMeta{
+		Type:                   data.FrameTypeLogLines,
+		PreferredVisualization: data.VisTypeLogs,
+	}
+	return frame
+}
+
+func (s *logGeneratorSim) services() []string {
+	var services []string
+	for _, service := range strings.Split(s.cfg.Services, ",") {
+		if service = strings.TrimSpace(service); service != "" {
+			services = append(services, service)
+		}
+	}
+	if len(services) == 0 {
+		services = []string{"app"}
+	}
+	return services
+}
+
+// GetValues returns a log line of a request. The line is the same for the same time and seed.
+func (s *logGeneratorSim) GetValues(t time.Time) map[string]any {
+	gen := seededRand(s.cfg.Seed, t.UnixNano())
+
+	services := s.services()
+	service := services[gen.Intn(len(services))]
+	method := logMethods[gen.Intn(len(logMethods))]
+	path := logPaths[gen.Intn(len(logPaths))]
+	traceID := fmt.Sprintf("%016x%016x", gen.Uint64(), gen.Uint64())
+	duration := 50 * math.Exp(0.6*gen.NormFloat64())
+
+	level := "info"
+	status := 200
+	msg := "request completed"
+	switch r := gen.Float64(); {
+	case r < s.cfg.ErrorRate:
+		level = "error"
+		status = []int{500, 502, 503, 504}[gen.Intn(4)]
+		msg = "request failed"
+	case r < s.cfg.ErrorRate+s.cfg.WarnRate:
+		level = "warn"
+		status = []int{400, 401, 404, 429}[gen.Intn(4)]
+		msg = "request rejected"
+	case r < s.cfg.ErrorRate+s.cfg.WarnRate+s.cfg.DebugRate:
+		level = "debug"
+		msg = "served from cache"
+		duration /= 10
+	}
+
+	body := fmt.Sprintf("level=%s msg=%q service=%s method=%s path=%s status=%d duration=%.1fms traceID=%s",
+		level, msg, service, method, path, status, duration, traceID)
+	labels, _ := json.Marshal(map[string]string{
+		"service": service,
+		"level":   level,
+	})
+
+	return map[string]any{
+		"timestamp": t,
+		"body":      body,
+		"severity":  level,
+		"id":        fmt.Sprintf("%d_%s", t.UnixNano(), traceID[:8]),
+		"labels":    json.RawMessage(labels),
+		"traceID":   traceID,
+	}
+}
+
+func (s *logGeneratorSim) Close() error {
+	return nil
+}
+
+func newLogGeneratorSimInfo() simulationInfo {
+	lc := logGeneratorConfig{
+		Seed:      1,
+		Services:  "frontend,checkout,payments",
+		ErrorRate: 0.02,
+		WarnRate:  0.08,
+		DebugRate: 0.2,
+	}
+
+	df := data.NewFrame("")
+	df.Fields = append(df.Fields, data.NewField("seed", nil, []int64{lc.Seed}))
+	df.Fields = append(df.Fields, data.NewField("services", nil, []string{lc.Services}))
+	df.Fields = append(df.Fields, data.NewField("errorRate", nil, []float64{lc.ErrorRate}).SetConfig(&data.FieldConfig{
+		Unit: "percentunit",
+	}))
+	df.Fields = append(df.Fields, data.NewField("warnRate", nil, []float64{lc.WarnRate}).SetConfig(&data.FieldConfig{
+		Unit: "percentunit",
+	}))
+	df.Fields = append(df.Fields, data.NewField("debugRate", nil, []float64{lc.DebugRate}).SetConfig(&data.FieldConfig{
+		Unit: "percentunit",
+	}))
+
+	return simulationInfo{
+		Type:         "logs",
+		Name:         "Logs",
+		Description:  "Structured request logs with levels and trace IDs",
+		ConfigFields: df,
+		OnlyForward:  false,
+		create: func(cfg simulationState) (Simulation, error) {
+			s := &logGeneratorSim{
+				key: cfg.Key,
+				cfg: lc,
+			}
+			err := updateConfigObjectFromJSON(&s.cfg, cfg.Config) // override any fields
+			return s, err
+		},
+	}
+}
@@ -0,0 +1,72 @@
+package sims
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogGeneratorQuery(t *testing.T) {
+	s, err := NewSimulationEngine()
+	require.NoError(t, err)
+
+	query := func(seed int64) *data.Frame {
+		sq := &simulationQuery{}
+		sq.Key = simulationKey{
+			Type:   "logs",
+			TickHZ: 1,
+			UID:    "seed",
+		}
+		sq.Stream = true
+		sb, err := json.Marshal(map[string]any{
+			"sim": sq,
+		})
+		require.NoError(t, err)
+
+		sim, err := s.Lookup(sq.simulationState)
+		require.NoError(t, err)
+		require.NoError(t, sim.SetConfig(map[string]any{"seed": seed}))
+
+		start := time.Date(2020, time.January, 10, 23, 0, 0, 0, time.UTC)
+		rsp, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "testdata"},
+			},
+			Queries: []backend.DataQuery{
+				{
+					RefID: "A",
+					TimeRange: backend.TimeRange{
+						From: start,
+						To:   start.Add(time.Second * 10),
+					},
+					Interval:      time.Second,
+					MaxDataPoints: 10,
+					JSON:          sb,
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, rsp.Responses["A"].Frames, 1)
+		return rsp.Responses["A"].Frames[0]
+	}
+
+	frame := query(1)
+	require.Equal(t, 10, frame.Rows())
+	require.Equal(t, data.FrameTypeLogLines, frame.Meta.Type)
+	require.Equal(t, "ds/testdata/sim/logs/1hz/seed", frame.Meta.Channel)
+
+	for i := 0; i < frame.Rows(); i++ {
+		body := frame.Fields[1].At(i).(string)
+		require.Contains(t, body, "level="+frame.Fields[2].At(i).(string))
+		require.Contains(t, body, "traceID="+frame.Fields[5].At(i).(string))
+		require.Len(t, frame.Fields[5].At(i).(string), 32)
+	}
+
+	require.Equal(t, frame.Fields[1].At(0), query(1).Fields[1].At(0))
+	require.NotEqual(t, frame.Fields[1].At(0), query(2).Fields[1].At(0))
+}
@@ -1,7 +1,10 @@
 package sims
 
 import (
+	"encoding/binary"
 	"encoding/json"
+	"hash/fnv"
+	"math/rand"
 	"net/http"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
@@ -74,3 +77,26 @@ func getBodyFromRequest(req *http.Request) (map[string]any, error) {
 	// TODO? create the map based on form parameters not JSON post
 	return result, err
 }
+
+// seededHash returns a hash of the seed and the parts, so that simulations return the same values for the same time.
+func seededHash(seed int64, parts ...int64) uint64 {
+	h := fnv.New64a()
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(seed))
+	_, _ = h.Write(b)
+	for _, p := range parts {
+		binary.LittleEndian.PutUint64(b, uint64(p))
+		_, _ = h.Write(b)
+	}
+	return h.Sum64()
+}
+
+// seededFloat returns a number in [0, 1) for the seed and the parts.
+func seededFloat(seed int64, parts ...int64) float64 {
+	return float64(seededHash(seed, parts...)>>11) / (1 << 53)
+}
+
+// seededRand returns a random generator for the seed and the parts.
+func seededRand(seed int64, parts ...int64) *rand.Rand {
+	return rand.New(rand.NewSource(int64(seededHash(seed, parts...))))
+}
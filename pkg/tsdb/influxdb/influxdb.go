@@ -94,8 +94,6 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 	logger := logger.FromContext(ctx)
 	logger.Debug("Received a query request", "numQueries", len(req.Queries))
 
-	tracer := tracing.DefaultTracer()
-
 	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
 	if err != nil {
 		return nil, err
@@ -103,11 +101,17 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 
 	logger.Debug(fmt.Sprintf("Making a %s type query", dsInfo.Version))
 
+	return s.query(ctx, dsInfo, req)
+}
+
+// query runs the queries with the query language of the version of the data source. It is shared by QueryData, the
+// resources and the streams.
+func (s *Service) query(ctx context.Context, dsInfo *models.DatasourceInfo, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	switch dsInfo.Version {
 	case influxVersionFlux:
 		return flux.Query(ctx, dsInfo, *req)
 	case influxVersionInfluxQL:
-		return influxql.Query(ctx, tracer, dsInfo, req, s.features)
+		return influxql.Query(ctx, tracing.DefaultTracer(), dsInfo, req, s.features)
 	case influxVersionSQL:
 		return fsql.Query(ctx, dsInfo, *req)
 	default:
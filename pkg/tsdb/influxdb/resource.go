@@ -0,0 +1,309 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/tsdb/influxdb/models"
+)
+
+const (
+	resourceRefID = "metadata"
+	// defaultPolicy is the retention policy the query editor uses for the default retention policy of the database.
+	defaultPolicy = "default"
+)
+
+// errUnsupportedResource is returned for the resources the query language of the data source has no query for.
+var errUnsupportedResource = errors.New("resource is not supported by the query language of the data source")
+
+var regexMeasurement = regexp.MustCompile(`^/.*/$`)
+
+// metadataQuery is the query of a resource. The values of the resource are read from the field with the name
+// valueField, or from the only string field of the frames if there is no such field.
+type metadataQuery struct {
+	json       []byte
+	valueField string
+}
+
+// CallResource serves the metadata of the database that the query editors need, so that the browser does not query
+// the database for it:
+//
+//	measurements                               the measurements of the database
+//	tag-keys?measurement=cpu                   the tag keys of a measurement
+//	tag-values?measurement=cpu&tag=host        the values of a tag of a measurement
+//	field-keys?measurement=cpu                 the field keys of a measurement
+//	buckets                                    the buckets, or the databases with InfluxQL
+//	retention-policies                         the retention policies of the database, InfluxQL only
+//
+// With InfluxQL, the resources of measurements take an optional policy parameter with the retention policy. With
+// Flux, they take an optional bucket parameter, which defaults to the default bucket of the data source. Every
+// resource responds with a JSON array of strings.
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req.Method != http.MethodGet {
+		return sendResourceError(sender, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", req.Method))
+	}
+
+	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		return sendResourceError(sender, http.StatusInternalServerError, err)
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return sendResourceError(sender, http.StatusBadRequest, err)
+	}
+	resource := strings.Trim(req.Path, "/")
+
+	q, err := buildMetadataQuery(dsInfo, resource, u.Query())
+	if errors.Is(err, errUnsupportedResource) {
+		return sendResourceError(sender, http.StatusNotFound, fmt.Errorf("%w: %s", err, resource))
+	}
+	if err != nil {
+		return sendResourceError(sender, http.StatusBadRequest, err)
+	}
+
+	values, err := s.queryMetadata(ctx, dsInfo, req.PluginContext, q)
+	if err != nil {
+		logger.FromContext(ctx).Warn("Failed to query resource", "resource", resource, "error", err)
+		return sendResourceError(sender, http.StatusInternalServerError, err)
+	}
+
+	body, err := json.Marshal(values)
+	if err != nil {
+		return sendResourceError(sender, http.StatusInternalServerError, err)
+	}
+	return sendResourceResponse(sender, http.StatusOK, body)
+}
+
+func (s *Service) queryMetadata(ctx context.Context, dsInfo *models.DatasourceInfo, pluginCtx backend.PluginContext, q metadataQuery) ([]string, error) {
+	now := time.Now()
+	resp, err := s.query(ctx, dsInfo, &backend.QueryDataRequest{
+		PluginContext: pluginCtx,
+		Queries: []backend.DataQuery{
+			{
+				RefID:         resourceRefID,
+				JSON:          q.json,
+				Interval:      time.Minute,
+				MaxDataPoints: 1000,
+				TimeRange: backend.TimeRange{
+					From: now.AddDate(0, 0, -1),
+					To:   now,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := resp.Responses[resourceRefID]
+	if !ok {
+		return nil, fmt.Errorf("missing response")
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	return frameValues(res.Frames, q.valueField), nil
+}
+
+// buildMetadataQuery returns the query of a resource in the query language of the data source.
+func buildMetadataQuery(dsInfo *models.DatasourceInfo, resource string, params url.Values) (metadataQuery, error) {
+	measurement := params.Get("measurement")
+	tag := params.Get("tag")
+	switch resource {
+	case "measurements", "buckets", "retention-policies":
+	case "tag-keys", "field-keys":
+		if measurement == "" {
+			return metadataQuery{}, fmt.Errorf("missing measurement parameter")
+		}
+	case "tag-values":
+		if measurement == "" || tag == "" {
+			return metadataQuery{}, fmt.Errorf("missing measurement or tag parameter")
+		}
+	default:
+		return metadataQuery{}, errUnsupportedResource
+	}
+
+	switch dsInfo.Version {
+	case influxVersionInfluxQL:
+		return influxQLMetadataQuery(dsInfo, resource, params.Get("policy"), measurement, tag)
+	case influxVersionFlux:
+		bucket := params.Get("bucket")
+		if bucket == "" {
+			bucket = dsInfo.DefaultBucket
+		}
+		return fluxMetadataQuery(resource, bucket, measurement, tag)
+	case influxVersionSQL:
+		return sqlMetadataQuery(resource, measurement, tag)
+	default:
+		return metadataQuery{}, fmt.Errorf("unknown influxdb version")
+	}
+}
+
+func influxQLMetadataQuery(dsInfo *models.DatasourceInfo, resource, policy, measurement, tag string) (metadataQuery, error) {
+	from := measurement
+	if !regexMeasurement.MatchString(measurement) {
+		from = quoteIdentifier(measurement)
+		if policy != "" && policy != defaultPolicy {
+			from = quoteIdentifier(policy) + "." + from
+		}
+	}
+
+	var query string
+	switch resource {
+	case "measurements":
+		query = "SHOW MEASUREMENTS"
+	case "tag-keys":
+		query = "SHOW TAG KEYS FROM " + from
+	case "tag-values":
+		query = "SHOW TAG VALUES FROM " + from + " WITH KEY = " + quoteIdentifier(tag)
+	case "field-keys":
+		query = "SHOW FIELD KEYS FROM " + from
+	case "buckets":
+		query = "SHOW DATABASES"
+	case "retention-policies":
+		query = "SHOW RETENTION POLICIES ON " + quoteIdentifier(dsInfo.DbName)
+	}
+
+	b, err := json.Marshal(map[string]any{"query": query, "rawQuery": true})
+	return metadataQuery{json: b, valueField: "Value"}, err
+}
+
+func fluxMetadataQuery(resource, bucket, measurement, tag string) (metadataQuery, error) {
+	if resource == "retention-policies" {
+		return metadataQuery{}, errUnsupportedResource
+	}
+	if resource != "buckets" && bucket == "" {
+		return metadataQuery{}, fmt.Errorf("missing bucket parameter and default bucket")
+	}
+
+	var query string
+	switch resource {
+	case "measurements":
+		query = fmt.Sprintf(`import "influxdata/influxdb/schema"
+schema.measurements(bucket: %s)`, fluxString(bucket))
+	case "tag-keys":
+		query = fmt.Sprintf(`import "influxdata/influxdb/schema"
+schema.measurementTagKeys(bucket: %s, measurement: %s)
+  |> filter(fn: (r) => r._value !~ /^_/)`, fluxString(bucket), fluxString(measurement))
+	case "tag-values":
+		query = fmt.Sprintf(`import "influxdata/influxdb/schema"
+schema.measurementTagValues(bucket: %s, measurement: %s, tag: %s)`, fluxString(bucket), fluxString(measurement), fluxString(tag))
+	case "field-keys":
+		query = fmt.Sprintf(`import "influxdata/influxdb/schema"
+schema.measurementFieldKeys(bucket: %s, measurement: %s)`, fluxString(bucket), fluxString(measurement))
+	case "buckets":
+		query = `buckets()
+  |> rename(columns: {name: "_value"})
+  |> keep(columns: ["_value"])`
+	}
+
+	b, err := json.Marshal(map[string]any{"query": query})
+	return metadataQuery{json: b, valueField: "_value"}, err
+}
+
+// sqlMetadataQuery returns the queries of the resources for InfluxDB 3, where measurements are tables, tags are the
+// columns of dictionary type and fields are the other columns but the time column.
+func sqlMetadataQuery(resource, measurement, tag string) (metadataQuery, error) {
+	var query string
+	switch resource {
+	case "measurements":
+		query = `SELECT table_name AS "value" FROM information_schema.tables WHERE table_schema = 'iox' ORDER BY table_name`
+	case "tag-keys":
+		query = fmt.Sprintf(`SELECT column_name AS "value" FROM information_schema.columns WHERE table_schema = 'iox' AND table_name = %s AND data_type LIKE 'Dictionary%%' ORDER BY column_name`, sqlString(measurement))
+	case "tag-values":
+		query = fmt.Sprintf(`SELECT DISTINCT %s AS "value" FROM %s WHERE time >= $__timeFrom AND %s IS NOT NULL ORDER BY "value"`, sqlIdentifier(tag), sqlIdentifier(measurement), sqlIdentifier(tag))
+	case "field-keys":
+		query = fmt.Sprintf(`SELECT column_name AS "value" FROM information_schema.columns WHERE table_schema = 'iox' AND table_name = %s AND data_type NOT LIKE 'Dictionary%%' AND column_name <> 'time' ORDER BY column_name`, sqlString(measurement))
+	default:
+		return metadataQuery{}, errUnsupportedResource
+	}
+
+	b, err := json.Marshal(map[string]any{"rawSql": query, "format": "table"})
+	return metadataQuery{json: b, valueField: "value"}, err
+}
+
+// frameValues returns the distinct string values of the value fields of the frames, in the order they appear in.
+func frameValues(frames data.Frames, valueField string) []string {
+	values := []string{}
+	seen := make(map[string]bool)
+	for _, frame := range frames {
+		field := valueFieldOf(frame, valueField)
+		if field == nil {
+			continue
+		}
+		for i := 0; i < field.Len(); i++ {
+			v, ok := field.ConcreteAt(i)
+			if !ok {
+				continue
+			}
+			s := fmt.Sprint(v)
+			if seen[s] {
+				continue
+			}
+			seen[s] = true
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+func valueFieldOf(frame *data.Frame, name string) *data.Field {
+	if field, _ := frame.FieldByName(name); field != nil {
+		return field
+	}
+	var found *data.Field
+	for _, field := range frame.Fields {
+		if field.Type().NonNullableType() != data.FieldTypeString {
+			continue
+		}
+		if found != nil {
+			return nil
+		}
+		found = field
+	}
+	return found
+}
+
+// quoteIdentifier quotes an identifier of InfluxQL.
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func sqlIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func fluxString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "${", `\${`)
+	return `"` + s + `"`
+}
+
+func sendResourceResponse(sender backend.CallResourceResponseSender, status int, body []byte) error {
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  status,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
+
+func sendResourceError(sender backend.CallResourceResponseSender, status int, err error) error {
+	body, _ := json.Marshal(map[string]string{"message": err.Error()})
+	return sendResourceResponse(sender, status, body)
+}
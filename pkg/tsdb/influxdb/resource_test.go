@@ -0,0 +1,107 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/tsdb/influxdb/models"
+)
+
+func TestBuildMetadataQuery(t *testing.T) {
+	params := url.Values{"measurement": {`cpu"load`}, "tag": {"host"}, "policy": {"autogen"}}
+
+	t.Run("should quote the identifiers of InfluxQL queries", func(t *testing.T) {
+		q, err := buildMetadataQuery(&models.DatasourceInfo{Version: influxVersionInfluxQL}, "tag-values", params)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"query":"SHOW TAG VALUES FROM \"autogen\".\"cpu\\\"load\" WITH KEY = \"host\"","rawQuery":true}`, string(q.json))
+		require.Equal(t, "Value", q.valueField)
+	})
+
+	t.Run("should keep regular expressions of measurements", func(t *testing.T) {
+		q, err := buildMetadataQuery(&models.DatasourceInfo{Version: influxVersionInfluxQL}, "field-keys", url.Values{"measurement": {"/^cpu/"}})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"query":"SHOW FIELD KEYS FROM /^cpu/","rawQuery":true}`, string(q.json))
+	})
+
+	t.Run("should query the default bucket with Flux", func(t *testing.T) {
+		q, err := buildMetadataQuery(&models.DatasourceInfo{Version: influxVersionFlux, DefaultBucket: "telegraf"}, "tag-keys", params)
+		require.NoError(t, err)
+		var model map[string]string
+		require.NoError(t, json.Unmarshal(q.json, &model))
+		require.Contains(t, model["query"], `schema.measurementTagKeys(bucket: "telegraf", measurement: "cpu\"load")`)
+		require.Equal(t, "_value", q.valueField)
+	})
+
+	t.Run("should read tag keys from the information schema with SQL", func(t *testing.T) {
+		q, err := buildMetadataQuery(&models.DatasourceInfo{Version: influxVersionSQL}, "tag-keys", url.Values{"measurement": {"o'clock"}})
+		require.NoError(t, err)
+		var model map[string]string
+		require.NoError(t, json.Unmarshal(q.json, &model))
+		require.Contains(t, model["rawSql"], `table_name = 'o''clock' AND data_type LIKE 'Dictionary%'`)
+	})
+
+	t.Run("should fail on missing parameters", func(t *testing.T) {
+		_, err := buildMetadataQuery(&models.DatasourceInfo{Version: influxVersionInfluxQL}, "tag-values", url.Values{"measurement": {"cpu"}})
+		require.ErrorContains(t, err, "missing measurement or tag parameter")
+	})
+
+	t.Run("should fail on unsupported resources", func(t *testing.T) {
+		_, err := buildMetadataQuery(&models.DatasourceInfo{Version: influxVersionSQL}, "buckets", url.Values{})
+		require.ErrorIs(t, err, errUnsupportedResource)
+		_, err = buildMetadataQuery(&models.DatasourceInfo{Version: influxVersionInfluxQL}, "series", url.Values{})
+		require.ErrorIs(t, err, errUnsupportedResource)
+	})
+}
+
+func TestCallResource(t *testing.T) {
+	s := GetMockService(influxVersionInfluxQL, RoundTripper{
+		Body: `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["key","value"],"values":[["host","server1"],["host","server2"]]},{"name":"mem","columns":["key","value"],"values":[["host","server1"]]}]}]}`,
+	})
+
+	call := func(t *testing.T, method, path, query string) *backend.CallResourceResponse {
+		sender := &fakeResourceSender{}
+		err := s.CallResource(context.Background(), &backend.CallResourceRequest{
+			Method: method,
+			Path:   path,
+			URL:    "/" + path + "?" + query,
+		}, sender)
+		require.NoError(t, err)
+		return sender.response
+	}
+
+	t.Run("should respond with the distinct values", func(t *testing.T) {
+		res := call(t, http.MethodGet, "tag-values", "measurement=/.*/&tag=host")
+		require.Equal(t, http.StatusOK, res.Status)
+		require.JSONEq(t, `["server1","server2"]`, string(res.Body))
+	})
+
+	t.Run("should respond with bad request on missing parameters", func(t *testing.T) {
+		res := call(t, http.MethodGet, "tag-keys", "")
+		require.Equal(t, http.StatusBadRequest, res.Status)
+	})
+
+	t.Run("should respond with not found on unknown resources", func(t *testing.T) {
+		res := call(t, http.MethodGet, "series", "")
+		require.Equal(t, http.StatusNotFound, res.Status)
+	})
+
+	t.Run("should only allow GET", func(t *testing.T) {
+		res := call(t, http.MethodPost, "measurements", "")
+		require.Equal(t, http.StatusMethodNotAllowed, res.Status)
+	})
+}
+
+type fakeResourceSender struct {
+	response *backend.CallResourceResponse
+}
+
+func (s *fakeResourceSender) Send(resp *backend.CallResourceResponse) error {
+	s.response = resp
+	return nil
+}
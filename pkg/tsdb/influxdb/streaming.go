@@ -0,0 +1,273 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	streamRefID = "stream"
+	// defaultStreamInterval is the interval the streams poll with when the query has no intervalMs.
+	defaultStreamInterval = 10 * time.Second
+	minStreamInterval     = time.Second
+	// streamReplaceOverlapMeta tells the subscribers that the rows of a message replace the rows from its first time on.
+	streamReplaceOverlapMeta = "streamReplaceOverlap"
+)
+
+// streamQuery holds the properties of the query model of a stream that the polling depends on. The rest of the query
+// model is passed to the query language of the data source as is.
+type streamQuery struct {
+	IntervalMs    int64 `json:"intervalMs"`
+	MaxDataPoints int64 `json:"maxDataPoints"`
+}
+
+func parseStreamQuery(raw json.RawMessage) (*streamQuery, time.Duration, error) {
+	q := &streamQuery{}
+	if err := json.Unmarshal(raw, q); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse stream query: %w", err)
+	}
+	interval := time.Duration(q.IntervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = defaultStreamInterval
+	}
+	if interval < minStreamInterval {
+		interval = minStreamInterval
+	}
+	return q, interval, nil
+}
+
+// SubscribeStream accepts the subscriptions of the channels with the path stream/${key}, where the data of the
+// subscription is the query model.
+func (s *Service) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	if _, err := s.getDSInfo(ctx, req.PluginContext); err != nil {
+		return &backend.SubscribeStreamResponse{
+			Status: backend.SubscribeStreamStatusNotFound,
+		}, err
+	}
+
+	if !strings.HasPrefix(req.Path, "stream/") {
+		return &backend.SubscribeStreamResponse{
+			Status: backend.SubscribeStreamStatusNotFound,
+		}, fmt.Errorf("expected stream in channel path")
+	}
+
+	if _, _, err := parseStreamQuery(req.Data); err != nil {
+		return &backend.SubscribeStreamResponse{
+			Status: backend.SubscribeStreamStatusNotFound,
+		}, err
+	}
+
+	return &backend.SubscribeStreamResponse{
+		Status: backend.SubscribeStreamStatusOK,
+	}, nil
+}
+
+// RunStream polls the query of the channel every intervalMs with a time range that moves with now(). The first poll
+// queries the last interval, and every further poll queries the time since the previous poll with an overlap of at
+// least one GROUP BY time() bucket, as the last bucket is not complete until it ends and points can arrive late. Only
+// the rows from the last sent row on are sent to the subscribers, which replace the last sent row with the resent
+// one. The time series of every poll are joined into a single wide frame.
+func (s *Service) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		return err
+	}
+
+	q, interval, err := parseStreamQuery(req.Data)
+	if err != nil {
+		return err
+	}
+
+	logger := logger.FromContext(ctx)
+	prev := data.FrameJSONCache{}
+	// last is the end of the previous poll and sent the time of the last sent row.
+	var last, sent time.Time
+	// bucket is the GROUP BY time() of the query, guessed from the times of the rows.
+	bucket := interval
+
+	poll := func(now time.Time) error {
+		from := streamFrom(now, last, sent, interval, bucket)
+		resp, err := s.query(ctx, dsInfo, &backend.QueryDataRequest{
+			PluginContext: req.PluginContext,
+			Queries: []backend.DataQuery{
+				{
+					RefID:         streamRefID,
+					JSON:          req.Data,
+					Interval:      interval,
+					MaxDataPoints: q.MaxDataPoints,
+					TimeRange:     backend.TimeRange{From: from, To: now},
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		res := resp.Responses[streamRefID]
+		if res.Error != nil {
+			return res.Error
+		}
+
+		// The rows before the last sent row were sent already, the last one is sent again as its bucket may have
+		// changed.
+		frame := joinTimeSeriesFrames(res.Frames, sent)
+		last = now
+		if frame == nil || frame.Rows() == 0 {
+			return nil
+		}
+		frame.SetMeta(&data.FrameMeta{Custom: map[string]any{streamReplaceOverlapMeta: true}})
+		times := frame.Fields[0]
+		sent = times.At(times.Len() - 1).(time.Time)
+		if step := minTimeStep(times); step > bucket {
+			bucket = step
+		}
+
+		next, err := data.FrameToJSONCache(frame)
+		if err != nil {
+			return err
+		}
+		if next.SameSchema(&prev) {
+			err = sender.SendBytes(next.Bytes(data.IncludeDataOnly))
+		} else {
+			err = sender.SendFrame(frame, data.IncludeAll)
+		}
+		prev = next
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := poll(time.Now()); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logger.Warn("Failed to poll stream", "path", req.Path, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Debug("Stop streaming (context canceled)", "path", req.Path)
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// streamFrom returns the start of the time range of a poll, given the end of the previous poll and the time of the
+// last sent row.
+func streamFrom(now, last, sent time.Time, interval, bucket time.Duration) time.Time {
+	from := now.Add(-interval)
+	if !last.IsZero() {
+		from = last.Add(-bucket)
+	}
+	// A time range that starts within a bucket only gets a part of the bucket. InfluxDB aligns the buckets to
+	// multiples of their duration since the epoch.
+	from = time.Unix(0, from.UnixNano()-from.UnixNano()%int64(bucket))
+	if !sent.IsZero() && sent.Before(from) && sent.Add(bucket).After(from) {
+		from = sent
+	}
+	return from
+}
+
+func (s *Service) PublishStream(_ context.Context, _ *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return &backend.PublishStreamResponse{
+		Status: backend.PublishStreamStatusPermissionDenied,
+	}, nil
+}
+
+// joinTimeSeriesFrames joins the frames with a time field into a single wide frame with a row per distinct time
+// at or after the given time. The value fields become nullable, as not every series has a value at every time. Frames
+// without a time field are dropped.
+func joinTimeSeriesFrames(frames data.Frames, from time.Time) *data.Frame {
+	type series struct {
+		field  *data.Field
+		values map[int64]int
+	}
+
+	var all []series
+	times := map[int64]time.Time{}
+	for _, frame := range frames {
+		timeIndex := -1
+		for i, field := range frame.Fields {
+			if field.Type().NonNullableType() == data.FieldTypeTime {
+				timeIndex = i
+				break
+			}
+		}
+		if timeIndex < 0 {
+			continue
+		}
+
+		rows := map[int64]int{}
+		for row := 0; row < frame.Rows(); row++ {
+			v, ok := frame.Fields[timeIndex].ConcreteAt(row)
+			if !ok {
+				continue
+			}
+			t := v.(time.Time)
+			if t.Before(from) {
+				continue
+			}
+			rows[t.UnixNano()] = row
+			times[t.UnixNano()] = t
+		}
+		for i, field := range frame.Fields {
+			if i == timeIndex {
+				continue
+			}
+			all = append(all, series{field: field, values: rows})
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+
+	keys := make([]int64, 0, len(times))
+	for k := range times {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	timeValues := make([]time.Time, len(keys))
+	for i, k := range keys {
+		timeValues[i] = times[k]
+	}
+	frame := data.NewFrame("", data.NewField(data.TimeSeriesTimeFieldName, nil, timeValues))
+	for _, ser := range all {
+		field := data.NewFieldFromFieldType(ser.field.Type().NullableType(), len(keys))
+		field.Name = ser.field.Name
+		field.Labels = ser.field.Labels
+		field.Config = ser.field.Config
+		for i, k := range keys {
+			row, ok := ser.values[k]
+			if !ok {
+				continue
+			}
+			if v, ok := ser.field.ConcreteAt(row); ok {
+				field.SetConcrete(i, v)
+			}
+		}
+		frame.Fields = append(frame.Fields, field)
+	}
+	return frame
+}
+
+// minTimeStep returns the smallest step between the sorted times of the field, or zero if it has less than two times.
+func minTimeStep(times *data.Field) time.Duration {
+	var step time.Duration
+	for i := 1; i < times.Len(); i++ {
+		d := times.At(i).(time.Time).Sub(times.At(i - 1).(time.Time))
+		if d > 0 && (step == 0 || d < step) {
+			step = d
+		}
+	}
+	return step
+}
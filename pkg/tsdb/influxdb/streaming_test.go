@@ -0,0 +1,89 @@
+package influxdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinTimeSeriesFrames(t *testing.T) {
+	t0 := time.Unix(1700000000, 0).UTC()
+	t1 := t0.Add(10 * time.Second)
+	t2 := t1.Add(10 * time.Second)
+	frames := data.Frames{
+		data.NewFrame("cpu",
+			data.NewField("Time", nil, []time.Time{t0, t1}),
+			data.NewField("Value", data.Labels{"host": "a"}, []*float64{fp(1), fp(2)}),
+		),
+		data.NewFrame("cpu",
+			data.NewField("Time", nil, []time.Time{t1, t2}),
+			data.NewField("Value", data.Labels{"host": "b"}, []float64{3, 4}),
+		),
+		data.NewFrame("measurements", data.NewField("Value", nil, []string{"cpu"})),
+	}
+
+	t.Run("should join the series on time", func(t *testing.T) {
+		frame := joinTimeSeriesFrames(frames, time.Time{})
+		require.Len(t, frame.Fields, 3)
+		require.Equal(t, 3, frame.Rows())
+		require.Equal(t, t0, frame.Fields[0].At(0))
+		require.Equal(t, data.Labels{"host": "a"}, frame.Fields[1].Labels)
+		require.Nil(t, frame.Fields[1].At(2))
+		require.Nil(t, frame.Fields[2].At(0))
+		require.Equal(t, 4.0, *frame.Fields[2].At(2).(*float64))
+	})
+
+	t.Run("should drop the rows before the last sent row", func(t *testing.T) {
+		frame := joinTimeSeriesFrames(frames, t1)
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, t1, frame.Fields[0].At(0))
+		require.Equal(t, t2, frame.Fields[0].At(1))
+	})
+
+	t.Run("should drop frames without time", func(t *testing.T) {
+		require.Nil(t, joinTimeSeriesFrames(frames[2:], time.Time{}))
+	})
+}
+
+func TestStreamFrom(t *testing.T) {
+	now := time.Unix(1700000125, 0)
+
+	t.Run("should query the last interval first", func(t *testing.T) {
+		require.Equal(t, time.Unix(1700000110, 0), streamFrom(now, time.Time{}, time.Time{}, 10*time.Second, 10*time.Second))
+	})
+
+	t.Run("should overlap the previous poll by a bucket", func(t *testing.T) {
+		last := now.Add(-10 * time.Second)
+		require.Equal(t, time.Unix(1700000100, 0), streamFrom(now, last, time.Time{}, 10*time.Second, 10*time.Second))
+		require.Equal(t, time.Unix(1700000040, 0), streamFrom(now, last, time.Time{}, 10*time.Second, time.Minute))
+	})
+
+	t.Run("should query the whole bucket of the last sent row", func(t *testing.T) {
+		last := now.Add(-10 * time.Second)
+		sent := time.Unix(1700000095, 0)
+		require.Equal(t, sent, streamFrom(now, last, sent, 10*time.Second, 10*time.Second))
+		require.Equal(t, time.Unix(1700000100, 0), streamFrom(now, last, sent.Add(-time.Minute), 10*time.Second, 10*time.Second))
+	})
+}
+
+func TestMinTimeStep(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	require.Equal(t, time.Duration(0), minTimeStep(data.NewField("Time", nil, []time.Time{t0})))
+	require.Equal(t, 10*time.Second, minTimeStep(data.NewField("Time", nil, []time.Time{t0, t0.Add(30 * time.Second), t0.Add(40 * time.Second)})))
+}
+
+func TestParseStreamQuery(t *testing.T) {
+	_, interval, err := parseStreamQuery([]byte(`{"query":"SELECT * FROM cpu","intervalMs":100}`))
+	require.NoError(t, err)
+	require.Equal(t, minStreamInterval, interval)
+
+	_, interval, err = parseStreamQuery([]byte(`{"query":"SELECT * FROM cpu"}`))
+	require.NoError(t, err)
+	require.Equal(t, defaultStreamInterval, interval)
+}
+
+func fp(f float64) *float64 {
+	return &f
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/grafana/dskit/concurrency"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"go.opentelemetry.io/otel/attribute"
@@ -65,6 +66,7 @@ var (
 type datasourceInfo struct {
 	HTTPClient *http.Client
 	URL        string
+	split      splitOptions
 
 	// open streams
 	streams   map[string]data.FrameJSONCache
@@ -77,6 +79,11 @@ type QueryJSONModel struct {
 	SupportingQueryType *string `json:"supportingQueryType"`
 }
 
+type JSONData struct {
+	QuerySplitDuration  string `json:"querySplitDuration"`
+	ShardQuerySplitting bool   `json:"shardQuerySplitting"`
+}
+
 type ResponseOpts struct {
 	metricDataplane bool
 	logsDataplane   bool
@@ -100,9 +107,25 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			return nil, err
 		}
 
+		jsonData := JSONData{}
+		if len(settings.JSONData) > 0 {
+			if err := json.Unmarshal(settings.JSONData, &jsonData); err != nil {
+				return nil, fmt.Errorf("error reading settings: %w", err)
+			}
+		}
+
+		split := splitOptions{shards: jsonData.ShardQuerySplitting}
+		if jsonData.QuerySplitDuration != "" {
+			if split.duration, err = gtime.ParseDuration(jsonData.QuerySplitDuration); err != nil {
+				logger.FromContext(ctx).Warn("Invalid query split duration, queries are not split by time", "querySplitDuration", jsonData.QuerySplitDuration, "error", err)
+				split.duration = 0
+			}
+		}
+
 		model := &datasourceInfo{
 			HTTPClient: client,
 			URL:        settings.URL,
+			split:      split,
 			streams:    make(map[string]data.FrameJSONCache),
 		}
 		return model, nil
@@ -209,7 +232,7 @@ func queryData(ctx context.Context, req *backend.QueryDataRequest, dsInfo *datas
 		resultLock := sync.Mutex{}
 		err = concurrency.ForEachJob(ctx, len(queries), 10, func(ctx context.Context, idx int) error {
 			query := queries[idx]
-			queryRes := executeQuery(ctx, query, req, runInParallel, api, responseOpts, tracer, plog)
+			queryRes := executeQuery(ctx, query, req, runInParallel, api, dsInfo.split, responseOpts, tracer, plog)
 
 			resultLock.Lock()
 			defer resultLock.Unlock()
@@ -218,7 +241,7 @@ func queryData(ctx context.Context, req *backend.QueryDataRequest, dsInfo *datas
 		})
 	} else {
 		for _, query := range queries {
-			queryRes := executeQuery(ctx, query, req, runInParallel, api, responseOpts, tracer, plog)
+			queryRes := executeQuery(ctx, query, req, runInParallel, api, dsInfo.split, responseOpts, tracer, plog)
 			result.Responses[query.RefID] = queryRes
 		}
 	}
@@ -226,7 +249,7 @@ func queryData(ctx context.Context, req *backend.QueryDataRequest, dsInfo *datas
 	return result, err
 }
 
-func executeQuery(ctx context.Context, query *lokiQuery, req *backend.QueryDataRequest, runInParallel bool, api *LokiAPI, responseOpts ResponseOpts, tracer tracing.Tracer, plog log.Logger) backend.DataResponse {
+func executeQuery(ctx context.Context, query *lokiQuery, req *backend.QueryDataRequest, runInParallel bool, api *LokiAPI, split splitOptions, responseOpts ResponseOpts, tracer tracing.Tracer, plog log.Logger) backend.DataResponse {
 	ctx, span := tracer.Start(ctx, "datasource.loki.queryData.runQueries.runQuery", trace.WithAttributes(
 		attribute.Bool("runInParallel", runInParallel),
 		attribute.String("expr", query.Expr),
@@ -239,7 +262,7 @@ func executeQuery(ctx context.Context, query *lokiQuery, req *backend.QueryDataR
 
 	defer span.End()
 
-	queryRes, err := runQuery(ctx, api, query, responseOpts, plog)
+	queryRes, err := runSplitQuery(ctx, api, query, split, responseOpts, plog)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
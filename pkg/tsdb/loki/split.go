@@ -0,0 +1,487 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const (
+	// maxConcurrentSplitQueries limits the split queries of a query that run at the same time.
+	maxConcurrentSplitQueries = 4
+	// streamShardLabel is the label Loki adds to the streams it shards automatically.
+	streamShardLabel = "__stream_shard__"
+)
+
+// splitOptions configures how range queries are split into smaller queries.
+type splitOptions struct {
+	// duration is the length of the time chunks, zero disables splitting by time.
+	duration time.Duration
+	// shards enables splitting log queries by the stream shards of Loki.
+	shards bool
+}
+
+// splitQuery is a part of a split query, with the time chunk and the stream shards it selects.
+type splitQuery struct {
+	start  time.Time
+	end    time.Time
+	shards []string
+}
+
+func isLogsQuery(expr string) bool {
+	return strings.HasPrefix(strings.TrimSpace(expr), "{")
+}
+
+// splitTimeRange splits the time range of a query into chunks of the split duration. Chunks of metric queries are
+// rounded up to a multiple of the step, and their start and end are inclusive, so that every chunk is evaluated at
+// the same points in time as the whole range. Chunks of log queries end where the next one starts.
+func splitTimeRange(start, end time.Time, step, splitDuration time.Duration, logs bool) []splitQuery {
+	length := splitDuration
+	if !logs && step > 0 {
+		length = ((splitDuration + step - 1) / step) * step
+	}
+
+	var chunks []splitQuery
+	for chunkStart := start; !chunkStart.After(end); chunkStart = chunkStart.Add(length) {
+		chunkEnd := chunkStart.Add(length)
+		if !logs && step > 0 {
+			chunkEnd = chunkEnd.Add(-step)
+		}
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+		chunks = append(chunks, splitQuery{start: chunkStart, end: chunkEnd})
+		if !chunkEnd.Before(end) {
+			break
+		}
+	}
+	return chunks
+}
+
+// groupShards splits the stream shards into at most n groups of consecutive shards.
+func groupShards(shards []string, n int) [][]string {
+	if len(shards) < n {
+		n = len(shards)
+	}
+	groups := make([][]string, 0, n)
+	for i := 0; i < n; i++ {
+		groups = append(groups, shards[i*len(shards)/n:(i+1)*len(shards)/n])
+	}
+	return groups
+}
+
+// addStreamShardMatcher adds a matcher for the stream shards to the stream selector at the start of a log query.
+func addStreamShardMatcher(expr string, shards []string) (string, error) {
+	start := strings.Index(expr, "{")
+	if start < 0 {
+		return "", fmt.Errorf("missing stream selector in query %q", expr)
+	}
+
+	var quote rune
+	escaped := false
+	for i, r := range expr[start+1:] {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0 && r == '\\' && quote != '`':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '`':
+			quote = r
+		case r == '}':
+			end := start + 1 + i
+			matcher := fmt.Sprintf("%s=~%q", streamShardLabel, strings.Join(shards, "|"))
+			if strings.TrimSpace(expr[start+1:end]) != "" {
+				matcher = ", " + matcher
+			}
+			return expr[:end] + matcher + expr[end:], nil
+		}
+	}
+	return "", fmt.Errorf("unterminated stream selector in query %q", expr)
+}
+
+// getStreamShards returns the stream shards of Loki in the time range of the query, or none if the streams are not
+// sharded.
+func getStreamShards(ctx context.Context, api *LokiAPI, query *lokiQuery) ([]string, error) {
+	qs := url.Values{}
+	qs.Set("start", strconv.FormatInt(query.Start.UnixNano(), 10))
+	qs.Set("end", strconv.FormatInt(query.End.UnixNano(), 10))
+
+	res, err := api.RawQuery(ctx, "/loki/api/v1/label/"+streamShardLabel+"/values?"+qs.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if res.Status/100 != 2 {
+		return nil, fmt.Errorf("unexpected status code %d", res.Status)
+	}
+
+	var values struct {
+		Data []string `json:"data"`
+	}
+	if err := json.Unmarshal(res.Body, &values); err != nil {
+		return nil, err
+	}
+	sort.Slice(values.Data, func(i, j int) bool {
+		a, errA := strconv.Atoi(values.Data[i])
+		b, errB := strconv.Atoi(values.Data[j])
+		if errA != nil || errB != nil {
+			return values.Data[i] < values.Data[j]
+		}
+		return a < b
+	})
+	return values.Data, nil
+}
+
+// runSplitQuery runs a range query as concurrent queries of time chunks, and of groups of stream shards for log
+// queries, and merges their frames. The time chunks of log queries with a line limit run one after the other in the
+// order of the direction, until the limit is reached. If some of the split queries fail, the frames of the others are
+// returned with the error and a notice about the missing results.
+func runSplitQuery(ctx context.Context, api *LokiAPI, query *lokiQuery, opts splitOptions, responseOpts ResponseOpts, plog log.Logger) (*backend.DataResponse, error) {
+	logs := isLogsQuery(query.Expr)
+	if query.QueryType != QueryTypeRange || (opts.duration <= 0 && !(opts.shards && logs)) {
+		return runQuery(ctx, api, query, responseOpts, plog)
+	}
+
+	chunks := [][]splitQuery{{{start: query.Start, end: query.End}}}
+	if opts.duration > 0 {
+		chunks = chunks[:0]
+		for _, split := range splitTimeRange(query.Start, query.End, query.Step, opts.duration, logs) {
+			chunks = append(chunks, []splitQuery{split})
+		}
+	}
+	if opts.shards && logs {
+		shards, err := getStreamShards(ctx, api, query)
+		if err != nil {
+			plog.Warn("Failed to get stream shards, splitting by time only", "error", err)
+		}
+		if len(shards) > 0 {
+			groups := groupShards(shards, maxConcurrentSplitQueries)
+			for i, chunk := range chunks {
+				sharded := make([]splitQuery, 0, len(groups))
+				for _, group := range groups {
+					sharded = append(sharded, splitQuery{start: chunk[0].start, end: chunk[0].end, shards: group})
+				}
+				chunks[i] = sharded
+			}
+		}
+	}
+	if len(chunks) == 1 && len(chunks[0]) == 1 && len(chunks[0][0].shards) == 0 {
+		return runQuery(ctx, api, query, responseOpts, plog)
+	}
+
+	limited := logs && query.MaxLines > 0
+	if limited && query.Direction != DirectionForward {
+		slices.Reverse(chunks)
+	}
+	if !limited {
+		// without a line limit all the split queries can run at the same time
+		var all []splitQuery
+		for _, chunk := range chunks {
+			all = append(all, chunk...)
+		}
+		chunks = [][]splitQuery{all}
+	}
+
+	var (
+		splits    []splitQuery
+		responses []*backend.DataResponse
+	)
+	lines := newLineCounter()
+	for _, chunk := range chunks {
+		maxLines := query.MaxLines
+		if limited {
+			if lines.count >= query.MaxLines {
+				break
+			}
+			maxLines = query.MaxLines - lines.count
+		}
+
+		plog.Debug("Running split queries", "splits", len(chunk), "splitDuration", opts.duration, "maxLines", maxLines)
+
+		chunkResponses := make([]*backend.DataResponse, len(chunk))
+		g := errgroup.Group{}
+		g.SetLimit(maxConcurrentSplitQueries)
+		for i, split := range chunk {
+			i, split := i, split
+			g.Go(func() error {
+				chunkResponses[i] = runSplit(ctx, api, query, split, maxLines, responseOpts, plog)
+				return nil
+			})
+		}
+		_ = g.Wait()
+
+		for _, res := range chunkResponses {
+			if res.Error == nil {
+				lines.add(res.Frames)
+			}
+		}
+		splits = append(splits, chunk...)
+		responses = append(responses, chunkResponses...)
+	}
+
+	var (
+		succeeded []*backend.DataResponse
+		failed    []splitQuery
+		firstErr  *backend.DataResponse
+	)
+	for i, res := range responses {
+		if res.Error != nil {
+			failed = append(failed, splits[i])
+			if firstErr == nil {
+				firstErr = res
+			}
+			continue
+		}
+		succeeded = append(succeeded, res)
+	}
+	if len(succeeded) == 0 {
+		return firstErr, firstErr.Error
+	}
+
+	res := &backend.DataResponse{Frames: mergeSplitFrames(succeeded, query)}
+	if len(failed) > 0 {
+		res.Error = fmt.Errorf("%d of %d split queries failed: %w", len(failed), len(splits), firstErr.Error)
+		res.ErrorSource = firstErr.ErrorSource
+		if len(res.Frames) == 0 {
+			res.Frames = data.Frames{data.NewFrame("")}
+		}
+		for _, split := range failed {
+			text := fmt.Sprintf("Results are incomplete, the query of the time range from %s to %s failed.", split.start.UTC().Format(time.RFC3339), split.end.UTC().Format(time.RFC3339))
+			if len(split.shards) > 0 {
+				text = fmt.Sprintf("Results are incomplete, the query of the stream shards %s in the time range from %s to %s failed.", strings.Join(split.shards, ", "), split.start.UTC().Format(time.RFC3339), split.end.UTC().Format(time.RFC3339))
+			}
+			res.Frames[0].AppendNotices(data.Notice{Severity: data.NoticeSeverityWarning, Text: text})
+		}
+		return res, res.Error
+	}
+	return res, nil
+}
+
+// lineCounter counts the distinct log lines of the split queries that have run.
+type lineCounter struct {
+	count int
+	seen  map[string]struct{}
+}
+
+func newLineCounter() *lineCounter {
+	return &lineCounter{seen: make(map[string]struct{})}
+}
+
+func (c *lineCounter) add(frames data.Frames) {
+	for _, frame := range frames {
+		if len(frame.Fields) < 2 || frame.Fields[0].Type() != data.FieldTypeJSON {
+			continue
+		}
+		idField, _ := frame.FieldByName("id")
+		if idField == nil {
+			c.count += frame.Rows()
+			continue
+		}
+		for row := 0; row < frame.Rows(); row++ {
+			id, _ := idField.At(row).(string)
+			if _, ok := c.seen[id]; ok {
+				continue
+			}
+			c.seen[id] = struct{}{}
+			c.count++
+		}
+	}
+}
+
+// runSplit runs a split query. The frames are adjusted with the original query, so that they look like the frames of
+// the whole query.
+func runSplit(ctx context.Context, api *LokiAPI, query *lokiQuery, split splitQuery, maxLines int, responseOpts ResponseOpts, plog log.Logger) *backend.DataResponse {
+	sq := *query
+	sq.Start = split.start
+	sq.End = split.end
+	sq.MaxLines = maxLines
+	if len(split.shards) > 0 {
+		expr, err := addStreamShardMatcher(query.Expr, split.shards)
+		if err != nil {
+			return &backend.DataResponse{Error: err}
+		}
+		sq.Expr = expr
+	}
+
+	res, err := api.DataQuery(ctx, sq, responseOpts)
+	if err != nil {
+		plog.Error("Error querying loki", "error", err)
+		return &backend.DataResponse{Error: err}
+	}
+	if res.Error != nil {
+		return res
+	}
+
+	for _, frame := range res.Frames {
+		if err := adjustFrame(frame, query, !responseOpts.metricDataplane, responseOpts.logsDataplane); err != nil {
+			plog.Error("Error adjusting frame", "error", err)
+			return &backend.DataResponse{Error: err}
+		}
+	}
+	return res
+}
+
+// mergeSplitFrames merges the frames of the split queries. The log lines are merged into one frame, ordered by the
+// direction of the query, without duplicates and limited to the maximum lines of the query. The rows of metric frames
+// with the same labels are merged in time order.
+func mergeSplitFrames(responses []*backend.DataResponse, query *lokiQuery) data.Frames {
+	var (
+		merged    data.Frames
+		logFrames data.Frames
+		stats     [][]data.QueryStat
+	)
+	byKey := make(map[string]*data.Frame)
+	for _, res := range responses {
+		for _, frame := range res.Frames {
+			if frame.Meta != nil {
+				stats = append(stats, frame.Meta.Stats)
+			}
+			if len(frame.Fields) < 2 {
+				continue
+			}
+			if frame.Fields[0].Type() == data.FieldTypeJSON {
+				logFrames = append(logFrames, frame)
+				continue
+			}
+
+			key := frameKey(frame)
+			m, ok := byKey[key]
+			if !ok {
+				m = emptyFrameCopy(frame)
+				byKey[key] = m
+				merged = append(merged, m)
+			}
+			appendMetricRows(m, frame)
+		}
+	}
+
+	if len(logFrames) > 0 {
+		merged = append(data.Frames{mergeLogFrames(logFrames, query)}, merged...)
+	}
+	if len(merged) > 0 {
+		if merged[0].Meta == nil {
+			merged[0].Meta = &data.FrameMeta{}
+		}
+		merged[0].Meta.Stats = mergeStats(stats)
+		for _, frame := range merged[1:] {
+			if frame.Meta != nil {
+				frame.Meta.Stats = nil
+			}
+		}
+	}
+	return merged
+}
+
+func emptyFrameCopy(frame *data.Frame) *data.Frame {
+	m := frame.EmptyCopy()
+	if frame.Meta != nil {
+		meta := *frame.Meta
+		m.Meta = &meta
+	}
+	for i, field := range frame.Fields {
+		m.Fields[i].Config = field.Config
+	}
+	return m
+}
+
+// appendMetricRows appends the rows of a frame that are after the last row of the merged frame, as the time chunks
+// are in time order.
+func appendMetricRows(m *data.Frame, frame *data.Frame) {
+	var last time.Time
+	if n := m.Rows(); n > 0 {
+		last, _ = m.Fields[0].At(n - 1).(time.Time)
+	}
+	for row := 0; row < frame.Rows(); row++ {
+		if t, ok := frame.Fields[0].At(row).(time.Time); ok && m.Rows() > 0 && !t.After(last) {
+			continue
+		}
+		m.AppendRow(frame.RowCopy(row)...)
+	}
+}
+
+func mergeLogFrames(frames data.Frames, query *lokiQuery) *data.Frame {
+	m := emptyFrameCopy(frames[0])
+
+	type logRow struct {
+		frame *data.Frame
+		row   int
+		time  time.Time
+	}
+	var rows []logRow
+	for _, frame := range frames {
+		if frameKey(frame) != frameKey(frames[0]) {
+			continue
+		}
+		for row := 0; row < frame.Rows(); row++ {
+			t, _ := frame.Fields[1].At(row).(time.Time)
+			rows = append(rows, logRow{frame: frame, row: row, time: t})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if query.Direction == DirectionForward {
+			return rows[i].time.Before(rows[j].time)
+		}
+		return rows[i].time.After(rows[j].time)
+	})
+
+	seen := make(map[string]struct{}, len(rows))
+	for _, r := range rows {
+		if query.MaxLines > 0 && m.Rows() >= query.MaxLines {
+			break
+		}
+		if idField, _ := r.frame.FieldByName("id"); idField != nil {
+			id, _ := idField.At(r.row).(string)
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+		}
+		m.AppendRow(r.frame.RowCopy(r.row)...)
+	}
+	return m
+}
+
+func frameKey(frame *data.Frame) string {
+	var sb strings.Builder
+	sb.WriteString(frame.Name)
+	for _, field := range frame.Fields {
+		sb.WriteString("\n" + field.Name + "\n" + field.Type().ItemTypeString() + "\n" + field.Labels.String())
+	}
+	return sb.String()
+}
+
+// mergeStats sums the stats of the split queries. Rates can not be summed, so they are dropped.
+func mergeStats(stats [][]data.QueryStat) []data.QueryStat {
+	var merged []data.QueryStat
+	index := make(map[string]int)
+	for _, s := range stats {
+		for _, stat := range s {
+			if strings.HasSuffix(stat.DisplayName, "per second") {
+				continue
+			}
+			i, ok := index[stat.DisplayName]
+			if !ok {
+				index[stat.DisplayName] = len(merged)
+				merged = append(merged, stat)
+				continue
+			}
+			merged[i].Value += stat.Value
+		}
+	}
+	return merged
+}
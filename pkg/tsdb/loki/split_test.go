@@ -0,0 +1,241 @@
+package loki
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+type splitRoundTripper func(req *http.Request) (int, string)
+
+func (rt splitRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	status, body := rt(req)
+	header := http.Header{}
+	header.Add("Content-Type", "application/json")
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+	}, nil
+}
+
+func makeSplitAPI(rt splitRoundTripper) *LokiAPI {
+	return newLokiAPI(&http.Client{Transport: rt}, "http://localhost:9999", log.New("test"), tracing.InitializeTracerForTest(), false)
+}
+
+func requestTimeRange(t *testing.T, req *http.Request) (time.Time, time.Time) {
+	start, err := strconv.ParseInt(req.URL.Query().Get("start"), 10, 64)
+	require.NoError(t, err)
+	end, err := strconv.ParseInt(req.URL.Query().Get("end"), 10, 64)
+	require.NoError(t, err)
+	return time.Unix(0, start).UTC(), time.Unix(0, end).UTC()
+}
+
+func TestSplitTimeRange(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	end := start.Add(48 * time.Hour)
+
+	t.Run("should split metric queries into step-aligned chunks with inclusive ends", func(t *testing.T) {
+		chunks := splitTimeRange(start, end, time.Hour, 24*time.Hour, false)
+		require.Equal(t, []splitQuery{
+			{start: start, end: start.Add(23 * time.Hour)},
+			{start: start.Add(24 * time.Hour), end: start.Add(47 * time.Hour)},
+			{start: end, end: end},
+		}, chunks)
+	})
+
+	t.Run("should round the chunks of metric queries up to a multiple of the step", func(t *testing.T) {
+		chunks := splitTimeRange(start, end, time.Hour, 24*time.Hour+time.Minute, false)
+		require.Len(t, chunks, 2)
+		require.Equal(t, start.Add(25*time.Hour), chunks[1].start)
+		require.Equal(t, time.Hour, chunks[1].start.Sub(chunks[0].end))
+	})
+
+	t.Run("should split log queries into adjacent chunks", func(t *testing.T) {
+		chunks := splitTimeRange(start, end, time.Hour, 24*time.Hour, true)
+		require.Equal(t, []splitQuery{
+			{start: start, end: start.Add(24 * time.Hour)},
+			{start: start.Add(24 * time.Hour), end: end},
+		}, chunks)
+	})
+}
+
+func TestAddStreamShardMatcher(t *testing.T) {
+	tt := []struct {
+		expr     string
+		expected string
+	}{
+		{expr: `{app="a"}`, expected: `{app="a", __stream_shard__=~"0|1"}`},
+		{expr: `{app="}"} |= "{x}"`, expected: `{app="}", __stream_shard__=~"0|1"} |= "{x}"`},
+		{expr: "{app=`a\\`} | json", expected: "{app=`a\\`, __stream_shard__=~\"0|1\"} | json"},
+		{expr: `{ }`, expected: `{ __stream_shard__=~"0|1"}`},
+	}
+	for _, test := range tt {
+		expr, err := addStreamShardMatcher(test.expr, []string{"0", "1"})
+		require.NoError(t, err)
+		require.Equal(t, test.expected, expr)
+	}
+
+	_, err := addStreamShardMatcher(`{app="a"`, []string{"0"})
+	require.Error(t, err)
+}
+
+func TestGroupShards(t *testing.T) {
+	require.Equal(t, [][]string{{"0", "1"}, {"2", "3", "4"}}, groupShards([]string{"0", "1", "2", "3", "4"}, 2))
+	require.Equal(t, [][]string{{"0"}, {"1"}}, groupShards([]string{"0", "1"}, 4))
+}
+
+func TestRunSplitQuery(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(3 * time.Hour)
+
+	t.Run("should merge the series of metric queries", func(t *testing.T) {
+		var (
+			mu       sync.Mutex
+			requests int
+		)
+		api := makeSplitAPI(func(req *http.Request) (int, string) {
+			mu.Lock()
+			requests++
+			mu.Unlock()
+			from, to := requestTimeRange(t, req)
+			var values []string
+			for ts := from; !ts.After(to); ts = ts.Add(30 * time.Minute) {
+				values = append(values, fmt.Sprintf(`[%d, "%d"]`, ts.Unix(), ts.Hour()))
+			}
+			return http.StatusOK, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"app":"a"},"values":[` + strings.Join(values, ",") + `]}]}}`
+		})
+		query := &lokiQuery{Expr: `sum(count_over_time({app="a"}[1m]))`, QueryType: QueryTypeRange, Direction: DirectionBackward, Step: 30 * time.Minute, Start: start, End: end, RefID: "A"}
+
+		res, err := runSplitQuery(context.Background(), api, query, splitOptions{duration: time.Hour}, ResponseOpts{}, log.New("test"))
+		require.NoError(t, err)
+		require.NoError(t, res.Error)
+		require.Equal(t, 4, requests)
+		require.Len(t, res.Frames, 1)
+		require.Equal(t, 7, res.Frames[0].Rows())
+		for i := 0; i < 7; i++ {
+			require.Equal(t, start.Add(time.Duration(i)*30*time.Minute), res.Frames[0].Fields[0].At(i).(time.Time).UTC())
+		}
+		require.Equal(t, "Expr: "+query.Expr+"\nStep: 30m0s", res.Frames[0].Meta.ExecutedQueryString)
+	})
+
+	t.Run("should merge the lines of log queries in the order of the direction", func(t *testing.T) {
+		api := makeSplitAPI(func(req *http.Request) (int, string) {
+			if strings.Contains(req.URL.Path, "/label/") {
+				return http.StatusOK, `{"status":"success","data":["1","0"]}`
+			}
+			from, _ := requestTimeRange(t, req)
+			shard := "0"
+			if strings.Contains(req.URL.Query().Get("query"), `"1"`) {
+				shard = "1"
+			}
+			// both chunks return the line at the boundary of the chunks
+			return http.StatusOK, fmt.Sprintf(`{"status":"success","data":{"resultType":"streams","result":[{"stream":{"shard":%q},"values":[["%d","line %s"],["%d","boundary"]]}]}}`,
+				shard, from.Add(time.Minute).UnixNano(), shard, start.Add(2*time.Hour).UnixNano())
+		})
+		query := &lokiQuery{Expr: `{app="a"}`, QueryType: QueryTypeRange, Direction: DirectionBackward, Step: time.Minute, Start: start, End: start.Add(2 * time.Hour), MaxLines: 4, RefID: "A"}
+
+		res, err := runSplitQuery(context.Background(), api, query, splitOptions{duration: time.Hour, shards: true}, ResponseOpts{}, log.New("test"))
+		require.NoError(t, err)
+		require.Len(t, res.Frames, 1)
+		frame := res.Frames[0]
+		require.Equal(t, 4, frame.Rows())
+
+		lines, _ := frame.FieldByName("Line")
+		times, _ := frame.FieldByName("Time")
+		for i := 1; i < frame.Rows(); i++ {
+			require.False(t, times.At(i).(time.Time).After(times.At(i-1).(time.Time)))
+		}
+		require.Equal(t, "boundary", lines.At(0))
+		require.Equal(t, "boundary", lines.At(1))
+		require.NotEqual(t, "boundary", lines.At(2))
+	})
+
+	t.Run("should run backward log queries from the newest chunk until the line limit is reached", func(t *testing.T) {
+		var (
+			ranges []time.Time
+			limits []string
+		)
+		api := makeSplitAPI(func(req *http.Request) (int, string) {
+			from, to := requestTimeRange(t, req)
+			ranges = append(ranges, from)
+			limits = append(limits, req.URL.Query().Get("limit"))
+			return http.StatusOK, fmt.Sprintf(`{"status":"success","data":{"resultType":"streams","result":[{"stream":{"app":"a"},"values":[["%d","newer"],["%d","older"]]}]}}`,
+				to.Add(-time.Minute).UnixNano(), from.Add(time.Minute).UnixNano())
+		})
+		query := &lokiQuery{Expr: `{app="a"}`, QueryType: QueryTypeRange, Direction: DirectionBackward, Step: time.Minute, Start: start, End: end, MaxLines: 3, RefID: "A"}
+
+		res, err := runSplitQuery(context.Background(), api, query, splitOptions{duration: time.Hour}, ResponseOpts{}, log.New("test"))
+		require.NoError(t, err)
+		require.Equal(t, []time.Time{start.Add(2 * time.Hour), start.Add(time.Hour)}, ranges)
+		require.Equal(t, []string{"3", "1"}, limits)
+		require.Len(t, res.Frames, 1)
+		require.Equal(t, 3, res.Frames[0].Rows())
+	})
+
+	t.Run("should return the frames of the split queries that succeeded with the error", func(t *testing.T) {
+		api := makeSplitAPI(func(req *http.Request) (int, string) {
+			from, _ := requestTimeRange(t, req)
+			if from.Equal(start) {
+				return http.StatusBadRequest, `{"message":"too many outstanding requests"}`
+			}
+			return http.StatusOK, fmt.Sprintf(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"app":"a"},"values":[[%d, "1"]]}]}}`, from.Unix())
+		})
+		query := &lokiQuery{Expr: `count_over_time({app="a"}[1m])`, QueryType: QueryTypeRange, Direction: DirectionBackward, Step: time.Hour, Start: start, End: end, RefID: "A"}
+
+		res, err := runSplitQuery(context.Background(), api, query, splitOptions{duration: time.Hour}, ResponseOpts{}, log.New("test"))
+		require.Error(t, err)
+		require.ErrorContains(t, res.Error, "1 of 4 split queries failed: too many outstanding requests")
+		require.Len(t, res.Frames, 1)
+		require.Equal(t, 3, res.Frames[0].Rows())
+		require.Len(t, res.Frames[0].Meta.Notices, 1)
+		require.Equal(t, data.NoticeSeverityWarning, res.Frames[0].Meta.Notices[0].Severity)
+	})
+
+	t.Run("should not split instant queries", func(t *testing.T) {
+		requests := 0
+		api := makeSplitAPI(func(req *http.Request) (int, string) {
+			requests++
+			return http.StatusOK, `{"status":"success","data":{"resultType":"vector","result":[]}}`
+		})
+		query := &lokiQuery{Expr: `count_over_time({app="a"}[3h])`, QueryType: QueryTypeInstant, Direction: DirectionBackward, Start: start, End: end, RefID: "A"}
+
+		_, err := runSplitQuery(context.Background(), api, query, splitOptions{duration: time.Hour}, ResponseOpts{}, log.New("test"))
+		require.NoError(t, err)
+		require.Equal(t, 1, requests)
+	})
+}
+
+func TestNewInstanceSettingsSplitDuration(t *testing.T) {
+	newInstance := func(t *testing.T, jsonData string) *datasourceInfo {
+		t.Helper()
+		settings := *getPluginContext().DataSourceInstanceSettings
+		settings.JSONData = []byte(jsonData)
+		instance, err := newInstanceSettings(getMockProvider[*healthCheckSuccessRoundTripper]())(context.Background(), settings)
+		require.NoError(t, err)
+		return instance.(*datasourceInfo)
+	}
+
+	t.Run("should split queries by the query split duration", func(t *testing.T) {
+		dsInfo := newInstance(t, `{"querySplitDuration":"1h","shardQuerySplitting":true}`)
+		require.Equal(t, splitOptions{duration: time.Hour, shards: true}, dsInfo.split)
+	})
+
+	t.Run("should not split queries by time with an invalid query split duration", func(t *testing.T) {
+		dsInfo := newInstance(t, `{"querySplitDuration":"one hour","shardQuerySplitting":true}`)
+		require.Equal(t, splitOptions{shards: true}, dsInfo.split)
+	})
+}
@@ -0,0 +1,44 @@
+package mssql
+
+import "strings"
+
+// mssqlSchemaDialect reads the schema from INFORMATION_SCHEMA and the catalog views.
+type mssqlSchemaDialect struct{}
+
+func (mssqlSchemaDialect) DatabasesQuery() string {
+	// the first four databases are the system databases master, tempdb, model and msdb.
+	return "SELECT name FROM sys.databases WHERE database_id > 4 ORDER BY name"
+}
+
+func (mssqlSchemaDialect) SchemasQuery() string {
+	return `SELECT SCHEMA_NAME FROM INFORMATION_SCHEMA.SCHEMATA
+WHERE SCHEMA_NAME NOT IN ('sys', 'INFORMATION_SCHEMA', 'guest') AND SCHEMA_NAME NOT LIKE 'db[_]%'
+ORDER BY SCHEMA_NAME`
+}
+
+func (mssqlSchemaDialect) TablesQuery() string {
+	return "SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = COALESCE(NULLIF(@p1, ''), SCHEMA_NAME()) ORDER BY TABLE_NAME"
+}
+
+func (mssqlSchemaDialect) ColumnsQuery() string {
+	return `SELECT COLUMN_NAME, DATA_TYPE, CAST(CASE WHEN IS_NULLABLE = 'YES' THEN 1 ELSE 0 END AS BIT) FROM INFORMATION_SCHEMA.COLUMNS
+WHERE TABLE_SCHEMA = COALESCE(NULLIF(@p1, ''), SCHEMA_NAME()) AND TABLE_NAME = @p2 ORDER BY ORDINAL_POSITION`
+}
+
+func (mssqlSchemaDialect) IndexesQuery() string {
+	return `SELECT i.name, c.name, i.is_unique
+FROM sys.indexes i
+JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+JOIN sys.tables t ON t.object_id = i.object_id
+WHERE SCHEMA_NAME(t.schema_id) = COALESCE(NULLIF(@p1, ''), SCHEMA_NAME()) AND t.name = @p2 AND i.name IS NOT NULL AND ic.is_included_column = 0
+ORDER BY i.name, ic.key_ordinal`
+}
+
+func (mssqlSchemaDialect) IsTimeType(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "datetime", "datetime2", "smalldatetime", "datetimeoffset", "date":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package mysql
+
+import "strings"
+
+// mysqlSchemaDialect reads the schema from information_schema. In MySQL, schemas are databases.
+type mysqlSchemaDialect struct{}
+
+const mysqlSystemSchemas = "'information_schema', 'performance_schema', 'mysql', 'sys'"
+
+func (mysqlSchemaDialect) DatabasesQuery() string {
+	return "SELECT schema_name FROM information_schema.schemata WHERE schema_name NOT IN (" + mysqlSystemSchemas + ") ORDER BY schema_name"
+}
+
+func (d mysqlSchemaDialect) SchemasQuery() string {
+	return d.DatabasesQuery()
+}
+
+func (mysqlSchemaDialect) TablesQuery() string {
+	return "SELECT table_name FROM information_schema.tables WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE()) ORDER BY table_name"
+}
+
+func (mysqlSchemaDialect) ColumnsQuery() string {
+	return `SELECT column_name, data_type, is_nullable = 'YES' FROM information_schema.columns
+WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND table_name = ? ORDER BY ordinal_position`
+}
+
+func (mysqlSchemaDialect) IndexesQuery() string {
+	return `SELECT index_name, column_name, non_unique = 0 FROM information_schema.statistics
+WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND table_name = ? ORDER BY index_name, seq_in_index`
+}
+
+func (mysqlSchemaDialect) IsTimeType(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "datetime", "timestamp", "date":
+		return true
+	}
+	return false
+}
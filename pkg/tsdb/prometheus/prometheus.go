@@ -16,6 +16,7 @@ import (
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
 
+	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb/prometheus/client"
 	"github.com/grafana/grafana/pkg/tsdb/prometheus/instrumentation"
@@ -34,16 +35,16 @@ type instance struct {
 	versionCache *cache.Cache
 }
 
-func ProvideService(httpClientProvider *httpclient.Provider, cfg *setting.Cfg) *Service {
+func ProvideService(httpClientProvider *httpclient.Provider, cfg *setting.Cfg, segmentCache remotecache.CacheStorage) *Service {
 	plog := backend.NewLoggerWith("logger", "tsdb.prometheus")
 	plog.Debug("Initializing")
 	return &Service{
-		im:     datasource.NewInstanceManager(newInstanceSettings(httpClientProvider, cfg, plog)),
+		im:     datasource.NewInstanceManager(newInstanceSettings(httpClientProvider, cfg, segmentCache, plog)),
 		logger: plog,
 	}
 }
 
-func newInstanceSettings(httpClientProvider *httpclient.Provider, cfg *setting.Cfg, log log.Logger) datasource.InstanceFactoryFunc {
+func newInstanceSettings(httpClientProvider *httpclient.Provider, cfg *setting.Cfg, segmentCache querydata.Cache, log log.Logger) datasource.InstanceFactoryFunc {
 	return func(ctx context.Context, settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
 		// Creates a http roundTripper.
 		opts, err := client.CreateTransportOptions(ctx, settings, cfg, log)
@@ -56,7 +57,7 @@ func newInstanceSettings(httpClientProvider *httpclient.Provider, cfg *setting.C
 		}
 
 		// New version using custom client and better response parsing
-		qd, err := querydata.New(httpClient, settings, log)
+		qd, err := querydata.New(httpClient, settings, segmentCache, log)
 		if err != nil {
 			return nil, err
 		}
@@ -33,6 +33,7 @@ func TestRangeResponses(t *testing.T) {
 		{name: "parse a matrix response with Infinity", filepath: "range_infinity"},
 		{name: "parse a matrix response with NaN", filepath: "range_nan"},
 		{name: "parse a response with legendFormat __auto", filepath: "range_auto"},
+		{name: "parse a matrix response with native histograms", filepath: "range_histogram"},
 	}
 
 	for _, test := range tt {
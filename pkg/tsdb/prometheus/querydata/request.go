@@ -9,6 +9,7 @@ import (
 
 	"github.com/grafana/grafana-azure-sdk-go/util/maputil"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -44,11 +45,18 @@ type QueryData struct {
 	URL                string
 	TimeInterval       string
 	exemplarSampler    func() exemplar.Sampler
+
+	// splitDuration is the length of the segments range queries are split into, zero disables splitting.
+	splitDuration time.Duration
+	// cache stores the segments older than the overlap window, it is nil if caching is disabled.
+	cache         Cache
+	overlapWindow time.Duration
 }
 
 func New(
 	httpClient *http.Client,
 	settings backend.DataSourceInstanceSettings,
+	cache Cache,
 	plog log.Logger,
 ) (*QueryData, error) {
 	jsonData, err := utils.GetJsonData(settings)
@@ -66,6 +74,29 @@ func New(
 		httpMethod = http.MethodPost
 	}
 
+	var splitDuration time.Duration
+	if d, _ := maputil.GetStringOptional(jsonData, "rangeQuerySplitDuration"); d != "" {
+		if splitDuration, err = gtime.ParseDuration(d); err != nil {
+			return nil, fmt.Errorf("invalid range query split duration: %w", err)
+		}
+	}
+
+	overlapWindow := defaultOverlapWindow
+	if w, _ := maputil.GetStringOptional(jsonData, "incrementalQueryOverlapWindow"); w != "" {
+		if overlapWindow, err = gtime.ParseDuration(w); err != nil {
+			return nil, fmt.Errorf("invalid incremental query overlap window: %w", err)
+		}
+	}
+
+	if cacheSegments, _ := maputil.GetBoolOptional(jsonData, "cacheRangeQuerySegments"); !cacheSegments {
+		cache = nil
+	}
+	// the team LBAC headers depend on the teams of the user, which can change while the segments are cached
+	if cache != nil && hasTeamHTTPHeaders(jsonData) {
+		plog.Warn("Range query segments are not cached, because the data source has team HTTP headers")
+		cache = nil
+	}
+
 	promClient := client.NewClient(httpClient, httpMethod, settings.URL)
 
 	// standard deviation sampler is the default for backwards compatibility
@@ -80,9 +111,19 @@ func New(
 		ID:                 settings.ID,
 		URL:                settings.URL,
 		exemplarSampler:    exemplarSampler,
+		splitDuration:      splitDuration,
+		cache:              cache,
+		overlapWindow:      overlapWindow,
 	}, nil
 }
 
+// hasTeamHTTPHeaders returns true if headers are added to the requests of the members of teams.
+func hasTeamHTTPHeaders(jsonData map[string]any) bool {
+	teamHTTPHeaders, _ := jsonData["teamHttpHeaders"].(map[string]any)
+	headers, _ := teamHTTPHeaders["headers"].(map[string]any)
+	return len(headers) > 0
+}
+
 func (s *QueryData) Execute(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	fromAlert := req.Headers["FromAlert"] == "true"
 	result := backend.QueryDataResponse{
@@ -93,13 +134,14 @@ func (s *QueryData) Execute(ctx context.Context, req *backend.QueryDataRequest)
 	hasPromQLScopeFeatureFlag := cfg.FeatureToggles().IsEnabled("promQLScope")
 	hasPrometheusDataplaneFeatureFlag := cfg.FeatureToggles().IsEnabled("prometheusDataplane")
 
+	cacheScope := segmentCacheScope(req.PluginContext, req.Headers)
 	for _, q := range req.Queries {
 		query, err := models.Parse(q, s.TimeInterval, s.intervalCalculator, fromAlert, hasPromQLScopeFeatureFlag)
 		if err != nil {
 			return &result, err
 		}
 
-		r := s.fetch(ctx, s.client, query, hasPrometheusDataplaneFeatureFlag)
+		r := s.fetch(ctx, s.client, query, cacheScope, hasPrometheusDataplaneFeatureFlag)
 		if r == nil {
 			s.log.FromContext(ctx).Debug("Received nil response from runQuery", "query", query.Expr)
 			continue
@@ -110,7 +152,7 @@ func (s *QueryData) Execute(ctx context.Context, req *backend.QueryDataRequest)
 	return &result, nil
 }
 
-func (s *QueryData) fetch(ctx context.Context, client *client.Client, q *models.Query, enablePrometheusDataplane bool) *backend.DataResponse {
+func (s *QueryData) fetch(ctx context.Context, client *client.Client, q *models.Query, cacheScope string, enablePrometheusDataplane bool) *backend.DataResponse {
 	traceCtx, end := s.trace(ctx, q)
 	defer end()
 
@@ -130,7 +172,7 @@ func (s *QueryData) fetch(ctx context.Context, client *client.Client, q *models.
 	}
 
 	if q.RangeQuery {
-		res := s.rangeQuery(traceCtx, client, q, enablePrometheusDataplane)
+		res := s.splitRangeQuery(traceCtx, client, q, cacheScope, enablePrometheusDataplane)
 		if res.Error != nil {
 			if dr.Error == nil {
 				dr.Error = res.Error
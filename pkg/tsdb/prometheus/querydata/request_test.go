@@ -451,7 +451,7 @@ func setup() (*testContext, error) {
 		return nil, err
 	}
 
-	queryData, _ := querydata.New(httpClient, settings, log.New())
+	queryData, _ := querydata.New(httpClient, settings, nil, log.New())
 
 	return &testContext{
 		httpProvider: httpProvider,
@@ -113,6 +113,10 @@ func addMetadataToMultiFrame(q *models.Query, frame *data.Frame, enableDataplane
 	if len(frame.Fields) < 2 {
 		return
 	}
+	if isHistogramFrame(frame) {
+		addMetadataToHistogramFrame(q, frame)
+		return
+	}
 	frame.Fields[0].Config = &data.FieldConfig{Interval: float64(q.Step.Milliseconds())}
 
 	customName := getName(q, frame.Fields[1])
@@ -130,6 +134,25 @@ func addMetadataToMultiFrame(q *models.Query, frame *data.Frame, enableDataplane
 	}
 }
 
+// addMetadataToHistogramFrame names the frames of native histograms after their series. The bucket fields keep their
+// names, the heatmap and histogram panels find the fields by name.
+func addMetadataToHistogramFrame(q *models.Query, frame *data.Frame) {
+	for _, field := range frame.Fields {
+		if field.Labels == nil {
+			continue
+		}
+		frame.Name = getName(q, field)
+		if frame.Meta.Type == converter.FrameTypeHeatmapCells {
+			frame.Fields[0].Config = &data.FieldConfig{Interval: float64(q.Step.Milliseconds())}
+		}
+		return
+	}
+}
+
+func isHistogramFrame(frame *data.Frame) bool {
+	return frame.Meta.Type == converter.FrameTypeHeatmapCells || frame.Meta.Type == converter.FrameTypeHistogram
+}
+
 // this is based on the logic from the String() function in github.com/prometheus/common/model.go
 func metricNameFromLabels(f *data.Field) string {
 	labels := f.Labels
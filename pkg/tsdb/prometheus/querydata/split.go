@@ -0,0 +1,231 @@
+package querydata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/client"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/models"
+)
+
+const (
+	// maxConcurrentSegmentQueries limits the sub-range queries of a split range query that run at the same time.
+	maxConcurrentSegmentQueries = 4
+	// defaultOverlapWindow is the default age of the newest data that is never served from the cache, because
+	// Prometheus may still receive samples for it.
+	defaultOverlapWindow   = 10 * time.Minute
+	segmentCacheExpiration = time.Hour
+)
+
+// Cache stores the responses of the segments of split range queries. remotecache.CacheStorage implements it.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, expire time.Duration) error
+}
+
+// authHeaders are the forwarded request headers that can change the response of Prometheus, the cached segments are
+// kept apart per value of them.
+var authHeaders = []string{"Authorization", "X-Id-Token", "Cookie"}
+
+// segmentCacheScope returns the scope that the cached segments of a request are kept apart by. Grafana adds headers
+// per org, user and team to the requests to Prometheus, such as the user header and the team LBAC headers, so the
+// segments are only shared by the requests of the same user of the same org with the same authorization headers.
+func segmentCacheScope(pCtx backend.PluginContext, headers map[string]string) string {
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "%d\n", pCtx.OrgID)
+	if pCtx.User != nil {
+		_, _ = fmt.Fprintf(&sb, "%s\n", pCtx.User.Login)
+	} else {
+		sb.WriteString("\n")
+	}
+	for _, name := range authHeaders {
+		_, _ = fmt.Fprintf(&sb, "%s\n", headers[name])
+	}
+	return sb.String()
+}
+
+// segment is a step-aligned sub-range of a range query. The start and the end are inclusive.
+type segment struct {
+	start time.Time
+	end   time.Time
+	// cached segments are queried whole, and the rows outside of the range of the query are dropped afterwards.
+	cached bool
+}
+
+// splitTimeRange splits the step-aligned time range of a query at the multiples of the split duration, rounded up to
+// a multiple of the step so that every segment is evaluated at the same points in time as the whole range. Segments
+// which end before the overlap window are cached when cache is true.
+func splitTimeRange(tr models.TimeRange, splitDuration time.Duration, offset int64, cache bool, cachedBefore time.Time) []segment {
+	length := ((splitDuration + tr.Step - 1) / tr.Step) * tr.Step
+	var segments []segment
+	for start := models.AlignTimeRange(tr.Start, length, offset); !start.After(tr.End); start = start.Add(length) {
+		seg := segment{start: start, end: start.Add(length - tr.Step)}
+		seg.cached = cache && seg.end.Before(cachedBefore)
+		if !seg.cached {
+			if seg.start.Before(tr.Start) {
+				seg.start = tr.Start
+			}
+			if seg.end.After(tr.End) {
+				seg.end = tr.End
+			}
+		}
+		segments = append(segments, seg)
+	}
+	return segments
+}
+
+// splitRangeQuery runs a range query as concurrent queries of its segments and merges the responses. Cached segments
+// are read from the cache instead of Prometheus, so that a refresh of a dashboard only queries the newest segments.
+func (s *QueryData) splitRangeQuery(ctx context.Context, c *client.Client, q *models.Query, cacheScope string, enablePrometheusDataplaneFlag bool) backend.DataResponse {
+	if s.splitDuration <= 0 || q.Step <= 0 {
+		return s.rangeQuery(ctx, c, q, enablePrometheusDataplaneFlag)
+	}
+
+	tr := q.TimeRange()
+	segments := splitTimeRange(tr, s.splitDuration, q.UtcOffsetSec, s.cache != nil, time.Now().Add(-s.overlapWindow))
+	if len(segments) == 1 && !segments[0].cached {
+		return s.rangeQuery(ctx, c, q, enablePrometheusDataplaneFlag)
+	}
+
+	responses := make([]backend.DataResponse, len(segments))
+	g := errgroup.Group{}
+	g.SetLimit(maxConcurrentSegmentQueries)
+	for i, seg := range segments {
+		i, seg := i, seg
+		g.Go(func() error {
+			responses[i] = s.querySegment(ctx, c, q, seg, cacheScope, enablePrometheusDataplaneFlag)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	for _, res := range responses {
+		if res.Error != nil {
+			return res
+		}
+	}
+
+	frames := mergeSegmentFrames(responses, tr)
+	if len(frames) == 0 {
+		frames = append(frames, data.NewFrame(""))
+	}
+	for _, frame := range frames {
+		if frame.Meta == nil {
+			frame.Meta = &data.FrameMeta{}
+		}
+		frame.Meta.ExecutedQueryString = ""
+	}
+	frames[0].Meta.ExecutedQueryString = executedQueryString(q)
+
+	return backend.DataResponse{Frames: frames}
+}
+
+func (s *QueryData) querySegment(ctx context.Context, c *client.Client, q *models.Query, seg segment, cacheScope string, enablePrometheusDataplaneFlag bool) backend.DataResponse {
+	sq := *q
+	sq.Start = seg.start
+	sq.End = seg.end
+	if !seg.cached {
+		return s.rangeQuery(ctx, c, &sq, enablePrometheusDataplaneFlag)
+	}
+
+	logger := s.log.FromContext(ctx)
+	key := s.segmentCacheKey(&sq, cacheScope, enablePrometheusDataplaneFlag)
+	if b, err := s.cache.Get(ctx, key); err == nil {
+		var frames data.Frames
+		if err := json.Unmarshal(b, &frames); err == nil {
+			restoreResultType(frames)
+			return backend.DataResponse{Frames: frames}
+		}
+		logger.Warn("Failed to read cached segment", "error", err)
+	}
+
+	res := s.rangeQuery(ctx, c, &sq, enablePrometheusDataplaneFlag)
+	if res.Error != nil {
+		return res
+	}
+	b, err := json.Marshal(res.Frames)
+	if err != nil {
+		logger.Warn("Failed to marshal segment", "error", err)
+		return res
+	}
+	if err := s.cache.Set(ctx, key, b, segmentCacheExpiration); err != nil {
+		logger.Warn("Failed to cache segment", "error", err)
+	}
+	return res
+}
+
+func (s *QueryData) segmentCacheKey(q *models.Query, cacheScope string, enablePrometheusDataplaneFlag bool) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\n%s\n%d\n%s\n%d\n%d\n%t\n%s", q.Expr, q.LegendFormat, q.Step, s.URL, q.Start.UnixNano(), q.End.UnixNano(), enablePrometheusDataplaneFlag, cacheScope)
+	return fmt.Sprintf("prometheus-segment-%d-%x", s.ID, h.Sum(nil))
+}
+
+// mergeSegmentFrames appends the rows of the frames of the same series in the responses of the segments, which are
+// in time order, and drops the rows outside of the time range of the query.
+func mergeSegmentFrames(responses []backend.DataResponse, tr models.TimeRange) data.Frames {
+	var merged data.Frames
+	byKey := make(map[string]*data.Frame)
+	for _, res := range responses {
+		for _, frame := range res.Frames {
+			if len(frame.Fields) == 0 {
+				continue
+			}
+			key := frameKey(frame)
+			m, ok := byKey[key]
+			if !ok {
+				m = frame.EmptyCopy()
+				if frame.Meta != nil {
+					meta := *frame.Meta
+					m.Meta = &meta
+				}
+				for i, field := range frame.Fields {
+					m.Fields[i].Config = field.Config
+				}
+				byKey[key] = m
+				merged = append(merged, m)
+			}
+			for row := 0; row < frame.Rows(); row++ {
+				if t, ok := frame.Fields[0].At(row).(time.Time); ok && (t.Before(tr.Start) || t.After(tr.End)) {
+					continue
+				}
+				m.AppendRow(frame.RowCopy(row)...)
+			}
+		}
+	}
+	return merged
+}
+
+func frameKey(frame *data.Frame) string {
+	var sb strings.Builder
+	sb.WriteString(frame.Name)
+	if frame.Meta != nil {
+		sb.WriteString("\n" + string(frame.Meta.Type))
+	}
+	for _, field := range frame.Fields {
+		sb.WriteString("\n" + field.Name + "\n" + field.Type().ItemTypeString() + "\n" + field.Labels.String())
+	}
+	return sb.String()
+}
+
+// restoreResultType restores the custom metadata of the frames after the round trip through JSON, which turns it
+// into a map[string]any.
+func restoreResultType(frames data.Frames) {
+	for _, frame := range frames {
+		if frame.Meta == nil {
+			continue
+		}
+		if custom, ok := frame.Meta.Custom.(map[string]any); ok {
+			if rt, ok := custom["resultType"].(string); ok {
+				frame.Meta.Custom = map[string]string{"resultType": rt}
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package querydata
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/client"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/models"
+	"github.com/grafana/grafana/pkg/tsdb/prometheus/querydata/exemplar"
+)
+
+func TestSplitTimeRange(t *testing.T) {
+	day := 24 * time.Hour
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tr := models.TimeRange{Start: start, End: start.Add(2 * day), Step: time.Hour}
+
+	t.Run("should split at multiples of the split duration", func(t *testing.T) {
+		segments := splitTimeRange(tr, day, 0, false, time.Time{})
+		require.Equal(t, []segment{
+			{start: start, end: time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)},
+			{start: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), end: time.Date(2024, 1, 2, 23, 0, 0, 0, time.UTC)},
+			{start: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), end: start.Add(2 * day)},
+		}, segments)
+	})
+
+	t.Run("should round the split duration up to a multiple of the step", func(t *testing.T) {
+		segments := splitTimeRange(tr, day+time.Minute, 0, false, time.Time{})
+		require.Greater(t, len(segments), 1)
+		require.Equal(t, int64(0), segments[1].start.Unix()%int64((25*time.Hour).Seconds()))
+		require.Equal(t, tr.Step, segments[1].start.Sub(segments[0].end))
+	})
+
+	t.Run("should query the whole segments that are cached", func(t *testing.T) {
+		segments := splitTimeRange(tr, day, 0, true, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC))
+		require.Equal(t, []segment{
+			{start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), end: time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC), cached: true},
+			{start: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), end: time.Date(2024, 1, 2, 23, 0, 0, 0, time.UTC), cached: true},
+			{start: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), end: start.Add(2 * day)},
+		}, segments)
+	})
+}
+
+func TestSplitRangeQuery(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	q := &models.Query{
+		Expr:       "up",
+		Step:       time.Hour,
+		Start:      start,
+		End:        start.Add(48 * time.Hour),
+		RefId:      "A",
+		RangeQuery: true,
+	}
+	doer := &fakeRangeDoer{}
+	c := client.NewClient(doer, http.MethodPost, "http://localhost:9090")
+	cache := &fakeCache{items: map[string][]byte{}}
+	qd := &QueryData{
+		log:             log.New(),
+		exemplarSampler: exemplar.NewStandardDeviationSampler,
+		splitDuration:   24 * time.Hour,
+		cache:           cache,
+		overlapWindow:   10 * time.Minute,
+	}
+
+	alice := segmentCacheScope(backend.PluginContext{OrgID: 1, User: &backend.User{Login: "alice"}}, nil)
+	res := qd.splitRangeQuery(context.Background(), c, q, alice, false)
+	require.NoError(t, res.Error)
+	require.Len(t, res.Frames, 1)
+	require.Equal(t, 49, res.Frames[0].Rows())
+	require.Equal(t, start, res.Frames[0].Fields[0].At(0))
+	require.Equal(t, float64(start.Unix()), res.Frames[0].Fields[1].At(0))
+	require.Equal(t, start.Add(48*time.Hour), res.Frames[0].Fields[0].At(48))
+	require.Equal(t, "Expr: up\nStep: 1h0m0s", res.Frames[0].Meta.ExecutedQueryString)
+	require.Len(t, doer.ranges, 3)
+	require.Len(t, cache.items, 3)
+
+	doer.ranges = nil
+	res = qd.splitRangeQuery(context.Background(), c, q, alice, false)
+	require.NoError(t, res.Error)
+	require.Equal(t, 49, res.Frames[0].Rows())
+	require.Empty(t, doer.ranges)
+	require.Equal(t, map[string]string{"resultType": "matrix"}, res.Frames[0].Meta.Custom)
+
+	doer.ranges = nil
+	res = qd.splitRangeQuery(context.Background(), c, q, segmentCacheScope(backend.PluginContext{OrgID: 1, User: &backend.User{Login: "alice"}}, map[string]string{"Authorization": "Bearer other"}), false)
+	require.NoError(t, res.Error)
+	require.Len(t, doer.ranges, 3)
+
+	// the requests of other users can have other headers, such as the team LBAC headers
+	for _, pCtx := range []backend.PluginContext{{OrgID: 1, User: &backend.User{Login: "bob"}}, {OrgID: 2, User: &backend.User{Login: "alice"}}} {
+		doer.ranges = nil
+		res = qd.splitRangeQuery(context.Background(), c, q, segmentCacheScope(pCtx, nil), false)
+		require.NoError(t, res.Error)
+		require.Len(t, doer.ranges, 3)
+	}
+}
+
+func TestHasTeamHTTPHeaders(t *testing.T) {
+	require.False(t, hasTeamHTTPHeaders(map[string]any{}))
+	require.False(t, hasTeamHTTPHeaders(map[string]any{"teamHttpHeaders": map[string]any{"headers": map[string]any{}}}))
+	require.True(t, hasTeamHTTPHeaders(map[string]any{"teamHttpHeaders": map[string]any{
+		"headers": map[string]any{"1": []any{map[string]any{"header": "X-Prom-Label-Policy", "value": "1:{job=\"a\"}"}}},
+	}}))
+}
+
+// fakeRangeDoer answers range queries with a sample per step, the value of which is its unix time.
+type fakeRangeDoer struct {
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (d *fakeRangeDoer) Do(req *http.Request) (*http.Response, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, err
+	}
+	start, _ := strconv.ParseFloat(req.Form.Get("start"), 64)
+	end, _ := strconv.ParseFloat(req.Form.Get("end"), 64)
+	step, _ := strconv.ParseFloat(req.Form.Get("step"), 64)
+
+	d.mu.Lock()
+	d.ranges = append(d.ranges, fmt.Sprintf("%v-%v", start, end))
+	d.mu.Unlock()
+
+	values := []string{}
+	for t := start; t <= end; t += step {
+		values = append(values, fmt.Sprintf(`[%v,"%v"]`, t, t))
+	}
+	body := `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"up"},"values":[` + strings.Join(values, ",") + `]}]}}`
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+}
+
+type fakeCache struct {
+	mu    sync.Mutex
+	items map[string][]byte
+}
+
+func (c *fakeCache) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if b, ok := c.items[key]; ok {
+		return b, nil
+	}
+	return nil, fmt.Errorf("not found")
+}
+
+func (c *fakeCache) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = value
+	return nil
+}
@@ -0,0 +1,302 @@
+package sqleng
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// schemaCacheTTL is how long the results of the schema resources are cached by a data source instance.
+const schemaCacheTTL = 5 * time.Minute
+
+// schemaCacheMaxEntries is the maximum number of responses of the schema resources cached by a data source instance.
+// When the cache is full, the least recently used response is evicted.
+const schemaCacheMaxEntries = 1000
+
+// SchemaDialect provides the queries that read the schema of the database for the schema resources. Every query
+// returns the rows in the order in which they are returned by the resource.
+//
+// The queries of tables, columns and indexes take the schema as first argument and the table as second argument.
+// If the schema is empty, they must use the default schema of the connection. In MySQL, where schemas are databases,
+// the schema is the name of the database.
+type SchemaDialect interface {
+	// DatabasesQuery returns the names of the databases of the server, as a single column.
+	DatabasesQuery() string
+	// SchemasQuery returns the names of the schemas of the connected database, as a single column.
+	SchemasQuery() string
+	// TablesQuery returns the names of the tables and views of a schema, as a single column.
+	TablesQuery() string
+	// ColumnsQuery returns the name, the data type and whether the column is nullable of the columns of a table.
+	ColumnsQuery() string
+	// IndexesQuery returns the name of the index, the name of the column and whether the index is unique of the
+	// columns of the indexes of a table, ordered by index and position of the column in the index.
+	IndexesQuery() string
+	// IsTimeType returns true if columns with the data type can be used as time column.
+	IsTimeType(dataType string) bool
+}
+
+// Column describes a column of a table.
+type Column struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// Index describes an index of a table.
+type Index struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+}
+
+type schemaCacheEntry struct {
+	key     string
+	body    []byte
+	expires time.Time
+}
+
+// schemaCache is a least recently used cache of the responses of the schema resources by their URL.
+type schemaCache struct {
+	mtx        sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	// lru contains the entries ordered from the most to the least recently used.
+	lru *list.List
+	now func() time.Time
+}
+
+func newSchemaCache() *schemaCache {
+	return &schemaCache{
+		maxEntries: schemaCacheMaxEntries,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+func (c *schemaCache) get(key string) ([]byte, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*schemaCacheEntry)
+	if c.now().After(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.body, true
+}
+
+func (c *schemaCache) set(key string, body []byte) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	entry := &schemaCacheEntry{key: key, body: body, expires: c.now().Add(schemaCacheTTL)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *schemaCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*schemaCacheEntry).key)
+}
+
+// CallResource serves the schema of the database:
+//
+//   - databases: the names of the databases of the server.
+//   - schemas: the names of the schemas of the connected database.
+//   - tables?schema=: the names of the tables of the schema.
+//   - columns?schema=&table=: the columns of the table, see Column.
+//   - indexes?schema=&table=: the indexes of the table, see Index.
+//   - time-columns?schema=&table=: the columns of the table that can be used as time column, most likely first.
+//
+// The responses are cached by the data source instance. The cache is bypassed if the refresh parameter is true.
+func (e *DataSourceHandler) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req.Method != http.MethodGet {
+		return sendSchemaError(sender, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", req.Method))
+	}
+	if e.schemaDialect == nil {
+		return sendSchemaError(sender, http.StatusNotFound, fmt.Errorf("schema introspection is not supported by this data source"))
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return sendSchemaError(sender, http.StatusBadRequest, fmt.Errorf("invalid URL: %w", err))
+	}
+	resource := strings.Trim(req.Path, "/")
+	params := u.Query()
+
+	key := resource + "?" + url.Values{"schema": {params.Get("schema")}, "table": {params.Get("table")}}.Encode()
+	if params.Get("refresh") != "true" {
+		if body, ok := e.schemaCache.get(key); ok {
+			return sendSchemaResponse(sender, http.StatusOK, body)
+		}
+	}
+
+	result, status, err := e.readSchema(ctx, resource, params.Get("schema"), params.Get("table"))
+	if err != nil {
+		return sendSchemaError(sender, status, err)
+	}
+	body, err := json.Marshal(result)
+	if err != nil {
+		return sendSchemaError(sender, http.StatusInternalServerError, err)
+	}
+	e.schemaCache.set(key, body)
+	return sendSchemaResponse(sender, http.StatusOK, body)
+}
+
+func (e *DataSourceHandler) readSchema(ctx context.Context, resource, schema, table string) (any, int, error) {
+	if table == "" && (resource == "columns" || resource == "indexes" || resource == "time-columns") {
+		return nil, http.StatusBadRequest, fmt.Errorf("parameter table is required")
+	}
+
+	var result any
+	var err error
+	switch resource {
+	case "databases":
+		result, err = e.queryNames(ctx, e.schemaDialect.DatabasesQuery())
+	case "schemas":
+		result, err = e.queryNames(ctx, e.schemaDialect.SchemasQuery())
+	case "tables":
+		result, err = e.queryNames(ctx, e.schemaDialect.TablesQuery(), schema)
+	case "columns":
+		result, err = e.queryColumns(ctx, schema, table)
+	case "indexes":
+		result, err = e.queryIndexes(ctx, schema, table)
+	case "time-columns":
+		var columns []Column
+		columns, err = e.queryColumns(ctx, schema, table)
+		if err == nil {
+			result = e.suggestTimeColumns(columns)
+		}
+	default:
+		return nil, http.StatusNotFound, fmt.Errorf("unknown resource %q", resource)
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, e.TransformQueryError(e.log.FromContext(ctx), err)
+	}
+	return result, http.StatusOK, nil
+}
+
+func (e *DataSourceHandler) queryNames(ctx context.Context, query string, args ...any) ([]string, error) {
+	names := []string{}
+	err := e.querySchema(ctx, query, args, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		names = append(names, name)
+		return nil
+	})
+	return names, err
+}
+
+func (e *DataSourceHandler) queryColumns(ctx context.Context, schema, table string) ([]Column, error) {
+	columns := []Column{}
+	err := e.querySchema(ctx, e.schemaDialect.ColumnsQuery(), []any{schema, table}, func(rows *sql.Rows) error {
+		var c Column
+		if err := rows.Scan(&c.Name, &c.Type, &c.Nullable); err != nil {
+			return err
+		}
+		columns = append(columns, c)
+		return nil
+	})
+	return columns, err
+}
+
+func (e *DataSourceHandler) queryIndexes(ctx context.Context, schema, table string) ([]Index, error) {
+	indexes := []Index{}
+	err := e.querySchema(ctx, e.schemaDialect.IndexesQuery(), []any{schema, table}, func(rows *sql.Rows) error {
+		var name, column string
+		var unique bool
+		if err := rows.Scan(&name, &column, &unique); err != nil {
+			return err
+		}
+		if n := len(indexes); n > 0 && indexes[n-1].Name == name {
+			indexes[n-1].Columns = append(indexes[n-1].Columns, column)
+			return nil
+		}
+		indexes = append(indexes, Index{Name: name, Columns: []string{column}, Unique: unique})
+		return nil
+	})
+	return indexes, err
+}
+
+func (e *DataSourceHandler) querySchema(ctx context.Context, query string, args []any, scan func(rows *sql.Rows) error) error {
+	rows, err := e.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			e.log.Warn("Failed to close rows", "err", err)
+		}
+	}()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// suggestTimeColumns returns the columns that can be used as time column. The columns whose name is one of the time
+// column names of the data source come first, then the columns with a time data type.
+func (e *DataSourceHandler) suggestTimeColumns(columns []Column) []string {
+	rank := func(c Column) int {
+		for i, name := range e.timeColumnNames {
+			if strings.EqualFold(c.Name, name) {
+				return i
+			}
+		}
+		if e.schemaDialect.IsTimeType(c.Type) {
+			return len(e.timeColumnNames)
+		}
+		return -1
+	}
+	candidates := make([]Column, 0, len(columns))
+	for _, c := range columns {
+		if rank(c) >= 0 {
+			candidates = append(candidates, c)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return rank(candidates[i]) < rank(candidates[j])
+	})
+	result := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		result = append(result, c.Name)
+	}
+	return result
+}
+
+func sendSchemaResponse(sender backend.CallResourceResponseSender, status int, body []byte) error {
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  status,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
+
+func sendSchemaError(sender backend.CallResourceResponseSender, status int, err error) error {
+	body, _ := json.Marshal(map[string]string{"message": err.Error()})
+	return sendSchemaResponse(sender, status, body)
+}
@@ -0,0 +1,178 @@
+package sqleng
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+// sqliteSchemaDialect reads the schema of a SQLite database, which only has the main schema.
+type sqliteSchemaDialect struct{}
+
+func (sqliteSchemaDialect) DatabasesQuery() string {
+	return "SELECT 'main'"
+}
+
+func (sqliteSchemaDialect) SchemasQuery() string {
+	return "SELECT 'main'"
+}
+
+func (sqliteSchemaDialect) TablesQuery() string {
+	return "SELECT name FROM sqlite_master WHERE type IN ('table', 'view') AND ?1 IN ('', 'main') ORDER BY name"
+}
+
+func (sqliteSchemaDialect) ColumnsQuery() string {
+	return `SELECT name, type, "notnull" = 0 FROM pragma_table_info(?2) WHERE ?1 IN ('', 'main') ORDER BY cid`
+}
+
+func (sqliteSchemaDialect) IndexesQuery() string {
+	return `SELECT il.name, ii.name, il."unique" FROM pragma_index_list(?2) il JOIN pragma_index_info(il.name) ii
+WHERE ?1 IN ('', 'main') ORDER BY il.name, ii.seqno`
+}
+
+func (sqliteSchemaDialect) IsTimeType(dataType string) bool {
+	return strings.EqualFold(dataType, "datetime")
+}
+
+type fakeResourceSender struct {
+	response *backend.CallResourceResponse
+}
+
+func (s *fakeResourceSender) Send(resp *backend.CallResourceResponse) error {
+	s.response = resp
+	return nil
+}
+
+func TestCallResource(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	_, err = db.Exec(`
+CREATE TABLE metrics (id INTEGER PRIMARY KEY, created_at DATETIME NOT NULL, host TEXT, value REAL, time INTEGER);
+CREATE UNIQUE INDEX metrics_host_created ON metrics (host, created_at);
+CREATE TABLE events (id INTEGER);
+`)
+	require.NoError(t, err)
+
+	handler := DataSourceHandler{
+		db:                     db,
+		log:                    backend.NewLoggerWith("logger", "test"),
+		queryResultTransformer: &testQueryResultTransformer{},
+		timeColumnNames:        []string{"time", "time_sec"},
+		schemaDialect:          sqliteSchemaDialect{},
+		schemaCache:            newSchemaCache(),
+	}
+
+	call := func(t *testing.T, path, query string) (int, []byte) {
+		t.Helper()
+		sender := &fakeResourceSender{}
+		url := path
+		if query != "" {
+			url += "?" + query
+		}
+		err := handler.CallResource(context.Background(), &backend.CallResourceRequest{Method: http.MethodGet, Path: path, URL: url}, sender)
+		require.NoError(t, err)
+		require.NotNil(t, sender.response)
+		return sender.response.Status, sender.response.Body
+	}
+
+	t.Run("should list tables", func(t *testing.T) {
+		status, body := call(t, "tables", "")
+		require.Equal(t, http.StatusOK, status)
+		require.JSONEq(t, `["events", "metrics"]`, string(body))
+	})
+
+	t.Run("should list columns with types", func(t *testing.T) {
+		status, body := call(t, "columns", "table=metrics")
+		require.Equal(t, http.StatusOK, status)
+		var columns []Column
+		require.NoError(t, json.Unmarshal(body, &columns))
+		require.Equal(t, []Column{
+			{Name: "id", Type: "INTEGER", Nullable: true},
+			{Name: "created_at", Type: "DATETIME", Nullable: false},
+			{Name: "host", Type: "TEXT", Nullable: true},
+			{Name: "value", Type: "REAL", Nullable: true},
+			{Name: "time", Type: "INTEGER", Nullable: true},
+		}, columns)
+	})
+
+	t.Run("should list indexes with their columns", func(t *testing.T) {
+		status, body := call(t, "indexes", "schema=main&table=metrics")
+		require.Equal(t, http.StatusOK, status)
+		var indexes []Index
+		require.NoError(t, json.Unmarshal(body, &indexes))
+		require.Equal(t, []Index{{Name: "metrics_host_created", Columns: []string{"host", "created_at"}, Unique: true}}, indexes)
+	})
+
+	t.Run("should suggest time columns", func(t *testing.T) {
+		status, body := call(t, "time-columns", "table=metrics")
+		require.Equal(t, http.StatusOK, status)
+		require.JSONEq(t, `["time", "created_at"]`, string(body))
+	})
+
+	t.Run("should cache responses until refreshed", func(t *testing.T) {
+		_, err := db.Exec("CREATE TABLE logs (id INTEGER)")
+		require.NoError(t, err)
+		_, body := call(t, "tables", "")
+		require.JSONEq(t, `["events", "metrics"]`, string(body))
+		_, body = call(t, "tables", "refresh=true")
+		require.JSONEq(t, `["events", "logs", "metrics"]`, string(body))
+
+		handler.schemaCache.now = func() time.Time { return time.Now().Add(schemaCacheTTL + time.Second) }
+		t.Cleanup(func() { handler.schemaCache.now = time.Now })
+		_, err = db.Exec("DROP TABLE logs")
+		require.NoError(t, err)
+		_, body = call(t, "tables", "")
+		require.JSONEq(t, `["events", "metrics"]`, string(body))
+	})
+
+	t.Run("should return errors", func(t *testing.T) {
+		status, _ := call(t, "columns", "")
+		require.Equal(t, http.StatusBadRequest, status)
+		status, _ = call(t, "unknown", "")
+		require.Equal(t, http.StatusNotFound, status)
+
+		sender := &fakeResourceSender{}
+		require.NoError(t, handler.CallResource(context.Background(), &backend.CallResourceRequest{Method: http.MethodPost, Path: "tables", URL: "tables"}, sender))
+		require.Equal(t, http.StatusMethodNotAllowed, sender.response.Status)
+
+		withoutDialect := DataSourceHandler{}
+		require.NoError(t, withoutDialect.CallResource(context.Background(), &backend.CallResourceRequest{Method: http.MethodGet, Path: "tables", URL: "tables"}, sender))
+		require.Equal(t, http.StatusNotFound, sender.response.Status)
+	})
+}
+
+func TestSchemaCache(t *testing.T) {
+	c := newSchemaCache()
+	c.maxEntries = 2
+
+	c.set("a", []byte("a"))
+	c.set("b", []byte("b"))
+	_, ok := c.get("a")
+	require.True(t, ok)
+
+	// b is the least recently used entry
+	c.set("c", []byte("c"))
+	_, ok = c.get("b")
+	require.False(t, ok)
+	body, ok := c.get("a")
+	require.True(t, ok)
+	require.Equal(t, []byte("a"), body)
+	_, ok = c.get("c")
+	require.True(t, ok)
+	require.Equal(t, 2, c.lru.Len())
+
+	c.set("a", []byte("updated"))
+	body, _ = c.get("a")
+	require.Equal(t, []byte("updated"), body)
+	require.Len(t, c.entries, 2)
+}
@@ -81,6 +81,8 @@ type DataPluginConfiguration struct {
 	TimeColumnNames   []string
 	MetricColumnTypes []string
 	RowLimit          int64
+	// SchemaDialect enables the schema resources of the data source, see DataSourceHandler.CallResource.
+	SchemaDialect SchemaDialect
 }
 
 type DataSourceHandler struct {
@@ -93,6 +95,8 @@ type DataSourceHandler struct {
 	dsInfo                 DataSourceInfo
 	rowLimit               int64
 	userError              string
+	schemaDialect          SchemaDialect
+	schemaCache            *schemaCache
 }
 
 type QueryJson struct {
@@ -128,6 +132,8 @@ func NewQueryDataHandler(cfg *setting.Cfg, db *sql.DB, config DataPluginConfigur
 		dsInfo:                 config.DSInfo,
 		rowLimit:               config.RowLimit,
 		userError:              cfg.UserFacingDefaultError,
+		schemaDialect:          config.SchemaDialect,
+		schemaCache:            newSchemaCache(),
 	}
 
 	if len(config.TimeColumnNames) > 0 {
@@ -109,8 +109,13 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 }
 
 func (s *Service) query(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery) (*backend.DataResponse, error) {
-	if query.QueryType == string(dataquery.TempoQueryTypeTraceId) {
+	switch dataquery.TempoQueryType(query.QueryType) {
+	case dataquery.TempoQueryTypeTraceId:
 		return s.getTrace(ctx, pCtx, query)
+	case dataquery.TempoQueryTypeTraceql, dataquery.TempoQueryTypeTraceqlSearch:
+		return s.runTraceQLSearch(ctx, pCtx, query)
+	case dataquery.TempoQueryTypeTraceqlMetrics:
+		return s.runTraceQLMetrics(ctx, pCtx, query)
 	}
 	return nil, fmt.Errorf("unsupported query type: '%s' for query with refID '%s'", query.QueryType, query.RefID)
 }
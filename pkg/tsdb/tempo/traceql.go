@@ -0,0 +1,248 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/tracing"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// searchResponse is the JSON response of the search API of Tempo
+type searchResponse struct {
+	Traces []struct {
+		TraceID           string      `json:"traceID"`
+		RootServiceName   string      `json:"rootServiceName"`
+		RootTraceName     string      `json:"rootTraceName"`
+		StartTimeUnixNano json.Number `json:"startTimeUnixNano"`
+		DurationMs        float64     `json:"durationMs"`
+	} `json:"traces"`
+}
+
+// queryRangeResponse is the JSON response of the TraceQL metrics API of Tempo
+type queryRangeResponse struct {
+	Series []struct {
+		Labels []struct {
+			Key   string         `json:"key"`
+			Value map[string]any `json:"value"`
+		} `json:"labels"`
+		Samples []struct {
+			TimestampMs json.Number `json:"timestampMs"`
+			Value       float64     `json:"value"`
+		} `json:"samples"`
+		PromLabels string `json:"promLabels"`
+	} `json:"series"`
+}
+
+// runTraceQLSearch runs a TraceQL query through the search API of Tempo and returns the matching traces as a table.
+func (s *Service) runTraceQLSearch(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery) (*backend.DataResponse, error) {
+	ctxLogger := s.logger.FromContext(ctx)
+	ctxLogger.Debug("Running TraceQL search", "function", logEntrypoint())
+
+	ctx, span := tracing.DefaultTracer().Start(ctx, "datasource.tempo.runTraceQLSearch", trace.WithAttributes(
+		attribute.String("queryType", query.QueryType),
+	))
+	defer span.End()
+
+	model, err := s.parseTraceQLQuery(ctx, query)
+	if err != nil {
+		return &backend.DataResponse{Error: err}, nil
+	}
+	dsInfo, err := s.getDSInfo(ctx, pCtx)
+	if err != nil {
+		ctxLogger.Error("Failed to get datasource information", "error", err, "function", logEntrypoint())
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("q", *model.Query)
+	params.Set("start", strconv.FormatInt(query.TimeRange.From.Unix(), 10))
+	params.Set("end", strconv.FormatInt(query.TimeRange.To.Unix(), 10))
+	if model.Limit != nil && *model.Limit > 0 {
+		params.Set("limit", strconv.FormatInt(*model.Limit, 10))
+	}
+	if model.Spss != nil && *model.Spss > 0 {
+		params.Set("spss", strconv.FormatInt(*model.Spss, 10))
+	}
+
+	var res searchResponse
+	if err := s.getJSON(ctx, dsInfo, "/api/search", params, &res); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return &backend.DataResponse{Error: err}, nil
+	}
+
+	frame := data.NewFrame("Traces",
+		data.NewField("traceID", nil, []string{}),
+		data.NewField("startTime", nil, []time.Time{}),
+		data.NewField("traceService", nil, []string{}),
+		data.NewField("traceName", nil, []string{}),
+		data.NewField("traceDuration", nil, []float64{}).SetConfig(&data.FieldConfig{Unit: "ms"}),
+	)
+	for _, t := range res.Traces {
+		start, err := t.StartTimeUnixNano.Int64()
+		if err != nil {
+			return &backend.DataResponse{Error: fmt.Errorf("failed to read start time of trace %s: %w", t.TraceID, err)}, nil
+		}
+		frame.AppendRow(t.TraceID, time.Unix(0, start).UTC(), t.RootServiceName, t.RootTraceName, t.DurationMs)
+	}
+	frame.RefID = query.RefID
+	frame.Meta = &data.FrameMeta{
+		PreferredVisualization: data.VisTypeTable,
+		ExecutedQueryString:    *model.Query,
+	}
+
+	ctxLogger.Debug("Successfully ran TraceQL search", "traces", len(res.Traces), "function", logEntrypoint())
+	return &backend.DataResponse{Frames: data.Frames{frame}}, nil
+}
+
+// runTraceQLMetrics runs a TraceQL metrics query, like rate() or quantile_over_time(), through the query range API of
+// Tempo and returns a time series per series of the response.
+func (s *Service) runTraceQLMetrics(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery) (*backend.DataResponse, error) {
+	ctxLogger := s.logger.FromContext(ctx)
+	ctxLogger.Debug("Running TraceQL metrics query", "function", logEntrypoint())
+
+	ctx, span := tracing.DefaultTracer().Start(ctx, "datasource.tempo.runTraceQLMetrics", trace.WithAttributes(
+		attribute.String("queryType", query.QueryType),
+	))
+	defer span.End()
+
+	model, err := s.parseTraceQLQuery(ctx, query)
+	if err != nil {
+		return &backend.DataResponse{Error: err}, nil
+	}
+	dsInfo, err := s.getDSInfo(ctx, pCtx)
+	if err != nil {
+		ctxLogger.Error("Failed to get datasource information", "error", err, "function", logEntrypoint())
+		return nil, err
+	}
+
+	step := query.Interval
+	if model.Step != nil && *model.Step != "" {
+		step, err = gtime.ParseDuration(*model.Step)
+		if err != nil {
+			return &backend.DataResponse{Error: fmt.Errorf("failed to parse step %q: %w", *model.Step, err)}, nil
+		}
+	}
+
+	params := url.Values{}
+	params.Set("q", *model.Query)
+	params.Set("start", strconv.FormatInt(query.TimeRange.From.Unix(), 10))
+	params.Set("end", strconv.FormatInt(query.TimeRange.To.Unix(), 10))
+	if step >= time.Second {
+		params.Set("step", step.String())
+	}
+
+	var res queryRangeResponse
+	if err := s.getJSON(ctx, dsInfo, "/api/metrics/query_range", params, &res); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return &backend.DataResponse{Error: err}, nil
+	}
+
+	frames := make(data.Frames, 0, len(res.Series))
+	for _, series := range res.Series {
+		labels := data.Labels{}
+		for _, l := range series.Labels {
+			labels[l.Key] = anyValueString(l.Value)
+		}
+
+		times := make([]time.Time, 0, len(series.Samples))
+		values := make([]float64, 0, len(series.Samples))
+		for _, sample := range series.Samples {
+			ms, err := sample.TimestampMs.Int64()
+			if err != nil {
+				return &backend.DataResponse{Error: fmt.Errorf("failed to read timestamp of sample: %w", err)}, nil
+			}
+			times = append(times, time.UnixMilli(ms).UTC())
+			values = append(values, sample.Value)
+		}
+
+		frame := data.NewFrame(series.PromLabels,
+			data.NewField(data.TimeSeriesTimeFieldName, nil, times),
+			data.NewField(data.TimeSeriesValueFieldName, labels, values),
+		)
+		frame.RefID = query.RefID
+		frame.Meta = &data.FrameMeta{Type: data.FrameTypeTimeSeriesMulti}
+		frames = append(frames, frame)
+	}
+	if len(frames) > 0 {
+		frames[0].Meta.ExecutedQueryString = *model.Query
+	}
+
+	ctxLogger.Debug("Successfully ran TraceQL metrics query", "series", len(frames), "function", logEntrypoint())
+	return &backend.DataResponse{Frames: frames}, nil
+}
+
+// parseTraceQLQuery returns the model of a TraceQL query. An invalid query fails only its own response, so the
+// other queries of the request still run.
+func (s *Service) parseTraceQLQuery(ctx context.Context, query backend.DataQuery) (*dataquery.TempoQuery, error) {
+	model := &dataquery.TempoQuery{}
+	if err := json.Unmarshal(query.JSON, model); err != nil {
+		s.logger.FromContext(ctx).Error("Failed to unmarshall Tempo query model", "error", err, "function", logEntrypoint())
+		return nil, fmt.Errorf("failed to read query with refID '%s': %w", query.RefID, err)
+	}
+	if model.Query == nil || *model.Query == "" {
+		return nil, fmt.Errorf("TraceQL query is required for query with refID '%s'", query.RefID)
+	}
+	return model, nil
+}
+
+// getJSON sends a GET request to an API of Tempo and decodes the JSON response.
+func (s *Service) getJSON(ctx context.Context, dsInfo *Datasource, path string, params url.Values, out any) error {
+	ctxLogger := s.logger.FromContext(ctx)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dsInfo.URL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := dsInfo.HTTPClient.Do(req)
+	if err != nil {
+		ctxLogger.Error("Failed to send request to Tempo", "error", err, "function", logEntrypoint())
+		return fmt.Errorf("failed get to tempo: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			ctxLogger.Error("Failed to close response body", "error", err, "function", logEntrypoint())
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to query %s Status: %s Body: %s", path, resp.Status, string(body))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", path, err)
+	}
+	return nil
+}
+
+// anyValueString returns the value of an OTLP AnyValue, like {"stringValue":"a"} or {"intValue":"1"}, as string.
+func anyValueString(v map[string]any) string {
+	for _, value := range v {
+		switch value := value.(type) {
+		case string:
+			return value
+		default:
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
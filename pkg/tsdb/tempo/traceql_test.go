@@ -0,0 +1,119 @@
+package tempo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceQL(t *testing.T) {
+	var requests []*url.URL
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL)
+		var err error
+		switch r.URL.Path {
+		case "/api/search":
+			_, err = rw.Write([]byte(`{"traces":[{"traceID":"2f3e0cee77ae5dc9c17ade3689eb2e54","rootServiceName":"shop-backend","rootTraceName":"update-billing","startTimeUnixNano":"1684778327699392724","durationMs":557}]}`))
+		case "/api/metrics/query_range":
+			_, err = rw.Write([]byte(`{"series":[{"labels":[{"key":"resource.service.name","value":{"stringValue":"shop-backend"}}],"samples":[{"timestampMs":"1700000000000","value":0.5},{"timestampMs":"1700000030000"}],"promLabels":"{resource.service.name=\"shop-backend\"}"}]}`))
+		default:
+			rw.WriteHeader(http.StatusBadRequest)
+			_, err = rw.Write([]byte(`invalid TraceQL query`))
+		}
+		require.NoError(t, err)
+	}))
+	t.Cleanup(ts.Close)
+
+	service := &Service{
+		logger: backend.NewLoggerWith("logger", "tempo-test"),
+		im:     &fakeInstanceManager{ds: &Datasource{HTTPClient: ts.Client(), URL: ts.URL}},
+	}
+	timeRange := backend.TimeRange{From: time.Unix(1700000000, 0), To: time.Unix(1700003600, 0)}
+
+	t.Run("should return the traces of a search as table", func(t *testing.T) {
+		requests = nil
+		res, err := service.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				QueryType: "traceql",
+				TimeRange: timeRange,
+				JSON:      []byte(`{"query":"{ status = error }","limit":20,"spss":3}`),
+			}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, res.Responses["A"].Error)
+		require.Equal(t, "/api/search", requests[0].Path)
+		require.Equal(t, url.Values{"q": {"{ status = error }"}, "start": {"1700000000"}, "end": {"1700003600"}, "limit": {"20"}, "spss": {"3"}}, requests[0].Query())
+
+		frame := res.Responses["A"].Frames[0]
+		require.Equal(t, 1, frame.Rows())
+		require.Equal(t, "2f3e0cee77ae5dc9c17ade3689eb2e54", frame.Fields[0].At(0))
+		require.Equal(t, time.Unix(0, 1684778327699392724).UTC(), frame.Fields[1].At(0))
+		require.Equal(t, 557.0, frame.Fields[4].At(0))
+	})
+
+	t.Run("should return the series of a metrics query as time series", func(t *testing.T) {
+		requests = nil
+		res, err := service.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				QueryType: "traceqlMetrics",
+				TimeRange: timeRange,
+				Interval:  15 * time.Second,
+				JSON:      []byte(`{"query":"{ status = error } | rate() by (resource.service.name)","step":"30s"}`),
+			}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, res.Responses["A"].Error)
+		require.Equal(t, "30s", requests[0].Query().Get("step"))
+
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		require.Equal(t, data.FrameTypeTimeSeriesMulti, frames[0].Meta.Type)
+		require.Equal(t, data.Labels{"resource.service.name": "shop-backend"}, frames[0].Fields[1].Labels)
+		require.Equal(t, time.UnixMilli(1700000030000).UTC(), frames[0].Fields[0].At(1))
+		require.Equal(t, 0.5, frames[0].Fields[1].At(0))
+		require.Equal(t, 0.0, frames[0].Fields[1].At(1))
+	})
+
+	t.Run("should fail only the query without TraceQL query", func(t *testing.T) {
+		res, err := service.QueryData(context.Background(), &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
+				{RefID: "A", QueryType: "traceqlMetrics", TimeRange: timeRange, JSON: []byte(`{}`)},
+				{RefID: "B", QueryType: "traceql", TimeRange: timeRange, JSON: []byte(`{"query":""}`)},
+				{RefID: "C", QueryType: "traceql", TimeRange: timeRange, JSON: []byte(`{"query":"{ status = error }"}`)},
+			},
+		})
+		require.NoError(t, err)
+		require.ErrorContains(t, res.Responses["A"].Error, "TraceQL query is required")
+		require.ErrorContains(t, res.Responses["B"].Error, "TraceQL query is required")
+		require.NoError(t, res.Responses["C"].Error)
+		require.Len(t, res.Responses["C"].Frames, 1)
+	})
+}
+
+func TestAnyValueString(t *testing.T) {
+	require.Equal(t, "a", anyValueString(map[string]any{"stringValue": "a"}))
+	require.Equal(t, "true", anyValueString(map[string]any{"boolValue": true}))
+	require.Equal(t, "", anyValueString(nil))
+}
+
+type fakeInstanceManager struct {
+	ds *Datasource
+}
+
+func (f *fakeInstanceManager) Get(_ context.Context, _ backend.PluginContext) (instancemgmt.Instance, error) {
+	return f.ds, nil
+}
+
+func (f *fakeInstanceManager) Do(_ context.Context, _ backend.PluginContext, _ instancemgmt.InstanceCallbackFunc) error {
+	return nil
+}
@@ -651,16 +651,10 @@ func readMatrixOrVectorMulti(iter *jsonitere.Iterator, resultType string, opt Op
 		}
 
 		if histogram != nil {
-			histogram.yMin.Labels = valueField.Labels
-			frame := data.NewFrame(valueField.Name, histogram.time, histogram.yMin, histogram.yMax, histogram.count, histogram.yLayout)
-			frame.Meta = &data.FrameMeta{
-				Type: "heatmap-cells",
-			}
-			if frame.Name == data.TimeSeriesValueFieldName {
-				frame.Name = "" // only set the name if useful
-			}
-			rsp.Frames = append(rsp.Frames, frame)
-		} else {
+			rsp.Frames = append(rsp.Frames, histogram.frame(resultType, valueField.Labels))
+		}
+		// a series can mix float samples and native histograms, so the floats are kept as their own frame
+		if histogram == nil || valueField.Len() > 0 {
 			frame := data.NewFrame("", timeField, valueField)
 			frame.Meta = &data.FrameMeta{
 				Type:   data.FrameTypeTimeSeriesMulti,
@@ -707,6 +701,15 @@ func readTimeValuePair(iter *jsonitere.Iterator) (time.Time, float64, error) {
 	return tt, fv, err
 }
 
+const (
+	// FrameTypeHeatmapCells is the type of the frames with the native histograms of range queries. Every row is a
+	// bucket of the histogram at the time in the xMax field, see histogramInfo.
+	FrameTypeHeatmapCells data.FrameType = "heatmap-cells"
+	// FrameTypeHistogram is the type of the frames with the native histogram of an instant query. Every row is a
+	// bucket with its bounds in the xMin and xMax fields.
+	FrameTypeHistogram data.FrameType = "histogram"
+)
+
 type histogramInfo struct {
 	//XMax (time)	YMin	Ymax	Count	YLayout
 	time    *data.Field
@@ -732,6 +735,30 @@ func newHistogramInfo() *histogramInfo {
 	return hist
 }
 
+// frame returns the native histograms of a range query as a heatmap-cells frame, and the histogram of an instant query
+// as a histogram frame with the xMin, xMax and count fields of the histogram transformation.
+func (hist *histogramInfo) frame(resultType string, labels data.Labels) *data.Frame {
+	if resultType == "vector" {
+		hist.yMin.Name = "xMin"
+		hist.yMax.Name = "xMax"
+		hist.count.Labels = labels
+		frame := data.NewFrame("", hist.yMin, hist.yMax, hist.count)
+		frame.Meta = &data.FrameMeta{
+			Type:   FrameTypeHistogram,
+			Custom: resultTypeToCustomMeta(resultType),
+		}
+		return frame
+	}
+
+	hist.yMin.Labels = labels
+	frame := data.NewFrame("", hist.time, hist.yMin, hist.yMax, hist.count, hist.yLayout)
+	frame.Meta = &data.FrameMeta{
+		Type:   FrameTypeHeatmapCells,
+		Custom: resultTypeToCustomMeta(resultType),
+	}
+	return frame
+}
+
 // This will read a single sparse histogram
 // [ time, { count, sum, buckets: [...] }]
 func readHistogram(iter *jsonitere.Iterator, hist *histogramInfo) error {
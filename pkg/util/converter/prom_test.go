@@ -23,6 +23,7 @@ var files = []string{
 	"prom-matrix-with-nans",
 	"prom-matrix-histogram-no-labels",
 	"prom-matrix-histogram-partitioned",
+	"prom-matrix-histogram-with-floats",
 	"prom-vector-histogram-no-labels",
 	"prom-vector",
 	"prom-string",
@@ -95,6 +95,50 @@ func RemoteAddr(req *http.Request) string {
 	return addr
 }
 
+// ClientIP returns the IP address of the client that sent the request.
+// Unlike RemoteAddr, the X-Real-IP and X-Forwarded-For headers are only
+// honoured when the request comes directly from one of the trusted proxies,
+// since any client can set them.
+func ClientIP(req *http.Request, trustedProxies []*net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	peer := net.ParseIP(host)
+	if peer == nil || !ipInNets(peer, trustedProxies) {
+		return peer
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(req.Header.Get("X-Real-IP"))); ip != nil {
+		return ip
+	}
+
+	// Walk X-Forwarded-For from the right, the closest hop first, and return
+	// the first address that was not appended by one of our own proxies.
+	hops := strings.Split(req.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		peer = ip
+		if !ipInNets(ip, trustedProxies) {
+			break
+		}
+	}
+
+	return peer
+}
+
+func ipInNets(ip net.IP, nets []*net.IPNet) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 const (
 	headerContentType = "Content-Type"
 	contentTypeJSON   = "application/json; charset=UTF-8"
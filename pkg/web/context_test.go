@@ -1,11 +1,13 @@
 package web
 
 import (
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 )
@@ -98,6 +100,55 @@ func TestContext_RemoteAddr(t *testing.T) {
 	}
 }
 
+func TestClientIP(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	trusted := []*net.IPNet{proxies}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{
+			name:       "Spoofed X-Real-Ip from an untrusted peer is ignored",
+			remoteAddr: "203.0.113.7:51299",
+			header:     http.Header{"X-Real-Ip": []string{"192.168.1.1"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Spoofed X-Forwarded-For from an untrusted peer is ignored",
+			remoteAddr: "203.0.113.7:51299",
+			header:     http.Header{"X-Forwarded-For": []string{"192.168.1.1"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "X-Real-Ip from a trusted proxy is honoured",
+			remoteAddr: "10.0.0.2:51299",
+			header:     http.Header{"X-Real-Ip": []string{"192.168.1.1"}},
+			want:       "192.168.1.1",
+		},
+		{
+			name:       "X-Forwarded-For returns the closest untrusted hop",
+			remoteAddr: "10.0.0.2:51299",
+			header:     http.Header{"X-Forwarded-For": []string{"192.168.1.1, 203.0.113.7, 10.0.0.3"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "IPv6 socket address",
+			remoteAddr: "[::1]:51299",
+			want:       "::1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &http.Request{RemoteAddr: tt.remoteAddr, Header: tt.header}
+			assert.Equal(t, tt.want, ClientIP(req, trusted).String())
+		})
+	}
+}
+
 func TestContext_noHandler(t *testing.T) {
 	recorder := httptest.NewRecorder()
 
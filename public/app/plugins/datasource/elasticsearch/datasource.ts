@@ -983,6 +983,15 @@ export class ElasticDatasource
     scopedVars: ScopedVars,
     filters?: AdHocVariableFilter[]
   ): ElasticsearchQuery {
+    // ES|QL and SQL queries are not lucene queries, they only get their variables interpolated
+    if (query.queryType === 'esql' || query.queryType === 'sql') {
+      return {
+        ...query,
+        datasource: this.getRef(),
+        query: this.templateSrv.replace(query.query || '', scopedVars),
+      };
+    }
+
     // We need a separate interpolation format for lucene queries, therefore we first interpolate any
     // lucene query string and then everything else
     const interpolateBucketAgg = (bucketAgg: BucketAggregation): BucketAggregation => {
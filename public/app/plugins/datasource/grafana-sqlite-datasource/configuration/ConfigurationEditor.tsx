@@ -0,0 +1,107 @@
+import React from 'react';
+
+import { DataSourcePluginOptionsEditorProps, onUpdateDatasourceJsonDataOption } from '@grafana/data';
+import { ConfigSection, ConfigSubSection, DataSourceDescription } from '@grafana/experimental';
+import { ConnectionLimits, Divider } from '@grafana/sql';
+import { Button, Field, IconButton, Input, Stack } from '@grafana/ui';
+
+import { SQLiteFile, SQLiteOptions } from '../types';
+
+export const ConfigurationEditor = (props: DataSourcePluginOptionsEditorProps<SQLiteOptions>) => {
+  const { options, onOptionsChange } = props;
+  const jsonData = options.jsonData;
+  const files = jsonData.files ?? [];
+
+  const onFilesChange = (files: SQLiteFile[]) => {
+    onOptionsChange({ ...options, jsonData: { ...jsonData, files } });
+  };
+
+  const onFileChange = (index: number, file: Partial<SQLiteFile>) => {
+    onFilesChange(files.map((f, i) => (i === index ? { ...f, ...file } : f)));
+  };
+
+  const WIDTH_LONG = 40;
+
+  return (
+    <>
+      <DataSourceDescription
+        dataSourceName="SQLite"
+        docsLink="https://grafana.com/docs/grafana/latest/datasources/sqlite/"
+        hasRequiredFields={false}
+      />
+
+      <Divider />
+
+      <ConfigSection
+        title="Database"
+        description="Files must be in one of the directories allowed by the sqlite_allowed_directories setting of the Grafana server. Relative paths are relative to the first allowed directory. The database is opened read-only."
+      >
+        <Field label="Database file" description="Leave empty to only query the attached files.">
+          <Input
+            width={WIDTH_LONG}
+            name="path"
+            value={jsonData.path || ''}
+            placeholder="field-data.db"
+            onChange={onUpdateDatasourceJsonDataOption(props, 'path')}
+          />
+        </Field>
+      </ConfigSection>
+
+      <Divider />
+
+      <ConfigSection
+        title="Attached files"
+        description="CSV and Parquet files are loaded as tables of the temp schema. The first row of CSV files is the header."
+      >
+        {files.map((file, index) => (
+          <Stack key={index} gap={1} alignItems="center">
+            <Field label="File path">
+              <Input
+                width={WIDTH_LONG}
+                value={file.path}
+                placeholder="readings.csv"
+                onChange={(e) => onFileChange(index, { path: e.currentTarget.value })}
+              />
+            </Field>
+            <Field label="Table name">
+              <Input
+                width={20}
+                value={file.table}
+                placeholder="readings"
+                onChange={(e) => onFileChange(index, { table: e.currentTarget.value })}
+              />
+            </Field>
+            <IconButton
+              name="trash-alt"
+              tooltip="Remove file"
+              onClick={() => onFilesChange(files.filter((_, i) => i !== index))}
+            />
+          </Stack>
+        ))}
+        <Button variant="secondary" icon="plus" onClick={() => onFilesChange([...files, { path: '', table: '' }])}>
+          Add file
+        </Button>
+      </ConfigSection>
+
+      <Divider />
+
+      <ConfigSection title="Additional settings" isCollapsible>
+        <ConfigSubSection title="SQLite Options">
+          <Field
+            label="Min time interval"
+            description="A lower limit for the auto group by time interval. Recommended to be set to write frequency, for example 1m if your data is written every minute."
+          >
+            <Input
+              width={WIDTH_LONG}
+              placeholder="1m"
+              value={jsonData.timeInterval || ''}
+              onChange={onUpdateDatasourceJsonDataOption(props, 'timeInterval')}
+            />
+          </Field>
+        </ConfigSubSection>
+
+        <ConnectionLimits options={options} onOptionsChange={onOptionsChange} />
+      </ConfigSection>
+    </>
+  );
+};
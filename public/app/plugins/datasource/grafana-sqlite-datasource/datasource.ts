@@ -0,0 +1,92 @@
+import { DataSourceInstanceSettings, TimeRange } from '@grafana/data';
+import { CompletionItemKind, LanguageDefinition, TableIdentifier } from '@grafana/experimental';
+import { SqlDatasource, DB, SQLQuery, formatSQL } from '@grafana/sql';
+
+import { getSqlCompletionProvider } from './sqlCompletionProvider';
+import { mapFieldsToTypes, quoteIdentifierIfNecessary, quoteLiteral, toRawSql } from './sqlUtil';
+import { SQLiteColumn, SQLiteOptions } from './types';
+
+export class SQLiteDatasource extends SqlDatasource {
+  sqlLanguageDefinition: LanguageDefinition | undefined;
+
+  constructor(instanceSettings: DataSourceInstanceSettings<SQLiteOptions>) {
+    super(instanceSettings);
+  }
+
+  getQueryModel() {
+    return { quoteLiteral };
+  }
+
+  getSqlLanguageDefinition(): LanguageDefinition {
+    if (this.sqlLanguageDefinition !== undefined) {
+      return this.sqlLanguageDefinition;
+    }
+
+    const args = {
+      getMeta: (identifier?: TableIdentifier) => this.fetchMeta(identifier),
+    };
+
+    this.sqlLanguageDefinition = {
+      id: 'sql',
+      completionProvider: getSqlCompletionProvider(args),
+      formatter: formatSQL,
+    };
+
+    return this.sqlLanguageDefinition;
+  }
+
+  // The schemas are main, the database file, and temp, the attached CSV and Parquet files.
+  async fetchDatasets(): Promise<string[]> {
+    return this.getResource<string[]>('schemas');
+  }
+
+  async fetchTables(dataset?: string): Promise<string[]> {
+    const tables = await this.getResource<string[]>('tables', { schema: dataset ?? '' });
+    return tables.map(quoteIdentifierIfNecessary);
+  }
+
+  async fetchFields(query: Partial<SQLQuery>) {
+    if (!query.table) {
+      return [];
+    }
+    const columns = await this.getResource<SQLiteColumn[]>('columns', {
+      schema: query.dataset ?? '',
+      table: query.table,
+    });
+    const fields = columns.map((c) => ({
+      name: c.name,
+      text: c.name,
+      value: quoteIdentifierIfNecessary(c.name),
+      type: c.type,
+      label: c.name,
+    }));
+    return mapFieldsToTypes(fields);
+  }
+
+  async fetchMeta(identifier?: TableIdentifier) {
+    if (identifier?.table) {
+      const fields = await this.fetchFields({ dataset: identifier.schema, table: identifier.table });
+      return fields.map((f) => ({ name: f.name, completion: f.value, kind: CompletionItemKind.Field }));
+    }
+    const tables = await this.fetchTables(identifier?.schema);
+    return tables.map((t) => ({ name: t, completion: t, kind: CompletionItemKind.Class }));
+  }
+
+  getDB(): DB {
+    if (this.db !== undefined) {
+      return this.db;
+    }
+
+    return {
+      datasets: () => this.fetchDatasets(),
+      tables: (dataset?: string) => this.fetchTables(dataset),
+      fields: (query: SQLQuery) => this.fetchFields(query),
+      validateQuery: (query: SQLQuery, _range?: TimeRange) =>
+        Promise.resolve({ query, error: '', isError: false, isValid: true }),
+      dsID: () => this.id,
+      toRawSql,
+      functions: () => ['TOTAL', 'GROUP_CONCAT'],
+      getEditorLanguageDefinition: () => this.getSqlLanguageDefinition(),
+    };
+  }
+}
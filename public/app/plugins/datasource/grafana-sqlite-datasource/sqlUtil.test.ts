@@ -0,0 +1,44 @@
+import { QueryEditorExpressionType, SQLQuery } from '@grafana/sql';
+
+import { mapFieldsToTypes, quoteIdentifierIfNecessary, toRawSql } from './sqlUtil';
+
+describe('toRawSql', () => {
+  it('builds the query of the table of the dataset', () => {
+    const query: SQLQuery = {
+      refId: 'A',
+      dataset: 'temp',
+      table: 'readings',
+      sql: {
+        columns: [
+          {
+            type: QueryEditorExpressionType.Function,
+            parameters: [{ type: QueryEditorExpressionType.FunctionParameter, name: 'value' }],
+          },
+        ],
+        limit: 10,
+      },
+    };
+    expect(toRawSql(query)).toBe('SELECT value FROM temp.readings LIMIT 10 ');
+  });
+});
+
+describe('quoteIdentifierIfNecessary', () => {
+  it('quotes identifiers with special characters', () => {
+    expect(quoteIdentifierIfNecessary('readings')).toBe('readings');
+    expect(quoteIdentifierIfNecessary('sensor readings')).toBe('"sensor readings"');
+    expect(quoteIdentifierIfNecessary('a"b')).toBe('"a""b"');
+  });
+});
+
+describe('mapFieldsToTypes', () => {
+  it('maps declared types with the type affinity rules', () => {
+    const fields = mapFieldsToTypes([
+      { name: 'a', type: 'BIGINT' },
+      { name: 'b', type: 'DOUBLE PRECISION' },
+      { name: 'c', type: 'DATETIME' },
+      { name: 'd', type: 'VARCHAR(10)' },
+      { name: 'e', type: '' },
+    ]);
+    expect(fields.map((f) => f.raqbFieldType)).toEqual(['number', 'number', 'datetime', 'text', 'text']);
+  });
+});
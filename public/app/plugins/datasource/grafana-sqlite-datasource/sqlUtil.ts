@@ -0,0 +1,79 @@
+import { isEmpty } from 'lodash';
+
+import { RAQBFieldTypes, SQLQuery, SQLSelectableValue, createSelectClause, haveColumns } from '@grafana/sql';
+
+export function toRawSql({ sql, dataset, table }: SQLQuery): string {
+  let rawQuery = '';
+
+  // Return early with empty string if there is no sql column
+  if (!sql || !haveColumns(sql.columns)) {
+    return rawQuery;
+  }
+
+  rawQuery += createSelectClause(sql.columns);
+
+  if (dataset && table) {
+    rawQuery += `FROM ${dataset}.${table} `;
+  } else if (table) {
+    rawQuery += `FROM ${table} `;
+  }
+
+  if (sql.whereString) {
+    rawQuery += `WHERE ${sql.whereString} `;
+  }
+
+  if (sql.groupBy?.[0]?.property.name) {
+    const groupBy = sql.groupBy.map((g) => g.property.name).filter((g) => !isEmpty(g));
+    rawQuery += `GROUP BY ${groupBy.join(', ')} `;
+  }
+
+  if (sql.orderBy?.property.name) {
+    rawQuery += `ORDER BY ${sql.orderBy.property.name} `;
+  }
+
+  if (sql.orderBy?.property.name && sql.orderByDirection) {
+    rawQuery += `${sql.orderByDirection} `;
+  }
+
+  if (sql.limit !== undefined && sql.limit >= 0) {
+    rawQuery += `LIMIT ${sql.limit} `;
+  }
+  return rawQuery;
+}
+
+// Puts double quotes around the identifier if it is necessary.
+export function quoteIdentifierIfNecessary(value: string) {
+  return /^[a-zA-Z_][a-zA-Z0-9_]*$/.test(value) ? value : `"${value.replace(/"/g, '""')}"`;
+}
+
+export function quoteLiteral(value: string) {
+  return "'" + value.replace(/'/g, "''") + "'";
+}
+
+/**
+ * Maps the declared types of SQLite columns to field types, following the type affinity rules of
+ * https://www.sqlite.org/datatype3.html.
+ */
+export function mapFieldsToTypes(columns: SQLSelectableValue[]) {
+  return columns.map((col) => {
+    let type: RAQBFieldTypes = 'text';
+    const declared = col.type?.toUpperCase() ?? '';
+    if (declared === 'DATE') {
+      type = 'date';
+    } else if (declared === 'DATETIME' || declared === 'TIMESTAMP') {
+      type = 'datetime';
+    } else if (declared.includes('BOOL')) {
+      type = 'boolean';
+    } else if (
+      declared.includes('INT') ||
+      declared.includes('REAL') ||
+      declared.includes('FLOA') ||
+      declared.includes('DOUB') ||
+      declared.includes('NUMERIC') ||
+      declared.includes('DECIMAL')
+    ) {
+      type = 'number';
+    }
+    return { ...col, raqbFieldType: type };
+  });
+}
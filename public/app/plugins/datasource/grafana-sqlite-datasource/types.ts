@@ -0,0 +1,19 @@
+import { SQLOptions, SQLQuery } from '@grafana/sql';
+
+export interface SQLiteFile {
+  path: string;
+  table: string;
+}
+
+export interface SQLiteOptions extends SQLOptions {
+  path?: string;
+  files?: SQLiteFile[];
+}
+
+export interface SQLiteQuery extends SQLQuery {}
+
+export interface SQLiteColumn {
+  name: string;
+  type: string;
+  nullable: boolean;
+}
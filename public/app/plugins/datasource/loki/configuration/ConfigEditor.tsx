@@ -35,6 +35,8 @@ const makeJsonUpdater =
 const setMaxLines = makeJsonUpdater('maxLines');
 const setPredefinedOperations = makeJsonUpdater('predefinedOperations');
 const setDerivedFields = makeJsonUpdater('derivedFields');
+const setQuerySplitDuration = makeJsonUpdater('querySplitDuration');
+const setShardQuerySplitting = makeJsonUpdater('shardQuerySplitting');
 
 export const ConfigEditor = (props: Props) => {
   const { options, onOptionsChange } = props;
@@ -81,6 +83,10 @@ export const ConfigEditor = (props: Props) => {
             onMaxLinedChange={(value) => onOptionsChange(setMaxLines(options, value))}
             predefinedOperations={options.jsonData.predefinedOperations || ''}
             onPredefinedOperationsChange={updatePredefinedOperations}
+            querySplitDuration={options.jsonData.querySplitDuration || ''}
+            onQuerySplitDurationChange={(value) => onOptionsChange(setQuerySplitDuration(options, value))}
+            shardQuerySplitting={options.jsonData.shardQuerySplitting ?? false}
+            onShardQuerySplittingChange={(value) => onOptionsChange(setShardQuerySplitting(options, value))}
           />
           <DerivedFields
             fields={options.jsonData.derivedFields}
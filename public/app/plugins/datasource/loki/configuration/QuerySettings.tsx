@@ -1,18 +1,32 @@
 import React from 'react';
 
+import { isValidGrafanaDuration } from '@grafana/data';
 import { ConfigDescriptionLink, ConfigSubSection } from '@grafana/experimental';
 import { config } from '@grafana/runtime';
-import { Badge, InlineField, InlineFieldRow, Input } from '@grafana/ui';
+import { Badge, InlineField, InlineFieldRow, InlineSwitch, Input } from '@grafana/ui';
 
 type Props = {
   maxLines: string;
   onMaxLinedChange: (value: string) => void;
   predefinedOperations: string;
   onPredefinedOperationsChange: (value: string) => void;
+  querySplitDuration: string;
+  onQuerySplitDurationChange: (value: string) => void;
+  shardQuerySplitting: boolean;
+  onShardQuerySplittingChange: (value: boolean) => void;
 };
 
 export const QuerySettings = (props: Props) => {
-  const { maxLines, onMaxLinedChange, predefinedOperations, onPredefinedOperationsChange } = props;
+  const {
+    maxLines,
+    onMaxLinedChange,
+    predefinedOperations,
+    onPredefinedOperationsChange,
+    querySplitDuration,
+    onQuerySplitDurationChange,
+    shardQuerySplitting,
+    onShardQuerySplittingChange,
+  } = props;
   return (
     <ConfigSubSection
       title="Queries"
@@ -47,6 +61,47 @@ export const QuerySettings = (props: Props) => {
         />
       </InlineField>
 
+      <InlineField
+        label="Query split duration"
+        htmlFor="loki_config_querySplitDuration"
+        labelWidth={22}
+        invalid={!!querySplitDuration && !isValidGrafanaDuration(querySplitDuration)}
+        error="Invalid duration, queries are not split by time"
+        tooltip={
+          <>
+            Set a duration like 1d or 6h. Range queries longer than this duration are split into queries of time chunks
+            that are run concurrently, also when alert rules are evaluated. Leave empty to query the whole range at
+            once.
+          </>
+        }
+      >
+        <Input
+          id="loki_config_querySplitDuration"
+          value={querySplitDuration}
+          onChange={(event: React.FormEvent<HTMLInputElement>) => onQuerySplitDurationChange(event.currentTarget.value)}
+          width={16}
+          placeholder="1d"
+          spellCheck={false}
+        />
+      </InlineField>
+
+      <InlineField
+        label="Split by stream shards"
+        htmlFor="loki_config_shardQuerySplitting"
+        labelWidth={22}
+        tooltip={
+          <>Split log queries by the stream shards of Loki too. Enable it if Loki shards your streams automatically.</>
+        }
+      >
+        <InlineSwitch
+          id="loki_config_shardQuerySplitting"
+          value={shardQuerySplitting}
+          onChange={(event: React.FormEvent<HTMLInputElement>) =>
+            onShardQuerySplittingChange(event.currentTarget.checked)
+          }
+        />
+      </InlineField>
+
       {config.featureToggles.lokiPredefinedOperations && (
         <InlineFieldRow>
           <InlineField
@@ -39,6 +39,8 @@ export interface LokiOptions extends DataSourceJsonData {
   alertmanager?: string;
   keepCookies?: string[];
   predefinedOperations?: string;
+  querySplitDuration?: string;
+  shardQuerySplitting?: boolean;
 }
 
 export interface LokiStreamResult {
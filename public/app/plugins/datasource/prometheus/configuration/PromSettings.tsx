@@ -161,12 +161,14 @@ export const PromSettings = (props: Props) => {
     timeInterval: string;
     queryTimeout: string;
     incrementalQueryOverlapWindow: string;
+    rangeQuerySplitDuration: string;
   };
 
   const [validDuration, updateValidDuration] = useState<ValidDuration>({
     timeInterval: '',
     queryTimeout: '',
     incrementalQueryOverlapWindow: '',
+    rangeQuerySplitDuration: '',
   });
 
   return (
@@ -431,7 +433,66 @@ export const PromSettings = (props: Props) => {
           </div>
 
           <div className="gf-form-inline">
-            {options.jsonData.incrementalQuerying && (
+            <div className="gf-form max-width-30">
+              <InlineField
+                label="Range query split duration"
+                labelWidth={PROM_CONFIG_LABEL_WIDTH}
+                tooltip={
+                  <>
+                    Set a duration like 1d or 6h. Range queries longer than this duration are split into aligned
+                    sub-ranges that are queried concurrently. Leave empty to query the whole range at once.
+                  </>
+                }
+                interactive={true}
+                disabled={options.readOnly}
+              >
+                <>
+                  <Input
+                    onBlur={(e) =>
+                      updateValidDuration({
+                        ...validDuration,
+                        rangeQuerySplitDuration: e.currentTarget.value,
+                      })
+                    }
+                    className="width-20"
+                    value={options.jsonData.rangeQuerySplitDuration}
+                    onChange={onChangeHandler('rangeQuerySplitDuration', options, onOptionsChange)}
+                    spellCheck={false}
+                    placeholder="1d"
+                  />
+                  {validateInput(validDuration.rangeQuerySplitDuration, DURATION_REGEX, durationError)}
+                </>
+              </InlineField>
+            </div>
+          </div>
+
+          {options.jsonData.rangeQuerySplitDuration && (
+            <div className="gf-form-inline">
+              <div className="gf-form max-width-30">
+                <InlineField
+                  label="Cache split range queries (beta)"
+                  labelWidth={PROM_CONFIG_LABEL_WIDTH}
+                  tooltip={
+                    <>
+                      Cache the sub-ranges of split range queries that are older than the query overlap window, so
+                      that a refresh of a dashboard only queries the newest sub-ranges.
+                    </>
+                  }
+                  interactive={true}
+                  className={styles.switchField}
+                  disabled={options.readOnly}
+                >
+                  <Switch
+                    value={options.jsonData.cacheRangeQuerySegments ?? false}
+                    onChange={onUpdateDatasourceJsonDataOptionChecked(props, 'cacheRangeQuerySegments')}
+                  />
+                </InlineField>
+              </div>
+            </div>
+          )}
+
+          <div className="gf-form-inline">
+            {(options.jsonData.incrementalQuerying || options.jsonData.cacheRangeQuerySegments) && (
               <InlineField
                 label="Query overlap window"
                 labelWidth={PROM_CONFIG_LABEL_WIDTH}
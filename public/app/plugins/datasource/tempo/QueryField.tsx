@@ -22,6 +22,7 @@ import { ServiceGraphSection } from './ServiceGraphSection';
 import { LokiQuery } from './_importedDependencies/datasources/loki/types';
 import { TempoQueryType } from './dataquery.gen';
 import { TempoDatasource } from './datasource';
+import { MetricsQueryEditor } from './traceql/MetricsQueryEditor';
 import { QueryEditor } from './traceql/QueryEditor';
 import { TempoQuery } from './types';
 
@@ -87,6 +88,7 @@ class TempoQueryFieldComponent extends React.PureComponent<Props, State> {
     let queryTypeOptions: Array<SelectableValue<TempoQueryType>> = [
       { value: 'traceqlSearch', label: 'Search' },
       { value: 'traceql', label: 'TraceQL' },
+      { value: 'traceqlMetrics', label: 'TraceQL Metrics' },
       { value: 'serviceMap', label: 'Service Graph' },
     ];
 
@@ -209,6 +211,14 @@ class TempoQueryFieldComponent extends React.PureComponent<Props, State> {
             onClearResults={this.onClearResults}
           />
         )}
+        {query.queryType === 'traceqlMetrics' && (
+          <MetricsQueryEditor
+            datasource={this.props.datasource}
+            query={query}
+            onRunQuery={this.props.onRunQuery}
+            onChange={onChange}
+          />
+        )}
       </>
     );
   }
@@ -154,6 +154,29 @@ describe('Tempo data source', () => {
     });
   });
 
+  it('runs TraceQL metrics queries in the backend', async () => {
+    setupBackendSrv(
+      createDataFrame({
+        refId: 'refid1',
+        fields: [
+          { name: 'Time', type: FieldType.time, values: [1700000000000] },
+          { name: 'Value', type: FieldType.number, values: [0.5] },
+        ],
+      })
+    );
+    const templateSrv = { replace: jest.fn((value) => value) } as unknown as TemplateSrv;
+    const ds = new TempoDatasource(defaultSettings, templateSrv);
+    const response = await lastValueFrom(
+      ds.query({
+        targets: [{ refId: 'refid1', queryType: 'traceqlMetrics', query: '{ status = error } | rate()', filters: [] }],
+        range: getDefaultTimeRange(),
+      } as unknown as DataQueryRequest<TempoQuery>)
+    );
+
+    expect(response.data).toHaveLength(1);
+    expect((response.data[0] as DataFrame).fields[1].values).toEqual([0.5]);
+  });
+
   it('parses json fields from backend', async () => {
     setupBackendSrv(
       createDataFrame({
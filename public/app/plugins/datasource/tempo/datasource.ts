@@ -445,6 +445,16 @@ export class TempoDatasource extends DataSourceWithBackend<TempoQuery, TempoJson
       }
     }
 
+    if (targets.traceqlMetrics?.length) {
+      reportInteraction('grafana_traces_traceql_metrics_queried', {
+        datasourceType: 'tempo',
+        app: options.app ?? '',
+        grafana_version: config.buildInfo.version,
+      });
+
+      subQueries.push(this.handleTraceQLMetricsQuery(options, targets.traceqlMetrics));
+    }
+
     if (targets.upload?.length) {
       if (this.uploadedJson) {
         reportInteraction('grafana_traces_json_file_uploaded', {
@@ -637,6 +647,20 @@ export class TempoDatasource extends DataSourceWithBackend<TempoQuery, TempoJson
     );
   }
 
+  /**
+   * Runs TraceQL metrics queries in the backend, which returns a time series for each series of the result.
+   * @param options
+   * @param targets
+   * @private
+   */
+  handleTraceQLMetricsQuery(options: DataQueryRequest<TempoQuery>, targets: TempoQuery[]): Observable<DataQueryResponse> {
+    const validTargets = targets.filter((t) => t.query?.trim());
+    if (!validTargets.length) {
+      return EMPTY;
+    }
+    return super.query({ ...options, targets: validTargets });
+  }
+
   traceIdQueryRequest(options: DataQueryRequest<TempoQuery>, targets: TempoQuery[]): DataQueryRequest<TempoQuery> {
     const request = {
       ...options,
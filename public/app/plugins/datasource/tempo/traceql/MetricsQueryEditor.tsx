@@ -0,0 +1,79 @@
+import { css } from '@emotion/css';
+import React, { useRef } from 'react';
+
+import { GrafanaTheme2, isValidDuration, QueryEditorProps } from '@grafana/data';
+import { EditorField, EditorRow } from '@grafana/experimental';
+import { AutoSizeInput, InlineLabel, useStyles2 } from '@grafana/ui';
+
+import { QueryOptionGroup } from '../_importedDependencies/datasources/prometheus/QueryOptionGroup';
+import { TempoDatasource } from '../datasource';
+import { MyDataSourceOptions, TempoQuery } from '../types';
+
+import { TraceQLEditor } from './TraceQLEditor';
+
+type Props = QueryEditorProps<TempoDatasource, TempoQuery, MyDataSourceOptions>;
+
+/**
+ * Editor of TraceQL metrics queries, which compute time series from the spans, for example the rate of errors.
+ * The queries run in the backend, so they can be used in alert rules.
+ */
+export function MetricsQueryEditor(props: Props) {
+  const styles = useStyles2(getStyles);
+  const { query } = props;
+
+  // See QueryEditor: the Monaco editor keeps the first version of the onChange callback.
+  const queryRef = useRef(query);
+  queryRef.current = query;
+  const onEditorChange = (value: string) => {
+    props.onChange({ ...queryRef.current, query: value });
+  };
+
+  const onStepChange = (e: React.FormEvent<HTMLInputElement>) => {
+    props.onChange({ ...query, step: e.currentTarget.value.trim() || undefined });
+  };
+  const invalidStep = !!query.step && !isValidDuration(query.step);
+
+  return (
+    <>
+      <InlineLabel>
+        Compute time series from spans using TraceQL metrics, for example{' '}
+        <code>{'{ status = error } | rate() by (resource.service.name)'}</code>.{' '}
+        <a rel="noreferrer" target="_blank" href="https://grafana.com/docs/tempo/latest/traceql/metrics-queries/">
+          Documentation
+        </a>
+      </InlineLabel>
+      <TraceQLEditor
+        placeholder="Enter a TraceQL metrics query (run with Shift+Enter)"
+        value={query.query || ''}
+        onChange={onEditorChange}
+        datasource={props.datasource}
+        onRunQuery={props.onRunQuery}
+      />
+      <div className={styles.optionsContainer}>
+        <EditorRow>
+          <QueryOptionGroup title="Options" collapsedInfo={[`Step: ${query.step || 'auto'}`]}>
+            <EditorField
+              label="Step"
+              tooltip="Step of the series, for example 30s or 1m. Defaults to the interval of the query."
+              invalid={invalidStep}
+              error="Invalid duration"
+            >
+              <AutoSizeInput
+                className="width-6"
+                placeholder="auto"
+                defaultValue={query.step}
+                onCommitChange={onStepChange}
+              />
+            </EditorField>
+          </QueryOptionGroup>
+        </EditorRow>
+      </div>
+    </>
+  );
+}
+
+const getStyles = (theme: GrafanaTheme2) => ({
+  optionsContainer: css({
+    marginTop: '10px',
+  }),
+});